@@ -18,6 +18,6 @@ echo "[INFO] Fetching dependencies..."
 go get github.com/gorilla/websocket
 
 # Run the Go script
-echo "[INFO] Running simulate_sender.go..."
+echo "[INFO] Running simulator..."
 cd ./cmd/csvserver
-go run simulate_sender.go
\ No newline at end of file
+go run . "$@"
\ No newline at end of file
@@ -0,0 +1,149 @@
+// recording.go
+//
+// Run recording and replay for the simulator. A recording is a JSON lines file:
+// the first line is a header with every nondeterministic input of the run (seed,
+// flags, wall-clock start time), every following line is one sent message with
+// its offset from the start of the run. Replaying a recording sends the exact
+// same messages with the same relative timing, so a bug seen in a simulated run
+// can be reproduced in CI.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// runHeader is the first line of a recording.
+type runHeader struct {
+	Start time.Time         `json:"start"`
+	Mode  string            `json:"mode"`
+	Seed  int64             `json:"seed"`
+	Args  []string          `json:"args"`
+	Flags map[string]string `json:"flags"`
+}
+
+// runEntry is a single recorded message.
+type runEntry struct {
+	OffsetNs int64  `json:"offset_ns"`
+	Data     string `json:"data"`
+}
+
+// runRecorder appends sent messages to a recording file.
+type runRecorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	w     *bufio.Writer
+	enc   *json.Encoder
+	start time.Time
+}
+
+// newRunRecorder creates the recording file and writes its header.
+func newRunRecorder(path, mode string, seed int64) (*runRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	r := &runRecorder{file: f, w: w, enc: json.NewEncoder(w), start: time.Now()}
+
+	flags := make(map[string]string)
+	flag.VisitAll(func(fl *flag.Flag) { flags[fl.Name] = fl.Value.String() })
+	header := runHeader{Start: r.start, Mode: mode, Seed: seed, Args: os.Args, Flags: flags}
+	if err := r.enc.Encode(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// record appends one sent message.
+func (r *runRecorder) record(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := runEntry{OffsetNs: int64(time.Since(r.start)), Data: string(data)}
+	if err := r.enc.Encode(entry); err != nil {
+		log.Printf("Error recording message: %v", err)
+	}
+}
+
+// close flushes and closes the recording.
+func (r *runRecorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.w.Flush(); err != nil {
+		log.Printf("Error flushing recording: %v", err)
+	}
+	r.file.Close()
+}
+
+// sendReplay sends the messages of a recording with their original relative timing.
+func sendReplay(conn *safeConn, path string, done chan struct{}) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("Error opening recording: %v", err)
+		closeDone(done)
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	// Header
+	if !scanner.Scan() {
+		log.Printf("Recording %s is empty", path)
+		closeDone(done)
+		return
+	}
+	var header runHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		log.Printf("Error reading recording header: %v", err)
+		closeDone(done)
+		return
+	}
+	log.Printf("Replaying %s run recorded at %s (seed %d)", header.Mode, header.Start.Format(time.RFC3339), header.Seed)
+
+	start := time.Now()
+	count := 0
+	for scanner.Scan() {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		var entry runEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("Skipping malformed recording entry: %v", err)
+			continue
+		}
+		if wait := time.Duration(entry.OffsetNs) - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+		}
+		if err := conn.writeMessage(websocket.TextMessage, []byte(entry.Data)); err != nil {
+			log.Printf("Error sending replayed message: %v", err)
+			closeDone(done)
+			return
+		}
+		count++
+		if *maxCount > 0 && count >= *maxCount {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Error reading recording: %v", err)
+	}
+
+	log.Printf("Replayed %d messages", count)
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, fmt.Sprintf("Replay of %s complete", path))
+	_ = conn.writeMessage(websocket.CloseMessage, closeMsg)
+	closeDone(done)
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
@@ -22,7 +24,6 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-var seq uint64 = 0
 var oldTime float64 = 0.0
 
 // Command line flags for easier configuration
@@ -34,19 +35,30 @@ var (
 	startLine  = flag.Int("startline", 960000, "Line number to start sending from")
 	timeAdjust = flag.Float64("timeadjust", 0.000415, "Time adjustment factor (seconds)")
 	liveDelay  = flag.Float64("livedelay", 3, "Delay between messages in live mode (milliseconds)")
+	seed       = flag.Int64("seed", 0, "Seed for randomised live values (0 = sequential values)")
+	maxCount   = flag.Int("count", 0, "Stop after sending this many messages (0 = unlimited)")
+	recordFile = flag.String("record", "", "Record the run (seed, flags, send timestamps, messages) to this file")
+	replayFile = flag.String("replay", "", "Replay a recorded run instead of generating data")
 )
 
 // safeConn is a thread-safe connection wrapper.
 type safeConn struct {
-	conn  *websocket.Conn
-	mutex sync.Mutex
+	conn     *websocket.Conn
+	mutex    sync.Mutex
+	recorder *runRecorder // Optional; records every data message sent
 }
 
 // writeMessage safely writes a message to the websocket connection.
 func (s *safeConn) writeMessage(messageType int, data []byte) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	return s.conn.WriteMessage(messageType, data)
+	if err := s.conn.WriteMessage(messageType, data); err != nil {
+		return err
+	}
+	if s.recorder != nil && messageType == websocket.TextMessage {
+		s.recorder.record(data)
+	}
+	return nil
 }
 
 // close safely closes the websocket connection.
@@ -80,8 +92,13 @@ func main() {
 	telemetryURL := fmt.Sprintf("ws://%s:%d/telemetry", cfg.WebSocket.IP, cfg.WebSocket.Port)
 	log.Printf("Simulated data sender connecting to %s in mode: %s", telemetryURL, cfg.Mode)
 
-	// Dial the receiver's telemetry WebSocket endpoint.
-	conn, _, err := websocket.DefaultDialer.Dial(telemetryURL, nil)
+	// Dial the receiver's telemetry WebSocket endpoint, authenticating when a
+	// token is configured.
+	header := http.Header{}
+	if cfg.WebSocket.Token != "" {
+		header.Set("Authorization", "Bearer "+cfg.WebSocket.Token)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(telemetryURL, header)
 	if err != nil {
 		log.Fatalf("Dial error: %v", err)
 	}
@@ -89,6 +106,17 @@ func main() {
 	// Create thread-safe connection wrapper
 	safeConnection := &safeConn{conn: conn}
 
+	// Record the run so it can be reproduced exactly
+	if *recordFile != "" {
+		rec, err := newRunRecorder(*recordFile, cfg.Mode, *seed)
+		if err != nil {
+			log.Fatalf("Error creating run recording: %v", err)
+		}
+		defer rec.close()
+		safeConnection.recorder = rec
+		log.Printf("Recording run to %s", *recordFile)
+	}
+
 	// Create a done channel for signaling termination
 	done := make(chan struct{})
 
@@ -112,11 +140,13 @@ func main() {
 	}()
 
 	// Stream data based on the configured mode.
-	switch cfg.Mode {
-	case "csv":
+	switch {
+	case *replayFile != "":
+		go sendReplay(safeConnection, *replayFile, done)
+	case cfg.Mode == "csv":
 		go sendCSV(safeConnection, *csvFile, *timeAdjust, *startLine, done)
-	case "live":
-		go sendLive(safeConnection, cfg, *liveDelay, done)
+	case cfg.Mode == "live":
+		go sendLive(safeConnection, cfg, *liveDelay, *seed, done)
 	default:
 		log.Fatalf("Invalid mode in configuration")
 	}
@@ -193,6 +223,10 @@ func sendCSV(conn *safeConn, filePath string, timeAdjust float64, startLine int,
 			closeDone(done)
 			return
 		}
+
+		if *maxCount > 0 && lineCount-startLine+1 >= *maxCount {
+			break
+		}
 	}
 
 	// Check for scanner errors.
@@ -209,7 +243,8 @@ func sendCSV(conn *safeConn, filePath string, timeAdjust float64, startLine int,
 }
 
 // sendLive sends simulated live CAN packets over the WebSocket connection.
-func sendLive(conn *safeConn, cfg *config.Config, delay float64, done chan struct{}) {
+// A non-zero seed selects randomised values that are reproducible for that seed.
+func sendLive(conn *safeConn, cfg *config.Config, delay float64, seed int64, done chan struct{}) {
 	// Load JSON definitions.
 	messages, _, err := candecoder.LoadJSONDefinitions(cfg.JSONFile)
 	if err != nil {
@@ -223,6 +258,11 @@ func sendLive(conn *safeConn, cfg *config.Config, delay float64, done chan struc
 		defer ticker.Stop()
 	}
 
+	gen := newValueGenerator(seed)
+	if seed != 0 {
+		log.Printf("Generating randomised values with seed %d", seed)
+	}
+
 	// Round-robin loop over all message definitions.
 	i := 0
 	msgCount := 0
@@ -238,7 +278,7 @@ func sendLive(conn *safeConn, cfg *config.Config, delay float64, done chan struc
 		}
 
 		msgDef := messages[i]
-		packet := generateValidCANPacket(msgDef)
+		packet := gen.generateValidCANPacket(msgDef)
 		packetStr := byteSliceToHexString(packet)
 
 		// Use thread-safe method to write message.
@@ -253,22 +293,59 @@ func sendLive(conn *safeConn, cfg *config.Config, delay float64, done chan struc
 		if msgCount%1000 == 0 {
 			log.Printf("Sent %d CAN packets", msgCount)
 		}
+		if *maxCount > 0 && msgCount >= *maxCount {
+			log.Printf("Sent %d CAN packets, stopping", msgCount)
+			closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Message count reached")
+			_ = conn.writeMessage(websocket.CloseMessage, closeMsg)
+			closeDone(done)
+			return
+		}
 	}
 }
 
-// generateValidCANPacket creates a CAN packet with sequential values.
-func generateValidCANPacket(msg types.Message) []byte {
-	data := make([]byte, msg.Length)
-	for _, signal := range msg.Signals {
-		var physValue float64
-		if strings.HasPrefix(strings.ToLower(signal.Name), "cell") {
+// valueGenerator produces signal values for simulated packets. Without a seed it
+// sweeps values sequentially; with a seed it draws them from a seeded PRNG, so
+// the same seed always yields the same packet stream.
+type valueGenerator struct {
+	seq uint64
+	rng *rand.Rand
+}
+
+func newValueGenerator(seed int64) *valueGenerator {
+	g := &valueGenerator{}
+	if seed != 0 {
+		g.rng = rand.New(rand.NewSource(seed))
+	}
+	return g
+}
+
+// next returns the next physical value for the named signal.
+func (g *valueGenerator) next(signalName string) float64 {
+	isCell := strings.HasPrefix(strings.ToLower(signalName), "cell")
+	if g.rng != nil {
+		if isCell {
 			// For cell signals: values in [0, 4)
-			physValue = float64(seq%4000) / 1000.0
-		} else {
-			// For other signals: values in [-10, 10)
-			physValue = (float64(int(seq%2000) - 1000)) / 100.0
+			return g.rng.Float64() * 4
 		}
-		seq++ // Increment global sequence counter
+		// For other signals: values in [-10, 10)
+		return g.rng.Float64()*20 - 10
+	}
+
+	var v float64
+	if isCell {
+		v = float64(g.seq%4000) / 1000.0
+	} else {
+		v = (float64(int(g.seq%2000) - 1000)) / 100.0
+	}
+	g.seq++ // Increment sequence counter
+	return v
+}
+
+// generateValidCANPacket creates a CAN packet with generated values.
+func (g *valueGenerator) generateValidCANPacket(msg types.Message) []byte {
+	data := make([]byte, msg.Length)
+	for _, signal := range msg.Signals {
+		physValue := g.next(signal.Name)
 
 		var rawValue uint64
 		if signal.IsFloat {
@@ -0,0 +1,78 @@
+// main.go
+//
+// deflint checks CAN definitions files with the rules the server applies when
+// loading them, so generated definitions can be validated before they reach the
+// car's gateway or the server. Every problem is printed with its level; the exit
+// status is 1 when a file fails at the selected strictness.
+//
+//	go run ./cmd/deflint [-strict] can_definitions.json...
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/types"
+)
+
+var strict = flag.Bool("strict", false, "Fail on warnings as well as errors")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: deflint [-strict] definitions.json...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	strictness := candecoder.StrictnessLenient
+	if *strict {
+		strictness = candecoder.StrictnessStrict
+	}
+	failed := false
+	for _, path := range flag.Args() {
+		ok, err := lintFile(path, strictness)
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+		}
+		if !ok {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// lintFile prints the problems of a definitions file and reports whether it
+// passes.
+func lintFile(path, strictness string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	var messages []types.Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return false, fmt.Errorf("invalid JSON: %w", err)
+	}
+	report, err := candecoder.Lint(messages, strictness)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range report.Problems {
+		fmt.Printf("%s: %s\n", path, p)
+	}
+	status := "ok"
+	if !report.Valid {
+		status = "FAILED"
+	}
+	fmt.Printf("%s: %d messages, %d errors, %d warnings (%s): %s\n",
+		path, report.Messages, report.Errors, report.Warnings, strictness, status)
+	return report.Valid, nil
+}
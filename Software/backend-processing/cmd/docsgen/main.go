@@ -0,0 +1,84 @@
+// main.go
+//
+// docsgen renders the telemetry reference (derived and live channels, database
+// tables and API routes) into reference.md and reference.html, which the server
+// embeds and serves at /api/docs with the CAN definitions it loaded filled in.
+// Routes are read from the router the server builds, so the reference cannot
+// drift from the code. Run it through `go generate ./internal/docs` or
+// `make docs`.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"telem-system/internal/docs"
+	"telem-system/internal/handlers"
+	"telem-system/pkg/db"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	outDir = flag.String("out", "internal/docs", "Directory the reference files are written to")
+)
+
+// Closure and method value suffixes of handler function names
+var funcSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+func main() {
+	flag.Parse()
+
+	// Routes only capture the queries; nothing is run against the database
+	r := chi.NewRouter()
+	handlers.RegisterRoutes(r, db.New(nil))
+	var routes []docs.Route
+	err := chi.Walk(r, func(method, route string, h http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, docs.Route{Method: method, Pattern: route, Handler: handlerName(h)})
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list routes: %v", err)
+	}
+
+	ref := docs.Build(routes)
+	page, err := ref.RenderHTML()
+	if err != nil {
+		log.Fatalf("Failed to render HTML: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, "reference.md"), ref.RenderMarkdown(), 0o644); err != nil {
+		log.Fatalf("Failed to write reference: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, "reference.html"), page, 0o644); err != nil {
+		log.Fatalf("Failed to write reference: %v", err)
+	}
+	log.Printf("Wrote reference: %d tables, %d routes", len(ref.TelemetryTables)+len(ref.ServerTables), len(ref.Routes))
+}
+
+// handlerName returns the name of the Go function serving a route, without its
+// package path.
+func handlerName(h http.Handler) string {
+	v := reflect.ValueOf(h)
+	if hf, ok := h.(http.HandlerFunc); ok {
+		v = reflect.ValueOf(hf)
+	}
+	if v.Kind() != reflect.Func {
+		return v.Type().String()
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimPrefix(name, "handlers.")
+	return funcSuffix.ReplaceAllString(name, "")
+}
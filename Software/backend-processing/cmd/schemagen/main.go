@@ -0,0 +1,36 @@
+// main.go
+//
+// schemagen prints the database schema (telemetry tables, server-owned tables and
+// views) for the storage codecs in the given configuration. The output can be
+// applied with psql to set up a fresh database or reviewed before migrating one.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"telem-system/internal/config"
+	"telem-system/pkg/db"
+)
+
+var (
+	configPath = flag.String("config", "../../configs/", "Path to config directory")
+	configName = flag.String("configname", "config", "Name of config file without extension")
+	configType = flag.String("configtype", "yaml", "Config file type (yaml, json, etc)")
+)
+
+func main() {
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath, *configName, *configType)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := db.SetColumnCodecs(cfg.ColumnCodecs()); err != nil {
+		log.Fatalf("Invalid storage configuration: %v", err)
+	}
+
+	for _, stmt := range db.SchemaStatements() {
+		fmt.Printf("%s;\n\n", stmt)
+	}
+}
@@ -0,0 +1,53 @@
+// limits.go
+//
+// Inbound limits for the raw telemetry WebSocket. The transmitter sends one CAN
+// frame per message, so the defaults sit well above the frame rate of a saturated
+// 1 Mbit/s bus while still stopping a runaway or hostile client.
+package main
+
+import (
+	"errors"
+	"telem-system/internal/config"
+	"telem-system/internal/wsserver"
+)
+
+const (
+	defaultTelemetryRate  = 10000 // Messages per second
+	defaultTelemetryBurst = 20000
+
+	// Longest valid message: a CSV row or a space-separated live packet
+	maxTelemetryMessage = 1024
+)
+
+// errNotPrintable is returned for telemetry messages containing binary data.
+var errNotPrintable = errors.New("message contains non-printable characters")
+
+// telemetryPolicy returns the inbound policy of the /telemetry endpoint.
+func telemetryPolicy(cfg *config.Config) wsserver.Policy {
+	p := wsserver.Policy{
+		Endpoint:      "telemetry",
+		Rate:          defaultTelemetryRate,
+		Burst:         defaultTelemetryBurst,
+		MaxViolations: cfg.WebSocket.RateLimit.MaxViolations,
+		MaxMessage:    maxTelemetryMessage,
+		Validate:      validateTelemetryMessage,
+	}
+	if cfg.WebSocket.RateLimit.TelemetryRate > 0 {
+		p.Rate = cfg.WebSocket.RateLimit.TelemetryRate
+	}
+	if cfg.WebSocket.RateLimit.TelemetryBurst > 0 {
+		p.Burst = cfg.WebSocket.RateLimit.TelemetryBurst
+	}
+	return p
+}
+
+// validateTelemetryMessage accepts only printable ASCII text, which covers both the
+// CSV rows and the live packet format.
+func validateTelemetryMessage(messageType int, data []byte) error {
+	for _, b := range data {
+		if (b < 0x20 || b > 0x7E) && b != '\t' && b != '\r' && b != '\n' {
+			return errNotPrintable
+		}
+	}
+	return nil
+}
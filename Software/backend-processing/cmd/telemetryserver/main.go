@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,14 +15,38 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"telem-system/internal/auth"
 	"telem-system/internal/config"
+	"telem-system/internal/docs"
 	"telem-system/internal/handlers"
 	"telem-system/internal/wsserver"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/backup"
 	"telem-system/pkg/candecoder"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/competition"
 	"telem-system/pkg/db"
+	"telem-system/pkg/exportcache"
+	"telem-system/pkg/features"
+	"telem-system/pkg/federation"
+	"telem-system/pkg/laps"
+	"telem-system/pkg/livechannels"
+	"telem-system/pkg/maintenance"
+	"telem-system/pkg/packhealth"
+	"telem-system/pkg/precharge"
 	"telem-system/pkg/processdata"
+	"telem-system/pkg/profiles"
+	"telem-system/pkg/readiness"
+	"telem-system/pkg/resistance"
+	"telem-system/pkg/scheduler"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/shutdown"
+	"telem-system/pkg/tccapture"
 	"telem-system/pkg/types"
+	"telem-system/pkg/vehiclestate"
+	"telem-system/pkg/webhooks"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -52,6 +77,9 @@ var (
 	}
 	// Map to track cell data entries
 	cellDataBuffers = make(map[float64]*types.Cell_Data)
+
+	// Jobs submitted to the worker pool and not yet processed
+	pendingJobs atomic.Int64
 )
 
 // Define a job structure for worker pool
@@ -114,6 +142,11 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 	}
 	defer conn.Close()
 
+	// Drop floods and malformed messages; disconnect clients that keep sending them
+	policy := telemetryPolicy(cfg)
+	conn.SetReadLimit(policy.MaxMessage)
+	limiter := wsserver.NewClientLimiter(policy)
+
 	// Process incoming messages based on the mode.
 	if cfg.Mode == "csv" {
 		// Reuse buffer and CSV reader for efficiency
@@ -121,10 +154,20 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 		csvReader := csv.NewReader(&buffer)
 
 		for {
-			_, msg, err := conn.ReadMessage()
+			messageType, msg, err := conn.ReadMessage()
 			if err != nil {
 				return
 			}
+			if abusive, err := limiter.Check(messageType, msg); err != nil {
+				if abusive {
+					limiter.Disconnect(conn, err)
+					return
+				}
+				continue
+			}
+			if !processdata.SourceEnabled(processdata.SourceWebSocket) || !maintenance.AcceptFrame() {
+				continue
+			}
 
 			buffer.Reset()
 			buffer.Write(msg)
@@ -136,10 +179,12 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 			if len(record) < 3 {
 				continue
 			}
-			frameID, err := strconv.Atoi(record[2])
+			observedID, err := strconv.Atoi(record[2])
 			if err != nil {
 				continue
 			}
+			// Translate IDs from mismatched firmware to their canonical definition
+			frameID := int(candecoder.RemapFrameID(uint32(observedID)))
 			msgDef, exists := messageMap[uint32(frameID)]
 			if !exists {
 				continue
@@ -148,6 +193,10 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 			if len(record) < 5+dataLen {
 				continue
 			}
+
+			// Count the frame towards bus load (as seen on the wire) before any decoding
+			processdata.RecordFrame(uint32(observedID), dataLen, msgDef.IsExtendedFrame)
+			sessions.NoteActivity(time.Now())
 			dataFields := record[5 : 5+dataLen]
 
 			// Get byte slice from pool
@@ -166,17 +215,29 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 				dataBytes[i] = byte(b)
 			}
 
+			// Full-resolution traction-control capture, ahead of any dropping or throttling
+			tccapture.CaptureFrame(uint32(frameID), dataBytes, msgDef, time.Now())
+
+			// High-rate IMU frames are stored in blocks and broadcast decimated
+			if processdata.IngestIMUFrame(uint32(frameID), dataBytes, msgDef, time.Now()) {
+				dataBytePool.Put(dataBytePtr) // Return to pool
+				continue
+			}
+
 			// Decode directly instead of using worker pool for special frame IDs
 			if frameID >= 50 && frameID <= 57 {
 				// Process cell data frames immediately for lowest latency
 				decoded, err := candecoder.DecodeMessage(dataBytes, msgDef)
+				processdata.NoteDecodeResult(msgDef, decoded, err)
 				if err == nil {
+					processdata.DropInvalidSignals(msgDef, decoded)
 					processCellData(uint32(frameID), decoded, msgDef, "csv")
 				}
 				dataBytePool.Put(dataBytePtr) // Return to pool
 			} else {
 				// Send other frames to worker pool
 				// Use non-blocking send to prevent backpressure
+				pendingJobs.Add(1)
 				select {
 				case jobChan <- dataJob{
 					frameID:   uint32(frameID),
@@ -188,6 +249,7 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 					// Job submitted successfully
 				default:
 					// Channel is full, discard job and return bytes to pool
+					pendingJobs.Add(-1)
 					dataBytePool.Put(dataBytePtr)
 					// Could increment a metrics counter here
 				}
@@ -195,10 +257,20 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 		}
 	} else if cfg.Mode == "live" {
 		for {
-			_, msg, err := conn.ReadMessage()
+			messageType, msg, err := conn.ReadMessage()
 			if err != nil {
 				return
 			}
+			if abusive, err := limiter.Check(messageType, msg); err != nil {
+				if abusive {
+					limiter.Disconnect(conn, err)
+					return
+				}
+				continue
+			}
+			if !processdata.SourceEnabled(processdata.SourceWebSocket) || !maintenance.AcceptFrame() {
+				continue
+			}
 
 			// Work directly with bytes instead of converting to string
 			data, err := candecoder.ParseLiveCANPacket(string(msg))
@@ -206,59 +278,91 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 				continue
 			}
 			// First 4 bytes contain the frameID
-			frameID := uint32(data[0])<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
-			msgDef, exists := messageMap[frameID]
-			if !exists {
-				continue
-			}
-			// Pad data if shorter than expected
-			messageData := data[4:]
+			observedID := uint32(data[0])<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+			ingestLiveFrame(observedID, data[4:], messageMap, jobChan)
+		}
+	}
+}
 
-			// Get buffer from pool for messageData
-			dataBytePtr := dataBytePool.Get().(*[]byte)
-			paddedData := (*dataBytePtr)[:msgDef.Length] // Reslice without allocation
-
-			// Copy message data to padded buffer
-			copy(paddedData, messageData)
-			if len(messageData) < msgDef.Length {
-				// Zero out the rest
-				for i := len(messageData); i < msgDef.Length; i++ {
-					paddedData[i] = 0
-				}
-			}
+// ingestLiveFrame runs a single raw frame from a live source (WebSocket or serial
+// adapter) through remapping, bus load accounting, decoding and processing.
+func ingestLiveFrame(observedID uint32, messageData []byte, messageMap map[uint32]types.Message, jobChan chan<- dataJob) {
+	// Translate IDs from mismatched firmware to their canonical definition
+	frameID := candecoder.RemapFrameID(observedID)
+	msgDef, exists := messageMap[frameID]
+
+	// Count every frame on the bus, including IDs we have no definition for
+	extended := observedID > 0x7FF
+	if exists {
+		extended = msgDef.IsExtendedFrame
+	}
+	processdata.RecordFrame(observedID, len(messageData), extended)
+	sessions.NoteActivity(time.Now())
 
-			// Decode directly instead of using worker pool for special frame IDs
-			if frameID >= 50 && frameID <= 57 {
-				// Process cell data frames immediately for lowest latency
-				decoded, err := candecoder.DecodeMessage(paddedData, msgDef)
-				if err == nil {
-					processCellData(frameID, decoded, msgDef, "live")
-				}
-				dataBytePool.Put(dataBytePtr) // Return to pool
-			} else {
-				// Use non-blocking send to prevent backpressure
-				select {
-				case jobChan <- dataJob{
-					frameID:   frameID,
-					data:      *dataBytePtr, // Use directly from pool
-					msgDef:    msgDef,
-					mode:      "live",
-					timestamp: time.Now(),
-				}:
-					// Job submitted successfully
-				default:
-					// Channel is full, discard job and return bytes to pool
-					dataBytePool.Put(dataBytePtr)
-					// Could increment a metrics counter here
-				}
-			}
+	if !exists {
+		return
+	}
+
+	// Get buffer from pool for messageData
+	dataBytePtr := dataBytePool.Get().(*[]byte)
+	paddedData := (*dataBytePtr)[:msgDef.Length] // Reslice without allocation
+
+	// Copy message data to padded buffer, padding if shorter than expected
+	copy(paddedData, messageData)
+	if len(messageData) < msgDef.Length {
+		// Zero out the rest
+		for i := len(messageData); i < msgDef.Length; i++ {
+			paddedData[i] = 0
 		}
 	}
+
+	// Full-resolution traction-control capture, ahead of any dropping or throttling
+	tccapture.CaptureFrame(frameID, paddedData, msgDef, time.Now())
+
+	// High-rate IMU frames are stored in blocks and broadcast decimated
+	if processdata.IngestIMUFrame(frameID, paddedData, msgDef, time.Now()) {
+		dataBytePool.Put(dataBytePtr) // Return to pool
+		return
+	}
+
+	// Decode directly instead of using worker pool for special frame IDs
+	if frameID >= 50 && frameID <= 57 {
+		// Process cell data frames immediately for lowest latency
+		decoded, err := candecoder.DecodeMessage(paddedData, msgDef)
+		processdata.NoteDecodeResult(msgDef, decoded, err)
+		if err == nil {
+			processdata.DropInvalidSignals(msgDef, decoded)
+			processCellData(frameID, decoded, msgDef, "live")
+		}
+		dataBytePool.Put(dataBytePtr) // Return to pool
+		return
+	}
+
+	// Use non-blocking send to prevent backpressure
+	pendingJobs.Add(1)
+	select {
+	case jobChan <- dataJob{
+		frameID:   frameID,
+		data:      *dataBytePtr, // Use directly from pool
+		msgDef:    msgDef,
+		mode:      "live",
+		timestamp: time.Now(),
+	}:
+		// Job submitted successfully
+	default:
+		// Channel is full, discard job and return bytes to pool
+		pendingJobs.Add(-1)
+		dataBytePool.Put(dataBytePtr)
+	}
 }
 
 func main() {
 	start := time.Now()
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	flag.Parse()
+	if *extractDir != "" {
+		extractAssets(*extractDir)
+	}
 
 	// Create a context that will be used to signal shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -271,8 +375,8 @@ func main() {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
-	// Load configuration
-	cfg, err := config.LoadConfig("../../configs/", "config", "yaml")
+	// Load configuration (see portable.go for -config and -portable)
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
@@ -293,12 +397,46 @@ func main() {
 	// Initialize the database query helper
 	queries := db.New(dbConn)
 
+	// Apply storage codecs before the schema is generated or any data is written
+	if err := db.SetColumnCodecs(cfg.ColumnCodecs()); err != nil {
+		log.Fatalf("Invalid storage configuration: %v", err)
+	}
+	processdata.SetNullOutOfRange(cfg.Storage.NullOutOfRange)
+
+	// Feature flags gating risky subsystems; admins toggle them at runtime
+	if err := features.Configure(cfg.FeatureFlags); err != nil {
+		log.Fatalf("Invalid feature flag config: %v", err)
+	}
+
+	// Create tables if missing
+	if err := db.EnsureSchema(ctx); err != nil {
+		log.Fatalf("Failed to ensure database schema: %v", err)
+	}
+
 	// Load CAN definitions
-	messages, messageMap, err := candecoder.LoadJSONDefinitions(cfg.JSONFile)
+	if err := candecoder.SetStrictness(cfg.DefsStrictness); err != nil {
+		log.Fatalf("Invalid definitions strictness: %v", err)
+	}
+	messages, messageMap, err := loadDefinitions(cfg)
 	if err != nil {
 		log.Fatalf("Failed to load JSON definitions: %v", err)
 	}
 	log.Printf("Loaded %d messages", len(messages))
+	docs.Configure(messages)
+
+	// Install frame ID remaps for firmware that sends shifted IDs
+	remap, err := cfg.FrameIDRemap()
+	if err != nil {
+		log.Fatalf("Invalid frame ID remap: %v", err)
+	}
+	if err := candecoder.SetFrameIDRemap(remap); err != nil {
+		log.Fatalf("Invalid frame ID remap: %v", err)
+	}
+	for observed, canonical := range remap {
+		if _, ok := messageMap[canonical]; !ok {
+			log.Printf("Warning: frame ID %d is remapped to %d, which has no definition", observed, canonical)
+		}
+	}
 
 	// Start the WebSocket hub
 	go wsserver.WsHub.Run()
@@ -307,12 +445,258 @@ func main() {
 	batchCtx, batchCancel := context.WithCancel(ctx)
 	defer batchCancel()
 
+	// Throttle rate, cell storage mode, muted alerts, alert rule sets and ingest
+	// sources come from the base settings overlaid with the selected profile
+	if err := alerts.ConfigureRuleSets(cfg.AlertRuleSets()); err != nil {
+		log.Fatalf("Invalid alert rule config: %v", err)
+	}
+	if err := profiles.Configure(cfg.ProfileSettings()); err != nil {
+		log.Fatalf("Invalid profile config: %v", err)
+	}
+	if _, err := profiles.Activate(cfg.Profile); err != nil {
+		log.Fatalf("Failed to activate profile: %v", err)
+	}
+
+	// Steering wheel inputs are stored as state changes
+	processdata.SetDriverInputFrameID(cfg.CANBus.DriverInputFrameID)
+
+	// High-rate IMU frames bypass the worker pool
+	if err := processdata.ConfigureIMU(processdata.IMUConfig{
+		AccelFrameID:    cfg.IMU.AccelFrameID,
+		GyroFrameID:     cfg.IMU.GyroFrameID,
+		BroadcastHz:     cfg.IMU.BroadcastHz,
+		BurstBefore:     time.Duration(cfg.IMU.BurstBeforeMs) * time.Millisecond,
+		BurstAfter:      time.Duration(cfg.IMU.BurstAfterMs) * time.Millisecond,
+		BurstThresholdG: cfg.IMU.BurstThresholdG,
+		BurstOnAlerts:   cfg.IMU.BurstOnAlerts,
+		Retention:       time.Duration(cfg.IMU.RetentionH) * time.Hour,
+	}); err != nil {
+		log.Fatalf("Invalid IMU config: %v", err)
+	}
+	if processdata.IMUEnabled() && processdata.IMURetention() > 0 {
+		if err := scheduler.Register(scheduler.Job{Name: "imu_retention", Schedule: "@hourly", Run: processdata.PruneIMUData}); err != nil {
+			log.Fatalf("Failed to register IMU retention job: %v", err)
+		}
+	}
+
+	// Store and broadcast policy of live channels
+	if err := livechannels.Configure(cfg.LiveChannels.Policies); err != nil {
+		log.Fatalf("Invalid live channel config: %v", err)
+	}
+
 	// Initialize batch processors for different data types
-	processdata.InitBatchProcessors(batchCtx, 35, 250*time.Millisecond) // Batch size and max wait time
+	// Batch size and max wait time; fails if the stored sequence numbers cannot be read
+	if err := processdata.InitBatchProcessors(batchCtx, 35, 250*time.Millisecond); err != nil {
+		log.Fatalf("Failed to initialize batch processors: %v", err)
+	}
+
+	// Start CAN bus load monitoring
+	processdata.InitBusMonitor(batchCtx, cfg.CANBus.Bitrate, cfg.CANBus.StatsIntervalMs)
+
+	// Forward alerts to live clients and watch for data that is not being stored
+	processdata.InitAlertBroadcast()
+	processdata.InitAlertHistory(batchCtx)
+	processdata.RetainFunc = wsserver.WsHub.SetRetained
+	processdata.InitAlertState(batchCtx)
 
-	// Disable throttling for maximum throughput
-	processdata.InitThrottler(cfg.ThrottlerInterval, 0) // Disable throttling
+	// Insert latency SLOs, checked by the persistence monitor
+	slos := make(map[string]processdata.SLO, len(cfg.Persistence.SLOs))
+	for table, s := range cfg.Persistence.SLOs {
+		slos[table] = processdata.SLO{Percentile: s.Percentile, Threshold: time.Duration(s.ThresholdMs) * time.Millisecond}
+	}
+	if err := processdata.ConfigureSLOs(slos, time.Duration(cfg.Persistence.SLOWindowS)*time.Second); err != nil {
+		log.Fatalf("Invalid persistence SLO config: %v", err)
+	}
+	processdata.InitPersistenceMonitor(batchCtx, cfg.Persistence.LagAlertMs, cfg.Persistence.LagCheckIntervalMs)
+
+	// Record pipeline counters for post-event analysis
+	processdata.InitMetricsRecorder(batchCtx, cfg.Metrics.IntervalMs)
+
+	// Open and close sessions automatically from vehicle activity
+	sessions.Configure(cfg.Sessions.Mode != "manual", time.Duration(cfg.Sessions.QuietPeriodS)*time.Second, cfg.Sessions.TSThresholdV)
+	processdata.InitSessionBroadcast()
+	if err := sessions.Init(batchCtx); err != nil {
+		log.Fatalf("Failed to initialize sessions: %v", err)
+	}
+
+	// Outbound webhooks on session, alert and export events
+	if err := webhooks.Configure(cfg.WebhookHooks()); err != nil {
+		log.Fatalf("Invalid webhook config: %v", err)
+	}
+	processdata.InitWebhookEvents()
+	webhooks.Start(batchCtx)
+
+	// Lap detection and per-lap aggregates
+	if err := laps.Configure(laps.Config{
+		GateLat:    cfg.Laps.GateLat,
+		GateLon:    cfg.Laps.GateLon,
+		GateRadius: cfg.Laps.GateRadiusM,
+		MinLapTime: time.Duration(cfg.Laps.MinLapS) * time.Second,
+		Channels:   cfg.Laps.Channels,
+	}, queries); err != nil {
+		log.Fatalf("Invalid lap config: %v", err)
+	}
+	processdata.InitLapBroadcast()
+
+	// Live track map downsampling
+	processdata.ConfigureTrackPosition(cfg.TrackPosition.ToleranceM, cfg.TrackPosition.MaxIntervalMs)
+
+	// Shutdown circuit timeline reconstruction
+	shutdown.Configure(shutdown.Config{
+		ShutdownCurrentMin: cfg.Shutdown.ShutdownCurrentMin,
+		TSALCurrentMin:     cfg.Shutdown.TSALCurrentMin,
+		AMSOKStatus:        cfg.Shutdown.AMSOKStatus,
+	})
+	processdata.InitShutdownTimeline()
+
+	// Precharge sequence analysis
+	precharge.Configure(precharge.Config{
+		StartVoltage:   cfg.Precharge.StartVoltage,
+		TargetFraction: cfg.Precharge.TargetFraction,
+		MinDuration:    time.Duration(cfg.Precharge.MinDurationMs) * time.Millisecond,
+		MaxDuration:    time.Duration(cfg.Precharge.MaxDurationMs) * time.Millisecond,
+		SettleTime:     time.Duration(cfg.Precharge.SettleMs) * time.Millisecond,
+		MaxFinalDelta:  cfg.Precharge.MaxFinalDeltaV,
+	})
+	processdata.InitPrechargeLog(batchCtx)
+
+	// Drive/regen classification of pack current
+	channels.ConfigureEnergy(channels.EnergyConfig{CurrentSign: cfg.Energy.CurrentSign, IdleCurrent: cfg.Energy.IdleCurrentA})
+
+	// Data gaps listed in query responses
+	if err := channels.ConfigureGaps(cfg.GapConfig()); err != nil {
+		log.Fatalf("Invalid gap config: %v", err)
+	}
+
+	// Shunt/hall pack current fusion
+	if err := channels.ConfigureCurrentFusion(channels.CurrentFusionConfig{
+		Policy:    cfg.CurrentFusion.Policy,
+		Tolerance: cfg.CurrentFusion.ToleranceA,
+		MaxAge:    time.Duration(cfg.CurrentFusion.MaxAgeMs) * time.Millisecond,
+	}); err != nil {
+		log.Fatalf("Invalid current fusion config: %v", err)
+	}
+
+	// Accumulator internal resistance estimation
+	resistance.Configure(resistance.Config{
+		MinStep:          cfg.Resistance.MinStepA,
+		MaxStepInterval:  time.Duration(cfg.Resistance.MaxStepIntervalMs) * time.Millisecond,
+		MaxResistance:    cfg.Resistance.MaxOhm,
+		Window:           cfg.Resistance.WindowSteps,
+		MinSteps:         cfg.Resistance.MinSteps,
+		ReportInterval:   time.Duration(cfg.Resistance.ReportIntervalS) * time.Second,
+		SeriesCells:      cfg.Resistance.SeriesCells,
+		CurrentSign:      cfg.Energy.CurrentSign,
+		TrendThreshold:   cfg.Resistance.TrendThreshold,
+		BaselineSessions: cfg.Resistance.BaselineSessions,
+	})
+	processdata.InitResistanceLog(batchCtx)
+
+	// Pack cycle counting and state of health
+	packhealth.Configure(packhealth.Config{
+		PackID:            cfg.Pack.ID,
+		NominalCapacityAh: cfg.Pack.NominalCapacityAh,
+		MinSOCSwing:       cfg.Pack.MinSOCSwing,
+		CurrentSign:       cfg.Energy.CurrentSign,
+	}, queries)
+	processdata.InitPackHealth()
+
+	// Consolidated vehicle state, which also gates alerts per state
+	if err := vehiclestate.Configure(vehiclestate.Config{
+		AMSOKStatus:       cfg.Shutdown.AMSOKStatus,
+		TSOnVoltage:       cfg.VehicleState.TSOnVoltage,
+		PrechargeFraction: cfg.VehicleState.PrechargeFraction,
+		DrivingSpeed:      cfg.VehicleState.DrivingSpeed,
+		ChargeCurrent:     cfg.VehicleState.ChargeCurrentA,
+		CurrentSign:       cfg.Energy.CurrentSign,
+		OffTimeout:        time.Duration(cfg.VehicleState.OffTimeoutMs) * time.Millisecond,
+		Debounce:          time.Duration(cfg.VehicleState.DebounceMs) * time.Millisecond,
+		Suppress:          cfg.VehicleState.Suppress,
+	}); err != nil {
+		log.Fatalf("Invalid vehicle state config: %v", err)
+	}
+	processdata.InitVehicleState(batchCtx)
+
+	// Accumulator segment aggregates
+	if err := channels.ConfigureSegments(cfg.AccumulatorSegments()); err != nil {
+		log.Fatalf("Invalid accumulator config: %v", err)
+	}
+
+	// Live rosbag2 recording for the driverless stack
+	if err := processdata.InitRosbagRecorder(batchCtx, cfg.Rosbag.Dir, cfg.Rosbag.Channels, time.Duration(cfg.Rosbag.RotateMin)*time.Minute); err != nil {
+		log.Fatalf("Invalid rosbag config: %v", err)
+	}
+
+	// Background exports cached on disk, with periodic cleanup
+	if err := exportcache.Configure(exportcache.Config{
+		Dir:           cfg.ExportCache.Dir,
+		TTL:           time.Duration(cfg.ExportCache.TTLH * float64(time.Hour)),
+		MaxBytes:      int64(cfg.ExportCache.MaxGB * (1 << 30)),
+		MaxConcurrent: cfg.ExportCache.MaxConcurrent,
+	}); err != nil {
+		log.Fatalf("Invalid export cache config: %v", err)
+	}
+	if exportcache.Enabled() {
+		if err := scheduler.Register(scheduler.Job{Name: "export_cache_cleanup", Schedule: "@every 10m", Run: exportcache.Cleanup}); err != nil {
+			log.Fatalf("Failed to register export cache cleanup: %v", err)
+		}
+	}
+
+	// End-of-day backup of the database to the backup drive
+	if err := backup.Configure(backup.Config{
+		Dir:             cfg.Backup.Dir,
+		Schedule:        cfg.Backup.Schedule,
+		Keep:            cfg.Backup.Keep,
+		ConnString:      cfg.Database.ConnectionString,
+		PgDump:          cfg.Backup.PgDump,
+		PgRestore:       cfg.Backup.PgRestore,
+		AllowSameDevice: cfg.Backup.AllowSameDevice,
+	}); err != nil {
+		log.Fatalf("Invalid backup config: %v", err)
+	}
+	if backup.Enabled() {
+		if err := scheduler.Register(scheduler.Job{Name: "backup", Schedule: backup.Schedule(), Timeout: 2 * time.Hour, Run: backup.Run}); err != nil {
+			log.Fatalf("Failed to register backup job: %v", err)
+		}
+	}
+
+	// Archived query ranges completed from the cloud mirror
+	if err := federation.Configure(federation.Config{
+		MirrorURL: cfg.Federation.MirrorURL,
+		Token:     cfg.Federation.Token,
+		Timeout:   time.Duration(cfg.Federation.TimeoutS) * time.Second,
+		Retention: time.Duration(cfg.Federation.RetentionH) * time.Hour,
+	}); err != nil {
+		log.Fatalf("Invalid federation config: %v", err)
+	}
+
+	// Traction-control capture mode, toggled through the admin API
+	tccapture.Configure(tccapture.Config{
+		FrameIDs:     cfg.TCCapture.FrameIDs,
+		MaxDuration:  time.Duration(cfg.TCCapture.MaxDurationMin) * time.Minute,
+		BufferFrames: cfg.TCCapture.BufferFrames,
+	})
+	if err := tccapture.Recover(ctx); err != nil {
+		log.Printf("Error closing interrupted TC capture runs: %v", err)
+	}
+
+	// Background jobs; features register their jobs above
+	scheduler.Start(batchCtx)
+
+	// Pre-run readiness requirements
+	readiness.Configure(cfg.ReadinessConfig())
+
+	// Smoothing of noisy live channels
+	if err := processdata.ConfigureLiveFilters(cfg.LiveFilterSpecs()); err != nil {
+		log.Fatalf("Invalid live filter config: %v", err)
+	}
+
+	// Live broadcasts go through the throttler configured by the profile
 	processdata.BroadcastFunc = processdata.ThrottledBroadcast
+	processdata.ConfigureCircuitBreaker(cfg.CircuitBreaker.Threshold, time.Duration(cfg.CircuitBreaker.OpenMs)*time.Millisecond)
+
+	// Live clients can request the latest messages of a channel on demand
+	wsserver.SetSnapshotSource(processdata.Snapshot)
 
 	// Create worker pool for data processing - fixed size for Raspberry Pi
 	numWorkers := 3                     // Using 4 workers as requested
@@ -324,14 +708,18 @@ func main() {
 			for job := range jobChan {
 				// Get job from channel
 				decoded, err := candecoder.DecodeMessage(job.data, job.msgDef)
+				processdata.NoteDecodeResult(job.msgDef, decoded, err)
 				if err != nil {
 					// Return byte slice to pool
 					byteSlice := job.data
 					dataBytePtr := &byteSlice
 					dataBytePool.Put(dataBytePtr)
+					pendingJobs.Add(-1)
 					continue
 				}
 
+				processdata.DropInvalidSignals(job.msgDef, decoded)
+
 				// Process decoded data - handle all except cell data (50-57)
 				// Cell data is processed directly in telemetryHandler
 				if job.frameID < 50 || job.frameID > 57 {
@@ -342,13 +730,39 @@ func main() {
 				byteSlice := job.data
 				dataBytePtr := &byteSlice
 				dataBytePool.Put(dataBytePtr)
+				pendingJobs.Add(-1)
 			}
 		}()
 	}
 
+	// Maintenance quiescing waits for the worker pool to drain
+	maintenance.SetQueueDepth(func() int { return int(pendingJobs.Load()) })
+
+	// Bench testing: read frames straight from a serial CAN adapter
+	if cfg.Serial.Enabled {
+		go runSerialSource(ctx, cfg, messageMap, jobChan)
+	}
+
 	// ---------------------
 	// REST API Server on port cfg.APIPort (e.g., 9092)
 	// ---------------------
+	authSettings, err := cfg.AuthSettings()
+	if err != nil {
+		log.Fatalf("Invalid auth config: %v", err)
+	}
+	authProvider, err := auth.NewProvider(authSettings)
+	if err != nil {
+		log.Fatalf("Failed to initialize auth provider: %v", err)
+	}
+	authProvider = auth.WithShareLinks(authProvider, handlers.ShareLookup)
+	log.Printf("Authentication provider: %s", authProvider.Name())
+
+	requireAuth := auth.Middleware(authProvider)
+	// Car ingest only needs a token in competition mode or with auth.require_ingest
+	requireIngestAuth := auth.MiddlewareWhen(authProvider, func() bool {
+		return cfg.Auth.RequireIngest || competition.Active()
+	})
+
 	apiRouter := chi.NewRouter()
 	apiRouter.Use(middleware.Logger)
 	apiRouter.Use(cors.Handler(cors.Options{
@@ -359,53 +773,85 @@ func main() {
 		AllowCredentials: false,
 		MaxAge:           300, // 5 minutes
 	}))
+	apiRouter.Use(requireAuth)
+	apiRouter.Use(handlers.CompetitionLock)
 
 	// Register additional API endpoints
 	handlers.RegisterRoutes(apiRouter, queries)
 
+	// Every API route runs the auth middleware installed above
+	competition.Route(":"+cfg.APIPort+"/api", true)
+
 	apiServer := &http.Server{
 		Addr:    ":" + cfg.APIPort,
 		Handler: apiRouter,
 	}
 
-	go func() {
-		log.Printf("API server listening on %s", apiServer.Addr)
-		if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("API server error: %v", err)
-		}
-	}()
-
 	// ---------------------
 	// Raw Telemetry WebSocket Server on port cfg.WebSocket.Port (e.g., 9091)
 	// ---------------------
 	telemetryMux := http.NewServeMux()
-	telemetryMux.HandleFunc("/telemetry", func(w http.ResponseWriter, r *http.Request) {
+	// The transmitter presents a token (websocket.token) when ingest auth is required
+	handleRoute(telemetryMux, cfg.WebSocket.Port, "/telemetry", requireIngestAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		telemetryHandler(w, r, cfg, messageMap, jobChan)
-	})
+	})))
 
 	telemetryServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.WebSocket.Port),
 		Handler: telemetryMux,
 	}
 
-	go func() {
-		log.Printf("Raw Telemetry WS server listening on %s", telemetryServer.Addr)
-		if err := telemetryServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Raw Telemetry WS server error: %v", err)
-		}
-	}()
-
 	// ---------------------
 	// Live Data WebSocket Server on port cfg.LiveWSPort (e.g., 9094)
 	// ---------------------
 	liveWsMux := http.NewServeMux()
-	liveWsMux.HandleFunc("/ws", wsserver.ServeWS)
+	wsserver.SetLiveRateLimit(cfg.WebSocket.RateLimit.LiveRate, cfg.WebSocket.RateLimit.LiveBurst, cfg.WebSocket.RateLimit.MaxViolations)
+	wsserver.SetMessageTTL(time.Duration(cfg.WebSocket.MessageTTLMs) * time.Millisecond)
+	// Clients select saved subscription profiles with ?profile=
+	wsserver.SetProfileSource(handlers.WSProfileLookup)
+	handleRoute(liveWsMux, cfg.LiveWSPort, "/ws", requireAuth(http.HandlerFunc(wsserver.ServeWS)))
+	// Control channel (subscriptions, acks, alerts, schema) for data sockets
+	// opened with ?session=
+	handleRoute(liveWsMux, cfg.LiveWSPort, "/ws/control", requireAuth(http.HandlerFunc(wsserver.ServeControl)))
+	// HTTP streaming fallback for venues that block WebSockets
+	handleRoute(liveWsMux, cfg.LiveWSPort, "/stream", requireAuth(http.HandlerFunc(wsserver.ServeStream)))
+	handleRoute(liveWsMux, cfg.LiveWSPort, "/transports", requireIngestAuth(http.HandlerFunc(wsserver.ServeTransports)))
 
 	liveDataServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.LiveWSPort),
 		Handler: liveWsMux,
 	}
 
+	// Competition mode pins the loaded definitions and config and needs auth on
+	// every route registered above, so it is configured before the servers start
+	if err := competition.Configure(competition.Config{
+		Enabled:           cfg.Competition.Enabled,
+		DefinitionsSHA256: cfg.Competition.DefinitionsSHA256,
+		ConfigSHA256:      cfg.Competition.ConfigSHA256,
+	}, competition.Setup{
+		AuthProvider:    authProvider.Name(),
+		DefinitionsFile: cfg.JSONFile,
+		ConfigFile:      cfg.File,
+		Definitions:     embedded.definitions,
+		Config:          embedded.config,
+	}); err != nil {
+		log.Fatalf("Invalid competition config: %v", err)
+	}
+
+	go func() {
+		log.Printf("API server listening on %s", apiServer.Addr)
+		if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("API server error: %v", err)
+		}
+	}()
+
+	go func() {
+		log.Printf("Raw Telemetry WS server listening on %s", telemetryServer.Addr)
+		if err := telemetryServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Raw Telemetry WS server error: %v", err)
+		}
+	}()
+
 	// Wait for termination signal in a separate goroutine
 	go func() {
 		<-signalChan
@@ -414,6 +860,9 @@ func main() {
 		// Cancel batch context to flush any pending writes
 		batchCancel()
 
+		// Let running background jobs observe the cancellation and return
+		scheduler.Wait()
+
 		// Allow some time for batch writes to complete
 		time.Sleep(100 * time.Millisecond)
 
@@ -435,3 +884,10 @@ func main() {
 
 	log.Printf("Telemetry Server completed in %s", time.Since(start))
 }
+
+// handleRoute registers a route on the mux of the server on port and records for
+// competition mode whether the handler authenticates its clients.
+func handleRoute(mux *http.ServeMux, port int, path string, h http.Handler) {
+	mux.Handle(path, h)
+	competition.Route(fmt.Sprintf(":%d%s", port, path), auth.Protects(h))
+}
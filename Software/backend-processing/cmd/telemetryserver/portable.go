@@ -0,0 +1,96 @@
+// portable.go
+//
+// Portable single-binary mode. The regular setup reads ../../configs/config.yaml
+// and the definitions file it names, relative to cmd/telemetryserver. With
+// -portable the server runs from any directory: the configuration is config.yaml
+// in the working directory or next to the binary, or else the one embedded at
+// build time, and the CAN definitions fall back to the embedded ones when
+// json_file does not exist (see package assets). -extract writes the embedded
+// files out for editing.
+//
+//	telemetryserver -extract . && telemetryserver -portable
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"telem-system/internal/assets"
+	"telem-system/internal/config"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/types"
+)
+
+var (
+	configPath = flag.String("config", "", "Configuration file (default ../../configs/config.yaml)")
+	portable   = flag.Bool("portable", false, "Run without the repository layout, falling back to the embedded configuration and CAN definitions")
+	extractDir = flag.String("extract", "", "Write the embedded configuration and CAN definitions to this directory and exit")
+)
+
+// embeddedSetup records which embedded files the server runs on, for competition
+// mode's pins; nil when the files on disk are used.
+type embeddedSetup struct {
+	config      []byte
+	definitions []byte
+}
+
+var embedded embeddedSetup
+
+// extractAssets writes the embedded files to dir and exits.
+func extractAssets(dir string) {
+	written, err := assets.Extract(dir)
+	for _, path := range written {
+		fmt.Println("Wrote", path)
+	}
+	if err != nil {
+		log.Fatalf("Failed to extract the embedded files: %v", err)
+	}
+	if _, ok := assets.Definitions(); !ok {
+		fmt.Println("No CAN definitions are embedded in this binary (build with make portable DEFS=...)")
+	}
+	os.Exit(0)
+}
+
+// loadConfig loads the configuration selected by the flags.
+func loadConfig() (*config.Config, error) {
+	if *configPath != "" {
+		return config.LoadConfigFile(*configPath)
+	}
+	if !*portable {
+		return config.LoadConfig("../../configs/", "config", "yaml")
+	}
+
+	candidates := []string{assets.ConfigName}
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), assets.ConfigName))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			log.Printf("Portable mode: using %s", path)
+			return config.LoadConfigFile(path)
+		}
+	}
+	log.Printf("Portable mode: no %s found, using the embedded configuration", assets.ConfigName)
+	embedded.config = assets.Config()
+	return config.LoadConfigData(embedded.config)
+}
+
+// loadDefinitions loads the CAN definitions of cfg. In portable mode the embedded
+// definitions are used when json_file is unset or does not exist.
+func loadDefinitions(cfg *config.Config) ([]types.Message, map[uint32]types.Message, error) {
+	if *portable {
+		_, err := os.Stat(cfg.JSONFile)
+		if cfg.JSONFile == "" || errors.Is(err, fs.ErrNotExist) {
+			if data, ok := assets.Definitions(); ok {
+				log.Printf("Portable mode: json_file %q not found, using the embedded CAN definitions", cfg.JSONFile)
+				embedded.definitions = data
+				return candecoder.ParseJSONDefinitions(data, "(embedded)")
+			}
+		}
+	}
+	return candecoder.LoadJSONDefinitions(cfg.JSONFile)
+}
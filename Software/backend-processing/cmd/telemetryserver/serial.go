@@ -0,0 +1,84 @@
+// serial.go
+//
+// Serial (SLCAN) CAN adapter ingest source for bench testing. Frames read from the
+// adapter go through the same pipeline as frames from the live WebSocket source.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"telem-system/internal/config"
+	"telem-system/pkg/maintenance"
+	"telem-system/pkg/processdata"
+	"telem-system/pkg/slcan"
+	"telem-system/pkg/types"
+	"time"
+)
+
+const (
+	// Default serial device of a CANable-style USB adapter
+	defaultSerialDevice = "/dev/ttyACM0"
+
+	// Delay before reopening the adapter after an error (e.g. unplugged)
+	serialRetryDelay = 2 * time.Second
+)
+
+// runSerialSource reads frames from the configured SLCAN adapter until ctx is
+// cancelled, reopening the adapter whenever it fails.
+func runSerialSource(ctx context.Context, cfg *config.Config, messageMap map[uint32]types.Message, jobChan chan<- dataJob) {
+	device := cfg.Serial.Device
+	if device == "" {
+		device = defaultSerialDevice
+	}
+	bitrate := cfg.CANBus.Bitrate
+	if bitrate <= 0 {
+		bitrate = 500000
+	}
+
+	for {
+		port, err := slcan.Open(device, cfg.Serial.Baud, bitrate)
+		if err != nil {
+			log.Printf("Serial CAN adapter %s unavailable: %v", device, err)
+		} else {
+			log.Printf("Reading CAN frames from serial adapter %s at %d bit/s", device, bitrate)
+
+			// Unblock the reader on shutdown
+			stop := context.AfterFunc(ctx, func() { port.Close() })
+			readSerialFrames(port, messageMap, jobChan)
+			if stop() {
+				port.Close()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(serialRetryDelay):
+		}
+	}
+}
+
+// readSerialFrames ingests frames until the adapter returns a read error.
+func readSerialFrames(port *slcan.Port, messageMap map[uint32]types.Message, jobChan chan<- dataJob) {
+	malformed := 0
+	for {
+		frame, err := port.ReadFrame()
+		if err != nil {
+			var parseErr *slcan.ParseError
+			if !errors.As(err, &parseErr) {
+				log.Printf("Serial CAN adapter read error: %v", err)
+				return
+			}
+			malformed++
+			if malformed%100 == 1 {
+				log.Printf("Skipping malformed SLCAN frame (%d so far): %v", malformed, err)
+			}
+			continue
+		}
+		if frame.RTR || !processdata.SourceEnabled(processdata.SourceSerial) || !maintenance.AcceptFrame() {
+			continue
+		}
+		ingestLiveFrame(frame.ID, frame.Data, messageMap, jobChan)
+	}
+}
@@ -0,0 +1,97 @@
+// assets.go
+//
+// Package assets holds the files embedded into the server for the portable
+// single-binary mode (telemetryserver -portable): a default configuration, the
+// CAN definitions and the status page. With them a fresh Pi or laptop needs the
+// binary and a database only, instead of the directory layout the relative paths
+// of the regular setup expect. The database schema needs no files; its
+// statements are compiled in (see db.EnsureSchema).
+//
+// The team's CAN definitions and configuration are not part of the repository.
+// `make portable DEFS=... CONFIG=...` copies them into defaults/ before building;
+// without CONFIG the configuration shipped in defaults/config.yaml is embedded.
+package assets
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Embedded files
+const (
+	defaultConfigFile = "defaults/config.yaml"          // Shipped with the repository
+	siteConfigFile    = "defaults/site-config.yaml"     // Copied by make portable CONFIG=
+	definitionsFile   = "defaults/can_definitions.json" // Copied by make portable DEFS=
+)
+
+// Names of the extracted files
+const (
+	ConfigName      = "config.yaml"
+	DefinitionsName = "can_definitions.json"
+)
+
+var (
+	//go:embed defaults
+	defaults embed.FS
+
+	// StatusPage is the minimal status UI served at /api/status.
+	//
+	//go:embed status.html
+	StatusPage []byte
+)
+
+// Config returns the embedded configuration: the site configuration if one was
+// embedded, the repository defaults otherwise.
+func Config() []byte {
+	if data, err := defaults.ReadFile(siteConfigFile); err == nil {
+		return data
+	}
+	data, err := defaults.ReadFile(defaultConfigFile)
+	if err != nil {
+		panic(err) // Embedded at build time
+	}
+	return data
+}
+
+// Definitions returns the embedded CAN definitions. It reports false when the
+// binary was built without them.
+func Definitions() ([]byte, bool) {
+	data, err := defaults.ReadFile(definitionsFile)
+	return data, err == nil
+}
+
+// Extract writes the embedded configuration and CAN definitions to dir as
+// ConfigName and DefinitionsName, so they can be edited next to the binary. It
+// returns the paths written and never overwrites existing files.
+func Extract(dir string) ([]string, error) {
+	type file struct {
+		name string
+		data []byte
+	}
+	files := []file{{ConfigName, Config()}}
+	if defs, ok := Definitions(); ok {
+		files = append(files, file{DefinitionsName, defs})
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if _, err := os.Stat(path); err == nil {
+			return written, fmt.Errorf("%s already exists", path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return written, err
+		}
+		if err := os.WriteFile(path, f.data, 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
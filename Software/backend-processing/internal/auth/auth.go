@@ -0,0 +1,227 @@
+// auth.go
+//
+// Package auth authenticates API and live WebSocket clients. The backend is
+// selected via config: "none" (default, everything open), "static" (token file,
+// used trackside without internet) or "oidc" (university SSO, used by the cloud
+// mirror). Every provider maps its identities onto the same role model.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// Role is a permission level. Higher roles include the lower ones.
+type Role int
+
+// Roles
+const (
+	RoleNone     Role = iota
+	RoleViewer        // Read telemetry, history and status
+	RoleOperator      // Start/stop sessions, record calibrations and annotations
+	RoleAdmin         // Configuration and maintenance endpoints
+)
+
+var roleNames = map[Role]string{
+	RoleNone:     "none",
+	RoleViewer:   "viewer",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+// String returns the configuration name of the role.
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("role(%d)", int(r))
+}
+
+// MarshalText encodes the role by name.
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// ParseRole returns the role with the given name.
+func ParseRole(name string) (Role, error) {
+	for r, n := range roleNames {
+		if strings.EqualFold(n, name) && r != RoleNone {
+			return r, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("unknown role %q", name)
+}
+
+// Principal is an authenticated client.
+type Principal struct {
+	Subject  string `json:"subject"`
+	Name     string `json:"name,omitempty"`
+	Role     Role   `json:"role"`
+	Provider string `json:"provider"`
+
+	// Share restricts a share link principal to one session's data; nil otherwise
+	Share *ShareScope `json:"share,omitempty"`
+}
+
+// Errors returned by providers
+var (
+	ErrNoCredentials      = errors.New("no credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Provider authenticates requests.
+type Provider interface {
+	// Name identifies the provider in logs and principals.
+	Name() string
+	// Authenticate validates the credentials of r. It returns ErrNoCredentials
+	// when the request carries none.
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// noneProvider grants every request full access.
+type noneProvider struct{}
+
+// NewNoneProvider returns a provider that disables authentication.
+func NewNoneProvider() Provider { return noneProvider{} }
+
+func (noneProvider) Name() string { return "none" }
+
+func (noneProvider) Authenticate(r *http.Request) (Principal, error) {
+	return Principal{Subject: "anonymous", Role: RoleAdmin, Provider: "none"}, nil
+}
+
+// BearerToken extracts the token from the Authorization header, falling back to
+// the access_token query parameter for browser WebSocket clients, which cannot
+// set headers.
+func BearerToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+			return strings.TrimSpace(h[7:])
+		}
+		return ""
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+type contextKey struct{}
+
+// FromContext returns the principal stored by Middleware.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// errResponse is the JSON body of authentication failures.
+type errResponse struct {
+	status int
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+func (e *errResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	render.Status(r, e.status)
+	return nil
+}
+
+// Middleware authenticates every request with p and requires at least the viewer
+// role. Share link principals are confined to SharePathPrefix. CORS preflight
+// requests pass through unauthenticated.
+func Middleware(p Provider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return guarded{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			principal, err := p.Authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="telemetry"`)
+				render.Render(w, r, &errResponse{status: http.StatusUnauthorized, Status: "Unauthorized.", Error: err.Error()})
+				return
+			}
+			if principal.Role < RoleViewer {
+				render.Render(w, r, &errResponse{status: http.StatusForbidden, Status: "Forbidden.", Error: "no role assigned"})
+				return
+			}
+			if principal.Share != nil && !strings.HasPrefix(r.URL.Path, SharePathPrefix) {
+				render.Render(w, r, &errResponse{status: http.StatusForbidden, Status: "Forbidden.", Error: "share links only grant access to shared data"})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, principal)))
+		})}
+	}
+}
+
+// MiddlewareWhen is Middleware applied only while required reports true; other
+// requests pass unauthenticated. Car ingest uses it so transmitters without a
+// token keep working until competition mode (or the configuration) requires one.
+func MiddlewareWhen(p Provider, required func() bool) func(http.Handler) http.Handler {
+	authenticate := Middleware(p)
+	return func(next http.Handler) http.Handler {
+		checked := authenticate(next)
+		return guarded{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if required() {
+				checked.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})}
+	}
+}
+
+// guarded is a handler wrapped by Middleware or MiddlewareWhen.
+type guarded struct {
+	http.Handler
+}
+
+// Protects reports whether h authenticates its requests, i.e. was returned by
+// Middleware or MiddlewareWhen. Competition mode uses it to check that no route
+// is left open.
+func Protects(h http.Handler) bool {
+	_, ok := h.(guarded)
+	return ok
+}
+
+// RequireRole rejects requests whose principal has less than the given role. It
+// must run after Middleware.
+func RequireRole(role Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := FromContext(r.Context())
+			if !ok || principal.Role < role {
+				render.Render(w, r, &errResponse{status: http.StatusForbidden, Status: "Forbidden.", Error: role.String() + " role required"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Settings selects and configures the authentication backend.
+type Settings struct {
+	Provider  string // "none" (default), "static" or "oidc"
+	TokenFile string // Token file of the static provider
+	OIDC      OIDCConfig
+}
+
+// NewProvider returns the provider selected by s.
+func NewProvider(s Settings) (Provider, error) {
+	switch s.Provider {
+	case "", "none":
+		return NewNoneProvider(), nil
+	case "static":
+		if s.TokenFile == "" {
+			return nil, errors.New("static auth requires a token file")
+		}
+		return NewStaticProvider(s.TokenFile)
+	case "oidc":
+		return NewOIDCProvider(s.OIDC)
+	default:
+		return nil, fmt.Errorf("unknown auth provider %q", s.Provider)
+	}
+}
@@ -0,0 +1,149 @@
+// static.go
+//
+// Static token provider for trackside use. Tokens are read from a text file with
+// one "<token> <role> [name]" entry per line; blank lines and lines starting with
+// '#' are ignored. A token may be given as "sha256:<hex>" so the file does not
+// have to hold the secret itself. The file is re-read when it changes.
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// staticEntry is a single token from the token file.
+type staticEntry struct {
+	role Role
+	name string
+}
+
+// StaticProvider authenticates bearer tokens listed in a token file.
+type StaticProvider struct {
+	path string
+
+	mu      sync.RWMutex
+	tokens  map[[sha256.Size]byte]staticEntry // Keyed by token hash
+	modTime time.Time
+}
+
+// NewStaticProvider loads the token file at path.
+func NewStaticProvider(path string) (*StaticProvider, error) {
+	p := &StaticProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Name implements Provider.
+func (p *StaticProvider) Name() string { return "static" }
+
+// Authenticate implements Provider.
+func (p *StaticProvider) Authenticate(r *http.Request) (Principal, error) {
+	token := BearerToken(r)
+	if token == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	p.maybeReload()
+
+	hash := sha256.Sum256([]byte(token))
+	p.mu.RLock()
+	entry, ok := p.tokens[hash]
+	p.mu.RUnlock()
+	if !ok {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return Principal{
+		Subject:  "token:" + hex.EncodeToString(hash[:4]),
+		Name:     entry.name,
+		Role:     entry.role,
+		Provider: p.Name(),
+	}, nil
+}
+
+// maybeReload re-reads the token file if it was modified. A broken file keeps the
+// previously loaded tokens.
+func (p *StaticProvider) maybeReload() {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return
+	}
+	p.mu.RLock()
+	changed := !info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+	if !changed {
+		return
+	}
+	if err := p.reload(); err != nil {
+		log.Printf("Keeping previous auth tokens: %v", err)
+	}
+}
+
+// reload parses the token file.
+func (p *StaticProvider) reload() error {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return fmt.Errorf("opening token file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading token file: %w", err)
+	}
+
+	tokens := make(map[[sha256.Size]byte]staticEntry)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("token file line %d: expected \"<token> <role> [name]\"", lineNo)
+		}
+		role, err := ParseRole(fields[1])
+		if err != nil {
+			return fmt.Errorf("token file line %d: %w", lineNo, err)
+		}
+		hash, err := tokenHash(fields[0])
+		if err != nil {
+			return fmt.Errorf("token file line %d: %w", lineNo, err)
+		}
+		tokens[hash] = staticEntry{role: role, name: strings.Join(fields[2:], " ")}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading token file: %w", err)
+	}
+
+	p.mu.Lock()
+	p.tokens = tokens
+	p.modTime = info.ModTime()
+	p.mu.Unlock()
+	log.Printf("Loaded %d auth token(s) from %s", len(tokens), p.path)
+	return nil
+}
+
+// tokenHash returns the SHA-256 of a plain token or decodes a "sha256:" entry.
+func tokenHash(field string) ([sha256.Size]byte, error) {
+	var hash [sha256.Size]byte
+	if hexHash, ok := strings.CutPrefix(field, "sha256:"); ok {
+		b, err := hex.DecodeString(hexHash)
+		if err != nil || len(b) != sha256.Size {
+			return hash, fmt.Errorf("malformed sha256 token hash")
+		}
+		copy(hash[:], b)
+		return hash, nil
+	}
+	return sha256.Sum256([]byte(field)), nil
+}
@@ -1,7 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"telem-system/internal/auth"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/filters"
+	"telem-system/pkg/profiles"
+	"telem-system/pkg/readiness"
+	"telem-system/pkg/webhooks"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,15 +27,341 @@ type Config struct {
 		URL  string `mapstructure:"url"`
 		IP   string `mapstructure:"ip"`   // Used by the sender for connection.
 		Port int    `mapstructure:"port"` // Raw telemetry WS port; receiver listens here.
+
+		Token string `mapstructure:"token"` // Bearer token the sender presents on /telemetry when auth is enabled
+
+		MessageTTLMs int `mapstructure:"message_ttl_ms"` // Live messages queued longer than this are dropped (default 1000, -1 disables)
+
+		// Per-connection inbound limits (messages per second and bucket size)
+		RateLimit struct {
+			LiveRate       float64 `mapstructure:"live_rate"` // Dashboard clients on /ws
+			LiveBurst      int     `mapstructure:"live_burst"`
+			TelemetryRate  float64 `mapstructure:"telemetry_rate"` // Transmitter on /telemetry
+			TelemetryBurst int     `mapstructure:"telemetry_burst"`
+			MaxViolations  int     `mapstructure:"max_violations"` // Dropped messages per 10s before disconnecting
+		} `mapstructure:"rate_limit"`
 	} `mapstructure:"websocket"`
 
+	File string `mapstructure:"-"` // Path of the loaded configuration file
+
 	DBCFile           string `mapstructure:"dbc_file"`
 	JSONFile          string `mapstructure:"json_file"`
-	Mode              string `mapstructure:"mode"`               // "csv" or "live"
-	ThrottlerInterval int    `mapstructure:"throttler_interval"` // in milliseconds
+	DefsStrictness    string `mapstructure:"definitions_strictness"` // "lenient" (default) or "strict" (warnings fail loading)
+	Mode              string `mapstructure:"mode"`                   // "csv" or "live"
+	ThrottlerInterval int    `mapstructure:"throttler_interval"`     // in milliseconds
 	APIPort           string `mapstructure:"apiport"`
 
 	LiveWSPort int `mapstructure:"live_ws_port"` // Live data WS (backend-to-frontend)
+
+	// Circuit breaker pausing the live broadcast while the hub rejects messages
+	CircuitBreaker struct {
+		Threshold int `mapstructure:"threshold"` // Consecutive rejected messages opening the circuit (default 100)
+		OpenMs    int `mapstructure:"open_ms"`   // Time open before messages are tried again (default 5000)
+	} `mapstructure:"circuit_breaker"`
+
+	// Live channel policies by message type, overriding the registry defaults:
+	// "both", "live" (broadcast only, e.g. ephemeral derived values) or "store"
+	LiveChannels struct {
+		Policies map[string]string `mapstructure:"policies"`
+	} `mapstructure:"live_channels"`
+
+	// Named profiles (e.g. track, garage, dyno) overriding the settings below.
+	// Profile is applied at startup; the TELEM_PROFILE environment variable
+	// overrides it and admins can switch profiles at runtime.
+	Profile  string `mapstructure:"profile"`
+	Profiles map[string]struct {
+		ThrottlerInterval *int `mapstructure:"throttler_interval"`
+		CellStorage       struct {
+			Mode             *string  `mapstructure:"mode"`
+			KeyframeInterval *int     `mapstructure:"keyframe_interval"`
+			Deadband         *float64 `mapstructure:"deadband"`
+		} `mapstructure:"cell_storage"`
+		MutedAlerts   []string        `mapstructure:"muted_alerts"`    // Replaces alerts.muted
+		AlertRuleSets []string        `mapstructure:"alert_rule_sets"` // Replaces alerts.active_rule_sets
+		Sources       map[string]bool `mapstructure:"sources"`         // "websocket" / "serial" ingest on or off
+	} `mapstructure:"profiles"`
+
+	// Feature flags overriding their defaults (see /api/admin/features)
+	FeatureFlags map[string]bool `mapstructure:"feature_flags"`
+
+	// Competition mode: admin changes locked, auth required, definitions and
+	// config pinned, storage before broadcast (see /api/competition)
+	Competition struct {
+		Enabled           bool   `mapstructure:"enabled"`            // On at startup
+		DefinitionsSHA256 string `mapstructure:"definitions_sha256"` // Pinned hash of json_file; empty accepts any
+		ConfigSHA256      string `mapstructure:"config_sha256"`      // Pinned hash of this file; empty accepts any
+	} `mapstructure:"competition"`
+
+	Alerts struct {
+		Muted []string `mapstructure:"muted"` // Alert keys or sources that are never raised
+
+		// Threshold rules on decoded signals, grouped into named sets (e.g. track,
+		// dyno); profiles select which sets are active
+		RuleSets map[string][]struct {
+			Name       string   `mapstructure:"name"`     // Alert key "rule.<name>"
+			Signal     string   `mapstructure:"signal"`   // Decoded CAN signal name
+			FrameID    uint32   `mapstructure:"frame_id"` // Only this frame's signal (0 matches any frame)
+			Above      *float64 `mapstructure:"above"`
+			Below      *float64 `mapstructure:"below"`
+			Severity   string   `mapstructure:"severity"`   // "info", "warning" (default) or "critical"
+			ForMs      int      `mapstructure:"for_ms"`     // Condition must hold this long before raising
+			Hysteresis float64  `mapstructure:"hysteresis"` // Margin inside the threshold before resolving
+			Message    string   `mapstructure:"message"`
+		} `mapstructure:"rule_sets"`
+		ActiveRuleSets []string `mapstructure:"active_rule_sets"` // Rule sets active without a profile
+	} `mapstructure:"alerts"`
+
+	CANBus struct {
+		Bitrate         int `mapstructure:"bitrate"`           // Nominal bus bitrate in bit/s (e.g. 500000)
+		StatsIntervalMs int `mapstructure:"stats_interval_ms"` // Bus load sampling window in milliseconds
+
+		DriverInputFrameID uint32 `mapstructure:"driver_input_frame_id"` // Steering wheel button/rotary frame (default 1792)
+
+		// Frame IDs to rewrite at ingest when firmware sends the wrong IDs
+		Remap []struct {
+			Observed  uint32 `mapstructure:"observed"`  // ID seen on the bus
+			Canonical uint32 `mapstructure:"canonical"` // ID of the definition to decode it with
+		} `mapstructure:"remap"`
+	} `mapstructure:"can_bus"`
+
+	// Accumulator layout: cell to segment mapping for segment-level aggregates
+	Accumulator struct {
+		Segments []struct {
+			Name          string `mapstructure:"name"`
+			FirstCell     int    `mapstructure:"first_cell"` // 1-based, inclusive
+			LastCell      int    `mapstructure:"last_cell"`
+			ThermistorIDs []int  `mapstructure:"thermistor_ids"` // Thermistor modules mounted on the segment
+		} `mapstructure:"segments"`
+	} `mapstructure:"accumulator"`
+
+	CellStorage struct {
+		Mode             string  `mapstructure:"mode"`              // "full" (default) or "delta"
+		KeyframeInterval int     `mapstructure:"keyframe_interval"` // Full row every N samples in delta mode
+		Deadband         float64 `mapstructure:"deadband"`          // Minimum change (V) recorded as a delta
+	} `mapstructure:"cell_storage"`
+
+	// Serial (SLCAN) CAN adapter, read in addition to the WebSocket source (bench testing)
+	Serial struct {
+		Enabled bool   `mapstructure:"enabled"`
+		Device  string `mapstructure:"device"` // e.g. /dev/ttyACM0
+		Baud    int    `mapstructure:"baud"`   // UART baud rate; ignored by USB CDC adapters
+	} `mapstructure:"serial"`
+
+	Sessions struct {
+		Mode         string  `mapstructure:"mode"`           // "auto" (default) or "manual"
+		QuietPeriodS int     `mapstructure:"quiet_period_s"` // Close an automatic session after this long without frames
+		TSThresholdV float64 `mapstructure:"ts_threshold_v"` // Tractive voltage above which the TS counts as active
+	} `mapstructure:"sessions"`
+
+	// Shutdown circuit reconstruction thresholds
+	Shutdown struct {
+		ShutdownCurrentMin float64 `mapstructure:"shutdown_current_min"` // PDM shutdown/reset current at which the loop counts as closed
+		TSALCurrentMin     float64 `mapstructure:"tsal_current_min"`     // PDM TSAL current at which the TSAL counts as on
+		AMSOKStatus        int     `mapstructure:"ams_ok_status"`        // AMS status value meaning no fault
+	} `mapstructure:"shutdown"`
+
+	// Pack current convention for the drive/regen energy split
+	Energy struct {
+		CurrentSign  float64 `mapstructure:"current_sign"`   // 1 (default) when discharge current is positive, -1 otherwise
+		IdleCurrentA float64 `mapstructure:"idle_current_a"` // Currents within this band count as neither drive nor regen
+	} `mapstructure:"energy"`
+
+	// Data gaps listed in query responses
+	Gaps struct {
+		Factor    float64        `mapstructure:"factor"`     // Intervals longer than this many expected periods are gaps (default 3)
+		PeriodsMs map[string]int `mapstructure:"periods_ms"` // Expected sample period per table; others are estimated from the data
+	} `mapstructure:"gaps"`
+
+	// Fusion of the shunt (pack_current) and hall (aculv_fd_1.cell_current) pack current
+	CurrentFusion struct {
+		Policy     string  `mapstructure:"policy"`      // "average" (default), "shunt" or "hall"
+		ToleranceA float64 `mapstructure:"tolerance_a"` // Largest difference at which the sensors agree (default 5)
+		MaxAgeMs   int     `mapstructure:"max_age_ms"`  // Readings older than this are not fused (default 500)
+	} `mapstructure:"current_fusion"`
+
+	// Accumulator internal resistance estimation from current steps
+	Resistance struct {
+		MinStepA          float64 `mapstructure:"min_step_a"`           // Smallest current change used as a step (default 20)
+		MaxStepIntervalMs int     `mapstructure:"max_step_interval_ms"` // Longest time between the samples of a step (default 250)
+		MaxOhm            float64 `mapstructure:"max_ohm"`              // Discard readings above this (default 1)
+		WindowSteps       int     `mapstructure:"window_steps"`         // Readings in the rolling median (default 25)
+		MinSteps          int     `mapstructure:"min_steps"`            // Readings before a session estimate is stored (default 10)
+		ReportIntervalS   int     `mapstructure:"report_interval_s"`    // Interval between stored estimates (default 30)
+		SeriesCells       int     `mapstructure:"series_cells"`         // Cells in series, for the per-cell figure (0 omits it)
+		TrendThreshold    float64 `mapstructure:"trend_threshold"`      // Fractional rise over previous sessions that alerts (default 0.15)
+		BaselineSessions  int     `mapstructure:"baseline_sessions"`    // Previous sessions forming the baseline (default 5)
+	} `mapstructure:"resistance"`
+
+	// Installed accumulator pack, for cycle counting and state of health
+	Pack struct {
+		ID                string  `mapstructure:"id"`                  // Pack in the car (default "default"); change when swapping packs
+		NominalCapacityAh float64 `mapstructure:"nominal_capacity_ah"` // Rated capacity; cycles and SOH need it
+		MinSOCSwing       float64 `mapstructure:"min_soc_swing"`       // Smallest SOC change (%) in a session that measures capacity (default 20)
+	} `mapstructure:"pack"`
+
+	// Consolidated vehicle state and the alerts suppressed in each state
+	VehicleState struct {
+		TSOnVoltage       float64             `mapstructure:"ts_on_voltage"`      // Tractive voltage above which the TS counts as on (default 60)
+		PrechargeFraction float64             `mapstructure:"precharge_fraction"` // Fraction of accumulator voltage that ends precharge (default 0.9)
+		DrivingSpeed      float64             `mapstructure:"driving_speed"`      // Speed (m/s) above which an enabled car is driving (default 1)
+		ChargeCurrentA    float64             `mapstructure:"charge_current_a"`   // Charge current that means charging at standstill (default 2)
+		OffTimeoutMs      int                 `mapstructure:"off_timeout_ms"`     // Without any input for this long the vehicle is OFF (default 2000)
+		DebounceMs        int                 `mapstructure:"debounce_ms"`        // A new state must hold this long (default 300)
+		Suppress          map[string][]string `mapstructure:"suppress"`           // State -> alert keys or sources ignored in it
+	} `mapstructure:"vehicle_state"`
+
+	// Precharge sequence detection and pass criteria
+	Precharge struct {
+		StartVoltage   float64 `mapstructure:"start_voltage"`     // Tractive voltage that starts a sequence
+		TargetFraction float64 `mapstructure:"target_fraction"`   // Fraction of accumulator voltage that completes it (default 0.9)
+		MinDurationMs  int     `mapstructure:"min_duration_ms"`   // Faster sequences fail (suspect precharge resistor)
+		MaxDurationMs  int     `mapstructure:"max_duration_ms"`   // Slower sequences fail
+		SettleMs       int     `mapstructure:"settle_ms"`         // Wait before measuring the final delta
+		MaxFinalDeltaV float64 `mapstructure:"max_final_delta_v"` // Maximum voltage difference after settling
+	} `mapstructure:"precharge"`
+
+	// Live rosbag2 recording for the driverless team
+	Rosbag struct {
+		Dir       string   `mapstructure:"dir"`        // Output directory (empty disables recording)
+		Channels  []string `mapstructure:"channels"`   // Live "type.field" or "type.*" selectors
+		RotateMin int      `mapstructure:"rotate_min"` // Minutes per file (default 10)
+	} `mapstructure:"rosbag"`
+
+	// On-disk cache of background exports (resumable downloads)
+	ExportCache struct {
+		Dir           string  `mapstructure:"dir"`            // Cache directory (empty disables export jobs)
+		TTLH          float64 `mapstructure:"ttl_h"`          // Hours a finished export is kept (default 24)
+		MaxGB         float64 `mapstructure:"max_gb"`         // Cache size before the oldest exports are evicted (default 20)
+		MaxConcurrent int     `mapstructure:"max_concurrent"` // Exports generated at the same time (default 2)
+	} `mapstructure:"export_cache"`
+
+	// End-of-day database backup to an attached drive
+	Backup struct {
+		Dir             string `mapstructure:"dir"`               // Directory on the backup drive (empty disables backups)
+		Schedule        string `mapstructure:"schedule"`          // Cron expression (default "0 21 * * *")
+		Keep            int    `mapstructure:"keep"`              // Backups kept on the drive (default 14)
+		PgDump          string `mapstructure:"pg_dump"`           // pg_dump executable (default from PATH)
+		PgRestore       string `mapstructure:"pg_restore"`        // pg_restore executable (default from PATH)
+		AllowSameDevice bool   `mapstructure:"allow_same_device"` // Accept a dir on the root filesystem (bench setups)
+	} `mapstructure:"backup"`
+
+	// Cloud mirror completing /api/query ranges archived off this server
+	Federation struct {
+		MirrorURL  string `mapstructure:"mirror_url"`        // Base URL of the mirror API (empty disables federation)
+		Token      string `mapstructure:"token"`             // Bearer token presented to the mirror
+		TimeoutS   int    `mapstructure:"timeout_s"`         // Limit on a mirror request (default 20)
+		RetentionH int    `mapstructure:"local_retention_h"` // Telemetry older than this many hours is archived off this server (required with mirror_url)
+	} `mapstructure:"federation"`
+
+	// Full-resolution capture of selected frames for traction-control tuning
+	TCCapture struct {
+		FrameIDs       []uint32 `mapstructure:"frame_ids"`        // Frames captured when a run selects none (default 101, 102, 200, 385, 513)
+		MaxDurationMin int      `mapstructure:"max_duration_min"` // A run stops by itself after this many minutes (default 30)
+		BufferFrames   int      `mapstructure:"buffer_frames"`    // Frames buffered ahead of the database writer (default 50000)
+	} `mapstructure:"tc_capture"`
+
+	// High-rate IMU board: full-rate storage, decimated broadcast and bursts around events
+	IMU struct {
+		AccelFrameID    uint32  `mapstructure:"accel_frame_id"`    // Accelerometer frame (x, y, z are its first three signals)
+		GyroFrameID     uint32  `mapstructure:"gyro_frame_id"`     // Gyroscope frame
+		BroadcastHz     float64 `mapstructure:"broadcast_hz"`      // Decimated live rate (default 50)
+		BurstBeforeMs   int     `mapstructure:"burst_before_ms"`   // Full-rate window kept before an event (default 2000)
+		BurstAfterMs    int     `mapstructure:"burst_after_ms"`    // Full-rate window kept after an event (default 3000)
+		BurstThresholdG float64 `mapstructure:"burst_threshold_g"` // Acceleration magnitude triggering a burst (0 disables)
+		BurstOnAlerts   bool    `mapstructure:"burst_on_alerts"`   // Critical alerts trigger a burst
+		RetentionH      int     `mapstructure:"retention_h"`       // Full-rate data outside bursts kept this many hours (0 keeps everything)
+	} `mapstructure:"imu"`
+
+	// Smoothing of broadcast values; stored data stays raw
+	LiveFilters []struct {
+		Channel  string  `mapstructure:"channel"`   // Live "type.field" or "type.*" selector
+		Type     string  `mapstructure:"type"`      // "moving_average", "median" or "lowpass"
+		Window   int     `mapstructure:"window"`    // Samples of the moving filters (default 5)
+		CutoffHz float64 `mapstructure:"cutoff_hz"` // Cutoff frequency of the low-pass filter
+	} `mapstructure:"live_filters"`
+
+	// Outbound webhooks fired on session, alert and export events
+	Webhooks []struct {
+		URL    string   `mapstructure:"url"`
+		Secret string   `mapstructure:"secret"` // HMAC-SHA256 signing key (X-Webhook-Signature)
+		Events []string `mapstructure:"events"` // e.g. "session.started", "alert.raised"; empty subscribes to all
+	} `mapstructure:"webhooks"`
+
+	Persistence struct {
+		LagAlertMs         int `mapstructure:"lag_alert_ms"`          // Alert when unflushed data is older than this
+		LagCheckIntervalMs int `mapstructure:"lag_check_interval_ms"` // How often persistence lag is checked
+
+		// Insert latency SLOs by table (e.g. cell_data: {percentile: 95,
+		// threshold_ms: 500}); "default" covers the tables without one
+		SLOs map[string]struct {
+			Percentile  float64 `mapstructure:"percentile"`   // Percentage of inserts that must meet the threshold
+			ThresholdMs int     `mapstructure:"threshold_ms"` // Maximum insert duration
+		} `mapstructure:"slos"`
+		SLOWindowS int `mapstructure:"slo_window_s"` // Compliance window in seconds (default 300)
+	} `mapstructure:"persistence"`
+
+	Metrics struct {
+		IntervalMs int `mapstructure:"interval_ms"` // Pipeline metrics sampling interval in milliseconds
+	} `mapstructure:"metrics"`
+
+	// Downsampled GPS for the live track map ("track_position" messages)
+	TrackPosition struct {
+		ToleranceM    float64 `mapstructure:"tolerance_m"`     // Maximum deviation of the drawn line from the dropped fixes (default 0.5)
+		MaxIntervalMs int     `mapstructure:"max_interval_ms"` // Send a fix at least this often (default 1000)
+	} `mapstructure:"track_position"`
+
+	// Lap detection and per-lap aggregates
+	Laps struct {
+		GateLat     float64  `mapstructure:"gate_lat"` // Start/finish gate position (0,0 disables GPS detection)
+		GateLon     float64  `mapstructure:"gate_lon"`
+		GateRadiusM float64  `mapstructure:"gate_radius_m"` // Distance from the gate that counts as crossing
+		MinLapS     int      `mapstructure:"min_lap_s"`     // Ignore GPS crossings closer together than this
+		Channels    []string `mapstructure:"channels"`      // Extra "table.column" channels aggregated per lap
+	} `mapstructure:"laps"`
+
+	// API and live WebSocket authentication
+	Auth struct {
+		Provider  string `mapstructure:"provider"`   // "none" (default), "static" or "oidc"
+		TokenFile string `mapstructure:"token_file"` // Static provider: "<token> <role> [name]" per line
+		OIDC      struct {
+			Issuer      string `mapstructure:"issuer"`
+			ClientID    string `mapstructure:"client_id"`    // Expected token audience
+			RoleClaim   string `mapstructure:"role_claim"`   // Default "roles"
+			NameClaim   string `mapstructure:"name_claim"`   // Default "name"
+			DefaultRole string `mapstructure:"default_role"` // Role of users without a mapped claim value (empty denies)
+			RoleMap     []struct {
+				Value string `mapstructure:"value"` // Claim value, e.g. an SSO group
+				Role  string `mapstructure:"role"`  // "viewer", "operator" or "admin"
+			} `mapstructure:"role_map"`
+		} `mapstructure:"oidc"`
+		RequireIngest bool `mapstructure:"require_ingest"` // Authenticate car ingest (/telemetry, /transports) outside competition mode too
+	} `mapstructure:"auth"`
+
+	// Pre-run readiness checks (/api/readiness/prerun)
+	Readiness struct {
+		NodeTimeoutMs int `mapstructure:"node_timeout_ms"` // A node is offline after this long without frames
+		Nodes         []struct {
+			Name     string   `mapstructure:"name"`
+			FrameIDs []uint32 `mapstructure:"frame_ids"` // Any of these frames proves the node is alive
+		} `mapstructure:"nodes"`
+		Calibrations       []string `mapstructure:"calibrations"`          // Names that must have a recent calibration
+		CalibrationMaxAgeH int      `mapstructure:"calibration_max_age_h"` // Maximum calibration age in hours
+		DBMaxLatencyMs     int      `mapstructure:"db_max_latency_ms"`     // Database round trip above which the check warns
+		StoragePath        string   `mapstructure:"storage_path"`          // Filesystem holding the database (empty skips the disk check)
+		MinFreeMB          int      `mapstructure:"min_free_mb"`           // Minimum free space on storage_path
+		DBQuotaMB          int      `mapstructure:"db_quota_mb"`           // Maximum database size (0 disables the check)
+	} `mapstructure:"readiness"`
+
+	// Storage codecs, applied in order (first match wins). Only affects tables created
+	// after the change; use cmd/schemagen to produce DDL for migrating existing ones.
+	Storage struct {
+		ColumnTypes []struct {
+			Columns string  `mapstructure:"columns"` // "table.column" pattern, e.g. "cell_data.cell*"
+			Type    string  `mapstructure:"type"`    // "double", "real", "smallint" or "integer"
+			Scale   float64 `mapstructure:"scale"`   // Units per integer step (integer types only)
+		} `mapstructure:"column_types"`
+		NullOutOfRange bool `mapstructure:"null_out_of_range"` // Store signals outside their CAN definition range as NULL
+	} `mapstructure:"storage"`
 }
 
 // LoadConfig reads and unmarshals the configuration file.
@@ -36,10 +373,191 @@ func LoadConfig(path, name, fileType string) (*Config, error) {
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("config file error: %v", err)
 	}
+	return decodeConfig(viper.ConfigFileUsed())
+}
+
+// LoadConfigFile reads the configuration from a file; the type follows its
+// extension.
+func LoadConfigFile(path string) (*Config, error) {
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("config file error: %v", err)
+	}
+	return decodeConfig(viper.ConfigFileUsed())
+}
 
+// LoadConfigData reads a YAML configuration held in memory, such as the one
+// embedded for the portable mode. Config.File is empty.
+func LoadConfigData(data []byte) (*Config, error) {
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("config data error: %v", err)
+	}
+	return decodeConfig("")
+}
+
+// decodeConfig decodes the configuration read by viper from file.
+func decodeConfig(file string) (*Config, error) {
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("config decode error: %v", err)
 	}
+	if p := os.Getenv("TELEM_PROFILE"); p != "" {
+		cfg.Profile = p
+	}
+	cfg.File = file
 	return &cfg, nil
 }
+
+// FrameIDRemap converts the remap list into an observed -> canonical map.
+func (c *Config) FrameIDRemap() (map[uint32]uint32, error) {
+	remap := make(map[uint32]uint32, len(c.CANBus.Remap))
+	for _, r := range c.CANBus.Remap {
+		if _, dup := remap[r.Observed]; dup {
+			return nil, fmt.Errorf("frame ID %d is remapped more than once", r.Observed)
+		}
+		remap[r.Observed] = r.Canonical
+	}
+	return remap, nil
+}
+
+// ColumnCodecs converts the storage section into database column codecs.
+func (c *Config) ColumnCodecs() []db.ColumnCodec {
+	codecs := make([]db.ColumnCodec, 0, len(c.Storage.ColumnTypes))
+	for _, ct := range c.Storage.ColumnTypes {
+		codecs = append(codecs, db.ColumnCodec{Pattern: ct.Columns, Type: ct.Type, Scale: ct.Scale})
+	}
+	return codecs
+}
+
+// GapConfig converts the gaps section into gap detection settings.
+func (c *Config) GapConfig() channels.GapConfig {
+	periods := make(map[string]time.Duration, len(c.Gaps.PeriodsMs))
+	for table, ms := range c.Gaps.PeriodsMs {
+		periods[table] = time.Duration(ms) * time.Millisecond
+	}
+	return channels.GapConfig{Factor: c.Gaps.Factor, Periods: periods}
+}
+
+// AccumulatorSegments converts the accumulator section into segment definitions.
+func (c *Config) AccumulatorSegments() []channels.Segment {
+	segs := make([]channels.Segment, 0, len(c.Accumulator.Segments))
+	for _, s := range c.Accumulator.Segments {
+		segs = append(segs, channels.Segment{Name: s.Name, FirstCell: s.FirstCell, LastCell: s.LastCell, ThermistorIDs: s.ThermistorIDs})
+	}
+	return segs
+}
+
+// LiveFilterSpecs converts the live_filters section into filter definitions.
+func (c *Config) LiveFilterSpecs() []filters.Spec {
+	specs := make([]filters.Spec, 0, len(c.LiveFilters))
+	for _, f := range c.LiveFilters {
+		specs = append(specs, filters.Spec{Channel: f.Channel, Type: f.Type, Window: f.Window, CutoffHz: f.CutoffHz})
+	}
+	return specs
+}
+
+// WebhookHooks converts the webhooks section into hook definitions.
+func (c *Config) WebhookHooks() []webhooks.Hook {
+	hooks := make([]webhooks.Hook, 0, len(c.Webhooks))
+	for _, h := range c.Webhooks {
+		hooks = append(hooks, webhooks.Hook{URL: h.URL, Secret: h.Secret, Events: h.Events})
+	}
+	return hooks
+}
+
+// ProfileSettings converts the base settings and the profiles section into
+// profile definitions.
+func (c *Config) ProfileSettings() (profiles.Settings, map[string]profiles.Profile) {
+	base := profiles.Settings{
+		ThrottlerInterval: c.ThrottlerInterval,
+		CellStorageMode:   c.CellStorage.Mode,
+		KeyframeInterval:  c.CellStorage.KeyframeInterval,
+		Deadband:          c.CellStorage.Deadband,
+		MutedAlerts:       c.Alerts.Muted,
+		AlertRuleSets:     c.Alerts.ActiveRuleSets,
+	}
+	named := make(map[string]profiles.Profile, len(c.Profiles))
+	for name, p := range c.Profiles {
+		named[name] = profiles.Profile{
+			ThrottlerInterval: p.ThrottlerInterval,
+			CellStorageMode:   p.CellStorage.Mode,
+			KeyframeInterval:  p.CellStorage.KeyframeInterval,
+			Deadband:          p.CellStorage.Deadband,
+			MutedAlerts:       p.MutedAlerts,
+			AlertRuleSets:     p.AlertRuleSets,
+			Sources:           p.Sources,
+		}
+	}
+	return base, named
+}
+
+// AlertRuleSets converts the alert rule sets section into rules.
+func (c *Config) AlertRuleSets() map[string][]alerts.Rule {
+	sets := make(map[string][]alerts.Rule, len(c.Alerts.RuleSets))
+	for name, rules := range c.Alerts.RuleSets {
+		for _, r := range rules {
+			sets[name] = append(sets[name], alerts.Rule{
+				Name:       r.Name,
+				Signal:     r.Signal,
+				FrameID:    r.FrameID,
+				Above:      r.Above,
+				Below:      r.Below,
+				Severity:   r.Severity,
+				ForMs:      r.ForMs,
+				Hysteresis: r.Hysteresis,
+				Message:    r.Message,
+			})
+		}
+	}
+	return sets
+}
+
+// ReadinessConfig converts the readiness section into pre-run check requirements.
+func (c *Config) ReadinessConfig() readiness.Config {
+	r := c.Readiness
+	rc := readiness.Config{
+		NodeTimeout:       time.Duration(r.NodeTimeoutMs) * time.Millisecond,
+		Calibrations:      r.Calibrations,
+		CalibrationMaxAge: time.Duration(r.CalibrationMaxAgeH) * time.Hour,
+		DBMaxLatency:      time.Duration(r.DBMaxLatencyMs) * time.Millisecond,
+		StoragePath:       r.StoragePath,
+		MinFreeBytes:      uint64(r.MinFreeMB) << 20,
+		DBQuotaBytes:      int64(r.DBQuotaMB) << 20,
+	}
+	for _, n := range r.Nodes {
+		rc.Nodes = append(rc.Nodes, readiness.Node{Name: n.Name, FrameIDs: n.FrameIDs})
+	}
+	return rc
+}
+
+// AuthSettings converts the auth section into provider settings.
+func (c *Config) AuthSettings() (auth.Settings, error) {
+	a := c.Auth
+	s := auth.Settings{
+		Provider:  a.Provider,
+		TokenFile: a.TokenFile,
+		OIDC: auth.OIDCConfig{
+			Issuer:    a.OIDC.Issuer,
+			Audience:  a.OIDC.ClientID,
+			RoleClaim: a.OIDC.RoleClaim,
+			NameClaim: a.OIDC.NameClaim,
+			RoleMap:   make(map[string]auth.Role, len(a.OIDC.RoleMap)),
+		},
+	}
+	if a.OIDC.DefaultRole != "" {
+		role, err := auth.ParseRole(a.OIDC.DefaultRole)
+		if err != nil {
+			return s, fmt.Errorf("auth.oidc.default_role: %w", err)
+		}
+		s.OIDC.DefaultRole = role
+	}
+	for _, m := range a.OIDC.RoleMap {
+		role, err := auth.ParseRole(m.Role)
+		if err != nil {
+			return s, fmt.Errorf("auth.oidc.role_map %q: %w", m.Value, err)
+		}
+		s.OIDC.RoleMap[m.Value] = role
+	}
+	return s, nil
+}
@@ -0,0 +1,423 @@
+// docs.go
+//
+// Package docs holds the telemetry reference: the CAN definitions, derived and
+// live channels, database tables and API routes of the server, rendered as Markdown
+// and HTML. The reference is generated from the registries themselves by
+// cmd/docsgen (run `go generate ./internal/docs` or `make docs`) and embedded into
+// the server, which serves it at /api/docs. The generated files are not edited by
+// hand.
+//
+// The CAN definitions are deployed separately from the binary, so the generated
+// files only mark where the CAN messages go. The server fills that section in at
+// request time from the definitions it loaded (see Configure), listing exactly
+// the frames it decodes.
+package docs
+
+//go:generate go run ../../cmd/docsgen -out .
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/livechannels"
+	"telem-system/pkg/types"
+)
+
+// Generated reference, served at /api/docs with the CAN messages filled in
+var (
+	//go:embed reference.md
+	referenceMarkdown []byte
+
+	//go:embed reference.html
+	referenceHTML []byte
+)
+
+// Markers the generated files carry in place of the CAN messages
+const (
+	canMarker    = "<!-- can-messages -->"
+	canNavMarker = "<!-- can-nav -->"
+)
+
+// CAN definitions loaded by the server, ordered by frame ID
+var (
+	mu       sync.RWMutex
+	messages []types.Message
+)
+
+// Route is an API route.
+type Route struct {
+	Method  string
+	Pattern string
+	Handler string // Go function serving the route
+}
+
+// ServerTable is a table owned by the server rather than filled from CAN frames,
+// with the statements creating and migrating it.
+type ServerTable struct {
+	Name string
+	DDL  string
+}
+
+// Reference is the content of the generated reference.
+type Reference struct {
+	DerivedChannels   []string
+	SegmentAggregates []string
+	LiveChannels      []livechannels.Channel
+	TelemetryTables   []db.TableSpec
+	ServerTables      []ServerTable
+	Routes            []Route
+}
+
+var (
+	createTable = regexp.MustCompile(`^CREATE TABLE IF NOT EXISTS (\w+)`)
+	tableRef    = regexp.MustCompile(`^(?:ALTER TABLE (?:IF EXISTS )?|CREATE (?:UNIQUE )?INDEX .* ON )(\w+)`)
+)
+
+// Build collects the reference from the routes given and the channel and table
+// registries.
+func Build(routes []Route) Reference {
+	ref := Reference{
+		DerivedChannels:   channels.DerivedChannels(),
+		SegmentAggregates: channels.SegmentAggregates,
+		LiveChannels:      livechannels.All(),
+		TelemetryTables:   db.TelemetryTables,
+		Routes:            append([]Route(nil), routes...),
+	}
+	sort.Slice(ref.Routes, func(i, k int) bool {
+		if ref.Routes[i].Pattern != ref.Routes[k].Pattern {
+			return ref.Routes[i].Pattern < ref.Routes[k].Pattern
+		}
+		return ref.Routes[i].Method < ref.Routes[k].Method
+	})
+
+	telemetry := make(map[string]bool, len(db.TelemetryTables))
+	for _, t := range db.TelemetryTables {
+		telemetry[t.Name] = true
+	}
+	index := make(map[string]int)
+	for _, stmt := range db.SchemaStatements() {
+		stmt = strings.TrimSpace(stmt)
+		if m := createTable.FindStringSubmatch(stmt); m != nil && !telemetry[m[1]] {
+			index[m[1]] = len(ref.ServerTables)
+			ref.ServerTables = append(ref.ServerTables, ServerTable{Name: m[1], DDL: stmt + ";"})
+			continue
+		}
+		if m := tableRef.FindStringSubmatch(stmt); m != nil {
+			if i, ok := index[m[1]]; ok {
+				ref.ServerTables[i].DDL += "\n" + stmt + ";"
+			}
+		}
+	}
+	sort.Slice(ref.ServerTables, func(i, k int) bool { return ref.ServerTables[i].Name < ref.ServerTables[k].Name })
+	return ref
+}
+
+// signalRange formats the physical range of a signal.
+func signalRange(s types.Signal) string {
+	if s.Minimum == nil && s.Maximum == nil {
+		return ""
+	}
+	f := func(v *float64) string {
+		if v == nil {
+			return "…"
+		}
+		return strconv.FormatFloat(*v, 'g', -1, 64)
+	}
+	return f(s.Minimum) + " – " + f(s.Maximum)
+}
+
+// signalChoices formats the value table of a signal, ordered by value.
+func signalChoices(s types.Signal) string {
+	keys := make([]string, 0, len(s.Choices))
+	for k := range s.Choices {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, k int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[k])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[k]
+	})
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + " = " + s.Choices[k]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// signalType describes the encoding of a signal.
+func signalType(s types.Signal) string {
+	typ := "unsigned"
+	switch {
+	case s.IsFloat:
+		typ = "float"
+	case s.IsSigned:
+		typ = "signed"
+	}
+	return fmt.Sprintf("%s %d bit @%d %s", typ, s.Length, s.Start, s.ByteOrder)
+}
+
+// frameID formats a frame ID in hex and decimal.
+func frameID(id uint32) string {
+	return fmt.Sprintf("0x%X (%d)", id, id)
+}
+
+// mdCell escapes a Markdown table cell.
+func mdCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// RenderMarkdown renders the reference as Markdown.
+func (ref Reference) RenderMarkdown() []byte {
+	var b bytes.Buffer
+	b.WriteString("# Telemetry reference\n\n")
+	b.WriteString("Generated by cmd/docsgen from the channel registry, table specs and API routes; the CAN messages are the definitions the server loaded. Do not edit.\n\n")
+	b.WriteString("- [CAN messages](#can-messages)\n- [Derived channels](#derived-channels)\n- [Live channels](#live-channels)\n- [Telemetry tables](#telemetry-tables)\n- [Server tables](#server-tables)\n- [API routes](#api-routes)\n\n")
+
+	b.WriteString("## CAN messages\n\n" + canMarker + "\n\n")
+
+	b.WriteString("## Derived channels\n\n")
+	b.WriteString("Computed from stored data at query time and addressed like table columns.\n\n")
+	for _, c := range ref.DerivedChannels {
+		fmt.Fprintf(&b, "- `%s`\n", c)
+	}
+	fmt.Fprintf(&b, "- `derived.segment<N>_<aggregate>` for every configured accumulator segment N (from 1); aggregates: %s\n\n",
+		"`"+strings.Join(ref.SegmentAggregates, "`, `")+"`")
+
+	b.WriteString("## Live channels\n\n")
+	b.WriteString("Message types broadcast on /ws and /stream. Every TelemetryMessage carries the channel ID and schema version. The default policy says whether values are stored, broadcast (live) or both.\n\n")
+	b.WriteString("| ID | Type | Version | Policy | Description |\n|---|---|---|---|---|\n")
+	for _, c := range ref.LiveChannels {
+		fmt.Fprintf(&b, "| %d | `%s` | %d | %s | %s |\n", c.ID, c.Type, c.Version, c.Policy, mdCell(c.Description))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Telemetry tables\n\n")
+	b.WriteString("Every table also has a `timestamp` and a `seq` column. Channels are addressed as `table.column`.\n\n")
+	for _, t := range ref.TelemetryTables {
+		fmt.Fprintf(&b, "### %s\n\n", t.Name)
+		b.WriteString("| Column | Kind |\n|---|---|\n")
+		for _, c := range t.Columns {
+			fmt.Fprintf(&b, "| %s | %s |\n", c.Name, c.Kind)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Server tables\n\n")
+	for _, t := range ref.ServerTables {
+		fmt.Fprintf(&b, "### %s\n\n```sql\n%s\n```\n\n", t.Name, t.DDL)
+	}
+
+	b.WriteString("## API routes\n\n| Method | Route | Handler |\n|---|---|---|\n")
+	for _, r := range ref.Routes {
+		fmt.Fprintf(&b, "| %s | `%s` | %s |\n", r.Method, r.Pattern, mdCell(r.Handler))
+	}
+	return b.Bytes()
+}
+
+var htmlTemplate = template.Must(template.New("reference").Funcs(template.FuncMap{
+	"join":         strings.Join,
+	"canMarker":    func() template.HTML { return canMarker },
+	"canNavMarker": func() template.HTML { return canNavMarker },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Telemetry reference</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0; display: flex; }
+nav { position: sticky; top: 0; height: 100vh; overflow-y: auto; min-width: 16rem; padding: 1rem; background: #f4f4f4; box-sizing: border-box; }
+nav a { display: block; color: #333; text-decoration: none; font-size: 0.9rem; }
+main { padding: 1rem 2rem; overflow-x: auto; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #ccc; padding: 0.2rem 0.5rem; text-align: left; font-size: 0.9rem; }
+pre { background: #f4f4f4; padding: 0.5rem; overflow-x: auto; }
+input { width: 100%; margin-bottom: 0.5rem; }
+</style>
+</head>
+<body>
+<nav>
+<input id="filter" placeholder="Filter" oninput="for (const s of document.querySelectorAll('section[data-name]')) s.hidden = !s.dataset.name.includes(this.value.toLowerCase())">
+<a href="#can-messages"><b>CAN messages</b></a>
+{{canNavMarker}}
+<a href="#derived-channels"><b>Derived channels</b></a>
+<a href="#live-channels"><b>Live channels</b></a>
+<a href="#telemetry-tables"><b>Telemetry tables</b></a>
+{{- range .TelemetryTables}}
+<a href="#table-{{.Name}}">{{.Name}}</a>
+{{- end}}
+<a href="#server-tables"><b>Server tables</b></a>
+{{- range .ServerTables}}
+<a href="#table-{{.Name}}">{{.Name}}</a>
+{{- end}}
+<a href="#api-routes"><b>API routes</b></a>
+</nav>
+<main>
+<h1>Telemetry reference</h1>
+<p>Generated by cmd/docsgen from the channel registry, table specs and API routes; the CAN messages are the definitions the server loaded. Do not edit.</p>
+
+<h2 id="can-messages">CAN messages</h2>
+{{canMarker}}
+
+<h2 id="derived-channels">Derived channels</h2>
+<p>Computed from stored data at query time and addressed like table columns.</p>
+<ul>
+{{- range .DerivedChannels}}
+<li><code>{{.}}</code></li>
+{{- end}}
+<li><code>derived.segment&lt;N&gt;_&lt;aggregate&gt;</code> for every configured accumulator segment N (from 1); aggregates: {{join .SegmentAggregates ", "}}</li>
+</ul>
+
+<h2 id="live-channels">Live channels</h2>
+<p>Message types broadcast on /ws and /stream. Every TelemetryMessage carries the channel ID and schema version. The default policy says whether values are stored, broadcast (live) or both.</p>
+<table>
+<tr><th>ID</th><th>Type</th><th>Version</th><th>Policy</th><th>Description</th></tr>
+{{- range .LiveChannels}}
+<tr><td>{{.ID}}</td><td><code>{{.Type}}</code></td><td>{{.Version}}</td><td>{{.Policy}}</td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+
+<h2 id="telemetry-tables">Telemetry tables</h2>
+<p>Every table also has a <code>timestamp</code> and a <code>seq</code> column. Channels are addressed as <code>table.column</code>.</p>
+{{- range .TelemetryTables}}
+<section data-name="{{.Name}}">
+<h3 id="table-{{.Name}}">{{.Name}}</h3>
+<table>
+<tr><th>Column</th><th>Kind</th></tr>
+{{- range .Columns}}
+<tr><td>{{.Name}}</td><td>{{.Kind}}</td></tr>
+{{- end}}
+</table>
+</section>
+{{- end}}
+
+<h2 id="server-tables">Server tables</h2>
+{{- range .ServerTables}}
+<section data-name="{{.Name}}">
+<h3 id="table-{{.Name}}">{{.Name}}</h3>
+<pre>{{.DDL}}</pre>
+</section>
+{{- end}}
+
+<h2 id="api-routes">API routes</h2>
+<table>
+<tr><th>Method</th><th>Route</th><th>Handler</th></tr>
+{{- range .Routes}}
+<tr><td>{{.Method}}</td><td><code>{{.Pattern}}</code></td><td>{{.Handler}}</td></tr>
+{{- end}}
+</table>
+</main>
+</body>
+</html>
+`))
+
+// RenderHTML renders the reference as a single HTML page with a navigation
+// sidebar and a filter.
+func (ref Reference) RenderHTML() ([]byte, error) {
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, ref); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Configure sets the CAN definitions listed in the reference served.
+func Configure(defs []types.Message) {
+	sorted := append([]types.Message(nil), defs...)
+	sort.Slice(sorted, func(i, k int) bool { return sorted[i].FrameID < sorted[k].FrameID })
+	mu.Lock()
+	messages = sorted
+	mu.Unlock()
+}
+
+// loaded returns the CAN definitions set by Configure.
+func loaded() []types.Message {
+	mu.RLock()
+	defer mu.RUnlock()
+	return messages
+}
+
+// renderCANMarkdown renders the CAN messages section as Markdown.
+func renderCANMarkdown(msgs []types.Message) []byte {
+	var b bytes.Buffer
+	if len(msgs) == 0 {
+		b.WriteString("No CAN definitions are loaded.")
+	}
+	for i, m := range msgs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "### %s — %s\n\n", m.Name, frameID(m.FrameID))
+		ext := ""
+		if m.IsExtendedFrame {
+			ext = ", extended frame"
+		}
+		fmt.Fprintf(&b, "%d bytes%s.\n\n", m.Length, ext)
+		b.WriteString("| Signal | Encoding | Factor | Offset | Range | Unit | Values |\n|---|---|---|---|---|---|---|\n")
+		for _, s := range m.Signals {
+			fmt.Fprintf(&b, "| %s | %s | %g | %g | %s | %s | %s |\n", mdCell(s.Name), signalType(s), s.Factor, s.Offset,
+				signalRange(s), mdCell(s.Unit), mdCell(signalChoices(s)))
+		}
+	}
+	return bytes.TrimRight(b.Bytes(), "\n")
+}
+
+var canTemplate = template.Must(template.New("can").Funcs(template.FuncMap{
+	"frameID":       frameID,
+	"signalType":    signalType,
+	"signalRange":   signalRange,
+	"signalChoices": signalChoices,
+	"lower":         strings.ToLower,
+}).Parse(`{{define "nav"}}
+{{- range .}}
+<a href="#msg-{{.FrameID}}">{{.Name}}</a>
+{{- end}}
+{{- end}}
+{{- define "messages"}}
+{{- if not .}}
+<p>No CAN definitions are loaded.</p>
+{{- end}}
+{{- range .}}
+<section data-name="{{lower .Name}} {{lower (frameID .FrameID)}}">
+<h3 id="msg-{{.FrameID}}">{{.Name}} — {{frameID .FrameID}}</h3>
+<p>{{.Length}} bytes{{if .IsExtendedFrame}}, extended frame{{end}}.</p>
+<table>
+<tr><th>Signal</th><th>Encoding</th><th>Factor</th><th>Offset</th><th>Range</th><th>Unit</th><th>Values</th></tr>
+{{- range .Signals}}
+<tr><td>{{.Name}}</td><td>{{signalType .}}</td><td>{{.Factor}}</td><td>{{.Offset}}</td><td>{{signalRange .}}</td><td>{{.Unit}}</td><td>{{signalChoices .}}</td></tr>
+{{- end}}
+</table>
+</section>
+{{- end}}
+{{- end}}`))
+
+// Markdown returns the reference as Markdown, listing the CAN definitions the
+// server loaded.
+func Markdown() []byte {
+	return bytes.Replace(referenceMarkdown, []byte(canMarker), renderCANMarkdown(loaded()), 1)
+}
+
+// HTML returns the reference as HTML, listing the CAN definitions the server
+// loaded.
+func HTML() ([]byte, error) {
+	msgs := loaded()
+	var nav, section bytes.Buffer
+	if err := canTemplate.ExecuteTemplate(&nav, "nav", msgs); err != nil {
+		return nil, err
+	}
+	if err := canTemplate.ExecuteTemplate(&section, "messages", msgs); err != nil {
+		return nil, err
+	}
+	page := bytes.Replace(referenceHTML, []byte(canNavMarker), bytes.TrimPrefix(nav.Bytes(), []byte("\n")), 1)
+	return bytes.Replace(page, []byte(canMarker), bytes.TrimPrefix(section.Bytes(), []byte("\n")), 1), nil
+}
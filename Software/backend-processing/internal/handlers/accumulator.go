@@ -0,0 +1,119 @@
+// accumulator.go
+//
+// Accumulator segment endpoints: the configured cell to segment mapping with the
+// latest aggregates, and segment aggregate history.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerAccumulatorRoutes registers the accumulator segment endpoints.
+func registerAccumulatorRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/accumulator/segments", segmentsHandler(queries))
+	r.Get("/api/accumulator/segments/history", segmentHistoryHandler(queries))
+}
+
+// segmentResponse describes a segment, its channel IDs and its latest aggregates.
+type segmentResponse struct {
+	channels.Segment
+	Channels map[string]string     `json:"channels"` // Aggregate -> channel ID
+	Latest   channels.SegmentStats `json:"latest"`
+}
+
+// segmentsHandler returns the configured segments with their aggregates at the
+// time given by the at query parameter (RFC 3339, default now).
+func segmentsHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		at := time.Now()
+		if s := r.URL.Query().Get("at"); s != "" {
+			var err error
+			if at, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid at: %w", err)))
+				return
+			}
+		}
+		stats, err := channels.SegmentSnapshot(r.Context(), queries, at)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+
+		segs := channels.Segments()
+		out := make([]segmentResponse, len(segs))
+		for i, seg := range segs {
+			out[i] = segmentResponse{Segment: seg, Channels: make(map[string]string), Latest: stats[i]}
+			for _, agg := range channels.SegmentAggregates {
+				out[i].Channels[agg] = channels.SegmentChannel(i, agg)
+			}
+		}
+		render.JSON(w, r, out)
+	}
+}
+
+// segmentHistoryHandler returns segment aggregates over a time range on a common
+// time grid. Query parameters: from, to (RFC 3339), aggregate (one of the segment
+// aggregates, default all) and step (milliseconds).
+func segmentHistoryHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		aggs := channels.SegmentAggregates
+		if a := r.URL.Query().Get("aggregate"); a != "" {
+			aggs = []string{a}
+		}
+		q := channels.Query{From: from, To: to, Domain: channels.DomainTime}
+		for i := range channels.Segments() {
+			for _, agg := range aggs {
+				q.Channels = append(q.Channels, channels.SegmentChannel(i, agg))
+			}
+		}
+		if len(q.Channels) == 0 {
+			render.Render(w, r, ErrInvalidRequest(errors.New("no accumulator segments configured")))
+			return
+		}
+		if err := channels.Validate(q.Channels[0]); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		if s := r.URL.Query().Get("step"); s != "" {
+			step, err := strconv.ParseFloat(s, 64)
+			if err != nil || step <= 0 {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid step %q", s)))
+				return
+			}
+			q.TimeStep = time.Duration(step * float64(time.Millisecond))
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		res, err := channels.Run(ctx, queries, q)
+		if err != nil {
+			if errors.Is(err, channels.ErrTooManyPoints) {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,17 @@
+// backups.go
+//
+// Admin endpoint of the backup log. Backups run as the "backup" job; trigger one
+// outside its schedule with POST /api/admin/jobs/backup/run.
+package handlers
+
+import (
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// registerBackupRoutes registers the backup log endpoint.
+func registerBackupRoutes(r chi.Router, queries *db.Queries) {
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/backups", makePaginatedHandler(queries.ScanBackupsPaginated))
+}
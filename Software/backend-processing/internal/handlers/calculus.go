@@ -0,0 +1,73 @@
+// calculus.go
+//
+// Derivative and integral endpoints for a single channel over a time range.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// calculusHandler serves /api/derivative and /api/integral. Query parameters:
+// channel ("table.column" or "derived.<name>"), from, to (RFC 3339), smooth
+// (moving average window in milliseconds), step (resampling grid in milliseconds)
+// and scale (multiplier applied to the result, e.g. 0.000277778 for A -> Ah).
+func calculusHandler(queries *db.Queries, op string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		params := r.URL.Query()
+		q := channels.CalculusQuery{Channel: params.Get("channel"), From: from, To: to, Op: op}
+		if q.Channel == "" {
+			render.Render(w, r, ErrInvalidRequest(errors.New("channel is required")))
+			return
+		}
+		if err := channels.Validate(q.Channel); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		for name, dst := range map[string]*time.Duration{"smooth": &q.Smoothing, "step": &q.Step} {
+			if s := params.Get(name); s != "" {
+				ms, err := strconv.ParseFloat(s, 64)
+				if err != nil || ms < 0 {
+					render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid %s %q", name, s)))
+					return
+				}
+				*dst = time.Duration(ms * float64(time.Millisecond))
+			}
+		}
+		if s := params.Get("scale"); s != "" {
+			if q.Scale, err = strconv.ParseFloat(s, 64); err != nil || q.Scale == 0 {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid scale %q", s)))
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		res, err := channels.RunCalculus(ctx, queries, q)
+		if err != nil {
+			if errors.Is(err, channels.ErrTooManyPoints) {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, res)
+	}
+}
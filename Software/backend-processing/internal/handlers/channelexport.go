@@ -0,0 +1,146 @@
+// channelexport.go
+//
+// Channel exports. Selected channels ("table.column" or "derived.<name>") over a
+// time range are merged onto the union of their sample times and written in any
+// registered format; a channel without a sample at a row's time is missing in
+// that row. /api/export/rosbag is the rosbag2 export of the driverless team,
+// with one std_msgs/msg/Float64 topic per channel.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/exporters"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// Upper bound on the samples of a single channel export, all channels combined
+const maxChannelExportSamples = 2000000
+
+// channelExportHandler serves /api/export/channels. Query parameters: channels
+// (comma-separated), from, to (RFC 3339) and format (default "csv").
+func channelExportHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan, err := planChannelExport(r, queries, "csv")
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		serveExport(w, r, plan)
+	}
+}
+
+// rosbagExportHandler serves /api/export/rosbag, a channel export defaulting to
+// the rosbag format.
+func rosbagExportHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan, err := planChannelExport(r, queries, "rosbag")
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		serveExport(w, r, plan)
+	}
+}
+
+// planChannelExport validates a channel export request. defFormat applies when
+// the request names no format.
+func planChannelExport(r *http.Request, queries *db.Queries, defFormat string) (exportPlan, error) {
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		return exportPlan{}, err
+	}
+	exporter, err := lookupExporter(r, defFormat)
+	if err != nil {
+		return exportPlan{}, err
+	}
+	format := exporter.Format()
+	var ids []string
+	seen := make(map[string]bool)
+	for _, c := range strings.Split(r.URL.Query().Get("channels"), ",") {
+		if c = strings.TrimSpace(c); c != "" && !seen[c] {
+			seen[c] = true
+			ids = append(ids, c)
+		}
+	}
+	if len(ids) == 0 {
+		return exportPlan{}, errors.New("channels is required")
+	}
+	for _, c := range ids {
+		if err := channels.Validate(c); err != nil {
+			return exportPlan{}, err
+		}
+	}
+
+	base := fmt.Sprintf("fsae_%s", from.UTC().Format("20060102T150405Z"))
+	name := base + format.Extension
+	return exportPlan{
+		Key:         fmt.Sprintf("%s|%s|%d|%d", format.Name, strings.Join(ids, ","), from.UnixNano(), to.UnixNano()),
+		File:        name,
+		ContentType: format.ContentType,
+		Event:       exportEvent{Format: format.Name, File: name, Channels: ids, From: from, To: to, RequestedBy: requestedBy(r)},
+		Prepare: func(ctx context.Context) (func(w io.Writer) error, error) {
+			fetchCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+			defer cancel()
+
+			series := make([]channels.Series, len(ids))
+			total := 0
+			for i, c := range ids {
+				var err error
+				if series[i], err = channels.Fetch(fetchCtx, queries, c, from, to); err != nil {
+					return nil, err
+				}
+				if total += len(series[i].Times); total > maxChannelExportSamples {
+					return nil, fmt.Errorf("%w: more than %d samples; narrow the range or select fewer channels", errExportTooLarge, maxChannelExportSamples)
+				}
+			}
+			ds := exporters.Dataset{Name: base, Columns: ids, Rows: mergeSeries(series)}
+			return func(w io.Writer) error { return exporter.Export(ctx, w, ds) }, nil
+		},
+	}, nil
+}
+
+// mergeSeries returns a row reader over the series merged in time order. Each
+// row holds the samples of every series at its time, NaN for the others.
+func mergeSeries(series []channels.Series) func(ctx context.Context, fn func(t time.Time, values []float64) error) error {
+	return func(ctx context.Context, fn func(t time.Time, values []float64) error) error {
+		next := make([]int, len(series))
+		values := make([]float64, len(series))
+		for n := 0; ; n++ {
+			if n%65536 == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			var t time.Time
+			found := false
+			for i, s := range series {
+				if next[i] < len(s.Times) && (!found || s.Times[next[i]].Before(t)) {
+					t, found = s.Times[next[i]], true
+				}
+			}
+			if !found {
+				return nil
+			}
+			for i, s := range series {
+				values[i] = math.NaN()
+				if next[i] < len(s.Times) && s.Times[next[i]].Equal(t) {
+					values[i] = s.Values[next[i]]
+					next[i]++
+				}
+			}
+			if err := fn(t, values); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// competition.go
+//
+// Competition mode endpoints: the status for every client (dashboards show a
+// banner), the admin switch, and the lock that rejects admin changes while the
+// mode is on.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"telem-system/internal/auth"
+	"telem-system/internal/wsserver"
+	"telem-system/pkg/competition"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// competitionPath is the switch, the one admin change allowed while locked.
+const competitionPath = "/api/admin/competition"
+
+// competitionRequest is the body of a competition mode switch.
+type competitionRequest struct {
+	Active *bool `json:"active" validate:"required"`
+}
+
+// registerCompetitionRoutes registers the competition mode endpoints.
+func registerCompetitionRoutes(r chi.Router) {
+	r.Get("/api/competition", competitionStatusHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Put(competitionPath, setCompetitionHandler)
+}
+
+// CompetitionLock rejects requests that change the server through the admin
+// endpoints with 423 while competition mode is on. Reads pass.
+func CompetitionLock(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if competition.Active() && strings.HasPrefix(r.URL.Path, "/api/admin/") && r.URL.Path != competitionPath {
+				render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusLocked, StatusText: "Locked in competition mode.", ErrorText: "admin changes are disabled in competition mode"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// competitionStatusHandler returns the competition mode status.
+func competitionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, competition.GetStatus())
+}
+
+// setCompetitionHandler turns competition mode on or off and returns its status,
+// which is also sent to the live control channel.
+// It responds 409 when the mode cannot be turned on.
+func setCompetitionHandler(w http.ResponseWriter, r *http.Request) {
+	var req competitionRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	status, err := competition.Set(*req.Active, requestedBy(r))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, competition.ErrAuthDisabled) || errors.Is(err, competition.ErrOpenRoute) ||
+			errors.Is(err, competition.ErrVersionDrift) {
+			code = http.StatusConflict
+		}
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: code, StatusText: "Competition mode not changed.", ErrorText: err.Error()})
+		return
+	}
+	wsserver.Notify("competition", status)
+	render.JSON(w, r, status)
+}
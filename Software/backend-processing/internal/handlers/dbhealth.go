@@ -0,0 +1,41 @@
+// dbhealth.go
+//
+// Admin storage health endpoint: table and index sizes, bloat estimates,
+// autovacuum status and connection utilization, so a growing index or a vacuum
+// falling behind is noticed before queries start timing out.
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Longest the statistics queries may take
+const dbHealthTimeout = 10 * time.Second
+
+// registerDBHealthRoutes registers the storage health endpoint.
+func registerDBHealthRoutes(r chi.Router, queries *db.Queries) {
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/dbhealth", dbHealthHandler(queries))
+}
+
+// dbHealthHandler returns the storage health report.
+func dbHealthHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		ctx, cancel := context.WithTimeout(r.Context(), dbHealthTimeout)
+		defer cancel()
+		h, err := queries.FetchDBHealth(ctx)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, h)
+	}
+}
@@ -0,0 +1,63 @@
+// decimate.go
+//
+// Decimated plotting endpoint: a single channel over a time range reduced to
+// M4 envelopes, one bucket per pixel column of the plot.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// decimateHandler serves /api/decimate. Query parameters: channel
+// ("table.column" or "derived.<name>"), from, to (RFC 3339) and width (pixel
+// width of the plot, i.e. the number of buckets). The envelope lists the data
+// gaps of the channel.
+func decimateHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		if !to.After(from) {
+			render.Render(w, r, ErrInvalidRequest(errors.New("to must be after from")))
+			return
+		}
+		params := r.URL.Query()
+		channel := params.Get("channel")
+		if channel == "" {
+			render.Render(w, r, ErrInvalidRequest(errors.New("channel is required")))
+			return
+		}
+		if err := channels.Validate(channel); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		width, err := strconv.Atoi(params.Get("width"))
+		if err != nil || width < 1 || width > channels.MaxDecimateWidth {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("width must be between 1 and %d", channels.MaxDecimateWidth)))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		env, err := channels.Decimate(ctx, queries, channel, from, to, width)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, env)
+	}
+}
@@ -0,0 +1,43 @@
+// definitions.go
+//
+// CAN definition linting: checks a definitions file before it is deployed, with
+// the same rules the server applies when loading one.
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/types"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Largest definitions file accepted for linting
+const maxDefinitionsBytes = 10 << 20
+
+// registerDefinitionRoutes registers the definition linting endpoint.
+func registerDefinitionRoutes(r chi.Router) {
+	r.Post("/api/definitions/lint", lintDefinitionsHandler)
+}
+
+// lintDefinitionsHandler lints the definitions JSON in the body (the format of
+// json_file) and returns every problem with its level. The strictness query
+// parameter ("lenient", the default, or "strict") decides whether warnings make
+// the definitions invalid. Problems do not change the status code; the body is
+// rejected with 400 only when it is not a definitions file.
+func lintDefinitionsHandler(w http.ResponseWriter, r *http.Request) {
+	var messages []types.Message
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDefinitionsBytes)).Decode(&messages); err != nil {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid definitions JSON: %w", err)))
+		return
+	}
+	report, err := candecoder.Lint(messages, r.URL.Query().Get("strictness"))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, report)
+}
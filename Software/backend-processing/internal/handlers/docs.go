@@ -0,0 +1,39 @@
+// docs.go
+//
+// Serves the generated telemetry reference (see package docs and cmd/docsgen)
+// with the CAN definitions the server loaded.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"telem-system/internal/docs"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerDocsRoutes registers the reference endpoint.
+func registerDocsRoutes(r chi.Router) {
+	r.Get("/api/docs", docsHandler)
+}
+
+// docsHandler serves the reference as HTML, or as Markdown with format=md.
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "html":
+		page, err := docs.HTML()
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	case "md", "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write(docs.Markdown())
+	default:
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("unsupported format %q: expected html or md", format)))
+	}
+}
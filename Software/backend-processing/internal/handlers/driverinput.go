@@ -0,0 +1,65 @@
+// driverinput.go
+//
+// Steering wheel input endpoints: the event history and the input states at a
+// point in time.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"telem-system/pkg/db"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerDriverInputRoutes registers the steering wheel input endpoints.
+func registerDriverInputRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/driverInputs", makePaginatedHandler(queries.ScanDriverInputEventsPaginated))
+	r.Get("/api/driverInputs/history", driverInputHistoryHandler(queries))
+	r.Get("/api/driverInputs/state", driverInputStateHandler(queries))
+}
+
+// driverInputHistoryHandler returns the input changes over a time range. Query
+// parameters: from, to (RFC 3339) and input (optional signal name).
+func driverInputHistoryHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		data, err := queries.FetchDriverInputHistory(r.Context(), r.URL.Query().Get("input"), from, to)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, data)
+	}
+}
+
+// driverInputStateHandler returns the state of every input at a point in time.
+// Query parameters: at (RFC 3339, default now).
+func driverInputStateHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		at := time.Now()
+		if s := r.URL.Query().Get("at"); s != "" {
+			var err error
+			if at, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid at: %w", err)))
+				return
+			}
+		}
+		data, err := queries.FetchDriverInputStates(r.Context(), at)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, data)
+	}
+}
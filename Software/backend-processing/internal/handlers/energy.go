@@ -0,0 +1,59 @@
+// energy.go
+//
+// Energy endpoint: pack energy split into drive and regen over a time range or a
+// session.
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// energyHandler serves /api/energy. Query parameters: session (session ID; the
+// range defaults to the whole session) or from, to (RFC 3339).
+func energyHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		var from, to time.Time
+		if s := r.URL.Query().Get("session"); s != "" {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid session %q", s)))
+				return
+			}
+			session, err := queries.FetchSession(r.Context(), id)
+			if err != nil {
+				render.Render(w, r, ErrRender(err))
+				return
+			}
+			from, to = session.StartedAt, time.Now()
+			if session.EndedAt != nil {
+				to = *session.EndedAt
+			}
+		} else {
+			var err error
+			if from, to, err = parseTimeRange(r); err != nil {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		split, err := channels.EnergySplitBetween(ctx, queries, from, to)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, split)
+	}
+}
@@ -0,0 +1,319 @@
+// export.go
+//
+// Data export and annotation endpoints. Telemetry tables can be exported in any
+// registered format (see package exporters) with the alerts and annotations of
+// the same period either embedded as a "markers" companion column (formats that
+// support it) or written to a sidecar CSV (both files zipped), so external tools
+// show the same context as the web UI.
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"telem-system/pkg/exporters"
+	"telem-system/pkg/types"
+	"telem-system/pkg/webhooks"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Marker placement modes
+const (
+	markersNone    = "none"
+	markersChannel = "channel"
+	markersSidecar = "sidecar"
+)
+
+// annotationRequest is the body of a new annotation. StartedAt defaults to the
+// current time.
+type annotationRequest struct {
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Text      string     `json:"text" validate:"required,max=2000"`
+}
+
+// registerExportRoutes registers the export, export format, export job, alert
+// history and annotation endpoints.
+func registerExportRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/export/formats", exportFormatsHandler)
+	r.Get("/api/export/channels", channelExportHandler(queries))
+	r.Get("/api/export/rosbag", rosbagExportHandler(queries))
+	registerExportJobRoutes(r, queries)
+	r.Get("/api/export/{table}", exportHandler(queries))
+	r.Get("/api/alerts/history", makePaginatedHandler(queries.ScanAlertHistoryPaginated))
+	r.Get("/api/annotations", makePaginatedHandler(queries.ScanAnnotationsPaginated))
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/annotations", createAnnotationHandler)
+}
+
+// createAnnotationHandler stores an annotation attributed to the caller.
+func createAnnotationHandler(w http.ResponseWriter, r *http.Request) {
+	var req annotationRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	a := types.Annotation{StartedAt: req.StartedAt, EndedAt: req.EndedAt, Text: req.Text}
+	if a.StartedAt.IsZero() {
+		a.StartedAt = time.Now()
+	}
+	if a.EndedAt != nil && a.EndedAt.Before(a.StartedAt) {
+		render.Render(w, r, ErrInvalidRequest(errors.New("ended_at is before started_at")))
+		return
+	}
+	if p, ok := auth.FromContext(r.Context()); ok {
+		a.Author = p.Name
+		if a.Author == "" {
+			a.Author = p.Subject
+		}
+	}
+	id, err := db.InsertAnnotation(r.Context(), a)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	a.ID = id
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, a)
+}
+
+// parseTimeRange reads the required from and optional to (default now) query
+// parameters as RFC 3339 timestamps.
+func parseTimeRange(r *http.Request) (from, to time.Time, err error) {
+	q := r.URL.Query()
+	if q.Get("from") == "" {
+		return from, to, errors.New("from is required")
+	}
+	if from, err = time.Parse(time.RFC3339Nano, q.Get("from")); err != nil {
+		return from, to, fmt.Errorf("invalid from: %w", err)
+	}
+	to = time.Now()
+	if q.Get("to") != "" {
+		if to, err = time.Parse(time.RFC3339Nano, q.Get("to")); err != nil {
+			return from, to, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if to.Before(from) {
+		return from, to, errors.New("to is before from")
+	}
+	return from, to, nil
+}
+
+// exportPlan is a validated export request: the file it produces and how to
+// produce it.
+type exportPlan struct {
+	Key         string // Identifies the request for the export cache
+	File        string
+	ContentType string
+	Event       exportEvent
+
+	// Prepare loads what the export needs before anything is written and
+	// returns the function writing the file. Errors wrapping errExportTooLarge
+	// are the client's to fix.
+	Prepare func(ctx context.Context) (func(w io.Writer) error, error)
+}
+
+// errExportTooLarge marks exports exceeding a size limit.
+var errExportTooLarge = errors.New("export too large")
+
+// exportHandler streams a telemetry table over a time range. Query parameters:
+// from, to (RFC 3339), format (see /api/export/formats, default "csv") and
+// markers ("none", "channel" or "sidecar").
+func exportHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan, err := planTableExport(r, queries, chi.URLParam(r, "table"))
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		serveExport(w, r, plan)
+	}
+}
+
+// exportFormatsHandler lists the export formats and their capabilities.
+func exportFormatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	render.JSON(w, r, map[string]interface{}{"formats": exporters.Formats()})
+}
+
+// serveExport prepares an export and streams it as the response.
+func serveExport(w http.ResponseWriter, r *http.Request, plan exportPlan) {
+	write, err := plan.Prepare(r.Context())
+	if err != nil {
+		if errors.Is(err, errExportTooLarge) {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	// Headers are sent before the first byte, so errors after this point can
+	// only abort the download
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", plan.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", plan.File))
+	if err := write(w); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	webhooks.Fire(webhooks.EventExportCompleted, plan.Event)
+}
+
+// lookupExporter returns the exporter of the format query parameter, or of def
+// when it is empty.
+func lookupExporter(r *http.Request, def string) (exporters.Exporter, error) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = def
+	}
+	e, ok := exporters.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unsupported export format %q (see /api/export/formats)", name)
+	}
+	return e, nil
+}
+
+// planTableExport validates a table export request.
+func planTableExport(r *http.Request, queries *db.Queries, table string) (exportPlan, error) {
+	spec, ok := db.LookupTable(table)
+	if !ok {
+		return exportPlan{}, fmt.Errorf("unknown table %q", table)
+	}
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		return exportPlan{}, err
+	}
+	exporter, err := lookupExporter(r, "csv")
+	if err != nil {
+		return exportPlan{}, err
+	}
+	format := exporter.Format()
+	mode := r.URL.Query().Get("markers")
+	if mode == "" {
+		mode = markersNone
+	}
+	if mode != markersNone && mode != markersChannel && mode != markersSidecar {
+		return exportPlan{}, fmt.Errorf("invalid markers mode %q", mode)
+	}
+	if mode == markersChannel && !format.MarkerColumn {
+		return exportPlan{}, fmt.Errorf("format %s has no markers column; use markers=sidecar", format.Name)
+	}
+
+	base := fmt.Sprintf("%s_%s", spec.Name, from.UTC().Format("20060102T150405Z"))
+	plan := exportPlan{
+		Key:         fmt.Sprintf("%s|%s|%d|%d|%s", format.Name, spec.Name, from.UnixNano(), to.UnixNano(), mode),
+		File:        base + format.Extension,
+		ContentType: format.ContentType,
+	}
+	if mode == markersSidecar {
+		plan.File, plan.ContentType = base+".zip", "application/zip"
+	}
+	plan.Event = exportEvent{Format: format.Name, File: plan.File, Table: spec.Name, From: from, To: to, RequestedBy: requestedBy(r)}
+	plan.Prepare = func(ctx context.Context) (func(w io.Writer) error, error) {
+		var markers []types.Marker
+		if mode != markersNone {
+			var err error
+			if markers, err = queries.FetchMarkers(ctx, from, to); err != nil {
+				return nil, err
+			}
+		}
+		columns := make([]string, len(spec.Columns))
+		for i, c := range spec.Columns {
+			columns[i] = c.Name
+		}
+		ds := exporters.Dataset{
+			Name:    base,
+			Table:   spec.Name,
+			Columns: columns,
+			Rows: func(ctx context.Context, fn func(t time.Time, values []float64) error) error {
+				return queries.ExportRows(ctx, spec.Name, from, to, fn)
+			},
+		}
+		return func(w io.Writer) error {
+			switch mode {
+			case markersSidecar:
+				return writeSidecarZip(ctx, exporter, ds, markers, w)
+			case markersChannel:
+				ds.Markers, ds.MarkerColumn = markers, true
+			}
+			return exporter.Export(ctx, w, ds)
+		}, nil
+	}
+	return plan, nil
+}
+
+// exportEvent is the data of an export.completed webhook.
+type exportEvent struct {
+	Format      string    `json:"format"`
+	File        string    `json:"file"`
+	Table       string    `json:"table,omitempty"`
+	Channels    []string  `json:"channels,omitempty"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+}
+
+// requestedBy names the caller of r for the webhook.
+func requestedBy(r *http.Request) string {
+	p, ok := auth.FromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Subject
+}
+
+// writeSidecarZip writes the export and the markers sidecar CSV into a zip
+// archive.
+func writeSidecarZip(ctx context.Context, exporter exporters.Exporter, ds exporters.Dataset,
+	markers []types.Marker, out io.Writer) error {
+	zw := zip.NewWriter(out)
+	data, err := zw.Create(ds.Name + exporter.Format().Extension)
+	if err != nil {
+		return err
+	}
+	if err := exporter.Export(ctx, data, ds); err != nil {
+		return err
+	}
+	side, err := zw.Create(ds.Name + "_markers.csv")
+	if err != nil {
+		return err
+	}
+	if err := writeMarkersCSV(markers, side); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeMarkersCSV writes the markers sidecar file.
+func writeMarkersCSV(markers []types.Marker, out io.Writer) error {
+	cw := csv.NewWriter(out)
+	if err := cw.Write([]string{"start", "end", "kind", "severity", "label", "text"}); err != nil {
+		return err
+	}
+	for _, m := range markers {
+		end := ""
+		if m.End != nil {
+			end = m.End.UTC().Format(time.RFC3339Nano)
+		}
+		if err := cw.Write([]string{m.Time.UTC().Format(time.RFC3339Nano), end, m.Kind, m.Severity, m.Label, m.Text}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
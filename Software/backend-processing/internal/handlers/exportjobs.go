@@ -0,0 +1,131 @@
+// exportjobs.go
+//
+// Background export jobs. Instead of streaming a long export in one response, a
+// client starts a job with the same parameters as /api/export/{table},
+// /api/export/channels or /api/export/rosbag, polls it until it is ready and
+// downloads the cached file. Downloads support range requests, so an interrupted
+// transfer resumes where it stopped instead of generating the export again.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"telem-system/pkg/exportcache"
+	"telem-system/pkg/webhooks"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerExportJobRoutes registers the background export endpoints.
+func registerExportJobRoutes(r chi.Router, queries *db.Queries) {
+	// Jobs run heavy queries in the background, so starting one takes an operator
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/export/jobs", startExportJobHandler(queries))
+	r.Get("/api/export/jobs", exportJobsHandler)
+	r.Get("/api/export/jobs/{id}", exportJobHandler)
+	r.Get("/api/export/jobs/{id}/download", exportDownloadHandler)
+}
+
+// startExportJobHandler starts (or reuses) a background export and responds 202
+// with the job. Query parameters: table (a telemetry table, "channels" or
+// "rosbag") and the parameters of the corresponding export endpoint.
+func startExportJobHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		var plan exportPlan
+		var err error
+		switch table := r.URL.Query().Get("table"); table {
+		case "":
+			err = errors.New("table is required")
+		case "rosbag":
+			plan, err = planChannelExport(r, queries, "rosbag")
+		case "channels":
+			plan, err = planChannelExport(r, queries, "csv")
+		default:
+			plan, err = planTableExport(r, queries, table)
+		}
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+
+		job, err := exportcache.Start(plan.Key, plan.File, plan.ContentType, func(ctx context.Context, w io.Writer) error {
+			write, err := plan.Prepare(ctx)
+			if err != nil {
+				return err
+			}
+			if err := write(w); err != nil {
+				return err
+			}
+			webhooks.Fire(webhooks.EventExportCompleted, plan.Event)
+			return nil
+		})
+		if errors.Is(err, exportcache.ErrDisabled) {
+			render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusServiceUnavailable, StatusText: "Export jobs unavailable.", ErrorText: err.Error()})
+			return
+		}
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		w.Header().Set("Location", "/api/export/jobs/"+job.ID)
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, job)
+	}
+}
+
+// exportJobsHandler lists the export jobs, newest first.
+func exportJobsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, exportcache.Jobs())
+}
+
+// exportJobHandler returns the status of an export job.
+func exportJobHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-store")
+	job, ok := exportcache.Get(chi.URLParam(r, "id"))
+	if !ok {
+		render.Render(w, r, errExportJobNotFound(chi.URLParam(r, "id")))
+		return
+	}
+	render.JSON(w, r, job)
+}
+
+// exportDownloadHandler serves the file of a finished export job, honouring
+// Range and If-Range so interrupted downloads can resume. It responds 409 while
+// the job is pending or failed.
+func exportDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	id := chi.URLParam(r, "id")
+	f, job, err := exportcache.Open(id)
+	switch {
+	case errors.Is(err, exportcache.ErrNotFound):
+		render.Render(w, r, errExportJobNotFound(id))
+		return
+	case errors.Is(err, exportcache.ErrNotReady):
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusConflict, StatusText: "Export not ready.", ErrorText: fmt.Sprintf("export job %s is %s", id, job.Status)})
+		return
+	case err != nil:
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", job.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.File))
+	w.Header().Set("ETag", fmt.Sprintf("%q", job.ID))
+	http.ServeContent(w, r, job.File, *job.FinishedAt, f)
+}
+
+// errExportJobNotFound is the response for unknown or expired jobs.
+func errExportJobNotFound(id string) render.Renderer {
+	return &ErrResponse{HTTPStatusCode: http.StatusNotFound, StatusText: "Export job not found.", ErrorText: fmt.Sprintf("no export job %s (it may have expired)", id)}
+}
@@ -0,0 +1,56 @@
+// features.go
+//
+// Admin endpoints of the runtime feature flags: list the flags with their state
+// and switch a flag without redeploying.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/features"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// featureRequest is the body of a flag change.
+type featureRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// registerFeatureRoutes registers the feature flag endpoints.
+func registerFeatureRoutes(r chi.Router) {
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/features", featuresHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Put("/api/admin/features/{name}", setFeatureHandler)
+}
+
+// featuresHandler lists the feature flags.
+func featuresHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, map[string]interface{}{"features": features.States()})
+}
+
+// setFeatureHandler switches a feature flag and returns its state. It responds
+// 404 for unknown flags.
+func setFeatureHandler(w http.ResponseWriter, r *http.Request) {
+	var req featureRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	state, err := features.Set(chi.URLParam(r, "name"), *req.Enabled, requestedBy(r))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, features.ErrUnknownFlag) {
+			status = http.StatusNotFound
+		}
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: status, StatusText: "Feature flag not changed.", ErrorText: err.Error()})
+		return
+	}
+	render.JSON(w, r, state)
+}
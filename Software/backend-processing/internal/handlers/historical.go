@@ -8,9 +8,11 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"sync"
+	"telem-system/pkg/channels"
 	"telem-system/pkg/db"
 	"time"
 
@@ -197,8 +199,11 @@ var (
 	cacheTTL         = 2 * time.Second // Short TTL for real-time data
 )
 
+// pageScanner runs a paginated query and calls fn for each row as it is scanned.
+type pageScanner[T any] func(ctx context.Context, limit, offset int, fn func(T) error) error
+
 // makePaginatedHandler creates a generic HTTP handler for paginated queries.
-func makePaginatedHandler[T any](fetchFunc func(ctx context.Context, limit, offset int) ([]T, error)) http.HandlerFunc {
+func makePaginatedHandler[T any](scanPage pageScanner[T]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS header (adjust in production as needed)
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -223,6 +228,24 @@ func makePaginatedHandler[T any](fetchFunc func(ctx context.Context, limit, offs
 			return
 		}
 
+		// Optional field transforms (see transform.go)
+		transforms, err := parseTransforms(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+
+		// Large pages and ndjson are streamed instead (see stream.go)
+		lines := wantsNDJSON(r)
+		if lines && len(transforms) > 0 {
+			render.Render(w, r, ErrInvalidRequest(errors.New("transforms are not supported with ndjson")))
+			return
+		}
+		if lines || (limit > streamMinRows && len(transforms) == 0) {
+			streamPage(w, r, scanPage, limit, offset, lines)
+			return
+		}
+
 		// Create a cache key for this specific request
 		cacheKey := r.URL.Path + "?" + r.URL.Query().Encode()
 
@@ -242,12 +265,25 @@ func makePaginatedHandler[T any](fetchFunc func(ctx context.Context, limit, offs
 		defer cancel()
 
 		// Fetch data from database
-		data, err := fetchFunc(ctx, limit, offset)
+		var data []T
+		err = scanPage(ctx, limit, offset, func(row T) error {
+			data = append(data, row)
+			return nil
+		})
 		if err != nil {
 			render.Render(w, r, ErrRender(err))
 			return
 		}
 
+		// Apply transforms before caching, the cache key includes them
+		var result interface{} = data
+		if len(transforms) > 0 {
+			if result, err = applyTransforms(data, transforms); err != nil {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+		}
+
 		// Cache the result
 		resultCacheMutex.Lock()
 		// Ensure cache doesn't grow too large (simple eviction strategy)
@@ -256,46 +292,139 @@ func makePaginatedHandler[T any](fetchFunc func(ctx context.Context, limit, offs
 			resultCache = make(map[string]resultCacheEntry)
 		}
 		resultCache[cacheKey] = resultCacheEntry{
-			data:       data,
+			data:       result,
 			expiration: time.Now().Add(cacheTTL),
 		}
 		resultCacheMutex.Unlock()
 
 		// Return the data
-		render.JSON(w, r, data)
+		render.JSON(w, r, result)
 	}
 }
 
 // RegisterRoutes registers all telemetry API endpoints.
 func RegisterRoutes(r chi.Router, queries *db.Queries) {
-	r.Get("/api/tcuData", makePaginatedHandler(queries.FetchTCUDataPaginated))
-	r.Get("/api/cellData", makePaginatedHandler(queries.FetchCellDataPaginated))
-	r.Get("/api/thermData", makePaginatedHandler(queries.FetchThermDataPaginated))
-	r.Get("/api/bamocarData", makePaginatedHandler(queries.FetchBamocarDataPaginated))
-	r.Get("/api/bamocarTxData", makePaginatedHandler(queries.FetchBamocarTxDataPaginated))
-	r.Get("/api/bamoCarReTransmitData", makePaginatedHandler(queries.FetchBamoCarReTransmitDataPaginated))
-	r.Get("/api/encoderData", makePaginatedHandler(queries.FetchEncoderDataPaginated))
-	r.Get("/api/packCurrentData", makePaginatedHandler(queries.FetchPackCurrentDataPaginated))
-	r.Get("/api/packVoltageData", makePaginatedHandler(queries.FetchPackVoltageDataPaginated))
-	r.Get("/api/pdmCurrentData", makePaginatedHandler(queries.FetchPDMCurrentDataPaginated))
-	r.Get("/api/pdmReTransmitData", makePaginatedHandler(queries.FetchPDMReTransmitDataPaginated))
-	r.Get("/api/insGPSData", makePaginatedHandler(queries.FetchINSGPSDataPaginated))
-	r.Get("/api/insIMUData", makePaginatedHandler(queries.FetchINSIMUDataPaginated))
-	r.Get("/api/frontFrequencyData", makePaginatedHandler(queries.FetchFrontFrequencyDataPaginated))
-	r.Get("/api/frontStrainGauges1Data", makePaginatedHandler(queries.FetchFrontStrainGauges1DataPaginated))
-	r.Get("/api/frontStrainGauges2Data", makePaginatedHandler(queries.FetchFrontStrainGauges2DataPaginated))
-	r.Get("/api/rearStrainGauges1Data", makePaginatedHandler(queries.FetchRearStrainGauges1DataPaginated))
-	r.Get("/api/rearStrainGauges2Data", makePaginatedHandler(queries.FetchRearStrainGauges2DataPaginated))
-	r.Get("/api/rearAnalogData", makePaginatedHandler(queries.FetchRearAnalogDataPaginated))
-	r.Get("/api/rearAeroData", makePaginatedHandler(queries.FetchRearAeroDataPaginated))
-	r.Get("/api/frontAeroData", makePaginatedHandler(queries.FetchFrontAeroDataPaginated))
-	r.Get("/api/gpsBestPosData", makePaginatedHandler(queries.FetchGPSBestPosDataPaginated))
-	r.Get("/api/rearFrequencyData", makePaginatedHandler(queries.FetchRearFrequencyDataPaginated))
-	r.Get("/api/aculvFd1Data", makePaginatedHandler(queries.FetchACULVFD1DataPaginated))
-	r.Get("/api/aculvFd2Data", makePaginatedHandler(queries.FetchACULVFD2DataPaginated))
-	r.Get("/api/aculv1Data", makePaginatedHandler(queries.FetchACULV1DataPaginated))
-	r.Get("/api/aculv2Data", makePaginatedHandler(queries.FetchACULV2DataPaginated))
-	r.Get("/api/pdm1Data", makePaginatedHandler(queries.FetchPDM1DataPaginated))
-	r.Get("/api/bamocarRxData", makePaginatedHandler(queries.FetchBamocarRxDataPaginated))
-	r.Get("/api/frontAnalogData", makePaginatedHandler(queries.FetchFrontAnalogDataPaginated))
+	r.Get("/api/tcuData", makePaginatedHandler(queries.ScanTCUDataPaginated))
+	r.Get("/api/cellData", makePaginatedHandler(queries.ScanCellDataPaginated))
+	r.Get("/api/thermData", makePaginatedHandler(queries.ScanThermDataPaginated))
+	r.Get("/api/bamocarData", makePaginatedHandler(queries.ScanBamocarDataPaginated))
+	r.Get("/api/bamocarTxData", makePaginatedHandler(queries.ScanBamocarTxDataPaginated))
+	r.Get("/api/bamoCarReTransmitData", makePaginatedHandler(queries.ScanBamoCarReTransmitDataPaginated))
+	r.Get("/api/encoderData", makePaginatedHandler(queries.ScanEncoderDataPaginated))
+	r.Get("/api/packCurrentData", makePaginatedHandler(queries.ScanPackCurrentDataPaginated))
+	r.Get("/api/packVoltageData", makePaginatedHandler(queries.ScanPackVoltageDataPaginated))
+	r.Get("/api/pdmCurrentData", makePaginatedHandler(queries.ScanPDMCurrentDataPaginated))
+	r.Get("/api/pdmReTransmitData", makePaginatedHandler(queries.ScanPDMReTransmitDataPaginated))
+	r.Get("/api/insGPSData", makePaginatedHandler(queries.ScanINSGPSDataPaginated))
+	r.Get("/api/insIMUData", makePaginatedHandler(queries.ScanINSIMUDataPaginated))
+	r.Get("/api/frontFrequencyData", makePaginatedHandler(queries.ScanFrontFrequencyDataPaginated))
+	r.Get("/api/frontStrainGauges1Data", makePaginatedHandler(queries.ScanFrontStrainGauges1DataPaginated))
+	r.Get("/api/frontStrainGauges2Data", makePaginatedHandler(queries.ScanFrontStrainGauges2DataPaginated))
+	r.Get("/api/rearStrainGauges1Data", makePaginatedHandler(queries.ScanRearStrainGauges1DataPaginated))
+	r.Get("/api/rearStrainGauges2Data", makePaginatedHandler(queries.ScanRearStrainGauges2DataPaginated))
+	r.Get("/api/rearAnalogData", makePaginatedHandler(queries.ScanRearAnalogDataPaginated))
+	r.Get("/api/rearAeroData", makePaginatedHandler(queries.ScanRearAeroDataPaginated))
+	r.Get("/api/frontAeroData", makePaginatedHandler(queries.ScanFrontAeroDataPaginated))
+	r.Get("/api/gpsBestPosData", makePaginatedHandler(queries.ScanGPSBestPosDataPaginated))
+	r.Get("/api/rearFrequencyData", makePaginatedHandler(queries.ScanRearFrequencyDataPaginated))
+	r.Get("/api/aculvFd1Data", makePaginatedHandler(queries.ScanACULVFD1DataPaginated))
+	r.Get("/api/aculvFd2Data", makePaginatedHandler(queries.ScanACULVFD2DataPaginated))
+	r.Get("/api/aculv1Data", makePaginatedHandler(queries.ScanACULV1DataPaginated))
+	r.Get("/api/aculv2Data", makePaginatedHandler(queries.ScanACULV2DataPaginated))
+	r.Get("/api/pdm1Data", makePaginatedHandler(queries.ScanPDM1DataPaginated))
+	r.Get("/api/bamocarRxData", makePaginatedHandler(queries.ScanBamocarRxDataPaginated))
+	r.Get("/api/frontAnalogData", makePaginatedHandler(queries.ScanFrontAnalogDataPaginated))
+	r.Get("/api/busLoadData", makePaginatedHandler(queries.ScanBusLoadDataPaginated))
+	r.Get("/api/serverMetrics", makePaginatedHandler(queries.ScanServerMetricsPaginated))
+	r.Get("/api/prechargeAttempts", makePaginatedHandler(queries.ScanPrechargeAttemptsPaginated))
+	r.Get("/api/resistanceEstimates", makePaginatedHandler(queries.ScanResistanceEstimatesPaginated))
+
+	// Runtime statistics
+	r.Get("/api/stats", statsHandler)
+	r.Get("/api/alerts", alertsHandler)
+	r.Get("/api/alerts/rules", alertRulesHandler)
+	r.Get("/api/auth/whoami", whoamiHandler)
+
+	// Sessions
+	registerSessionRoutes(r, queries)
+
+	// Pre-run readiness and calibrations
+	registerReadinessRoutes(r, queries)
+
+	// Export, alert history and annotations
+	registerExportRoutes(r, queries)
+
+	// Multi-channel queries
+	r.Get("/api/query", queryHandler(queries))
+	r.Get("/api/derivative", calculusHandler(queries, channels.OpDerivative))
+	r.Get("/api/integral", calculusHandler(queries, channels.OpIntegral))
+	r.Get("/api/decimate", decimateHandler(queries))
+	r.Get("/api/snapshot", snapshotHandler(queries))
+	r.Get("/api/preview", previewHandler(queries))
+
+	// Drive/regen energy split
+	r.Get("/api/energy", energyHandler(queries))
+
+	// Laps
+	registerLapRoutes(r, queries)
+
+	// Steering wheel inputs
+	registerDriverInputRoutes(r, queries)
+
+	// Accumulator segments
+	registerAccumulatorRoutes(r, queries)
+
+	// Pack cycle counts and state of health
+	registerPackHealthRoutes(r, queries)
+
+	// Shutdown circuit timeline
+	registerShutdownRoutes(r, queries)
+
+	// Consolidated vehicle state
+	registerVehicleStateRoutes(r, queries)
+
+	// Expiring read-only share links
+	registerShareRoutes(r, queries)
+
+	// Live channel registry
+	registerLiveChannelRoutes(r)
+
+	// Live subscription profiles
+	registerWSProfileRoutes(r, queries)
+
+	// Traction-control capture mode
+	registerTCCaptureRoutes(r, queries)
+
+	// Maintenance warm shutdown
+	registerMaintenanceRoutes(r)
+
+	// Full-rate IMU samples and bursts
+	registerIMURoutes(r, queries)
+
+	// End-of-day database backups
+	registerBackupRoutes(r, queries)
+
+	// Storage health
+	registerDBHealthRoutes(r, queries)
+
+	// Background job scheduler
+	registerJobRoutes(r)
+
+	// Configuration profiles
+	registerProfileRoutes(r)
+
+	// Runtime feature flags
+	registerFeatureRoutes(r)
+
+	// Competition mode status and switch
+	registerCompetitionRoutes(r)
+
+	// CAN definition linting
+	registerDefinitionRoutes(r)
+
+	// Generated telemetry reference
+	registerDocsRoutes(r)
+
+	// Embedded status page
+	registerStatusRoutes(r)
 }
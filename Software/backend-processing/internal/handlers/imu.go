@@ -0,0 +1,122 @@
+// imu.go
+//
+// Full-rate IMU endpoints: read the stored samples of a time range, list the
+// bursts kept around events with their samples, and record a burst on request.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"telem-system/pkg/processdata"
+	"telem-system/pkg/types"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// imuBurstRequest is the body of a burst request.
+type imuBurstRequest struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+// registerIMURoutes registers the IMU endpoints.
+func registerIMURoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/imu/samples", imuSamplesHandler(queries))
+	r.Get("/api/imu/bursts", makePaginatedHandler(queries.ScanIMUBurstsPaginated))
+	r.Get("/api/imu/bursts/{id}/samples", imuBurstSamplesHandler(queries))
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/imu/bursts", triggerIMUBurstHandler)
+}
+
+// imuSensor reads the sensor query parameter (default accel).
+func imuSensor(r *http.Request) (string, error) {
+	switch s := r.URL.Query().Get("sensor"); s {
+	case "", processdata.IMUSensorAccel:
+		return processdata.IMUSensorAccel, nil
+	case processdata.IMUSensorGyro:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid sensor %q (accel or gyro)", s)
+	}
+}
+
+// imuSamplesHandler returns the full-rate samples of a sensor between from and
+// to, paginated.
+func imuSamplesHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sensor, err := imuSensor(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		serveIMUSamples(w, r, queries, sensor, from, to)
+	}
+}
+
+// imuBurstSamplesHandler returns the full-rate samples of a sensor within a
+// burst window, paginated.
+func imuBurstSamplesHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		sensor, err := imuSensor(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		b, err := queries.FetchIMUBurst(r.Context(), id)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		serveIMUSamples(w, r, queries, sensor, b.From, b.To)
+	}
+}
+
+// serveIMUSamples responds with a page of the samples of sensor in [from, to].
+func serveIMUSamples(w http.ResponseWriter, r *http.Request, queries *db.Queries, sensor string, from, to time.Time) {
+	makePaginatedHandler(func(ctx context.Context, limit, offset int, fn func(types.IMUSample) error) error {
+		return queries.ScanIMUSamplesPaginated(ctx, sensor, from, to, limit, offset, fn)
+	})(w, r)
+}
+
+// triggerIMUBurstHandler records a burst around the current time attributed to
+// the caller. It responds 409 while a burst window is open or when no IMU frame
+// is configured.
+func triggerIMUBurstHandler(w http.ResponseWriter, r *http.Request) {
+	var req imuBurstRequest
+	if r.ContentLength != 0 {
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	b, err := processdata.TriggerIMUBurst(r.Context(), req.Note, requestedBy(r))
+	if errors.Is(err, processdata.ErrIMUBurstOpen) || errors.Is(err, processdata.ErrIMUDisabled) {
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusConflict, StatusText: "Burst not recorded.", ErrorText: err.Error()})
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, b)
+}
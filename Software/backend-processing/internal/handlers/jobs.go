@@ -0,0 +1,46 @@
+// jobs.go
+//
+// Admin endpoints of the background job scheduler: list the registered jobs with
+// their schedule and last run, and trigger a job outside its schedule.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/scheduler"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerJobRoutes registers the job scheduler endpoints.
+func registerJobRoutes(r chi.Router) {
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/jobs", jobsHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Post("/api/admin/jobs/{name}/run", triggerJobHandler)
+}
+
+// jobsHandler lists the registered jobs.
+func jobsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, map[string]interface{}{"jobs": scheduler.Jobs()})
+}
+
+// triggerJobHandler starts a job immediately. It responds 202 once the run has
+// started, 404 for unknown jobs and 409 when the job is already running.
+func triggerJobHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := scheduler.Trigger(name); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, scheduler.ErrUnknownJob):
+			status = http.StatusNotFound
+		case errors.Is(err, scheduler.ErrJobRunning):
+			status = http.StatusConflict
+		}
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: status, StatusText: "Job not started.", ErrorText: err.Error()})
+		return
+	}
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, map[string]string{"job": name, "status": scheduler.StatusRunning})
+}
@@ -0,0 +1,89 @@
+// laps.go
+//
+// Lap endpoints: the sortable lap summary list (run sheet) and manual lap marks.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"telem-system/pkg/laps"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerLapRoutes registers the lap endpoints.
+func registerLapRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/laps", lapsHandler(queries))
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/laps/mark", markLapHandler)
+}
+
+// lapsHandler returns lap summaries. Query parameters: sort (a lap_summary column,
+// default started_at), order ("asc" or "desc"), session (session ID) and the usual
+// pagination parameters.
+func lapsHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		limit, offset, err := parsePaginationParams(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		q := r.URL.Query()
+		sortBy := q.Get("sort")
+		if sortBy == "" {
+			sortBy = "started_at"
+		}
+		if !db.LapSortColumns[sortBy] {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("cannot sort by %q", sortBy)))
+			return
+		}
+		var desc bool
+		switch q.Get("order") {
+		case "", "asc":
+		case "desc":
+			desc = true
+		default:
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid order %q", q.Get("order"))))
+			return
+		}
+		var sessionID *int64
+		if s := q.Get("session"); s != "" {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid session %q", s)))
+				return
+			}
+			sessionID = &id
+		}
+
+		data, err := queries.FetchLaps(r.Context(), sessionID, sortBy, desc, limit, offset)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, data)
+	}
+}
+
+// markLapHandler records a manual start/finish crossing. The summary of the
+// completed lap is stored shortly afterwards.
+func markLapHandler(w http.ResponseWriter, r *http.Request) {
+	err := laps.Mark(time.Now())
+	if errors.Is(err, laps.ErrNoLapStarted) {
+		render.JSON(w, r, map[string]interface{}{"completed": false, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, map[string]interface{}{"completed": true})
+}
@@ -0,0 +1,24 @@
+// livechannels.go
+//
+// Serves the live channel registry, so clients can map the channel IDs, check
+// the schema versions of live messages and see which channels are stored.
+package handlers
+
+import (
+	"net/http"
+	"telem-system/pkg/livechannels"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerLiveChannelRoutes registers the live channel registry endpoint.
+func registerLiveChannelRoutes(r chi.Router) {
+	r.Get("/api/live-channels", liveChannelsHandler)
+}
+
+// liveChannelsHandler lists the registered live channels ordered by ID.
+func liveChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	render.JSON(w, r, map[string]interface{}{"channels": livechannels.All()})
+}
@@ -0,0 +1,87 @@
+// maintenance.go
+//
+// Admin endpoints of the maintenance warm shutdown: quiesce ingest, flush and
+// checkpoint before backing up or vacuuming the database, then resume.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/maintenance"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Longest a quiesce may wait for queued frames and batches unless requested otherwise
+const defaultQuiesceTimeout = 30 * time.Second
+
+// quiesceRequest is the optional body of a quiesce.
+type quiesceRequest struct {
+	TimeoutS float64 `json:"timeout_s" validate:"min=0,max=600"`
+}
+
+// registerMaintenanceRoutes registers the maintenance endpoints.
+func registerMaintenanceRoutes(r chi.Router) {
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/maintenance", maintenanceStatusHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Post("/api/admin/maintenance/quiesce", quiesceHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Post("/api/admin/maintenance/resume", resumeHandler)
+}
+
+// maintenanceStatusHandler returns the ingest state.
+func maintenanceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, maintenance.Status())
+}
+
+// quiesceHandler pauses ingest, flushes everything received and returns the
+// report, whose clean field tells whether it is safe to start maintenance. It
+// responds 409 when ingest is already quiesced.
+func quiesceHandler(w http.ResponseWriter, r *http.Request) {
+	var req quiesceRequest
+	if r.ContentLength != 0 {
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	timeout := defaultQuiesceTimeout
+	if req.TimeoutS > 0 {
+		timeout = time.Duration(req.TimeoutS * float64(time.Second))
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	report, err := maintenance.Quiesce(ctx, requestedBy(r))
+	if errors.Is(err, maintenance.ErrQuiesced) {
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusConflict, StatusText: "Already quiesced.", ErrorText: err.Error()})
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+// resumeHandler reopens ingest and returns the final report of the pause. It
+// responds 409 when ingest is not quiesced.
+func resumeHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := maintenance.Resume(requestedBy(r))
+	if errors.Is(err, maintenance.ErrNotQuiesced) {
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusConflict, StatusText: "Not quiesced.", ErrorText: err.Error()})
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.JSON(w, r, report)
+}
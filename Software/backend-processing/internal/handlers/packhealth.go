@@ -0,0 +1,63 @@
+// packhealth.go
+//
+// Pack health endpoints: the latest cycle count and state of health of every
+// pack, and the per-session history of one pack for end-of-season evaluation.
+package handlers
+
+import (
+	"net/http"
+	"telem-system/pkg/db"
+	"telem-system/pkg/packhealth"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerPackHealthRoutes registers the pack health endpoints.
+func registerPackHealthRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/packHealth", packHealthSummaryHandler(queries))
+	r.Get("/api/packHealth/{pack}", packHealthHistoryHandler(queries))
+}
+
+// packHealthSummaryHandler returns the newest record of every pack and the pack
+// currently installed.
+func packHealthSummaryHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		data, err := queries.FetchPackHealthSummary(r.Context())
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		cfg := packhealth.Settings()
+		resp := map[string]interface{}{
+			"installed_pack": cfg.PackID,
+			"packs":          data,
+		}
+		if cfg.NominalCapacityAh > 0 {
+			resp["nominal_capacity_ah"] = cfg.NominalCapacityAh
+		}
+		render.JSON(w, r, resp)
+	}
+}
+
+// packHealthHistoryHandler returns the per-session records of a pack, oldest
+// first, with the usual pagination parameters.
+func packHealthHistoryHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		limit, offset, err := parsePaginationParams(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		data, err := queries.FetchPackHealthHistory(r.Context(), chi.URLParam(r, "pack"), limit, offset)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, data)
+	}
+}
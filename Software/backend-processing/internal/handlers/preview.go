@@ -0,0 +1,62 @@
+// preview.go
+//
+// Derived channel preview: evaluates a candidate formula over a recorded time
+// range.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// previewHandler serves /api/preview. Query parameters: expr (the formula, e.g.
+// "pack_voltage.voltage * pack_current.current / 1000"), from, to (RFC 3339) and
+// step (grid step in milliseconds, default 100). It responds 400 with the
+// position of the error when the formula does not parse.
+func previewHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		params := r.URL.Query()
+		q := channels.PreviewQuery{Expression: params.Get("expr"), From: from, To: to}
+		if q.Expression == "" {
+			render.Render(w, r, ErrInvalidRequest(errors.New("expr is required")))
+			return
+		}
+		if s := params.Get("step"); s != "" {
+			ms, err := strconv.ParseFloat(s, 64)
+			if err != nil || ms <= 0 {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid step %q", s)))
+				return
+			}
+			q.Step = time.Duration(ms * float64(time.Millisecond))
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		res, err := channels.Preview(ctx, queries, q)
+		if err != nil {
+			if errors.Is(err, channels.ErrInvalidExpression) || errors.Is(err, channels.ErrTooManyPoints) {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,59 @@
+// profiles.go
+//
+// Configuration profile endpoints: show the active profile and switch profiles at
+// runtime.
+package handlers
+
+import (
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/profiles"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// profileRequest is the body of a profile switch. An empty name selects the base
+// settings.
+type profileRequest struct {
+	Name string `json:"name" validate:"max=100"`
+}
+
+// registerProfileRoutes registers the profile endpoints.
+func registerProfileRoutes(r chi.Router) {
+	r.Get("/api/profile", profileHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Post("/api/admin/profile", switchProfileHandler)
+}
+
+// profileHandler returns the active profile, its settings and the available
+// profiles.
+func profileHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-store")
+	name, settings := profiles.Active()
+	render.JSON(w, r, map[string]interface{}{
+		"active":    name,
+		"settings":  settings,
+		"available": profiles.Names(),
+	})
+}
+
+// switchProfileHandler activates a profile and returns the settings in effect.
+func switchProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var req profileRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	settings, err := profiles.Activate(req.Name)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	name, _ := profiles.Active()
+	render.JSON(w, r, map[string]interface{}{"active": name, "settings": settings})
+}
@@ -0,0 +1,93 @@
+// query.go
+//
+// Multi-channel query endpoint. Returns several channels resampled onto a common
+// time grid, or onto a distance grid for lap-to-lap overlay plots.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/federation"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// queryResponse is a query result with the origin of each portion of its range,
+// listed when a cloud mirror is configured.
+type queryResponse struct {
+	channels.Result
+	Sources []federation.Source `json:"sources,omitempty"`
+}
+
+// queryHandler serves /api/query. Query parameters: channels (comma-separated
+// "table.column" or "derived.<name>"), from, to (RFC 3339), domain ("time" or
+// "distance") and step (milliseconds in the time domain, metres in the distance
+// domain). The response lists the data gaps of every channel that has any.
+// Ranges reaching back past the locally stored data are completed from the cloud
+// mirror unless federate=false.
+func queryHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		q := channels.Query{From: from, To: to, Domain: r.URL.Query().Get("domain")}
+		for _, c := range strings.Split(r.URL.Query().Get("channels"), ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				q.Channels = append(q.Channels, c)
+			}
+		}
+		if len(q.Channels) == 0 {
+			render.Render(w, r, ErrInvalidRequest(errors.New("channels is required")))
+			return
+		}
+		for _, c := range q.Channels {
+			if err := channels.Validate(c); err != nil {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+		}
+		if q.Domain != "" && q.Domain != channels.DomainTime && q.Domain != channels.DomainDistance {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("unknown domain %q", q.Domain)))
+			return
+		}
+		if s := r.URL.Query().Get("step"); s != "" {
+			step, err := strconv.ParseFloat(s, 64)
+			if err != nil || step <= 0 {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid step %q", s)))
+				return
+			}
+			q.TimeStep = time.Duration(step * float64(time.Millisecond))
+			q.DistStep = step
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		var res queryResponse
+		if federation.Enabled() && r.URL.Query().Get("federate") != "false" {
+			res.Result, res.Sources, err = federation.Run(ctx, queries, q)
+		} else {
+			res.Result, err = channels.Run(ctx, queries, q)
+		}
+		if err != nil {
+			if errors.Is(err, channels.ErrTooManyPoints) || errors.Is(err, channels.ErrNoMotion) {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,89 @@
+// readiness.go
+//
+// Pre-run readiness endpoints: the composite go/no-go check and the calibration log
+// it reads.
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"telem-system/pkg/readiness"
+	"telem-system/pkg/types"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// calibrationRequest is the body of a calibration record. CalibratedAt defaults to
+// the current time.
+type calibrationRequest struct {
+	Name         string             `json:"name" validate:"required,max=100"`
+	CalibratedAt time.Time          `json:"calibrated_at"`
+	Notes        string             `json:"notes" validate:"max=2000"`
+	Params       map[string]float64 `json:"params"` // Parameters determined by the calibration
+}
+
+// registerReadinessRoutes registers the readiness and calibration endpoints.
+func registerReadinessRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/readiness/prerun", prerunHandler(queries))
+	r.Get("/api/calibrations", makePaginatedHandler(queries.ScanCalibrationsPaginated))
+	r.Get("/api/calibrations/latest", latestCalibrationsHandler(queries))
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/calibrations", recordCalibrationHandler)
+}
+
+// prerunHandler runs the pre-run checks. The response status is 200 for go and 503
+// for no-go so scripts can use it directly.
+func prerunHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		report := readiness.Evaluate(ctx, queries)
+		if report.Status != readiness.VerdictGo {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, report)
+	}
+}
+
+// latestCalibrationsHandler returns the most recent calibration of every name.
+func latestCalibrationsHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := queries.FetchLatestCalibrations(r.Context())
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, data)
+	}
+}
+
+// recordCalibrationHandler adds an entry to the calibration log.
+func recordCalibrationHandler(w http.ResponseWriter, r *http.Request) {
+	var req calibrationRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	c := types.Calibration{Name: req.Name, CalibratedAt: req.CalibratedAt, Notes: req.Notes, Params: req.Params}
+	if c.CalibratedAt.IsZero() {
+		c.CalibratedAt = time.Now()
+	}
+	id, err := db.InsertCalibration(r.Context(), c)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	c.ID = id
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, c)
+}
@@ -0,0 +1,100 @@
+// sessions.go
+//
+// Session endpoints: list sessions, inspect the open one and start/stop sessions
+// manually. Automatic start/stop is handled by the sessions package.
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"telem-system/pkg/sessions"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// sessionStartRequest is the optional body of a manual session start.
+type sessionStartRequest struct {
+	Name string `json:"name" validate:"max=200"`
+}
+
+// registerSessionRoutes registers the session endpoints.
+func registerSessionRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/sessions", makePaginatedHandler(queries.ScanSessionsPaginated))
+	r.Get("/api/sessions/current", currentSessionHandler)
+	r.Get("/api/sessions/{id}", sessionHandler(queries))
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/sessions/start", startSessionHandler)
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/sessions/stop", stopSessionHandler)
+}
+
+// currentSessionHandler returns the open session, or null if there is none.
+func currentSessionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	s, ok := sessions.Current()
+	if !ok {
+		render.JSON(w, r, nil)
+		return
+	}
+	render.JSON(w, r, s)
+}
+
+// sessionHandler returns a single session by ID, or 404 for an unknown ID.
+func sessionHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		s, err := queries.FetchSession(r.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusNotFound, StatusText: "Session not found.", ErrorText: fmt.Sprintf("no session %d", id)})
+			return
+		}
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, s)
+	}
+}
+
+// startSessionHandler manually opens a session, closing the open one first.
+func startSessionHandler(w http.ResponseWriter, r *http.Request) {
+	var req sessionStartRequest
+	if r.ContentLength > 0 {
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	s, err := sessions.Start(r.Context(), req.Name)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.JSON(w, r, s)
+}
+
+// stopSessionHandler manually closes the open session.
+func stopSessionHandler(w http.ResponseWriter, r *http.Request) {
+	s, err := sessions.Stop(r.Context())
+	if errors.Is(err, sessions.ErrNoSession) {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.JSON(w, r, s)
+}
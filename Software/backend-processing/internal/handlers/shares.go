@@ -0,0 +1,266 @@
+// shares.go
+//
+// Share link endpoints. Operators create expiring, read-only links scoped to a
+// session and a set of channels, list them and revoke them. Holders of a share
+// token can only call the /api/share/ endpoints, which serve the scoped channels
+// within the session.
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"telem-system/internal/auth"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	// Lifetime of a share link unless requested otherwise, and the longest allowed
+	defaultShareTTL = 72 * time.Hour
+	maxShareTTL     = 30 * 24 * time.Hour
+)
+
+// shareRequest is the body of a new share link.
+type shareRequest struct {
+	SessionID  int64    `json:"session_id" validate:"required,min=1"`
+	Channels   []string `json:"channels" validate:"required,min=1,max=100"`
+	ExpiresInH float64  `json:"expires_in_h" validate:"min=0"`
+	Note       string   `json:"note" validate:"max=500"`
+}
+
+// shareResponse is the created link with its token, which is not shown again.
+type shareResponse struct {
+	types.ShareLink
+	Token string `json:"token"`
+}
+
+// registerShareRoutes registers the share link management and access endpoints.
+func registerShareRoutes(r chi.Router, queries *db.Queries) {
+	r.With(auth.RequireRole(auth.RoleOperator)).Get("/api/shares", makePaginatedHandler(queries.ScanShareLinksPaginated))
+	r.With(auth.RequireRole(auth.RoleOperator)).Post("/api/shares", createShareHandler(queries))
+	r.With(auth.RequireRole(auth.RoleOperator)).Delete("/api/shares/{id}", revokeShareHandler)
+	r.Get(auth.SharePathPrefix+"info", shareInfoHandler(queries))
+	r.Get(auth.SharePathPrefix+"query", shareQueryHandler(queries))
+}
+
+// ShareLookup resolves share tokens for auth.WithShareLinks from the share_links
+// table.
+func ShareLookup(ctx context.Context, hash [sha256.Size]byte) (auth.ShareScope, bool, error) {
+	l, ok, err := db.FetchActiveShareLink(ctx, hash[:])
+	if err != nil || !ok {
+		return auth.ShareScope{}, false, err
+	}
+	return auth.ShareScope{LinkID: l.ID, SessionID: l.SessionID, Channels: l.Channels, ExpiresAt: l.ExpiresAt}, true, nil
+}
+
+// createShareHandler creates a share link attributed to the caller and returns
+// its token.
+func createShareHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req shareRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		ttl := defaultShareTTL
+		if req.ExpiresInH > 0 {
+			ttl = time.Duration(req.ExpiresInH * float64(time.Hour))
+		}
+		if ttl > maxShareTTL {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("expires_in_h exceeds %.0f", maxShareTTL.Hours())))
+			return
+		}
+		for i, c := range req.Channels {
+			c = strings.TrimSpace(c)
+			if err := channels.Validate(c); err != nil {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+			req.Channels[i] = c
+		}
+		if _, err := queries.FetchSession(r.Context(), req.SessionID); err != nil {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("unknown session %d", req.SessionID)))
+			return
+		}
+
+		token, hash, err := auth.NewShareToken()
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		now := time.Now()
+		l := types.ShareLink{
+			SessionID: req.SessionID,
+			Channels:  req.Channels,
+			Note:      req.Note,
+			CreatedAt: now,
+			ExpiresAt: now.Add(ttl),
+		}
+		if p, ok := auth.FromContext(r.Context()); ok {
+			l.CreatedBy = p.Name
+			if l.CreatedBy == "" {
+				l.CreatedBy = p.Subject
+			}
+		}
+		if l.ID, err = db.InsertShareLink(r.Context(), l, hash[:]); err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, shareResponse{ShareLink: l, Token: token})
+	}
+}
+
+// revokeShareHandler revokes a share link. It responds 404 for unknown or already
+// revoked links.
+func revokeShareHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	ok, err := db.RevokeShareLink(r.Context(), id)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	if !ok {
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusNotFound, StatusText: "Share link not found.", ErrorText: fmt.Sprintf("no active share link %d", id)})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// shareScope returns the share scope of the request, rendering 403 for callers
+// that did not authenticate with a share token.
+func shareScope(w http.ResponseWriter, r *http.Request) (*auth.ShareScope, bool) {
+	p, ok := auth.FromContext(r.Context())
+	if !ok || p.Share == nil {
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusForbidden, StatusText: "Forbidden.", ErrorText: "share token required"})
+		return nil, false
+	}
+	return p.Share, true
+}
+
+// shareInfoHandler returns the shared session, channels and expiry.
+func shareInfoHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Cache-Control", "no-store")
+
+		scope, ok := shareScope(w, r)
+		if !ok {
+			return
+		}
+		s, err := queries.FetchSession(r.Context(), scope.SessionID)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, map[string]interface{}{
+			"session":    s,
+			"channels":   scope.Channels,
+			"expires_at": scope.ExpiresAt,
+		})
+	}
+}
+
+// shareQueryHandler serves the shared channels resampled onto a common time grid,
+// like /api/query. Query parameters: channels (comma-separated subset of the
+// shared channels, default all), from, to (RFC 3339, clamped to the session,
+// default the whole session) and step (milliseconds).
+func shareQueryHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		scope, ok := shareScope(w, r)
+		if !ok {
+			return
+		}
+		s, err := queries.FetchSession(r.Context(), scope.SessionID)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+
+		q := channels.Query{From: s.StartedAt, To: time.Now(), Domain: channels.DomainTime}
+		if s.EndedAt != nil {
+			q.To = *s.EndedAt
+		}
+		params := r.URL.Query()
+		if v := params.Get("from"); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid from: %w", err)))
+				return
+			}
+			if t.After(q.From) {
+				q.From = t
+			}
+		}
+		if v := params.Get("to"); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid to: %w", err)))
+				return
+			}
+			if t.Before(q.To) {
+				q.To = t
+			}
+		}
+		if q.To.Before(q.From) {
+			render.Render(w, r, ErrInvalidRequest(errors.New("range is outside the shared session")))
+			return
+		}
+
+		for _, c := range strings.Split(params.Get("channels"), ",") {
+			if c = strings.TrimSpace(c); c == "" {
+				continue
+			}
+			if !scope.Allows(c) {
+				render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusForbidden, StatusText: "Forbidden.", ErrorText: fmt.Sprintf("channel %q is not shared", c)})
+				return
+			}
+			q.Channels = append(q.Channels, c)
+		}
+		if len(q.Channels) == 0 {
+			q.Channels = scope.Channels
+		}
+		if v := params.Get("step"); v != "" {
+			step, err := strconv.ParseFloat(v, 64)
+			if err != nil || step <= 0 {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid step %q", v)))
+				return
+			}
+			q.TimeStep = time.Duration(step * float64(time.Millisecond))
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		res, err := channels.Run(ctx, queries, q)
+		if err != nil {
+			if errors.Is(err, channels.ErrTooManyPoints) {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,69 @@
+// shutdown.go
+//
+// Shutdown circuit endpoints: the element event timeline, the reconstructed trips
+// (which element opened first) and the current element states.
+package handlers
+
+import (
+	"net/http"
+	"telem-system/pkg/db"
+	"telem-system/pkg/shutdown"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerShutdownRoutes registers the shutdown circuit endpoints.
+func registerShutdownRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/shutdown/events", makePaginatedHandler(queries.ScanShutdownEventsPaginated))
+	r.Get("/api/shutdown/timeline", shutdownTimelineHandler(queries))
+	r.Get("/api/shutdown/trips", shutdownTripsHandler(queries))
+	r.Get("/api/shutdown/state", shutdownStateHandler)
+}
+
+// shutdownTimelineHandler returns the element state changes over a time range.
+// Query parameters: from, to (RFC 3339).
+func shutdownTimelineHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		data, err := queries.FetchShutdownEvents(r.Context(), from, to)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, data)
+	}
+}
+
+// shutdownTripsHandler returns the trips that started within a time range, each
+// with the elements in the order they opened. Query parameters: from, to (RFC 3339).
+func shutdownTripsHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		events, err := queries.FetchShutdownTripEvents(r.Context(), from, to)
+		if err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, shutdown.GroupTrips(events))
+	}
+}
+
+// shutdownStateHandler returns the current state (closed or not) of every element
+// seen since startup.
+func shutdownStateHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, shutdown.States())
+}
@@ -0,0 +1,90 @@
+// snapshot.go
+//
+// Point-in-time snapshot endpoint: the value of every stored channel as of a
+// given instant, for scrubbing dashboards through history.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	// Default and largest window searched for the last value of a channel
+	defaultSnapshotLookback = time.Hour
+	maxSnapshotLookback     = 7 * 24 * time.Hour
+)
+
+// snapshotResponse is the state of the requested channels at an instant. Channels
+// without a value within the lookback are null.
+type snapshotResponse struct {
+	At        time.Time                    `json:"at"`
+	LookbackS float64                      `json:"lookback_s"`
+	Channels  map[string]*types.TimedValue `json:"channels"`
+}
+
+// snapshotHandler serves /api/snapshot. Query parameters: at (RFC 3339, default
+// now), channels (comma-separated "table.column", default every stored channel)
+// and lookback_s (how far back a channel's last value is searched, default one
+// hour).
+func snapshotHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		at := time.Now()
+		if s := r.URL.Query().Get("at"); s != "" {
+			var err error
+			if at, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid at: %w", err)))
+				return
+			}
+		}
+		lookback := defaultSnapshotLookback
+		if s := r.URL.Query().Get("lookback_s"); s != "" {
+			secs, err := strconv.ParseFloat(s, 64)
+			if err != nil || secs <= 0 || time.Duration(secs*float64(time.Second)) > maxSnapshotLookback {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid lookback_s %q (at most %s)", s, maxSnapshotLookback)))
+				return
+			}
+			lookback = time.Duration(secs * float64(time.Second))
+		}
+		var ids []string
+		for _, c := range strings.Split(r.URL.Query().Get("channels"), ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				ids = append(ids, c)
+			}
+		}
+		if len(ids) == 0 {
+			ids = channels.StoredChannels()
+		}
+		for _, c := range ids {
+			if err := channels.Validate(c); err != nil {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		values, err := channels.Snapshot(ctx, queries, ids, at, lookback)
+		if err != nil {
+			if errors.Is(err, channels.ErrNotStored) {
+				render.Render(w, r, ErrInvalidRequest(err))
+				return
+			}
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		render.JSON(w, r, snapshotResponse{At: at, LookbackS: lookback.Seconds(), Channels: values})
+	}
+}
@@ -0,0 +1,67 @@
+// stats.go
+//
+// Runtime statistics endpoint exposing pipeline counters (throttler and its circuit breaker, decode cache,
+// bus load, persistence lag and SLOs, WebSocket client limits and broadcast queue) for monitoring dashboards.
+package handlers
+
+import (
+	"net/http"
+	"telem-system/internal/auth"
+	"telem-system/internal/wsserver"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/processdata"
+
+	"github.com/go-chi/render"
+)
+
+// statsHandler returns a snapshot of the current pipeline statistics.
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	sent, dropped, state := processdata.GetThrottlerStats()
+	hits, misses := candecoder.GetCacheStats()
+
+	render.JSON(w, r, map[string]interface{}{
+		"throttler": map[string]interface{}{
+			"sent":          sent,
+			"dropped":       dropped,
+			"circuit_state": state,
+		},
+		"circuit_breaker": processdata.GetCircuitBreakerStats(),
+		"decode_cache": map[string]interface{}{
+			"hits":   hits,
+			"misses": misses,
+		},
+		"frame_remap":     candecoder.GetRemapStats(),
+		"bus_load":        processdata.GetBusLoadStats(),
+		"persistence":     processdata.GetPersistenceLag(),
+		"persistence_slo": processdata.GetSLOStatus(),
+		"ws_limits":       wsserver.GetLimiterStats(),
+		"ws_hub":          wsserver.GetHubStats(),
+	})
+}
+
+// whoamiHandler returns the authenticated principal of the request.
+func whoamiHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	p, _ := auth.FromContext(r.Context())
+	render.JSON(w, r, p)
+}
+
+// alertsHandler returns the currently active alerts.
+func alertsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, alerts.Active())
+}
+
+// alertRulesHandler returns the active alert rule sets and their rules, and the
+// configured rule sets.
+func alertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, map[string]interface{}{
+		"active":    alerts.ActiveRuleSets(),
+		"rules":     alerts.ActiveRules(),
+		"available": alerts.RuleSetNames(),
+	})
+}
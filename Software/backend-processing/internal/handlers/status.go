@@ -0,0 +1,25 @@
+// status.go
+//
+// Serves the minimal status page embedded into the server (see package assets),
+// so a portable deployment can be checked from a browser without the dashboard.
+package handlers
+
+import (
+	"net/http"
+	"telem-system/internal/assets"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// registerStatusRoutes registers the status page.
+func registerStatusRoutes(r chi.Router) {
+	r.Get("/api/status", statusPageHandler)
+}
+
+// statusPageHandler serves the status page, which polls /api/stats, /api/alerts
+// and /api/competition. An access_token query parameter is passed on to them.
+func statusPageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(assets.StatusPage)
+}
@@ -0,0 +1,117 @@
+// stream.go
+//
+// Streamed responses for the paginated endpoints. render.JSON encodes a whole
+// result set into memory before writing it, which for a full page of cell data
+// (35k rows of 128 cells) costs hundreds of MB on the Pi. Large pages are instead
+// read with a single query and each row is encoded to the client as it is
+// scanned, so memory use does not grow with the page size.
+//
+// A page is streamed when it is larger than the default page size or when
+// newline-delimited JSON is requested (format=ndjson or an Accept header of
+// application/x-ndjson). Streamed JSON has the same shape as a buffered page;
+// ndjson writes one row object per line. Streamed pages are not cached.
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	// Rows written between flushes of a streamed page
+	streamFlushRows = 1000
+
+	// Limit on the query of a streamed page, which runs while rows are written
+	streamTimeout = time.Minute
+
+	// Pages larger than this are streamed
+	streamMinRows = defaultPageSize
+
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// wantsNDJSON reports whether the client asked for newline-delimited JSON.
+func wantsNDJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "ndjson" ||
+		strings.Contains(r.Header.Get("Accept"), ndjsonContentType)
+}
+
+// streamPage writes limit rows starting at offset, encoding each row as scanPage
+// scans it. Errors before the first row are rendered as usual; later errors can
+// only abort the response, since the status has been sent.
+func streamPage[T any](w http.ResponseWriter, r *http.Request, scanPage pageScanner[T], limit, offset int, lines bool) {
+	var bw *bufio.Writer
+	var writeErr error // The client went away
+	flusher, _ := w.(http.Flusher)
+	written := 0
+
+	ctx, cancel := context.WithTimeout(r.Context(), streamTimeout)
+	defer cancel()
+	err := scanPage(ctx, limit, offset, func(row T) error {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if bw == nil {
+			if lines {
+				w.Header().Set("Content-Type", ndjsonContentType)
+			} else {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			}
+			w.WriteHeader(http.StatusOK)
+			bw = bufio.NewWriterSize(w, 64<<10)
+			if !lines {
+				bw.WriteByte('[')
+			}
+		}
+		if !lines && written > 0 {
+			bw.WriteByte(',')
+		}
+		bw.Write(b)
+		if writeErr = bw.WriteByte('\n'); writeErr != nil {
+			return writeErr
+		}
+		written++
+		if written%streamFlushRows == 0 {
+			if writeErr = bw.Flush(); writeErr != nil {
+				return writeErr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if writeErr != nil {
+		return
+	}
+	if err != nil {
+		if bw == nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		log.Printf("Streamed %s aborted after %d rows: %v", r.URL.Path, written, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if bw == nil {
+		// No rows
+		if lines {
+			w.Header().Set("Content-Type", ndjsonContentType)
+			return
+		}
+		render.JSON(w, r, []T{})
+		return
+	}
+	if !lines {
+		bw.WriteString("]\n")
+	}
+	bw.Flush()
+}
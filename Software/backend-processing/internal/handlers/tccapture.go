@@ -0,0 +1,114 @@
+// tccapture.go
+//
+// Admin endpoints of the traction-control capture mode: start and stop a
+// full-resolution capture run of selected frame IDs, show the active run, and
+// list past runs and their stored frames.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"telem-system/internal/auth"
+	"telem-system/pkg/db"
+	"telem-system/pkg/tccapture"
+	"telem-system/pkg/types"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// tcCaptureRequest is the body of a capture start. An empty frame_ids selects the
+// configured frames.
+type tcCaptureRequest struct {
+	FrameIDs []uint32 `json:"frame_ids" validate:"max=64,dive,max=536870911"`
+	Note     string   `json:"note" validate:"max=500"`
+}
+
+// registerTCCaptureRoutes registers the traction-control capture endpoints.
+func registerTCCaptureRoutes(r chi.Router, queries *db.Queries) {
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/tc-capture", tcCaptureStatusHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Post("/api/admin/tc-capture/start", startTCCaptureHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Post("/api/admin/tc-capture/stop", stopTCCaptureHandler)
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/tc-capture/runs", makePaginatedHandler(queries.ScanTCCaptureRunsPaginated))
+	r.With(auth.RequireRole(auth.RoleAdmin)).Get("/api/admin/tc-capture/runs/{id}/frames", tcCaptureFramesHandler(queries))
+}
+
+// tcCaptureStatusHandler returns the active run with its counters, or null.
+func tcCaptureStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	run, ok := tccapture.Status()
+	if !ok {
+		render.JSON(w, r, map[string]interface{}{"active": false, "run": nil})
+		return
+	}
+	render.JSON(w, r, map[string]interface{}{"active": true, "run": run})
+}
+
+// startTCCaptureHandler starts a capture run attributed to the caller. It
+// responds 409 while another run is active.
+func startTCCaptureHandler(w http.ResponseWriter, r *http.Request) {
+	var req tcCaptureRequest
+	if r.ContentLength != 0 {
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	run, err := tccapture.Start(r.Context(), req.FrameIDs, req.Note, requestedBy(r))
+	if errors.Is(err, tccapture.ErrActive) {
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusConflict, StatusText: "Capture not started.", ErrorText: err.Error()})
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, run)
+}
+
+// stopTCCaptureHandler stops the active run once its frames are stored and
+// returns it. It responds 409 when no run is active.
+func stopTCCaptureHandler(w http.ResponseWriter, r *http.Request) {
+	run, err := tccapture.Stop(r.Context())
+	if errors.Is(err, tccapture.ErrNotActive) {
+		render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusConflict, StatusText: "Capture not stopped.", ErrorText: err.Error()})
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	render.JSON(w, r, run)
+}
+
+// tcCaptureFramesHandler returns the frames of a run in capture order, paginated.
+// The optional frame_id query parameter limits them to one frame ID.
+func tcCaptureFramesHandler(queries *db.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		frameID := int64(-1)
+		if s := r.URL.Query().Get("frame_id"); s != "" {
+			id, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid frame_id %q", s)))
+				return
+			}
+			frameID = int64(id)
+		}
+		makePaginatedHandler(func(ctx context.Context, limit, offset int, fn func(types.TCCaptureFrame) error) error {
+			return queries.ScanTCCaptureFramesPaginated(ctx, runID, frameID, limit, offset, fn)
+		})(w, r)
+	}
+}
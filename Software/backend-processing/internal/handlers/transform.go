@@ -0,0 +1,202 @@
+// transform.go
+//
+// Server-side field transforms for historical query results, so quick derived
+// plots do not need a server release. Transforms are given as repeated
+// transform query parameters of the form
+//
+//	[name=]field:op[:arg]
+//
+// where field is a result field (optionally written as a JSONPath "$.field"), op
+// is one of scale, offset, abs or rate, and name stores the result in a new field
+// instead of replacing the input. Transforms are applied in order, so a later
+// transform can use the output of an earlier one, e.g.
+//
+//	?transform=pack_voltage:scale:0.001&transform=dvdt=pack_voltage:rate
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maximum number of transforms per request
+const maxTransforms = 16
+
+// Transform operations
+const (
+	opScale  = "scale"  // value * arg
+	opOffset = "offset" // value + arg
+	opAbs    = "abs"    // |value|
+	opRate   = "rate"   // change per second since the previous row
+)
+
+// fieldTransform is a single parsed transform.
+type fieldTransform struct {
+	field  string
+	output string
+	op     string
+	arg    float64
+}
+
+// parseTransforms reads the transform query parameters.
+func parseTransforms(r *http.Request) ([]fieldTransform, error) {
+	specs := r.URL.Query()["transform"]
+	if len(specs) > maxTransforms {
+		return nil, fmt.Errorf("at most %d transforms are allowed", maxTransforms)
+	}
+	out := make([]fieldTransform, 0, len(specs))
+	for _, spec := range specs {
+		t, err := parseTransform(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transform %q: %w", spec, err)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// parseTransform parses "[name=]field:op[:arg]".
+func parseTransform(spec string) (fieldTransform, error) {
+	var t fieldTransform
+	if name, rest, ok := strings.Cut(spec, "="); ok {
+		t.output = strings.TrimSpace(name)
+		if t.output == "" {
+			return t, fmt.Errorf("empty output name")
+		}
+		spec = rest
+	}
+
+	parts := strings.Split(spec, ":")
+	t.field = strings.TrimPrefix(strings.TrimSpace(parts[0]), "$.")
+	if t.field == "" || strings.ContainsAny(t.field, ".[]") {
+		return t, fmt.Errorf("field must be a top-level result field")
+	}
+	if t.output == "" {
+		t.output = t.field
+	}
+	if len(parts) < 2 {
+		return t, fmt.Errorf("missing operation")
+	}
+	t.op = strings.ToLower(strings.TrimSpace(parts[1]))
+
+	switch t.op {
+	case opScale, opOffset:
+		if len(parts) != 3 {
+			return t, fmt.Errorf("%s takes one argument", t.op)
+		}
+		arg, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+		if err != nil || math.IsNaN(arg) || math.IsInf(arg, 0) {
+			return t, fmt.Errorf("invalid %s argument %q", t.op, parts[2])
+		}
+		t.arg = arg
+	case opAbs, opRate:
+		if len(parts) != 2 {
+			return t, fmt.Errorf("%s takes no argument", t.op)
+		}
+	default:
+		return t, fmt.Errorf("unknown operation %q", t.op)
+	}
+	return t, nil
+}
+
+// applyTransforms converts data (a slice of result rows) to generic rows and
+// applies the transforms in order. Null values stay null; the first row of a rate
+// and rows without time progress are null.
+func applyTransforms(data interface{}, transforms []fieldTransform) ([]map[string]interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, fmt.Errorf("results cannot be transformed: %w", err)
+	}
+
+	for _, t := range transforms {
+		if t.op == opRate {
+			if err := applyRate(rows, t); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, row := range rows {
+			v, ok, err := numericField(row, t.field)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				row[t.output] = nil
+				continue
+			}
+			switch t.op {
+			case opScale:
+				v *= t.arg
+			case opOffset:
+				v += t.arg
+			case opAbs:
+				v = math.Abs(v)
+			}
+			row[t.output] = v
+		}
+	}
+	return rows, nil
+}
+
+// applyRate replaces t.output with the change of t.field per second of timestamp.
+func applyRate(rows []map[string]interface{}, t fieldTransform) error {
+	var prevV float64
+	var prevT time.Time
+	havePrev := false
+	for _, row := range rows {
+		v, ok, err := numericField(row, t.field)
+		if err != nil {
+			return err
+		}
+		ts, err := rowTimestamp(row)
+		if err != nil {
+			return err
+		}
+		row[t.output] = nil
+		if !ok {
+			havePrev = false
+			continue
+		}
+		if havePrev {
+			if dt := ts.Sub(prevT).Seconds(); dt > 0 {
+				row[t.output] = (v - prevV) / dt
+			}
+		}
+		prevV, prevT, havePrev = v, ts, true
+	}
+	return nil
+}
+
+// numericField returns the value of a numeric field; ok is false for null.
+func numericField(row map[string]interface{}, field string) (v float64, ok bool, err error) {
+	raw, present := row[field]
+	if !present {
+		return 0, false, fmt.Errorf("unknown field %q", field)
+	}
+	switch x := raw.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return x, true, nil
+	default:
+		return 0, false, fmt.Errorf("field %q is not numeric", field)
+	}
+}
+
+// rowTimestamp returns the timestamp field of a row.
+func rowTimestamp(row map[string]interface{}) (time.Time, error) {
+	s, ok := row["timestamp"].(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("rate requires a timestamp field")
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
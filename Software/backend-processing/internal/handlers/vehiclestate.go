@@ -0,0 +1,37 @@
+// vehiclestate.go
+//
+// Vehicle state endpoints: the current consolidated state and the stored
+// transitions.
+package handlers
+
+import (
+	"net/http"
+	"telem-system/pkg/db"
+	"telem-system/pkg/vehiclestate"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// registerVehicleStateRoutes registers the vehicle state endpoints.
+func registerVehicleStateRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/vehicleState", vehicleStateHandler)
+	r.Get("/api/vehicleState/events", makePaginatedHandler(queries.ScanVehicleStateEventsPaginated))
+}
+
+// vehicleStateHandler returns the current vehicle state and when it was entered.
+func vehicleStateHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-store")
+
+	state, since := vehiclestate.Current()
+	resp := struct {
+		State string     `json:"state"`
+		Since *time.Time `json:"since,omitempty"`
+	}{State: state}
+	if !since.IsZero() {
+		resp.Since = &since
+	}
+	render.JSON(w, r, resp)
+}
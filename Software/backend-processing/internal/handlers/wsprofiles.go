@@ -0,0 +1,176 @@
+// wsprofiles.go
+//
+// Live subscription profile endpoints. Operators save named profiles (channels,
+// maximum rate, format) that live clients select with /ws?profile=<name>.
+// Saving a profile reconfigures the connected clients using it; clients of a
+// deleted profile keep their settings until they reconnect.
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"telem-system/internal/auth"
+	"telem-system/internal/wsserver"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Profile names and channels are used in URLs and stored comma-joined
+var wsNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// wsProfileRequest is the body of a saved profile.
+type wsProfileRequest struct {
+	Description string   `json:"description" validate:"max=500"`
+	Channels    []string `json:"channels" validate:"max=100"`
+	MaxRate     float64  `json:"max_rate" validate:"min=0,max=1000"`
+	Format      string   `json:"format" validate:"omitempty,oneof=protobuf json"`
+}
+
+// wsProfileResponse is a profile with the number of live clients using it.
+type wsProfileResponse struct {
+	types.WSProfile
+	Clients int `json:"clients"`
+}
+
+// registerWSProfileRoutes registers the subscription profile endpoints.
+func registerWSProfileRoutes(r chi.Router, queries *db.Queries) {
+	r.Get("/api/ws-profiles", makePaginatedHandler(wsProfilesFetcher(queries)))
+	r.Get("/api/ws-profiles/{name}", wsProfileHandler)
+	r.With(auth.RequireRole(auth.RoleOperator)).Put("/api/ws-profiles/{name}", saveWSProfileHandler)
+	r.With(auth.RequireRole(auth.RoleOperator)).Delete("/api/ws-profiles/{name}", deleteWSProfileHandler)
+}
+
+// WSProfileLookup resolves ?profile= for wsserver.SetProfileSource from the
+// ws_profiles table.
+func WSProfileLookup(ctx context.Context, name string) (wsserver.Subscription, bool, error) {
+	p, ok, err := db.FetchWSProfile(ctx, name)
+	if err != nil || !ok {
+		return wsserver.Subscription{}, false, err
+	}
+	return wsSubscription(p), true, nil
+}
+
+// wsSubscription returns the live subscription of a profile.
+func wsSubscription(p types.WSProfile) wsserver.Subscription {
+	return wsserver.Subscription{Channels: p.Channels, MaxRate: p.MaxRate, Format: p.Format}
+}
+
+// wsProfilesFetcher lists profiles with their connected client counts.
+func wsProfilesFetcher(queries *db.Queries) pageScanner[wsProfileResponse] {
+	return func(ctx context.Context, limit, offset int, fn func(wsProfileResponse) error) error {
+		clients := wsserver.WsHub.ProfileClients()
+		return queries.ScanWSProfilesPaginated(ctx, limit, offset, func(p types.WSProfile) error {
+			return fn(wsProfileResponse{WSProfile: p, Clients: clients[p.Name]})
+		})
+	}
+}
+
+// wsProfileName returns the validated profile name of the URL, rendering 400 for
+// invalid names.
+func wsProfileName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := chi.URLParam(r, "name")
+	if !wsNamePattern.MatchString(name) {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid profile name %q", name)))
+		return "", false
+	}
+	return name, true
+}
+
+// wsProfileNotFound renders 404 for an unknown profile.
+func wsProfileNotFound(w http.ResponseWriter, r *http.Request, name string) {
+	render.Render(w, r, &ErrResponse{HTTPStatusCode: http.StatusNotFound, StatusText: "Profile not found.", ErrorText: fmt.Sprintf("no subscription profile %q", name)})
+}
+
+// wsProfileHandler returns a profile.
+func wsProfileHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-store")
+
+	name, ok := wsProfileName(w, r)
+	if !ok {
+		return
+	}
+	p, ok, err := db.FetchWSProfile(r.Context(), name)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	if !ok {
+		wsProfileNotFound(w, r, name)
+		return
+	}
+	render.JSON(w, r, wsProfileResponse{WSProfile: p, Clients: wsserver.WsHub.ProfileClients()[name]})
+}
+
+// saveWSProfileHandler creates or replaces a profile, applies it to the connected
+// clients using it and announces it on the live control channel.
+func saveWSProfileHandler(w http.ResponseWriter, r *http.Request) {
+	name, ok := wsProfileName(w, r)
+	if !ok {
+		return
+	}
+	var req wsProfileRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	for _, c := range req.Channels {
+		if !wsNamePattern.MatchString(c) {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid channel %q", c)))
+			return
+		}
+	}
+	if req.Format == "" {
+		req.Format = wsserver.FormatProtobuf
+	}
+
+	p := types.WSProfile{
+		Name:        name,
+		Description: req.Description,
+		Channels:    req.Channels,
+		MaxRate:     req.MaxRate,
+		Format:      req.Format,
+		UpdatedBy:   requestedBy(r),
+		UpdatedAt:   time.Now(),
+	}
+	if p.Channels == nil {
+		p.Channels = []string{}
+	}
+	if err := db.UpsertWSProfile(r.Context(), p); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	clients := wsserver.WsHub.ApplyProfile(name, wsSubscription(p))
+	wsserver.Notify("ws_profile", p)
+	render.JSON(w, r, wsProfileResponse{WSProfile: p, Clients: clients})
+}
+
+// deleteWSProfileHandler deletes a profile and announces the deletion on the live
+// control channel. It responds 404 for unknown profiles.
+func deleteWSProfileHandler(w http.ResponseWriter, r *http.Request) {
+	name, ok := wsProfileName(w, r)
+	if !ok {
+		return
+	}
+	ok, err := db.DeleteWSProfile(r.Context(), name)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+	if !ok {
+		wsProfileNotFound(w, r, name)
+		return
+	}
+	wsserver.Notify("ws_profile", map[string]interface{}{"name": name, "deleted": true})
+	w.WriteHeader(http.StatusNoContent)
+}
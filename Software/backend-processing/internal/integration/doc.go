@@ -0,0 +1,15 @@
+// doc.go
+//
+// Package integration holds the end-to-end tests of the server: ingest -> store
+// -> query round trips against a real PostgreSQL database. They are skipped
+// unless TELEM_TEST_DB names a disposable database; `make test-integration`
+// starts a throwaway container and runs them.
+//
+// Every frame of the CAN definitions is fed through the processing pipeline from
+// a deterministic payload, by default with the fixture in testdata. The fixture
+// defines every frame the server handles and annotates each message with the
+// table it is stored in and each signal with its column, so every decoded value
+// is compared with the stored row, the CSV export and the channel query.
+// TELEM_TEST_DEFS selects other definitions; every frame they define must still
+// be stored, but only their annotated signals are compared.
+package integration
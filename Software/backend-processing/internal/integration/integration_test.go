@@ -0,0 +1,536 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"telem-system/internal/handlers"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/db"
+	"telem-system/pkg/processdata"
+	"telem-system/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultDefs = "testdata/can_definitions.json"
+
+	// How long to wait for a frame to be stored
+	storeTimeout = 5 * time.Second
+
+	// Batch writer settings: flush every record quickly
+	batchSize    = 1
+	batchMaxWait = 20 * time.Millisecond
+
+	// Steering wheel frame, processdata's default; its inputs are stored as events
+	driverInputFrame = 1792
+)
+
+// Frame IDs routed by processdata.HandleDataInsertions into telemetry tables. The
+// cell frames 50-57 are sent as one group since a cell_data row is stored after
+// the last of them.
+var supportedFrames = []uint32{
+	4, 5, 6, 8, 30, 40, 41,
+	60, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	80, 81, 82, 100, 101, 102, 200, 258, 259, 385, 513, 600,
+	1280, 1312, 1536, 1537, 1552, 1553, 1554, 1555, 1680,
+}
+
+var cellFrames = []uint32{50, 51, 52, 53, 54, 55, 56, 57}
+
+// annotatedMessage holds the annotations of the fixture definitions: the table a
+// frame is stored in and the column of every signal.
+type annotatedMessage struct {
+	FrameID uint32 `json:"frame_id"`
+	Table   string `json:"table"`
+	Signals []struct {
+		Name   string `json:"name"`
+		Column string `json:"column"`
+	} `json:"signals"`
+}
+
+// sample is a stored row and the decoded values expected in it by column.
+type sample struct {
+	frames []uint32
+	at     time.Time
+	values map[string]float64
+}
+
+// checker holds the definitions and what was stored.
+type checker struct {
+	messages    map[uint32]types.Message
+	annotations map[uint32]annotatedMessage
+	samples     map[string][]sample // Stored rows by table
+	api         *httptest.Server
+	start       time.Time
+}
+
+func TestIntegration(t *testing.T) {
+	dbURL := os.Getenv("TELEM_TEST_DB")
+	if dbURL == "" {
+		t.Skip("TELEM_TEST_DB is not set (make test-integration; never point it at a production database)")
+	}
+	defsPath := os.Getenv("TELEM_TEST_DEFS")
+	if defsPath == "" {
+		defsPath = defaultDefs
+	}
+
+	conn, err := db.Connect(dbURL)
+	if err != nil {
+		t.Fatalf("connect to the test database: %v", err)
+	}
+	defer conn.Close()
+	queries := db.New(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := db.EnsureSchema(ctx); err != nil {
+		t.Fatalf("create the schema: %v", err)
+	}
+
+	c := &checker{samples: map[string][]sample{}, start: time.Now().Add(-time.Second)}
+	if _, c.messages, err = candecoder.LoadJSONDefinitions(defsPath); err != nil {
+		t.Fatalf("load CAN definitions: %v", err)
+	}
+	if c.annotations, err = loadAnnotations(defsPath); err != nil {
+		t.Fatalf("load CAN definitions: %v", err)
+	}
+
+	router := chi.NewRouter()
+	handlers.RegisterRoutes(router, queries)
+	c.api = httptest.NewServer(router)
+	defer c.api.Close()
+
+	if err := processdata.InitBatchProcessors(ctx, batchSize, batchMaxWait); err != nil {
+		t.Fatalf("initialize batch processors: %v", err)
+	}
+
+	t.Run("schema", c.checkSchema)
+	if defsPath == defaultDefs {
+		t.Run("fixture", c.checkFixture)
+	}
+
+	stored := make(map[string]bool)
+	for _, frames := range c.frameGroups() {
+		frames := frames
+		t.Run(fmt.Sprintf("frame %v", frames), func(t *testing.T) {
+			if frames[0] == driverInputFrame {
+				c.checkDriverInputs(t, ctx)
+				return
+			}
+			for _, table := range c.roundTrip(t, ctx, frames) {
+				stored[table] = true
+			}
+		})
+	}
+	for _, spec := range db.TelemetryTables {
+		spec := spec
+		t.Run(spec.Name, func(t *testing.T) {
+			if !stored[spec.Name] {
+				t.Fatalf("no frame stored a row in %s", spec.Name)
+			}
+			c.checkExport(t, spec)
+			c.checkQuery(t, spec)
+		})
+	}
+}
+
+// loadAnnotations returns the annotated messages of a definitions file by frame ID.
+// Messages without a table are left out.
+func loadAnnotations(path string) (map[uint32]annotatedMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var messages []annotatedMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+	out := make(map[uint32]annotatedMessage, len(messages))
+	for _, m := range messages {
+		if m.Table == "" {
+			continue
+		}
+		if _, ok := db.LookupTable(m.Table); !ok {
+			return nil, fmt.Errorf("frame %d: unknown table %q", m.FrameID, m.Table)
+		}
+		out[m.FrameID] = m
+	}
+	return out, nil
+}
+
+// frameGroups returns every defined frame in ID order, the cell frames as one
+// group.
+func (c *checker) frameGroups() [][]uint32 {
+	ids := make([]uint32, 0, len(c.messages))
+	for id := range c.messages {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	var groups [][]uint32
+	cells := false
+	for _, id := range ids {
+		if !slices.Contains(cellFrames, id) {
+			groups = append(groups, []uint32{id})
+			continue
+		}
+		if !cells {
+			groups = append(groups, cellFrames)
+			cells = true
+		}
+	}
+	return groups
+}
+
+// checkFixture verifies that the fixture defines every frame the server handles
+// and annotates every telemetry frame with its table.
+func (c *checker) checkFixture(t *testing.T) {
+	frames := append(slices.Clone(supportedFrames), cellFrames...)
+	for _, id := range frames {
+		if _, ok := c.messages[id]; !ok {
+			t.Errorf("frame %d is handled by the server but not defined", id)
+		} else if _, ok := c.annotations[id]; !ok {
+			t.Errorf("frame %d has no table annotation", id)
+		}
+	}
+	if _, ok := c.messages[driverInputFrame]; !ok {
+		t.Errorf("steering wheel frame %d is not defined", driverInputFrame)
+	}
+}
+
+// checkSchema verifies that every telemetry table and column exists.
+func (c *checker) checkSchema(t *testing.T) {
+	for _, spec := range db.TelemetryTables {
+		rows, err := db.DB.Query(`
+			SELECT column_name FROM information_schema.columns WHERE table_name = $1
+		`, spec.Name)
+		if err != nil {
+			t.Errorf("%s: %v", spec.Name, err)
+			continue
+		}
+		var cols []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err == nil {
+				cols = append(cols, name)
+			}
+		}
+		rows.Close()
+		for _, col := range spec.Columns {
+			if !slices.Contains(cols, col.Name) {
+				t.Errorf("%s: missing column %s", spec.Name, col.Name)
+			}
+		}
+		if !slices.Contains(cols, "seq") {
+			t.Errorf("%s: missing column seq", spec.Name)
+		}
+	}
+}
+
+// roundTrip ingests the frames, checks the stored rows against the decoded values
+// and returns the telemetry tables that gained rows.
+func (c *checker) roundTrip(t *testing.T, ctx context.Context, frames []uint32) []string {
+	before := c.counts(t, ctx)
+
+	cells := map[float64]*types.Cell_Data{0: {}}
+	want := make(map[string]map[string]float64) // Decoded values by table and column
+	for _, id := range frames {
+		decoded := c.decode(t, id, want)
+		processdata.HandleDataInsertions(id, decoded, cells, 0, "live")
+	}
+	if frames[len(frames)-1] == cellFrames[len(cellFrames)-1] {
+		cells[0].Timestamp = time.Now()
+		processdata.AddCellDataToBatch(*cells[0])
+	}
+
+	deadline := time.Now().Add(storeTimeout)
+	var grown []string
+	for time.Now().Before(deadline) {
+		time.Sleep(2 * batchMaxWait)
+		grown = grownTables(before, c.counts(t, ctx))
+		if len(grown) > 0 {
+			// Let writers of other tables touched by the frame catch up
+			time.Sleep(4 * batchMaxWait)
+			grown = grownTables(before, c.counts(t, ctx))
+			break
+		}
+	}
+	if len(grown) == 0 {
+		t.Fatalf("nothing stored within %s", storeTimeout)
+	}
+	for table := range want {
+		if !slices.Contains(grown, table) {
+			t.Errorf("nothing stored in %s", table)
+		}
+	}
+	for _, table := range grown {
+		c.checkStored(t, ctx, table, frames, want[table])
+	}
+	return grown
+}
+
+// grownTables returns the tables with more rows after than before, sorted.
+func grownTables(before, after map[string]int64) []string {
+	var grown []string
+	for table, n := range after {
+		if n > before[table] {
+			grown = append(grown, table)
+		}
+	}
+	slices.Sort(grown)
+	return grown
+}
+
+// checkStored compares the latest row of a table with the decoded values and
+// records it for the export and query checks.
+func (c *checker) checkStored(t *testing.T, ctx context.Context, table string, frames []uint32, want map[string]float64) {
+	spec, _ := db.LookupTable(table)
+	cols := make([]string, len(spec.Columns))
+	for i, col := range spec.Columns {
+		cols[i] = col.Name
+	}
+	var at time.Time
+	values := make([]sql.NullFloat64, len(cols))
+	dest := []interface{}{&at}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	query := fmt.Sprintf("SELECT timestamp, %s FROM %s ORDER BY timestamp DESC, seq DESC LIMIT 1", strings.Join(cols, ", "), table)
+	if err := db.DB.QueryRowContext(ctx, query).Scan(dest...); err != nil {
+		t.Errorf("read %s: %v", table, err)
+		return
+	}
+	for col, v := range want {
+		got := values[slices.Index(cols, col)]
+		switch {
+		case !got.Valid:
+			t.Errorf("%s.%s stored NULL, decoded %g", table, col, v)
+		case got.Float64 != v:
+			t.Errorf("%s.%s stored %g, decoded %g", table, col, got.Float64, v)
+		}
+	}
+	c.samples[table] = append(c.samples[table], sample{frames: frames, at: at, values: want})
+}
+
+// checkDriverInputs ingests the steering wheel frame and checks that every input
+// comes back from the input history with its decoded value.
+func (c *checker) checkDriverInputs(t *testing.T, ctx context.Context) {
+	msg := c.messages[driverInputFrame]
+	decoded := c.decode(t, driverInputFrame, nil)
+	processdata.HandleDataInsertions(driverInputFrame, decoded, nil, 0, "live")
+
+	deadline := time.Now().Add(storeTimeout)
+	var got map[string]float64
+	for time.Now().Before(deadline) {
+		time.Sleep(2 * batchMaxWait)
+		body, err := c.get("/api/driverInputs/history", url.Values{
+			"from": {c.start.Format(time.RFC3339Nano)},
+			"to":   {time.Now().Format(time.RFC3339Nano)},
+		})
+		if err != nil {
+			t.Fatalf("input history: %v", err)
+		}
+		var events []types.DriverInputEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			t.Fatalf("input history: invalid response: %v", err)
+		}
+		got = make(map[string]float64, len(events))
+		for _, e := range events {
+			got[e.Input] = e.Value
+		}
+		if len(got) >= len(msg.Signals) {
+			break
+		}
+	}
+	for _, sig := range msg.Signals {
+		want, err := strconv.ParseFloat(decoded[sig.Name], 64)
+		if err != nil {
+			t.Errorf("input %s decoded to %q", sig.Name, decoded[sig.Name])
+			continue
+		}
+		v, ok := got[sig.Name]
+		switch {
+		case !ok:
+			t.Errorf("input %s: no event stored within %s", sig.Name, storeTimeout)
+		case v != want:
+			t.Errorf("input %s: stored %g, decoded %g", sig.Name, v, want)
+		}
+	}
+}
+
+// decode returns the decoded signals of a frame from a deterministic payload and
+// adds the values of its annotated signals to want.
+func (c *checker) decode(t *testing.T, id uint32, want map[string]map[string]float64) map[string]string {
+	msg := c.messages[id]
+	data := make([]byte, msg.Length)
+	for i := range data {
+		data[i] = byte(int(id)*7 + i*13 + 1)
+	}
+	decoded, err := candecoder.DecodeMessage(data, msg)
+	if err != nil {
+		t.Fatalf("decode frame %d: %v", id, err)
+	}
+	if a, ok := c.annotations[id]; ok && want != nil {
+		if want[a.Table] == nil {
+			want[a.Table] = make(map[string]float64)
+		}
+		for _, sig := range a.Signals {
+			if sig.Column == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(decoded[sig.Name], 64)
+			if err != nil {
+				t.Errorf("frame %d: signal %s decoded to %q", id, sig.Name, decoded[sig.Name])
+				continue
+			}
+			want[a.Table][sig.Column] = v
+		}
+	}
+	if !slices.Contains(cellFrames, id) {
+		return decoded
+	}
+	// Cell frames carry consecutive cells, numbered across the frames
+	cells := make(map[string]string, len(msg.Signals))
+	offset := int(id-cellFrames[0]) * len(msg.Signals)
+	for i, sig := range msg.Signals {
+		if v, ok := decoded[sig.Name]; ok {
+			cells["Cell"+strconv.Itoa(offset+i+1)] = v
+		}
+	}
+	return cells
+}
+
+// counts returns the row count of every telemetry table.
+func (c *checker) counts(t *testing.T, ctx context.Context) map[string]int64 {
+	out := make(map[string]int64, len(db.TelemetryTables))
+	for _, spec := range db.TelemetryTables {
+		var n int64
+		if err := db.DB.QueryRowContext(ctx, "SELECT count(*) FROM "+spec.Name).Scan(&n); err != nil {
+			t.Fatalf("count %s: %v", spec.Name, err)
+		}
+		out[spec.Name] = n
+	}
+	return out
+}
+
+// checkExport downloads the table as CSV and checks the header and that every
+// stored row comes back with its decoded values.
+func (c *checker) checkExport(t *testing.T, spec db.TableSpec) {
+	body, err := c.get("/api/export/"+spec.Name, url.Values{"from": {c.start.Format(time.RFC3339Nano)}})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatalf("export: invalid CSV: %v", err)
+	}
+	if len(records) < 2 {
+		t.Fatal("export: no data rows")
+	}
+	header := records[0]
+	for _, col := range spec.Columns {
+		if !slices.Contains(header, col.Name) {
+			t.Errorf("export: column %s missing from header", col.Name)
+		}
+	}
+
+	rows := make(map[int64][]string, len(records)-1)
+	for _, rec := range records[1:] {
+		at, err := time.Parse(time.RFC3339Nano, rec[0])
+		if err != nil {
+			t.Errorf("export: invalid timestamp %q", rec[0])
+			continue
+		}
+		rows[at.UnixMicro()] = rec
+	}
+	for _, s := range c.samples[spec.Name] {
+		rec, ok := rows[s.at.UnixMicro()]
+		if !ok {
+			t.Errorf("export: no row for frame %v at %s", s.frames, s.at.Format(time.RFC3339Nano))
+			continue
+		}
+		for col, v := range s.values {
+			field := rec[slices.Index(header, col)]
+			if got, err := strconv.ParseFloat(field, 64); err != nil || got != v {
+				t.Errorf("export: frame %v exported %s=%q, decoded %g", s.frames, col, field, v)
+			}
+		}
+	}
+}
+
+// checkQuery reads every stored row back through the channel query API, querying
+// the compared columns (or the first column) at the row's timestamp.
+func (c *checker) checkQuery(t *testing.T, spec db.TableSpec) {
+	for _, s := range c.samples[spec.Name] {
+		cols := make([]string, 0, len(s.values))
+		for col := range s.values {
+			cols = append(cols, col)
+		}
+		if len(cols) == 0 {
+			cols = append(cols, spec.Columns[0].Name)
+		}
+		slices.Sort(cols)
+		channels := make([]string, len(cols))
+		for i, col := range cols {
+			channels[i] = spec.Name + "." + col
+		}
+
+		at := s.at.Format(time.RFC3339Nano)
+		body, err := c.get("/api/query", url.Values{
+			"channels": {strings.Join(channels, ",")},
+			"from":     {at},
+			"to":       {at},
+			"federate": {"false"},
+		})
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		var res struct {
+			X        []float64             `json:"x"`
+			Channels map[string][]*float64 `json:"channels"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			t.Fatalf("query: invalid response: %v", err)
+		}
+		for i, channel := range channels {
+			values := res.Channels[channel]
+			if len(res.X) != 1 || len(values) != 1 || values[0] == nil {
+				t.Errorf("query %s: no sample for frame %v at %s", channel, s.frames, at)
+				continue
+			}
+			if v, ok := s.values[cols[i]]; ok && *values[0] != v {
+				t.Errorf("query %s: frame %v returned %g, decoded %g", channel, s.frames, *values[0], v)
+			}
+		}
+	}
+}
+
+// get requests an API path and returns the body of a 200 response.
+func (c *checker) get(path string, params url.Values) ([]byte, error) {
+	resp, err := http.Get(c.api.URL + path + "?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,63 @@
+// control.go
+//
+// Control messages sent by /ws clients. A client requests a full snapshot of a
+// channel with the JSON text message {"action": "snapshot", "channel": "cell"};
+// the latest messages of that channel are written to that client only, ahead of
+// the next periodic broadcast, in the client's format. Other messages are
+// ignored.
+package wsserver
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+)
+
+// Control actions
+const actionSnapshot = "snapshot"
+
+// SnapshotFunc returns the encoded messages forming the latest full value of a
+// channel.
+type SnapshotFunc func(channel string) [][]byte
+
+// snapshotSource serves snapshot requests; nil disables them.
+var snapshotSource SnapshotFunc
+
+// SetSnapshotSource installs the source of channel snapshots. It must be called
+// before the server starts accepting clients.
+func SetSnapshotSource(fn SnapshotFunc) {
+	snapshotSource = fn
+}
+
+// controlMessage is a client request.
+type controlMessage struct {
+	Action  string `json:"action"`
+	Channel string `json:"channel"`
+}
+
+// handleControl answers a control message from conn. Write errors are returned
+// so the reader loop can drop the client.
+func handleControl(conn client, messageType int, data []byte) error {
+	if messageType != websocket.TextMessage {
+		return nil
+	}
+	var msg controlMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil
+	}
+	if msg.Action != actionSnapshot || msg.Channel == "" || snapshotSource == nil {
+		return nil
+	}
+	sub := conn.subscription()
+	for _, m := range snapshotSource(msg.Channel) {
+		var jsonCache []byte
+		messageType, data, ok := sub.encode(m, &jsonCache)
+		if !ok {
+			continue
+		}
+		if err := conn.writeMessage(messageType, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
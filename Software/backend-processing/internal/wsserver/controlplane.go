@@ -0,0 +1,450 @@
+// controlplane.go
+//
+// Control channel for live clients at /ws/control, separate from the data stream.
+// Everything on it is JSON text. On connect the server sends
+//
+//	{"type": "hello", "session": "<id>"}
+//
+// followed by a "schema" event with the live channel registry (IDs, schema
+// versions and policies) and a "state" event for every retained server state
+// (e.g. the active alerts). Data sockets opened with ?session=<id> (on /ws or
+// /stream) are bound to the session: they carry telemetry payloads only, and
+// their subscription is set over the control channel.
+//
+// Client requests carry an optional id echoed in the reply, an "ack" or an
+// "error" event:
+//
+//	{"id": "1", "action": "subscribe", "channels": ["cell"], "max_rate": 5, "format": "json"}
+//	{"id": "2", "action": "snapshot", "channel": "cell"}
+//	{"id": "3", "action": "ping"}
+//
+// The server pushes "state" events when a retained state changes and "notice"
+// events for admin notifications (see Notify). Bound data sockets keep running
+// with their last subscription when the control channel closes.
+package wsserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"telem-system/pkg/livechannels"
+	pb "telem-system/proto"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Maximum number of concurrent control sessions
+	maxControlSessions = maxClients
+
+	// A control write blocked for longer than this drops the session
+	controlWriteTimeout = 5 * time.Second
+
+	// Events queued per control session; a session falling this far behind is
+	// dropped so a slow client cannot hold up broadcasts to the others
+	controlSendBuffer = 64
+)
+
+// Control plane requests
+const (
+	actionSubscribe = "subscribe"
+	actionPing      = "ping"
+)
+
+// Control plane events
+const (
+	eventHello  = "hello"
+	eventAck    = "ack"
+	eventError  = "error"
+	eventState  = "state"
+	eventSchema = "schema"
+	eventNotice = "notice"
+)
+
+// controlPolicy is the inbound policy applied to /ws/control clients.
+var controlPolicy = Policy{
+	Endpoint:   "ws_control",
+	Rate:       defaultLiveRate,
+	Burst:      defaultLiveBurst,
+	MaxMessage: maxMessageSize,
+	Validate: func(messageType int, data []byte) error {
+		if messageType != websocket.TextMessage {
+			return errors.New("control messages must be text")
+		}
+		return nil
+	},
+}
+
+var (
+	errUnknownSession = errors.New("unknown control session")
+	errSessionClosed  = errors.New("control session closed")
+	errSendQueueFull  = errors.New("control send queue full")
+)
+
+// controlEvent is a message sent on the control channel.
+type controlEvent struct {
+	Type     string                 `json:"type"`
+	ID       string                 `json:"id,omitempty"`
+	Session  string                 `json:"session,omitempty"`
+	Key      string                 `json:"key,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Clients  *int                   `json:"clients,omitempty"` // Bound data sockets a request applied to
+	Channels []livechannels.Channel `json:"channels,omitempty"`
+	Data     json.RawMessage        `json:"data,omitempty"`
+}
+
+// controlRequest is a request from a control client.
+type controlRequest struct {
+	ID      string `json:"id"`
+	Action  string `json:"action"`
+	Channel string `json:"channel"` // snapshot
+	Subscription
+}
+
+// controlSession is a connected control client. Events are queued and written
+// by the session's own writer goroutine (see writeLoop).
+type controlSession struct {
+	id        string
+	conn      *websocket.Conn
+	out       chan []byte   // Encoded events waiting to be written
+	done      chan struct{} // Closed when the session is closed
+	closeOnce sync.Once
+
+	mu  sync.Mutex   // Guards sub
+	sub Subscription // Subscription of the bound data sockets
+}
+
+var (
+	sessionsMu sync.RWMutex
+	sessions   = make(map[string]*controlSession)
+)
+
+// newControlSession returns a session on conn with an empty send queue.
+func newControlSession(id string, conn *websocket.Conn) *controlSession {
+	return &controlSession{
+		id:   id,
+		conn: conn,
+		out:  make(chan []byte, controlSendBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+// send queues an event for the session without blocking. A session whose queue
+// is full is closed.
+func (s *controlSession) send(ev controlEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-s.done:
+		return errSessionClosed
+	default:
+	}
+	select {
+	case s.out <- b:
+		return nil
+	default:
+		s.close()
+		return errSendQueueFull
+	}
+}
+
+// write writes an event directly to the connection. It is only used for the
+// connect snapshot, before writeLoop starts, so the snapshot does not depend on
+// the size of the send queue.
+func (s *controlSession) write(ev controlEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(controlWriteTimeout))
+	return s.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+// writeLoop writes the queued events until the session is closed. A failed or
+// timed out write closes the session.
+func (s *controlSession) writeLoop() {
+	for {
+		select {
+		case b := <-s.out:
+			s.conn.SetWriteDeadline(time.Now().Add(controlWriteTimeout))
+			if err := s.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+				s.close()
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// close stops the writer and closes the connection, which ends the reader loop.
+func (s *controlSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+// subscription returns the current subscription of the session.
+func (s *controlSession) subscription() Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sub
+}
+
+// sessionSubscriber returns a subscriber bound to a control session, for a data
+// socket opened with ?session=.
+func sessionSubscriber(id string) (*subscriber, error) {
+	sessionsMu.RLock()
+	s, ok := sessions[id]
+	sessionsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownSession, id)
+	}
+	sub := newSubscriber("", s.subscription())
+	sub.session = id
+	return sub, nil
+}
+
+// broadcastControl queues an event for every control session. Sessions whose
+// queue is full are closed; their reader loops clean them up.
+func broadcastControl(ev controlEvent) {
+	sessionsMu.RLock()
+	list := make([]*controlSession, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, s)
+	}
+	sessionsMu.RUnlock()
+	for _, s := range list {
+		s.send(ev)
+	}
+}
+
+// Notify sends an admin notification to every control session as a "notice"
+// event with the given key (e.g. "competition") and data encoded as JSON.
+func Notify(key string, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error encoding %s notice: %v", key, err)
+		return
+	}
+	broadcastControl(controlEvent{Type: eventNotice, Key: key, Data: b})
+}
+
+// stateEvent converts a retained state message (a binary TelemetryMessage) to a
+// "state" event. A nil msg signals a cleared state.
+func stateEvent(key string, msg []byte) (controlEvent, error) {
+	ev := controlEvent{Type: eventState, Key: key, Data: json.RawMessage("null")}
+	if msg == nil {
+		return ev, nil
+	}
+	var tm pb.TelemetryMessage
+	if err := proto.Unmarshal(msg, &tm); err != nil {
+		return ev, err
+	}
+	b, err := protojson.Marshal(&tm)
+	if err != nil {
+		return ev, err
+	}
+	ev.Data = b
+	return ev, nil
+}
+
+// publishState forwards a retained state change to the control sessions.
+func publishState(key string, msg []byte) {
+	ev, err := stateEvent(key, msg)
+	if err != nil {
+		log.Printf("Error encoding %s state: %v", key, err)
+		return
+	}
+	broadcastControl(ev)
+}
+
+// ServeControl upgrades an HTTP request to a control channel WebSocket.
+func ServeControl(w http.ResponseWriter, r *http.Request) {
+	sessionsMu.RLock()
+	full := len(sessions) >= maxControlSessions
+	sessionsMu.RUnlock()
+	if full {
+		http.Error(w, "too many control sessions", http.StatusServiceUnavailable)
+		return
+	}
+
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		http.Error(w, "session id generation failed", http.StatusInternalServerError)
+		return
+	}
+	upgrader := websocket.Upgrader{
+		CheckOrigin:     func(r *http.Request) bool { return true },
+		ReadBufferSize:  wsReadBufferSize,
+		WriteBufferSize: wsWriteBufferSize,
+	}
+	wsConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	wsConn.SetReadLimit(controlPolicy.MaxMessage)
+
+	s := newControlSession(hex.EncodeToString(raw[:]), wsConn)
+	sessionsMu.Lock()
+	sessions[s.id] = s
+	sessionsMu.Unlock()
+	defer func() {
+		sessionsMu.Lock()
+		delete(sessions, s.id)
+		sessionsMu.Unlock()
+		s.close()
+	}()
+
+	// The connect snapshot is written before the writer starts; events
+	// broadcast meanwhile are queued and follow it
+	snapshot := []controlEvent{
+		{Type: eventHello, Session: s.id},
+		{Type: eventSchema, Channels: livechannels.All()},
+	}
+	snapshot = append(snapshot, WsHub.retainedStates()...)
+	for _, ev := range snapshot {
+		if err := s.write(ev); err != nil {
+			return
+		}
+	}
+	go s.writeLoop()
+
+	limiter := NewClientLimiter(controlPolicy)
+	for {
+		messageType, data, err := wsConn.ReadMessage()
+		if err != nil {
+			return
+		}
+		abusive, err := limiter.Check(messageType, data)
+		if abusive {
+			limiter.Disconnect(wsConn, err)
+			return
+		}
+		if err != nil {
+			continue
+		}
+		if err := s.handle(data); err != nil {
+			return
+		}
+	}
+}
+
+// handle answers a control request. Send errors are returned so the reader loop
+// can drop the session.
+func (s *controlSession) handle(data []byte) error {
+	var req controlRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return s.send(controlEvent{Type: eventError, Error: "invalid JSON: " + err.Error()})
+	}
+	fail := func(err error) error {
+		return s.send(controlEvent{Type: eventError, ID: req.ID, Error: err.Error()})
+	}
+
+	switch req.Action {
+	case actionPing:
+		return s.send(controlEvent{Type: eventAck, ID: req.ID})
+
+	case actionSubscribe:
+		if req.Format != "" && req.Format != FormatProtobuf && req.Format != FormatJSON {
+			return fail(fmt.Errorf("format must be %s or %s", FormatProtobuf, FormatJSON))
+		}
+		if req.MaxRate < 0 {
+			return fail(errors.New("max_rate must not be negative"))
+		}
+		s.mu.Lock()
+		s.sub = req.Subscription
+		s.mu.Unlock()
+		n := WsHub.applySession(s.id, req.Subscription)
+		return s.send(controlEvent{Type: eventAck, ID: req.ID, Clients: &n})
+
+	case actionSnapshot:
+		if req.Channel == "" {
+			return fail(errors.New("channel is required"))
+		}
+		if snapshotSource == nil {
+			return fail(errors.New("snapshots are not available"))
+		}
+		n := WsHub.sendSnapshot(s.id, snapshotSource(req.Channel))
+		return s.send(controlEvent{Type: eventAck, ID: req.ID, Clients: &n})
+
+	default:
+		return fail(fmt.Errorf("unknown action %q", req.Action))
+	}
+}
+
+// sessionConns returns the data clients bound to a control session.
+func (h *Hub) sessionConns(id string) []client {
+	var conns []client
+	h.clientsMu.RLock()
+	for conn := range h.clients {
+		if conn.subscription().session == id {
+			conns = append(conns, conn)
+		}
+	}
+	h.clientsMu.RUnlock()
+	return conns
+}
+
+// applySession updates the subscription of the data clients bound to a control
+// session and returns how many were updated.
+func (h *Hub) applySession(id string, s Subscription) int {
+	st := compileSubscription(s)
+	conns := h.sessionConns(id)
+	for _, conn := range conns {
+		conn.subscription().state.Store(st)
+	}
+	return len(conns)
+}
+
+// sendSnapshot writes snapshot messages to the data clients bound to a control
+// session and returns how many received them. Failed clients are cleaned up by
+// their reader loops.
+func (h *Hub) sendSnapshot(id string, msgs [][]byte) int {
+	n := 0
+	for _, conn := range h.sessionConns(id) {
+		sub := conn.subscription()
+		ok := true
+		for _, m := range msgs {
+			var jsonCache []byte
+			messageType, data, encoded := sub.encode(m, &jsonCache)
+			if !encoded {
+				continue
+			}
+			if err := conn.writeMessage(messageType, data); err != nil {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// retainedStates returns a "state" event for every retained state.
+func (h *Hub) retainedStates() []controlEvent {
+	h.retainedMu.RLock()
+	defer h.retainedMu.RUnlock()
+	events := make([]controlEvent, 0, len(h.retained))
+	for key, msg := range h.retained {
+		ev, err := stateEvent(key, msg)
+		if err != nil {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
@@ -8,6 +8,8 @@ package wsserver
 import (
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -25,12 +27,61 @@ const (
 
 	// Broadcast channel buffer size - significantly increased for high throughput
 	broadcastBufferSize = 1000 // Buffer 1 seconds of 1000 msg/sec
+
+	// Default age after which a queued live message is dropped instead of sent
+	defaultMessageTTL = time.Second
+
+	// Default inbound limits for dashboard clients, which only send occasional
+	// control messages
+	defaultLiveRate  = 10
+	defaultLiveBurst = 20
 )
 
+// livePolicy is the inbound policy applied to /ws clients.
+var livePolicy = Policy{
+	Endpoint:   "ws",
+	Rate:       defaultLiveRate,
+	Burst:      defaultLiveBurst,
+	MaxMessage: maxMessageSize,
+}
+
+// SetLiveRateLimit sets the inbound limits of /ws clients. Non-positive values keep
+// the defaults. It must be called before the server starts accepting clients.
+func SetLiveRateLimit(rate float64, burst, maxViolations int) {
+	if rate > 0 {
+		livePolicy.Rate = rate
+	}
+	if burst > 0 {
+		livePolicy.Burst = burst
+	}
+	if maxViolations > 0 {
+		livePolicy.MaxViolations = maxViolations
+	}
+}
+
+// SetMessageTTL sets the maximum age of a queued message at send time. When the
+// broadcast queue backs up, older messages are dropped so live views stay current.
+// Zero keeps the default; a negative ttl disables dropping.
+func SetMessageTTL(ttl time.Duration) {
+	if ttl == 0 {
+		ttl = defaultMessageTTL
+	}
+	WsHub.ttl.Store(int64(ttl))
+}
+
+// client is a live data consumer registered with the hub: a WebSocket connection
+// or an HTTP stream (see stream.go).
+type client interface {
+	writeMessage(messageType int, data []byte) error
+	subscription() *subscriber
+	Close() error
+}
+
 // safeConn wraps a websocket connection with a mutex for thread-safe writes
 type safeConn struct {
 	conn  *websocket.Conn
 	mutex sync.Mutex
+	sub   *subscriber
 }
 
 // writeMessage safely writes a message to the websocket connection
@@ -40,14 +91,39 @@ func (s *safeConn) writeMessage(messageType int, data []byte) error {
 	return s.conn.WriteMessage(messageType, data)
 }
 
+// subscription returns the channel selection and format of the client.
+func (s *safeConn) subscription() *subscriber {
+	return s.sub
+}
+
+// Close closes the underlying websocket connection.
+func (s *safeConn) Close() error {
+	return s.conn.Close()
+}
+
+// message is a queued broadcast message.
+type message struct {
+	data     []byte
+	channel  string    // Message type
+	key      string    // Board, for types sent once per board
+	enqueued time.Time // Zero for messages that never go stale (retained state)
+	retained bool      // Retained state, sent on the control channel to bound clients
+}
+
 // Hub manages active WebSocket connections and broadcasting.
 type Hub struct {
-	clients     map[*safeConn]bool // Active client connections
-	clientsMu   sync.RWMutex       // Mutex for clients map
-	Broadcast   chan []byte        // Channel for outbound messages
-	Register    chan *safeConn     // Channel for new connections
-	Unregister  chan *safeConn     // Channel for closed connections
-	clientCount int32              // Current client count
+	clients     map[client]bool // Active client connections
+	clientsMu   sync.RWMutex    // Mutex for clients map
+	broadcast   chan message    // Channel for outbound messages
+	Register    chan client     // Channel for new connections
+	Unregister  chan client     // Channel for closed connections
+	clientCount int32           // Current client count
+
+	ttl          atomic.Int64  // Maximum message age at send time (time.Duration)
+	staleDropped atomic.Uint64 // Messages dropped for exceeding the TTL
+
+	retained   map[string][]byte // Last message per state key, replayed to new clients
+	retainedMu sync.RWMutex
 }
 
 // WsHub is the global hub instance.
@@ -55,12 +131,98 @@ var WsHub = NewHub()
 
 // NewHub creates and initializes a new Hub.
 func NewHub() *Hub {
-	return &Hub{
-		clients:    make(map[*safeConn]bool),
-		Broadcast:  make(chan []byte, broadcastBufferSize),
-		Register:   make(chan *safeConn, 8),
-		Unregister: make(chan *safeConn, 8),
+	h := &Hub{
+		clients:    make(map[client]bool),
+		broadcast:  make(chan message, broadcastBufferSize),
+		Register:   make(chan client, 8),
+		Unregister: make(chan client, 8),
+		retained:   make(map[string][]byte),
 	}
+	h.ttl.Store(int64(defaultMessageTTL))
+	return h
+}
+
+// TrySend queues msg, a message of the given channel (message type) and board
+// key, for broadcast without blocking. It reports false when the queue is full.
+func (h *Hub) TrySend(channel, key string, msg []byte) bool {
+	select {
+	case h.broadcast <- message{data: msg, channel: channel, key: key, enqueued: time.Now()}:
+		return true
+	default:
+		return false
+	}
+}
+
+// stale reports whether m has been queued for longer than the TTL.
+func (h *Hub) stale(m message) bool {
+	ttl := time.Duration(h.ttl.Load())
+	return ttl > 0 && !m.enqueued.IsZero() && time.Since(m.enqueued) > ttl
+}
+
+// HubStats holds the live broadcast counters.
+type HubStats struct {
+	Clients      int32   `json:"clients"`
+	Queued       int     `json:"queued"`
+	TTLMs        float64 `json:"ttl_ms"`
+	StaleDropped uint64  `json:"stale_dropped"`
+}
+
+// GetHubStats returns the live broadcast counters.
+func GetHubStats() HubStats {
+	WsHub.clientsMu.RLock()
+	clients := WsHub.clientCount
+	WsHub.clientsMu.RUnlock()
+	return HubStats{
+		Clients:      clients,
+		Queued:       len(WsHub.broadcast),
+		TTLMs:        float64(time.Duration(WsHub.ttl.Load())) / float64(time.Millisecond),
+		StaleDropped: WsHub.staleDropped.Load(),
+	}
+}
+
+// SetRetained stores msg as the current value of a server-side state (e.g. the
+// active alerts) and broadcasts it. Clients that connect later receive the latest
+// message of every state before any other message. A nil msg clears the state.
+// The key doubles as the channel clients subscribe to. Control sessions receive
+// the change as a "state" event instead of their data sockets.
+func (h *Hub) SetRetained(key string, msg []byte) {
+	h.retainedMu.Lock()
+	if msg == nil {
+		delete(h.retained, key)
+	} else {
+		h.retained[key] = msg
+	}
+	h.retainedMu.Unlock()
+
+	publishState(key, msg)
+	if msg != nil {
+		h.broadcast <- message{data: msg, channel: key, retained: true}
+	}
+}
+
+// sendRetained writes every retained state message the client subscribes to to a
+// newly registered client.
+func (h *Hub) sendRetained(conn client) error {
+	sub := conn.subscription()
+	if sub.session != "" {
+		return nil // Sent on the control channel
+	}
+	h.retainedMu.RLock()
+	defer h.retainedMu.RUnlock()
+	for key, msg := range h.retained {
+		if !sub.selects(key) {
+			continue
+		}
+		var jsonCache []byte
+		messageType, data, ok := sub.encode(msg, &jsonCache)
+		if !ok {
+			continue
+		}
+		if err := conn.writeMessage(messageType, data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Run continuously processes registration, unregistration and broadcasting.
@@ -71,37 +233,61 @@ func (h *Hub) Run() {
 			h.clientsMu.Lock()
 			if h.clientCount >= maxClients {
 				h.clientsMu.Unlock()
-				conn.conn.Close()
+				conn.Close()
 				continue
 			}
 			h.clientCount++
 			h.clients[conn] = true
 			h.clientsMu.Unlock()
 
+			// Bring the client up to date with the retained state; a failed write
+			// is cleaned up by the reader loop
+			h.sendRetained(conn)
+
 		case conn := <-h.Unregister:
 			h.clientsMu.Lock()
 			if _, ok := h.clients[conn]; ok {
 				delete(h.clients, conn)
-				conn.conn.Close()
+				conn.Close()
 				h.clientCount--
 			}
 			h.clientsMu.Unlock()
 
-		case message := <-h.Broadcast:
+		case msg := <-h.broadcast:
+			// Prefer freshness over completeness: a message that waited too long
+			// in the queue is dropped rather than delivered late. It is decided
+			// once, so every client gets the same message.
+			if h.stale(msg) {
+				h.staleDropped.Add(1)
+				continue
+			}
 			h.clientsMu.RLock()
 			if len(h.clients) == 0 {
 				h.clientsMu.RUnlock()
 				continue
 			}
-			conns := make([]*safeConn, 0, len(h.clients))
+			conns := make([]client, 0, len(h.clients))
 			for conn := range h.clients {
 				conns = append(conns, conn)
 			}
 			h.clientsMu.RUnlock()
 
-			var failedConns []*safeConn
+			var failedConns []client
+			var jsonCache []byte
+			now := time.Now()
 			for _, conn := range conns {
-				if err := conn.writeMessage(websocket.BinaryMessage, message); err != nil {
+				sub := conn.subscription()
+				if msg.retained && sub.session != "" {
+					continue // Sent on the control channel
+				}
+				if !sub.wants(msg.channel, msg.key, now) {
+					continue
+				}
+				messageType, data, ok := sub.encode(msg.data, &jsonCache)
+				if !ok {
+					continue
+				}
+				if err := conn.writeMessage(messageType, data); err != nil {
 					failedConns = append(failedConns, conn)
 				}
 			}
@@ -110,7 +296,7 @@ func (h *Hub) Run() {
 				h.clientsMu.Lock()
 				for _, conn := range failedConns {
 					delete(h.clients, conn)
-					conn.conn.Close()
+					conn.Close()
 					h.clientCount--
 				}
 				h.clientsMu.Unlock()
@@ -119,8 +305,14 @@ func (h *Hub) Run() {
 	}
 }
 
-// ServeWS upgrades an HTTP request to a WebSocket connection and registers the client.
+// ServeWS upgrades an HTTP request to a WebSocket connection and registers the
+// client with the subscription of its ?session= or ?profile=, if any.
 func ServeWS(w http.ResponseWriter, r *http.Request) {
+	sub, err := subscriberFromRequest(r)
+	if err != nil {
+		rejectSubscription(w, err)
+		return
+	}
 	upgrader := websocket.Upgrader{
 		CheckOrigin:     func(r *http.Request) bool { return true },
 		ReadBufferSize:  wsReadBufferSize,
@@ -132,23 +324,38 @@ func ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create a safe connection wrapper
-	safeConn := &safeConn{conn: wsConn}
+	safeConn := &safeConn{conn: wsConn, sub: sub}
 
 	// Set read limit
-	wsConn.SetReadLimit(maxMessageSize)
+	wsConn.SetReadLimit(livePolicy.MaxMessage)
 
 	// Register the connection
 	WsHub.Register <- safeConn
 
-	// Simple reader loop - just reads until connection is closed
+	// Reader loop - reads until the connection is closed, answering control
+	// messages and dropping clients that flood the server or send malformed
+	// messages
+	limiter := NewClientLimiter(livePolicy)
 	go func() {
 		defer func() {
 			WsHub.Unregister <- safeConn
 		}()
 		for {
-			if _, _, err := wsConn.ReadMessage(); err != nil {
+			messageType, data, err := wsConn.ReadMessage()
+			if err != nil {
 				break // If error, break the loop which will trigger unregister
 			}
+			abusive, err := limiter.Check(messageType, data)
+			if abusive {
+				limiter.Disconnect(wsConn, err)
+				break
+			}
+			if err != nil {
+				continue
+			}
+			if err := handleControl(safeConn, messageType, data); err != nil {
+				break
+			}
 		}
 	}()
 }
@@ -0,0 +1,192 @@
+// ratelimit.go
+// ----------------------------------------------------------------------
+// Per-connection inbound rate limiting and payload validation.
+// Each connection gets a token bucket; messages arriving with an empty bucket
+// or failing validation are dropped and count as violations. A client that
+// keeps violating the policy is disconnected with a policy-violation close.
+// ----------------------------------------------------------------------
+package wsserver
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Violations within violationWindow after which a client is disconnected
+	defaultMaxViolations = 20
+
+	// Window over which violations are counted
+	violationWindow = 10 * time.Second
+
+	// How long a policy-violation close frame may take to send
+	closeWriteTimeout = time.Second
+)
+
+// Rejection reasons
+var (
+	ErrRateLimited  = errors.New("rate limit exceeded")
+	ErrEmptyMessage = errors.New("empty message")
+	ErrInvalidUTF8  = errors.New("text message is not valid UTF-8")
+)
+
+// Policy describes the inbound limits of an endpoint.
+type Policy struct {
+	Endpoint      string  // Name used in the stats
+	Rate          float64 // Sustained messages per second
+	Burst         int     // Bucket size
+	MaxViolations int     // Violations within the window before disconnecting; <=0 selects the default
+	MaxMessage    int64   // Read limit in bytes
+	// Validate checks a message payload; nil accepts any non-empty message.
+	Validate func(messageType int, data []byte) error
+}
+
+// LimiterStats reports the violation counters of an endpoint.
+type LimiterStats struct {
+	Endpoint     string `json:"endpoint"`
+	Accepted     uint64 `json:"accepted"`
+	RateLimited  uint64 `json:"rate_limited"`
+	Invalid      uint64 `json:"invalid"`
+	Disconnected uint64 `json:"disconnected"`
+}
+
+// endpointCounters holds the counters of one endpoint.
+type endpointCounters struct {
+	accepted     uint64
+	rateLimited  uint64
+	invalid      uint64
+	disconnected uint64
+}
+
+var (
+	countersMu sync.Mutex
+	counters   = make(map[string]*endpointCounters)
+)
+
+// countersFor returns the counters of an endpoint, creating them on first use.
+func countersFor(endpoint string) *endpointCounters {
+	countersMu.Lock()
+	defer countersMu.Unlock()
+	c, ok := counters[endpoint]
+	if !ok {
+		c = &endpointCounters{}
+		counters[endpoint] = c
+	}
+	return c
+}
+
+// GetLimiterStats returns the counters of every endpoint with a limiter.
+func GetLimiterStats() []LimiterStats {
+	countersMu.Lock()
+	defer countersMu.Unlock()
+	stats := make([]LimiterStats, 0, len(counters))
+	for name, c := range counters {
+		stats = append(stats, LimiterStats{
+			Endpoint:     name,
+			Accepted:     atomic.LoadUint64(&c.accepted),
+			RateLimited:  atomic.LoadUint64(&c.rateLimited),
+			Invalid:      atomic.LoadUint64(&c.invalid),
+			Disconnected: atomic.LoadUint64(&c.disconnected),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Endpoint < stats[j].Endpoint })
+	return stats
+}
+
+// ClientLimiter enforces a Policy on a single connection. It is used from the
+// connection's read loop only and is not safe for concurrent use.
+type ClientLimiter struct {
+	policy      Policy
+	counters    *endpointCounters
+	tokens      float64
+	last        time.Time
+	violations  int
+	windowStart time.Time
+}
+
+// NewClientLimiter returns a limiter with a full bucket.
+func NewClientLimiter(p Policy) *ClientLimiter {
+	if p.MaxViolations <= 0 {
+		p.MaxViolations = defaultMaxViolations
+	}
+	if p.Burst < 1 {
+		p.Burst = 1
+	}
+	now := time.Now()
+	return &ClientLimiter{
+		policy:      p,
+		counters:    countersFor(p.Endpoint),
+		tokens:      float64(p.Burst),
+		last:        now,
+		windowStart: now,
+	}
+}
+
+// Check decides whether a message is processed. A non-nil error means the
+// message must be dropped; abusive reports whether the client has exceeded its
+// violation budget and must be disconnected.
+func (l *ClientLimiter) Check(messageType int, data []byte) (abusive bool, err error) {
+	now := time.Now()
+
+	// Refill the bucket
+	if l.policy.Rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.policy.Rate
+		if l.tokens > float64(l.policy.Burst) {
+			l.tokens = float64(l.policy.Burst)
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		atomic.AddUint64(&l.counters.rateLimited, 1)
+		return l.violation(now), ErrRateLimited
+	}
+	l.tokens--
+
+	if err := l.validate(messageType, data); err != nil {
+		atomic.AddUint64(&l.counters.invalid, 1)
+		return l.violation(now), err
+	}
+	atomic.AddUint64(&l.counters.accepted, 1)
+	return false, nil
+}
+
+// validate applies the basic checks followed by the policy validator.
+func (l *ClientLimiter) validate(messageType int, data []byte) error {
+	if len(data) == 0 {
+		return ErrEmptyMessage
+	}
+	if messageType == websocket.TextMessage && !utf8.Valid(data) {
+		return ErrInvalidUTF8
+	}
+	if l.policy.Validate != nil {
+		return l.policy.Validate(messageType, data)
+	}
+	return nil
+}
+
+// violation records a violation and reports whether the budget is exhausted.
+func (l *ClientLimiter) violation(now time.Time) bool {
+	if now.Sub(l.windowStart) > violationWindow {
+		l.windowStart = now
+		l.violations = 0
+	}
+	l.violations++
+	return l.violations > l.policy.MaxViolations
+}
+
+// Disconnect closes conn with a policy-violation status and counts the
+// disconnect. reason is sent to the client in the close frame.
+func (l *ClientLimiter) Disconnect(conn *websocket.Conn, reason error) {
+	atomic.AddUint64(&l.counters.disconnected, 1)
+	msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, fmt.Sprintf("too many violations: %v", reason))
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(closeWriteTimeout))
+	conn.Close()
+}
@@ -0,0 +1,148 @@
+// stream.go
+//
+// HTTP streaming fallback for networks that block WebSockets. /stream delivers the
+// same throttled live messages as /ws over a single chunked HTTP response. Each
+// message is framed as a 4-byte big-endian length followed by the binary
+// TelemetryMessage; zero-length frames are heartbeats that keep proxies from
+// closing an idle response. /transports lists the available transports in order
+// of preference so clients can negotiate one.
+package wsserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	// Content type of the length-prefixed stream
+	streamContentType = "application/x-telemetry-stream"
+
+	// Interval between heartbeat frames
+	streamHeartbeatInterval = 15 * time.Second
+
+	// A stream write blocked for longer than this drops the client
+	streamWriteTimeout = 5 * time.Second
+)
+
+var errStreamClosed = errors.New("stream closed")
+
+// streamConn is a hub client writing to a streaming HTTP response.
+type streamConn struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	mu        sync.Mutex
+	sub       *subscriber
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// writeMessage writes a single length-prefixed frame and flushes it.
+func (s *streamConn) writeMessage(_ int, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return errStreamClosed
+	default:
+	}
+
+	s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+	if _, err := s.w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// subscription returns the channel selection and format of the client.
+func (s *streamConn) subscription() *subscriber {
+	return s.sub
+}
+
+// Close ends the stream; the handler returns and completes the response.
+func (s *streamConn) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return nil
+}
+
+// ServeStream streams live messages over a chunked HTTP response until the client
+// disconnects or the hub drops it. Like /ws it accepts ?session= and ?profile=.
+func ServeStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sub, err := subscriberFromRequest(r)
+	if err != nil {
+		rejectSubscription(w, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", streamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable buffering in nginx-style proxies
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return // Streaming not supported by this connection
+	}
+
+	conn := &streamConn{w: w, rc: rc, sub: sub, done: make(chan struct{})}
+	WsHub.Register <- conn
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-heartbeat.C:
+			if err := conn.writeMessage(0, nil); err != nil {
+				conn.Close()
+			}
+		case <-r.Context().Done():
+			conn.Close()
+		case <-conn.done:
+			// Wait for a write in progress; later writes see the stream closed
+			conn.mu.Lock()
+			conn.mu.Unlock()
+			WsHub.Unregister <- conn
+			return
+		}
+	}
+}
+
+// Transport describes a live data transport offered to clients.
+type Transport struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Framing string `json:"framing"`
+}
+
+// transports lists the live transports in order of preference.
+var transports = []Transport{
+	{Name: "websocket", Path: "/ws", Framing: "binary message per TelemetryMessage"},
+	{Name: "stream", Path: "/stream", Framing: "uint32 big-endian length + TelemetryMessage; length 0 is a heartbeat"},
+}
+
+// ServeTransports lists the live transports so client SDKs can fall back from
+// WebSockets to HTTP streaming when the former is blocked, and the path of the
+// control channel.
+func ServeTransports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	render.JSON(w, r, map[string]interface{}{"transports": transports, "control": "/ws/control"})
+}
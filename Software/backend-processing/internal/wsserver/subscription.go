@@ -0,0 +1,195 @@
+// subscription.go
+//
+// Per-client subscriptions. A live client connecting with ?profile=<name> gets
+// the settings of that saved profile: the channels (message types) it receives,
+// a maximum rate per channel and board, and the message format. Clients without
+// a profile receive every channel as binary TelemetryMessages. Saving a profile
+// reconfigures the connected clients using it, so kiosk displays can be changed
+// remotely. Clients connecting with ?session=<id> are bound to a control session
+// instead (see controlplane.go), which sets their subscription.
+package wsserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	pb "telem-system/proto"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Message formats
+const (
+	FormatProtobuf = "protobuf" // Binary TelemetryMessage (default)
+	FormatJSON     = "json"     // TelemetryMessage in its JSON mapping, as text messages
+)
+
+// Subscription selects and paces the live messages of a client.
+type Subscription struct {
+	Channels []string `json:"channels"` // Message types; empty selects all
+	MaxRate  float64  `json:"max_rate"` // Messages per second per channel and board; 0 is unlimited
+	Format   string   `json:"format"`   // FormatProtobuf or FormatJSON; empty selects protobuf
+}
+
+// ProfileFunc returns the subscription saved under a profile name. It reports
+// false when there is no such profile.
+type ProfileFunc func(ctx context.Context, name string) (Subscription, bool, error)
+
+// profileSource resolves ?profile=; nil rejects clients asking for a profile.
+var profileSource ProfileFunc
+
+// SetProfileSource installs the lookup of subscription profiles. It must be
+// called before the server starts accepting clients.
+func SetProfileSource(fn ProfileFunc) {
+	profileSource = fn
+}
+
+var errUnknownProfile = errors.New("unknown subscription profile")
+
+// subscriptionState is the compiled form of a Subscription.
+type subscriptionState struct {
+	channels map[string]bool // nil selects all
+	interval time.Duration   // Minimum spacing per channel and board; 0 is unlimited
+	json     bool
+}
+
+func compileSubscription(s Subscription) *subscriptionState {
+	st := &subscriptionState{json: s.Format == FormatJSON}
+	if len(s.Channels) > 0 {
+		st.channels = make(map[string]bool, len(s.Channels))
+		for _, c := range s.Channels {
+			st.channels[c] = true
+		}
+	}
+	if s.MaxRate > 0 {
+		st.interval = time.Duration(float64(time.Second) / s.MaxRate)
+	}
+	return st
+}
+
+// subscriber is the subscription of one client.
+type subscriber struct {
+	profile  string // Profile name; empty for the default subscription
+	session  string // Control session the client is bound to; empty when unbound
+	state    atomic.Pointer[subscriptionState]
+	lastSent map[string]time.Time // Channel and board -> last send; used by the hub goroutine only
+}
+
+func newSubscriber(profile string, s Subscription) *subscriber {
+	sub := &subscriber{profile: profile, lastSent: make(map[string]time.Time)}
+	sub.state.Store(compileSubscription(s))
+	return sub
+}
+
+// subscriberFromRequest returns the subscription selected by the session or
+// profile query parameter of r.
+func subscriberFromRequest(r *http.Request) (*subscriber, error) {
+	if id := r.URL.Query().Get("session"); id != "" {
+		return sessionSubscriber(id)
+	}
+	name := r.URL.Query().Get("profile")
+	if name == "" {
+		return newSubscriber("", Subscription{}), nil
+	}
+	if profileSource == nil {
+		return nil, errUnknownProfile
+	}
+	s, ok, err := profileSource(r.Context(), name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownProfile, name)
+	}
+	return newSubscriber(name, s), nil
+}
+
+// rejectSubscription answers a request whose profile or session could not be
+// resolved.
+func rejectSubscription(w http.ResponseWriter, err error) {
+	if errors.Is(err, errUnknownProfile) || errors.Is(err, errUnknownSession) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, "profile lookup failed", http.StatusInternalServerError)
+}
+
+// selects reports whether the subscription includes channel.
+func (s *subscriber) selects(channel string) bool {
+	st := s.state.Load()
+	return st.channels == nil || st.channels[channel]
+}
+
+// wants reports whether a broadcast message of channel and board key is due for
+// this client, recording the send when it is. It must only be called by the hub
+// goroutine.
+func (s *subscriber) wants(channel, key string, now time.Time) bool {
+	st := s.state.Load()
+	if st.channels != nil && !st.channels[channel] {
+		return false
+	}
+	if st.interval <= 0 {
+		return true
+	}
+	k := channel + "\x00" + key
+	if last, ok := s.lastSent[k]; ok && now.Sub(last) < st.interval {
+		return false
+	}
+	s.lastSent[k] = now
+	return true
+}
+
+// encode returns the WebSocket message type and payload of a binary
+// TelemetryMessage in the client's format. The JSON form is computed once per
+// message and kept in jsonCache; ok is false when it cannot be produced.
+func (s *subscriber) encode(data []byte, jsonCache *[]byte) (messageType int, payload []byte, ok bool) {
+	if !s.state.Load().json {
+		return websocket.BinaryMessage, data, true
+	}
+	if *jsonCache == nil {
+		var msg pb.TelemetryMessage
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return 0, nil, false
+		}
+		b, err := protojson.Marshal(&msg)
+		if err != nil {
+			return 0, nil, false
+		}
+		*jsonCache = b
+	}
+	return websocket.TextMessage, *jsonCache, true
+}
+
+// ApplyProfile updates the subscription of every connected client using the
+// named profile and returns how many clients were updated.
+func (h *Hub) ApplyProfile(name string, s Subscription) int {
+	st := compileSubscription(s)
+	n := 0
+	h.clientsMu.RLock()
+	for conn := range h.clients {
+		if sub := conn.subscription(); sub.profile == name {
+			sub.state.Store(st)
+			n++
+		}
+	}
+	h.clientsMu.RUnlock()
+	return n
+}
+
+// ProfileClients returns the number of connected clients per profile name.
+func (h *Hub) ProfileClients() map[string]int {
+	counts := make(map[string]int)
+	h.clientsMu.RLock()
+	for conn := range h.clients {
+		if p := conn.subscription().profile; p != "" {
+			counts[p]++
+		}
+	}
+	h.clientsMu.RUnlock()
+	return counts
+}
@@ -0,0 +1,174 @@
+// alerts.go
+//
+// Package alerts keeps track of server-side alert conditions. Subsystems raise an
+// alert under a stable key while a condition holds and resolve it once it clears;
+// raising an already active alert only updates its value. Subscribers are notified
+// of every state change (raise, update of message/severity, resolve). Alerts can
+// be muted by key or source, e.g. shutdown trips on the dyno; muted alerts are
+// never raised. Independently of muting, alerts can be suppressed for as long as
+// the vehicle is in a state where they do not apply (e.g. low-speed alerts while
+// charging). Besides the alerts raised by subsystems, configurable threshold
+// rules raise alerts from decoded signal values (see rules.go).
+package alerts
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Alert severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert describes a single alert condition.
+type Alert struct {
+	Key        string    `json:"key"`
+	Source     string    `json:"source"`
+	Severity   string    `json:"severity"`
+	Message    string    `json:"message"`
+	Value      float64   `json:"value"`
+	Threshold  float64   `json:"threshold"`
+	Active     bool      `json:"active"`
+	RaisedAt   time.Time `json:"raised_at"`
+	ResolvedAt time.Time `json:"resolved_at,omitempty"`
+}
+
+var (
+	mu          sync.RWMutex
+	active      = make(map[string]*Alert)
+	muted       = make(map[string]bool) // Alert keys and sources
+	suppressed  = make(map[string]bool) // Alert keys and sources, set by the vehicle state
+	subscribers []func(Alert)
+)
+
+// Subscribe registers fn to be called on every alert state change. fn is called
+// synchronously and must not block.
+func Subscribe(fn func(Alert)) {
+	mu.Lock()
+	subscribers = append(subscribers, fn)
+	mu.Unlock()
+}
+
+// SetMuted replaces the muted alert keys and sources. Active alerts that become
+// muted are resolved.
+func SetMuted(keysOrSources []string) {
+	mu.Lock()
+	muted = make(map[string]bool, len(keysOrSources))
+	for _, k := range keysOrSources {
+		muted[k] = true
+	}
+	var resolve []string
+	for key, a := range active {
+		if muted[key] || muted[a.Source] {
+			resolve = append(resolve, key)
+		}
+	}
+	mu.Unlock()
+
+	for _, key := range resolve {
+		Resolve(key)
+	}
+}
+
+// SetSuppressed replaces the suppressed alert keys and sources. Active alerts
+// that become suppressed are resolved; suppressed alerts are not raised.
+func SetSuppressed(keysOrSources []string) {
+	mu.Lock()
+	suppressed = make(map[string]bool, len(keysOrSources))
+	for _, k := range keysOrSources {
+		suppressed[k] = true
+	}
+	var resolve []string
+	for key, a := range active {
+		if suppressed[key] || suppressed[a.Source] {
+			resolve = append(resolve, key)
+		}
+	}
+	mu.Unlock()
+
+	for _, key := range resolve {
+		Resolve(key)
+	}
+}
+
+// Raise activates the alert a.Key, or updates it if it is already active.
+// Subscribers are only notified when the alert becomes active or its severity
+// or message changes, not on every value update.
+func Raise(a Alert) {
+	mu.Lock()
+	if muted[a.Key] || muted[a.Source] || suppressed[a.Key] || suppressed[a.Source] {
+		mu.Unlock()
+		return
+	}
+	existing, ok := active[a.Key]
+	changed := !ok || existing.Severity != a.Severity || existing.Message != a.Message
+	if ok {
+		a.RaisedAt = existing.RaisedAt
+	} else if a.RaisedAt.IsZero() {
+		a.RaisedAt = time.Now()
+	}
+	a.Active = true
+	a.ResolvedAt = time.Time{}
+	stored := a
+	active[a.Key] = &stored
+	subs := subscribers
+	mu.Unlock()
+
+	if changed {
+		notify(subs, a)
+	}
+}
+
+// Resolve clears the alert with the given key. It is a no-op if the alert is not active.
+func Resolve(key string) {
+	mu.Lock()
+	existing, ok := active[key]
+	if !ok {
+		mu.Unlock()
+		return
+	}
+	delete(active, key)
+	a := *existing
+	a.Active = false
+	a.ResolvedAt = time.Now()
+	subs := subscribers
+	mu.Unlock()
+
+	notify(subs, a)
+}
+
+// IsActive reports whether the alert with the given key is active.
+func IsActive(key string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := active[key]
+	return ok
+}
+
+// Active returns all active alerts, oldest first.
+func Active() []Alert {
+	mu.RLock()
+	out := make([]Alert, 0, len(active))
+	for _, a := range active {
+		out = append(out, *a)
+	}
+	mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].RaisedAt.Equal(out[j].RaisedAt) {
+			return out[i].RaisedAt.Before(out[j].RaisedAt)
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+func notify(subs []func(Alert), a Alert) {
+	for _, fn := range subs {
+		fn(a)
+	}
+}
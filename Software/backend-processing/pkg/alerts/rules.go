@@ -0,0 +1,310 @@
+// rules.go
+//
+// Threshold rules. A rule raises an alert while a decoded CAN signal is above or
+// below a threshold, optionally only after the condition has held for a while,
+// and resolves it once the value is back inside the threshold by the hysteresis
+// margin. Rules are grouped into named rule sets (e.g. track, dyno) and the
+// active sets are selected by the configuration profile, so the same signal can
+// be watched with different thresholds and severities per context.
+package alerts
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RuleSource is the source of every rule alert; muting it mutes all rules.
+const RuleSource = "rules"
+
+// Rule raises an alert from a decoded signal value.
+type Rule struct {
+	Name       string   `json:"name"`     // Alert key "rule.<name>"
+	Signal     string   `json:"signal"`   // Decoded CAN signal name
+	FrameID    uint32   `json:"frame_id"` // Only this frame's signal; 0 matches any frame
+	Above      *float64 `json:"above,omitempty"`
+	Below      *float64 `json:"below,omitempty"`
+	Severity   string   `json:"severity"`
+	ForMs      int      `json:"for_ms"`     // Condition must hold this long before raising
+	Hysteresis float64  `json:"hysteresis"` // Margin inside the threshold before resolving
+	Message    string   `json:"message"`    // Default "<signal> above/below <threshold>"
+}
+
+// ruleState is an active rule and its evaluation state.
+type ruleState struct {
+	Rule
+	key      string
+	breached time.Time // Start of the current breach; zero while inside the threshold
+	raised   bool
+}
+
+var (
+	rulesMu     sync.Mutex
+	ruleSets    = make(map[string][]Rule)
+	activeSets  []string
+	rulesByName = make(map[string][]*ruleState) // Active rules by signal name
+)
+
+// RuleKey returns the alert key of a rule.
+func RuleKey(name string) string {
+	return "rule." + name
+}
+
+// validate checks a rule and fills in its defaults.
+func (r *Rule) validate() error {
+	if r.Name == "" || r.Signal == "" {
+		return fmt.Errorf("rule needs a name and a signal")
+	}
+	if r.Above == nil && r.Below == nil {
+		return fmt.Errorf("rule %s: needs an above or below threshold", r.Name)
+	}
+	if r.Above != nil && r.Below != nil && *r.Below >= *r.Above {
+		return fmt.Errorf("rule %s: below must be less than above", r.Name)
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityWarning
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return fmt.Errorf("rule %s: unknown severity %q", r.Name, r.Severity)
+	}
+	if r.ForMs < 0 || r.Hysteresis < 0 {
+		return fmt.Errorf("rule %s: for and hysteresis must not be negative", r.Name)
+	}
+	return nil
+}
+
+// ConfigureRuleSets installs the named rule sets. Active sets are replaced by
+// sets of the same name and deactivated if their set is gone.
+func ConfigureRuleSets(sets map[string][]Rule) error {
+	named := make(map[string][]Rule, len(sets))
+	for name, rules := range sets {
+		seen := make(map[string]bool, len(rules))
+		checked := make([]Rule, len(rules))
+		for i, r := range rules {
+			if err := r.validate(); err != nil {
+				return fmt.Errorf("rule set %s: %w", name, err)
+			}
+			if seen[r.Name] {
+				return fmt.Errorf("rule set %s: duplicate rule %s", name, r.Name)
+			}
+			seen[r.Name] = true
+			checked[i] = r
+		}
+		named[name] = checked
+	}
+
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	ruleSets = named
+	var keep []string
+	for _, name := range activeSets {
+		if _, ok := named[name]; ok {
+			keep = append(keep, name)
+		}
+	}
+	activateLocked(keep)
+	return nil
+}
+
+// RuleSetNames returns the configured rule set names, sorted.
+func RuleSetNames() []string {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	names := make([]string, 0, len(ruleSets))
+	for name := range ruleSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckRuleSets reports an error if a name is not a configured rule set or two
+// of the sets define a rule of the same name.
+func CheckRuleSets(names []string) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	_, err := resolveLocked(names)
+	return err
+}
+
+// ActivateRuleSets makes the named rule sets the active ones. Rules that stay
+// active unchanged keep their state; alerts of the others are resolved.
+func ActivateRuleSets(names []string) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if _, err := resolveLocked(names); err != nil {
+		return err
+	}
+	activateLocked(names)
+	return nil
+}
+
+// ActiveRuleSets returns the names of the active rule sets.
+func ActiveRuleSets() []string {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	return append([]string(nil), activeSets...)
+}
+
+// ActiveRules returns the rules of the active rule sets, ordered by name.
+func ActiveRules() []Rule {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	var out []Rule
+	for _, states := range rulesByName {
+		for _, s := range states {
+			out = append(out, s.Rule)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// resolveLocked returns the rules of the named sets. The caller holds rulesMu.
+func resolveLocked(names []string) ([]Rule, error) {
+	var rules []Rule
+	from := make(map[string]string) // Defining set by rule name
+	for i, name := range names {
+		set, ok := ruleSets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown alert rule set %q", name)
+		}
+		if slices.Contains(names[:i], name) {
+			continue
+		}
+		for _, r := range set {
+			if other, dup := from[r.Name]; dup {
+				return nil, fmt.Errorf("rule %s is defined by both rule sets %s and %s", r.Name, other, name)
+			}
+			from[r.Name] = name
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// activateLocked replaces the active rules. Raise and Resolve are called under
+// rulesMu so that a concurrent evaluation cannot raise an alert of a rule after
+// it was deactivated. The caller holds rulesMu and has checked names.
+func activateLocked(names []string) {
+	rules, _ := resolveLocked(names)
+	previous := make(map[string]*ruleState)
+	for _, states := range rulesByName {
+		for _, s := range states {
+			previous[s.Name] = s
+		}
+	}
+
+	activeSets = append([]string(nil), names...)
+	rulesByName = make(map[string][]*ruleState, len(rules))
+	for _, r := range rules {
+		s, ok := previous[r.Name]
+		if ok && s.Rule.equal(r) {
+			delete(previous, r.Name)
+		} else {
+			s = &ruleState{Rule: r, key: RuleKey(r.Name)}
+		}
+		rulesByName[r.Signal] = append(rulesByName[r.Signal], s)
+	}
+	for _, s := range previous {
+		if s.raised {
+			Resolve(s.key)
+		}
+	}
+}
+
+// equal reports whether two rules have the same definition.
+func (r Rule) equal(o Rule) bool {
+	sameBound := func(a, b *float64) bool { return a == nil && b == nil || a != nil && b != nil && *a == *b }
+	a, b := r, o
+	a.Above, a.Below, b.Above, b.Below = nil, nil, nil, nil
+	return a == b && sameBound(r.Above, o.Above) && sameBound(r.Below, o.Below)
+}
+
+// EvaluateRules checks the decoded signals of a frame against the active rules.
+func EvaluateRules(frameID uint32, decoded map[string]string, t time.Time) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if len(rulesByName) == 0 {
+		return
+	}
+	for signal, raw := range decoded {
+		states := rulesByName[signal]
+		if len(states) == 0 {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+		for _, s := range states {
+			if s.FrameID == 0 || s.FrameID == frameID {
+				s.evaluate(v, t)
+			}
+		}
+	}
+}
+
+// evaluate updates the rule with a value and raises or resolves its alert. The
+// caller holds rulesMu.
+func (s *ruleState) evaluate(v float64, t time.Time) {
+	threshold, breach := s.breach(v)
+	if !breach {
+		s.breached = time.Time{}
+		if s.raised && s.cleared(v) {
+			s.raised = false
+			Resolve(s.key)
+		}
+		return
+	}
+	if s.breached.IsZero() {
+		s.breached = t
+	}
+	if !s.raised && t.Sub(s.breached) < time.Duration(s.ForMs)*time.Millisecond {
+		return
+	}
+	msg := s.Message
+	if msg == "" {
+		dir := "above"
+		if s.Below != nil && v < *s.Below {
+			dir = "below"
+		}
+		msg = fmt.Sprintf("%s %s %g", s.Signal, dir, threshold)
+	}
+	s.raised = true
+	Raise(Alert{
+		Key:       s.key,
+		Source:    RuleSource,
+		Severity:  s.Severity,
+		Message:   msg,
+		Value:     v,
+		Threshold: threshold,
+	})
+}
+
+// breach reports whether v is outside the rule's thresholds and which threshold
+// it crossed.
+func (s *ruleState) breach(v float64) (float64, bool) {
+	if s.Above != nil && v > *s.Above {
+		return *s.Above, true
+	}
+	if s.Below != nil && v < *s.Below {
+		return *s.Below, true
+	}
+	return 0, false
+}
+
+// cleared reports whether v is inside the thresholds by the hysteresis margin.
+func (s *ruleState) cleared(v float64) bool {
+	if s.Above != nil && v > *s.Above-s.Hysteresis {
+		return false
+	}
+	if s.Below != nil && v < *s.Below+s.Hysteresis {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+func bound(v float64) *float64 { return &v }
+
+// setRules installs rule sets, activates names and clears all alerts.
+func setRules(t *testing.T, sets map[string][]Rule, names ...string) {
+	t.Helper()
+	if err := ConfigureRuleSets(sets); err != nil {
+		t.Fatal(err)
+	}
+	if err := ActivateRuleSets(names); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ConfigureRuleSets(nil)
+		for _, a := range Active() {
+			Resolve(a.Key)
+		}
+	})
+}
+
+func activeAlert(key string) (Alert, bool) {
+	for _, a := range Active() {
+		if a.Key == key {
+			return a, true
+		}
+	}
+	return Alert{}, false
+}
+
+func TestRuleRaiseAndResolve(t *testing.T) {
+	setRules(t, map[string][]Rule{
+		"track": {{Name: "pack_temp", Signal: "MaxTemp", Above: bound(55), Severity: SeverityCritical, ForMs: 1000, Hysteresis: 2}},
+	}, "track")
+	key := RuleKey("pack_temp")
+	start := time.Unix(1700000000, 0)
+	eval := func(v string, after time.Duration) {
+		EvaluateRules(60, map[string]string{"MaxTemp": v, "Other": "99"}, start.Add(after))
+	}
+
+	eval("56", 0)
+	if IsActive(key) {
+		t.Fatal("raised before the condition held for for_ms")
+	}
+	eval("57", 999*time.Millisecond)
+	if IsActive(key) {
+		t.Fatal("raised before the condition held for for_ms")
+	}
+	eval("58", time.Second)
+	a, ok := activeAlert(key)
+	if !ok {
+		t.Fatal("not raised after the condition held for for_ms")
+	}
+	if a.Severity != SeverityCritical || a.Source != RuleSource || a.Value != 58 || a.Threshold != 55 || a.Message != "MaxTemp above 55" {
+		t.Errorf("alert = %+v", a)
+	}
+
+	eval("54", 2*time.Second) // Inside the threshold, not by the hysteresis margin
+	if !IsActive(key) {
+		t.Fatal("resolved inside the hysteresis margin")
+	}
+	eval("56", 3*time.Second) // Still raised: no second for_ms wait
+	if !IsActive(key) {
+		t.Fatal("resolved while above the threshold")
+	}
+	eval("53", 4*time.Second)
+	if IsActive(key) {
+		t.Fatal("not resolved below the threshold minus the hysteresis")
+	}
+
+	// A breach shorter than for_ms never raises
+	eval("60", 5*time.Second)
+	eval("50", 5500*time.Millisecond)
+	eval("60", 6*time.Second)
+	eval("60", 6900*time.Millisecond)
+	if IsActive(key) {
+		t.Fatal("raised although the breach was interrupted")
+	}
+}
+
+func TestRuleBelowAndFrame(t *testing.T) {
+	setRules(t, map[string][]Rule{
+		"garage": {{Name: "lv_low", Signal: "Voltage", FrameID: 8, Below: bound(11.5), Above: bound(15)}},
+	}, "garage")
+	key := RuleKey("lv_low")
+	now := time.Now()
+
+	EvaluateRules(9, map[string]string{"Voltage": "10"}, now)
+	if IsActive(key) {
+		t.Fatal("raised from another frame")
+	}
+	EvaluateRules(8, map[string]string{"Voltage": "not a number"}, now)
+	EvaluateRules(8, map[string]string{"Voltage": "10"}, now)
+	a, ok := activeAlert(key)
+	if !ok || a.Severity != SeverityWarning || a.Message != "Voltage below 11.5" || a.Threshold != 11.5 {
+		t.Fatalf("alert = %+v, active %v", a, ok)
+	}
+	EvaluateRules(8, map[string]string{"Voltage": "16"}, now)
+	if a, _ := activeAlert(key); a.Message != "Voltage above 15" || a.Threshold != 15 {
+		t.Fatalf("alert after crossing the other bound = %+v", a)
+	}
+	EvaluateRules(8, map[string]string{"Voltage": "12"}, now)
+	if IsActive(key) {
+		t.Fatal("not resolved inside both bounds")
+	}
+}
+
+func TestRuleSetActivation(t *testing.T) {
+	track := Rule{Name: "pack_temp", Signal: "MaxTemp", Above: bound(55)}
+	dyno := Rule{Name: "pack_temp", Signal: "MaxTemp", Above: bound(45), Severity: SeverityCritical}
+	setRules(t, map[string][]Rule{
+		"track": {track},
+		"dyno":  {dyno},
+		"extra": {{Name: "motor_temp", Signal: "MotorTemp", Above: bound(100)}},
+	}, "track", "extra")
+	key := RuleKey("pack_temp")
+	now := time.Now()
+
+	EvaluateRules(60, map[string]string{"MaxTemp": "50"}, now)
+	if IsActive(key) {
+		t.Fatal("track rule raised below its threshold")
+	}
+	EvaluateRules(60, map[string]string{"MaxTemp": "56", "MotorTemp": "101"}, now)
+	if !IsActive(key) || !IsActive(RuleKey("motor_temp")) {
+		t.Fatal("track and extra rules not raised")
+	}
+
+	// Switching sets: the unchanged rule keeps its alert, the changed one is
+	// resolved and evaluated with its new threshold
+	if err := ActivateRuleSets([]string{"dyno", "extra"}); err != nil {
+		t.Fatal(err)
+	}
+	if IsActive(key) || !IsActive(RuleKey("motor_temp")) {
+		t.Fatal("switching sets did not resolve only the changed rule")
+	}
+	EvaluateRules(60, map[string]string{"MaxTemp": "50"}, now)
+	if a, ok := activeAlert(key); !ok || a.Severity != SeverityCritical {
+		t.Fatalf("dyno rule alert = %+v, active %v", a, ok)
+	}
+	if got := ActiveRuleSets(); len(got) != 2 || got[0] != "dyno" {
+		t.Errorf("ActiveRuleSets() = %v", got)
+	}
+
+	if err := ActivateRuleSets(nil); err != nil {
+		t.Fatal(err)
+	}
+	if IsActive(key) || IsActive(RuleKey("motor_temp")) {
+		t.Fatal("deactivating all sets left rule alerts active")
+	}
+
+	if err := ActivateRuleSets([]string{"track", "dyno"}); err == nil {
+		t.Error("activating two sets defining the same rule succeeded")
+	}
+	if err := ActivateRuleSets([]string{"pit"}); err == nil {
+		t.Error("activating an unknown set succeeded")
+	}
+	if err := CheckRuleSets([]string{"track", "track", "extra"}); err != nil {
+		t.Errorf("CheckRuleSets with a repeated set: %v", err)
+	}
+}
+
+func TestRuleMuted(t *testing.T) {
+	setRules(t, map[string][]Rule{"track": {{Name: "pack_temp", Signal: "MaxTemp", Above: bound(55)}}}, "track")
+	SetMuted([]string{RuleSource})
+	t.Cleanup(func() { SetMuted(nil) })
+
+	EvaluateRules(60, map[string]string{"MaxTemp": "60"}, time.Now())
+	if IsActive(RuleKey("pack_temp")) {
+		t.Fatal("muted rule source raised an alert")
+	}
+	SetMuted(nil)
+	EvaluateRules(60, map[string]string{"MaxTemp": "60"}, time.Now())
+	if !IsActive(RuleKey("pack_temp")) {
+		t.Fatal("rule not raised after unmuting")
+	}
+}
+
+func TestConfigureRuleSetsInvalid(t *testing.T) {
+	tests := map[string]Rule{
+		"no name":          {Signal: "A", Above: bound(1)},
+		"no signal":        {Name: "a", Above: bound(1)},
+		"no threshold":     {Name: "a", Signal: "A"},
+		"crossed bounds":   {Name: "a", Signal: "A", Above: bound(1), Below: bound(2)},
+		"unknown severity": {Name: "a", Signal: "A", Above: bound(1), Severity: "fatal"},
+		"negative for":     {Name: "a", Signal: "A", Above: bound(1), ForMs: -1},
+		"negative margin":  {Name: "a", Signal: "A", Above: bound(1), Hysteresis: -1},
+	}
+	for name, r := range tests {
+		if err := ConfigureRuleSets(map[string][]Rule{"s": {r}}); err == nil {
+			t.Errorf("%s: configured", name)
+		}
+	}
+	dup := Rule{Name: "a", Signal: "A", Above: bound(1)}
+	if err := ConfigureRuleSets(map[string][]Rule{"s": {dup, dup}}); err == nil {
+		t.Error("duplicate rule names in a set configured")
+	}
+}
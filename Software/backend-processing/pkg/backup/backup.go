@@ -0,0 +1,361 @@
+// backup.go
+//
+// Package backup writes the end-of-day database backup to an attached drive, so
+// the data of a testing day survives the SD card holding the database. The
+// telemetry tables are not partitioned by day, so every run dumps the whole
+// database with pg_dump in its compressed custom format. The dump is then
+// verified: its table of contents must list the data of every telemetry table and
+// pg_restore must read the complete archive. Verified dumps are renamed into
+// place next to a sha256sum file, the oldest are removed beyond the configured
+// count, every run is recorded in the backups table and a failed run raises a
+// critical alert until a later run succeeds.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Backup statuses
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+const (
+	// Defaults, overridable through Config
+	DefaultSchedule  = "0 21 * * *" // After the end of a testing day
+	defaultKeep      = 14
+	defaultPgDump    = "pg_dump"
+	defaultPgRestore = "pg_restore"
+
+	// File names: telemetry-20060102-150405.dump
+	filePrefix = "telemetry-"
+	fileSuffix = ".dump"
+	partSuffix = ".part"
+	sumSuffix  = ".sha256"
+
+	// Alert raised while the last backup failed
+	alertKey = "backup.failed"
+
+	// Bytes of tool output kept for error messages
+	maxStderr = 4096
+)
+
+// Config holds the backup drive and tools.
+type Config struct {
+	Dir             string // Directory on the backup drive (empty disables backups)
+	Schedule        string // See scheduler.ParseSchedule; empty selects DefaultSchedule
+	Keep            int    // Backups kept on the drive; 0 selects the default
+	ConnString      string // Database to dump
+	PgDump          string // pg_dump executable; empty looks it up in PATH
+	PgRestore       string // pg_restore executable; empty looks it up in PATH
+	AllowSameDevice bool   // Accept a Dir on the root filesystem (no drive mounted)
+}
+
+var cfg Config
+
+// Configure validates and installs the backup settings. The directory is checked
+// by every run, as the drive may be attached later.
+func Configure(c Config) error {
+	if c.Dir != "" && c.ConnString == "" {
+		return errors.New("backups need the database connection string")
+	}
+	if c.Keep < 0 {
+		return fmt.Errorf("keep must not be negative, got %d", c.Keep)
+	}
+	if c.Schedule == "" {
+		c.Schedule = DefaultSchedule
+	}
+	if c.Keep == 0 {
+		c.Keep = defaultKeep
+	}
+	if c.PgDump == "" {
+		c.PgDump = defaultPgDump
+	}
+	if c.PgRestore == "" {
+		c.PgRestore = defaultPgRestore
+	}
+	cfg = c
+	return nil
+}
+
+// Enabled reports whether a backup directory is configured.
+func Enabled() bool {
+	return cfg.Dir != ""
+}
+
+// Schedule returns the schedule of the backup job.
+func Schedule() string {
+	return cfg.Schedule
+}
+
+// Run writes and verifies a backup, records it and raises or resolves the backup
+// alert. It is the function of the scheduled backup job.
+func Run(ctx context.Context) error {
+	b := types.Backup{StartedAt: time.Now(), Status: StatusFailed}
+	err := write(ctx, &b)
+	finished := time.Now()
+	b.FinishedAt = &finished
+	if err != nil {
+		b.Error = err.Error()
+		log.Printf("Backup failed: %v", err)
+		alerts.Raise(alerts.Alert{
+			Key:      alertKey,
+			Source:   "backup",
+			Severity: alerts.SeverityCritical,
+			Message:  fmt.Sprintf("Database backup failed: %v", err),
+		})
+	} else {
+		b.Status = StatusOK
+		log.Printf("Backup %s written and verified (%d bytes, %d tables)", b.File, b.SizeBytes, b.Tables)
+		alerts.Resolve(alertKey)
+	}
+
+	// Record the run even when ctx ended it
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if _, rerr := db.InsertBackup(recordCtx, b); rerr != nil {
+		log.Printf("Error recording backup: %v", rerr)
+		if err == nil {
+			err = fmt.Errorf("backup written but not recorded: %w", rerr)
+		}
+	}
+	return err
+}
+
+// write dumps the database to the backup directory and verifies the dump,
+// filling in b.
+func write(ctx context.Context, b *types.Backup) error {
+	if err := checkDir(cfg.Dir); err != nil {
+		return err
+	}
+	name := filePrefix + b.StartedAt.Format("20060102-150405") + fileSuffix
+	path := filepath.Join(cfg.Dir, name)
+	part := path + partSuffix
+	defer os.Remove(part)
+
+	// The password goes to pg_dump through its environment, not its arguments,
+	// which any local user can read
+	conn, password, err := splitPassword(cfg.ConnString)
+	if err != nil {
+		return err
+	}
+	var env []string
+	if password != "" {
+		env = []string{"PGPASSWORD=" + password}
+	}
+	if err := runTool(ctx, io.Discard, env, cfg.PgDump, "--format=custom", "--file="+part, "--dbname="+conn); err != nil {
+		return err
+	}
+	if err := syncFile(part); err != nil {
+		return fmt.Errorf("syncing dump: %w", err)
+	}
+	tables, err := verify(ctx, part)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+	sum, size, err := checksum(part)
+	if err != nil {
+		return fmt.Errorf("checksum failed: %w", err)
+	}
+
+	sumLine := fmt.Sprintf("%s  %s\n", sum, name)
+	if err := os.WriteFile(path+sumSuffix, []byte(sumLine), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(part, path); err != nil {
+		return err
+	}
+	if err := syncFile(cfg.Dir); err != nil {
+		return fmt.Errorf("syncing backup directory: %w", err)
+	}
+	b.File, b.SizeBytes, b.SHA256, b.Tables = name, size, sum, tables
+
+	if err := prune(cfg.Dir, cfg.Keep); err != nil {
+		log.Printf("Error removing old backups: %v", err)
+	}
+	return nil
+}
+
+// checkDir fails unless dir is an existing directory on its own drive.
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("backup directory unavailable (drive not attached?): %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("backup path %s is not a directory", dir)
+	}
+	if cfg.AllowSameDevice {
+		return nil
+	}
+	separate, err := onSeparateDevice(dir)
+	if err != nil {
+		return err
+	}
+	if !separate {
+		return fmt.Errorf("backup directory %s is not on a separate drive (drive not mounted?)", dir)
+	}
+	return nil
+}
+
+// verify checks that the archive lists the data of every telemetry table and can
+// be read completely, and returns the number of tables with data.
+func verify(ctx context.Context, path string) (int, error) {
+	var toc bytes.Buffer
+	if err := runTool(ctx, &toc, nil, cfg.PgRestore, "--list", path); err != nil {
+		return 0, err
+	}
+	tables := make(map[string]bool)
+	for _, line := range strings.Split(toc.String(), "\n") {
+		// e.g. "3412; 0 16390 TABLE DATA public tcu1 telem"
+		_, rest, ok := strings.Cut(line, " TABLE DATA ")
+		if f := strings.Fields(rest); ok && len(f) >= 2 {
+			tables[f[1]] = true
+		}
+	}
+	for _, t := range db.TelemetryTables {
+		if !tables[t.Name] {
+			return 0, fmt.Errorf("archive has no data for table %s", t.Name)
+		}
+	}
+
+	// Without a target database pg_restore writes the SQL script to stdout,
+	// reading and decompressing every data block
+	if err := runTool(ctx, io.Discard, nil, cfg.PgRestore, path); err != nil {
+		return 0, err
+	}
+	return len(tables), nil
+}
+
+// splitPassword removes the password from a connection string, in URL or
+// keyword/value form, and returns it separately. A keyword/value password must
+// not contain spaces.
+func splitPassword(conn string) (string, string, error) {
+	if strings.HasPrefix(conn, "postgres://") || strings.HasPrefix(conn, "postgresql://") {
+		u, err := url.Parse(conn)
+		if err != nil {
+			// The error quotes the URL, password included
+			return "", "", errors.New("invalid database connection URL")
+		}
+		password, _ := u.User.Password()
+		if u.User != nil {
+			u.User = url.User(u.User.Username())
+		}
+		q := u.Query()
+		if p := q.Get("password"); p != "" {
+			password = p
+		}
+		if q.Has("password") {
+			q.Del("password")
+			u.RawQuery = q.Encode()
+		}
+		return u.String(), password, nil
+	}
+	var password string
+	var kept []string
+	for _, f := range strings.Fields(conn) {
+		if v, ok := strings.CutPrefix(f, "password="); ok {
+			password = strings.Trim(v, "'")
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return strings.Join(kept, " "), password, nil
+}
+
+// runTool runs a PostgreSQL client tool writing its output to stdout, with env
+// added to the server's environment. Errors carry the end of its stderr.
+func runTool(ctx context.Context, stdout io.Writer, env []string, tool string, args ...string) error {
+	cmd := exec.CommandContext(ctx, tool, args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = stdout
+	stderr := &tailBuffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(string(stderr.buf))
+		if msg == "" {
+			return fmt.Errorf("%s: %w", filepath.Base(tool), err)
+		}
+		return fmt.Errorf("%s: %w: %s", filepath.Base(tool), err, msg)
+	}
+	return nil
+}
+
+// tailBuffer keeps the last maxStderr bytes written to it.
+type tailBuffer struct {
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxStderr {
+		t.buf = t.buf[len(t.buf)-maxStderr:]
+	}
+	return len(p), nil
+}
+
+// checksum returns the SHA-256 digest and size of a file, read back from disk.
+func checksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// syncFile flushes a file or directory to the drive.
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// prune removes the oldest backups beyond keep, with their checksum files.
+func prune(dir string, keep int) error {
+	matches, err := filepath.Glob(filepath.Join(dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches) // Names sort by time
+	var errs []error
+	for i := 0; i < len(matches)-keep; i++ {
+		if err := os.Remove(matches[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.Remove(matches[i] + sumSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+		log.Printf("Removed old backup %s", filepath.Base(matches[i]))
+	}
+	return errors.Join(errs...)
+}
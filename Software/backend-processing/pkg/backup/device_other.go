@@ -0,0 +1,13 @@
+//go:build !unix
+
+// device_other.go
+//
+// Mounted drive detection stub for platforms without stat device numbers.
+package backup
+
+import "errors"
+
+// onSeparateDevice is not supported on this platform; set allow_same_device.
+func onSeparateDevice(dir string) (bool, error) {
+	return false, errors.New("drive detection not supported on this platform")
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+// device_unix.go
+//
+// Mounted drive detection for Unix systems.
+package backup
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// onSeparateDevice reports whether dir is on another filesystem than the root,
+// i.e. whether a drive is mounted on it or on a directory above it.
+func onSeparateDevice(dir string) (bool, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	var st, root unix.Stat_t
+	if err := unix.Stat(abs, &st); err != nil {
+		return false, err
+	}
+	if err := unix.Stat("/", &root); err != nil {
+		return false, err
+	}
+	return st.Dev != root.Dev, nil
+}
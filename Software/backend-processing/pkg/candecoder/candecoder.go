@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math"
 	"os"
 	"runtime"
@@ -129,18 +130,39 @@ func hexChar(nibble byte) byte {
 }
 
 // LoadJSONDefinitions reads and parses a JSON file containing CAN message definitions.
-// It returns both a slice of messages and a map of messages keyed by frame ID.
+// It returns both a slice of messages and a map of messages keyed by frame ID, or a
+// *DefinitionError when the definitions conflict or, in strict mode, are
+// suspicious (see validate.go). Warnings are logged otherwise.
 func LoadJSONDefinitions(jsonPath string) ([]types.Message, map[uint32]types.Message, error) {
 	data, err := os.ReadFile(jsonPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read JSON file %s: %w", jsonPath, err)
 	}
+	return ParseJSONDefinitions(data, jsonPath)
+}
 
+// ParseJSONDefinitions is LoadJSONDefinitions for definitions held in memory,
+// such as the ones embedded for the portable mode. source names them in errors
+// and logs.
+func ParseJSONDefinitions(data []byte, source string) ([]types.Message, map[uint32]types.Message, error) {
 	var messages []types.Message
 	if err := json.Unmarshal(data, &messages); err != nil {
 		return nil, nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
+	// Refuse definitions that would silently mis-decode; report suspicious ones
+	strictness := StrictnessLenient
+	if loadStrict.Load() {
+		strictness = StrictnessStrict
+	}
+	problems := LintDefinitions(messages)
+	if err := failing(problems, strictness); err != nil {
+		return nil, nil, fmt.Errorf("invalid definitions in %s: %w", source, err)
+	}
+	for _, p := range problems {
+		log.Printf("CAN definitions %s: %s", source, p)
+	}
+
 	// Pre-allocate map with the exact size needed
 	msgMap := make(map[uint32]types.Message, len(messages))
 
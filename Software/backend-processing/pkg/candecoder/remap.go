@@ -0,0 +1,87 @@
+// remap.go
+//
+// Frame-ID remapping applied at ingest. When firmware ships with shifted or
+// off-by-one IDs, a remap table (observed ID -> canonical ID) lets the server decode
+// the frames with the right definitions without reflashing. Each remap is logged
+// the first time it is applied and counted for the stats endpoint.
+package candecoder
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// frameRemap is a single configured remap with its hit counter.
+type frameRemap struct {
+	canonical uint32
+	hits      uint64
+	logged    int32
+}
+
+var (
+	remapMu    sync.RWMutex
+	remapTable map[uint32]*frameRemap
+)
+
+// RemapStat reports how often a remap has been applied.
+type RemapStat struct {
+	Observed  uint32 `json:"observed"`
+	Canonical uint32 `json:"canonical"`
+	Hits      uint64 `json:"hits"`
+}
+
+// SetFrameIDRemap installs the remap table. An observed ID may only be remapped
+// once, and remaps are not chained: an observed ID is mapped directly to its
+// canonical ID even if that ID is itself remapped.
+func SetFrameIDRemap(remap map[uint32]uint32) error {
+	table := make(map[uint32]*frameRemap, len(remap))
+	for observed, canonical := range remap {
+		if observed == canonical {
+			return fmt.Errorf("frame ID %d is remapped to itself", observed)
+		}
+		table[observed] = &frameRemap{canonical: canonical}
+		log.Printf("Frame ID remap configured: %d (0x%X) -> %d (0x%X)", observed, observed, canonical, canonical)
+	}
+
+	remapMu.Lock()
+	remapTable = table
+	remapMu.Unlock()
+	return nil
+}
+
+// RemapFrameID returns the canonical ID for an observed frame ID. IDs without a
+// remap are returned unchanged.
+func RemapFrameID(observed uint32) uint32 {
+	remapMu.RLock()
+	r, ok := remapTable[observed]
+	remapMu.RUnlock()
+	if !ok {
+		return observed
+	}
+
+	atomic.AddUint64(&r.hits, 1)
+	if atomic.CompareAndSwapInt32(&r.logged, 0, 1) {
+		log.Printf("Remapping frame ID %d (0x%X) to %d (0x%X)", observed, observed, r.canonical, r.canonical)
+	}
+	return r.canonical
+}
+
+// GetRemapStats returns the hit count of every configured remap.
+func GetRemapStats() []RemapStat {
+	remapMu.RLock()
+	defer remapMu.RUnlock()
+
+	stats := make([]RemapStat, 0, len(remapTable))
+	for observed, r := range remapTable {
+		stats = append(stats, RemapStat{
+			Observed:  observed,
+			Canonical: r.canonical,
+			Hits:      atomic.LoadUint64(&r.hits),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Observed < stats[j].Observed })
+	return stats
+}
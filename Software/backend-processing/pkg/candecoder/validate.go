@@ -0,0 +1,320 @@
+// validate.go
+//
+// Consistency checks run when definitions are loaded, and exposed as a linter
+// (POST /api/definitions/lint, cmd/deflint) so generated definitions can be
+// checked before they reach the car's gateway or the server. Problems have a
+// level. Errors, such as a duplicate frame ID, overlapping signals or signals
+// reaching past the frame length, would decode without complaint but produce
+// wrong values, so loading fails with a report of every problem. Warnings, such
+// as a missing unit, a zero factor or a range the signal length cannot hold,
+// are suspicious; they are logged at load and only fail it in strict mode.
+package candecoder
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+
+	"telem-system/pkg/types"
+)
+
+// Largest frame length in bytes (CAN FD)
+const maxFrameLength = 64
+
+// Largest standard and extended frame IDs
+const (
+	maxStandardFrameID = 0x7FF
+	maxExtendedFrameID = 0x1FFFFFFF
+)
+
+// Problem levels
+const (
+	LevelError   = "error"   // Mis-decodes; always fails loading
+	LevelWarning = "warning" // Suspicious; fails loading in strict mode
+)
+
+// Strictness levels
+const (
+	StrictnessLenient = "lenient" // Only errors fail
+	StrictnessStrict  = "strict"  // Warnings fail too
+)
+
+// strictness applied by LoadJSONDefinitions
+var loadStrict atomic.Bool
+
+// DefinitionProblem is a single inconsistency in the definitions.
+type DefinitionProblem struct {
+	FrameID uint32 `json:"frame_id"`
+	Message string `json:"message"`
+	Level   string `json:"level"`
+	Detail  string `json:"detail"`
+}
+
+func (p DefinitionProblem) String() string {
+	return fmt.Sprintf("%s: frame 0x%X (%s): %s", p.Level, p.FrameID, p.Message, p.Detail)
+}
+
+// DefinitionError reports every problem found in a set of definitions.
+type DefinitionError struct {
+	Problems []DefinitionProblem
+}
+
+func (e *DefinitionError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d conflicting or invalid CAN definitions:", len(e.Problems))
+	for _, p := range e.Problems {
+		sb.WriteString("\n  ")
+		sb.WriteString(p.String())
+	}
+	return sb.String()
+}
+
+// signalBit is a bit of the frame payload: byte index and bit within the byte
+// (0 is the least significant bit).
+type signalBit struct {
+	byteIdx, bit int
+}
+
+// SetStrictness sets the strictness LoadJSONDefinitions applies.
+func SetStrictness(strictness string) error {
+	switch strictness {
+	case "", StrictnessLenient:
+		loadStrict.Store(false)
+	case StrictnessStrict:
+		loadStrict.Store(true)
+	default:
+		return fmt.Errorf("unknown strictness %q (lenient or strict)", strictness)
+	}
+	return nil
+}
+
+// Fails reports whether a problem fails validation at the given strictness.
+func (p DefinitionProblem) Fails(strictness string) bool {
+	return p.Level == LevelError || strictness == StrictnessStrict
+}
+
+// ValidateDefinitions lints messages and returns a *DefinitionError listing the
+// problems that fail at the given strictness, or nil.
+func ValidateDefinitions(messages []types.Message, strictness string) error {
+	return failing(LintDefinitions(messages), strictness)
+}
+
+// failing returns a *DefinitionError listing the problems that fail at the given
+// strictness, or nil.
+func failing(problems []DefinitionProblem, strictness string) error {
+	var failed []DefinitionProblem
+	for _, p := range problems {
+		if p.Fails(strictness) {
+			failed = append(failed, p)
+		}
+	}
+	if len(failed) > 0 {
+		return &DefinitionError{Problems: failed}
+	}
+	return nil
+}
+
+// LintDefinitions checks messages and returns every problem found. Errors are
+// duplicate frame IDs, frame IDs out of range, invalid frame and signal lengths,
+// duplicate signal names, signals exceeding the frame length and signals sharing
+// payload bits. Warnings are listed at lintSignal and lintMessage.
+func LintDefinitions(messages []types.Message) []DefinitionProblem {
+	var problems []DefinitionProblem
+	seen := make(map[uint32]int, len(messages)) // Frame ID -> index of first definition
+
+	for i, msg := range messages {
+		report := func(format string, args ...interface{}) {
+			problems = append(problems, DefinitionProblem{FrameID: msg.FrameID, Message: msg.Name, Level: LevelError, Detail: fmt.Sprintf(format, args...)})
+		}
+		warn := func(format string, args ...interface{}) {
+			problems = append(problems, DefinitionProblem{FrameID: msg.FrameID, Message: msg.Name, Level: LevelWarning, Detail: fmt.Sprintf(format, args...)})
+		}
+
+		if first, ok := seen[msg.FrameID]; ok {
+			report("duplicate frame ID, already defined by %s (definition %d)", messages[first].Name, first+1)
+		} else {
+			seen[msg.FrameID] = i
+		}
+		if msg.FrameID > maxExtendedFrameID {
+			report("frame ID exceeds 29 bits")
+		}
+		if msg.Length < 0 || msg.Length > maxFrameLength {
+			report("invalid length %d bytes (must be 0-%d)", msg.Length, maxFrameLength)
+			continue
+		}
+		lintMessage(msg, warn)
+
+		owner := make([]int, msg.Length*8) // Payload bit -> signal index + 1
+		names := make(map[string]bool, len(msg.Signals))
+		reported := make(map[[2]int]bool)
+		for j, sig := range msg.Signals {
+			if names[sig.Name] {
+				report("duplicate signal name %s", sig.Name)
+			}
+			names[sig.Name] = true
+
+			if sig.Start < 0 || sig.Length <= 0 || sig.Length > 64 {
+				report("signal %s has invalid start bit %d or length %d", sig.Name, sig.Start, sig.Length)
+				continue
+			}
+			if sig.IsFloat && sig.Length != 32 && sig.Length != 64 {
+				report("float signal %s has length %d (must be 32 or 64)", sig.Name, sig.Length)
+				continue
+			}
+			if end := sig.Start + sig.Length; end > msg.Length*8 {
+				report("signal %s (bits %d-%d) exceeds the frame length of %d bytes", sig.Name, sig.Start, end-1, msg.Length)
+				continue
+			}
+			lintSignal(sig, warn)
+
+			for _, b := range signalBits(sig) {
+				idx := b.byteIdx*8 + b.bit
+				other := owner[idx] - 1
+				if other < 0 {
+					owner[idx] = j + 1
+					continue
+				}
+				if pair := [2]int{other, j}; !reported[pair] {
+					reported[pair] = true
+					report("signals %s and %s overlap at byte %d bit %d", msg.Signals[other].Name, sig.Name, b.byteIdx, b.bit)
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// LintReport is the result of linting a set of definitions.
+type LintReport struct {
+	Strictness string              `json:"strictness"`
+	Valid      bool                `json:"valid"` // No problem fails at the strictness
+	Messages   int                 `json:"messages"`
+	Errors     int                 `json:"errors"`
+	Warnings   int                 `json:"warnings"`
+	Problems   []DefinitionProblem `json:"problems"`
+}
+
+// Lint lints messages and reports whether they pass at the given strictness
+// (empty selects lenient).
+func Lint(messages []types.Message, strictness string) (LintReport, error) {
+	if strictness == "" {
+		strictness = StrictnessLenient
+	}
+	if strictness != StrictnessLenient && strictness != StrictnessStrict {
+		return LintReport{}, fmt.Errorf("unknown strictness %q (lenient or strict)", strictness)
+	}
+	r := LintReport{Strictness: strictness, Messages: len(messages), Problems: LintDefinitions(messages)}
+	if r.Problems == nil {
+		r.Problems = []DefinitionProblem{}
+	}
+	for _, p := range r.Problems {
+		if p.Level == LevelError {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+	r.Valid = failing(r.Problems, strictness) == nil
+	return r, nil
+}
+
+// validFDLengths are the frame lengths above 8 bytes a CAN FD frame can have.
+var validFDLengths = map[int]bool{12: true, 16: true, 20: true, 24: true, 32: true, 48: true, 64: true}
+
+// lintMessage reports suspicious message definitions: a name missing, no
+// signals, a frame ID above 11 bits not marked extended and lengths no CAN frame
+// has.
+func lintMessage(msg types.Message, warn func(format string, args ...interface{})) {
+	if strings.TrimSpace(msg.Name) == "" {
+		warn("message has no name")
+	}
+	if len(msg.Signals) == 0 {
+		warn("message has no signals")
+	}
+	if msg.FrameID > maxStandardFrameID && !msg.IsExtendedFrame {
+		warn("frame ID exceeds 11 bits but is not marked extended")
+	}
+	if msg.Length == 0 {
+		warn("frame length is 0 bytes")
+	} else if msg.Length > 8 && !validFDLengths[msg.Length] {
+		warn("length %d bytes is not a CAN FD frame length", msg.Length)
+	}
+}
+
+// lintSignal reports suspicious signal definitions: a zero factor (every value
+// decodes to the offset), a missing unit on a value that is not a flag or an
+// enumeration, an unknown byte order, a minimum above the maximum, a signed
+// single bit and a declared range the signal length cannot represent.
+func lintSignal(sig types.Signal, warn func(format string, args ...interface{})) {
+	if sig.Factor == 0 {
+		warn("signal %s has factor 0, so every value decodes to the offset", sig.Name)
+	}
+	if sig.Unit == "" && sig.Length > 1 && len(sig.Choices) == 0 {
+		warn("signal %s has no unit", sig.Name)
+	}
+	if sig.ByteOrder != "little_endian" && !strings.EqualFold(sig.ByteOrder, "big_endian") {
+		warn("signal %s has byte order %q (little_endian or big_endian)", sig.Name, sig.ByteOrder)
+	}
+	if sig.Minimum != nil && sig.Maximum != nil && *sig.Minimum > *sig.Maximum {
+		warn("signal %s has minimum %g above maximum %g", sig.Name, *sig.Minimum, *sig.Maximum)
+	}
+	if sig.IsSigned && sig.Length == 1 {
+		warn("signal %s is a signed single bit", sig.Name)
+	}
+	if sig.IsFloat || sig.Factor == 0 || sig.Length > 52 {
+		return
+	}
+	// Physical range the raw bits can represent
+	rawMin, rawMax := 0.0, math.Exp2(float64(sig.Length))-1
+	if sig.IsSigned {
+		rawMin, rawMax = -math.Exp2(float64(sig.Length-1)), math.Exp2(float64(sig.Length-1))-1
+	}
+	lo, hi := rawMin*sig.Factor+sig.Offset, rawMax*sig.Factor+sig.Offset
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	// Tolerate rounding of the declared bounds to the factor
+	slack := math.Abs(sig.Factor)
+	if sig.Minimum != nil && *sig.Minimum < lo-slack {
+		warn("signal %s minimum %g is below the %g its %d bits can represent", sig.Name, *sig.Minimum, lo, sig.Length)
+	}
+	if sig.Maximum != nil && *sig.Maximum > hi+slack {
+		warn("signal %s maximum %g is above the %g its %d bits can represent", sig.Name, *sig.Maximum, hi, sig.Length)
+	}
+}
+
+// signalBits returns the payload bits read by decodeSignal for sig, following
+// the same decoding paths.
+func signalBits(sig types.Signal) []signalBit {
+	bits := make([]signalBit, 0, sig.Length)
+	wholeBytes := func(start, n int) {
+		for i := 0; i < n; i++ {
+			for b := 0; b < 8; b++ {
+				bits = append(bits, signalBit{start + i, b})
+			}
+		}
+	}
+
+	switch {
+	case sig.IsFloat:
+		wholeBytes(sig.Start/8, sig.Length/8)
+	case sig.ByteOrder == "little_endian" && sig.Length%8 == 0 && sig.Start%8 == 0:
+		wholeBytes(sig.Start/8, sig.Length/8)
+	case sig.Length <= 8 && sig.Start%8 == 0:
+		// Low bits of a single byte, whatever the byte order
+		for b := 0; b < sig.Length; b++ {
+			bits = append(bits, signalBit{sig.Start / 8, b})
+		}
+	case sig.ByteOrder == "little_endian":
+		for pos := sig.Start; pos < sig.Start+sig.Length; pos++ {
+			bits = append(bits, signalBit{pos / 8, pos % 8})
+		}
+	default:
+		for pos := sig.Start; pos < sig.Start+sig.Length; pos++ {
+			bits = append(bits, signalBit{pos / 8, 7 - pos%8})
+		}
+	}
+	return bits
+}
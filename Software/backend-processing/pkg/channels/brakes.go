@@ -0,0 +1,112 @@
+// brakes.go
+//
+// Brake temperature estimation. Each axle is modelled as a single thermal mass:
+//
+//	C * dT/dt = share * gain * max(bse - bse_zero, 0) * v - (h0 + h1 * v) * (T - T_amb)
+//
+// where the braking power is the brake force (proportional to brake pressure, BSE)
+// times vehicle speed. The coefficients come from the latest "brake_thermal_model"
+// calibration in the calibration registry; missing parameters use the defaults.
+// The model is started at ambient temperature brakeWarmup before the requested
+// range so the estimate has settled by the time the range begins.
+package channels
+
+import (
+	"context"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// BrakeModelCalibration is the calibration registry name holding the model parameters.
+const BrakeModelCalibration = "brake_thermal_model"
+
+// History simulated before the requested range
+const brakeWarmup = 10 * time.Minute
+
+// Default model parameters (rough figures for an FSAE car; calibrate per car)
+var defaultBrakeParams = map[string]float64{
+	"front_bias":          0.65, // Share of braking power on the front axle
+	"pressure_gain":       40,   // Brake force in N per BSE unit
+	"bse_zero":            0,    // BSE reading with the pedal released
+	"front_heat_capacity": 1800, // J/K, both front discs and pads
+	"rear_heat_capacity":  1200, // J/K
+	"cooling_base":        2.0,  // W/K at standstill
+	"cooling_speed":       0.4,  // Additional W/K per m/s
+	"ambient_c":           25,   // Ambient temperature in deg C
+}
+
+// brakeParams returns the model parameters from the calibration registry merged
+// over the defaults.
+func brakeParams(ctx context.Context, queries *db.Queries) (map[string]float64, error) {
+	p := make(map[string]float64, len(defaultBrakeParams))
+	for k, v := range defaultBrakeParams {
+		p[k] = v
+	}
+	cal, ok, err := queries.FetchLatestCalibration(ctx, BrakeModelCalibration)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		for k, v := range cal.Params {
+			if _, known := p[k]; known {
+				p[k] = v
+			}
+		}
+	}
+	return p, nil
+}
+
+// brakeTemp estimates the brake temperature of one axle ("front" or "rear") in deg C.
+func brakeTemp(ctx context.Context, queries *db.Queries, axle string, from, to time.Time) (Series, error) {
+	p, err := brakeParams(ctx, queries)
+	if err != nil {
+		return Series{}, err
+	}
+	share := p["front_bias"]
+	capacity := p["front_heat_capacity"]
+	if axle == "rear" {
+		share = 1 - share
+		capacity = p["rear_heat_capacity"]
+	}
+
+	start := from.Add(-brakeWarmup)
+	times, bse, err := queries.FetchColumns(ctx, "tcu1", []string{"bse"}, start, to)
+	if err != nil {
+		return Series{}, err
+	}
+	speed, err := speed(ctx, queries, start, to)
+	if err != nil {
+		return Series{}, err
+	}
+	v := SampleAt(speed, times)
+
+	ambient := p["ambient_c"]
+	temp := ambient
+	out := Series{}
+	for i, t := range times {
+		if i > 0 {
+			dt := t.Sub(times[i-1])
+			if dt > maxIntegrationGap {
+				dt = maxIntegrationGap // Data gap: do not extrapolate heating
+			}
+			speedNow := 0.0
+			if v[i] != nil {
+				speedNow = *v[i]
+			}
+			pressure := bse[0][i] - p["bse_zero"]
+			if pressure < 0 {
+				pressure = 0
+			}
+			heating := share * p["pressure_gain"] * pressure * speedNow
+			cooling := (p["cooling_base"] + p["cooling_speed"]*speedNow) * (temp - ambient)
+			if capacity > 0 {
+				temp += (heating - cooling) / capacity * dt.Seconds()
+			}
+		}
+		if !t.Before(from) {
+			out.Times = append(out.Times, t)
+			out.Values = append(out.Values, temp)
+		}
+	}
+	return out, nil
+}
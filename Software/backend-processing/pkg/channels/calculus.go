@@ -0,0 +1,162 @@
+// calculus.go
+//
+// Derivative and integral of a single channel over a time range, e.g. current to
+// charge or speed to distance. The channel can be smoothed with a centred moving
+// average first, which keeps derivatives of noisy signals usable.
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// Calculus operations
+const (
+	OpDerivative = "derivative"
+	OpIntegral   = "integral"
+)
+
+// Upper bound on the smoothing window
+const maxSmoothing = time.Minute
+
+// CalculusQuery describes a derivative or integral request.
+type CalculusQuery struct {
+	Channel   string
+	From, To  time.Time
+	Op        string        // OpDerivative or OpIntegral
+	Smoothing time.Duration // Moving average window applied first; 0 disables
+	Step      time.Duration // Resample the result onto a time grid; 0 returns every sample
+	Scale     float64       // Multiplier applied to the result (e.g. 1/3600 for A -> Ah); 0 means 1
+}
+
+// CalculusResult holds the computed series. T is in milliseconds since the Unix
+// epoch. Total is the integral over the whole range (integrals only).
+type CalculusResult struct {
+	Channel string     `json:"channel"`
+	Op      string     `json:"op"`
+	T       []float64  `json:"t"`
+	Values  []*float64 `json:"values"`
+	Total   *float64   `json:"total,omitempty"`
+}
+
+// RunCalculus executes a derivative or integral query.
+func RunCalculus(ctx context.Context, queries *db.Queries, q CalculusQuery) (CalculusResult, error) {
+	if q.Op != OpDerivative && q.Op != OpIntegral {
+		return CalculusResult{}, fmt.Errorf("unknown operation %q", q.Op)
+	}
+	if q.To.Before(q.From) {
+		return CalculusResult{}, errors.New("to is before from")
+	}
+	if q.Smoothing < 0 || q.Smoothing > maxSmoothing {
+		return CalculusResult{}, fmt.Errorf("smoothing must be between 0 and %s", maxSmoothing)
+	}
+	if q.Scale == 0 {
+		q.Scale = 1
+	}
+
+	var grid []time.Time
+	if q.Step > 0 {
+		n := int(q.To.Sub(q.From)/q.Step) + 1
+		if n > maxPoints {
+			return CalculusResult{}, ErrTooManyPoints
+		}
+		grid = make([]time.Time, n)
+		for i := range grid {
+			grid[i] = q.From.Add(time.Duration(i) * q.Step)
+		}
+	}
+
+	s, err := Fetch(ctx, queries, q.Channel, q.From, q.To)
+	if err != nil {
+		return CalculusResult{}, err
+	}
+	if grid == nil && len(s.Times) > maxPoints {
+		return CalculusResult{}, ErrTooManyPoints
+	}
+	if q.Smoothing > 0 {
+		s = Smooth(s, q.Smoothing)
+	}
+
+	res := CalculusResult{Channel: q.Channel, Op: q.Op}
+	switch q.Op {
+	case OpDerivative:
+		s = Derivative(s)
+	case OpIntegral:
+		s = Integrate(s)
+		if n := len(s.Values); n > 0 {
+			total := s.Values[n-1] * q.Scale
+			res.Total = &total
+		}
+	}
+	for i := range s.Values {
+		s.Values[i] *= q.Scale
+	}
+
+	if grid != nil {
+		res.Values = SampleAt(s, grid)
+		res.T = make([]float64, len(grid))
+		for i, t := range grid {
+			res.T[i] = float64(t.UnixNano()) / 1e6
+		}
+		return res, nil
+	}
+	res.T = make([]float64, len(s.Times))
+	res.Values = make([]*float64, len(s.Values))
+	for i := range s.Times {
+		res.T[i] = float64(s.Times[i].UnixNano()) / 1e6
+		res.Values[i] = &s.Values[i]
+	}
+	return res, nil
+}
+
+// Smooth returns the centred moving average of s over the given window.
+func Smooth(s Series, window time.Duration) Series {
+	out := Series{Times: s.Times, Values: make([]float64, len(s.Values))}
+	half := window / 2
+	lo, hi := 0, 0
+	sum := 0.0
+	for i, t := range s.Times {
+		for hi < len(s.Times) && s.Times[hi].Sub(t) <= half {
+			sum += s.Values[hi]
+			hi++
+		}
+		for t.Sub(s.Times[lo]) > half {
+			sum -= s.Values[lo]
+			lo++
+		}
+		out.Values[i] = sum / float64(hi-lo)
+	}
+	return out
+}
+
+// Derivative returns the rate of change of s per second, using central differences
+// and one-sided differences at the ends of the data. Neighbours further away than
+// maxIntegrationGap are not used; samples without a usable neighbour are dropped.
+func Derivative(s Series) Series {
+	out := Series{Times: make([]time.Time, 0, len(s.Times)), Values: make([]float64, 0, len(s.Values))}
+	near := func(a, b int) bool {
+		dt := s.Times[b].Sub(s.Times[a])
+		return dt > 0 && dt <= maxIntegrationGap
+	}
+	for i := range s.Times {
+		prev, next := i-1, i+1
+		hasPrev := prev >= 0 && near(prev, i)
+		hasNext := next < len(s.Times) && near(i, next)
+		switch {
+		case hasPrev && hasNext:
+		case hasPrev:
+			next = i
+		case hasNext:
+			prev = i
+		default:
+			continue
+		}
+		dt := s.Times[next].Sub(s.Times[prev]).Seconds()
+		out.Times = append(out.Times, s.Times[i])
+		out.Values = append(out.Values, (s.Values[next]-s.Values[prev])/dt)
+	}
+	return out
+}
@@ -0,0 +1,243 @@
+// channels.go
+//
+// Package channels addresses telemetry signals as named channels and serves
+// multi-channel queries. A channel is either a stored column ("table.column", e.g.
+// "pack_voltage.voltage") or a derived channel ("derived.speed",
+// "derived.distance", "derived.brake_temp_front", "derived.pack_current_fused",
+// "derived.segment1_voltage", ...).
+// Query results can be resampled onto a common time grid or, for lap-to-lap
+// overlays, onto a distance grid using the derived distance channel. For plots of
+// long ranges a channel can be decimated to M4 envelopes (see decimate.go), and a
+// candidate derived channel formula can be previewed over recorded data (see
+// preview.go).
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// Query domains
+const (
+	DomainTime     = "time"
+	DomainDistance = "distance"
+)
+
+const (
+	// Default grid steps
+	defaultTimeStep     = 100 * time.Millisecond
+	defaultDistanceStep = 1.0 // metres
+
+	// Upper bound on grid points per query
+	maxPoints = 50000
+)
+
+// Series is a channel sampled at increasing times.
+type Series struct {
+	Times  []time.Time
+	Values []float64
+}
+
+// Query describes a multi-channel request.
+type Query struct {
+	Channels []string
+	From, To time.Time
+	Domain   string        // DomainTime (default) or DomainDistance
+	TimeStep time.Duration // Grid step in the time domain
+	DistStep float64       // Grid step in metres in the distance domain
+}
+
+// Result holds the channels resampled onto a common axis. X is in milliseconds
+// since the Unix epoch for the time domain and in metres from the start of the
+// range for the distance domain. Values without data on both sides are null.
+// Gaps lists the data gaps of the channels that have any, in time (see gaps.go).
+type Result struct {
+	Domain   string                `json:"domain"`
+	X        []float64             `json:"x"`
+	Channels map[string][]*float64 `json:"channels"`
+	Gaps     map[string][]Gap      `json:"gaps,omitempty"`
+}
+
+// ErrTooManyPoints is returned when the grid would exceed maxPoints.
+var ErrTooManyPoints = fmt.Errorf("query would return more than %d points; increase the step or narrow the range", maxPoints)
+
+// ErrNoMotion is returned for distance-domain queries over ranges without INS data.
+var ErrNoMotion = errors.New("not enough motion data to build a distance axis")
+
+// Validate checks that id names an existing channel.
+func Validate(id string) error {
+	table, column, ok := strings.Cut(id, ".")
+	if !ok {
+		return fmt.Errorf("invalid channel %q: expected table.column", id)
+	}
+	if table == derivedPrefix {
+		if _, _, ok := parseSegmentChannel(column); !ok && !derivedChannels[column] {
+			return fmt.Errorf("unknown derived channel %q", column)
+		}
+		return nil
+	}
+	spec, ok := db.LookupTable(table)
+	if !ok {
+		return fmt.Errorf("unknown table %q", table)
+	}
+	if _, ok := spec.Column(column); !ok {
+		return fmt.Errorf("unknown column %q in table %q", column, table)
+	}
+	return nil
+}
+
+// Fetch loads a single channel over [from, to].
+func Fetch(ctx context.Context, queries *db.Queries, id string, from, to time.Time) (Series, error) {
+	table, column, ok := strings.Cut(id, ".")
+	if !ok {
+		return Series{}, fmt.Errorf("invalid channel %q: expected table.column", id)
+	}
+	if table == derivedPrefix {
+		return fetchDerived(ctx, queries, column, from, to)
+	}
+	times, values, err := queries.FetchColumns(ctx, table, []string{column}, from, to)
+	if err != nil {
+		return Series{}, err
+	}
+	return Series{Times: times, Values: values[0]}, nil
+}
+
+// Run executes a multi-channel query.
+func Run(ctx context.Context, queries *db.Queries, q Query) (Result, error) {
+	if len(q.Channels) == 0 {
+		return Result{}, errors.New("no channels requested")
+	}
+	if q.To.Before(q.From) {
+		return Result{}, errors.New("to is before from")
+	}
+	if q.Domain == "" {
+		q.Domain = DomainTime
+	}
+
+	series := make(map[string]Series, len(q.Channels))
+	for _, id := range q.Channels {
+		if _, dup := series[id]; dup {
+			continue
+		}
+		s, err := Fetch(ctx, queries, id, q.From, q.To)
+		if err != nil {
+			return Result{}, err
+		}
+		series[id] = s
+	}
+
+	var grid []time.Time // Sample time of every grid point
+	res := Result{Domain: q.Domain, Channels: make(map[string][]*float64, len(series))}
+	switch q.Domain {
+	case DomainTime:
+		step := q.TimeStep
+		if step <= 0 {
+			step = defaultTimeStep
+		}
+		n := int(q.To.Sub(q.From)/step) + 1
+		if n > maxPoints {
+			return Result{}, ErrTooManyPoints
+		}
+		grid = make([]time.Time, n)
+		res.X = make([]float64, n)
+		for i := range grid {
+			grid[i] = q.From.Add(time.Duration(i) * step)
+			res.X[i] = float64(grid[i].UnixNano()) / 1e6
+		}
+	case DomainDistance:
+		step := q.DistStep
+		if step <= 0 {
+			step = defaultDistanceStep
+		}
+		dist, err := fetchDerived(ctx, queries, "distance", q.From, q.To)
+		if err != nil {
+			return Result{}, err
+		}
+		if grid, res.X, err = distanceGrid(dist, step); err != nil {
+			return Result{}, err
+		}
+	default:
+		return Result{}, fmt.Errorf("unknown domain %q", q.Domain)
+	}
+
+	for id, s := range series {
+		res.Channels[id] = SampleAt(s, grid)
+		if gaps := seriesGaps(id, s); len(gaps) > 0 {
+			if res.Gaps == nil {
+				res.Gaps = make(map[string][]Gap)
+			}
+			res.Gaps[id] = gaps
+		}
+	}
+	return res, nil
+}
+
+// distanceGrid returns the times at which the car passed every step metres of the
+// distance series, and the distances themselves.
+func distanceGrid(dist Series, step float64) ([]time.Time, []float64, error) {
+	if len(dist.Values) < 2 {
+		return nil, nil, ErrNoMotion
+	}
+	total := dist.Values[len(dist.Values)-1]
+	n := int(math.Floor(total/step)) + 1
+	if n > maxPoints {
+		return nil, nil, ErrTooManyPoints
+	}
+
+	times := make([]time.Time, n)
+	xs := make([]float64, n)
+	j := 0
+	for i := 0; i < n; i++ {
+		d := float64(i) * step
+		xs[i] = d
+		// Distance is non-decreasing: advance to the segment containing d
+		for j < len(dist.Values)-2 && dist.Values[j+1] < d {
+			j++
+		}
+		d0, d1 := dist.Values[j], dist.Values[j+1]
+		t0, t1 := dist.Times[j], dist.Times[j+1]
+		if d <= d0 || d1 == d0 {
+			times[i] = t0
+			continue
+		}
+		frac := (d - d0) / (d1 - d0)
+		if frac > 1 {
+			frac = 1
+		}
+		times[i] = t0.Add(time.Duration(frac * float64(t1.Sub(t0))))
+	}
+	return times, xs, nil
+}
+
+// SampleAt linearly interpolates s at the given increasing times. Points outside
+// the series are nil.
+func SampleAt(s Series, at []time.Time) []*float64 {
+	out := make([]*float64, len(at))
+	if len(s.Times) == 0 {
+		return out
+	}
+	j := 0
+	for i, t := range at {
+		if t.Before(s.Times[0]) || t.After(s.Times[len(s.Times)-1]) {
+			continue
+		}
+		for j < len(s.Times)-1 && s.Times[j+1].Before(t) {
+			j++
+		}
+		v := s.Values[j]
+		if j < len(s.Times)-1 && t.After(s.Times[j]) {
+			span := s.Times[j+1].Sub(s.Times[j])
+			if span > 0 {
+				frac := float64(t.Sub(s.Times[j])) / float64(span)
+				v += frac * (s.Values[j+1] - s.Values[j])
+			}
+		}
+		out[i] = &v
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+// currentfusion.go
+//
+// Pack current fusion. The pack current is measured twice: by the shunt on the
+// pack current frame (pack_current.current) and by the AMS hall sensor
+// (aculv_fd_1.cell_current). While both readings agree within the tolerance they
+// are combined according to the configured policy; when they diverge neither can
+// be trusted to be the right one, so the policy's primary sensor is used and the
+// disagreement is reported. A single available reading is used as-is.
+package channels
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// Fusion policies
+const (
+	FusionAverage = "average" // Mean of both sensors, shunt while they diverge
+	FusionShunt   = "shunt"   // Shunt, hall only when the shunt is unavailable
+	FusionHall    = "hall"    // Hall, shunt only when the hall sensor is unavailable
+)
+
+// Sources of a fused sample
+const (
+	SourceShunt   = "shunt"
+	SourceHall    = "hall"
+	SourceAverage = "average"
+)
+
+// Defaults, overridable through ConfigureCurrentFusion
+const (
+	defaultFusionTolerance = 5.0 // A
+	defaultFusionMaxAge    = 500 * time.Millisecond
+)
+
+// CurrentFusionConfig describes how the two pack current readings are combined.
+type CurrentFusionConfig struct {
+	Policy    string        // FusionAverage (default), FusionShunt or FusionHall
+	Tolerance float64       // Largest difference in A at which the sensors agree
+	MaxAge    time.Duration // A live reading older than this is unavailable
+}
+
+// FusedCurrent is one fused pack current sample.
+type FusedCurrent struct {
+	Current    float64
+	Source     string  // Sensor(s) the value comes from
+	Difference float64 // Shunt minus hall, 0 unless both were available
+	Diverged   bool    // Both available and further apart than the tolerance
+}
+
+var (
+	fusionMu  sync.RWMutex
+	fusionCfg = CurrentFusionConfig{
+		Policy:    FusionAverage,
+		Tolerance: defaultFusionTolerance,
+		MaxAge:    defaultFusionMaxAge,
+	}
+)
+
+// ConfigureCurrentFusion installs the fusion policy. An empty policy and
+// non-positive tolerance or age select the defaults.
+func ConfigureCurrentFusion(cfg CurrentFusionConfig) error {
+	switch cfg.Policy {
+	case "":
+		cfg.Policy = FusionAverage
+	case FusionAverage, FusionShunt, FusionHall:
+	default:
+		return fmt.Errorf("unknown current fusion policy %q", cfg.Policy)
+	}
+	if cfg.Tolerance <= 0 {
+		cfg.Tolerance = defaultFusionTolerance
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultFusionMaxAge
+	}
+	fusionMu.Lock()
+	fusionCfg = cfg
+	fusionMu.Unlock()
+	return nil
+}
+
+// CurrentFusionSettings returns the installed fusion policy.
+func CurrentFusionSettings() CurrentFusionConfig {
+	fusionMu.RLock()
+	defer fusionMu.RUnlock()
+	return fusionCfg
+}
+
+// FuseCurrent combines a shunt and a hall reading; nil marks an unavailable one.
+// It reports false when neither is available.
+func FuseCurrent(shunt, hall *float64) (FusedCurrent, bool) {
+	cfg := CurrentFusionSettings()
+	switch {
+	case shunt == nil && hall == nil:
+		return FusedCurrent{}, false
+	case hall == nil:
+		return FusedCurrent{Current: *shunt, Source: SourceShunt}, true
+	case shunt == nil:
+		return FusedCurrent{Current: *hall, Source: SourceHall}, true
+	}
+
+	f := FusedCurrent{Difference: *shunt - *hall}
+	f.Diverged = math.Abs(f.Difference) > cfg.Tolerance
+	switch {
+	case cfg.Policy == FusionHall:
+		f.Current, f.Source = *hall, SourceHall
+	case cfg.Policy == FusionAverage && !f.Diverged:
+		f.Current, f.Source = (*shunt+*hall)/2, SourceAverage
+	default:
+		f.Current, f.Source = *shunt, SourceShunt
+	}
+	return f, true
+}
+
+// fusedCurrent returns the fused pack current over [from, to] at the shunt
+// sample times, with the hall reading interpolated to them. Without shunt data
+// the hall samples are used.
+func fusedCurrent(ctx context.Context, queries *db.Queries, from, to time.Time) (Series, error) {
+	shunt, err := Fetch(ctx, queries, "pack_current.current", from, to)
+	if err != nil {
+		return Series{}, err
+	}
+	hall, err := Fetch(ctx, queries, "aculv_fd_1.cell_current", from, to)
+	if err != nil {
+		return Series{}, err
+	}
+	if len(shunt.Times) == 0 {
+		return hall, nil
+	}
+
+	h := SampleAt(hall, shunt.Times)
+	out := Series{Times: shunt.Times, Values: make([]float64, len(shunt.Times))}
+	for i := range shunt.Times {
+		f, _ := FuseCurrent(&shunt.Values[i], h[i])
+		out.Values[i] = f.Current
+	}
+	return out, nil
+}
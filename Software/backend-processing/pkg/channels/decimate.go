@@ -0,0 +1,111 @@
+// decimate.go
+//
+// M4 decimation for plotting long ranges. The range is split into one bucket per
+// pixel column and only the first, last, minimum and maximum sample of every
+// bucket is kept, which draws the same line as the full data at that width.
+// Stored channels are aggregated while streaming from the database, so a full
+// endurance run never has to be held in memory.
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// MaxDecimateWidth is the largest number of buckets of a decimation.
+const MaxDecimateWidth = 10000
+
+// Bucket is the M4 aggregate of one bucket. Samples are [time, value] pairs with
+// the time in milliseconds since the Unix epoch.
+type Bucket struct {
+	Start float64    `json:"t"` // Start of the bucket
+	Count int        `json:"n"` // Samples in the bucket
+	First [2]float64 `json:"first"`
+	Last  [2]float64 `json:"last"`
+	Min   [2]float64 `json:"min"`
+	Max   [2]float64 `json:"max"`
+}
+
+// Envelope is a decimated channel. Buckets without samples are left out.
+type Envelope struct {
+	Channel  string   `json:"channel"`
+	From     float64  `json:"from"` // Milliseconds since the Unix epoch
+	To       float64  `json:"to"`
+	BucketMs float64  `json:"bucket_ms"`
+	Samples  int      `json:"samples"` // Samples aggregated
+	Buckets  []Bucket `json:"buckets"`
+	Gaps     []Gap    `json:"gaps,omitempty"` // See gaps.go
+}
+
+// Decimate aggregates a channel over [from, to] into width equal buckets.
+func Decimate(ctx context.Context, queries *db.Queries, id string, from, to time.Time, width int) (Envelope, error) {
+	if width < 1 || width > MaxDecimateWidth {
+		return Envelope{}, fmt.Errorf("width must be between 1 and %d", MaxDecimateWidth)
+	}
+	if !to.After(from) {
+		return Envelope{}, errors.New("to must be after from")
+	}
+	table, column, ok := strings.Cut(id, ".")
+	if !ok {
+		return Envelope{}, fmt.Errorf("invalid channel %q: expected table.column", id)
+	}
+
+	span := to.Sub(from)
+	env := Envelope{
+		Channel:  id,
+		From:     float64(from.UnixNano()) / 1e6,
+		To:       float64(to.UnixNano()) / 1e6,
+		BucketMs: float64(span) / float64(width) / float64(time.Millisecond),
+	}
+	current := -1
+	gaps := newGapDetector(id)
+	add := func(t time.Time, v float64) {
+		if math.IsNaN(v) {
+			return
+		}
+		gaps.add(t)
+		i := int(float64(t.Sub(from)) / float64(span) * float64(width))
+		i = min(max(i, 0), width-1) // t == to falls into the last bucket
+		sample := [2]float64{float64(t.UnixNano()) / 1e6, v}
+		env.Samples++
+		if i != current {
+			current = i
+			env.Buckets = append(env.Buckets, Bucket{
+				Start: env.From + float64(i)*env.BucketMs,
+				First: sample, Last: sample, Min: sample, Max: sample,
+			})
+		}
+		b := &env.Buckets[len(env.Buckets)-1]
+		b.Count++
+		b.Last = sample
+		if v < b.Min[1] {
+			b.Min = sample
+		}
+		if v > b.Max[1] {
+			b.Max = sample
+		}
+	}
+
+	if table == derivedPrefix {
+		s, err := fetchDerived(ctx, queries, column, from, to)
+		if err != nil {
+			return Envelope{}, err
+		}
+		for i, t := range s.Times {
+			add(t, s.Values[i])
+		}
+		env.Gaps = gaps.finish()
+		return env, nil
+	}
+	err := queries.ScanColumns(ctx, table, []string{column}, from, to, func(t time.Time, row []float64) error {
+		add(t, row[0])
+		return nil
+	})
+	env.Gaps = gaps.finish()
+	return env, err
+}
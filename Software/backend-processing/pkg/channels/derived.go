@@ -0,0 +1,96 @@
+// derived.go
+//
+// Derived channels computed from stored data at query time. Speed comes from the
+// INS north/east velocities; distance integrates that speed over time, starting at
+// zero at the beginning of the queried range. Brake temperatures are estimated by
+// the thermal model in brakes.go; the fused pack current comes from
+// currentfusion.go.
+package channels
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// Table name used to address derived channels
+const derivedPrefix = "derived"
+
+// Longest gap between INS samples that is integrated; the car is assumed to be
+// stationary across longer gaps (e.g. INS dropouts in the pits)
+const maxIntegrationGap = 2 * time.Second
+
+// derivedChannels lists the fixed derived channel names; accumulator segment
+// channels depend on the configuration (see segments.go).
+var derivedChannels = map[string]bool{
+	"speed":              true,
+	"distance":           true,
+	"brake_temp_front":   true,
+	"brake_temp_rear":    true,
+	"pack_current_fused": true,
+}
+
+// DerivedChannels returns the IDs of the fixed derived channels, sorted.
+// Accumulator segment channels are not included (see SegmentChannel).
+func DerivedChannels() []string {
+	ids := make([]string, 0, len(derivedChannels))
+	for name := range derivedChannels {
+		ids = append(ids, derivedPrefix+"."+name)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// fetchDerived computes a derived channel over [from, to].
+func fetchDerived(ctx context.Context, queries *db.Queries, name string, from, to time.Time) (Series, error) {
+	switch name {
+	case "speed":
+		return speed(ctx, queries, from, to)
+	case "distance":
+		s, err := speed(ctx, queries, from, to)
+		if err != nil {
+			return Series{}, err
+		}
+		return Integrate(s), nil
+	case "brake_temp_front":
+		return brakeTemp(ctx, queries, "front", from, to)
+	case "brake_temp_rear":
+		return brakeTemp(ctx, queries, "rear", from, to)
+	case "pack_current_fused":
+		return fusedCurrent(ctx, queries, from, to)
+	}
+	if seg, agg, ok := parseSegmentChannel(name); ok {
+		return segmentSeries(ctx, queries, seg, agg, from, to)
+	}
+	return Series{}, fmt.Errorf("unknown derived channel %q", name)
+}
+
+// speed returns the horizontal speed in m/s from the INS velocities.
+func speed(ctx context.Context, queries *db.Queries, from, to time.Time) (Series, error) {
+	times, vel, err := queries.FetchColumns(ctx, "ins_imu", []string{"north_vel", "east_vel"}, from, to)
+	if err != nil {
+		return Series{}, err
+	}
+	s := Series{Times: times, Values: make([]float64, len(times))}
+	for i := range times {
+		s.Values[i] = math.Hypot(vel[0][i], vel[1][i])
+	}
+	return s, nil
+}
+
+// Integrate returns the cumulative trapezoidal integral of s over time (e.g.
+// distance from speed). Gaps longer than maxIntegrationGap contribute nothing.
+func Integrate(s Series) Series {
+	out := Series{Times: s.Times, Values: make([]float64, len(s.Values))}
+	for i := 1; i < len(s.Values); i++ {
+		dt := s.Times[i].Sub(s.Times[i-1])
+		out.Values[i] = out.Values[i-1]
+		if dt > 0 && dt <= maxIntegrationGap {
+			out.Values[i] += 0.5 * (s.Values[i] + s.Values[i-1]) * dt.Seconds()
+		}
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+// energy.go
+//
+// Pack energy split into drive and regen. Pack current samples are classified by
+// sign (discharge is drive, charge is regen) with a small idle band around zero,
+// and pack power is integrated separately for each class.
+package channels
+
+import (
+	"context"
+	"math"
+	"sync"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"telem-system/pkg/utils"
+	"time"
+)
+
+// EnergyConfig describes the pack current convention.
+type EnergyConfig struct {
+	CurrentSign float64 // Pack current sign convention, see utils.CurrentSign
+	IdleCurrent float64 // Currents within ±IdleCurrent A count as neither drive nor regen
+}
+
+var (
+	energyMu  sync.RWMutex
+	energyCfg = EnergyConfig{CurrentSign: 1}
+)
+
+// ConfigureEnergy installs the pack current convention. A zero sign keeps positive
+// discharge current.
+func ConfigureEnergy(cfg EnergyConfig) {
+	cfg.CurrentSign = utils.CurrentSign(cfg.CurrentSign)
+	cfg.IdleCurrent = math.Abs(cfg.IdleCurrent)
+	energyMu.Lock()
+	energyCfg = cfg
+	energyMu.Unlock()
+}
+
+// PowerSplit returns the drive and regen power in W of a pack sample; at most one
+// of them is non-zero.
+func PowerSplit(voltage, current float64) (drive, regen float64) {
+	energyMu.RLock()
+	cfg := energyCfg
+	energyMu.RUnlock()
+
+	i := current * cfg.CurrentSign
+	switch {
+	case i > cfg.IdleCurrent:
+		return voltage * i, 0
+	case i < -cfg.IdleCurrent:
+		return 0, -voltage * i
+	}
+	return 0, 0
+}
+
+// EnergySplitBetween computes the drive/regen energy split over [from, to] from the
+// stored pack current and voltage.
+func EnergySplitBetween(ctx context.Context, queries *db.Queries, from, to time.Time) (types.EnergySplit, error) {
+	split := types.EnergySplit{From: from, To: to}
+	current, err := Fetch(ctx, queries, "pack_current.current", from, to)
+	if err != nil {
+		return split, err
+	}
+	voltage, err := Fetch(ctx, queries, "pack_voltage.voltage", from, to)
+	if err != nil {
+		return split, err
+	}
+	v := SampleAt(voltage, current.Times)
+
+	var drive, regen Series
+	for i, t := range current.Times {
+		if v[i] == nil {
+			continue
+		}
+		d, r := PowerSplit(*v[i], current.Values[i])
+		drive.Times = append(drive.Times, t)
+		drive.Values = append(drive.Values, d)
+		regen.Times = append(regen.Times, t)
+		regen.Values = append(regen.Values, r)
+	}
+	split.Samples = len(drive.Values)
+	if split.Samples < 2 {
+		return split, nil
+	}
+	split.DriveWh = Integrate(drive).Values[split.Samples-1] / 3600
+	split.RegenWh = Integrate(regen).Values[split.Samples-1] / 3600
+	split.NetWh = split.DriveWh - split.RegenWh
+	split.RegenFraction = RegenFraction(split.DriveWh, split.RegenWh)
+	return split, nil
+}
+
+// RegenFraction returns regen / drive, or nil without drive energy.
+func RegenFraction(driveWh, regenWh float64) *float64 {
+	if driveWh <= 0 {
+		return nil
+	}
+	f := regenWh / driveWh
+	return &f
+}
@@ -0,0 +1,392 @@
+// expr.go
+//
+// Arithmetic expressions over channels, used to try a derived channel formula on
+// recorded data before it is added to the pipeline. An expression combines
+// channel IDs ("pack_voltage.voltage", "derived.speed"), numbers, the constants
+// pi and e, the operators + - * / % ^ and comparisons (< <= > >= == !=, which
+// yield 1 or 0), and the functions abs, sqrt, exp, log, log10, sin, cos, tan,
+// atan2, min, max, pow and clamp. For example:
+//
+//	pack_voltage.voltage * pack_current.current / 1000
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Longest accepted expression
+	maxExprLength = 1000
+
+	// Most distinct channels an expression may reference
+	maxExprChannels = 16
+)
+
+// ErrInvalidExpression is returned for expressions that do not parse.
+var ErrInvalidExpression = errors.New("invalid expression")
+
+// Expr is a parsed expression.
+type Expr struct {
+	channels []string
+	eval     evalFunc
+}
+
+// evalFunc evaluates an expression node given the values of the referenced
+// channels, in the order of Expr.channels.
+type evalFunc func(vals []float64) float64
+
+// exprFuncs lists the functions with their number of arguments.
+var exprFuncs = map[string]struct {
+	args int
+	fn   func(a []float64) float64
+}{
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"exp":   {1, func(a []float64) float64 { return math.Exp(a[0]) }},
+	"log":   {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"log10": {1, func(a []float64) float64 { return math.Log10(a[0]) }},
+	"sin":   {1, func(a []float64) float64 { return math.Sin(a[0]) }},
+	"cos":   {1, func(a []float64) float64 { return math.Cos(a[0]) }},
+	"tan":   {1, func(a []float64) float64 { return math.Tan(a[0]) }},
+	"atan2": {2, func(a []float64) float64 { return math.Atan2(a[0], a[1]) }},
+	"min":   {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":   {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+	"pow":   {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"clamp": {3, func(a []float64) float64 { return math.Max(a[1], math.Min(a[2], a[0])) }},
+}
+
+var exprConstants = map[string]float64{"pi": math.Pi, "e": math.E}
+
+// ParseExpr parses an expression and checks the channels it references.
+func ParseExpr(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidExpression)
+	}
+	if len(src) > maxExprLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidExpression, maxExprLength)
+	}
+	p := &exprParser{src: src, index: make(map[string]int)}
+	p.next()
+	eval, err := p.comparison()
+	if err == nil && p.tok.kind != tokEOF {
+		err = p.errorf("unexpected %q", p.tok.text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{channels: p.channels, eval: eval}, nil
+}
+
+// Channels returns the channels referenced by the expression, in order of first
+// appearance.
+func (e *Expr) Channels() []string {
+	return append([]string(nil), e.channels...)
+}
+
+// Eval evaluates the expression with the values of its channels, in the order of
+// Channels.
+func (e *Expr) Eval(vals []float64) float64 {
+	return e.eval(vals)
+}
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokNumber
+	tokIdent // Name, possibly dotted (channel ID)
+	tokOp
+)
+
+type exprToken struct {
+	kind int
+	text string
+	num  float64
+	pos  int // Byte offset in the source
+}
+
+// exprParser is a recursive descent parser that compiles as it goes.
+type exprParser struct {
+	src      string
+	pos      int
+	tok      exprToken
+	err      error // Lexing error, reported at the next parse step
+	channels []string
+	index    map[string]int // Channel -> position in channels
+}
+
+// errorf returns a parse error at the current token.
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at column %d: %s", ErrInvalidExpression, p.tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+// next reads the next token.
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = exprToken{kind: tokEOF, pos: start}
+		return
+	}
+	c := p.src[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent, e.g. 1e-3
+		if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.src) && (p.src[end] == '+' || p.src[end] == '-') {
+				end++
+			}
+			if end < len(p.src) && isDigit(p.src[end]) {
+				for end < len(p.src) && isDigit(p.src[end]) {
+					end++
+				}
+				p.pos = end
+			}
+		}
+		text := p.src[start:p.pos]
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil && p.err == nil {
+			p.err = fmt.Errorf("%w at column %d: invalid number %q", ErrInvalidExpression, start+1, text)
+		}
+		p.tok = exprToken{kind: tokNumber, text: text, num: v, pos: start}
+	case isIdentStart(c):
+		for p.pos < len(p.src) && (isIdentStart(p.src[p.pos]) || isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = exprToken{kind: tokIdent, text: p.src[start:p.pos], pos: start}
+	default:
+		p.pos++
+		if p.pos < len(p.src) && p.src[p.pos] == '=' && strings.IndexByte("<>=!", c) >= 0 {
+			p.pos++
+		}
+		p.tok = exprToken{kind: tokOp, text: p.src[start:p.pos], pos: start}
+	}
+}
+
+// isOp reports whether the current token is one of the operators.
+func (p *exprParser) isOp(ops ...string) bool {
+	if p.tok.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if p.tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// comparison := sum [("<" | "<=" | ">" | ">=" | "==" | "!=") sum]
+func (p *exprParser) comparison() (evalFunc, error) {
+	left, err := p.sum()
+	if err != nil || !p.isOp("<", "<=", ">", ">=", "==", "!=") {
+		return left, err
+	}
+	op := p.tok.text
+	p.next()
+	right, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	var cmp func(a, b float64) bool
+	switch op {
+	case "<":
+		cmp = func(a, b float64) bool { return a < b }
+	case "<=":
+		cmp = func(a, b float64) bool { return a <= b }
+	case ">":
+		cmp = func(a, b float64) bool { return a > b }
+	case ">=":
+		cmp = func(a, b float64) bool { return a >= b }
+	case "==":
+		cmp = func(a, b float64) bool { return a == b }
+	default:
+		cmp = func(a, b float64) bool { return a != b }
+	}
+	return func(v []float64) float64 {
+		if cmp(left(v), right(v)) {
+			return 1
+		}
+		return 0
+	}, nil
+}
+
+// sum := product {("+" | "-") product}
+func (p *exprParser) sum() (evalFunc, error) {
+	left, err := p.product()
+	for err == nil && p.isOp("+", "-") {
+		op := p.tok.text
+		p.next()
+		var right evalFunc
+		if right, err = p.product(); err != nil {
+			break
+		}
+		l := left
+		if op == "+" {
+			left = func(v []float64) float64 { return l(v) + right(v) }
+		} else {
+			left = func(v []float64) float64 { return l(v) - right(v) }
+		}
+	}
+	return left, err
+}
+
+// product := unary {("*" | "/" | "%") unary}
+func (p *exprParser) product() (evalFunc, error) {
+	left, err := p.unary()
+	for err == nil && p.isOp("*", "/", "%") {
+		op := p.tok.text
+		p.next()
+		var right evalFunc
+		if right, err = p.unary(); err != nil {
+			break
+		}
+		l := left
+		switch op {
+		case "*":
+			left = func(v []float64) float64 { return l(v) * right(v) }
+		case "/":
+			left = func(v []float64) float64 { return l(v) / right(v) }
+		default:
+			left = func(v []float64) float64 { return math.Mod(l(v), right(v)) }
+		}
+	}
+	return left, err
+}
+
+// unary := ("-" | "+") unary | power
+func (p *exprParser) unary() (evalFunc, error) {
+	if p.isOp("-", "+") {
+		neg := p.tok.text == "-"
+		p.next()
+		operand, err := p.unary()
+		if err != nil || !neg {
+			return operand, err
+		}
+		return func(v []float64) float64 { return -operand(v) }, nil
+	}
+	return p.power()
+}
+
+// power := primary ["^" unary] (right associative, binds tighter than unary minus
+// on its left: -2^2 is -4)
+func (p *exprParser) power() (evalFunc, error) {
+	base, err := p.primary()
+	if err != nil || !p.isOp("^") {
+		return base, err
+	}
+	p.next()
+	exp, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(v []float64) float64 { return math.Pow(base(v), exp(v)) }, nil
+}
+
+// primary := number | constant | channel | function "(" args ")" | "(" comparison ")"
+func (p *exprParser) primary() (evalFunc, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	tok := p.tok
+	switch tok.kind {
+	case tokNumber:
+		p.next()
+		return func([]float64) float64 { return tok.num }, nil
+	case tokIdent:
+		p.next()
+		if strings.Contains(tok.text, ".") {
+			return p.channel(tok)
+		}
+		if p.isOp("(") {
+			return p.call(tok)
+		}
+		if c, ok := exprConstants[strings.ToLower(tok.text)]; ok {
+			return func([]float64) float64 { return c }, nil
+		}
+		p.tok = tok
+		return nil, p.errorf("unknown name %q; channels are written table.column", tok.text)
+	case tokOp:
+		if tok.text == "(" {
+			p.next()
+			inner, err := p.comparison()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				return nil, p.errorf("expected )")
+			}
+			p.next()
+			return inner, nil
+		}
+		return nil, p.errorf("unexpected %q", tok.text)
+	default:
+		return nil, p.errorf("unexpected end of expression")
+	}
+}
+
+// channel resolves a channel reference.
+func (p *exprParser) channel(tok exprToken) (evalFunc, error) {
+	if err := Validate(tok.text); err != nil {
+		return nil, fmt.Errorf("%w at column %d: %v", ErrInvalidExpression, tok.pos+1, err)
+	}
+	i, ok := p.index[tok.text]
+	if !ok {
+		if len(p.channels) == maxExprChannels {
+			return nil, fmt.Errorf("%w: more than %d channels", ErrInvalidExpression, maxExprChannels)
+		}
+		i = len(p.channels)
+		p.index[tok.text] = i
+		p.channels = append(p.channels, tok.text)
+	}
+	return func(v []float64) float64 { return v[i] }, nil
+}
+
+// call parses the arguments of a function call; the current token is "(".
+func (p *exprParser) call(tok exprToken) (evalFunc, error) {
+	f, ok := exprFuncs[strings.ToLower(tok.text)]
+	if !ok {
+		p.tok = tok
+		return nil, p.errorf("unknown function %q", tok.text)
+	}
+	p.next()
+	var args []evalFunc
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if !p.isOp(",") {
+				return nil, p.errorf("expected , or )")
+			}
+			p.next()
+		}
+		arg, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) != f.args {
+		return nil, p.errorf("%s takes %d arguments, got %d", tok.text, f.args, len(args))
+	}
+	p.next()
+	return func(v []float64) float64 {
+		a := make([]float64, len(args))
+		for i, arg := range args {
+			a[i] = arg(v)
+		}
+		return f.fn(a)
+	}, nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool { return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
@@ -0,0 +1,151 @@
+// gaps.go
+//
+// Data gap detection for range queries. A gap is an interval between two
+// consecutive samples of a channel longer than its expected period times a
+// tolerance factor, e.g. a radio dropout. Query results list the gaps of every
+// channel so plots can break the line there instead of interpolating across
+// them. The expected period of a table can be configured; otherwise it is the
+// median interval between the first samples of the range.
+package channels
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"telem-system/pkg/db"
+	"time"
+)
+
+const (
+	// Default tolerance: intervals longer than this many expected periods are gaps
+	defaultGapFactor = 3.0
+
+	// Intervals the expected period is estimated from
+	gapEstimateIntervals = 64
+
+	// Shortest interval reported as a gap, so jitter on fast channels is not
+	gapMinDuration = 50 * time.Millisecond
+)
+
+// GapConfig sets how gaps are detected.
+type GapConfig struct {
+	Factor  float64                  // Intervals longer than Factor expected periods are gaps; 0 selects 3
+	Periods map[string]time.Duration // Expected sample period per table; others are estimated
+}
+
+// Gap is an interval without samples, from the last sample before it to the
+// first sample after it, in milliseconds since the Unix epoch.
+type Gap struct {
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+var (
+	gapMu  sync.RWMutex
+	gapCfg = GapConfig{Factor: defaultGapFactor}
+)
+
+// ConfigureGaps installs the gap detection settings.
+func ConfigureGaps(cfg GapConfig) error {
+	if cfg.Factor < 0 || (cfg.Factor > 0 && cfg.Factor < 1) {
+		return fmt.Errorf("gap factor must be at least 1, got %g", cfg.Factor)
+	}
+	if cfg.Factor == 0 {
+		cfg.Factor = defaultGapFactor
+	}
+	for table, period := range cfg.Periods {
+		if _, ok := db.LookupTable(table); !ok {
+			return fmt.Errorf("expected period for unknown table %q", table)
+		}
+		if period <= 0 {
+			return fmt.Errorf("expected period of %s must be positive", table)
+		}
+	}
+	gapMu.Lock()
+	gapCfg = cfg
+	gapMu.Unlock()
+	return nil
+}
+
+// gapDetector finds the gaps of a channel from its sample times, given in
+// increasing order. Until the expected period is known, the first samples are
+// held to estimate it.
+type gapDetector struct {
+	period  time.Duration // Expected period; 0 while estimating
+	factor  float64
+	last    time.Time
+	pending []time.Time // Samples held while estimating
+	gaps    []Gap
+}
+
+// newGapDetector returns a detector for the channel id.
+func newGapDetector(id string) *gapDetector {
+	gapMu.RLock()
+	defer gapMu.RUnlock()
+	table, _, _ := strings.Cut(id, ".")
+	return &gapDetector{period: gapCfg.Periods[table], factor: gapCfg.Factor}
+}
+
+// add records the next sample time.
+func (d *gapDetector) add(t time.Time) {
+	if d.period > 0 {
+		d.check(t)
+		return
+	}
+	d.pending = append(d.pending, t)
+	if len(d.pending) > gapEstimateIntervals {
+		d.estimate()
+	}
+}
+
+// finish returns the gaps, estimating the period from the held samples if there
+// were too few to do so earlier.
+func (d *gapDetector) finish() []Gap {
+	if d.period == 0 {
+		d.estimate()
+	}
+	return d.gaps
+}
+
+// estimate sets the period to the median interval of the held samples and checks
+// them.
+func (d *gapDetector) estimate() {
+	if len(d.pending) < 3 {
+		d.pending = nil
+		return // Too few samples to tell a gap from the period
+	}
+	intervals := make([]time.Duration, len(d.pending)-1)
+	for i := range intervals {
+		intervals[i] = d.pending[i+1].Sub(d.pending[i])
+	}
+	slices.Sort(intervals)
+	d.period = intervals[len(intervals)/2]
+	if d.period <= 0 {
+		d.period = time.Nanosecond // Bursts of samples sharing a timestamp
+	}
+	for _, t := range d.pending {
+		d.check(t)
+	}
+	d.pending = nil
+}
+
+// check records a gap before t if it is long enough.
+func (d *gapDetector) check(t time.Time) {
+	if !d.last.IsZero() {
+		interval := t.Sub(d.last)
+		if interval >= gapMinDuration && float64(interval) > d.factor*float64(d.period) {
+			d.gaps = append(d.gaps, Gap{From: float64(d.last.UnixNano()) / 1e6, To: float64(t.UnixNano()) / 1e6})
+		}
+	}
+	d.last = t
+}
+
+// seriesGaps returns the gaps of a fetched series.
+func seriesGaps(id string, s Series) []Gap {
+	d := newGapDetector(id)
+	for _, t := range s.Times {
+		d.add(t)
+	}
+	return d.finish()
+}
@@ -0,0 +1,117 @@
+// preview.go
+//
+// Preview of a candidate derived channel: an expression (see expr.go) evaluated
+// over a recorded time range, so formulas can be iterated on against real data
+// without changing and restarting the pipeline. The referenced channels are
+// resampled onto a common time grid and the expression is evaluated at every
+// grid point.
+package channels
+
+import (
+	"context"
+	"errors"
+	"math"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// PreviewQuery describes an expression preview.
+type PreviewQuery struct {
+	Expression string
+	From, To   time.Time
+	Step       time.Duration // Grid step; 0 selects the default time step
+}
+
+// PreviewResult holds the evaluated series. T is in milliseconds since the Unix
+// epoch. Values are null where a referenced channel has no data or the result is
+// not a finite number (e.g. division by zero).
+type PreviewResult struct {
+	Expression string       `json:"expression"`
+	Channels   []string     `json:"channels"`
+	T          []float64    `json:"t"`
+	Values     []*float64   `json:"values"`
+	Stats      PreviewStats `json:"stats"`
+}
+
+// PreviewStats summarises the non-null values of a preview.
+type PreviewStats struct {
+	Count int      `json:"count"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Mean  *float64 `json:"mean,omitempty"`
+}
+
+// Preview evaluates an expression over a time range. It returns an error
+// wrapping ErrInvalidExpression when the expression does not parse.
+func Preview(ctx context.Context, queries *db.Queries, q PreviewQuery) (PreviewResult, error) {
+	expr, err := ParseExpr(q.Expression)
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	if q.To.Before(q.From) {
+		return PreviewResult{}, errors.New("to is before from")
+	}
+	step := q.Step
+	if step <= 0 {
+		step = defaultTimeStep
+	}
+	n := int(q.To.Sub(q.From)/step) + 1
+	if n > maxPoints {
+		return PreviewResult{}, ErrTooManyPoints
+	}
+	grid := make([]time.Time, n)
+	for i := range grid {
+		grid[i] = q.From.Add(time.Duration(i) * step)
+	}
+
+	ids := expr.Channels()
+	sampled := make([][]*float64, len(ids))
+	for i, id := range ids {
+		s, err := Fetch(ctx, queries, id, q.From, q.To)
+		if err != nil {
+			return PreviewResult{}, err
+		}
+		sampled[i] = SampleAt(s, grid)
+	}
+
+	res := PreviewResult{
+		Expression: q.Expression,
+		Channels:   ids,
+		T:          make([]float64, n),
+		Values:     make([]*float64, n),
+	}
+	vals := make([]float64, len(ids))
+	var sum float64
+	for i, t := range grid {
+		res.T[i] = float64(t.UnixNano()) / 1e6
+		missing := false
+		for j := range ids {
+			if sampled[j][i] == nil {
+				missing = true
+				break
+			}
+			vals[j] = *sampled[j][i]
+		}
+		if missing {
+			continue
+		}
+		v := expr.Eval(vals)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		res.Values[i] = &v
+		if res.Stats.Count == 0 || v < *res.Stats.Min {
+			res.Stats.Min = &v
+		}
+		if res.Stats.Count == 0 || v > *res.Stats.Max {
+			res.Stats.Max = &v
+		}
+		res.Stats.Count++
+		sum += v
+	}
+	if res.Stats.Count > 0 {
+		mean := sum / float64(res.Stats.Count)
+		res.Stats.Mean = &mean
+	}
+	return res, nil
+}
@@ -0,0 +1,276 @@
+// segments.go
+//
+// Accumulator segment aggregates. The pack is described as a list of segments,
+// each a contiguous range of cells plus the thermistor modules mounted on it.
+// Every segment exposes derived channels for its voltage (sum of its cells), its
+// lowest and highest cell and its hottest thermistor, addressed as
+// "derived.segment<N>_<aggregate>" with N counted from 1.
+package channels
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// Segment aggregates
+const (
+	AggVoltage = "voltage"
+	AggMinCell = "min_cell"
+	AggMaxCell = "max_cell"
+	AggMaxTemp = "max_temp"
+)
+
+// SegmentAggregates lists the aggregates available for every segment.
+var SegmentAggregates = []string{AggVoltage, AggMinCell, AggMaxCell, AggMaxTemp}
+
+// Number of temperature channels of a thermistor module
+const thermChannels = 16
+
+// How far back a snapshot looks for the latest sample
+const snapshotLookback = 10 * time.Second
+
+// Segment is a group of series-connected cells.
+type Segment struct {
+	Name          string `json:"name"`
+	FirstCell     int    `json:"first_cell"` // 1-based, inclusive
+	LastCell      int    `json:"last_cell"`
+	ThermistorIDs []int  `json:"thermistor_ids"` // therm_data modules mounted on the segment
+}
+
+// SegmentStats holds the aggregates of a segment at a point in time. Values are
+// nil when the segment has no recent data.
+type SegmentStats struct {
+	Segment   int       `json:"segment"` // 1-based index
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+	Voltage   *float64  `json:"voltage"`
+	MinCell   *float64  `json:"min_cell"`
+	MaxCell   *float64  `json:"max_cell"`
+	MaxTemp   *float64  `json:"max_temp"`
+}
+
+var (
+	segmentsMu sync.RWMutex
+	segments   []Segment
+)
+
+// ConfigureSegments installs the cell to segment mapping. Cell ranges must be
+// within the cell_data table and must not overlap.
+func ConfigureSegments(s []Segment) error {
+	spec, _ := db.LookupTable("cell_data")
+	owner := make(map[int]string)
+	for i, seg := range s {
+		if seg.Name == "" {
+			s[i].Name = fmt.Sprintf("Segment %d", i+1)
+		}
+		if seg.FirstCell < 1 || seg.LastCell < seg.FirstCell {
+			return fmt.Errorf("segment %q: invalid cell range %d-%d", s[i].Name, seg.FirstCell, seg.LastCell)
+		}
+		if _, ok := spec.Column(cellColumn(seg.LastCell)); !ok {
+			return fmt.Errorf("segment %q: cell %d does not exist", s[i].Name, seg.LastCell)
+		}
+		for c := seg.FirstCell; c <= seg.LastCell; c++ {
+			if other, dup := owner[c]; dup {
+				return fmt.Errorf("cell %d is in segments %q and %q", c, other, s[i].Name)
+			}
+			owner[c] = s[i].Name
+		}
+	}
+	segmentsMu.Lock()
+	segments = s
+	segmentsMu.Unlock()
+	return nil
+}
+
+// Segments returns the configured segments.
+func Segments() []Segment {
+	segmentsMu.RLock()
+	defer segmentsMu.RUnlock()
+	return segments
+}
+
+// SegmentChannel returns the channel ID of an aggregate of the segment with the
+// given 0-based index.
+func SegmentChannel(index int, agg string) string {
+	return fmt.Sprintf("%s.segment%d_%s", derivedPrefix, index+1, agg)
+}
+
+// parseSegmentChannel resolves a derived channel name such as "segment2_voltage".
+func parseSegmentChannel(name string) (Segment, string, bool) {
+	rest, ok := strings.CutPrefix(name, "segment")
+	if !ok {
+		return Segment{}, "", false
+	}
+	num, agg, ok := strings.Cut(rest, "_")
+	if !ok {
+		return Segment{}, "", false
+	}
+	n, err := strconv.Atoi(num)
+	segs := Segments()
+	if err != nil || n < 1 || n > len(segs) {
+		return Segment{}, "", false
+	}
+	for _, a := range SegmentAggregates {
+		if a == agg {
+			return segs[n-1], agg, true
+		}
+	}
+	return Segment{}, "", false
+}
+
+// segmentSeries computes a segment aggregate over [from, to].
+func segmentSeries(ctx context.Context, queries *db.Queries, seg Segment, agg string, from, to time.Time) (Series, error) {
+	if agg == AggMaxTemp {
+		return segmentMaxTemp(ctx, queries, seg, from, to)
+	}
+	times, cells, err := queries.FetchColumns(ctx, "cell_data", segmentCellColumns(seg), from, to)
+	if err != nil {
+		return Series{}, err
+	}
+	s := Series{Times: times, Values: make([]float64, len(times))}
+	for i := range times {
+		s.Values[i] = cellAggregate(cells, i, agg)
+	}
+	return s, nil
+}
+
+// segmentMaxTemp returns the hottest thermistor of the segment. Modules report in
+// turn, so every sample combines the latest reading of each module.
+func segmentMaxTemp(ctx context.Context, queries *db.Queries, seg Segment, from, to time.Time) (Series, error) {
+	times, ids, temps, err := fetchTherms(ctx, queries, from, to)
+	if err != nil {
+		return Series{}, err
+	}
+	latest := make(map[int]float64, len(seg.ThermistorIDs))
+	var s Series
+	for i, t := range times {
+		if !slices.Contains(seg.ThermistorIDs, ids[i]) {
+			continue
+		}
+		latest[ids[i]] = temps[i]
+		hottest := math.Inf(-1)
+		for _, v := range latest {
+			hottest = math.Max(hottest, v)
+		}
+		s.Times = append(s.Times, t)
+		s.Values = append(s.Values, hottest)
+	}
+	return s, nil
+}
+
+// SegmentSnapshot returns the aggregates of every segment from the latest samples
+// at or before at.
+func SegmentSnapshot(ctx context.Context, queries *db.Queries, at time.Time) ([]SegmentStats, error) {
+	segs := Segments()
+	out := make([]SegmentStats, len(segs))
+	if len(segs) == 0 {
+		return out, nil
+	}
+	from := at.Add(-snapshotLookback)
+
+	// Latest cell row, covering every configured cell
+	var cols []string
+	for _, seg := range segs {
+		cols = append(cols, segmentCellColumns(seg)...)
+	}
+	cellTimes, cells, err := queries.FetchColumns(ctx, "cell_data", cols, from, at)
+	if err != nil {
+		return nil, err
+	}
+
+	// Latest hottest reading per thermistor module
+	thermTimes, ids, temps, err := fetchTherms(ctx, queries, from, at)
+	if err != nil {
+		return nil, err
+	}
+	moduleMax := make(map[int]float64)
+	for i := range thermTimes {
+		moduleMax[ids[i]] = temps[i]
+	}
+
+	col := 0
+	for i, seg := range segs {
+		st := SegmentStats{Segment: i + 1, Name: seg.Name}
+		n := seg.LastCell - seg.FirstCell + 1
+		if last := len(cellTimes) - 1; last >= 0 {
+			st.Timestamp = cellTimes[last]
+			segCells := cells[col : col+n]
+			v, lo, hi := cellAggregate(segCells, last, AggVoltage), cellAggregate(segCells, last, AggMinCell), cellAggregate(segCells, last, AggMaxCell)
+			st.Voltage, st.MinCell, st.MaxCell = &v, &lo, &hi
+		}
+		col += n
+		for _, id := range seg.ThermistorIDs {
+			if t, ok := moduleMax[id]; ok && (st.MaxTemp == nil || t > *st.MaxTemp) {
+				st.MaxTemp = &t
+			}
+		}
+		out[i] = st
+	}
+	return out, nil
+}
+
+// fetchTherms returns every thermistor module sample in [from, to] as its module
+// ID and hottest channel.
+func fetchTherms(ctx context.Context, queries *db.Queries, from, to time.Time) ([]time.Time, []int, []float64, error) {
+	cols := []string{"thermistor_id"}
+	for i := 1; i <= thermChannels; i++ {
+		cols = append(cols, "therm"+strconv.Itoa(i))
+	}
+	times, values, err := queries.FetchColumns(ctx, "therm_data", cols, from, to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ids := make([]int, len(times))
+	temps := make([]float64, len(times))
+	for i := range times {
+		ids[i] = int(values[0][i])
+		temps[i] = math.Inf(-1)
+		for _, ch := range values[1:] {
+			temps[i] = math.Max(temps[i], ch[i])
+		}
+	}
+	return times, ids, temps, nil
+}
+
+// cellAggregate computes a cell aggregate of row i.
+func cellAggregate(cells [][]float64, i int, agg string) float64 {
+	var v float64
+	switch agg {
+	case AggVoltage:
+		for _, c := range cells {
+			v += c[i]
+		}
+	case AggMinCell:
+		v = math.Inf(1)
+		for _, c := range cells {
+			v = math.Min(v, c[i])
+		}
+	case AggMaxCell:
+		v = math.Inf(-1)
+		for _, c := range cells {
+			v = math.Max(v, c[i])
+		}
+	}
+	return v
+}
+
+// segmentCellColumns returns the cell_data columns of a segment.
+func segmentCellColumns(seg Segment) []string {
+	cols := make([]string, 0, seg.LastCell-seg.FirstCell+1)
+	for c := seg.FirstCell; c <= seg.LastCell; c++ {
+		cols = append(cols, cellColumn(c))
+	}
+	return cols
+}
+
+// cellColumn returns the cell_data column of a 1-based cell number.
+func cellColumn(n int) string {
+	return "cell" + strconv.Itoa(n)
+}
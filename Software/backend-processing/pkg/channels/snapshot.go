@@ -0,0 +1,72 @@
+// snapshot.go
+//
+// Point-in-time state of the stored channels: the last value of every channel at
+// or before a given instant, for dashboards scrubbing through history. Derived
+// channels are computed over ranges and are not part of a snapshot.
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// ErrNotStored is returned for derived channels in a snapshot.
+var ErrNotStored = errors.New("derived channels have no point-in-time value")
+
+// StoredChannels returns the ID of every stored channel, in table order.
+func StoredChannels() []string {
+	var ids []string
+	for _, t := range db.TelemetryTables {
+		for _, c := range t.Columns {
+			ids = append(ids, t.Name+"."+c.Name)
+		}
+	}
+	return ids
+}
+
+// Snapshot returns the last value at or before at of each stored channel in ids,
+// looking back no further than lookback. Channels without a value in that window
+// map to nil.
+func Snapshot(ctx context.Context, queries *db.Queries, ids []string, at time.Time, lookback time.Duration) (map[string]*types.TimedValue, error) {
+	var tables []string
+	columns := make(map[string][]string)
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := Validate(id); err != nil {
+			return nil, err
+		}
+		table, column, _ := strings.Cut(id, ".")
+		if table == derivedPrefix {
+			return nil, fmt.Errorf("%w: %s", ErrNotStored, id)
+		}
+		if _, ok := columns[table]; !ok {
+			tables = append(tables, table)
+		}
+		columns[table] = append(columns[table], column)
+	}
+
+	out := make(map[string]*types.TimedValue, len(ids))
+	for _, table := range tables {
+		values, err := queries.FetchLatestValues(ctx, table, columns[table], at.Add(-lookback), at)
+		if err != nil {
+			return nil, err
+		}
+		for _, column := range columns[table] {
+			var v *types.TimedValue
+			if tv, ok := values[column]; ok {
+				v = &tv
+			}
+			out[table+"."+column] = v
+		}
+	}
+	return out, nil
+}
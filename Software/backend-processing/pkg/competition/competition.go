@@ -0,0 +1,244 @@
+// competition.go
+//
+// Package competition implements competition mode, the single switch flipped
+// before scrutineering. While it is on:
+//   - admin endpoints that change the server (profiles, feature flags, jobs,
+//     maintenance, captures) are locked; reads stay available,
+//   - it can only be on with an authentication provider and with every route of
+//     the servers (API, telemetry ingest, live data) behind authentication,
+//   - the CAN definitions and configuration are pinned: the hashes of the loaded
+//     files are recorded, must match the configured pins, and the mode refuses
+//     to start when the files on disk no longer match what the server runs,
+//   - storage takes priority over broadcast: live frames are shed while
+//     persistence lags.
+package competition
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"telem-system/pkg/processdata"
+	"time"
+)
+
+// Errors returned when competition mode cannot be turned on
+var (
+	ErrAuthDisabled = errors.New("competition mode requires an authentication provider")
+	ErrOpenRoute    = errors.New("competition mode requires authentication on every route")
+	ErrVersionDrift = errors.New("files on disk differ from the loaded ones; restart before competition mode")
+)
+
+// Config sets up competition mode.
+type Config struct {
+	Enabled           bool   // On at startup
+	DefinitionsSHA256 string // Expected hash of the CAN definitions; empty accepts the loaded file
+	ConfigSHA256      string // Expected hash of the configuration file; empty accepts the loaded file
+}
+
+// Setup describes the running server.
+type Setup struct {
+	AuthProvider    string // Name of the authentication provider
+	DefinitionsFile string // CAN definitions loaded at startup
+	ConfigFile      string // Configuration file loaded at startup
+
+	// Embedded contents (portable mode), hashed instead of the files when set
+	Definitions []byte
+	Config      []byte
+}
+
+// Status reports competition mode.
+type Status struct {
+	Active             bool       `json:"active"`
+	Since              *time.Time `json:"since,omitempty"`
+	By                 string     `json:"by,omitempty"`
+	DefinitionsSHA256  string     `json:"definitions_sha256"`
+	ConfigSHA256       string     `json:"config_sha256"`
+	DefinitionsChanged bool       `json:"definitions_changed"` // File on disk differs from the loaded one
+	ConfigChanged      bool       `json:"config_changed"`
+	ShedBroadcasts     uint64     `json:"shed_broadcasts"` // Live frames shed while storage lagged
+}
+
+var (
+	active atomic.Bool
+
+	mu       sync.Mutex // Guards the fields below
+	setup    Setup
+	loaded   [2]string // Hashes of the definitions and configuration at startup
+	since    time.Time
+	activeBy string
+	routes   = make(map[string]bool) // Registered routes by whether they authenticate
+)
+
+// Route records a route served by the server and whether it authenticates its
+// clients. Competition mode refuses to turn on while a registered route does
+// not, so every route must be registered before Configure.
+func Route(name string, authenticated bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	routes[name] = authenticated
+}
+
+// openRoutesLocked returns the registered routes without authentication, sorted.
+// It must be called with mu held.
+func openRoutesLocked() []string {
+	var open []string
+	for name, authenticated := range routes {
+		if !authenticated {
+			open = append(open, name)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
+
+// Configure records the files the server runs on and turns competition mode on
+// if configured. The loaded files must match the configured pins.
+func Configure(cfg Config, s Setup) error {
+	defs, err := hashSource(s.DefinitionsFile, s.Definitions)
+	if err != nil {
+		return fmt.Errorf("hash definitions: %w", err)
+	}
+	conf, err := hashSource(s.ConfigFile, s.Config)
+	if err != nil {
+		return fmt.Errorf("hash configuration: %w", err)
+	}
+	if err := checkPin("definitions", cfg.DefinitionsSHA256, defs); err != nil {
+		return err
+	}
+	if err := checkPin("configuration", cfg.ConfigSHA256, conf); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	setup, loaded = s, [2]string{defs, conf}
+	mu.Unlock()
+
+	if cfg.Enabled {
+		_, err := Set(true, "config")
+		return err
+	}
+	return nil
+}
+
+// Active reports whether competition mode is on. It is safe to call on every
+// request.
+func Active() bool {
+	return active.Load()
+}
+
+// Set turns competition mode on or off and returns its status.
+func Set(on bool, by string) (Status, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if on == active.Load() {
+		return statusLocked(), nil
+	}
+	if on {
+		if setup.AuthProvider == "" || setup.AuthProvider == "none" {
+			return Status{}, ErrAuthDisabled
+		}
+		if open := openRoutesLocked(); len(open) > 0 {
+			return Status{}, fmt.Errorf("%w: %s", ErrOpenRoute, strings.Join(open, ", "))
+		}
+		if defs, conf := currentHashes(); defs != loaded[0] || conf != loaded[1] {
+			return Status{}, ErrVersionDrift
+		}
+		since, activeBy = time.Now(), by
+	} else {
+		since, activeBy = time.Time{}, ""
+	}
+	active.Store(on)
+	processdata.SetPersistenceFirst(on)
+	if on {
+		log.Printf("Competition mode on (by %s): admin changes locked, definitions %s, config %s",
+			by, short(loaded[0]), short(loaded[1]))
+	} else {
+		log.Printf("Competition mode off (by %s)", by)
+	}
+	return statusLocked(), nil
+}
+
+// GetStatus returns the competition mode status.
+func GetStatus() Status {
+	mu.Lock()
+	defer mu.Unlock()
+	return statusLocked()
+}
+
+// statusLocked builds the status. It must be called with mu held.
+func statusLocked() Status {
+	s := Status{
+		Active:            active.Load(),
+		By:                activeBy,
+		DefinitionsSHA256: loaded[0],
+		ConfigSHA256:      loaded[1],
+		ShedBroadcasts:    processdata.ShedBroadcasts(),
+	}
+	if !since.IsZero() {
+		t := since
+		s.Since = &t
+	}
+	defs, conf := currentHashes()
+	s.DefinitionsChanged, s.ConfigChanged = defs != loaded[0], conf != loaded[1]
+	return s
+}
+
+// currentHashes hashes the files as they are on disk now; unreadable files hash
+// to the empty string. It must be called with mu held.
+func currentHashes() (defs, conf string) {
+	defs, _ = hashSource(setup.DefinitionsFile, setup.Definitions)
+	conf, _ = hashSource(setup.ConfigFile, setup.Config)
+	return defs, conf
+}
+
+// hashSource returns the hex SHA-256 of embedded contents, or of the file at path
+// when there are none.
+func hashSource(path string, data []byte) (string, error) {
+	if data == nil {
+		return hashFile(path)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// checkPin compares a loaded hash with its pin.
+func checkPin(what, pin, got string) error {
+	if pin != "" && !strings.EqualFold(pin, got) {
+		return fmt.Errorf("%s sha256 %s does not match the pinned %s", what, got, pin)
+	}
+	return nil
+}
+
+// hashFile returns the hex SHA-256 of a file; an empty path hashes to the empty
+// string.
+func hashFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// short abbreviates a hash for logs.
+func short(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
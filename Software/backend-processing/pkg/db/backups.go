@@ -0,0 +1,44 @@
+// backups.go
+//
+// Insert and fetch functions for the backup log.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+)
+
+// InsertBackup records a finished backup and returns its ID.
+func InsertBackup(ctx context.Context, b types.Backup) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO backups (started_at, finished_at, status, file, size_bytes, sha256, tables, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, b.StartedAt, b.FinishedAt, b.Status, b.File, b.SizeBytes, b.SHA256, b.Tables, b.Error).Scan(&id)
+	return id, err
+}
+
+// ScanBackupsPaginated calls fn for each row of recorded backups, newest first.
+func (q *Queries) ScanBackupsPaginated(ctx context.Context, limit, offset int, fn func(types.Backup) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, started_at, finished_at, status, file, size_bytes, sha256, tables, error
+		FROM backups
+		ORDER BY started_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b types.Backup
+		if err := rows.Scan(&b.ID, &b.StartedAt, &b.FinishedAt, &b.Status, &b.File, &b.SizeBytes, &b.SHA256, &b.Tables, &b.Error); err != nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,101 @@
+// batch.go
+//
+// Row writer shared by the telemetry batch inserts. A batch is written with a
+// prepared INSERT per row in a transaction or, while the copy_inserts feature
+// flag is on, with a single COPY. Both take the rows produced by encodeRow, whose
+// column order (timestamp, the TableSpec columns, seq) gives COPY its column
+// list.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telem-system/pkg/features"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+)
+
+// batchWriter writes the rows of one batch.
+type batchWriter struct {
+	table string
+	tx    *sql.Tx
+	stmt  *sql.Stmt       // nil when writing with COPY
+	rows  [][]interface{} // Rows buffered for COPY
+}
+
+// beginBatch starts a batch for a telemetry table. insert is the statement used
+// when not writing with COPY.
+func beginBatch(ctx context.Context, table, insert string) (*batchWriter, error) {
+	b := &batchWriter{table: table}
+	if _, ok := LookupTable(table); ok && features.Enabled(features.CopyInserts) {
+		return b, nil
+	}
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := tx.PrepareContext(ctx, insert)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	b.tx, b.stmt = tx, stmt
+	return b, nil
+}
+
+// add writes or buffers an encoded row.
+func (b *batchWriter) add(ctx context.Context, row []interface{}) error {
+	if b.stmt == nil {
+		b.rows = append(b.rows, row)
+		return nil
+	}
+	_, err := b.stmt.ExecContext(ctx, row...)
+	return err
+}
+
+// commit stores the batch.
+func (b *batchWriter) commit(ctx context.Context) error {
+	if b.stmt == nil {
+		return copyRows(ctx, b.table, b.rows)
+	}
+	return b.tx.Commit()
+}
+
+// close releases the batch, rolling it back unless it was committed.
+func (b *batchWriter) close() {
+	if b.stmt != nil {
+		b.stmt.Close()
+		b.tx.Rollback()
+	}
+}
+
+// copyRows writes encoded rows to a telemetry table with COPY. The rows are
+// stored together or not at all.
+func copyRows(ctx context.Context, table string, rows [][]interface{}) error {
+	spec, ok := LookupTable(table)
+	if !ok {
+		return fmt.Errorf("unknown table %q", table)
+	}
+	columns := make([]string, 0, len(spec.Columns)+2)
+	columns = append(columns, "timestamp")
+	for _, c := range spec.Columns {
+		columns = append(columns, c.Name)
+	}
+	columns = append(columns, "seq")
+
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("COPY needs the pgx driver, got %T", driverConn)
+		}
+		_, err := c.Conn().CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+		return err
+	})
+}
@@ -0,0 +1,118 @@
+// calibrations.go
+//
+// Insert and fetch functions for the calibration log (calibration registry).
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"telem-system/pkg/types"
+)
+
+// InsertCalibration records a calibration and returns its ID.
+func InsertCalibration(ctx context.Context, c types.Calibration) (int64, error) {
+	params := []byte("{}")
+	if len(c.Params) > 0 {
+		var err error
+		if params, err = json.Marshal(c.Params); err != nil {
+			return 0, err
+		}
+	}
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO calibrations (name, calibrated_at, notes, params)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, c.Name, c.CalibratedAt, c.Notes, string(params)).Scan(&id)
+	return id, err
+}
+
+// scanCalibration scans a calibrations row.
+func scanCalibration(row interface{ Scan(...interface{}) error }) (types.Calibration, error) {
+	var c types.Calibration
+	var params []byte
+	if err := row.Scan(&c.ID, &c.Name, &c.CalibratedAt, &c.Notes, &params); err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(params, &c.Params); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// FetchLatestCalibrations returns the most recent calibration of every name.
+func (q *Queries) FetchLatestCalibrations(ctx context.Context) ([]types.Calibration, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (name) id, name, calibrated_at, notes, params
+		FROM calibrations
+		ORDER BY name, calibrated_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var data []types.Calibration
+	for rows.Next() {
+		c, err := scanCalibration(rows)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, c)
+	}
+	return data, rows.Err()
+}
+
+// FetchLatestCalibration returns the most recent calibration with the given name.
+// ok is false if the name was never calibrated.
+func (q *Queries) FetchLatestCalibration(ctx context.Context, name string) (c types.Calibration, ok bool, err error) {
+	c, err = scanCalibration(q.db.QueryRowContext(ctx, `
+		SELECT id, name, calibrated_at, notes, params
+		FROM calibrations
+		WHERE name = $1
+		ORDER BY calibrated_at DESC
+		LIMIT 1
+	`, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, false, nil
+	}
+	return c, err == nil, err
+}
+
+// ScanCalibrationsPaginated calls fn for each row of the calibration log, oldest
+// first.
+func (q *Queries) ScanCalibrationsPaginated(ctx context.Context, limit, offset int, fn func(types.Calibration) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, name, calibrated_at, notes, params
+		FROM calibrations
+		ORDER BY calibrated_at ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c, err := scanCalibration(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DatabaseSize returns the size of the current database in bytes.
+func (q *Queries) DatabaseSize(ctx context.Context) (int64, error) {
+	var size int64
+	err := q.db.QueryRowContext(ctx, `SELECT pg_database_size(current_database())`).Scan(&size)
+	return size, err
+}
+
+// Ping checks that the database is reachable.
+func (q *Queries) Ping(ctx context.Context) error {
+	return q.db.PingContext(ctx)
+}
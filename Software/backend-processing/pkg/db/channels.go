@@ -0,0 +1,150 @@
+// channels.go
+//
+// Column reads used by the channel query service.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// FetchColumns returns the timestamps and the values of the given columns of a
+// telemetry table within [from, to], in time order, with storage codecs undone.
+// values[i] holds the samples of columns[i]. Rows in which one of the columns is
+// NULL (a missing signal) are skipped, so callers only see real samples.
+func (q *Queries) FetchColumns(ctx context.Context, table string, columns []string, from, to time.Time) ([]time.Time, [][]float64, error) {
+	var times []time.Time
+	values := make([][]float64, len(columns))
+	err := q.ScanColumns(ctx, table, columns, from, to, func(t time.Time, row []float64) error {
+		times = append(times, t)
+		for i, v := range row {
+			values[i] = append(values[i], v)
+		}
+		return nil
+	})
+	return times, values, err
+}
+
+// ScanColumns is FetchColumns without holding the samples: fn is called for
+// every row in time order, with row following columns. row is reused between
+// calls.
+func (q *Queries) ScanColumns(ctx context.Context, table string, columns []string, from, to time.Time, fn func(t time.Time, row []float64) error) error {
+	spec, ok := LookupTable(table)
+	if !ok {
+		return fmt.Errorf("unknown table %q", table)
+	}
+	for _, c := range columns {
+		if _, ok := spec.Column(c); !ok {
+			return fmt.Errorf("unknown column %q in table %q", c, table)
+		}
+	}
+
+	source := spec.Name
+	if spec.Name == "cell_data" {
+		source = "cell_data_full" // The view applies the codec scales
+	}
+	rows, err := q.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT timestamp, %s
+		FROM %s
+		WHERE timestamp >= $1 AND timestamp <= $2
+		ORDER BY timestamp ASC, seq ASC
+	`, strings.Join(columns, ", "), source), from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var t time.Time
+	row := make([]float64, len(columns))
+	dest := make([]interface{}, len(columns)+1)
+	dest[0] = &t
+	for i := range row {
+		dest[i+1] = &row[i]
+	}
+	var meta types.RecordMeta
+	for rows.Next() {
+		meta.Missing = meta.Missing[:0]
+		if err := scanRow(rows, source, &meta, dest...); err != nil {
+			return err
+		}
+		if len(meta.Missing) > 0 {
+			continue
+		}
+		if err := fn(t, row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FetchLatestValues returns the last stored value at or before at of each given
+// column of a telemetry table, looking back no further than since, with storage
+// codecs undone. Every column is read by its own LATERAL subquery, which walks
+// the timestamp index backwards and stops at the first row holding a value, so
+// a column missing from the latest rows still gets its last real sample. Columns
+// without a value in the window are left out of the result.
+func (q *Queries) FetchLatestValues(ctx context.Context, table string, columns []string, since, at time.Time) (map[string]types.TimedValue, error) {
+	spec, ok := LookupTable(table)
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", table)
+	}
+	for _, c := range columns {
+		if _, ok := spec.Column(c); !ok {
+			return nil, fmt.Errorf("unknown column %q in table %q", c, table)
+		}
+	}
+	if len(columns) == 0 {
+		return map[string]types.TimedValue{}, nil
+	}
+
+	source := spec.Name
+	if spec.Name == "cell_data" {
+		source = "cell_data_full" // The view applies the codec scales
+	}
+	var sel, joins strings.Builder
+	for i, c := range columns {
+		if i > 0 {
+			sel.WriteString(", ")
+		}
+		// The value keeps the column name so scanRow applies its codec
+		fmt.Fprintf(&sel, "v%d.timestamp AS ts%d, v%d.%s", i, i, i, c)
+		fmt.Fprintf(&joins, `
+		LEFT JOIN LATERAL (
+			SELECT timestamp, %s FROM %s
+			WHERE timestamp >= p.since AND timestamp <= p.until AND %s IS NOT NULL
+			ORDER BY timestamp DESC, seq DESC NULLS LAST
+			LIMIT 1
+		) v%d ON true`, c, source, c, i)
+	}
+	rows, err := q.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM (SELECT $1::timestamptz AS since, $2::timestamptz AS until) p%s
+	`, sel.String(), joins.String()), since, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := make([]sql.NullTime, len(columns))
+	values := make([]float64, len(columns))
+	dest := make([]interface{}, 0, 2*len(columns))
+	for i := range columns {
+		dest = append(dest, &times[i], &values[i])
+	}
+	out := make(map[string]types.TimedValue, len(columns))
+	for rows.Next() {
+		if err := scanRow(rows, source, nil, dest...); err != nil {
+			return nil, err
+		}
+		for i, c := range columns {
+			if times[i].Valid {
+				out[c] = types.TimedValue{Timestamp: times[i].Time, Value: values[i]}
+			}
+		}
+	}
+	return out, rows.Err()
+}
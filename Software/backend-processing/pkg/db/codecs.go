@@ -0,0 +1,268 @@
+// codecs.go
+//
+// Per-column storage codecs. Most sensors deliver 12–16 bit values, so storing
+// them as DOUBLE PRECISION wastes space. A codec selects a narrower SQL type for
+// matching columns: REAL (float4) or a fixed-point SMALLINT/INTEGER with a scale,
+// where the stored integer is round(value / scale). The schema generator uses the
+// codecs for column types, the insert layer encodes values and the fetchers decode
+// them back into engineering units.
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"telem-system/pkg/types"
+)
+
+// ColumnCodec selects the storage type for all columns matching Pattern.
+// Pattern is matched against "table.column" using path.Match syntax, e.g.
+// "cell_data.cell*" or "*.pdm_batt_voltage". The first matching codec wins.
+type ColumnCodec struct {
+	Pattern string
+	Type    string  // "double", "real"/"float4", "smallint"/"int2" or "integer"/"int4"
+	Scale   float64 // Engineering units per integer step (integer types only)
+}
+
+// columnPlan describes how a single scaled column is encoded.
+type columnPlan struct {
+	index int // Position in the insert argument list (0 is the timestamp)
+	scale float64
+	min   float64
+	max   float64
+}
+
+var (
+	columnCodecs []ColumnCodec
+	// Scaled integer columns per table, keyed by table name
+	encodePlans = map[string][]columnPlan{}
+	// Scale per column for decoding, keyed by table name and column name
+	decodeScales = map[string]map[string]float64{}
+)
+
+// normaliseSQLType maps the accepted type spellings to their canonical SQL names.
+func normaliseSQLType(t string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(t)) {
+	case "", "double", "double precision", "float8":
+		return "DOUBLE PRECISION", true
+	case "real", "float4", "float32":
+		return "REAL", true
+	case "smallint", "int2":
+		return "SMALLINT", true
+	case "integer", "int", "int4":
+		return "INTEGER", true
+	}
+	return "", false
+}
+
+// SetColumnCodecs validates and installs the storage codecs. It must be called
+// before the schema is generated and before any data is inserted or fetched.
+func SetColumnCodecs(codecs []ColumnCodec) error {
+	installed := make([]ColumnCodec, 0, len(codecs))
+	for _, c := range codecs {
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid column pattern %q: %w", c.Pattern, err)
+		}
+		sqlType, ok := normaliseSQLType(c.Type)
+		if !ok {
+			return fmt.Errorf("unsupported column type %q for %q", c.Type, c.Pattern)
+		}
+		if sqlType == "SMALLINT" || sqlType == "INTEGER" {
+			if c.Scale < 0 {
+				return fmt.Errorf("negative scale for %q", c.Pattern)
+			}
+			if c.Scale == 0 {
+				c.Scale = 1
+			}
+		} else if c.Scale != 0 {
+			return fmt.Errorf("scale is only valid for integer types (%q)", c.Pattern)
+		}
+		c.Type = sqlType
+		installed = append(installed, c)
+	}
+
+	plans := map[string][]columnPlan{}
+	scales := map[string]map[string]float64{}
+	for _, t := range TelemetryTables {
+		for i, col := range t.Columns {
+			c, ok := matchCodec(installed, t.Name, col.Name)
+			if !ok || (c.Type != "SMALLINT" && c.Type != "INTEGER") {
+				continue
+			}
+			if c.Scale == 1 && col.Kind == KindInt {
+				continue // Already stored as an integer
+			}
+			p := columnPlan{index: i + 1, scale: c.Scale, min: math.MinInt32, max: math.MaxInt32}
+			if c.Type == "SMALLINT" {
+				p.min, p.max = math.MinInt16, math.MaxInt16
+			}
+			plans[t.Name] = append(plans[t.Name], p)
+			if scales[t.Name] == nil {
+				scales[t.Name] = map[string]float64{}
+			}
+			scales[t.Name][col.Name] = c.Scale
+		}
+	}
+
+	columnCodecs = installed
+	encodePlans = plans
+	decodeScales = scales
+	return nil
+}
+
+// matchCodec returns the first codec matching table.column.
+func matchCodec(codecs []ColumnCodec, table, column string) (ColumnCodec, bool) {
+	name := table + "." + column
+	for _, c := range codecs {
+		if ok, _ := path.Match(c.Pattern, name); ok {
+			return c, true
+		}
+	}
+	return ColumnCodec{}, false
+}
+
+// columnSQLType returns the SQL type used to store a telemetry column.
+func columnSQLType(table string, col ColumnSpec) string {
+	if c, ok := matchCodec(columnCodecs, table, col.Name); ok {
+		return c.Type
+	}
+	if col.Kind == KindInt {
+		return "INTEGER"
+	}
+	return "DOUBLE PRECISION"
+}
+
+// columnScale returns the fixed-point scale of a column, or 0 if it is stored unscaled.
+func columnScale(table, column string) float64 {
+	return decodeScales[table][column]
+}
+
+// encodeRow applies the storage codecs of table to a positional insert argument
+// list (timestamp first, then the columns in TableSpec order). Columns listed as
+// missing in meta are stored as NULL.
+func encodeRow(table string, meta types.RecordMeta, args ...interface{}) []interface{} {
+	plans := encodePlans[table]
+	out := make([]interface{}, len(args), len(args)+1)
+	copy(out, args)
+	for _, p := range plans {
+		if p.index >= len(out) {
+			continue
+		}
+		v, ok := toFloat(out[p.index])
+		if !ok {
+			continue
+		}
+		// Clamp so a single out-of-range sample cannot fail the whole batch
+		out[p.index] = int64(math.Max(p.min, math.Min(p.max, math.Round(v/p.scale))))
+	}
+	if len(meta.Missing) > 0 {
+		if spec, ok := LookupTable(table); ok {
+			for i, c := range spec.Columns {
+				if i+1 < len(out) && meta.IsMissing(c.Name) {
+					out[i+1] = nil
+				}
+			}
+		}
+	}
+	return append(out, seqArg(meta.Seq))
+}
+
+// seqArg returns the seq column value of a record; records that were not
+// sequenced (seq 0) store NULL.
+func seqArg(seq int64) interface{} {
+	if seq == 0 {
+		return nil
+	}
+	return seq
+}
+
+// scanRow scans the current row of a fetch from table and converts fixed-point
+// columns back into engineering units. Numeric columns are read through sql.Null
+// types: a NULL leaves the destination at zero and, if meta is not nil, adds the
+// column to meta.Missing. A trailing seq column beyond dest is read into meta.Seq.
+func scanRow(rows *sql.Rows, table string, meta *types.RecordMeta, dest ...interface{}) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	var seq sql.NullInt64
+	nullable := make([]interface{}, len(dest), len(dest)+1)
+	if len(cols) == len(dest)+1 && cols[len(dest)] == "seq" {
+		nullable = append(nullable, &seq)
+	}
+	for i, d := range dest {
+		switch d.(type) {
+		case *float64, *float32:
+			nullable[i] = &sql.NullFloat64{}
+		case *int:
+			nullable[i] = &sql.NullInt64{}
+		default:
+			nullable[i] = d
+		}
+	}
+	if err := rows.Scan(nullable...); err != nil {
+		return err
+	}
+	if meta != nil {
+		meta.Seq = seq.Int64
+	}
+
+	scales := decodeScales[table]
+	for i, d := range dest {
+		valid := true
+		switch d := d.(type) {
+		case *float64:
+			n := nullable[i].(*sql.NullFloat64)
+			*d, valid = n.Float64, n.Valid
+		case *float32:
+			n := nullable[i].(*sql.NullFloat64)
+			*d, valid = float32(n.Float64), n.Valid
+		case *int:
+			n := nullable[i].(*sql.NullInt64)
+			*d, valid = int(n.Int64), n.Valid
+		}
+		if i >= len(cols) {
+			continue
+		}
+		if !valid {
+			if meta != nil {
+				meta.Missing = append(meta.Missing, cols[i])
+			}
+			continue
+		}
+		scale, ok := scales[cols[i]]
+		if !ok {
+			continue
+		}
+		switch d := d.(type) {
+		case *float64:
+			*d *= scale
+		case *float32:
+			*d = float32(float64(*d) * scale)
+		case *int:
+			*d = int(math.Round(float64(*d) * scale))
+		}
+	}
+	return nil
+}
+
+// toFloat converts a numeric insert argument to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -45,32 +45,41 @@ func Connect(connStr string) (*sql.DB, error) {
 	return db, nil
 }
 
-// FetchTCUDataPaginated returns TCU data with pagination.
-func (q *Queries) FetchTCUDataPaginated(ctx context.Context, limit, offset int) ([]types.TCU_Data, error) {
+// Checkpoint forces a PostgreSQL checkpoint, writing all dirty buffers to disk.
+// It needs superuser or the pg_checkpoint role.
+func Checkpoint(ctx context.Context) error {
+	_, err := DB.ExecContext(ctx, "CHECKPOINT")
+	return err
+}
+
+// ScanTCUDataPaginated calls fn for each row of TCU data with pagination.
+func (q *Queries) ScanTCUDataPaginated(ctx context.Context, limit, offset int, fn func(types.TCU_Data) error) error {
 	query := `
-		SELECT timestamp, apps1, apps2, bse, status
+		SELECT timestamp, apps1, apps2, bse, status, seq
 		FROM tcu1
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.TCU_Data
 	for rows.Next() {
 		var rec types.TCU_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.APPS1, &rec.APPS2, &rec.BSE, &rec.Status); err != nil {
-			return nil, err
+		if err := scanRow(rows, "tcu1", &rec.RecordMeta, &rec.Timestamp, &rec.APPS1, &rec.APPS2, &rec.BSE, &rec.Status); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchCellDataPaginated returns paginated cell data.
-func (q *Queries) FetchCellDataPaginated(ctx context.Context, limit, offset int) ([]types.Cell_Data, error) {
+// ScanCellDataPaginated calls fn for each row of cell data. Rows are read through
+// the cell_data_full view so delta-compressed samples come back as full rows.
+func (q *Queries) ScanCellDataPaginated(ctx context.Context, limit, offset int, fn func(types.Cell_Data) error) error {
 	query := `
 		SELECT 
 			timestamp, 
@@ -89,20 +98,19 @@ func (q *Queries) FetchCellDataPaginated(ctx context.Context, limit, offset int)
 			cell97, cell98, cell99, cell100, cell101, cell102, cell103, cell104,
 			cell105, cell106, cell107, cell108, cell109, cell110, cell111, cell112,
 			cell113, cell114, cell115, cell116, cell117, cell118, cell119, cell120,
-			cell121, cell122, cell123, cell124, cell125, cell126, cell127, cell128
-		FROM cell_data
-		ORDER BY timestamp ASC
+			cell121, cell122, cell123, cell124, cell125, cell126, cell127, cell128, seq
+		FROM cell_data_full
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.Cell_Data
 	for rows.Next() {
 		var rec types.Cell_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "cell_data_full", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Cell1, &rec.Cell2, &rec.Cell3, &rec.Cell4, &rec.Cell5, &rec.Cell6, &rec.Cell7, &rec.Cell8,
 			&rec.Cell9, &rec.Cell10, &rec.Cell11, &rec.Cell12, &rec.Cell13, &rec.Cell14, &rec.Cell15, &rec.Cell16,
@@ -121,30 +129,31 @@ func (q *Queries) FetchCellDataPaginated(ctx context.Context, limit, offset int)
 			&rec.Cell113, &rec.Cell114, &rec.Cell115, &rec.Cell116, &rec.Cell117, &rec.Cell118, &rec.Cell119, &rec.Cell120,
 			&rec.Cell121, &rec.Cell122, &rec.Cell123, &rec.Cell124, &rec.Cell125, &rec.Cell126, &rec.Cell127, &rec.Cell128,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // Rear Analog Data
-func (q *Queries) FetchRearAnalogDataPaginated(ctx context.Context, limit, offset int) ([]types.RearAnalog_Data, error) {
+func (q *Queries) ScanRearAnalogDataPaginated(ctx context.Context, limit, offset int, fn func(types.RearAnalog_Data) error) error {
 	query := `
-		SELECT timestamp, analog1, analog2, analog3, analog4, analog5, analog6, analog7, analog8
+		SELECT timestamp, analog1, analog2, analog3, analog4, analog5, analog6, analog7, analog8, seq
 		FROM rear_analog
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.RearAnalog_Data
 	for rows.Next() {
 		var rec types.RearAnalog_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "rear_analog", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Analog1,
 			&rec.Analog2,
@@ -155,30 +164,31 @@ func (q *Queries) FetchRearAnalogDataPaginated(ctx context.Context, limit, offse
 			&rec.Analog7,
 			&rec.Analog8,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // Rear Aero Data
-func (q *Queries) FetchRearAeroDataPaginated(ctx context.Context, limit, offset int) ([]types.RearAero_Data, error) {
+func (q *Queries) ScanRearAeroDataPaginated(ctx context.Context, limit, offset int, fn func(types.RearAero_Data) error) error {
 	query := `
-		SELECT timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3
+		SELECT timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3, seq
 		FROM rear_aero
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.RearAero_Data
 	for rows.Next() {
 		var rec types.RearAero_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "rear_aero", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Pressure1,
 			&rec.Pressure2,
@@ -187,30 +197,31 @@ func (q *Queries) FetchRearAeroDataPaginated(ctx context.Context, limit, offset
 			&rec.Temperature2,
 			&rec.Temperature3,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // Front Aero Data
-func (q *Queries) FetchFrontAeroDataPaginated(ctx context.Context, limit, offset int) ([]types.FrontAero_Data, error) {
+func (q *Queries) ScanFrontAeroDataPaginated(ctx context.Context, limit, offset int, fn func(types.FrontAero_Data) error) error {
 	query := `
-		SELECT timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3
+		SELECT timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3, seq
 		FROM front_aero
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.FrontAero_Data
 	for rows.Next() {
 		var rec types.FrontAero_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "front_aero", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Pressure1,
 			&rec.Pressure2,
@@ -219,30 +230,31 @@ func (q *Queries) FetchFrontAeroDataPaginated(ctx context.Context, limit, offset
 			&rec.Temperature2,
 			&rec.Temperature3,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // GPS Best Position Data
-func (q *Queries) FetchGPSBestPosDataPaginated(ctx context.Context, limit, offset int) ([]types.GPSBestPos_Data, error) {
+func (q *Queries) ScanGPSBestPosDataPaginated(ctx context.Context, limit, offset int, fn func(types.GPSBestPos_Data) error) error {
 	query := `
-		SELECT timestamp, latitude, longitude, altitude, std_latitude, std_longitude, std_altitude, gps_status
+		SELECT timestamp, latitude, longitude, altitude, std_latitude, std_longitude, std_altitude, gps_status, seq
 		FROM gps_best_pos
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.GPSBestPos_Data
 	for rows.Next() {
 		var rec types.GPSBestPos_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "gps_best_pos", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Latitude,
 			&rec.Longitude,
@@ -252,60 +264,62 @@ func (q *Queries) FetchGPSBestPosDataPaginated(ctx context.Context, limit, offse
 			&rec.StdAltitude,
 			&rec.GPSStatus,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // Rear Frequency Data
-func (q *Queries) FetchRearFrequencyDataPaginated(ctx context.Context, limit, offset int) ([]types.RearFrequency_Data, error) {
+func (q *Queries) ScanRearFrequencyDataPaginated(ctx context.Context, limit, offset int, fn func(types.RearFrequency_Data) error) error {
 	query := `
-		SELECT timestamp, freq1, freq2, freq3, freq4
+		SELECT timestamp, freq1, freq2, freq3, freq4, seq
 		FROM rear_frequency
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.RearFrequency_Data
 	for rows.Next() {
 		var rec types.RearFrequency_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "rear_frequency", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Freq1,
 			&rec.Freq2,
 			&rec.Freq3,
 			&rec.Freq4,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // Bamocar RX Data
-func (q *Queries) FetchBamocarRxDataPaginated(ctx context.Context, limit, offset int) ([]types.BamocarRxData_Data, error) {
+func (q *Queries) ScanBamocarRxDataPaginated(ctx context.Context, limit, offset int, fn func(types.BamocarRxData_Data) error) error {
 	query := `
-		SELECT timestamp, regid, byte1, byte2, byte3, byte4, byte5
+		SELECT timestamp, regid, byte1, byte2, byte3, byte4, byte5, seq
 		FROM bamocar_rx_data
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.BamocarRxData_Data
 	for rows.Next() {
 		var rec types.BamocarRxData_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "bamocar_rx_data", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.REGID,
 			&rec.Byte1,
@@ -314,113 +328,117 @@ func (q *Queries) FetchBamocarRxDataPaginated(ctx context.Context, limit, offset
 			&rec.Byte4,
 			&rec.Byte5,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // ACULV FD_2 Data
-func (q *Queries) FetchACULVFD2DataPaginated(ctx context.Context, limit, offset int) ([]types.ACULV_FD_2_Data, error) {
+func (q *Queries) ScanACULVFD2DataPaginated(ctx context.Context, limit, offset int, fn func(types.ACULV_FD_2_Data) error) error {
 	query := `
-		SELECT timestamp, fan_set_point, rpm
+		SELECT timestamp, fan_set_point, rpm, seq
 		FROM aculv_fd_2
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.ACULV_FD_2_Data
 	for rows.Next() {
 		var rec types.ACULV_FD_2_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "aculv_fd_2", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.FanSetPoint,
 			&rec.RPM,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // ACULV1 Data
-func (q *Queries) FetchACULV1DataPaginated(ctx context.Context, limit, offset int) ([]types.ACULV1_Data, error) {
+func (q *Queries) ScanACULV1DataPaginated(ctx context.Context, limit, offset int, fn func(types.ACULV1_Data) error) error {
 	query := `
-		SELECT timestamp, charge_status1, charge_status2
+		SELECT timestamp, charge_status1, charge_status2, seq
 		FROM aculv1
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.ACULV1_Data
 	for rows.Next() {
 		var rec types.ACULV1_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "aculv1", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.ChargeStatus1,
 			&rec.ChargeStatus2,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // ACULV2 Data
-func (q *Queries) FetchACULV2DataPaginated(ctx context.Context, limit, offset int) ([]types.ACULV2_Data, error) {
+func (q *Queries) ScanACULV2DataPaginated(ctx context.Context, limit, offset int, fn func(types.ACULV2_Data) error) error {
 	query := `
-		SELECT timestamp, charge_request
+		SELECT timestamp, charge_request, seq
 		FROM aculv2
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.ACULV2_Data
 	for rows.Next() {
 		var rec types.ACULV2_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "aculv2", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.ChargeRequest,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 // PDM1 Data
-func (q *Queries) FetchPDM1DataPaginated(ctx context.Context, limit, offset int) ([]types.PDM1_Data, error) {
+func (q *Queries) ScanPDM1DataPaginated(ctx context.Context, limit, offset int, fn func(types.PDM1_Data) error) error {
 	query := `
-		SELECT timestamp, compound_id, pdm_int_temperature, pdm_batt_voltage, global_error_flag, total_current, internal_rail_voltage, reset_source
+		SELECT timestamp, compound_id, pdm_int_temperature, pdm_batt_voltage, global_error_flag, total_current, internal_rail_voltage, reset_source, seq
 		FROM pdm1
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.PDM1_Data
 	for rows.Next() {
 		var rec types.PDM1_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "pdm1", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.CompoundID,
 			&rec.PDMIntTemperature,
@@ -430,29 +448,30 @@ func (q *Queries) FetchPDM1DataPaginated(ctx context.Context, limit, offset int)
 			&rec.InternalRailVoltage,
 			&rec.ResetSource,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-func (q *Queries) FetchRearStrainGauges2DataPaginated(ctx context.Context, limit, offset int) ([]types.RearStrainGauges2_Data, error) {
+func (q *Queries) ScanRearStrainGauges2DataPaginated(ctx context.Context, limit, offset int, fn func(types.RearStrainGauges2_Data) error) error {
 	query := `
-		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
+		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
 		FROM rear_strain_gauges_2
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.RearStrainGauges2_Data
 	for rows.Next() {
 		var rec types.RearStrainGauges2_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "rear_strain_gauges_2", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Gauge1,
 			&rec.Gauge2,
@@ -461,29 +480,30 @@ func (q *Queries) FetchRearStrainGauges2DataPaginated(ctx context.Context, limit
 			&rec.Gauge5,
 			&rec.Gauge6,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-func (q *Queries) FetchRearStrainGauges1DataPaginated(ctx context.Context, limit, offset int) ([]types.RearStrainGauges1_Data, error) {
+func (q *Queries) ScanRearStrainGauges1DataPaginated(ctx context.Context, limit, offset int, fn func(types.RearStrainGauges1_Data) error) error {
 	query := `
-		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
+		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
 		FROM rear_strain_gauges_1
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.RearStrainGauges1_Data
 	for rows.Next() {
 		var rec types.RearStrainGauges1_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "rear_strain_gauges_1", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.Gauge1,
 			&rec.Gauge2,
@@ -492,228 +512,238 @@ func (q *Queries) FetchRearStrainGauges1DataPaginated(ctx context.Context, limit
 			&rec.Gauge5,
 			&rec.Gauge6,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-func (q *Queries) FetchBamocarDataPaginated(ctx context.Context, limit, offset int) ([]types.TCU2_data, error) {
+func (q *Queries) ScanBamocarDataPaginated(ctx context.Context, limit, offset int, fn func(types.TCU2_data) error) error {
 	query := `
-		SELECT timestamp, bamocar_frg, bamocar_rfe, brake_light
+		SELECT timestamp, bamocar_frg, bamocar_rfe, brake_light, seq
 		FROM tcu2
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var data []types.TCU2_data
 	for rows.Next() {
 		var rec types.TCU2_data
-		if err := rows.Scan(&rec.Timestamp, &rec.BamocarFRG, &rec.BamocarRFE, &rec.BrakeLight); err != nil {
-			return nil, err
+		if err := scanRow(rows, "tcu2", &rec.RecordMeta, &rec.Timestamp, &rec.BamocarFRG, &rec.BamocarRFE, &rec.BrakeLight); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchThermDataPaginated returns paginated Thermistor data.
-func (q *Queries) FetchThermDataPaginated(ctx context.Context, limit, offset int) ([]types.Therm_Data, error) {
+// ScanThermDataPaginated calls fn for each row of Thermistor data.
+func (q *Queries) ScanThermDataPaginated(ctx context.Context, limit, offset int, fn func(types.Therm_Data) error) error {
 	query := `
 		SELECT timestamp, thermistor_id, therm1, therm2, therm3, therm4, therm5, therm6, therm7, therm8, 
-		       therm9, therm10, therm11, therm12, therm13, therm14, therm15, therm16
+		       therm9, therm10, therm11, therm12, therm13, therm14, therm15, therm16, seq
 		FROM therm_data
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.Therm_Data
 	for rows.Next() {
 		var rec types.Therm_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "therm_data", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.ThermistorID, &rec.Therm1, &rec.Therm2, &rec.Therm3, &rec.Therm4,
 			&rec.Therm5, &rec.Therm6, &rec.Therm7, &rec.Therm8, &rec.Therm9, &rec.Therm10,
 			&rec.Therm11, &rec.Therm12, &rec.Therm13, &rec.Therm14, &rec.Therm15, &rec.Therm16,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchTCU2DataPaginated returns paginated TCU2 data.
-func (q *Queries) FetchTCU2DataPaginated(ctx context.Context, limit, offset int) ([]types.TCU2_data, error) {
+// ScanTCU2DataPaginated calls fn for each row of TCU2 data.
+func (q *Queries) ScanTCU2DataPaginated(ctx context.Context, limit, offset int, fn func(types.TCU2_data) error) error {
 	query := `
-		SELECT timestamp, brake_light, bamocar_rfe, bamocar_frg
+		SELECT timestamp, brake_light, bamocar_rfe, bamocar_frg, seq
 		FROM tcu2
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.TCU2_data
 	for rows.Next() {
 		var rec types.TCU2_data
-		if err := rows.Scan(&rec.Timestamp, &rec.BrakeLight, &rec.BamocarRFE, &rec.BamocarFRG); err != nil {
-			return nil, err
+		if err := scanRow(rows, "tcu2", &rec.RecordMeta, &rec.Timestamp, &rec.BrakeLight, &rec.BamocarRFE, &rec.BamocarFRG); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchBamocarTxDataPaginated returns paginated Bamocar Tx data.
-func (q *Queries) FetchBamocarTxDataPaginated(ctx context.Context, limit, offset int) ([]types.BamocarTxData_Data, error) {
+// ScanBamocarTxDataPaginated calls fn for each row of Bamocar Tx data.
+func (q *Queries) ScanBamocarTxDataPaginated(ctx context.Context, limit, offset int, fn func(types.BamocarTxData_Data) error) error {
 	query := `
-		SELECT timestamp, regid, data
+		SELECT timestamp, regid, data, seq
 		FROM bamocar_tx_data
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.BamocarTxData_Data
 	for rows.Next() {
 		var rec types.BamocarTxData_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.REGID, &rec.Data); err != nil {
-			return nil, err
+		if err := scanRow(rows, "bamocar_tx_data", &rec.RecordMeta, &rec.Timestamp, &rec.REGID, &rec.Data); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchBamoCarReTransmitDataPaginated returns paginated Bamo Car Re-transmit data.
-func (q *Queries) FetchBamoCarReTransmitDataPaginated(ctx context.Context, limit, offset int) ([]types.BamoCarReTransmit_Data, error) {
+// ScanBamoCarReTransmitDataPaginated calls fn for each row of Bamo Car Re-transmit
+// data.
+func (q *Queries) ScanBamoCarReTransmitDataPaginated(ctx context.Context, limit, offset int, fn func(types.BamoCarReTransmit_Data) error) error {
 	query := `
-		SELECT timestamp, motor_temp, controller_temp
+		SELECT timestamp, motor_temp, controller_temp, seq
 		FROM bamo_car_re_transmit
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.BamoCarReTransmit_Data
 	for rows.Next() {
 		var rec types.BamoCarReTransmit_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.MotorTemp, &rec.ControllerTemp); err != nil {
-			return nil, err
+		if err := scanRow(rows, "bamo_car_re_transmit", &rec.RecordMeta, &rec.Timestamp, &rec.MotorTemp, &rec.ControllerTemp); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchEncoderDataPaginated returns paginated Encoder data.
-func (q *Queries) FetchEncoderDataPaginated(ctx context.Context, limit, offset int) ([]types.Encoder_Data, error) {
+// ScanEncoderDataPaginated calls fn for each row of Encoder data.
+func (q *Queries) ScanEncoderDataPaginated(ctx context.Context, limit, offset int, fn func(types.Encoder_Data) error) error {
 	query := `
-		SELECT timestamp, encoder1, encoder2, encoder3, encoder4
+		SELECT timestamp, encoder1, encoder2, encoder3, encoder4, seq
 		FROM encoder_data
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.Encoder_Data
 	for rows.Next() {
 		var rec types.Encoder_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.Encoder1, &rec.Encoder2, &rec.Encoder3, &rec.Encoder4); err != nil {
-			return nil, err
+		if err := scanRow(rows, "encoder_data", &rec.RecordMeta, &rec.Timestamp, &rec.Encoder1, &rec.Encoder2, &rec.Encoder3, &rec.Encoder4); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchPackCurrentDataPaginated returns paginated Pack Current data.
-func (q *Queries) FetchPackCurrentDataPaginated(ctx context.Context, limit, offset int) ([]types.PackCurrent_Data, error) {
+// ScanPackCurrentDataPaginated calls fn for each row of Pack Current data.
+func (q *Queries) ScanPackCurrentDataPaginated(ctx context.Context, limit, offset int, fn func(types.PackCurrent_Data) error) error {
 	query := `
-		SELECT timestamp, current
+		SELECT timestamp, current, seq
 		FROM pack_current
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.PackCurrent_Data
 	for rows.Next() {
 		var rec types.PackCurrent_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.Current); err != nil {
-			return nil, err
+		if err := scanRow(rows, "pack_current", &rec.RecordMeta, &rec.Timestamp, &rec.Current); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchPackVoltageDataPaginated returns paginated Pack Voltage data.
-func (q *Queries) FetchPackVoltageDataPaginated(ctx context.Context, limit, offset int) ([]types.PackVoltage_Data, error) {
+// ScanPackVoltageDataPaginated calls fn for each row of Pack Voltage data.
+func (q *Queries) ScanPackVoltageDataPaginated(ctx context.Context, limit, offset int, fn func(types.PackVoltage_Data) error) error {
 	query := `
-		SELECT timestamp, voltage
+		SELECT timestamp, voltage, seq
 		FROM pack_voltage
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.PackVoltage_Data
 	for rows.Next() {
 		var rec types.PackVoltage_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.Voltage); err != nil {
-			return nil, err
+		if err := scanRow(rows, "pack_voltage", &rec.RecordMeta, &rec.Timestamp, &rec.Voltage); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchPDMCurrentDataPaginated returns paginated PDM Current data.
-func (q *Queries) FetchPDMCurrentDataPaginated(ctx context.Context, limit, offset int) ([]types.PDMCurrent_Data, error) {
+// ScanPDMCurrentDataPaginated calls fn for each row of PDM Current data.
+func (q *Queries) ScanPDMCurrentDataPaginated(ctx context.Context, limit, offset int, fn func(types.PDMCurrent_Data) error) error {
 	query := `
-		SELECT timestamp, accumulator_current, tcu_current, bamocar_current, pumps_current, tsal_current, daq_current, display_kvaser_current, shutdown_reset_current
+		SELECT timestamp, accumulator_current, tcu_current, bamocar_current, pumps_current, tsal_current, daq_current, display_kvaser_current, shutdown_reset_current, seq
 		FROM pdm_current
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.PDMCurrent_Data
 	for rows.Next() {
 		var rec types.PDMCurrent_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "pdm_current", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.AccumulatorCurrent,
 			&rec.TCUCurrent,
@@ -724,30 +754,31 @@ func (q *Queries) FetchPDMCurrentDataPaginated(ctx context.Context, limit, offse
 			&rec.DisplayKvaserCurrent,
 			&rec.ShutdownResetCurrent,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchPDMReTransmitDataPaginated returns paginated PDM Re-transmit data.
-func (q *Queries) FetchPDMReTransmitDataPaginated(ctx context.Context, limit, offset int) ([]types.PDMReTransmit_Data, error) {
+// ScanPDMReTransmitDataPaginated calls fn for each row of PDM Re-transmit data.
+func (q *Queries) ScanPDMReTransmitDataPaginated(ctx context.Context, limit, offset int, fn func(types.PDMReTransmit_Data) error) error {
 	query := `
-		SELECT timestamp, pdm_int_temperature, pdm_batt_voltage, global_error_flag, total_current, internal_rail_voltage, reset_source
+		SELECT timestamp, pdm_int_temperature, pdm_batt_voltage, global_error_flag, total_current, internal_rail_voltage, reset_source, seq
 		FROM pdm_re_transmit
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.PDMReTransmit_Data
 	for rows.Next() {
 		var rec types.PDMReTransmit_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "pdm_re_transmit", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.PDMIntTemperature,
 			&rec.PDMBattVoltage,
@@ -756,30 +787,31 @@ func (q *Queries) FetchPDMReTransmitDataPaginated(ctx context.Context, limit, of
 			&rec.InternalRailVoltage,
 			&rec.ResetSource,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchINSGPSDataPaginated returns paginated INS GPS data.
-func (q *Queries) FetchINSGPSDataPaginated(ctx context.Context, limit, offset int) ([]types.INS_GPS_Data, error) {
+// ScanINSGPSDataPaginated calls fn for each row of INS GPS data.
+func (q *Queries) ScanINSGPSDataPaginated(ctx context.Context, limit, offset int, fn func(types.INS_GPS_Data) error) error {
 	query := `
-		SELECT timestamp, gnss_week, gnss_seconds, gnss_lat, gnss_long, gnss_height
+		SELECT timestamp, gnss_week, gnss_seconds, gnss_lat, gnss_long, gnss_height, seq
 		FROM ins_gps
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.INS_GPS_Data
 	for rows.Next() {
 		var rec types.INS_GPS_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "ins_gps", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.GNSSWeek,
 			&rec.GNSSSeconds,
@@ -787,30 +819,31 @@ func (q *Queries) FetchINSGPSDataPaginated(ctx context.Context, limit, offset in
 			&rec.GNSSLong,
 			&rec.GNSSHeight,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchINSIMUDataPaginated returns paginated INS IMU data.
-func (q *Queries) FetchINSIMUDataPaginated(ctx context.Context, limit, offset int) ([]types.INS_IMU_Data, error) {
+// ScanINSIMUDataPaginated calls fn for each row of INS IMU data.
+func (q *Queries) ScanINSIMUDataPaginated(ctx context.Context, limit, offset int, fn func(types.INS_IMU_Data) error) error {
 	query := `
-		SELECT timestamp, north_vel, east_vel, up_vel, roll, pitch, azimuth, status
+		SELECT timestamp, north_vel, east_vel, up_vel, roll, pitch, azimuth, status, seq
 		FROM ins_imu
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.INS_IMU_Data
 	for rows.Next() {
 		var rec types.INS_IMU_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "ins_imu", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.NorthVel,
 			&rec.EastVel,
@@ -820,126 +853,133 @@ func (q *Queries) FetchINSIMUDataPaginated(ctx context.Context, limit, offset in
 			&rec.Azimuth,
 			&rec.Status,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchFrontFrequencyDataPaginated returns paginated Front Frequency data.
-func (q *Queries) FetchFrontFrequencyDataPaginated(ctx context.Context, limit, offset int) ([]types.FrontFrequency_Data, error) {
+// ScanFrontFrequencyDataPaginated calls fn for each row of Front Frequency data.
+func (q *Queries) ScanFrontFrequencyDataPaginated(ctx context.Context, limit, offset int, fn func(types.FrontFrequency_Data) error) error {
 	query := `
-		SELECT timestamp, rear_right, front_right, rear_left, front_left
+		SELECT timestamp, rear_right, front_right, rear_left, front_left, seq
 		FROM front_frequency
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.FrontFrequency_Data
 	for rows.Next() {
 		var rec types.FrontFrequency_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.RearRight, &rec.FrontRight, &rec.RearLeft, &rec.FrontLeft); err != nil {
-			return nil, err
+		if err := scanRow(rows, "front_frequency", &rec.RecordMeta, &rec.Timestamp, &rec.RearRight, &rec.FrontRight, &rec.RearLeft, &rec.FrontLeft); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchFrontStrainGauges1DataPaginated returns paginated Front Strain Gauges 1 data.
-func (q *Queries) FetchFrontStrainGauges1DataPaginated(ctx context.Context, limit, offset int) ([]types.FrontStrainGauges1_Data, error) {
+// ScanFrontStrainGauges1DataPaginated calls fn for each row of Front Strain Gauges
+// 1 data.
+func (q *Queries) ScanFrontStrainGauges1DataPaginated(ctx context.Context, limit, offset int, fn func(types.FrontStrainGauges1_Data) error) error {
 	query := `
-		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
+		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
 		FROM front_strain_gauges_1
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.FrontStrainGauges1_Data
 	for rows.Next() {
 		var rec types.FrontStrainGauges1_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.Gauge1, &rec.Gauge2, &rec.Gauge3, &rec.Gauge4, &rec.Gauge5, &rec.Gauge6); err != nil {
-			return nil, err
+		if err := scanRow(rows, "front_strain_gauges_1", &rec.RecordMeta, &rec.Timestamp, &rec.Gauge1, &rec.Gauge2, &rec.Gauge3, &rec.Gauge4, &rec.Gauge5, &rec.Gauge6); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchFrontStrainGauges2DataPaginated returns paginated Front Strain Gauges 2 data.
-func (q *Queries) FetchFrontStrainGauges2DataPaginated(ctx context.Context, limit, offset int) ([]types.FrontStrainGauges2_Data, error) {
+// ScanFrontStrainGauges2DataPaginated calls fn for each row of Front Strain Gauges
+// 2 data.
+func (q *Queries) ScanFrontStrainGauges2DataPaginated(ctx context.Context, limit, offset int, fn func(types.FrontStrainGauges2_Data) error) error {
 	query := `
-		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
+		SELECT timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
 		FROM front_strain_gauges_2
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.FrontStrainGauges2_Data
 	for rows.Next() {
 		var rec types.FrontStrainGauges2_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.Gauge1, &rec.Gauge2, &rec.Gauge3, &rec.Gauge4, &rec.Gauge5, &rec.Gauge6); err != nil {
-			return nil, err
+		if err := scanRow(rows, "front_strain_gauges_2", &rec.RecordMeta, &rec.Timestamp, &rec.Gauge1, &rec.Gauge2, &rec.Gauge3, &rec.Gauge4, &rec.Gauge5, &rec.Gauge6); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchFrontAnalogDataPaginated returns paginated Front Analog data.
-func (q *Queries) FetchFrontAnalogDataPaginated(ctx context.Context, limit, offset int) ([]types.FrontAnalog_Data, error) {
+// ScanFrontAnalogDataPaginated calls fn for each row of Front Analog data.
+func (q *Queries) ScanFrontAnalogDataPaginated(ctx context.Context, limit, offset int, fn func(types.FrontAnalog_Data) error) error {
 	query := `
-		SELECT timestamp, left_rad, right_rad, front_right_pot, front_left_pot, rear_right_pot, rear_left_pot, steering_angle, analog8
+		SELECT timestamp, left_rad, right_rad, front_right_pot, front_left_pot, rear_right_pot, rear_left_pot, steering_angle, analog8, seq
 		FROM front_analog
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.FrontAnalog_Data
 	for rows.Next() {
 		var rec types.FrontAnalog_Data
-		if err := rows.Scan(&rec.Timestamp, &rec.LeftRad, &rec.RightRad, &rec.FrontRightPot, &rec.FrontLeftPot, &rec.RearRightPot, &rec.RearLeftPot, &rec.SteeringAngle, &rec.Analog8); err != nil {
-			return nil, err
+		if err := scanRow(rows, "front_analog", &rec.RecordMeta, &rec.Timestamp, &rec.LeftRad, &rec.RightRad, &rec.FrontRightPot, &rec.FrontLeftPot, &rec.RearRightPot, &rec.RearLeftPot, &rec.SteeringAngle, &rec.Analog8); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
-// FetchACULVFD1DataPaginated returns paginated ACULV FD 1 data.
-func (q *Queries) FetchACULVFD1DataPaginated(ctx context.Context, limit, offset int) ([]types.ACULV_FD_1_Data, error) {
+// ScanACULVFD1DataPaginated calls fn for each row of ACULV FD 1 data.
+func (q *Queries) ScanACULVFD1DataPaginated(ctx context.Context, limit, offset int, fn func(types.ACULV_FD_1_Data) error) error {
 	query := `
-		SELECT timestamp, ams_status, fld, state_of_charge, accumulator_voltage, tractive_voltage, cell_current, isolation_monitoring, isolation_monitoring1
+		SELECT timestamp, ams_status, fld, state_of_charge, accumulator_voltage, tractive_voltage, cell_current, isolation_monitoring, isolation_monitoring1, seq
 		FROM aculv_fd_1
-		ORDER BY timestamp ASC
+		ORDER BY timestamp ASC, seq ASC
 		LIMIT $1 OFFSET $2
 	`
 	rows, err := q.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var data []types.ACULV_FD_1_Data
 	for rows.Next() {
 		var rec types.ACULV_FD_1_Data
-		if err := rows.Scan(
+		if err := scanRow(rows, "aculv_fd_1", &rec.RecordMeta,
 			&rec.Timestamp,
 			&rec.AMSStatus,
 			&rec.FLD,
@@ -950,11 +990,13 @@ func (q *Queries) FetchACULVFD1DataPaginated(ctx context.Context, limit, offset
 			&rec.IsolationMonitoring,
 			&rec.IsolationMonitoring1,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
 		}
-		data = append(data, rec)
 	}
-	return data, nil
+	return rows.Err()
 }
 
 //
@@ -967,15 +1009,8 @@ func InsertCellDataBatch(ctx context.Context, batch []types.Cell_Data) error {
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "cell_data", `
 		INSERT INTO cell_data (
 			timestamp,
 			cell1, cell2, cell3, cell4, cell5, cell6, cell7, cell8,
@@ -993,7 +1028,7 @@ func InsertCellDataBatch(ctx context.Context, batch []types.Cell_Data) error {
 			cell97, cell98, cell99, cell100, cell101, cell102, cell103, cell104,
 			cell105, cell106, cell107, cell108, cell109, cell110, cell111, cell112,
 			cell113, cell114, cell115, cell116, cell117, cell118, cell119, cell120,
-			cell121, cell122, cell123, cell124, cell125, cell126, cell127, cell128
+			cell121, cell122, cell123, cell124, cell125, cell126, cell127, cell128, seq
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
 			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
@@ -1007,13 +1042,13 @@ func InsertCellDataBatch(ctx context.Context, batch []types.Cell_Data) error {
 			$91, $92, $93, $94, $95, $96, $97, $98, $99, $100,
 			$101, $102, $103, $104, $105, $106, $107, $108, $109, $110,
 			$111, $112, $113, $114, $115, $116, $117, $118, $119, $120,
-			$121, $122, $123, $124, $125, $126, $127, $128, $129
+			$121, $122, $123, $124, $125, $126, $127, $128, $129, $130
 		)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
@@ -1036,14 +1071,14 @@ func InsertCellDataBatch(ctx context.Context, batch []types.Cell_Data) error {
 			data.Cell113, data.Cell114, data.Cell115, data.Cell116, data.Cell117, data.Cell118, data.Cell119, data.Cell120,
 			data.Cell121, data.Cell122, data.Cell123, data.Cell124, data.Cell125, data.Cell126, data.Cell127, data.Cell128,
 		}
-		_, err := stmt.ExecContext(ctx, args...)
+		err := b.add(ctx, encodeRow("cell_data", data.RecordMeta, args...))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertThermDataBatch inserts multiple thermistor data records in a single transaction
@@ -1052,40 +1087,33 @@ func InsertThermDataBatch(ctx context.Context, batch []types.Therm_Data) error {
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "therm_data", `
 		INSERT INTO therm_data (
 			timestamp, thermistor_id, therm1, therm2, therm3, therm4, 
 			therm5, therm6, therm7, therm8, therm9, therm10, 
-			therm11, therm12, therm13, therm14, therm15, therm16
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+			therm11, therm12, therm13, therm14, therm15, therm16, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.ThermistorID, data.Therm1, data.Therm2, data.Therm3, data.Therm4,
-			data.Therm5, data.Therm6, data.Therm7, data.Therm8, data.Therm9, data.Therm10,
-			data.Therm11, data.Therm12, data.Therm13, data.Therm14, data.Therm15, data.Therm16,
+		err := b.add(ctx,
+			encodeRow("therm_data", data.RecordMeta, data.Timestamp, data.ThermistorID, data.Therm1, data.Therm2, data.Therm3, data.Therm4,
+				data.Therm5, data.Therm6, data.Therm7, data.Therm8, data.Therm9, data.Therm10,
+				data.Therm11, data.Therm12, data.Therm13, data.Therm14, data.Therm15, data.Therm16),
 		)
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertPackCurrentDataBatch inserts multiple pack current data records in a single transaction
@@ -1094,30 +1122,23 @@ func InsertPackCurrentDataBatch(ctx context.Context, batch []types.PackCurrent_D
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO pack_current (timestamp, current) VALUES ($1, $2)`)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "pack_current", `INSERT INTO pack_current (timestamp, current, seq) VALUES ($1, $2, $3)`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.Current)
+		err := b.add(ctx, encodeRow("pack_current", data.RecordMeta, data.Timestamp, data.Current))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertPackVoltageDataBatch inserts multiple pack voltage data records in a single transaction
@@ -1126,30 +1147,23 @@ func InsertPackVoltageDataBatch(ctx context.Context, batch []types.PackVoltage_D
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO pack_voltage (timestamp, voltage) VALUES ($1, $2)`)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "pack_voltage", `INSERT INTO pack_voltage (timestamp, voltage, seq) VALUES ($1, $2, $3)`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.Voltage)
+		err := b.add(ctx, encodeRow("pack_voltage", data.RecordMeta, data.Timestamp, data.Voltage))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertTCU2DataBatch inserts multiple TCU2 data records in a single transaction
@@ -1158,33 +1172,26 @@ func InsertTCU2DataBatch(ctx context.Context, batch []types.TCU2_data) error {
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO tcu2 (timestamp, brake_light, bamocar_rfe, bamocar_frg) 
-		VALUES ($1, $2, $3, $4)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "tcu2", `
+		INSERT INTO tcu2 (timestamp, brake_light, bamocar_rfe, bamocar_frg, seq) 
+		VALUES ($1, $2, $3, $4, $5)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.BrakeLight, data.BamocarRFE, data.BamocarFRG)
+		err := b.add(ctx, encodeRow("tcu2", data.RecordMeta, data.Timestamp, data.BrakeLight, data.BamocarRFE, data.BamocarFRG))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertTCUDataBatch inserts multiple TCU data records in a single transaction
@@ -1193,33 +1200,26 @@ func InsertTCUDataBatch(ctx context.Context, batch []types.TCU_Data) error {
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO tcu1 (timestamp, apps1, apps2, bse, status) 
-		VALUES ($1, $2, $3, $4, $5)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "tcu1", `
+		INSERT INTO tcu1 (timestamp, apps1, apps2, bse, status, seq) 
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.APPS1, data.APPS2, data.BSE, data.Status)
+		err := b.add(ctx, encodeRow("tcu1", data.RecordMeta, data.Timestamp, data.APPS1, data.APPS2, data.BSE, data.Status))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertFrontAnalogDataBatch inserts multiple front analog data records in a single transaction
@@ -1228,37 +1228,30 @@ func InsertFrontAnalogDataBatch(ctx context.Context, batch []types.FrontAnalog_D
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "front_analog", `
 		INSERT INTO front_analog (
 			timestamp, left_rad, right_rad, front_right_pot, front_left_pot, 
-			rear_right_pot, rear_left_pot, steering_angle, analog8
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			rear_right_pot, rear_left_pot, steering_angle, analog8, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.LeftRad, data.RightRad, data.FrontRightPot,
-			data.FrontLeftPot, data.RearRightPot, data.RearLeftPot, data.SteeringAngle, data.Analog8)
+		err := b.add(ctx,
+			encodeRow("front_analog", data.RecordMeta, data.Timestamp, data.LeftRad, data.RightRad, data.FrontRightPot,
+				data.FrontLeftPot, data.RearRightPot, data.RearLeftPot, data.SteeringAngle, data.Analog8))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertRearStrainGauges1DataBatch inserts multiple rear strain gauges 1 data records in a single transaction
@@ -1267,35 +1260,28 @@ func InsertRearStrainGauges1DataBatch(ctx context.Context, batch []types.RearStr
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "rear_strain_gauges_1", `
 		INSERT INTO rear_strain_gauges_1 (
-			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6)
+		err := b.add(ctx,
+			encodeRow("rear_strain_gauges_1", data.RecordMeta, data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertRearStrainGauges2DataBatch inserts multiple rear strain gauges 2 data records in a single transaction
@@ -1304,35 +1290,28 @@ func InsertRearStrainGauges2DataBatch(ctx context.Context, batch []types.RearStr
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "rear_strain_gauges_2", `
 		INSERT INTO rear_strain_gauges_2 (
-			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6)
+		err := b.add(ctx,
+			encodeRow("rear_strain_gauges_2", data.RecordMeta, data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertFrontStrainGauges1DataBatch inserts multiple front strain gauges 1 data records in a single transaction
@@ -1341,35 +1320,28 @@ func InsertFrontStrainGauges1DataBatch(ctx context.Context, batch []types.FrontS
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "front_strain_gauges_1", `
 		INSERT INTO front_strain_gauges_1 (
-			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6)
+		err := b.add(ctx,
+			encodeRow("front_strain_gauges_1", data.RecordMeta, data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertFrontStrainGauges2DataBatch inserts multiple front strain gauges 2 data records in a single transaction
@@ -1378,35 +1350,28 @@ func InsertFrontStrainGauges2DataBatch(ctx context.Context, batch []types.FrontS
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "front_strain_gauges_2", `
 		INSERT INTO front_strain_gauges_2 (
-			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			timestamp, gauge1, gauge2, gauge3, gauge4, gauge5, gauge6, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6)
+		err := b.add(ctx,
+			encodeRow("front_strain_gauges_2", data.RecordMeta, data.Timestamp, data.Gauge1, data.Gauge2, data.Gauge3, data.Gauge4, data.Gauge5, data.Gauge6))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertRearAnalogDataBatch inserts multiple rear analog data records in a single transaction
@@ -1415,36 +1380,29 @@ func InsertRearAnalogDataBatch(ctx context.Context, batch []types.RearAnalog_Dat
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "rear_analog", `
 		INSERT INTO rear_analog (
-			timestamp, analog1, analog2, analog3, analog4, analog5, analog6, analog7, analog8
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			timestamp, analog1, analog2, analog3, analog4, analog5, analog6, analog7, analog8, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Analog1, data.Analog2, data.Analog3, data.Analog4,
-			data.Analog5, data.Analog6, data.Analog7, data.Analog8)
+		err := b.add(ctx,
+			encodeRow("rear_analog", data.RecordMeta, data.Timestamp, data.Analog1, data.Analog2, data.Analog3, data.Analog4,
+				data.Analog5, data.Analog6, data.Analog7, data.Analog8))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertRearAeroDataBatch inserts multiple rear aero data records in a single transaction
@@ -1453,36 +1411,29 @@ func InsertRearAeroDataBatch(ctx context.Context, batch []types.RearAero_Data) e
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "rear_aero", `
 		INSERT INTO rear_aero (
-			timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Pressure1, data.Pressure2, data.Pressure3,
-			data.Temperature1, data.Temperature2, data.Temperature3)
+		err := b.add(ctx,
+			encodeRow("rear_aero", data.RecordMeta, data.Timestamp, data.Pressure1, data.Pressure2, data.Pressure3,
+				data.Temperature1, data.Temperature2, data.Temperature3))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertFrontAeroDataBatch inserts multiple front aero data records in a single transaction
@@ -1491,36 +1442,29 @@ func InsertFrontAeroDataBatch(ctx context.Context, batch []types.FrontAero_Data)
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "front_aero", `
 		INSERT INTO front_aero (
-			timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			timestamp, pressure1, pressure2, pressure3, temperature1, temperature2, temperature3, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Pressure1, data.Pressure2, data.Pressure3,
-			data.Temperature1, data.Temperature2, data.Temperature3)
+		err := b.add(ctx,
+			encodeRow("front_aero", data.RecordMeta, data.Timestamp, data.Pressure1, data.Pressure2, data.Pressure3,
+				data.Temperature1, data.Temperature2, data.Temperature3))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertBamocarRxDataBatch inserts multiple bamocar rx data records in a single transaction
@@ -1529,35 +1473,28 @@ func InsertBamocarRxDataBatch(ctx context.Context, batch []types.BamocarRxData_D
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "bamocar_rx_data", `
 		INSERT INTO bamocar_rx_data (
-			timestamp, regid, byte1, byte2, byte3, byte4, byte5
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			timestamp, regid, byte1, byte2, byte3, byte4, byte5, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.REGID, data.Byte1, data.Byte2, data.Byte3, data.Byte4, data.Byte5)
+		err := b.add(ctx,
+			encodeRow("bamocar_rx_data", data.RecordMeta, data.Timestamp, data.REGID, data.Byte1, data.Byte2, data.Byte3, data.Byte4, data.Byte5))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertBamocarTxDataBatch inserts multiple bamocar tx data records in a single transaction
@@ -1566,33 +1503,26 @@ func InsertBamocarTxDataBatch(ctx context.Context, batch []types.BamocarTxData_D
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO bamocar_tx_data (timestamp, regid, data) 
-		VALUES ($1, $2, $3)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "bamocar_tx_data", `
+		INSERT INTO bamocar_tx_data (timestamp, regid, data, seq) 
+		VALUES ($1, $2, $3, $4)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.REGID, data.Data)
+		err := b.add(ctx, encodeRow("bamocar_tx_data", data.RecordMeta, data.Timestamp, data.REGID, data.Data))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // Individual legacy insert functions - These remain for compatibility
@@ -1610,19 +1540,19 @@ func (q *Queries) InsertThermData(ctx context.Context, data types.Therm_Data) er
 
 func (q *Queries) InsertACULV2Data(ctx context.Context, data types.ACULV2_Data) error {
 	query := `
-        INSERT INTO aculv2 (timestamp, charge_request)
-        VALUES ($1, $2)
+        INSERT INTO aculv2 (timestamp, charge_request, seq)
+        VALUES ($1, $2, $3)
     `
-	_, err := q.db.ExecContext(ctx, query, data.Timestamp, data.ChargeRequest)
+	_, err := q.db.ExecContext(ctx, query, encodeRow("aculv2", data.RecordMeta, data.Timestamp, data.ChargeRequest)...)
 	return err
 }
 
 func (q *Queries) InsertACULV_FD_2_Data(ctx context.Context, data types.ACULV_FD_2_Data) error {
 	query := `
-        INSERT INTO aculv_fd_2 (timestamp, fan_set_point, rpm)
-        VALUES ($1, $2, $3)
+        INSERT INTO aculv_fd_2 (timestamp, fan_set_point, rpm, seq)
+        VALUES ($1, $2, $3, $4)
     `
-	_, err := q.db.ExecContext(ctx, query, data.Timestamp, data.FanSetPoint, data.RPM)
+	_, err := q.db.ExecContext(ctx, query, encodeRow("aculv_fd_2", data.RecordMeta, data.Timestamp, data.FanSetPoint, data.RPM)...)
 	return err
 }
 
@@ -1630,10 +1560,8 @@ func InsertCellData(ctx context.Context, data types.Cell_Data) error {
 	return InsertCellDataBatch(ctx, []types.Cell_Data{data})
 }
 
-// Additional batch insert functions for db.go to support the new batch processors
-
-// InsertACULVFD1DataBatch inserts multiple ACULV FD 1 data records in a single transaction
-func InsertACULVFD1DataBatch(ctx context.Context, batch []types.ACULV_FD_1_Data) error {
+// InsertCellDeltaBatch inserts multiple delta-compressed cell samples in a single transaction
+func InsertCellDeltaBatch(ctx context.Context, batch []types.CellDelta_Data) error {
 	if len(batch) == 0 {
 		return nil
 	}
@@ -1647,10 +1575,8 @@ func InsertACULVFD1DataBatch(ctx context.Context, batch []types.ACULV_FD_1_Data)
 
 	// Prepare the statement once for reuse
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO aculv_fd_1 (
-			timestamp, ams_status, fld, state_of_charge, accumulator_voltage, 
-			tractive_voltage, cell_current, isolation_monitoring, isolation_monitoring1
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO cell_data_delta (timestamp, keyframe_ts, cell_indices, cell_values, seq)
+		VALUES ($1, $2, $3, $4, $5)
 	`)
 	if err != nil {
 		return err
@@ -1659,10 +1585,7 @@ func InsertACULVFD1DataBatch(ctx context.Context, batch []types.ACULV_FD_1_Data)
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.AMSStatus, data.FLD, data.StateOfCharge,
-			data.AccumulatorVoltage, data.TractiveVoltage, data.CellCurrent,
-			data.IsolationMonitoring, data.IsolationMonitoring1)
+		_, err := stmt.ExecContext(ctx, data.Timestamp, data.KeyframeTimestamp, data.CellIndices, data.CellValues, seqArg(data.Seq))
 		if err != nil {
 			return err
 		}
@@ -1672,39 +1595,67 @@ func InsertACULVFD1DataBatch(ctx context.Context, batch []types.ACULV_FD_1_Data)
 	return tx.Commit()
 }
 
-// InsertACULVFD2DataBatch inserts multiple ACULV FD 2 data records in a single transaction
-func InsertACULVFD2DataBatch(ctx context.Context, batch []types.ACULV_FD_2_Data) error {
+// Additional batch insert functions for db.go to support the new batch processors
+
+// InsertACULVFD1DataBatch inserts multiple ACULV FD 1 data records in a single transaction
+func InsertACULVFD1DataBatch(ctx context.Context, batch []types.ACULV_FD_1_Data) error {
 	if len(batch) == 0 {
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "aculv_fd_1", `
+		INSERT INTO aculv_fd_1 (
+			timestamp, ams_status, fld, state_of_charge, accumulator_voltage, 
+			tractive_voltage, cell_current, isolation_monitoring, isolation_monitoring1, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer b.close()
 
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO aculv_fd_2 (timestamp, fan_set_point, rpm)
-		VALUES ($1, $2, $3)
+	// Insert each record
+	for _, data := range batch {
+		err := b.add(ctx,
+			encodeRow("aculv_fd_1", data.RecordMeta, data.Timestamp, data.AMSStatus, data.FLD, data.StateOfCharge,
+				data.AccumulatorVoltage, data.TractiveVoltage, data.CellCurrent,
+				data.IsolationMonitoring, data.IsolationMonitoring1))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Commit the batch
+	return b.commit(ctx)
+}
+
+// InsertACULVFD2DataBatch inserts multiple ACULV FD 2 data records in a single transaction
+func InsertACULVFD2DataBatch(ctx context.Context, batch []types.ACULV_FD_2_Data) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "aculv_fd_2", `
+		INSERT INTO aculv_fd_2 (timestamp, fan_set_point, rpm, seq)
+		VALUES ($1, $2, $3, $4)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.FanSetPoint, data.RPM)
+		err := b.add(ctx, encodeRow("aculv_fd_2", data.RecordMeta, data.Timestamp, data.FanSetPoint, data.RPM))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertACULV1DataBatch inserts multiple ACULV1 data records in a single transaction
@@ -1713,33 +1664,26 @@ func InsertACULV1DataBatch(ctx context.Context, batch []types.ACULV1_Data) error
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO aculv1 (timestamp, charge_status1, charge_status2)
-		VALUES ($1, $2, $3)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "aculv1", `
+		INSERT INTO aculv1 (timestamp, charge_status1, charge_status2, seq)
+		VALUES ($1, $2, $3, $4)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.ChargeStatus1, data.ChargeStatus2)
+		err := b.add(ctx, encodeRow("aculv1", data.RecordMeta, data.Timestamp, data.ChargeStatus1, data.ChargeStatus2))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertACULV2DataBatch inserts multiple ACULV2 data records in a single transaction
@@ -1748,33 +1692,26 @@ func InsertACULV2DataBatch(ctx context.Context, batch []types.ACULV2_Data) error
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO aculv2 (timestamp, charge_request)
-		VALUES ($1, $2)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "aculv2", `
+		INSERT INTO aculv2 (timestamp, charge_request, seq)
+		VALUES ($1, $2, $3)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx, data.Timestamp, data.ChargeRequest)
+		err := b.add(ctx, encodeRow("aculv2", data.RecordMeta, data.Timestamp, data.ChargeRequest))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertGPSBestPosDataBatch inserts multiple GPS Best Pos data records in a single transaction
@@ -1783,36 +1720,29 @@ func InsertGPSBestPosDataBatch(ctx context.Context, batch []types.GPSBestPos_Dat
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "gps_best_pos", `
 		INSERT INTO gps_best_pos (
-			timestamp, latitude, longitude, altitude, std_latitude, std_longitude, std_altitude, gps_status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			timestamp, latitude, longitude, altitude, std_latitude, std_longitude, std_altitude, gps_status, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Latitude, data.Longitude, data.Altitude,
-			data.StdLatitude, data.StdLongitude, data.StdAltitude, data.GPSStatus)
+		err := b.add(ctx,
+			encodeRow("gps_best_pos", data.RecordMeta, data.Timestamp, data.Latitude, data.Longitude, data.Altitude,
+				data.StdLatitude, data.StdLongitude, data.StdAltitude, data.GPSStatus))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertINSGPSDataBatch inserts multiple INS GPS data records in a single transaction
@@ -1821,34 +1751,27 @@ func InsertINSGPSDataBatch(ctx context.Context, batch []types.INS_GPS_Data) erro
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO ins_gps (timestamp, gnss_week, gnss_seconds, gnss_lat, gnss_long, gnss_height)
-		VALUES ($1, $2, $3, $4, $5, $6)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "ins_gps", `
+		INSERT INTO ins_gps (timestamp, gnss_week, gnss_seconds, gnss_lat, gnss_long, gnss_height, seq)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.GNSSWeek, data.GNSSSeconds, data.GNSSLat, data.GNSSLong, data.GNSSHeight)
+		err := b.add(ctx,
+			encodeRow("ins_gps", data.RecordMeta, data.Timestamp, data.GNSSWeek, data.GNSSSeconds, data.GNSSLat, data.GNSSLong, data.GNSSHeight))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertINSIMUDataBatch inserts multiple INS IMU data records in a single transaction
@@ -1857,34 +1780,27 @@ func InsertINSIMUDataBatch(ctx context.Context, batch []types.INS_IMU_Data) erro
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO ins_imu (timestamp, north_vel, east_vel, up_vel, roll, pitch, azimuth, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "ins_imu", `
+		INSERT INTO ins_imu (timestamp, north_vel, east_vel, up_vel, roll, pitch, azimuth, status, seq)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.NorthVel, data.EastVel, data.UpVel, data.Roll, data.Pitch, data.Azimuth, data.Status)
+		err := b.add(ctx,
+			encodeRow("ins_imu", data.RecordMeta, data.Timestamp, data.NorthVel, data.EastVel, data.UpVel, data.Roll, data.Pitch, data.Azimuth, data.Status))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertFrontFrequencyDataBatch inserts multiple Front Frequency data records in a single transaction
@@ -1893,34 +1809,27 @@ func InsertFrontFrequencyDataBatch(ctx context.Context, batch []types.FrontFrequ
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO front_frequency (timestamp, rear_right, front_right, rear_left, front_left)
-		VALUES ($1, $2, $3, $4, $5)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "front_frequency", `
+		INSERT INTO front_frequency (timestamp, rear_right, front_right, rear_left, front_left, seq)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.RearRight, data.FrontRight, data.RearLeft, data.FrontLeft)
+		err := b.add(ctx,
+			encodeRow("front_frequency", data.RecordMeta, data.Timestamp, data.RearRight, data.FrontRight, data.RearLeft, data.FrontLeft))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertRearFrequencyDataBatch inserts multiple Rear Frequency data records in a single transaction
@@ -1929,34 +1838,27 @@ func InsertRearFrequencyDataBatch(ctx context.Context, batch []types.RearFrequen
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO rear_frequency (timestamp, freq1, freq2, freq3, freq4)
-		VALUES ($1, $2, $3, $4, $5)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "rear_frequency", `
+		INSERT INTO rear_frequency (timestamp, freq1, freq2, freq3, freq4, seq)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Freq1, data.Freq2, data.Freq3, data.Freq4)
+		err := b.add(ctx,
+			encodeRow("rear_frequency", data.RecordMeta, data.Timestamp, data.Freq1, data.Freq2, data.Freq3, data.Freq4))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertPDM1DataBatch inserts multiple PDM1 data records in a single transaction
@@ -1965,37 +1867,30 @@ func InsertPDM1DataBatch(ctx context.Context, batch []types.PDM1_Data) error {
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "pdm1", `
 		INSERT INTO pdm1 (
 			timestamp, compound_id, pdm_int_temperature, pdm_batt_voltage, 
-			global_error_flag, total_current, internal_rail_voltage, reset_source
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			global_error_flag, total_current, internal_rail_voltage, reset_source, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.CompoundID, data.PDMIntTemperature, data.PDMBattVoltage,
-			data.GlobalErrorFlag, data.TotalCurrent, data.InternalRailVoltage, data.ResetSource)
+		err := b.add(ctx,
+			encodeRow("pdm1", data.RecordMeta, data.Timestamp, data.CompoundID, data.PDMIntTemperature, data.PDMBattVoltage,
+				data.GlobalErrorFlag, data.TotalCurrent, data.InternalRailVoltage, data.ResetSource))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertEncoderDataBatch inserts multiple Encoder data records in a single transaction
@@ -2004,34 +1899,27 @@ func InsertEncoderDataBatch(ctx context.Context, batch []types.Encoder_Data) err
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO encoder_data (timestamp, encoder1, encoder2, encoder3, encoder4)
-		VALUES ($1, $2, $3, $4, $5)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "encoder_data", `
+		INSERT INTO encoder_data (timestamp, encoder1, encoder2, encoder3, encoder4, seq)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.Encoder1, data.Encoder2, data.Encoder3, data.Encoder4)
+		err := b.add(ctx,
+			encodeRow("encoder_data", data.RecordMeta, data.Timestamp, data.Encoder1, data.Encoder2, data.Encoder3, data.Encoder4))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertBamoCarReTransmitDataBatch inserts multiple Bamo Car Re Transmit data records in a single transaction
@@ -2040,34 +1928,27 @@ func InsertBamoCarReTransmitDataBatch(ctx context.Context, batch []types.BamoCar
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO bamo_car_re_transmit (timestamp, motor_temp, controller_temp)
-		VALUES ($1, $2, $3)
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "bamo_car_re_transmit", `
+		INSERT INTO bamo_car_re_transmit (timestamp, motor_temp, controller_temp, seq)
+		VALUES ($1, $2, $3, $4)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.MotorTemp, data.ControllerTemp)
+		err := b.add(ctx,
+			encodeRow("bamo_car_re_transmit", data.RecordMeta, data.Timestamp, data.MotorTemp, data.ControllerTemp))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertPDMCurrentDataBatch inserts multiple PDM Current data records in a single transaction
@@ -2076,38 +1957,31 @@ func InsertPDMCurrentDataBatch(ctx context.Context, batch []types.PDMCurrent_Dat
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "pdm_current", `
 		INSERT INTO pdm_current (
 			timestamp, accumulator_current, tcu_current, bamocar_current, pumps_current, 
-			tsal_current, daq_current, display_kvaser_current, shutdown_reset_current
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			tsal_current, daq_current, display_kvaser_current, shutdown_reset_current, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.AccumulatorCurrent, data.TCUCurrent, data.BamocarCurrent,
-			data.PumpsCurrent, data.TSALCurrent, data.DAQCurrent,
-			data.DisplayKvaserCurrent, data.ShutdownResetCurrent)
+		err := b.add(ctx,
+			encodeRow("pdm_current", data.RecordMeta, data.Timestamp, data.AccumulatorCurrent, data.TCUCurrent, data.BamocarCurrent,
+				data.PumpsCurrent, data.TSALCurrent, data.DAQCurrent,
+				data.DisplayKvaserCurrent, data.ShutdownResetCurrent))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 // InsertPDMReTransmitDataBatch inserts multiple PDM Re Transmit data records in a single transaction
@@ -2116,37 +1990,30 @@ func InsertPDMReTransmitDataBatch(ctx context.Context, batch []types.PDMReTransm
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement once for reuse
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "pdm_re_transmit", `
 		INSERT INTO pdm_re_transmit (
 			timestamp, pdm_int_temperature, pdm_batt_voltage, global_error_flag, 
-			total_current, internal_rail_voltage, reset_source
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			total_current, internal_rail_voltage, reset_source, seq
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record
 	for _, data := range batch {
-		_, err := stmt.ExecContext(ctx,
-			data.Timestamp, data.PDMIntTemperature, data.PDMBattVoltage,
-			data.GlobalErrorFlag, data.TotalCurrent, data.InternalRailVoltage, data.ResetSource)
+		err := b.add(ctx,
+			encodeRow("pdm_re_transmit", data.RecordMeta, data.Timestamp, data.PDMIntTemperature, data.PDMBattVoltage,
+				data.GlobalErrorFlag, data.TotalCurrent, data.InternalRailVoltage, data.ResetSource))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
 
 func InsertBamocarDataBatch(ctx context.Context, batch []types.BamocarTxData_Data) error {
@@ -2154,32 +2021,25 @@ func InsertBamocarDataBatch(ctx context.Context, batch []types.BamocarTxData_Dat
 		return nil
 	}
 
-	// Start a transaction
-	tx, err := DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Prepare the statement for inserting into bamocar_tx_data
-	stmt, err := tx.PrepareContext(ctx, `
+	// Start the batch: a prepared statement in a transaction, or COPY
+	b, err := beginBatch(ctx, "bamocar_tx_data", `
         INSERT INTO bamocar_tx_data (
-            timestamp, regid, data
-        ) VALUES ($1, $2, $3)
+            timestamp, regid, data, seq
+        ) VALUES ($1, $2, $3, $4)
     `)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer b.close()
 
 	// Insert each record in the batch
 	for _, record := range batch {
-		_, err := stmt.ExecContext(ctx, record.Timestamp, record.REGID, record.Data)
+		err := b.add(ctx, encodeRow("bamocar_tx_data", record.RecordMeta, record.Timestamp, record.REGID, record.Data))
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	// Commit the batch
+	return b.commit(ctx)
 }
@@ -0,0 +1,234 @@
+// dbhealth.go
+//
+// Storage health report read from the PostgreSQL statistics views: table and
+// index sizes, a dead tuple bloat estimate, autovacuum history and progress, and
+// connection utilization. The report carries warnings for the conditions that
+// have slowed queries down during events (an index growing past its table, dead
+// tuples autovacuum is not keeping up with, a saturated pool).
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Warning thresholds of the storage health report
+const (
+	deadRatioWarning   = 0.2              // Share of dead tuples in a table
+	deadTuplesWarning  = 10000            // Dead tuples below which the ratio is ignored
+	indexRatioWarning  = 1.0              // Index size / table heap size
+	indexBytesWarning  = 64 << 20         // Index size below which the ratio is ignored
+	utilizationWarning = 0.8              // Pool and server connection utilization
+	longXactWarning    = 10 * time.Minute // Open transactions hold back vacuum
+)
+
+// FetchDBHealth returns the storage health report of the database.
+func (q *Queries) FetchDBHealth(ctx context.Context) (types.DBHealth, error) {
+	h := types.DBHealth{Timestamp: time.Now()}
+	err := q.db.QueryRowContext(ctx, `
+		SELECT pg_database_size(current_database()), current_setting('autovacuum') = 'on'
+	`).Scan(&h.DatabaseBytes, &h.Autovacuum)
+	if err != nil {
+		return h, err
+	}
+	if h.Tables, err = q.fetchTableHealth(ctx); err != nil {
+		return h, err
+	}
+	if h.Indexes, err = q.fetchIndexHealth(ctx); err != nil {
+		return h, err
+	}
+	if h.Vacuums, err = q.fetchVacuumProgress(ctx); err != nil {
+		return h, err
+	}
+	if h.Connections, err = q.fetchConnectionHealth(ctx); err != nil {
+		return h, err
+	}
+	h.Warnings = healthWarnings(h)
+	return h, nil
+}
+
+func (q *Queries) fetchTableHealth(ctx context.Context) ([]types.TableHealth, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT relname, pg_total_relation_size(relid), pg_relation_size(relid), pg_indexes_size(relid),
+			n_live_tup, n_dead_tup, n_mod_since_analyze,
+			last_vacuum, last_autovacuum, last_analyze, last_autoanalyze,
+			vacuum_count, autovacuum_count, autoanalyze_count
+		FROM pg_stat_user_tables
+		WHERE schemaname = current_schema()
+		ORDER BY pg_total_relation_size(relid) DESC, relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := []types.TableHealth{}
+	for rows.Next() {
+		var t types.TableHealth
+		var lastVacuum, lastAutovacuum, lastAnalyze, lastAutoanalyze sql.NullTime
+		if err := rows.Scan(&t.Name, &t.TotalBytes, &t.HeapBytes, &t.IndexBytes,
+			&t.LiveTuples, &t.DeadTuples, &t.ModsSinceAnalyze,
+			&lastVacuum, &lastAutovacuum, &lastAnalyze, &lastAutoanalyze,
+			&t.VacuumCount, &t.AutovacuumCount, &t.AutoanalyzeCount); err != nil {
+			return nil, err
+		}
+		if n := t.LiveTuples + t.DeadTuples; n > 0 {
+			t.DeadRatio = float64(t.DeadTuples) / float64(n)
+			t.BloatBytes = int64(t.DeadRatio * float64(t.HeapBytes))
+		}
+		t.LastVacuum = nullTime(lastVacuum)
+		t.LastAutovacuum = nullTime(lastAutovacuum)
+		t.LastAnalyze = nullTime(lastAnalyze)
+		t.LastAutoanalyze = nullTime(lastAutoanalyze)
+		data = append(data, t)
+	}
+	return data, rows.Err()
+}
+
+func (q *Queries) fetchIndexHealth(ctx context.Context) ([]types.IndexHealth, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT indexrelname, relname, pg_relation_size(indexrelid), pg_relation_size(relid),
+			idx_scan, idx_tup_read
+		FROM pg_stat_user_indexes
+		WHERE schemaname = current_schema()
+		ORDER BY pg_relation_size(indexrelid) DESC, indexrelname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := []types.IndexHealth{}
+	for rows.Next() {
+		var i types.IndexHealth
+		var heapBytes int64
+		if err := rows.Scan(&i.Name, &i.Table, &i.Bytes, &heapBytes, &i.Scans, &i.TuplesRead); err != nil {
+			return nil, err
+		}
+		if heapBytes > 0 {
+			i.HeapRatio = float64(i.Bytes) / float64(heapBytes)
+		}
+		data = append(data, i)
+	}
+	return data, rows.Err()
+}
+
+func (q *Queries) fetchVacuumProgress(ctx context.Context) ([]types.VacuumProgress, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT p.pid, p.relid::regclass::text, p.phase, p.heap_blks_total, p.heap_blks_scanned, a.xact_start
+		FROM pg_stat_progress_vacuum p
+		LEFT JOIN pg_stat_activity a ON a.pid = p.pid
+		WHERE p.datname = current_database()
+		ORDER BY p.pid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := []types.VacuumProgress{}
+	for rows.Next() {
+		var v types.VacuumProgress
+		var total, scanned int64
+		var started sql.NullTime
+		if err := rows.Scan(&v.PID, &v.Table, &v.Phase, &total, &scanned, &started); err != nil {
+			return nil, err
+		}
+		if total > 0 {
+			v.Progress = float64(scanned) / float64(total)
+		}
+		v.StartedAt = nullTime(started)
+		data = append(data, v)
+	}
+	return data, rows.Err()
+}
+
+func (q *Queries) fetchConnectionHealth(ctx context.Context) (types.ConnectionHealth, error) {
+	stats := q.db.Stats()
+	c := types.ConnectionHealth{
+		PoolMaxOpen:   stats.MaxOpenConnections,
+		PoolOpen:      stats.OpenConnections,
+		PoolInUse:     stats.InUse,
+		PoolIdle:      stats.Idle,
+		PoolWaitCount: stats.WaitCount,
+		PoolWaitMs:    float64(stats.WaitDuration) / float64(time.Millisecond),
+		ServerByState: map[string]int{},
+	}
+	if c.PoolMaxOpen > 0 {
+		c.PoolUtilization = float64(c.PoolInUse) / float64(c.PoolMaxOpen)
+	}
+
+	err := q.db.QueryRowContext(ctx, `
+		SELECT current_setting('max_connections')::int, count(*),
+			COALESCE(EXTRACT(EPOCH FROM now() - min(xact_start) FILTER (WHERE datname = current_database())), 0)
+		FROM pg_stat_activity
+		WHERE backend_type = 'client backend'
+	`).Scan(&c.ServerMax, &c.ServerTotal, &c.LongestXactSecs)
+	if err != nil {
+		return c, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT COALESCE(state, 'unknown'), count(*)
+		FROM pg_stat_activity
+		WHERE datname = current_database() AND backend_type = 'client backend'
+		GROUP BY 1
+	`)
+	if err != nil {
+		return c, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var state string
+		var n int
+		if err := rows.Scan(&state, &n); err != nil {
+			return c, err
+		}
+		c.ServerByState[state] = n
+	}
+	return c, rows.Err()
+}
+
+// healthWarnings returns the conditions of h that need attention.
+func healthWarnings(h types.DBHealth) []string {
+	var warnings []string
+	if !h.Autovacuum {
+		warnings = append(warnings, "autovacuum is disabled")
+	}
+	for _, t := range h.Tables {
+		if t.DeadTuples >= deadTuplesWarning && t.DeadRatio >= deadRatioWarning {
+			last := "never"
+			if t.LastAutovacuum != nil {
+				last = t.LastAutovacuum.Format(time.RFC3339)
+			}
+			warnings = append(warnings, fmt.Sprintf("table %s: %.0f%% dead tuples (%d), about %d MiB bloat, last autovacuum %s",
+				t.Name, t.DeadRatio*100, t.DeadTuples, t.BloatBytes>>20, last))
+		}
+	}
+	for _, i := range h.Indexes {
+		if i.Bytes >= indexBytesWarning && i.HeapRatio > indexRatioWarning {
+			warnings = append(warnings, fmt.Sprintf("index %s is %.1fx the size of table %s (%d MiB)",
+				i.Name, i.HeapRatio, i.Table, i.Bytes>>20))
+		}
+	}
+	c := h.Connections
+	if c.PoolUtilization >= utilizationWarning {
+		warnings = append(warnings, fmt.Sprintf("connection pool %d of %d in use, %d waits", c.PoolInUse, c.PoolMaxOpen, c.PoolWaitCount))
+	}
+	if c.ServerMax > 0 && float64(c.ServerTotal) >= utilizationWarning*float64(c.ServerMax) {
+		warnings = append(warnings, fmt.Sprintf("server has %d of %d connections", c.ServerTotal, c.ServerMax))
+	}
+	if xact := time.Duration(c.LongestXactSecs * float64(time.Second)); xact >= longXactWarning {
+		warnings = append(warnings, fmt.Sprintf("a transaction has been open for %s, holding back vacuum", xact.Round(time.Second)))
+	}
+	return warnings
+}
+
+// nullTime returns the time of t, or nil when it is NULL.
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
@@ -0,0 +1,102 @@
+// driverinput.go
+//
+// Insert and fetch functions for steering wheel input events.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// InsertDriverInputEventsBatch inserts driver input events in a single transaction.
+func InsertDriverInputEventsBatch(ctx context.Context, batch []types.DriverInputEvent) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO driver_input_events (timestamp, input, value, previous)
+		VALUES ($1, $2, $3, $4)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range batch {
+		if _, err := stmt.ExecContext(ctx, e.Timestamp, e.Input, e.Value, e.Previous); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// queryDriverInputEvents runs a query returning driver_input_events rows.
+func (q *Queries) queryDriverInputEvents(ctx context.Context, query string, args ...interface{}) ([]types.DriverInputEvent, error) {
+	var data []types.DriverInputEvent
+	err := q.scanDriverInputEvents(ctx, func(e types.DriverInputEvent) error {
+		data = append(data, e)
+		return nil
+	}, query, args...)
+	return data, err
+}
+
+// scanDriverInputEvents runs a query returning driver_input_events rows and calls
+// fn for each row as it is scanned.
+func (q *Queries) scanDriverInputEvents(ctx context.Context, fn func(types.DriverInputEvent) error, query string, args ...interface{}) error {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e types.DriverInputEvent
+		if err := rows.Scan(&e.Timestamp, &e.Input, &e.Value, &e.Previous); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ScanDriverInputEventsPaginated calls fn for each row of driver input events,
+// oldest first.
+func (q *Queries) ScanDriverInputEventsPaginated(ctx context.Context, limit, offset int, fn func(types.DriverInputEvent) error) error {
+	return q.scanDriverInputEvents(ctx, fn, `
+		SELECT timestamp, input, value, previous
+		FROM driver_input_events
+		ORDER BY timestamp ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+}
+
+// FetchDriverInputHistory returns the events within [from, to], optionally limited
+// to one input (empty input selects all).
+func (q *Queries) FetchDriverInputHistory(ctx context.Context, input string, from, to time.Time) ([]types.DriverInputEvent, error) {
+	return q.queryDriverInputEvents(ctx, `
+		SELECT timestamp, input, value, previous
+		FROM driver_input_events
+		WHERE timestamp >= $1 AND timestamp <= $2 AND ($3 = '' OR input = $3)
+		ORDER BY timestamp ASC
+	`, from, to, input)
+}
+
+// FetchDriverInputStates returns the last event of every input at or before t,
+// i.e. the input states at that time.
+func (q *Queries) FetchDriverInputStates(ctx context.Context, t time.Time) ([]types.DriverInputEvent, error) {
+	return q.queryDriverInputEvents(ctx, `
+		SELECT DISTINCT ON (input) timestamp, input, value, previous
+		FROM driver_input_events
+		WHERE timestamp <= $1
+		ORDER BY input, timestamp DESC
+	`, t)
+}
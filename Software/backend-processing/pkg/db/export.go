@@ -0,0 +1,70 @@
+// export.go
+//
+// Streaming reads of whole telemetry tables over a time range, used by the export
+// endpoints. Values are returned as float64 with storage codecs already undone.
+package db
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// ExportRows calls fn for every row of a telemetry table with a timestamp in
+// [from, to], in time order. values follows the column order of the table spec and
+// is reused between calls; NULL values (missing signals) are NaN. Cell data is read through the cell_data_full view so
+// delta-compressed samples are exported as full rows.
+func (q *Queries) ExportRows(ctx context.Context, table string, from, to time.Time, fn func(t time.Time, values []float64) error) error {
+	spec, ok := LookupTable(table)
+	if !ok {
+		return fmt.Errorf("unknown table %q", table)
+	}
+
+	source := spec.Name
+	if spec.Name == "cell_data" {
+		source = "cell_data_full" // The view applies the codec scales
+	}
+	cols := make([]string, len(spec.Columns))
+	for i, c := range spec.Columns {
+		cols[i] = c.Name
+	}
+	rows, err := q.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT timestamp, %s
+		FROM %s
+		WHERE timestamp >= $1 AND timestamp <= $2
+		ORDER BY timestamp ASC, seq ASC
+	`, strings.Join(cols, ", "), source), from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var ts time.Time
+	values := make([]float64, len(spec.Columns))
+	dest := make([]interface{}, len(values)+1)
+	dest[0] = &ts
+	for i := range values {
+		dest[i+1] = &values[i]
+	}
+	var meta types.RecordMeta
+	for rows.Next() {
+		meta.Missing = meta.Missing[:0]
+		if err := scanRow(rows, source, &meta, dest...); err != nil {
+			return err
+		}
+		if len(meta.Missing) > 0 {
+			for i, c := range spec.Columns {
+				if meta.IsMissing(c.Name) {
+					values[i] = math.NaN()
+				}
+			}
+		}
+		if err := fn(ts, values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,144 @@
+// imu.go
+//
+// Insert, fetch and retention functions for full-rate IMU data and the bursts
+// kept around events.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// IMUBlockSpan is the longest time covered by one imu_blocks row.
+const IMUBlockSpan = time.Second
+
+// imuBurstColumns lists the columns read by scanIMUBurst.
+const imuBurstColumns = `id, session_id, event_at, from_ts, to_ts, trigger, note, requested_by`
+
+// InsertIMUBlocksBatch inserts blocks of IMU samples in a single transaction.
+func InsertIMUBlocksBatch(ctx context.Context, batch []types.IMUBlock) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO imu_blocks (sensor, started_at, offsets_us, x, y, z)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, b := range batch {
+		if _, err := stmt.ExecContext(ctx, b.Sensor, b.Start, b.OffsetsUs, b.X, b.Y, b.Z); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ScanIMUSamplesPaginated calls fn for each row of the full-rate samples of a
+// sensor between from and to, oldest first.
+func (q *Queries) ScanIMUSamplesPaginated(ctx context.Context, sensor string, from, to time.Time, limit, offset int, fn func(types.IMUSample) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT t, x, y, z
+		FROM (
+			SELECT b.started_at + s.offset_us * interval '1 microsecond' AS t, s.x, s.y, s.z
+			FROM imu_blocks b, unnest(b.offsets_us, b.x, b.y, b.z) AS s (offset_us, x, y, z)
+			WHERE b.sensor = $1 AND b.started_at > $2::timestamptz - $3 * interval '1 microsecond' AND b.started_at <= $4
+		) samples
+		WHERE t >= $2 AND t <= $4
+		ORDER BY t
+		LIMIT $5 OFFSET $6
+	`, sensor, from, IMUBlockSpan.Microseconds(), to, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s := types.IMUSample{Sensor: sensor}
+		if err := rows.Scan(&s.Timestamp, &s.X, &s.Y, &s.Z); err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DeleteIMUBlocksBefore removes blocks started before cutoff, except those
+// overlapping a burst window, and returns how many were removed.
+func DeleteIMUBlocksBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := DB.ExecContext(ctx, `
+		DELETE FROM imu_blocks b
+		WHERE b.started_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM imu_bursts u
+			WHERE b.started_at <= u.to_ts AND b.started_at > u.from_ts - $2 * interval '1 microsecond'
+		)
+	`, cutoff, IMUBlockSpan.Microseconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// InsertIMUBurst stores a burst window and returns its ID.
+func InsertIMUBurst(ctx context.Context, b types.IMUBurst) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO imu_bursts (session_id, event_at, from_ts, to_ts, trigger, note, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, b.SessionID, b.EventAt, b.From, b.To, b.Trigger, b.Note, b.RequestedBy).Scan(&id)
+	return id, err
+}
+
+// scanIMUBurst scans an imu_bursts row.
+func scanIMUBurst(row interface{ Scan(...interface{}) error }) (types.IMUBurst, error) {
+	var b types.IMUBurst
+	err := row.Scan(&b.ID, &b.SessionID, &b.EventAt, &b.From, &b.To, &b.Trigger, &b.Note, &b.RequestedBy)
+	return b, err
+}
+
+// ScanIMUBurstsPaginated calls fn for each row of burst windows, newest first.
+func (q *Queries) ScanIMUBurstsPaginated(ctx context.Context, limit, offset int, fn func(types.IMUBurst) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+imuBurstColumns+`
+		FROM imu_bursts
+		ORDER BY event_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		b, err := scanIMUBurst(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FetchIMUBurst returns a single burst window by ID.
+func (q *Queries) FetchIMUBurst(ctx context.Context, id int64) (types.IMUBurst, error) {
+	return scanIMUBurst(q.db.QueryRowContext(ctx, `
+		SELECT `+imuBurstColumns+`
+		FROM imu_bursts
+		WHERE id = $1
+	`, id))
+}
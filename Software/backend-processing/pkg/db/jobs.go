@@ -0,0 +1,46 @@
+// jobs.go
+//
+// Persistence of the last run of scheduled background jobs.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+)
+
+// UpsertJobRun stores the last run of a job, replacing the previous one.
+func UpsertJobRun(ctx context.Context, run types.JobRun) error {
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO job_runs (job, trigger, started_at, finished_at, status, error, run_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (job) DO UPDATE SET
+			trigger = EXCLUDED.trigger,
+			started_at = EXCLUDED.started_at,
+			finished_at = EXCLUDED.finished_at,
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			run_count = EXCLUDED.run_count
+	`, run.Job, run.Trigger, run.StartedAt, run.FinishedAt, run.Status, run.Error, run.RunCount)
+	return err
+}
+
+// FetchJobRuns returns the last run of every job that has run, keyed by job name.
+func FetchJobRuns(ctx context.Context) (map[string]types.JobRun, error) {
+	rows, err := DB.QueryContext(ctx, `
+		SELECT job, trigger, started_at, finished_at, status, error, run_count
+		FROM job_runs
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	runs := make(map[string]types.JobRun)
+	for rows.Next() {
+		var r types.JobRun
+		if err := rows.Scan(&r.Job, &r.Trigger, &r.StartedAt, &r.FinishedAt, &r.Status, &r.Error, &r.RunCount); err != nil {
+			return nil, err
+		}
+		runs[r.Job] = r
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,113 @@
+// laps.go
+//
+// Insert and fetch functions for the lap_summary table.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// LapSortColumns lists the lap_summary columns laps can be sorted by.
+var LapSortColumns = map[string]bool{
+	"lap_number": true, "started_at": true, "duration_s": true, "distance_m": true,
+	"max_speed": true, "avg_speed": true, "energy_wh": true, "max_cell_temp": true,
+	"max_motor_temp": true, "max_controller_temp": true, "alert_count": true,
+	"drive_energy_wh": true, "regen_energy_wh": true,
+}
+
+// InsertLapSummary stores a lap summary and returns its ID.
+func InsertLapSummary(ctx context.Context, l types.LapSummary) (int64, error) {
+	channels, err := json.Marshal(l.Channels)
+	if err != nil {
+		return 0, err
+	}
+	if l.Channels == nil {
+		channels = []byte("{}")
+	}
+	var id int64
+	err = DB.QueryRowContext(ctx, `
+		INSERT INTO lap_summary (session_id, lap_number, started_at, ended_at, duration_s,
+			distance_m, max_speed, avg_speed, energy_wh, max_cell_temp, max_motor_temp,
+			max_controller_temp, alert_count, channels, trigger, drive_energy_wh, regen_energy_wh)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id
+	`, l.SessionID, l.LapNumber, l.StartedAt, l.EndedAt, l.DurationS,
+		l.DistanceM, l.MaxSpeed, l.AvgSpeed, l.EnergyWh, l.MaxCellTemp, l.MaxMotorTemp,
+		l.MaxControllerTemp, l.AlertCount, string(channels), l.Trigger, l.DriveEnergyWh, l.RegenEnergyWh).Scan(&id)
+	return id, err
+}
+
+// LastLapNumber returns the highest lap number of a session (or of laps outside
+// any session when sessionID is nil), or 0 if there are none.
+func LastLapNumber(ctx context.Context, sessionID *int64) (int, error) {
+	var n sql.NullInt64
+	err := DB.QueryRowContext(ctx, `
+		SELECT MAX(lap_number) FROM lap_summary
+		WHERE session_id IS NOT DISTINCT FROM $1
+	`, sessionID).Scan(&n)
+	return int(n.Int64), err
+}
+
+// CountAlertsRaised returns the number of alerts raised within [from, to).
+func CountAlertsRaised(ctx context.Context, from, to time.Time) (int, error) {
+	var n int
+	err := DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM alert_history WHERE raised_at >= $1 AND raised_at < $2
+	`, from, to).Scan(&n)
+	return n, err
+}
+
+// FetchLaps returns lap summaries sorted by the given column (see LapSortColumns),
+// optionally restricted to one session.
+func (q *Queries) FetchLaps(ctx context.Context, sessionID *int64, sortBy string, desc bool, limit, offset int) ([]types.LapSummary, error) {
+	if !LapSortColumns[sortBy] {
+		return nil, fmt.Errorf("cannot sort by %q", sortBy)
+	}
+	order := "ASC NULLS LAST"
+	if desc {
+		order = "DESC NULLS LAST"
+	}
+	rows, err := q.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, session_id, lap_number, started_at, ended_at, duration_s, distance_m,
+			max_speed, avg_speed, energy_wh, max_cell_temp, max_motor_temp,
+			max_controller_temp, alert_count, channels, trigger, drive_energy_wh, regen_energy_wh
+		FROM lap_summary
+		WHERE $1::BIGINT IS NULL OR session_id = $1
+		ORDER BY %s %s, started_at ASC
+		LIMIT $2 OFFSET $3
+	`, sortBy, order), sessionID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var data []types.LapSummary
+	for rows.Next() {
+		var l types.LapSummary
+		var session sql.NullInt64
+		var channels []byte
+		if err := rows.Scan(&l.ID, &session, &l.LapNumber, &l.StartedAt, &l.EndedAt, &l.DurationS,
+			&l.DistanceM, &l.MaxSpeed, &l.AvgSpeed, &l.EnergyWh, &l.MaxCellTemp, &l.MaxMotorTemp,
+			&l.MaxControllerTemp, &l.AlertCount, &channels, &l.Trigger, &l.DriveEnergyWh, &l.RegenEnergyWh); err != nil {
+			return nil, err
+		}
+		if l.DriveEnergyWh != nil && l.RegenEnergyWh != nil && *l.DriveEnergyWh > 0 {
+			f := *l.RegenEnergyWh / *l.DriveEnergyWh
+			l.RegenFraction = &f
+		}
+		if session.Valid {
+			id := session.Int64
+			l.SessionID = &id
+		}
+		if err := json.Unmarshal(channels, &l.Channels); err != nil {
+			return nil, err
+		}
+		data = append(data, l)
+	}
+	return data, rows.Err()
+}
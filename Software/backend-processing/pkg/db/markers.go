@@ -0,0 +1,200 @@
+// markers.go
+//
+// Alert history and annotations: insert and fetch functions, and the merged
+// marker timeline used when exporting data.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// UpsertAlertRecord stores an alert occurrence, updating severity, message and
+// value if it was already stored.
+func UpsertAlertRecord(ctx context.Context, a types.AlertRecord) error {
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO alert_history (key, source, severity, message, value, threshold, raised_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (key, raised_at) DO UPDATE
+		SET severity = EXCLUDED.severity, message = EXCLUDED.message,
+			value = EXCLUDED.value, threshold = EXCLUDED.threshold
+	`, a.Key, a.Source, a.Severity, a.Message, a.Value, a.Threshold, a.RaisedAt)
+	return err
+}
+
+// ResolveAlertRecord marks an alert occurrence as resolved.
+func ResolveAlertRecord(ctx context.Context, key string, raisedAt, resolvedAt time.Time) error {
+	_, err := DB.ExecContext(ctx, `
+		UPDATE alert_history SET resolved_at = $3
+		WHERE key = $1 AND raised_at = $2 AND resolved_at IS NULL
+	`, key, raisedAt, resolvedAt)
+	return err
+}
+
+// ResolveOpenAlertRecords resolves every alert left active by a previous run and
+// returns how many were resolved.
+func ResolveOpenAlertRecords(ctx context.Context, resolvedAt time.Time) (int64, error) {
+	res, err := DB.ExecContext(ctx, `
+		UPDATE alert_history SET resolved_at = GREATEST(raised_at, $1)
+		WHERE resolved_at IS NULL
+	`, resolvedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// scanAlertRecord scans an alert_history row.
+func scanAlertRecord(row interface{ Scan(...interface{}) error }) (types.AlertRecord, error) {
+	var a types.AlertRecord
+	var resolvedAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.Key, &a.Source, &a.Severity, &a.Message, &a.Value, &a.Threshold, &a.RaisedAt, &resolvedAt); err != nil {
+		return a, err
+	}
+	if resolvedAt.Valid {
+		t := resolvedAt.Time
+		a.ResolvedAt = &t
+	}
+	return a, nil
+}
+
+// ScanAlertHistoryPaginated calls fn for each row of past and active alerts,
+// oldest first.
+func (q *Queries) ScanAlertHistoryPaginated(ctx context.Context, limit, offset int, fn func(types.AlertRecord) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, key, source, severity, message, value, threshold, raised_at, resolved_at
+		FROM alert_history
+		ORDER BY raised_at ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a, err := scanAlertRecord(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// InsertAnnotation stores an annotation and returns its ID.
+func InsertAnnotation(ctx context.Context, a types.Annotation) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO annotations (started_at, ended_at, text, author)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, a.StartedAt, a.EndedAt, a.Text, a.Author).Scan(&id)
+	return id, err
+}
+
+// scanAnnotation scans an annotations row.
+func scanAnnotation(row interface{ Scan(...interface{}) error }) (types.Annotation, error) {
+	var a types.Annotation
+	var endedAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.StartedAt, &endedAt, &a.Text, &a.Author); err != nil {
+		return a, err
+	}
+	if endedAt.Valid {
+		t := endedAt.Time
+		a.EndedAt = &t
+	}
+	return a, nil
+}
+
+// ScanAnnotationsPaginated calls fn for each row of annotations, oldest first.
+func (q *Queries) ScanAnnotationsPaginated(ctx context.Context, limit, offset int, fn func(types.Annotation) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, started_at, ended_at, text, author
+		FROM annotations
+		ORDER BY started_at ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a, err := scanAnnotation(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FetchMarkers returns the alerts and annotations overlapping [from, to], ordered
+// by time.
+func (q *Queries) FetchMarkers(ctx context.Context, from, to time.Time) ([]types.Marker, error) {
+	var markers []types.Marker
+
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, key, source, severity, message, value, threshold, raised_at, resolved_at
+		FROM alert_history
+		WHERE raised_at <= $2 AND (resolved_at IS NULL OR resolved_at >= $1)
+		ORDER BY raised_at ASC
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a, err := scanAlertRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		markers = append(markers, types.Marker{
+			Time:     a.RaisedAt,
+			End:      a.ResolvedAt,
+			Kind:     "alert",
+			Severity: a.Severity,
+			Label:    a.Key,
+			Text:     a.Message,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.db.QueryContext(ctx, `
+		SELECT id, started_at, ended_at, text, author
+		FROM annotations
+		WHERE started_at <= $2 AND COALESCE(ended_at, started_at) >= $1
+		ORDER BY started_at ASC
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a, err := scanAnnotation(rows)
+		if err != nil {
+			return nil, err
+		}
+		markers = append(markers, types.Marker{
+			Time:  a.StartedAt,
+			End:   a.EndedAt,
+			Kind:  "annotation",
+			Label: a.Author,
+			Text:  a.Text,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].Time.Before(markers[j].Time) })
+	return markers, nil
+}
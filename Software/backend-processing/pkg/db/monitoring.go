@@ -0,0 +1,97 @@
+// monitoring.go
+//
+// Insert and fetch functions for server monitoring tables (bus load, pipeline metrics).
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"telem-system/pkg/types"
+)
+
+// InsertBusLoadData inserts a single bus load sample.
+func InsertBusLoadData(ctx context.Context, data types.BusLoad_Data) error {
+	topIDs, err := json.Marshal(data.TopIDs)
+	if err != nil {
+		return err
+	}
+	_, err = DB.ExecContext(ctx, `
+		INSERT INTO bus_load (timestamp, frames_per_sec, bytes_per_sec, bits_per_sec, load_percent, top_ids)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, data.Timestamp, data.FramesPerSec, data.BytesPerSec, data.BitsPerSec, data.LoadPercent, string(topIDs))
+	return err
+}
+
+// ScanBusLoadDataPaginated calls fn for each row of bus load samples.
+func (q *Queries) ScanBusLoadDataPaginated(ctx context.Context, limit, offset int, fn func(types.BusLoad_Data) error) error {
+	query := `
+		SELECT timestamp, frames_per_sec, bytes_per_sec, bits_per_sec, load_percent, top_ids
+		FROM bus_load
+		ORDER BY timestamp ASC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := q.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rec types.BusLoad_Data
+		var topIDs []byte
+		if err := rows.Scan(&rec.Timestamp, &rec.FramesPerSec, &rec.BytesPerSec, &rec.BitsPerSec, &rec.LoadPercent, &topIDs); err != nil {
+			return err
+		}
+		if len(topIDs) > 0 {
+			if err := json.Unmarshal(topIDs, &rec.TopIDs); err != nil {
+				return err
+			}
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// InsertServerMetrics inserts a single pipeline metrics sample.
+func InsertServerMetrics(ctx context.Context, data types.ServerMetrics_Data) error {
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO server_metrics (
+			timestamp, cache_hits, cache_misses, cache_hit_rate, messages_sent, messages_dropped,
+			sent_per_sec, dropped_per_sec, circuit_state, persistence_lag_ms
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, data.Timestamp, int64(data.CacheHits), int64(data.CacheMisses), data.CacheHitRate,
+		int64(data.MessagesSent), int64(data.MessagesDropped), data.SentPerSec, data.DroppedPerSec,
+		data.CircuitState, data.PersistenceLagMs)
+	return err
+}
+
+// ScanServerMetricsPaginated calls fn for each row of pipeline metrics samples.
+func (q *Queries) ScanServerMetricsPaginated(ctx context.Context, limit, offset int, fn func(types.ServerMetrics_Data) error) error {
+	query := `
+		SELECT timestamp, cache_hits, cache_misses, cache_hit_rate, messages_sent, messages_dropped,
+			sent_per_sec, dropped_per_sec, circuit_state, persistence_lag_ms
+		FROM server_metrics
+		ORDER BY timestamp ASC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := q.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rec types.ServerMetrics_Data
+		var hits, misses, sent, dropped int64
+		if err := rows.Scan(&rec.Timestamp, &hits, &misses, &rec.CacheHitRate, &sent, &dropped,
+			&rec.SentPerSec, &rec.DroppedPerSec, &rec.CircuitState, &rec.PersistenceLagMs); err != nil {
+			return err
+		}
+		rec.CacheHits, rec.CacheMisses = uint64(hits), uint64(misses)
+		rec.MessagesSent, rec.MessagesDropped = uint64(sent), uint64(dropped)
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,120 @@
+// packhealth.go
+//
+// Insert and fetch functions for accumulator pack health records.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"telem-system/pkg/types"
+)
+
+const packHealthColumns = `id, pack_id, session_id, recorded_at, discharge_ah, charge_ah,
+	total_discharge_ah, total_charge_ah, cycles, capacity_ah, soh`
+
+// InsertPackHealth stores a pack health record and returns its ID.
+func InsertPackHealth(ctx context.Context, h types.PackHealth) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO pack_health (pack_id, session_id, recorded_at, discharge_ah, charge_ah,
+			total_discharge_ah, total_charge_ah, cycles, capacity_ah, soh)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, h.PackID, h.SessionID, h.RecordedAt, h.DischargeAh, h.ChargeAh,
+		h.TotalDischargeAh, h.TotalChargeAh, h.Cycles, h.CapacityAh, h.SOH).Scan(&id)
+	return id, err
+}
+
+// FetchLatestPackHealth returns the newest record of a pack. It reports false if
+// the pack has none.
+func FetchLatestPackHealth(ctx context.Context, packID string) (types.PackHealth, bool, error) {
+	row := DB.QueryRowContext(ctx, `
+		SELECT `+packHealthColumns+`
+		FROM pack_health
+		WHERE pack_id = $1
+		ORDER BY recorded_at DESC
+		LIMIT 1
+	`, packID)
+	h, err := scanPackHealth(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.PackHealth{}, false, nil
+	}
+	return h, err == nil, err
+}
+
+// FetchPackCapacities returns up to limit measured capacities of a pack, newest
+// first.
+func FetchPackCapacities(ctx context.Context, packID string, limit int) ([]float64, error) {
+	rows, err := DB.QueryContext(ctx, `
+		SELECT capacity_ah
+		FROM pack_health
+		WHERE pack_id = $1 AND capacity_ah IS NOT NULL
+		ORDER BY recorded_at DESC
+		LIMIT $2
+	`, packID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []float64
+	for rows.Next() {
+		var c float64
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// FetchPackHealthSummary returns the newest record of every pack, by pack ID.
+func (q *Queries) FetchPackHealthSummary(ctx context.Context) ([]types.PackHealth, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (pack_id) `+packHealthColumns+`
+		FROM pack_health
+		ORDER BY pack_id, recorded_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanPackHealthRows(rows)
+}
+
+// FetchPackHealthHistory returns the records of a pack, oldest first.
+func (q *Queries) FetchPackHealthHistory(ctx context.Context, packID string, limit, offset int) ([]types.PackHealth, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+packHealthColumns+`
+		FROM pack_health
+		WHERE pack_id = $1
+		ORDER BY recorded_at ASC
+		LIMIT $2 OFFSET $3
+	`, packID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanPackHealthRows(rows)
+}
+
+// scanPackHealthRows reads all pack health records of a result set.
+func scanPackHealthRows(rows *sql.Rows) ([]types.PackHealth, error) {
+	var data []types.PackHealth
+	for rows.Next() {
+		h, err := scanPackHealth(rows)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, h)
+	}
+	return data, rows.Err()
+}
+
+// scanPackHealth reads a pack health record in packHealthColumns order.
+func scanPackHealth(row interface{ Scan(...interface{}) error }) (types.PackHealth, error) {
+	var h types.PackHealth
+	err := row.Scan(&h.ID, &h.PackID, &h.SessionID, &h.RecordedAt, &h.DischargeAh, &h.ChargeAh,
+		&h.TotalDischargeAh, &h.TotalChargeAh, &h.Cycles, &h.CapacityAh, &h.SOH)
+	return h, err
+}
@@ -0,0 +1,45 @@
+// precharge.go
+//
+// Insert and fetch functions for precharge attempts.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+)
+
+// InsertPrechargeAttempt stores a precharge attempt and returns its ID.
+func InsertPrechargeAttempt(ctx context.Context, a types.PrechargeAttempt) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO precharge_attempts (started_at, ended_at, duration_s, accumulator_v, final_delta_v, passed, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, a.StartedAt, a.EndedAt, a.DurationS, a.AccumulatorV, a.FinalDeltaV, a.Passed, a.Reason).Scan(&id)
+	return id, err
+}
+
+// ScanPrechargeAttemptsPaginated calls fn for each row of precharge attempts,
+// oldest first.
+func (q *Queries) ScanPrechargeAttemptsPaginated(ctx context.Context, limit, offset int, fn func(types.PrechargeAttempt) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, started_at, ended_at, duration_s, accumulator_v, final_delta_v, passed, reason
+		FROM precharge_attempts
+		ORDER BY started_at ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a types.PrechargeAttempt
+		if err := rows.Scan(&a.ID, &a.StartedAt, &a.EndedAt, &a.DurationS, &a.AccumulatorV, &a.FinalDeltaV, &a.Passed, &a.Reason); err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,70 @@
+// resistance.go
+//
+// Insert and fetch functions for accumulator internal resistance estimates.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+)
+
+// InsertResistanceEstimate stores a resistance estimate and returns its ID.
+func InsertResistanceEstimate(ctx context.Context, e types.ResistanceEstimate) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO resistance_estimates (session_id, estimated_at, pack_ohm, cell_ohm, steps, final)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, e.SessionID, e.EstimatedAt, e.PackOhm, e.CellOhm, e.Steps, e.Final).Scan(&id)
+	return id, err
+}
+
+// ScanResistanceEstimatesPaginated calls fn for each row of resistance estimates,
+// oldest first.
+func (q *Queries) ScanResistanceEstimatesPaginated(ctx context.Context, limit, offset int, fn func(types.ResistanceEstimate) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, session_id, estimated_at, pack_ohm, cell_ohm, steps, final
+		FROM resistance_estimates
+		ORDER BY estimated_at ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e types.ResistanceEstimate
+		if err := rows.Scan(&e.ID, &e.SessionID, &e.EstimatedAt, &e.PackOhm, &e.CellOhm, &e.Steps, &e.Final); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FetchPreviousResistance returns the final pack resistance of up to limit
+// sessions other than sessionID, newest first.
+func FetchPreviousResistance(ctx context.Context, sessionID int64, limit int) ([]float64, error) {
+	rows, err := DB.QueryContext(ctx, `
+		SELECT pack_ohm
+		FROM resistance_estimates
+		WHERE final AND session_id <> $1
+		ORDER BY estimated_at DESC
+		LIMIT $2
+	`, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []float64
+	for rows.Next() {
+		var r float64
+		if err := rows.Scan(&r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,336 @@
+// schema.go
+//
+// Schema bootstrap. The CAN telemetry tables are generated from their table specs
+// (with column types chosen by the storage codecs), followed by the tables owned by
+// the telemetry server itself (monitoring, bookkeeping, derived data). Everything
+// is created on startup if missing.
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// schemaStatements are executed in order by EnsureSchema. Every statement must be idempotent.
+var schemaStatements = []string{
+	`CREATE TABLE IF NOT EXISTS bus_load (
+		timestamp      TIMESTAMPTZ      NOT NULL,
+		frames_per_sec DOUBLE PRECISION NOT NULL,
+		bytes_per_sec  DOUBLE PRECISION NOT NULL,
+		bits_per_sec   DOUBLE PRECISION NOT NULL,
+		load_percent   DOUBLE PRECISION NOT NULL,
+		top_ids        JSONB
+	)`,
+	`CREATE INDEX IF NOT EXISTS bus_load_timestamp_idx ON bus_load (timestamp)`,
+
+	// Periodic pipeline counters (decode cache, throttler, persistence)
+	`CREATE TABLE IF NOT EXISTS server_metrics (
+		timestamp          TIMESTAMPTZ      NOT NULL,
+		cache_hits         BIGINT           NOT NULL,
+		cache_misses       BIGINT           NOT NULL,
+		cache_hit_rate     DOUBLE PRECISION NOT NULL,
+		messages_sent      BIGINT           NOT NULL,
+		messages_dropped   BIGINT           NOT NULL,
+		sent_per_sec       DOUBLE PRECISION NOT NULL,
+		dropped_per_sec    DOUBLE PRECISION NOT NULL,
+		circuit_state      INTEGER          NOT NULL,
+		persistence_lag_ms DOUBLE PRECISION NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS server_metrics_timestamp_idx ON server_metrics (timestamp)`,
+
+	// Sessions (manual or opened/closed automatically from vehicle activity)
+	`CREATE TABLE IF NOT EXISTS sessions (
+		id           BIGSERIAL   PRIMARY KEY,
+		name         TEXT        NOT NULL,
+		started_at   TIMESTAMPTZ NOT NULL,
+		ended_at     TIMESTAMPTZ,
+		start_reason TEXT        NOT NULL,
+		end_reason   TEXT,
+		auto         BOOLEAN     NOT NULL DEFAULT FALSE
+	)`,
+	`CREATE INDEX IF NOT EXISTS sessions_started_at_idx ON sessions (started_at)`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS feature_flags JSONB NOT NULL DEFAULT '{}'`,
+
+	// Calibration log (one row per calibration performed)
+	`CREATE TABLE IF NOT EXISTS calibrations (
+		id            BIGSERIAL   PRIMARY KEY,
+		name          TEXT        NOT NULL,
+		calibrated_at TIMESTAMPTZ NOT NULL,
+		notes         TEXT        NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS calibrations_name_idx ON calibrations (name, calibrated_at)`,
+	`ALTER TABLE calibrations ADD COLUMN IF NOT EXISTS params JSONB NOT NULL DEFAULT '{}'`,
+
+	// Alert history (one row per alert occurrence)
+	`CREATE TABLE IF NOT EXISTS alert_history (
+		id          BIGSERIAL        PRIMARY KEY,
+		key         TEXT             NOT NULL,
+		source      TEXT             NOT NULL,
+		severity    TEXT             NOT NULL,
+		message     TEXT             NOT NULL,
+		value       DOUBLE PRECISION NOT NULL,
+		threshold   DOUBLE PRECISION NOT NULL,
+		raised_at   TIMESTAMPTZ      NOT NULL,
+		resolved_at TIMESTAMPTZ,
+		UNIQUE (key, raised_at)
+	)`,
+	`CREATE INDEX IF NOT EXISTS alert_history_raised_at_idx ON alert_history (raised_at)`,
+
+	// User annotations on the timeline
+	`CREATE TABLE IF NOT EXISTS annotations (
+		id         BIGSERIAL   PRIMARY KEY,
+		started_at TIMESTAMPTZ NOT NULL,
+		ended_at   TIMESTAMPTZ,
+		text       TEXT        NOT NULL,
+		author     TEXT        NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS annotations_started_at_idx ON annotations (started_at)`,
+
+	// Per-lap aggregates (one row per completed lap)
+	`CREATE TABLE IF NOT EXISTS lap_summary (
+		id                  BIGSERIAL        PRIMARY KEY,
+		session_id          BIGINT           REFERENCES sessions (id),
+		lap_number          INTEGER          NOT NULL,
+		started_at          TIMESTAMPTZ      NOT NULL,
+		ended_at            TIMESTAMPTZ      NOT NULL,
+		duration_s          DOUBLE PRECISION NOT NULL,
+		distance_m          DOUBLE PRECISION,
+		max_speed           DOUBLE PRECISION,
+		avg_speed           DOUBLE PRECISION,
+		energy_wh           DOUBLE PRECISION,
+		max_cell_temp       DOUBLE PRECISION,
+		max_motor_temp      DOUBLE PRECISION,
+		max_controller_temp DOUBLE PRECISION,
+		alert_count         INTEGER          NOT NULL DEFAULT 0,
+		channels            JSONB            NOT NULL DEFAULT '{}',
+		trigger             TEXT             NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS lap_summary_started_at_idx ON lap_summary (started_at)`,
+	`ALTER TABLE lap_summary ADD COLUMN IF NOT EXISTS drive_energy_wh DOUBLE PRECISION`,
+	`ALTER TABLE lap_summary ADD COLUMN IF NOT EXISTS regen_energy_wh DOUBLE PRECISION`,
+
+	// Steering wheel input state changes
+	`CREATE TABLE IF NOT EXISTS driver_input_events (
+		timestamp TIMESTAMPTZ      NOT NULL,
+		input     TEXT             NOT NULL,
+		value     DOUBLE PRECISION NOT NULL,
+		previous  DOUBLE PRECISION
+	)`,
+	`CREATE INDEX IF NOT EXISTS driver_input_events_timestamp_idx ON driver_input_events (timestamp)`,
+	`CREATE INDEX IF NOT EXISTS driver_input_events_input_idx ON driver_input_events (input, timestamp)`,
+
+	// Shutdown circuit element state changes
+	`CREATE TABLE IF NOT EXISTS shutdown_events (
+		timestamp  TIMESTAMPTZ      NOT NULL,
+		element    TEXT             NOT NULL,
+		closed     BOOLEAN          NOT NULL,
+		value      DOUBLE PRECISION NOT NULL,
+		trip_start TIMESTAMPTZ,
+		trip_order INTEGER
+	)`,
+	`CREATE INDEX IF NOT EXISTS shutdown_events_timestamp_idx ON shutdown_events (timestamp)`,
+	`CREATE INDEX IF NOT EXISTS shutdown_events_trip_idx ON shutdown_events (trip_start) WHERE trip_start IS NOT NULL`,
+
+	// Precharge sequences
+	`CREATE TABLE IF NOT EXISTS precharge_attempts (
+		id            BIGSERIAL        PRIMARY KEY,
+		started_at    TIMESTAMPTZ      NOT NULL,
+		ended_at      TIMESTAMPTZ      NOT NULL,
+		duration_s    DOUBLE PRECISION NOT NULL,
+		accumulator_v DOUBLE PRECISION NOT NULL,
+		final_delta_v DOUBLE PRECISION NOT NULL,
+		passed        BOOLEAN          NOT NULL,
+		reason        TEXT             NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS precharge_attempts_started_at_idx ON precharge_attempts (started_at)`,
+
+	// Rolling accumulator internal resistance estimates (periodic and final per session)
+	`CREATE TABLE IF NOT EXISTS resistance_estimates (
+		id           BIGSERIAL        PRIMARY KEY,
+		session_id   BIGINT           NOT NULL REFERENCES sessions (id),
+		estimated_at TIMESTAMPTZ      NOT NULL,
+		pack_ohm     DOUBLE PRECISION NOT NULL,
+		cell_ohm     DOUBLE PRECISION,
+		steps        INTEGER          NOT NULL,
+		final        BOOLEAN          NOT NULL DEFAULT FALSE
+	)`,
+	`CREATE INDEX IF NOT EXISTS resistance_estimates_session_idx ON resistance_estimates (session_id, estimated_at)`,
+
+	// Accumulator pack throughput and health (one row per pack and session)
+	`CREATE TABLE IF NOT EXISTS pack_health (
+		id                 BIGSERIAL        PRIMARY KEY,
+		pack_id            TEXT             NOT NULL,
+		session_id         BIGINT           NOT NULL REFERENCES sessions (id),
+		recorded_at        TIMESTAMPTZ      NOT NULL,
+		discharge_ah       DOUBLE PRECISION NOT NULL,
+		charge_ah          DOUBLE PRECISION NOT NULL,
+		total_discharge_ah DOUBLE PRECISION NOT NULL,
+		total_charge_ah    DOUBLE PRECISION NOT NULL,
+		cycles             DOUBLE PRECISION,
+		capacity_ah        DOUBLE PRECISION,
+		soh                DOUBLE PRECISION
+	)`,
+	`CREATE INDEX IF NOT EXISTS pack_health_pack_idx ON pack_health (pack_id, recorded_at)`,
+
+	// Transitions of the consolidated vehicle state
+	`CREATE TABLE IF NOT EXISTS vehicle_state_events (
+		timestamp TIMESTAMPTZ NOT NULL,
+		state     TEXT        NOT NULL,
+		previous  TEXT        NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS vehicle_state_events_timestamp_idx ON vehicle_state_events (timestamp)`,
+
+	// Expiring read-only share links (only the token hash is stored)
+	`CREATE TABLE IF NOT EXISTS share_links (
+		id         BIGSERIAL   PRIMARY KEY,
+		token_hash BYTEA       NOT NULL UNIQUE,
+		session_id BIGINT      NOT NULL REFERENCES sessions (id),
+		channels   TEXT[]      NOT NULL,
+		note       TEXT        NOT NULL DEFAULT '',
+		created_by TEXT        NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		revoked_at TIMESTAMPTZ
+	)`,
+
+	// Traction-control capture runs and their full-resolution frames
+	`CREATE TABLE IF NOT EXISTS tc_capture_runs (
+		id         BIGSERIAL   PRIMARY KEY,
+		session_id BIGINT      REFERENCES sessions (id),
+		started_at TIMESTAMPTZ NOT NULL,
+		ended_at   TIMESTAMPTZ,
+		frame_ids  INTEGER[]   NOT NULL,
+		note       TEXT        NOT NULL DEFAULT '',
+		started_by TEXT        NOT NULL,
+		frames     BIGINT      NOT NULL DEFAULT 0,
+		dropped    BIGINT      NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS tc_capture_frames (
+		run_id      BIGINT      NOT NULL REFERENCES tc_capture_runs (id),
+		captured_at TIMESTAMPTZ NOT NULL,
+		frame_id    INTEGER     NOT NULL,
+		data        BYTEA       NOT NULL,
+		signals     JSONB       NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS tc_capture_frames_run_idx ON tc_capture_frames (run_id, frame_id, captured_at)`,
+
+	// Named live subscription profiles, selected by clients at connect time
+	`CREATE TABLE IF NOT EXISTS ws_profiles (
+		name        TEXT             PRIMARY KEY,
+		description TEXT             NOT NULL DEFAULT '',
+		channels    TEXT[]           NOT NULL DEFAULT '{}',
+		max_rate    DOUBLE PRECISION NOT NULL DEFAULT 0,
+		format      TEXT             NOT NULL DEFAULT 'protobuf',
+		updated_by  TEXT             NOT NULL,
+		updated_at  TIMESTAMPTZ      NOT NULL DEFAULT now()
+	)`,
+
+	// Database backups written to the backup drive and their verification
+	`CREATE TABLE IF NOT EXISTS backups (
+		id          BIGSERIAL   PRIMARY KEY,
+		started_at  TIMESTAMPTZ NOT NULL,
+		finished_at TIMESTAMPTZ,
+		status      TEXT        NOT NULL,
+		file        TEXT        NOT NULL DEFAULT '',
+		size_bytes  BIGINT      NOT NULL DEFAULT 0,
+		sha256      TEXT        NOT NULL DEFAULT '',
+		tables      INTEGER     NOT NULL DEFAULT 0,
+		error       TEXT        NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS backups_started_at_idx ON backups (started_at)`,
+
+	// Full-rate IMU samples, one row per sensor and block of up to IMUBlockSpan
+	`CREATE TABLE IF NOT EXISTS imu_blocks (
+		sensor     TEXT        NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL,
+		offsets_us INTEGER[]   NOT NULL,
+		x          REAL[]      NOT NULL,
+		y          REAL[]      NOT NULL,
+		z          REAL[]      NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS imu_blocks_sensor_started_at_idx ON imu_blocks (sensor, started_at)`,
+
+	// Windows of full-rate IMU data around events, exempt from IMU retention
+	`CREATE TABLE IF NOT EXISTS imu_bursts (
+		id           BIGSERIAL   PRIMARY KEY,
+		session_id   BIGINT      REFERENCES sessions (id),
+		event_at     TIMESTAMPTZ NOT NULL,
+		from_ts      TIMESTAMPTZ NOT NULL,
+		to_ts        TIMESTAMPTZ NOT NULL,
+		trigger      TEXT        NOT NULL,
+		note         TEXT        NOT NULL DEFAULT '',
+		requested_by TEXT        NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS imu_bursts_event_at_idx ON imu_bursts (event_at)`,
+
+	// Last run of every scheduled background job
+	`CREATE TABLE IF NOT EXISTS job_runs (
+		job         TEXT        PRIMARY KEY,
+		trigger     TEXT        NOT NULL,
+		started_at  TIMESTAMPTZ NOT NULL,
+		finished_at TIMESTAMPTZ,
+		status      TEXT        NOT NULL,
+		error       TEXT        NOT NULL DEFAULT '',
+		run_count   BIGINT      NOT NULL DEFAULT 0
+	)`,
+
+	// Delta-compressed cell samples, reconstructed against their keyframe row in cell_data
+	`CREATE TABLE IF NOT EXISTS cell_data_delta (
+		timestamp    TIMESTAMPTZ        NOT NULL,
+		keyframe_ts  TIMESTAMPTZ        NOT NULL,
+		cell_indices SMALLINT[]         NOT NULL,
+		cell_values  DOUBLE PRECISION[] NOT NULL,
+		seq          BIGINT
+	)`,
+	`ALTER TABLE cell_data_delta ADD COLUMN IF NOT EXISTS seq BIGINT`,
+	`CREATE INDEX IF NOT EXISTS cell_data_delta_timestamp_idx ON cell_data_delta (timestamp)`,
+	`CREATE INDEX IF NOT EXISTS cell_data_delta_seq_idx ON cell_data_delta (seq)`,
+}
+
+// SchemaStatements returns the complete, ordered list of idempotent schema statements.
+func SchemaStatements() []string {
+	stmts := GenerateTelemetrySchema()
+	stmts = append(stmts, schemaStatements...)
+	return append(stmts, cellDataFullViewSQL())
+}
+
+// cellDataFullViewSQL builds the cell_data_full view, which returns keyframe rows from
+// cell_data as-is and expands every delta row into a full 128-cell row by overlaying
+// the changed cells on its keyframe. Fixed-point cell columns are scaled back so the
+// view always yields volts as DOUBLE PRECISION.
+func cellDataFullViewSQL() string {
+	var keyCols, deltaCols strings.Builder
+	for i := 1; i <= 128; i++ {
+		if i > 1 {
+			keyCols.WriteString(", ")
+			deltaCols.WriteString(",\n\t\t\t")
+		}
+		stored := fmt.Sprintf("cell%d::double precision", i)
+		if scale := columnScale("cell_data", fmt.Sprintf("cell%d", i)); scale != 0 {
+			stored = fmt.Sprintf("cell%d * %g::double precision", i, scale)
+		}
+		fmt.Fprintf(&keyCols, "%s AS cell%d", stored, i)
+		fmt.Fprintf(&deltaCols, "COALESCE(d.cell_values[array_position(d.cell_indices, %d::smallint)], k.%s) AS cell%d", i, stored, i)
+	}
+	return fmt.Sprintf(`CREATE OR REPLACE VIEW cell_data_full AS
+		SELECT timestamp, %s, seq
+		FROM cell_data
+		UNION ALL
+		SELECT d.timestamp,
+			%s,
+			d.seq
+		FROM cell_data_delta d
+		JOIN cell_data k ON k.timestamp = d.keyframe_ts`, keyCols.String(), deltaCols.String())
+}
+
+// EnsureSchema creates the tables, indexes and views if they do not exist yet.
+// Column types of existing tables are left untouched.
+func EnsureSchema(ctx context.Context) error {
+	for _, stmt := range SchemaStatements() {
+		if _, err := DB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("schema statement failed: %w", err)
+		}
+	}
+	return nil
+}
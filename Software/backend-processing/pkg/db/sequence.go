@@ -0,0 +1,27 @@
+// sequence.go
+//
+// Sequence numbers of stored telemetry records. Every telemetry row carries a seq
+// assigned by its batch writer, one higher than the previous record of the table,
+// so rows sharing a timestamp keep their arrival order and missing rows show up as
+// gaps. The writers resume from the highest stored number after a restart.
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// MaxSequence returns the highest sequence number stored in a telemetry table, or
+// 0 if it has none. Cell samples are numbered across cell_data and cell_data_delta.
+// The seq indexes of the schema answer it without scanning the tables.
+func MaxSequence(ctx context.Context, table string) (int64, error) {
+	query := "SELECT max(seq) FROM " + table
+	if table == "cell_data" {
+		query = "SELECT GREATEST((SELECT max(seq) FROM cell_data), (SELECT max(seq) FROM cell_data_delta))"
+	}
+	var seq sql.NullInt64
+	if err := DB.QueryRowContext(ctx, query).Scan(&seq); err != nil {
+		return 0, err
+	}
+	return seq.Int64, nil
+}
@@ -0,0 +1,106 @@
+// sessions.go
+//
+// Insert, update and fetch functions for the sessions table.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// CreateSession inserts a new open session and returns its ID.
+func CreateSession(ctx context.Context, s types.Session) (int64, error) {
+	flags := []byte("{}")
+	if len(s.FeatureFlags) > 0 {
+		var err error
+		if flags, err = json.Marshal(s.FeatureFlags); err != nil {
+			return 0, err
+		}
+	}
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO sessions (name, started_at, start_reason, auto, feature_flags)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, s.Name, s.StartedAt, s.StartReason, s.Auto, string(flags)).Scan(&id)
+	return id, err
+}
+
+// EndSession closes an open session.
+func EndSession(ctx context.Context, id int64, endedAt time.Time, reason string) error {
+	_, err := DB.ExecContext(ctx, `
+		UPDATE sessions SET ended_at = $2, end_reason = $3
+		WHERE id = $1 AND ended_at IS NULL
+	`, id, endedAt, reason)
+	return err
+}
+
+// CloseOpenSessions closes every session left open (e.g. by a crash) and returns
+// how many were closed.
+func CloseOpenSessions(ctx context.Context, endedAt time.Time, reason string) (int64, error) {
+	res, err := DB.ExecContext(ctx, `
+		UPDATE sessions SET ended_at = GREATEST(started_at, $1), end_reason = $2
+		WHERE ended_at IS NULL
+	`, endedAt, reason)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// scanSession scans a sessions row.
+func scanSession(row interface{ Scan(...interface{}) error }) (types.Session, error) {
+	var s types.Session
+	var endedAt sql.NullTime
+	var endReason sql.NullString
+	var flags []byte
+	if err := row.Scan(&s.ID, &s.Name, &s.StartedAt, &endedAt, &s.StartReason, &endReason, &s.Auto, &flags); err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(flags, &s.FeatureFlags); err != nil {
+		return s, err
+	}
+	if endedAt.Valid {
+		t := endedAt.Time
+		s.EndedAt = &t
+	}
+	s.EndReason = endReason.String
+	return s, nil
+}
+
+// ScanSessionsPaginated calls fn for each row of sessions, oldest first.
+func (q *Queries) ScanSessionsPaginated(ctx context.Context, limit, offset int, fn func(types.Session) error) error {
+	query := `
+		SELECT id, name, started_at, ended_at, start_reason, end_reason, auto, feature_flags
+		FROM sessions
+		ORDER BY started_at ASC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := q.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FetchSession returns a single session by ID.
+func (q *Queries) FetchSession(ctx context.Context, id int64) (types.Session, error) {
+	return scanSession(q.db.QueryRowContext(ctx, `
+		SELECT id, name, started_at, ended_at, start_reason, end_reason, auto, feature_flags
+		FROM sessions
+		WHERE id = $1
+	`, id))
+}
@@ -0,0 +1,96 @@
+// shares.go
+//
+// Insert, lookup and revocation of share links.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"telem-system/pkg/types"
+)
+
+// shareLinkColumns lists the columns read by scanShareLink.
+const shareLinkColumns = `id, session_id, array_to_string(channels, ','), note, created_by, created_at, expires_at, revoked_at`
+
+// InsertShareLink stores a share link with the hash of its token and returns its ID.
+func InsertShareLink(ctx context.Context, l types.ShareLink, tokenHash []byte) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO share_links (token_hash, session_id, channels, note, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, tokenHash, l.SessionID, l.Channels, l.Note, l.CreatedBy, l.CreatedAt, l.ExpiresAt).Scan(&id)
+	return id, err
+}
+
+// FetchActiveShareLink returns the unexpired, unrevoked share link with the given
+// token hash. It reports false when there is none.
+func FetchActiveShareLink(ctx context.Context, tokenHash []byte) (types.ShareLink, bool, error) {
+	l, err := scanShareLink(DB.QueryRowContext(ctx, `
+		SELECT `+shareLinkColumns+`
+		FROM share_links
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > now()
+	`, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return l, false, nil
+	}
+	return l, err == nil, err
+}
+
+// ScanShareLinksPaginated calls fn for each row of share links, newest first.
+func (q *Queries) ScanShareLinksPaginated(ctx context.Context, limit, offset int, fn func(types.ShareLink) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+shareLinkColumns+`
+		FROM share_links
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		l, err := scanShareLink(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// RevokeShareLink revokes a share link. It reports false when the link does not
+// exist or was already revoked.
+func RevokeShareLink(ctx context.Context, id int64) (bool, error) {
+	res, err := DB.ExecContext(ctx, `
+		UPDATE share_links SET revoked_at = now()
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// scanShareLink reads a row selected with shareLinkColumns.
+func scanShareLink(row interface{ Scan(...interface{}) error }) (types.ShareLink, error) {
+	var l types.ShareLink
+	var channels string
+	var revokedAt sql.NullTime
+	if err := row.Scan(&l.ID, &l.SessionID, &channels, &l.Note, &l.CreatedBy, &l.CreatedAt, &l.ExpiresAt, &revokedAt); err != nil {
+		return l, err
+	}
+	if channels != "" {
+		l.Channels = strings.Split(channels, ",")
+	}
+	if revokedAt.Valid {
+		t := revokedAt.Time
+		l.RevokedAt = &t
+	}
+	return l, nil
+}
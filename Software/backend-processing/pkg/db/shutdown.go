@@ -0,0 +1,101 @@
+// shutdown.go
+//
+// Insert and fetch functions for shutdown circuit events.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// InsertShutdownEventsBatch inserts shutdown circuit events in a single transaction.
+func InsertShutdownEventsBatch(ctx context.Context, batch []types.ShutdownEvent) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO shutdown_events (timestamp, element, closed, value, trip_start, trip_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range batch {
+		if _, err := stmt.ExecContext(ctx, e.Timestamp, e.Element, e.Closed, e.Value, e.TripStart, e.TripOrder); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// queryShutdownEvents runs a query returning shutdown_events rows.
+func (q *Queries) queryShutdownEvents(ctx context.Context, query string, args ...interface{}) ([]types.ShutdownEvent, error) {
+	var data []types.ShutdownEvent
+	err := q.scanShutdownEvents(ctx, func(e types.ShutdownEvent) error {
+		data = append(data, e)
+		return nil
+	}, query, args...)
+	return data, err
+}
+
+// scanShutdownEvents runs a query returning shutdown_events rows and calls fn for
+// each row as it is scanned.
+func (q *Queries) scanShutdownEvents(ctx context.Context, fn func(types.ShutdownEvent) error, query string, args ...interface{}) error {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e types.ShutdownEvent
+		if err := rows.Scan(&e.Timestamp, &e.Element, &e.Closed, &e.Value, &e.TripStart, &e.TripOrder); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ScanShutdownEventsPaginated calls fn for each row of shutdown circuit events,
+// oldest first.
+func (q *Queries) ScanShutdownEventsPaginated(ctx context.Context, limit, offset int, fn func(types.ShutdownEvent) error) error {
+	return q.scanShutdownEvents(ctx, fn, `
+		SELECT timestamp, element, closed, value, trip_start, trip_order
+		FROM shutdown_events
+		ORDER BY timestamp ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+}
+
+// FetchShutdownEvents returns the shutdown circuit events within [from, to].
+func (q *Queries) FetchShutdownEvents(ctx context.Context, from, to time.Time) ([]types.ShutdownEvent, error) {
+	return q.queryShutdownEvents(ctx, `
+		SELECT timestamp, element, closed, value, trip_start, trip_order
+		FROM shutdown_events
+		WHERE timestamp >= $1 AND timestamp <= $2
+		ORDER BY timestamp ASC
+	`, from, to)
+}
+
+// FetchShutdownTripEvents returns the events of the trips that started within
+// [from, to], ordered by trip and position in the trip.
+func (q *Queries) FetchShutdownTripEvents(ctx context.Context, from, to time.Time) ([]types.ShutdownEvent, error) {
+	return q.queryShutdownEvents(ctx, `
+		SELECT timestamp, element, closed, value, trip_start, trip_order
+		FROM shutdown_events
+		WHERE trip_start >= $1 AND trip_start <= $2
+		ORDER BY trip_start ASC, timestamp ASC
+	`, from, to)
+}
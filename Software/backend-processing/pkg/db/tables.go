@@ -0,0 +1,181 @@
+// tables.go
+//
+// Table specifications for the CAN telemetry tables. The column order of every
+// spec matches the column order used by the corresponding batch insert statement,
+// which lets the insert layer map positional arguments to columns. The specs also
+// drive the schema generator and the storage codecs.
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column kinds as produced by the decoder
+const (
+	KindFloat = "float"
+	KindInt   = "int"
+)
+
+// ColumnSpec describes a single telemetry column (excluding the timestamp).
+type ColumnSpec struct {
+	Name string
+	Kind string
+}
+
+// TableSpec describes a telemetry table.
+type TableSpec struct {
+	Name    string
+	Columns []ColumnSpec
+}
+
+// floatCols returns float columns with the given names.
+func floatCols(names ...string) []ColumnSpec {
+	cols := make([]ColumnSpec, len(names))
+	for i, n := range names {
+		cols[i] = ColumnSpec{Name: n, Kind: KindFloat}
+	}
+	return cols
+}
+
+// intCols returns integer columns with the given names.
+func intCols(names ...string) []ColumnSpec {
+	cols := make([]ColumnSpec, len(names))
+	for i, n := range names {
+		cols[i] = ColumnSpec{Name: n, Kind: KindInt}
+	}
+	return cols
+}
+
+// numberedCols returns columns prefix1..prefixN of the given kind.
+func numberedCols(prefix string, n int, kind string) []ColumnSpec {
+	cols := make([]ColumnSpec, n)
+	for i := range cols {
+		cols[i] = ColumnSpec{Name: fmt.Sprintf("%s%d", prefix, i+1), Kind: kind}
+	}
+	return cols
+}
+
+// concatCols joins column groups in order.
+func concatCols(groups ...[]ColumnSpec) []ColumnSpec {
+	var cols []ColumnSpec
+	for _, g := range groups {
+		cols = append(cols, g...)
+	}
+	return cols
+}
+
+// TelemetryTables lists every CAN telemetry table in schema order.
+var TelemetryTables = []TableSpec{
+	{Name: "tcu1", Columns: concatCols(floatCols("apps1", "apps2", "bse"), intCols("status"))},
+	{Name: "tcu2", Columns: intCols("brake_light", "bamocar_rfe", "bamocar_frg")},
+	{Name: "cell_data", Columns: numberedCols("cell", 128, KindFloat)},
+	{Name: "therm_data", Columns: concatCols(intCols("thermistor_id"), numberedCols("therm", 16, KindFloat))},
+	{Name: "pack_current", Columns: floatCols("current")},
+	{Name: "pack_voltage", Columns: floatCols("voltage")},
+	{Name: "front_analog", Columns: concatCols(
+		intCols("left_rad", "right_rad"),
+		floatCols("front_right_pot", "front_left_pot", "rear_right_pot", "rear_left_pot", "steering_angle"),
+		intCols("analog8"),
+	)},
+	{Name: "rear_analog", Columns: numberedCols("analog", 8, KindInt)},
+	{Name: "front_strain_gauges_1", Columns: numberedCols("gauge", 6, KindInt)},
+	{Name: "front_strain_gauges_2", Columns: numberedCols("gauge", 6, KindInt)},
+	{Name: "rear_strain_gauges_1", Columns: numberedCols("gauge", 6, KindInt)},
+	{Name: "rear_strain_gauges_2", Columns: numberedCols("gauge", 6, KindInt)},
+	{Name: "front_aero", Columns: concatCols(numberedCols("pressure", 3, KindInt), numberedCols("temperature", 3, KindInt))},
+	{Name: "rear_aero", Columns: concatCols(numberedCols("pressure", 3, KindInt), numberedCols("temperature", 3, KindInt))},
+	{Name: "bamocar_rx_data", Columns: concatCols(intCols("regid"), numberedCols("byte", 5, KindInt))},
+	{Name: "bamocar_tx_data", Columns: intCols("regid", "data")},
+	{Name: "bamo_car_re_transmit", Columns: intCols("motor_temp", "controller_temp")},
+	{Name: "aculv_fd_1", Columns: concatCols(
+		intCols("ams_status", "fld"),
+		floatCols("state_of_charge", "accumulator_voltage", "tractive_voltage", "cell_current"),
+		intCols("isolation_monitoring"),
+		floatCols("isolation_monitoring1"),
+	)},
+	{Name: "aculv_fd_2", Columns: floatCols("fan_set_point", "rpm")},
+	{Name: "aculv1", Columns: floatCols("charge_status1", "charge_status2")},
+	{Name: "aculv2", Columns: intCols("charge_request")},
+	{Name: "gps_best_pos", Columns: concatCols(
+		floatCols("latitude", "longitude", "altitude", "std_latitude", "std_longitude", "std_altitude"),
+		intCols("gps_status"),
+	)},
+	{Name: "ins_gps", Columns: concatCols(intCols("gnss_week"), floatCols("gnss_seconds", "gnss_lat", "gnss_long", "gnss_height"))},
+	{Name: "ins_imu", Columns: concatCols(floatCols("north_vel", "east_vel", "up_vel", "roll", "pitch", "azimuth"), intCols("status"))},
+	{Name: "front_frequency", Columns: floatCols("rear_right", "front_right", "rear_left", "front_left")},
+	{Name: "rear_frequency", Columns: numberedCols("freq", 4, KindFloat)},
+	{Name: "pdm1", Columns: concatCols(
+		intCols("compound_id", "pdm_int_temperature"),
+		floatCols("pdm_batt_voltage"),
+		intCols("global_error_flag", "total_current"),
+		floatCols("internal_rail_voltage"),
+		intCols("reset_source"),
+	)},
+	{Name: "pdm_current", Columns: intCols(
+		"accumulator_current", "tcu_current", "bamocar_current", "pumps_current",
+		"tsal_current", "daq_current", "display_kvaser_current", "shutdown_reset_current",
+	)},
+	{Name: "pdm_re_transmit", Columns: concatCols(
+		intCols("pdm_int_temperature"),
+		floatCols("pdm_batt_voltage"),
+		intCols("global_error_flag", "total_current"),
+		floatCols("internal_rail_voltage"),
+		intCols("reset_source"),
+	)},
+	{Name: "encoder_data", Columns: numberedCols("encoder", 4, KindInt)},
+}
+
+// tableIndex maps table name to its spec.
+var tableIndex = func() map[string]*TableSpec {
+	m := make(map[string]*TableSpec, len(TelemetryTables))
+	for i := range TelemetryTables {
+		m[TelemetryTables[i].Name] = &TelemetryTables[i]
+	}
+	return m
+}()
+
+// LookupTable returns the spec for a telemetry table.
+func LookupTable(name string) (*TableSpec, bool) {
+	t, ok := tableIndex[name]
+	return t, ok
+}
+
+// Column returns the spec for a column of the table.
+func (t *TableSpec) Column(name string) (ColumnSpec, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ColumnSpec{}, false
+}
+
+// GenerateTelemetrySchema renders CREATE TABLE statements for every telemetry table,
+// using the column types selected by the configured storage codecs. Every column
+// except the timestamp is nullable: NULL marks a signal that was missing or failed
+// validation. Tables created from older schemas may declare NOT NULL columns, so
+// the constraint is dropped from existing tables as well. The seq column holds the
+// sequence number assigned by the batch writer (see types.RecordMeta) and is added
+// to existing tables; its index keeps MaxSequence from scanning the table.
+func GenerateTelemetrySchema() []string {
+	stmts := make([]string, 0, len(TelemetryTables)*4)
+	for _, t := range TelemetryTables {
+		var b, alter strings.Builder
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n\ttimestamp TIMESTAMPTZ NOT NULL", t.Name)
+		fmt.Fprintf(&alter, "ALTER TABLE %s", t.Name)
+		for i, c := range t.Columns {
+			fmt.Fprintf(&b, ",\n\t%s %s NULL", c.Name, columnSQLType(t.Name, c))
+			if i > 0 {
+				alter.WriteString(",")
+			}
+			fmt.Fprintf(&alter, "\n\tALTER COLUMN %s DROP NOT NULL", c.Name)
+		}
+		b.WriteString(",\n\tseq BIGINT\n)")
+		alter.WriteString(",\n\tADD COLUMN IF NOT EXISTS seq BIGINT")
+		stmts = append(stmts, b.String(), alter.String())
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_timestamp_idx ON %s (timestamp)", t.Name, t.Name))
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_seq_idx ON %s (seq)", t.Name, t.Name))
+	}
+	return stmts
+}
@@ -0,0 +1,140 @@
+// tccapture.go
+//
+// Insert and fetch functions for traction-control capture runs and frames.
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// tcCaptureRunColumns lists the columns read by scanTCCaptureRun.
+const tcCaptureRunColumns = `id, session_id, started_at, ended_at, array_to_string(frame_ids, ','), note, started_by, frames, dropped`
+
+// InsertTCCaptureRun stores a new capture run and returns its ID.
+func InsertTCCaptureRun(ctx context.Context, r types.TCCaptureRun) (int64, error) {
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO tc_capture_runs (session_id, started_at, frame_ids, note, started_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, r.SessionID, r.StartedAt, r.FrameIDs, r.Note, r.StartedBy).Scan(&id)
+	return id, err
+}
+
+// UpdateTCCaptureRun records the frame counters of a run and, when endedAt is
+// non-nil, its end.
+func UpdateTCCaptureRun(ctx context.Context, id int64, frames, dropped int64, endedAt *time.Time) error {
+	_, err := DB.ExecContext(ctx, `
+		UPDATE tc_capture_runs SET frames = $2, dropped = $3, ended_at = COALESCE($4, ended_at)
+		WHERE id = $1
+	`, id, frames, dropped, endedAt)
+	return err
+}
+
+// EndOpenTCCaptureRuns closes runs left open by a previous process.
+func EndOpenTCCaptureRuns(ctx context.Context) error {
+	_, err := DB.ExecContext(ctx, `
+		UPDATE tc_capture_runs SET ended_at = now() WHERE ended_at IS NULL
+	`)
+	return err
+}
+
+// InsertTCCaptureFramesBatch inserts captured frames in a single transaction.
+func InsertTCCaptureFramesBatch(ctx context.Context, batch []types.TCCaptureFrame) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO tc_capture_frames (run_id, captured_at, frame_id, data, signals)
+		VALUES ($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range batch {
+		signals, err := json.Marshal(f.Signals)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, f.RunID, f.CapturedAt, int64(f.FrameID), f.Data, string(signals)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ScanTCCaptureRunsPaginated calls fn for each row of capture runs, newest first.
+func (q *Queries) ScanTCCaptureRunsPaginated(ctx context.Context, limit, offset int, fn func(types.TCCaptureRun) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+tcCaptureRunColumns+`
+		FROM tc_capture_runs
+		ORDER BY started_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r types.TCCaptureRun
+		var frameIDs string
+		if err := rows.Scan(&r.ID, &r.SessionID, &r.StartedAt, &r.EndedAt, &frameIDs, &r.Note, &r.StartedBy, &r.Frames, &r.Dropped); err != nil {
+			return err
+		}
+		for _, s := range strings.Split(frameIDs, ",") {
+			if id, err := strconv.Atoi(s); err == nil {
+				r.FrameIDs = append(r.FrameIDs, id)
+			}
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ScanTCCaptureFramesPaginated calls fn for each row of the frames of a run in
+// capture order, optionally limited to one frame ID (frameID < 0 selects all).
+func (q *Queries) ScanTCCaptureFramesPaginated(ctx context.Context, runID int64, frameID int64, limit, offset int, fn func(types.TCCaptureFrame) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT run_id, captured_at, frame_id, data, signals
+		FROM tc_capture_frames
+		WHERE run_id = $1 AND ($2 < 0 OR frame_id = $2)
+		ORDER BY captured_at, frame_id
+		LIMIT $3 OFFSET $4
+	`, runID, frameID, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f types.TCCaptureFrame
+		var id int64
+		var signals []byte
+		if err := rows.Scan(&f.RunID, &f.CapturedAt, &id, &f.Data, &signals); err != nil {
+			return err
+		}
+		f.FrameID = uint32(id)
+		if err := json.Unmarshal(signals, &f.Signals); err != nil {
+			return err
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,43 @@
+// vehiclestate.go
+//
+// Insert and fetch functions for vehicle state transitions.
+package db
+
+import (
+	"context"
+	"telem-system/pkg/types"
+)
+
+// InsertVehicleStateChange stores a vehicle state transition.
+func InsertVehicleStateChange(ctx context.Context, c types.VehicleStateChange) error {
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO vehicle_state_events (timestamp, state, previous)
+		VALUES ($1, $2, $3)
+	`, c.Timestamp, c.State, c.Previous)
+	return err
+}
+
+// ScanVehicleStateEventsPaginated calls fn for each row of vehicle state
+// transitions, oldest first.
+func (q *Queries) ScanVehicleStateEventsPaginated(ctx context.Context, limit, offset int, fn func(types.VehicleStateChange) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT timestamp, state, previous
+		FROM vehicle_state_events
+		ORDER BY timestamp ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c types.VehicleStateChange
+		if err := rows.Scan(&c.Timestamp, &c.State, &c.Previous); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,99 @@
+// wsprofiles.go
+//
+// Storage of live subscription profiles.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"telem-system/pkg/types"
+)
+
+// wsProfileColumns lists the columns read by scanWSProfile.
+const wsProfileColumns = `name, description, array_to_string(channels, ','), max_rate, format, updated_by, updated_at`
+
+// UpsertWSProfile creates or replaces a subscription profile.
+func UpsertWSProfile(ctx context.Context, p types.WSProfile) error {
+	channels := p.Channels
+	if channels == nil {
+		channels = []string{}
+	}
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO ws_profiles (name, description, channels, max_rate, format, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (name) DO UPDATE SET
+			description = EXCLUDED.description,
+			channels    = EXCLUDED.channels,
+			max_rate    = EXCLUDED.max_rate,
+			format      = EXCLUDED.format,
+			updated_by  = EXCLUDED.updated_by,
+			updated_at  = EXCLUDED.updated_at
+	`, p.Name, p.Description, channels, p.MaxRate, p.Format, p.UpdatedBy, p.UpdatedAt)
+	return err
+}
+
+// FetchWSProfile returns the named subscription profile. It reports false when
+// there is none.
+func FetchWSProfile(ctx context.Context, name string) (types.WSProfile, bool, error) {
+	p, err := scanWSProfile(DB.QueryRowContext(ctx, `
+		SELECT `+wsProfileColumns+`
+		FROM ws_profiles
+		WHERE name = $1
+	`, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, false, nil
+	}
+	return p, err == nil, err
+}
+
+// ScanWSProfilesPaginated calls fn for each row of subscription profiles ordered
+// by name.
+func (q *Queries) ScanWSProfilesPaginated(ctx context.Context, limit, offset int, fn func(types.WSProfile) error) error {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+wsProfileColumns+`
+		FROM ws_profiles
+		ORDER BY name
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		p, err := scanWSProfile(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DeleteWSProfile deletes a subscription profile. It reports false when the
+// profile does not exist.
+func DeleteWSProfile(ctx context.Context, name string) (bool, error) {
+	res, err := DB.ExecContext(ctx, `DELETE FROM ws_profiles WHERE name = $1`, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// scanWSProfile reads a row selected with wsProfileColumns.
+func scanWSProfile(row interface{ Scan(...interface{}) error }) (types.WSProfile, error) {
+	var p types.WSProfile
+	var channels string
+	if err := row.Scan(&p.Name, &p.Description, &channels, &p.MaxRate, &p.Format, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+		return p, err
+	}
+	p.Channels = []string{}
+	if channels != "" {
+		p.Channels = strings.Split(channels, ",")
+	}
+	return p, nil
+}
@@ -0,0 +1,350 @@
+// exportcache.go
+//
+// Package exportcache generates large exports in the background and keeps the
+// resulting files on disk under a job ID, so a client can download them with
+// HTTP range requests and resume an interrupted transfer instead of having the
+// export generated again. Identical requests share a job while it is pending or
+// its file is still cached. Finished files are removed once they expire or when
+// the cache grows beyond its size limit, oldest first. Jobs are kept in memory;
+// files left over from a previous run are removed by Configure. Only files named
+// like the cache's own (a job ID, optionally with the partial file suffix) are
+// touched, so the directory may be shared.
+package exportcache
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job statuses
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+const (
+	// Defaults, overridable through Config
+	defaultTTL           = 24 * time.Hour
+	defaultMaxBytes      = 20 << 30 // 20 GiB
+	defaultMaxConcurrent = 2
+	defaultTimeout       = 2 * time.Hour
+
+	// Suffix of files still being generated
+	partSuffix = ".part"
+
+	// Random bytes of a job ID, which is their hex encoding and the file name
+	idBytes = 12
+)
+
+var (
+	// ErrNotFound is returned for unknown or expired jobs.
+	ErrNotFound = errors.New("export job not found")
+
+	// ErrNotReady is returned when opening a job that has not finished.
+	ErrNotReady = errors.New("export is not ready")
+
+	// ErrDisabled is returned by Start when no cache directory is configured.
+	ErrDisabled = errors.New("export cache is disabled")
+)
+
+// Config holds the cache location and limits.
+type Config struct {
+	Dir           string        // Cache directory (empty disables the cache)
+	TTL           time.Duration // How long a finished file is kept
+	MaxBytes      int64         // Total size of cached files before the oldest are evicted
+	MaxConcurrent int           // Exports generated at the same time
+	Timeout       time.Duration // Limit on generating a single export
+}
+
+// Job is a background export.
+type Job struct {
+	ID          string     `json:"id"`
+	File        string     `json:"file"` // Download file name
+	ContentType string     `json:"content_type"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Size        int64      `json:"size"` // Bytes written so far
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // Set once finished
+
+	key  string
+	path string
+}
+
+// Generator writes the export to w. It must return when ctx is cancelled.
+type Generator func(ctx context.Context, w io.Writer) error
+
+// cache holds the jobs.
+type cache struct {
+	mu    sync.Mutex
+	cfg   Config
+	jobs  map[string]*Job
+	byKey map[string]string // Request key -> job ID
+	slots chan struct{}     // Limits concurrent generation
+}
+
+var c = &cache{jobs: make(map[string]*Job), byKey: make(map[string]string)}
+
+// withDefaults fills non-positive values with the defaults.
+func withDefaults(cfg Config) Config {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultMaxBytes
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = defaultMaxConcurrent
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return cfg
+}
+
+// Configure sets up the cache directory, removing cache files left by a previous
+// run; other files in the directory are kept. Non-positive limits select the
+// defaults. It must be called before Start.
+func Configure(cfg Config) error {
+	cfg = withDefaults(cfg)
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return fmt.Errorf("creating export cache directory: %w", err)
+		}
+		entries, err := os.ReadDir(cfg.Dir)
+		if err != nil {
+			return fmt.Errorf("reading export cache directory: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isCacheFile(e.Name()) {
+				os.Remove(filepath.Join(cfg.Dir, e.Name()))
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.cfg = cfg
+	c.slots = make(chan struct{}, cfg.MaxConcurrent)
+	c.mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether a cache directory is configured.
+func Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg.Dir != ""
+}
+
+// Start returns the job for key, starting gen in the background when there is no
+// pending or cached job for it yet. key identifies the request (e.g. its
+// parameters); file and contentType describe the download.
+func Start(key, file, contentType string, gen Generator) (Job, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cfg.Dir == "" {
+		return Job{}, ErrDisabled
+	}
+	if id, ok := c.byKey[key]; ok {
+		if j, ok := c.jobs[id]; ok && j.Status != StatusFailed {
+			return *j, nil
+		}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	j := &Job{
+		ID:          id,
+		File:        file,
+		ContentType: contentType,
+		Status:      StatusPending,
+		CreatedAt:   time.Now(),
+		key:         key,
+		path:        filepath.Join(c.cfg.Dir, id),
+	}
+	c.jobs[id] = j
+	c.byKey[key] = id
+	go c.run(j, gen, c.cfg.Timeout, c.slots)
+	return *j, nil
+}
+
+// Get returns a job by ID.
+func Get(id string) (Job, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	j, ok := c.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// Jobs returns all jobs, newest first.
+func Jobs() []Job {
+	c.mu.Lock()
+	out := make([]Job, 0, len(c.jobs))
+	for _, j := range c.jobs {
+		out = append(out, *j)
+	}
+	c.mu.Unlock()
+	sort.Slice(out, func(i, k int) bool { return out[i].CreatedAt.After(out[k].CreatedAt) })
+	return out
+}
+
+// Open opens the file of a finished job. The file stays readable until it is
+// closed, even if the job expires in the meantime.
+func Open(id string) (*os.File, Job, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	j, ok := c.jobs[id]
+	if !ok {
+		return nil, Job{}, ErrNotFound
+	}
+	if j.Status != StatusReady {
+		return nil, *j, ErrNotReady
+	}
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, *j, err
+	}
+	return f, *j, nil
+}
+
+// Cleanup removes expired jobs and their files, then evicts the oldest finished
+// files while the cache exceeds its size limit.
+func Cleanup(ctx context.Context) error {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ready []*Job
+	var total int64
+	for _, j := range c.jobs {
+		if j.ExpiresAt != nil && now.After(*j.ExpiresAt) {
+			c.remove(j)
+			continue
+		}
+		if j.Status == StatusReady {
+			ready = append(ready, j)
+		}
+		total += j.Size
+	}
+	sort.Slice(ready, func(i, k int) bool { return ready[i].FinishedAt.Before(*ready[k].FinishedAt) })
+	for _, j := range ready {
+		if total <= c.cfg.MaxBytes {
+			break
+		}
+		total -= j.Size
+		c.remove(j)
+	}
+	return nil
+}
+
+// remove drops a job and deletes its file. The caller holds c.mu.
+func (c *cache) remove(j *Job) {
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Error removing cached export %s: %v", j.ID, err)
+	}
+	delete(c.jobs, j.ID)
+	if c.byKey[j.key] == j.ID {
+		delete(c.byKey, j.key)
+	}
+}
+
+// run generates the file of a job once a slot is free.
+func (c *cache) run(j *Job, gen Generator, timeout time.Duration, slots chan struct{}) {
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := c.generate(ctx, j, gen)
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := now.Add(c.cfg.TTL)
+	j.FinishedAt, j.ExpiresAt = &now, &expires
+	if err != nil {
+		j.Status, j.Error, j.Size = StatusFailed, err.Error(), 0
+		log.Printf("Export %s (%s) failed: %v", j.ID, j.File, err)
+		return
+	}
+	j.Status = StatusReady
+	log.Printf("Export %s (%s) ready, %d bytes", j.ID, j.File, j.Size)
+}
+
+// generate writes the file to a temporary name and moves it into place once
+// complete, so a partial file is never served.
+func (c *cache) generate(ctx context.Context, j *Job, gen Generator) error {
+	part := j.path + partSuffix
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriterSize(&progressWriter{w: f, job: j}, 1<<20)
+	err = gen(ctx, bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(part, j.path)
+	}
+	if err != nil {
+		os.Remove(part)
+	}
+	return err
+}
+
+// progressWriter counts the bytes written to a job's file.
+type progressWriter struct {
+	w   io.Writer
+	job *Job
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	c.mu.Lock()
+	p.job.Size += int64(n)
+	c.mu.Unlock()
+	return n, err
+}
+
+// newID returns a random job ID.
+func newID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating export job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isCacheFile reports whether a file name is one the cache writes: a job ID, or a
+// job ID with partSuffix for an export still being generated.
+func isCacheFile(name string) bool {
+	name = strings.TrimSuffix(name, partSuffix)
+	if len(name) != hex.EncodedLen(idBytes) {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
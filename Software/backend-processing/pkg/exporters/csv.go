@@ -0,0 +1,115 @@
+// csv.go
+//
+// CSV export: a timestamp column (RFC 3339, UTC) followed by the dataset
+// columns, with an optional markers column holding the alerts and annotations.
+package exporters
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"telem-system/pkg/types"
+	"time"
+)
+
+type csvExporter struct{}
+
+func (csvExporter) Format() Format {
+	return Format{
+		Name:         "csv",
+		Description:  "Comma-separated values with RFC 3339 timestamps",
+		Extension:    ".csv",
+		ContentType:  "text/csv",
+		Streaming:    true,
+		MarkerColumn: true,
+	}
+}
+
+// Export writes the rows. Each marker edge is attached to the first row at or
+// after its time; edges after the last row get rows of their own with empty
+// values.
+func (csvExporter) Export(ctx context.Context, w io.Writer, ds Dataset) error {
+	cw := csv.NewWriter(w)
+	var events []markerEvent
+	if ds.MarkerColumn {
+		events = markerEvents(ds.Markers)
+	}
+
+	header := make([]string, 0, len(ds.Columns)+2)
+	header = append(header, "timestamp")
+	header = append(header, ds.Columns...)
+	if ds.MarkerColumn {
+		header = append(header, "markers")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	err := ds.Rows(ctx, func(t time.Time, values []float64) error {
+		record[0] = t.UTC().Format(time.RFC3339Nano)
+		for i, v := range values {
+			if math.IsNaN(v) {
+				record[i+1] = "" // Missing signal
+				continue
+			}
+			record[i+1] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		if ds.MarkerColumn {
+			var texts []string
+			for len(events) > 0 && !events[0].t.After(t) {
+				texts = append(texts, events[0].text)
+				events = events[1:]
+			}
+			record[len(record)-1] = strings.Join(texts, " | ")
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Events after the last data row
+	for _, e := range events {
+		for i := range record {
+			record[i] = ""
+		}
+		record[0] = e.t.UTC().Format(time.RFC3339Nano)
+		record[len(record)-1] = e.text
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markerEvent is a single marker edge placed in the markers column.
+type markerEvent struct {
+	t    time.Time
+	text string
+}
+
+// markerEvents turns markers into start and end events ordered by time.
+func markerEvents(markers []types.Marker) []markerEvent {
+	events := make([]markerEvent, 0, 2*len(markers))
+	for _, m := range markers {
+		label := m.Kind
+		if m.Severity != "" {
+			label += " " + m.Severity
+		}
+		if m.Label != "" {
+			label += " " + m.Label
+		}
+		events = append(events, markerEvent{t: m.Time, text: label + ": " + m.Text})
+		if m.End != nil {
+			events = append(events, markerEvent{t: *m.End, text: label + " end"})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].t.Before(events[j].t) })
+	return events
+}
@@ -0,0 +1,39 @@
+package exporters
+
+import (
+	"telem-system/pkg/types"
+	"testing"
+	"time"
+)
+
+func TestCSVExport(t *testing.T) {
+	got := string(export(t, "csv", testDataset()))
+	want := "timestamp,voltage,current,cell 1\n" +
+		"2024-05-01T12:00:00Z,400.5,-12.25,3.7\n" +
+		"2024-05-01T12:00:00.25Z,,0,3.65\n" +
+		"2024-05-01T12:00:01.5Z,399.75,150.125,\n"
+	if got != want {
+		t.Fatalf("export =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCSVExportMarkers(t *testing.T) {
+	end := testStart.Add(500 * time.Millisecond)
+	after := testStart.Add(3 * time.Second)
+	ds := testDataset()
+	ds.MarkerColumn = true
+	ds.Markers = []types.Marker{
+		{Time: testStart.Add(100 * time.Millisecond), End: &end, Kind: "alert", Severity: "warning", Label: "pack_temp", Text: "Pack hot"},
+		{Time: after, Kind: "annotation", Label: "alice", Text: "Box, box"},
+	}
+
+	got := string(export(t, "csv", ds))
+	want := "timestamp,voltage,current,cell 1,markers\n" +
+		"2024-05-01T12:00:00Z,400.5,-12.25,3.7,\n" +
+		"2024-05-01T12:00:00.25Z,,0,3.65,alert warning pack_temp: Pack hot\n" +
+		"2024-05-01T12:00:01.5Z,399.75,150.125,,alert warning pack_temp end\n" +
+		"2024-05-01T12:00:03Z,,,,\"annotation alice: Box, box\"\n"
+	if got != want {
+		t.Fatalf("export =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+// exporters.go
+//
+// Package exporters holds the export file formats. Every format implements
+// Exporter and is registered under its name, which is what clients pass as the
+// format query parameter; Formats lists the registered formats and their
+// capabilities. An exporter receives a Dataset (columns of float64 samples at
+// shared timestamps, read in time order) and writes it in its format, so the
+// export endpoints do not depend on any particular format and adding one is a
+// single new Exporter.
+//
+// Streaming formats write rows as they are read. The others need the whole export
+// in memory (e.g. column-major files or files whose headers hold the row count)
+// and fail with ErrTooLarge beyond MaxBufferedValues.
+package exporters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// MaxBufferedValues is the largest export, in values of all columns combined,
+// that a non-streaming format holds in memory.
+const MaxBufferedValues = 20000000
+
+// ErrTooLarge is returned by non-streaming exporters for datasets exceeding
+// MaxBufferedValues.
+var ErrTooLarge = fmt.Errorf("export exceeds %d values; narrow the range, select fewer channels or use a streaming format", MaxBufferedValues)
+
+// Format describes an export format and what it supports.
+type Format struct {
+	Name         string `json:"name"` // Value of the format query parameter
+	Description  string `json:"description"`
+	Extension    string `json:"extension"` // File extension including the dot
+	ContentType  string `json:"content_type"`
+	Streaming    bool   `json:"streaming"`     // Rows are written as they are read; otherwise limited to MaxBufferedValues
+	MarkerColumn bool   `json:"marker_column"` // Can embed alerts and annotations as a column (markers=channel)
+}
+
+// Dataset is the data of an export: float64 columns sampled at shared
+// timestamps. NaN marks a value missing from a row.
+type Dataset struct {
+	Name    string   // Base name of the export, e.g. "pack_voltage_20240501T120000Z"
+	Table   string   // Source table of table exports; empty for channel exports
+	Columns []string // Column names of a table, or channel IDs
+
+	// Markers are embedded as a column when MarkerColumn is set (formats with
+	// Format.MarkerColumn only).
+	Markers      []types.Marker
+	MarkerColumn bool
+
+	// Rows calls fn for every row in time order. values follows Columns and is
+	// reused between calls. Rows can be called once.
+	Rows func(ctx context.Context, fn func(t time.Time, values []float64) error) error
+}
+
+// Channel returns the channel ID of column i: "table.column" for table exports,
+// the column itself for channel exports.
+func (d Dataset) Channel(i int) string {
+	if d.Table != "" {
+		return d.Table + "." + d.Columns[i]
+	}
+	return d.Columns[i]
+}
+
+// Exporter writes datasets in one format.
+type Exporter interface {
+	Format() Format
+
+	// Export writes ds to w. It must return when ctx is cancelled.
+	Export(ctx context.Context, w io.Writer, ds Dataset) error
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Exporter)
+)
+
+// Register adds an exporter under its format name.
+func Register(e Exporter) error {
+	f := e.Format()
+	if f.Name == "" || f.Extension == "" || f.ContentType == "" {
+		return errors.New("exporter needs a name, an extension and a content type")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := registry[f.Name]; dup {
+		return fmt.Errorf("export format %s is already registered", f.Name)
+	}
+	registry[f.Name] = e
+	return nil
+}
+
+// Lookup returns the exporter of a format.
+func Lookup(name string) (Exporter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := registry[name]
+	return e, ok
+}
+
+// Formats lists the registered formats by name.
+func Formats() []Format {
+	mu.RLock()
+	out := make([]Format, 0, len(registry))
+	for _, e := range registry {
+		out = append(out, e.Format())
+	}
+	mu.RUnlock()
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	return out
+}
+
+func init() {
+	for _, e := range []Exporter{csvExporter{}, jsonExporter{}, jsonExporter{lines: true}, parquetExporter{}, mf4Exporter{}, matExporter{}, rosbagExporter{}} {
+		if err := Register(e); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// bufferRows reads all rows of ds into memory, column by column, failing with
+// ErrTooLarge beyond MaxBufferedValues.
+func bufferRows(ctx context.Context, ds Dataset) ([]time.Time, [][]float64, error) {
+	var times []time.Time
+	cols := make([][]float64, len(ds.Columns))
+	perRow := len(ds.Columns) + 1
+	err := ds.Rows(ctx, func(t time.Time, values []float64) error {
+		if (len(times)+1)*perRow > MaxBufferedValues {
+			return ErrTooLarge
+		}
+		times = append(times, t)
+		for i, v := range values {
+			cols[i] = append(cols[i], v)
+		}
+		return nil
+	})
+	return times, cols, err
+}
@@ -0,0 +1,81 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// testStart is the time of the first row of testDataset.
+var testStart = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// testTimes and testValues are the rows of testDataset: three columns, with a
+// missing value in each value column and a name MATLAB does not accept.
+var (
+	testColumns = []string{"voltage", "current", "cell 1"}
+	testTimes   = []time.Time{testStart, testStart.Add(250 * time.Millisecond), testStart.Add(1500 * time.Millisecond)}
+	testValues  = [][]float64{
+		{400.5, -12.25, 3.7},
+		{math.NaN(), 0, 3.65},
+		{399.75, 150.125, math.NaN()},
+	}
+)
+
+// testDataset returns a table dataset over testTimes and testValues.
+func testDataset() Dataset {
+	return Dataset{
+		Name:    "pack_20240501T120000Z",
+		Table:   "pack",
+		Columns: testColumns,
+		Rows: func(ctx context.Context, fn func(t time.Time, values []float64) error) error {
+			values := make([]float64, len(testColumns))
+			for r, t := range testTimes {
+				copy(values, testValues[r])
+				if err := fn(t, values); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// export runs the exporter of a format on ds.
+func export(t *testing.T, format string, ds Dataset) []byte {
+	t.Helper()
+	e, ok := Lookup(format)
+	if !ok {
+		t.Fatalf("format %s is not registered", format)
+	}
+	var buf bytes.Buffer
+	if err := e.Export(context.Background(), &buf, ds); err != nil {
+		t.Fatalf("export %s: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+// sameValue reports whether got equals want, treating NaN as equal to NaN.
+func sameValue(got, want float64) bool {
+	return got == want || math.IsNaN(got) && math.IsNaN(want)
+}
+
+func TestFormatsRegistered(t *testing.T) {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name)
+	}
+	want := []string{"csv", "json", "mat", "mf4", "ndjson", "parquet", "rosbag"}
+	if len(names) != len(want) {
+		t.Fatalf("formats = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("formats = %v, want %v", names, want)
+		}
+	}
+	if err := Register(csvExporter{}); err == nil {
+		t.Fatal("registering csv twice succeeded")
+	}
+}
@@ -0,0 +1,132 @@
+// json.go
+//
+// JSON exports: one object per row with a timestamp field (RFC 3339, UTC)
+// followed by the dataset columns, and an optional markers field holding the
+// alerts and annotations. "json" writes the rows as a single array, "ndjson" one
+// row per line. Rows are encoded as they are read, so memory use does not grow
+// with the export.
+package exporters
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type jsonExporter struct {
+	lines bool // One object per line instead of an array
+}
+
+func (e jsonExporter) Format() Format {
+	if e.lines {
+		return Format{
+			Name:         "ndjson",
+			Description:  "Newline-delimited JSON, one object per row",
+			Extension:    ".ndjson",
+			ContentType:  "application/x-ndjson",
+			Streaming:    true,
+			MarkerColumn: true,
+		}
+	}
+	return Format{
+		Name:         "json",
+		Description:  "JSON array of row objects with RFC 3339 timestamps",
+		Extension:    ".json",
+		ContentType:  "application/json",
+		Streaming:    true,
+		MarkerColumn: true,
+	}
+}
+
+// Export writes the rows. Missing values are null. Marker edges are attached as
+// in CSV exports: to the first row at or after their time, or to rows of their
+// own after the last data row.
+func (e jsonExporter) Export(ctx context.Context, w io.Writer, ds Dataset) error {
+	bw := bufio.NewWriterSize(w, 64<<10)
+	var events []markerEvent
+	if ds.MarkerColumn {
+		events = markerEvents(ds.Markers)
+	}
+
+	// Field names are quoted once; rows are appended to a reused buffer
+	keys := make([][]byte, len(ds.Columns))
+	for i, c := range ds.Columns {
+		key, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		keys[i] = append(append([]byte{','}, key...), ':')
+	}
+	sep, open, end := []byte(","), []byte("["), []byte("]\n")
+	if e.lines {
+		sep, open, end = nil, nil, nil
+	}
+	if _, err := bw.Write(open); err != nil {
+		return err
+	}
+
+	var buf []byte
+	n := 0
+	writeRow := func(t time.Time, values []float64, markers []string) error {
+		buf = buf[:0]
+		if n > 0 {
+			buf = append(buf, sep...)
+		}
+		n++
+		buf = append(buf, `{"timestamp":"`...)
+		buf = t.UTC().AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"')
+		for i, v := range values {
+			buf = append(buf, keys[i]...)
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				buf = append(buf, "null"...) // Missing signal
+				continue
+			}
+			buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
+		}
+		if ds.MarkerColumn {
+			text, err := json.Marshal(strings.Join(markers, " | "))
+			if err != nil {
+				return err
+			}
+			buf = append(buf, `,"markers":`...)
+			buf = append(buf, text...)
+		}
+		buf = append(buf, "}\n"...)
+		_, err := bw.Write(buf)
+		return err
+	}
+
+	var texts []string
+	err := ds.Rows(ctx, func(t time.Time, values []float64) error {
+		texts = texts[:0]
+		for len(events) > 0 && !events[0].t.After(t) {
+			texts = append(texts, events[0].text)
+			events = events[1:]
+		}
+		return writeRow(t, values, texts)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Events after the last data row
+	empty := make([]float64, len(ds.Columns))
+	for i := range empty {
+		empty[i] = math.NaN()
+	}
+	for _, ev := range events {
+		if err := writeRow(ev.t, empty, []string{ev.text}); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.Write(end); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
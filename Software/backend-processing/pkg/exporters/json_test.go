@@ -0,0 +1,38 @@
+package exporters
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestJSONExport(t *testing.T) {
+	rows := `{"timestamp":"2024-05-01T12:00:00Z","voltage":400.5,"current":-12.25,"cell 1":3.7}
+{"timestamp":"2024-05-01T12:00:00.25Z","voltage":null,"current":0,"cell 1":3.65}
+{"timestamp":"2024-05-01T12:00:01.5Z","voltage":399.75,"current":150.125,"cell 1":null}
+`
+	if got := string(export(t, "ndjson", testDataset())); got != rows {
+		t.Fatalf("ndjson export =\n%s\nwant\n%s", got, rows)
+	}
+
+	got := export(t, "json", testDataset())
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("json export is not a JSON array: %v\n%s", err, got)
+	}
+	if len(decoded) != len(testTimes) {
+		t.Fatalf("json export has %d rows, want %d", len(decoded), len(testTimes))
+	}
+	for r, row := range decoded {
+		for i, c := range testColumns {
+			want := testValues[r][i]
+			got, ok := row[c].(float64)
+			if !ok {
+				got = math.NaN() // null
+			}
+			if row[c] != nil && !ok || !sameValue(got, want) {
+				t.Errorf("row %d: %s = %v, want %g", r, c, row[c], want)
+			}
+		}
+	}
+}
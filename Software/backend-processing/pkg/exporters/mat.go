@@ -0,0 +1,139 @@
+// mat.go
+//
+// MATLAB export as a Level 5 MAT-file. The file holds a column vector t with the
+// sample times in seconds since the Unix epoch (UTC) and one column vector per
+// dataset column, named after the column with characters MATLAB does not accept
+// in variable names replaced by underscores. Missing values are NaN.
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// MAT-file data types and array classes used here
+	matINT8        = 1
+	matINT32       = 5
+	matUINT32      = 6
+	matDOUBLE      = 9
+	matMATRIX      = 14
+	matDoubleClass = 6
+
+	// Longest MATLAB variable name
+	matMaxName = 63
+)
+
+type matExporter struct{}
+
+func (matExporter) Format() Format {
+	return Format{
+		Name:        "mat",
+		Description: "MATLAB Level 5 MAT-file with a time vector t (Unix seconds) and a vector per channel",
+		Extension:   ".mat",
+		ContentType: "application/x-matlab-data",
+	}
+}
+
+func (matExporter) Export(ctx context.Context, w io.Writer, ds Dataset) error {
+	times, cols, err := bufferRows(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 128)
+	copy(header, fmt.Sprintf("MATLAB 5.0 MAT-file, Platform: GLNXA64, Created on: %s", time.Now().UTC().Format("Mon Jan 2 15:04:05 2006")))
+	for i := len(strings.TrimRight(string(header[:116]), "\x00")); i < 116; i++ {
+		header[i] = ' '
+	}
+	binary.LittleEndian.PutUint16(header[124:], 0x0100)
+	copy(header[126:], "IM")
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	t := make([]float64, len(times))
+	for i, ts := range times {
+		t[i] = float64(ts.UnixNano()) / 1e9
+	}
+	if err := writeMatVector(w, "t", t); err != nil {
+		return err
+	}
+	used := map[string]bool{"t": true}
+	for i, c := range cols {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeMatVector(w, matName(ds.Columns[i], used), c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMatVector writes a double column vector variable.
+func writeMatVector(w io.Writer, name string, values []float64) error {
+	namePad := (8 - len(name)%8) % 8
+	size := 16 + 16 + 8 + len(name) + namePad + 8 + 8*len(values)
+	var b bytes.Buffer
+	put := func(v ...uint32) {
+		for _, x := range v {
+			binary.Write(&b, binary.LittleEndian, x)
+		}
+	}
+	put(matMATRIX, uint32(size))
+	put(matUINT32, 8, matDoubleClass, 0)     // Array flags
+	put(matINT32, 8, uint32(len(values)), 1) // Dimensions: n x 1
+	put(matINT8, uint32(len(name)))          // Array name
+	b.WriteString(name)
+	b.Write(make([]byte, namePad))
+	put(matDOUBLE, uint32(8*len(values)))
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, 64*1024)
+	for _, v := range values {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		if len(buf) == cap(buf) {
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// matName turns a column name into a unique MATLAB variable name.
+func matName(column string, used map[string]bool) string {
+	var sb strings.Builder
+	for _, r := range column {
+		if r < 128 && (r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	name := sb.String()
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		name = "x" + name
+	}
+	if len(name) > matMaxName {
+		name = name[:matMaxName]
+	}
+	base := name
+	for n := 2; used[name]; n++ {
+		suffix := fmt.Sprintf("_%d", n)
+		name = base[:min(len(base), matMaxName-len(suffix))] + suffix
+	}
+	used[name] = true
+	return name
+}
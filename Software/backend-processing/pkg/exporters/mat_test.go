@@ -0,0 +1,112 @@
+package exporters
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// matTestVariable is a double column vector read back from an exported file.
+type matTestVariable struct {
+	name   string
+	values []float64
+}
+
+// readMatVariables parses the column vectors written by writeMatVector.
+func readMatVariables(t *testing.T, file []byte) []matTestVariable {
+	t.Helper()
+	u32 := func(b []byte) uint32 { return binary.LittleEndian.Uint32(b) }
+	var out []matTestVariable
+	for b := file[128:]; len(b) > 0; {
+		if len(b) < 8 || u32(b) != matMATRIX {
+			t.Fatalf("variable %d: not a matrix element", len(out)+1)
+		}
+		size := int(u32(b[4:]))
+		if 8+size > len(b) {
+			t.Fatalf("variable %d: %d bytes overrun the file", len(out)+1, size)
+		}
+		el := b[8 : 8+size]
+		b = b[8+size:]
+
+		if u32(el) != matUINT32 || u32(el[4:]) != 8 || el[8] != matDoubleClass {
+			t.Fatalf("variable %d: array flags are not a double array", len(out)+1)
+		}
+		if u32(el[16:]) != matINT32 || u32(el[20:]) != 8 || u32(el[28:]) != 1 {
+			t.Fatalf("variable %d: not a column vector", len(out)+1)
+		}
+		rows := int(u32(el[24:]))
+		if u32(el[32:]) != matINT8 {
+			t.Fatalf("variable %d: name is not int8", len(out)+1)
+		}
+		nameLen := int(u32(el[36:]))
+		v := matTestVariable{name: string(el[40 : 40+nameLen])}
+		el = el[40+(nameLen+7)/8*8:]
+		if u32(el) != matDOUBLE || int(u32(el[4:])) != 8*rows || len(el) != 8+8*rows {
+			t.Fatalf("variable %s: data is not %d doubles", v.name, rows)
+		}
+		for i := 0; i < rows; i++ {
+			v.values = append(v.values, math.Float64frombits(binary.LittleEndian.Uint64(el[8+8*i:])))
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestMatRoundTrip(t *testing.T) {
+	file := export(t, "mat", testDataset())
+
+	if len(file) < 128 || !strings.HasPrefix(string(file), "MATLAB 5.0 MAT-file") {
+		t.Fatal("missing MAT-file header")
+	}
+	if v := binary.LittleEndian.Uint16(file[124:]); v != 0x0100 || string(file[126:128]) != "IM" {
+		t.Fatalf("header version %#x, endian %q", v, file[126:128])
+	}
+
+	vars := readMatVariables(t, file)
+	names := []string{"t", "voltage", "current", "cell_1"}
+	if len(vars) != len(names) {
+		t.Fatalf("%d variables, want %d", len(vars), len(names))
+	}
+	for i, v := range vars {
+		if v.name != names[i] {
+			t.Errorf("variable %d is %s, want %s", i, v.name, names[i])
+		}
+		if len(v.values) != len(testTimes) {
+			t.Fatalf("%s has %d values, want %d", v.name, len(v.values), len(testTimes))
+		}
+		for r, got := range v.values {
+			want := float64(testTimes[r].UnixNano()) / 1e9
+			if i > 0 {
+				want = testValues[r][i-1]
+			}
+			if !sameValue(got, want) {
+				t.Errorf("%s(%d) = %g, want %g", v.name, r+1, got, want)
+			}
+		}
+	}
+}
+
+func TestMatName(t *testing.T) {
+	used := map[string]bool{"t": true}
+	long := strings.Repeat("a", 70)
+	tests := []struct {
+		column, want string
+	}{
+		{"voltage", "voltage"},
+		{"pack_voltage.voltage", "pack_voltage_voltage"},
+		{"t", "t_2"},
+		{"1st", "x1st"},
+		{"_hidden", "x_hidden"},
+		{"temp °C", "temp__C"},
+		{"", "x"},
+		{long, long[:matMaxName]},
+		{long, long[:matMaxName-2] + "_2"},
+		{"voltage", "voltage_2"},
+	}
+	for _, tt := range tests {
+		if got := matName(tt.column, used); got != tt.want {
+			t.Errorf("matName(%q) = %q, want %q", tt.column, got, tt.want)
+		}
+	}
+}
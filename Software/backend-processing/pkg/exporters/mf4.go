@@ -0,0 +1,219 @@
+// mf4.go
+//
+// ASAM MDF 4.1 (.mf4) export for the powertrain team's measurement tools. The
+// file holds a single channel group: a master time channel in seconds since the
+// first sample and one 64-bit float channel per dataset column. Missing values
+// are flagged through invalidation bits (and stored as NaN).
+package exporters
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	// Block sizes: 24-byte header, 8 bytes per link, then the data section
+	mdfIDSize = 64
+	mdfHDSize = 24 + 6*8 + 32
+	mdfFHSize = 24 + 2*8 + 16
+	mdfDGSize = 24 + 4*8 + 8
+	mdfCGSize = 24 + 6*8 + 32
+	mdfCNSize = 24 + 8*8 + 72
+
+	// Channel types, sync types and data types
+	mdfChannelFixed  = 0
+	mdfChannelMaster = 2
+	mdfSyncNone      = 0
+	mdfSyncTime      = 1
+	mdfFloatLE       = 4
+
+	// Channel flag: the invalidation bit of the channel is valid
+	mdfFlagInvalBitValid = 0x02
+
+	// Program identifier in the ID block
+	mdfProgram = "fsaets"
+)
+
+type mf4Exporter struct{}
+
+func (mf4Exporter) Format() Format {
+	return Format{
+		Name:        "mf4",
+		Description: "ASAM MDF 4.1 measurement file with a time master channel and a float channel per channel",
+		Extension:   ".mf4",
+		ContentType: "application/octet-stream",
+	}
+}
+
+// mdfBlock assembles a block in memory.
+type mdfBlock struct {
+	buf []byte
+}
+
+// newMDFBlock starts a block with the given ID, total length and links.
+func newMDFBlock(id string, length int, links ...int64) *mdfBlock {
+	b := &mdfBlock{buf: make([]byte, 0, length)}
+	b.buf = append(b.buf, "##"+id...)
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	b.buf = binary.LittleEndian.AppendUint64(b.buf, uint64(length))
+	b.buf = binary.LittleEndian.AppendUint64(b.buf, uint64(len(links)))
+	for _, l := range links {
+		b.buf = binary.LittleEndian.AppendUint64(b.buf, uint64(l))
+	}
+	return b
+}
+
+func (b *mdfBlock) u8(v uint8)   { b.buf = append(b.buf, v) }
+func (b *mdfBlock) u32(v uint32) { b.buf = binary.LittleEndian.AppendUint32(b.buf, v) }
+func (b *mdfBlock) u64(v uint64) { b.buf = binary.LittleEndian.AppendUint64(b.buf, v) }
+func (b *mdfBlock) f64(v float64) {
+	b.buf = binary.LittleEndian.AppendUint64(b.buf, math.Float64bits(v))
+}
+func (b *mdfBlock) zero(n int) { b.buf = append(b.buf, make([]byte, n)...) }
+
+// mdfTextSize is the length of a TX or MD block holding s, zero-terminated and
+// padded to 8 bytes.
+func mdfTextSize(s string) int {
+	return 24 + (len(s)+1+7)/8*8
+}
+
+// mdfText returns a TX or MD block.
+func mdfText(id, s string) []byte {
+	size := mdfTextSize(s)
+	b := newMDFBlock(id, size)
+	b.buf = append(b.buf, s...)
+	b.zero(size - len(b.buf))
+	return b.buf
+}
+
+func (mf4Exporter) Export(ctx context.Context, w io.Writer, ds Dataset) error {
+	times, cols, err := bufferRows(ctx, ds)
+	if err != nil {
+		return err
+	}
+	var start time.Time
+	if len(times) > 0 {
+		start = times[0]
+	}
+	comment := fmt.Sprintf(`<FHcomment xmlns="http://www.asam.net/mdf/v4"><TX>%s</TX><tool_id>%s</tool_id><tool_vendor>FSAE</tool_vendor><tool_version>1</tool_version></FHcomment>`, html.EscapeString(ds.Name), mdfProgram)
+	names := append([]string{"t"}, ds.Columns...)
+	invalBytes := (len(ds.Columns) + 7) / 8
+	recordSize := 8*len(names) + invalBytes
+
+	// Layout: ID, HD, FH, MD, DG, CG, CNs, TXs (channel names, then the time
+	// unit), DT last so its length does not affect the other offsets
+	hdAt := int64(mdfIDSize)
+	fhAt := hdAt + mdfHDSize
+	mdAt := fhAt + mdfFHSize
+	dgAt := mdAt + int64(mdfTextSize(comment))
+	cgAt := dgAt + mdfDGSize
+	cnAt := cgAt + mdfCGSize
+	txAt := make([]int64, len(names)+1)
+	txAt[0] = cnAt + int64(len(names)*mdfCNSize)
+	for i, n := range names {
+		txAt[i+1] = txAt[i] + int64(mdfTextSize(n))
+	}
+	unitAt := txAt[len(names)]
+	dtAt := unitAt + int64(mdfTextSize("s"))
+
+	var out []byte
+	id := make([]byte, mdfIDSize)
+	copy(id, "MDF     4.10    ")
+	copy(id[16:24], fmt.Sprintf("%-8s", mdfProgram))
+	binary.LittleEndian.PutUint16(id[28:], 410)
+	out = append(out, id...)
+
+	hd := newMDFBlock("HD", mdfHDSize, dgAt, fhAt, 0, 0, 0, 0)
+	hd.u64(uint64(start.UnixNano()))
+	hd.zero(4)  // Time zone and DST offsets
+	hd.zero(4)  // Time flags (UTC), class, flags, reserved
+	hd.zero(16) // Start angle and distance
+	out = append(out, hd.buf...)
+
+	fh := newMDFBlock("FH", mdfFHSize, 0, mdAt)
+	fh.u64(uint64(time.Now().UnixNano()))
+	fh.zero(8)
+	out = append(out, fh.buf...)
+	out = append(out, mdfText("MD", comment)...)
+
+	dg := newMDFBlock("DG", mdfDGSize, 0, cgAt, dtAt, 0)
+	dg.zero(8) // No record IDs
+	out = append(out, dg.buf...)
+
+	cg := newMDFBlock("CG", mdfCGSize, 0, cnAt, 0, 0, 0, 0)
+	cg.u64(0)
+	cg.u64(uint64(len(times)))
+	cg.zero(8) // Flags, path separator, reserved
+	cg.u32(uint32(8 * len(names)))
+	cg.u32(uint32(invalBytes))
+	out = append(out, cg.buf...)
+
+	for i := range names {
+		next, unit := int64(0), int64(0)
+		if i < len(names)-1 {
+			next = cnAt + int64((i+1)*mdfCNSize)
+		}
+		cnType, syncType, flags, invalPos := uint8(mdfChannelFixed), uint8(mdfSyncNone), uint32(mdfFlagInvalBitValid), uint32(i-1)
+		if i == 0 {
+			cnType, syncType, flags, invalPos, unit = mdfChannelMaster, mdfSyncTime, 0, 0, unitAt
+		}
+		cn := newMDFBlock("CN", mdfCNSize, next, 0, txAt[i], 0, 0, 0, unit, 0)
+		cn.u8(cnType)
+		cn.u8(syncType)
+		cn.u8(mdfFloatLE)
+		cn.u8(0)              // Bit offset
+		cn.u32(uint32(8 * i)) // Byte offset
+		cn.u32(64)            // Bit count
+		cn.u32(flags)
+		cn.u32(invalPos)
+		cn.zero(4) // Precision, reserved, attachment count
+		for range 6 {
+			cn.f64(0) // Value range and limits (not set)
+		}
+		out = append(out, cn.buf...)
+	}
+	for _, n := range names {
+		out = append(out, mdfText("TX", n)...)
+	}
+	out = append(out, mdfText("TX", "s")...)
+
+	dt := newMDFBlock("DT", 24+recordSize*len(times))
+	out = append(out, dt.buf...)
+	if _, err := w.Write(out); err != nil {
+		return err
+	}
+
+	record := make([]byte, recordSize)
+	buf := make([]byte, 0, 64*1024)
+	for r, t := range times {
+		if r%65536 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		binary.LittleEndian.PutUint64(record, math.Float64bits(t.Sub(start).Seconds()))
+		inval := record[8*len(names):]
+		clear(inval)
+		for i, c := range cols {
+			v := c[r]
+			binary.LittleEndian.PutUint64(record[8*(i+1):], math.Float64bits(v))
+			if math.IsNaN(v) {
+				inval[i/8] |= 1 << (i % 8)
+			}
+		}
+		buf = append(buf, record...)
+		if len(buf) >= 60*1024 {
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	_, err = w.Write(buf)
+	return err
+}
@@ -0,0 +1,171 @@
+package exporters
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mdfTestBlock is a block read back from an exported file.
+type mdfTestBlock struct {
+	links []int64
+	data  []byte
+}
+
+// readMDFBlock reads the block at offset and checks its ID.
+func readMDFBlock(t *testing.T, file []byte, offset int64, id string) mdfTestBlock {
+	t.Helper()
+	if offset <= 0 || offset+24 > int64(len(file)) || offset%8 != 0 {
+		t.Fatalf("%s block at invalid offset %d", id, offset)
+	}
+	b := file[offset:]
+	if got := string(b[:4]); got != "##"+id {
+		t.Fatalf("block at %d is %q, want ##%s", offset, got, id)
+	}
+	length := int64(binary.LittleEndian.Uint64(b[8:]))
+	links := int(binary.LittleEndian.Uint64(b[16:]))
+	if offset+length > int64(len(file)) || 24+8*int64(links) > length {
+		t.Fatalf("%s block at %d: length %d with %d links overruns the file", id, offset, length, links)
+	}
+	block := mdfTestBlock{data: b[24+8*links : length]}
+	for i := 0; i < links; i++ {
+		block.links = append(block.links, int64(binary.LittleEndian.Uint64(b[24+8*i:])))
+	}
+	return block
+}
+
+// mdfTestText returns the text of the TX block at offset.
+func mdfTestText(t *testing.T, file []byte, offset int64) string {
+	t.Helper()
+	return strings.TrimRight(string(readMDFBlock(t, file, offset, "TX").data), "\x00")
+}
+
+func TestMF4RoundTrip(t *testing.T) {
+	file := export(t, "mf4", testDataset())
+
+	if !strings.HasPrefix(string(file), "MDF     4.10    ") {
+		t.Fatalf("ID block = %q", file[:16])
+	}
+	if v := binary.LittleEndian.Uint16(file[28:]); v != 410 {
+		t.Fatalf("version = %d, want 410", v)
+	}
+
+	hd := readMDFBlock(t, file, mdfIDSize, "HD")
+	if start := int64(binary.LittleEndian.Uint64(hd.data)); start != testStart.UnixNano() {
+		t.Errorf("start time = %d, want %d", start, testStart.UnixNano())
+	}
+	fh := readMDFBlock(t, file, hd.links[1], "FH")
+	md := readMDFBlock(t, file, fh.links[1], "MD")
+	if !strings.Contains(string(md.data), "<TX>pack_20240501T120000Z</TX>") {
+		t.Errorf("file history comment = %q", md.data)
+	}
+
+	dg := readMDFBlock(t, file, hd.links[0], "DG")
+	if dg.links[0] != 0 {
+		t.Error("more than one data group")
+	}
+	cg := readMDFBlock(t, file, dg.links[1], "CG")
+	if cg.links[0] != 0 {
+		t.Error("more than one channel group")
+	}
+	cycles := binary.LittleEndian.Uint64(cg.data[8:])
+	dataBytes := binary.LittleEndian.Uint32(cg.data[24:])
+	invalBytes := binary.LittleEndian.Uint32(cg.data[28:])
+	if cycles != uint64(len(testTimes)) {
+		t.Fatalf("cycle count = %d, want %d", cycles, len(testTimes))
+	}
+	if dataBytes != uint32(8*(len(testColumns)+1)) || invalBytes != 1 {
+		t.Fatalf("record = %d data bytes + %d invalidation bytes", dataBytes, invalBytes)
+	}
+
+	// Channels: the time master, then one per column in order
+	type channel struct {
+		name              string
+		typ, sync, format uint8
+		byteOffset, bits  uint32
+		flags, invalPos   uint32
+		unit              string
+	}
+	var channels []channel
+	for at := cg.links[1]; at != 0; {
+		cn := readMDFBlock(t, file, at, "CN")
+		c := channel{
+			name:       mdfTestText(t, file, cn.links[2]),
+			typ:        cn.data[0],
+			sync:       cn.data[1],
+			format:     cn.data[2],
+			byteOffset: binary.LittleEndian.Uint32(cn.data[4:]),
+			bits:       binary.LittleEndian.Uint32(cn.data[8:]),
+			flags:      binary.LittleEndian.Uint32(cn.data[12:]),
+			invalPos:   binary.LittleEndian.Uint32(cn.data[16:]),
+		}
+		if cn.links[6] != 0 {
+			c.unit = mdfTestText(t, file, cn.links[6])
+		}
+		channels = append(channels, c)
+		at = cn.links[0]
+	}
+	if len(channels) != len(testColumns)+1 {
+		t.Fatalf("%d channels, want %d", len(channels), len(testColumns)+1)
+	}
+	master := channels[0]
+	if master.name != "t" || master.typ != mdfChannelMaster || master.sync != mdfSyncTime || master.unit != "s" || master.byteOffset != 0 {
+		t.Errorf("master channel = %+v", master)
+	}
+	for i, c := range channels[1:] {
+		if c.name != testColumns[i] || c.typ != mdfChannelFixed || c.byteOffset != uint32(8*(i+1)) ||
+			c.flags&mdfFlagInvalBitValid == 0 || c.invalPos != uint32(i) {
+			t.Errorf("channel %d = %+v", i+1, c)
+		}
+	}
+	for _, c := range channels {
+		if c.format != mdfFloatLE || c.bits != 64 {
+			t.Errorf("channel %s is not a 64-bit little-endian float", c.name)
+		}
+	}
+
+	dt := readMDFBlock(t, file, dg.links[2], "DT")
+	recordSize := int(dataBytes + invalBytes)
+	if len(dt.data) != recordSize*len(testTimes) {
+		t.Fatalf("data block holds %d bytes, want %d records of %d", len(dt.data), len(testTimes), recordSize)
+	}
+	if int64(len(file)) != dg.links[2]+24+int64(len(dt.data)) {
+		t.Errorf("%d bytes after the data block", int64(len(file))-dg.links[2]-24-int64(len(dt.data)))
+	}
+	for r, want := range testTimes {
+		record := dt.data[r*recordSize:]
+		if got := math.Float64frombits(binary.LittleEndian.Uint64(record)); got != want.Sub(testStart).Seconds() {
+			t.Errorf("row %d: t = %g, want %g", r, got, want.Sub(testStart).Seconds())
+		}
+		inval := record[dataBytes]
+		for i, c := range channels[1:] {
+			want := testValues[r][i]
+			got := math.Float64frombits(binary.LittleEndian.Uint64(record[c.byteOffset:]))
+			if invalid := inval&(1<<c.invalPos) != 0; invalid != math.IsNaN(want) {
+				t.Errorf("%s row %d: invalidation bit %v, want value %g", c.name, r, invalid, want)
+			}
+			if !sameValue(got, want) {
+				t.Errorf("%s row %d = %g, want %g", c.name, r, got, want)
+			}
+		}
+	}
+}
+
+func TestMF4Empty(t *testing.T) {
+	ds := testDataset()
+	ds.Rows = func(ctx context.Context, fn func(t time.Time, values []float64) error) error { return nil }
+	file := export(t, "mf4", ds)
+
+	hd := readMDFBlock(t, file, mdfIDSize, "HD")
+	dg := readMDFBlock(t, file, hd.links[0], "DG")
+	cg := readMDFBlock(t, file, dg.links[1], "CG")
+	if cycles := binary.LittleEndian.Uint64(cg.data[8:]); cycles != 0 {
+		t.Errorf("cycle count = %d, want 0", cycles)
+	}
+	if dt := readMDFBlock(t, file, dg.links[2], "DT"); len(dt.data) != 0 {
+		t.Errorf("data block holds %d bytes, want 0", len(dt.data))
+	}
+}
@@ -0,0 +1,334 @@
+// parquet.go
+//
+// Apache Parquet export. The schema is a required INT64 timestamp column
+// (TIMESTAMP_MICROS, UTC) followed by an optional DOUBLE column per dataset
+// column; missing values are nulls. Rows are written in row groups as they are
+// read, each column chunk as a single uncompressed, plain-encoded data page, so
+// the export streams with bounded memory.
+package exporters
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	// Values (all columns combined) per row group
+	parquetRowGroupValues = 1 << 20
+
+	// Writer name stored in the footer
+	parquetCreatedBy = "fsae-telemetry"
+
+	// Parquet enums used here
+	parquetTypeInt64         = 2
+	parquetTypeDouble        = 5
+	parquetRequired          = 0
+	parquetOptional          = 1
+	parquetTimestampMicros   = 10
+	parquetEncodingPlain     = 0
+	parquetEncodingRLE       = 3
+	parquetCodecUncompressed = 0
+	parquetPageData          = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+type parquetExporter struct{}
+
+func (parquetExporter) Format() Format {
+	return Format{
+		Name:        "parquet",
+		Description: "Apache Parquet with a microsecond timestamp column and a nullable double column per channel",
+		Extension:   ".parquet",
+		ContentType: "application/vnd.apache.parquet",
+		Streaming:   true,
+	}
+}
+
+// parquetChunk is the footer metadata of a written column chunk.
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// parquetRowGroup is the footer metadata of a written row group.
+type parquetRowGroup struct {
+	rows   int64
+	chunks []parquetChunk
+}
+
+// parquetWriter buffers a row group and writes it when full.
+type parquetWriter struct {
+	w      io.Writer
+	offset int64
+	names  []string
+	times  []int64
+	cols   [][]float64
+	groups []parquetRowGroup
+	page   []byte
+}
+
+func (parquetExporter) Export(ctx context.Context, w io.Writer, ds Dataset) error {
+	pw := &parquetWriter{w: w, names: ds.Columns, cols: make([][]float64, len(ds.Columns))}
+	if err := pw.write(parquetMagic); err != nil {
+		return err
+	}
+	groupRows := max(1, parquetRowGroupValues/(len(ds.Columns)+1))
+	err := ds.Rows(ctx, func(t time.Time, values []float64) error {
+		pw.times = append(pw.times, t.UnixMicro())
+		for i, v := range values {
+			pw.cols[i] = append(pw.cols[i], v)
+		}
+		if len(pw.times) >= groupRows {
+			return pw.flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = pw.flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	footer := pw.footer()
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	if err := pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return pw.write(parquetMagic)
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered rows as a row group.
+func (pw *parquetWriter) flush() error {
+	rows := len(pw.times)
+	if rows == 0 {
+		return nil
+	}
+	group := parquetRowGroup{rows: int64(rows)}
+
+	// Timestamp column: required, no definition levels
+	pw.page = pw.page[:0]
+	for _, t := range pw.times {
+		pw.page = binary.LittleEndian.AppendUint64(pw.page, uint64(t))
+	}
+	chunk, err := pw.writePage(rows)
+	if err != nil {
+		return err
+	}
+	group.chunks = append(group.chunks, chunk)
+
+	// Value columns: definition levels (1 = present) bit-packed, then the
+	// present values
+	for _, col := range pw.cols {
+		pw.page = pw.page[:0]
+		groups := (rows + 7) / 8
+		levels := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+		bits := make([]byte, groups)
+		for i, v := range col {
+			if !math.IsNaN(v) {
+				bits[i/8] |= 1 << (i % 8)
+			}
+		}
+		levels = append(levels, bits...)
+		pw.page = binary.LittleEndian.AppendUint32(pw.page, uint32(len(levels)))
+		pw.page = append(pw.page, levels...)
+		for _, v := range col {
+			if !math.IsNaN(v) {
+				pw.page = binary.LittleEndian.AppendUint64(pw.page, math.Float64bits(v))
+			}
+		}
+		chunk, err := pw.writePage(rows)
+		if err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.times = pw.times[:0]
+	for i := range pw.cols {
+		pw.cols[i] = pw.cols[i][:0]
+	}
+	return nil
+}
+
+// writePage writes the page buffer as a column chunk with a single data page.
+func (pw *parquetWriter) writePage(rows int) (parquetChunk, error) {
+	var t thriftWriter
+	t.i32(1, parquetPageData)
+	t.i32(2, int32(len(pw.page)))
+	t.i32(3, int32(len(pw.page)))
+	t.structBegin(5) // DataPageHeader
+	t.i32(1, int32(rows))
+	t.i32(2, parquetEncodingPlain)
+	t.i32(3, parquetEncodingRLE)
+	t.i32(4, parquetEncodingRLE)
+	t.structEnd()
+	t.stop()
+
+	chunk := parquetChunk{offset: pw.offset, size: int64(len(t.buf) + len(pw.page)), values: int64(rows)}
+	if err := pw.write(t.buf); err != nil {
+		return chunk, err
+	}
+	return chunk, pw.write(pw.page)
+}
+
+// footer encodes the FileMetaData.
+func (pw *parquetWriter) footer() []byte {
+	var total int64
+	for _, g := range pw.groups {
+		total += g.rows
+	}
+
+	var t thriftWriter
+	t.i32(1, 1) // Version
+	t.list(2, thriftStruct, len(pw.names)+2)
+	t.elemBegin() // Root
+	t.str(4, "schema")
+	t.i32(5, int32(len(pw.names)+1))
+	t.elemEnd()
+	t.elemBegin()
+	t.i32(1, parquetTypeInt64)
+	t.i32(3, parquetRequired)
+	t.str(4, "timestamp")
+	t.i32(6, parquetTimestampMicros)
+	t.elemEnd()
+	for _, n := range pw.names {
+		t.elemBegin()
+		t.i32(1, parquetTypeDouble)
+		t.i32(3, parquetOptional)
+		t.str(4, n)
+		t.elemEnd()
+	}
+	t.i64(3, total)
+	t.list(4, thriftStruct, len(pw.groups))
+	for _, g := range pw.groups {
+		t.elemBegin()
+		t.list(1, thriftStruct, len(g.chunks))
+		var size int64
+		for i, c := range g.chunks {
+			typ, name := int32(parquetTypeDouble), "timestamp"
+			encodings := []int32{parquetEncodingPlain, parquetEncodingRLE}
+			if i == 0 {
+				typ, encodings = parquetTypeInt64, []int32{parquetEncodingPlain}
+			} else {
+				name = pw.names[i-1]
+			}
+			t.elemBegin() // ColumnChunk
+			t.i64(2, c.offset)
+			t.structBegin(3) // ColumnMetaData
+			t.i32(1, typ)
+			t.list(2, thriftI32, len(encodings))
+			for _, e := range encodings {
+				t.varint(int64(e))
+			}
+			t.list(3, thriftBinary, 1)
+			t.binary(name)
+			t.i32(4, parquetCodecUncompressed)
+			t.i64(5, c.values)
+			t.i64(6, c.size)
+			t.i64(7, c.size)
+			t.i64(9, c.offset)
+			t.structEnd()
+			t.elemEnd()
+			size += c.size
+		}
+		t.i64(2, size)
+		t.i64(3, g.rows)
+		t.elemEnd()
+	}
+	t.str(6, parquetCreatedBy)
+	t.stop()
+	return t.buf
+}
+
+// Thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Parquet metadata structs with the Thrift compact
+// protocol. Only the types the metadata needs are supported.
+type thriftWriter struct {
+	buf   []byte
+	last  int16   // Last field ID of the current struct
+	stack []int16 // Last field IDs of the enclosing structs
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+// varint appends a zigzag-encoded integer.
+func (t *thriftWriter) varint(v int64) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(v<<1^v>>63))
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) { t.field(id, thriftI32); t.varint(int64(v)) }
+func (t *thriftWriter) i64(id int16, v int64) { t.field(id, thriftI64); t.varint(v) }
+func (t *thriftWriter) str(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary(s)
+}
+
+// list starts a list field of n elements.
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xF0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+// structBegin starts a struct field; structEnd closes it.
+func (t *thriftWriter) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+func (t *thriftWriter) structEnd() { t.elemEnd() }
+
+// elemBegin starts a struct that is a list element; elemEnd closes it.
+func (t *thriftWriter) elemBegin() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) elemEnd() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// stop ends the current struct.
+func (t *thriftWriter) stop() { t.buf = append(t.buf, 0) }
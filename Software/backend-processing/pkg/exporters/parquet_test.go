@@ -0,0 +1,271 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// thriftStructValue is a decoded Thrift struct: field values by ID. Integers are
+// int64, binaries []byte, lists []interface{} and structs thriftStructValue.
+type thriftStructValue map[int16]interface{}
+
+func (s thriftStructValue) int(id int16) int64  { v, _ := s[id].(int64); return v }
+func (s thriftStructValue) str(id int16) string { v, _ := s[id].([]byte); return string(v) }
+func (s thriftStructValue) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+func (s thriftStructValue) sub(id int16) thriftStructValue {
+	v, _ := s[id].(thriftStructValue)
+	return v
+}
+
+// thriftReader decodes the Thrift compact protocol types written by thriftWriter.
+type thriftReader struct {
+	buf []byte
+	err error
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.buf) == 0 {
+		r.err = fmt.Errorf("thrift: unexpected end of data")
+		return 0
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("thrift: invalid varint")
+		r.buf = nil
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := r.uvarint()
+		if uint64(len(r.buf)) < n {
+			r.err = fmt.Errorf("thrift: binary of %d bytes past the end", n)
+			r.buf = nil
+			return []byte(nil)
+		}
+		b := r.buf[:n]
+		r.buf = r.buf[n:]
+		return b
+	case thriftList:
+		head := r.byte()
+		n, elem := uint64(head>>4), head&0x0F
+		if n == 15 {
+			n = r.uvarint()
+		}
+		var out []interface{}
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			out = append(out, r.value(elem))
+		}
+		return out
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.err = fmt.Errorf("thrift: unsupported type %d", typ)
+	return nil
+}
+
+// readStruct decodes a struct up to its stop field.
+func (r *thriftReader) readStruct() thriftStructValue {
+	s := make(thriftStructValue)
+	var last int16
+	for r.err == nil {
+		head := r.byte()
+		if head == 0 {
+			break
+		}
+		id := last + int16(head>>4)
+		if head>>4 == 0 {
+			id = int16(r.varint())
+		}
+		s[id] = r.value(head & 0x0F)
+		last = id
+	}
+	return s
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	data := export(t, "parquet", testDataset())
+
+	if !bytes.HasPrefix(data, parquetMagic) || !bytes.HasSuffix(data, parquetMagic) {
+		t.Fatal("file does not start and end with PAR1")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerAt := len(data) - 8 - footerLen
+	r := &thriftReader{buf: data[footerAt : len(data)-8]}
+	meta := r.readStruct()
+	if r.err != nil {
+		t.Fatalf("footer: %v", r.err)
+	}
+	if len(r.buf) != 0 {
+		t.Fatalf("footer: %d bytes after FileMetaData", len(r.buf))
+	}
+
+	// Schema: root, required timestamp, an optional double per column
+	if meta.int(3) != int64(len(testTimes)) {
+		t.Fatalf("num_rows = %d, want %d", meta.int(3), len(testTimes))
+	}
+	if meta.str(6) != parquetCreatedBy {
+		t.Errorf("created_by = %q, want %q", meta.str(6), parquetCreatedBy)
+	}
+	schema := meta.list(2)
+	if len(schema) != len(testColumns)+2 {
+		t.Fatalf("schema has %d elements, want %d", len(schema), len(testColumns)+2)
+	}
+	root := schema[0].(thriftStructValue)
+	if root.int(5) != int64(len(testColumns)+1) {
+		t.Errorf("root has %d children, want %d", root.int(5), len(testColumns)+1)
+	}
+	ts := schema[1].(thriftStructValue)
+	if ts.str(4) != "timestamp" || ts.int(1) != parquetTypeInt64 || ts.int(3) != parquetRequired || ts.int(6) != parquetTimestampMicros {
+		t.Errorf("timestamp schema element = %v", ts)
+	}
+	for i, c := range testColumns {
+		el := schema[i+2].(thriftStructValue)
+		if el.str(4) != c || el.int(1) != parquetTypeDouble || el.int(3) != parquetOptional {
+			t.Errorf("schema element of %s = %v", c, el)
+		}
+	}
+
+	groups := meta.list(4)
+	if len(groups) != 1 {
+		t.Fatalf("%d row groups, want 1", len(groups))
+	}
+	group := groups[0].(thriftStructValue)
+	chunks := group.list(1)
+	if group.int(3) != int64(len(testTimes)) || len(chunks) != len(testColumns)+1 {
+		t.Fatalf("row group has %d rows and %d chunks", group.int(3), len(chunks))
+	}
+
+	var total int64
+	for i, chunk := range chunks {
+		cm := chunk.(thriftStructValue).sub(3)
+		offset, size := cm.int(9), cm.int(6)
+		total += size
+		if offset < 4 || offset+size > int64(footerAt) {
+			t.Fatalf("chunk %d at %d+%d is outside the data", i, offset, size)
+		}
+		if path := cm.list(3); len(path) != 1 {
+			t.Errorf("chunk %d has path %v", i, path)
+		}
+		if cm.int(5) != int64(len(testTimes)) {
+			t.Errorf("chunk %d has %d values, want %d", i, cm.int(5), len(testTimes))
+		}
+
+		r := &thriftReader{buf: data[offset : offset+size]}
+		header := r.readStruct()
+		page := r.buf
+		if r.err != nil {
+			t.Fatalf("chunk %d: page header: %v", i, r.err)
+		}
+		if header.int(1) != parquetPageData || header.int(2) != int64(len(page)) || header.int(3) != int64(len(page)) {
+			t.Fatalf("chunk %d: page header %v does not match the %d byte page", i, header, len(page))
+		}
+		if n := header.sub(5).int(1); n != int64(len(testTimes)) {
+			t.Fatalf("chunk %d: page has %d values, want %d", i, n, len(testTimes))
+		}
+
+		if i == 0 {
+			if cm.int(1) != parquetTypeInt64 || string(cm.list(3)[0].([]byte)) != "timestamp" {
+				t.Errorf("chunk 0 is not the timestamp column: %v", cm)
+			}
+			for row, want := range testTimes {
+				got := time.UnixMicro(int64(binary.LittleEndian.Uint64(page[8*row:]))).UTC()
+				if !got.Equal(want) {
+					t.Errorf("row %d: timestamp %s, want %s", row, got, want)
+				}
+			}
+			continue
+		}
+
+		// Definition levels: length-prefixed RLE/bit-packed hybrid, one bit-packed run
+		levelsLen := binary.LittleEndian.Uint32(page)
+		levels := page[4 : 4+levelsLen]
+		run, n := binary.Uvarint(levels)
+		if run&1 != 1 || int(run>>1) != (len(testTimes)+7)/8 {
+			t.Fatalf("chunk %d: definition levels header %d, want one bit-packed run", i, run)
+		}
+		bits := levels[n:]
+		values := page[4+levelsLen:]
+		for row := range testTimes {
+			want := testValues[row][i-1]
+			present := bits[row/8]&(1<<(row%8)) != 0
+			if present == math.IsNaN(want) {
+				t.Errorf("%s row %d: present = %v, want value %g", testColumns[i-1], row, present, want)
+				continue
+			}
+			if !present {
+				continue
+			}
+			got := math.Float64frombits(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+			if got != want {
+				t.Errorf("%s row %d = %g, want %g", testColumns[i-1], row, got, want)
+			}
+		}
+		if len(values) != 0 {
+			t.Errorf("chunk %d: %d bytes after the values", i, len(values))
+		}
+	}
+	if group.int(2) != total {
+		t.Errorf("row group total_byte_size = %d, want %d", group.int(2), total)
+	}
+}
+
+func TestParquetRowGroups(t *testing.T) {
+	rows := parquetRowGroupValues/2 + 10 // Two row groups with one column
+	ds := Dataset{
+		Columns: []string{"v"},
+		Rows: func(ctx context.Context, fn func(t time.Time, values []float64) error) error {
+			values := make([]float64, 1)
+			for i := 0; i < rows; i++ {
+				values[0] = float64(i)
+				if err := fn(testStart.Add(time.Duration(i)*time.Millisecond), values); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	data := export(t, "parquet", ds)
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := &thriftReader{buf: data[len(data)-8-footerLen : len(data)-8]}
+	meta := r.readStruct()
+	if r.err != nil {
+		t.Fatalf("footer: %v", r.err)
+	}
+	if meta.int(3) != int64(rows) {
+		t.Fatalf("num_rows = %d, want %d", meta.int(3), rows)
+	}
+	var sum int64
+	for _, g := range meta.list(4) {
+		sum += g.(thriftStructValue).int(3)
+	}
+	if n := len(meta.list(4)); n != 2 || sum != int64(rows) {
+		t.Fatalf("%d row groups with %d rows, want 2 with %d", n, sum, rows)
+	}
+}
@@ -0,0 +1,44 @@
+// rosbag.go
+//
+// rosbag2 export for the driverless team: one std_msgs/msg/Float64 topic per
+// column (see package rosbag). Missing values are not published.
+package exporters
+
+import (
+	"context"
+	"io"
+	"math"
+	"telem-system/pkg/rosbag"
+	"time"
+)
+
+type rosbagExporter struct{}
+
+func (rosbagExporter) Format() Format {
+	return Format{
+		Name:        "rosbag",
+		Description: "rosbag2 recording (MCAP) with a std_msgs/msg/Float64 topic per channel",
+		Extension:   rosbag.FileExtension,
+		ContentType: "application/octet-stream",
+		Streaming:   true,
+	}
+}
+
+func (rosbagExporter) Export(ctx context.Context, w io.Writer, ds Dataset) error {
+	bag := rosbag.NewWriter(w)
+	err := ds.Rows(ctx, func(t time.Time, values []float64) error {
+		for i, v := range values {
+			if math.IsNaN(v) {
+				continue
+			}
+			if err := bag.WriteFloat64(ds.Channel(i), t, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return bag.Close()
+}
@@ -0,0 +1,79 @@
+package exporters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"telem-system/pkg/rosbag"
+	"testing"
+	"time"
+)
+
+func TestRosbagExport(t *testing.T) {
+	file := export(t, "rosbag", testDataset())
+
+	magic := []byte{0x89, 'M', 'C', 'A', 'P', 0x30, '\r', '\n'}
+	if !bytes.HasPrefix(file, magic) || !bytes.HasSuffix(file, magic) {
+		t.Fatal("file does not start and end with the MCAP magic")
+	}
+
+	// Read the data section: topics by channel ID, then the messages
+	type message struct {
+		topic string
+		at    time.Time
+		value float64
+	}
+	topics := make(map[uint16]string)
+	var messages []message
+	str := func(b []byte) (string, []byte) {
+		n := binary.LittleEndian.Uint32(b)
+		return string(b[4 : 4+n]), b[4+n:]
+	}
+	for b := file[len(magic):]; len(b) > 0; {
+		op, length := b[0], binary.LittleEndian.Uint64(b[1:])
+		body := b[9 : 9+length]
+		b = b[9+length:]
+		if op == 0x0F { // Data end
+			break
+		}
+		switch op {
+		case 0x04: // Channel
+			id := binary.LittleEndian.Uint16(body)
+			topics[id], _ = str(body[4:])
+		case 0x05: // Message
+			topic, ok := topics[binary.LittleEndian.Uint16(body)]
+			if !ok {
+				t.Fatal("message on a channel that was not written before")
+			}
+			data := body[22:]
+			if len(data) != 12 || !bytes.Equal(data[:4], []byte{0, 1, 0, 0}) {
+				t.Fatalf("message data %x is not a little-endian CDR float64", data)
+			}
+			messages = append(messages, message{
+				topic: topic,
+				at:    time.Unix(0, int64(binary.LittleEndian.Uint64(body[6:]))).UTC(),
+				value: math.Float64frombits(binary.LittleEndian.Uint64(data[4:])),
+			})
+		}
+	}
+
+	var want []message
+	for r, at := range testTimes {
+		for i, v := range testValues[r] {
+			if !math.IsNaN(v) {
+				want = append(want, message{topic: rosbag.TopicName("pack." + testColumns[i]), at: at, value: v})
+			}
+		}
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("%d messages, want %d (missing values are not published)", len(messages), len(want))
+	}
+	for i := range want {
+		if got := messages[i]; got.topic != want[i].topic || !got.at.Equal(want[i].at) || got.value != want[i].value {
+			t.Errorf("message %d = %s %s %g, want %s %s %g", i, got.topic, got.at, got.value, want[i].topic, want[i].at, want[i].value)
+		}
+	}
+	if len(topics) != len(testColumns) {
+		t.Errorf("%d topics, want %d", len(topics), len(testColumns))
+	}
+}
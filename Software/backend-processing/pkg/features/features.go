@@ -0,0 +1,166 @@
+// features.go
+//
+// Package features holds the runtime feature flags gating risky subsystems, so a
+// new code path can be switched off at the track without redeploying. Flags are
+// declared here with their default; the configuration overrides the defaults at
+// startup and admins toggle flags at runtime through the API. Runtime changes
+// last until the server restarts. The flag states at the start of a session are
+// stored with the session, so its data can be traced to the code paths that
+// wrote it.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Flags
+const (
+	// CopyInserts writes telemetry batches with a single COPY instead of a
+	// prepared INSERT per row
+	CopyInserts = "copy_inserts"
+)
+
+// Flag is a declared feature flag.
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// declared lists every flag. Subsystems behind a flag add it here.
+var declared = []Flag{
+	{Name: CopyInserts, Description: "Write telemetry batches with COPY instead of prepared INSERTs", Default: false},
+}
+
+// ErrUnknownFlag is returned for flags that are not declared.
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// State is a flag with its current value.
+type State struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Default     bool       `json:"default"`
+	Configured  *bool      `json:"configured,omitempty"` // Value from the configuration, if set
+	UpdatedBy   string     `json:"updated_by,omitempty"` // Last runtime change
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// flag is the runtime state of a declared flag.
+type flag struct {
+	Flag
+	enabled    atomic.Bool
+	configured *bool
+	updatedBy  string    // Guarded by mu
+	updatedAt  time.Time // Guarded by mu
+}
+
+var (
+	mu    sync.Mutex // Serializes changes
+	flags = func() map[string]*flag {
+		m := make(map[string]*flag, len(declared))
+		for _, d := range declared {
+			f := &flag{Flag: d}
+			f.enabled.Store(d.Default)
+			m[d.Name] = f
+		}
+		return m
+	}()
+)
+
+// Configure applies the configured flag values over the defaults. Unknown names
+// are rejected so a typo does not silently leave a subsystem in its default
+// state.
+func Configure(values map[string]bool) error {
+	for name := range values {
+		if _, ok := flags[name]; !ok {
+			return fmt.Errorf("%w %q", ErrUnknownFlag, name)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for name, f := range flags {
+		f.configured = nil
+		on := f.Default
+		if v, ok := values[name]; ok {
+			on = v
+			f.configured = &v
+		}
+		f.enabled.Store(on)
+	}
+	return nil
+}
+
+// Enabled reports whether a flag is on. Undeclared flags are off. It is safe to
+// call on hot paths.
+func Enabled(name string) bool {
+	f, ok := flags[name]
+	return ok && f.enabled.Load()
+}
+
+// Set switches a flag at runtime.
+func Set(name string, on bool, by string) (State, error) {
+	f, ok := flags[name]
+	if !ok {
+		return State{}, fmt.Errorf("%w %q", ErrUnknownFlag, name)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	previous := f.enabled.Swap(on)
+	f.updatedBy, f.updatedAt = by, time.Now()
+	if previous != on {
+		log.Printf("Feature flag %s turned %s by %s", name, onOff(on), by)
+	}
+	return f.state(), nil
+}
+
+// States returns every flag with its current value, sorted by name.
+func States() []State {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]State, 0, len(flags))
+	for _, f := range flags {
+		out = append(out, f.state())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Snapshot returns the current value of every flag.
+func Snapshot() map[string]bool {
+	out := make(map[string]bool, len(flags))
+	for name, f := range flags {
+		out[name] = f.enabled.Load()
+	}
+	return out
+}
+
+// state must be called with mu held.
+func (f *flag) state() State {
+	s := State{
+		Name:        f.Name,
+		Description: f.Description,
+		Enabled:     f.enabled.Load(),
+		Default:     f.Default,
+		Configured:  f.configured,
+		UpdatedBy:   f.updatedBy,
+	}
+	if !f.updatedAt.IsZero() {
+		t := f.updatedAt
+		s.UpdatedAt = &t
+	}
+	return s
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,251 @@
+// federation.go
+//
+// Package federation completes multi-channel queries whose range reaches back
+// past the data kept on the trackside server. The local horizon is the configured
+// retention cutoff: telemetry older than the retention period is archived off the
+// server. The archived part of a range is requested from the configured cloud
+// mirror and fills the grid points that have no local sample; samples stored
+// locally are never replaced. The response lists which portion of the range came
+// from where. When the mirror cannot be reached the local result is returned with
+// the archived portion flagged unavailable.
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// Sources of a portion of a result
+const (
+	SourceLocal       = "local"
+	SourceMirror      = "mirror"
+	SourceUnavailable = "unavailable" // Archived, and the mirror could not be queried
+)
+
+const (
+	// Default limit on a mirror request
+	defaultTimeout = 20 * time.Second
+
+	// Largest mirror response read
+	maxResponseBytes = 64 << 20
+
+	// User agent of mirror requests
+	userAgent = "fsae-telemetry-federation"
+)
+
+// Config selects the cloud mirror.
+type Config struct {
+	MirrorURL string        // Base URL of the mirror API; empty disables federation
+	Token     string        // Bearer token presented to the mirror
+	Timeout   time.Duration // Limit on a mirror request; 0 selects the default
+	Retention time.Duration // Telemetry older than this is archived off the server
+}
+
+// Source is a portion of the query range and where its values came from.
+type Source struct {
+	Source string    `json:"source"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Error  string    `json:"error,omitempty"` // Why the mirror could not be queried
+}
+
+var (
+	cfg    Config
+	client = &http.Client{}
+)
+
+// Configure validates and installs the mirror settings. It must be called before
+// the API starts serving.
+func Configure(c Config) error {
+	if c.MirrorURL != "" {
+		u, err := url.Parse(c.MirrorURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("mirror URL %q must be http or https", c.MirrorURL)
+		}
+		c.MirrorURL = strings.TrimRight(c.MirrorURL, "/")
+		if c.Retention <= 0 {
+			return errors.New("a mirror needs the local retention period after which telemetry is archived")
+		}
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	cfg = c
+	return nil
+}
+
+// Enabled reports whether a mirror is configured.
+func Enabled() bool {
+	return cfg.MirrorURL != ""
+}
+
+// Run executes q, completing the part of the range before the local horizon from
+// the mirror. Time-domain results are merged point by point; distance-domain
+// queries reaching past the horizon are answered by the mirror alone, as the
+// distance axis has to be built from continuous data. Without a mirror it is
+// channels.Run with the whole range flagged local.
+func Run(ctx context.Context, queries *db.Queries, q channels.Query) (channels.Result, []Source, error) {
+	local := []Source{{Source: SourceLocal, From: q.From, To: q.To}}
+	horizon := localHorizon()
+	if !Enabled() || !q.From.Before(horizon) {
+		res, err := channels.Run(ctx, queries, q)
+		return res, local, err
+	}
+
+	if q.Domain == channels.DomainDistance {
+		res, err := mirrorQuery(ctx, q)
+		if err == nil {
+			return res, []Source{{Source: SourceMirror, From: q.From, To: q.To}}, nil
+		}
+		log.Printf("Federated query: mirror unavailable: %v", err)
+		res, lerr := channels.Run(ctx, queries, q)
+		return res, unavailable(q.From, q.To, horizon, err), lerr
+	}
+
+	res, err := channels.Run(ctx, queries, q)
+	if err != nil {
+		return res, nil, err
+	}
+	archived := q
+	if q.To.After(horizon) {
+		archived.To = horizon.Add(-time.Nanosecond)
+	}
+	remote, merr := mirrorQuery(ctx, archived)
+	if merr != nil {
+		log.Printf("Federated query: mirror unavailable: %v", merr)
+		return res, unavailable(q.From, q.To, horizon, merr), nil
+	}
+	if err := mergeArchived(&res, remote, horizon); err != nil {
+		return res, unavailable(q.From, q.To, horizon, err), nil
+	}
+	if !q.To.After(horizon) {
+		return res, []Source{{Source: SourceMirror, From: q.From, To: q.To}}, nil
+	}
+	return res, []Source{
+		{Source: SourceMirror, From: q.From, To: horizon},
+		{Source: SourceLocal, From: horizon, To: q.To},
+	}, nil
+}
+
+// unavailable flags the archived part of [from, to] as unavailable because of err.
+func unavailable(from, to, horizon time.Time, err error) []Source {
+	if !to.After(horizon) {
+		return []Source{{Source: SourceUnavailable, From: from, To: to, Error: err.Error()}}
+	}
+	return []Source{
+		{Source: SourceUnavailable, From: from, To: horizon, Error: err.Error()},
+		{Source: SourceLocal, From: horizon, To: to},
+	}
+}
+
+// localHorizon returns the retention cutoff: telemetry before it has been
+// archived off the server.
+func localHorizon() time.Time {
+	return time.Now().Add(-cfg.Retention)
+}
+
+// mergeArchived fills the grid points of res before horizon that have no local
+// sample from remote. Both results share the time grid, as the mirror query
+// starts at the same time with the same step. Gaps are taken from the mirror
+// only for channels without a local sample in the archived part.
+func mergeArchived(res *channels.Result, remote channels.Result, horizon time.Time) error {
+	limit := float64(horizon.UnixNano()) / 1e6
+	n := 0
+	for n < len(res.X) && res.X[n] < limit {
+		n++
+	}
+	if len(remote.X) < n {
+		return fmt.Errorf("mirror returned %d grid points, expected %d", len(remote.X), n)
+	}
+	for i := 0; i < n; i++ {
+		if remote.X[i] != res.X[i] {
+			return errors.New("mirror grid does not match the local grid")
+		}
+	}
+	stored := make(map[string]bool)
+	for id, values := range res.Channels {
+		archived := remote.Channels[id]
+		for i := 0; i < n && i < len(archived); i++ {
+			if values[i] != nil {
+				stored[id] = true
+				continue
+			}
+			values[i] = archived[i]
+		}
+	}
+	for id, gaps := range remote.Gaps {
+		if stored[id] {
+			continue
+		}
+		var archived []channels.Gap
+		for _, g := range gaps {
+			if g.To < limit {
+				archived = append(archived, g)
+			}
+		}
+		if len(archived) == 0 {
+			continue
+		}
+		if res.Gaps == nil {
+			res.Gaps = make(map[string][]channels.Gap)
+		}
+		res.Gaps[id] = append(archived, res.Gaps[id]...)
+	}
+	return nil
+}
+
+// mirrorQuery runs q on the mirror. The mirror is asked not to federate further.
+func mirrorQuery(ctx context.Context, q channels.Query) (channels.Result, error) {
+	params := url.Values{}
+	params.Set("channels", strings.Join(q.Channels, ","))
+	params.Set("from", q.From.UTC().Format(time.RFC3339Nano))
+	params.Set("to", q.To.UTC().Format(time.RFC3339Nano))
+	params.Set("federate", "false")
+	if q.Domain != "" {
+		params.Set("domain", q.Domain)
+	}
+	switch {
+	case q.Domain == channels.DomainDistance && q.DistStep > 0:
+		params.Set("step", strconv.FormatFloat(q.DistStep, 'f', -1, 64))
+	case q.Domain != channels.DomainDistance && q.TimeStep > 0:
+		params.Set("step", strconv.FormatFloat(float64(q.TimeStep)/float64(time.Millisecond), 'f', -1, 64))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.MirrorURL+"/api/query?"+params.Encode(), nil)
+	if err != nil {
+		return channels.Result{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return channels.Result{}, err
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, maxResponseBytes)
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(body, 512))
+		return channels.Result{}, fmt.Errorf("mirror responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var res channels.Result
+	if err := json.NewDecoder(body).Decode(&res); err != nil {
+		return channels.Result{}, fmt.Errorf("invalid mirror response: %w", err)
+	}
+	return res, nil
+}
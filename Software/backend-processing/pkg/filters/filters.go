@@ -0,0 +1,133 @@
+// filters.go
+//
+// Package filters implements the smoothing filters applied to live values: a
+// moving average and a moving median over the last N samples, and a first-order
+// low-pass with a cutoff frequency. A filter restarts from the raw value after a
+// gap in the samples, so a channel that comes back is not dragged towards values
+// from before the gap.
+package filters
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Filter types
+const (
+	TypeMovingAverage = "moving_average"
+	TypeMedian        = "median"
+	TypeLowPass       = "lowpass"
+)
+
+const (
+	// Default window of the moving filters
+	defaultWindow = 5
+
+	// Longest window of the moving filters
+	maxWindow = 1000
+
+	// A gap longer than this restarts a filter
+	resetGap = 2 * time.Second
+)
+
+// Spec configures the filter of a channel.
+type Spec struct {
+	Channel  string  // "type.field" or "type.*" of the live message
+	Type     string  // TypeMovingAverage, TypeMedian or TypeLowPass
+	Window   int     // Samples of the moving filters (default 5)
+	CutoffHz float64 // Cutoff frequency of the low-pass filter
+}
+
+// Validate checks the filter type and its parameters.
+func (s Spec) Validate() error {
+	switch s.Type {
+	case TypeMovingAverage, TypeMedian:
+		if s.Window < 0 || s.Window > maxWindow {
+			return fmt.Errorf("filter window must be between 1 and %d", maxWindow)
+		}
+	case TypeLowPass:
+		if s.CutoffHz <= 0 {
+			return fmt.Errorf("low-pass filter needs a positive cutoff_hz")
+		}
+	default:
+		return fmt.Errorf("unknown filter type %q", s.Type)
+	}
+	return nil
+}
+
+// Filter smooths the samples of a single signal.
+type Filter interface {
+	// Apply adds the sample v taken at t and returns the filtered value.
+	Apply(v float64, t time.Time) float64
+}
+
+// New returns a filter in its initial state. The spec must be valid.
+func (s Spec) New() Filter {
+	window := s.Window
+	if window <= 0 {
+		window = defaultWindow
+	}
+	switch s.Type {
+	case TypeMedian:
+		return &moving{window: window, median: true}
+	case TypeLowPass:
+		return &lowPass{rc: 1 / (2 * math.Pi * s.CutoffHz)}
+	default:
+		return &moving{window: window}
+	}
+}
+
+// moving averages or takes the median of the last window samples.
+type moving struct {
+	window  int
+	median  bool
+	samples []float64
+	last    time.Time
+}
+
+func (m *moving) Apply(v float64, t time.Time) float64 {
+	if t.Sub(m.last) > resetGap {
+		m.samples = m.samples[:0]
+	}
+	m.last = t
+	m.samples = append(m.samples, v)
+	if len(m.samples) > m.window {
+		m.samples = m.samples[len(m.samples)-m.window:]
+	}
+	if m.median {
+		s := slices.Clone(m.samples)
+		slices.Sort(s)
+		n := len(s)
+		if n%2 == 1 {
+			return s[n/2]
+		}
+		return (s[n/2-1] + s[n/2]) / 2
+	}
+	sum := 0.0
+	for _, x := range m.samples {
+		sum += x
+	}
+	return sum / float64(len(m.samples))
+}
+
+// lowPass is a first-order low-pass filter that accounts for the actual time
+// between samples.
+type lowPass struct {
+	rc    float64 // Time constant in seconds
+	value float64
+	last  time.Time
+}
+
+func (l *lowPass) Apply(v float64, t time.Time) float64 {
+	dt := t.Sub(l.last)
+	l.last = t
+	if dt > resetGap || dt < 0 {
+		l.value = v
+		return v
+	}
+	alpha := dt.Seconds() / (l.rc + dt.Seconds())
+	l.value += alpha * (v - l.value)
+	return l.value
+}
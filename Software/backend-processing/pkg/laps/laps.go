@@ -0,0 +1,201 @@
+// laps.go
+//
+// Package laps detects completed laps and maintains the lap_summary table. A lap
+// is completed when the car crosses the configured start/finish gate (a GPS point
+// with a radius) or when an operator marks it manually. Once the lap's data has
+// been persisted its aggregates are computed and stored, forming the basis of
+// the run sheet.
+package laps
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Lap triggers
+const (
+	TriggerGPS    = "gps"
+	TriggerManual = "manual"
+)
+
+const (
+	// Defaults for the start/finish gate
+	defaultGateRadius = 10.0 // metres
+	defaultMinLapTime = 20 * time.Second
+
+	// Delay before a completed lap is summarised, leaving time for the batch
+	// processors to flush its data
+	defaultSummaryDelay = 5 * time.Second
+
+	// Mean Earth radius for distance computations
+	earthRadius = 6371000.0
+)
+
+// Config configures lap detection and the aggregated channels.
+type Config struct {
+	GateLat, GateLon float64       // Start/finish gate; zero disables GPS detection
+	GateRadius       float64       // Metres
+	MinLapTime       time.Duration // GPS crossings closer together are ignored
+	SummaryDelay     time.Duration
+	Channels         []string // Extra channels aggregated into the channels column
+}
+
+// ErrNoLapStarted is returned by Mark when the mark only started lap timing.
+var ErrNoLapStarted = errors.New("no lap in progress; this mark started lap timing")
+
+// detector holds the lap state.
+type detector struct {
+	mu          sync.Mutex
+	cfg         Config
+	queries     *db.Queries
+	inside      bool      // Car currently within the gate radius
+	lapStart    time.Time // Start of the lap in progress
+	subscribers []func(types.LapSummary)
+}
+
+var det = &detector{cfg: Config{GateRadius: defaultGateRadius, MinLapTime: defaultMinLapTime, SummaryDelay: defaultSummaryDelay}}
+
+// Configure installs the lap configuration and the query helper used to read lap
+// data. Non-positive values select the defaults.
+func Configure(cfg Config, queries *db.Queries) error {
+	for _, id := range cfg.Channels {
+		if err := channels.Validate(id); err != nil {
+			return err
+		}
+	}
+	if cfg.GateRadius <= 0 {
+		cfg.GateRadius = defaultGateRadius
+	}
+	if cfg.MinLapTime <= 0 {
+		cfg.MinLapTime = defaultMinLapTime
+	}
+	if cfg.SummaryDelay <= 0 {
+		cfg.SummaryDelay = defaultSummaryDelay
+	}
+	det.mu.Lock()
+	det.cfg = cfg
+	det.queries = queries
+	det.mu.Unlock()
+	return nil
+}
+
+// Subscribe registers fn to be called with every stored lap summary.
+func Subscribe(fn func(types.LapSummary)) {
+	det.mu.Lock()
+	det.subscribers = append(det.subscribers, fn)
+	det.mu.Unlock()
+}
+
+// NoteGPS feeds a position fix into the start/finish gate detector.
+func NoteGPS(lat, lon float64, t time.Time) {
+	det.mu.Lock()
+	cfg := det.cfg
+	if cfg.GateLat == 0 && cfg.GateLon == 0 {
+		det.mu.Unlock()
+		return
+	}
+	inside := haversine(lat, lon, cfg.GateLat, cfg.GateLon) <= cfg.GateRadius
+	entered := inside && !det.inside
+	det.inside = inside
+	det.mu.Unlock()
+
+	if entered {
+		det.crossing(t, TriggerGPS)
+	}
+}
+
+// Mark records a manual start/finish crossing at t. The first mark starts lap
+// timing and returns ErrNoLapStarted.
+func Mark(t time.Time) error {
+	if !det.crossing(t, TriggerManual) {
+		return ErrNoLapStarted
+	}
+	return nil
+}
+
+// crossing completes the lap in progress (if any) and starts the next one. It
+// reports whether a lap was completed.
+func (d *detector) crossing(t time.Time, trigger string) bool {
+	d.mu.Lock()
+	start := d.lapStart
+	cfg := d.cfg
+	if trigger == TriggerGPS && !start.IsZero() && t.Sub(start) < cfg.MinLapTime {
+		d.mu.Unlock()
+		return false // Jitter around the gate
+	}
+	d.lapStart = t
+	d.mu.Unlock()
+
+	if start.IsZero() {
+		log.Printf("Lap timing started (%s)", trigger)
+		return false
+	}
+
+	var sessionID *int64
+	if s, ok := sessions.Current(); ok {
+		id := s.ID
+		sessionID = &id
+	}
+	time.AfterFunc(cfg.SummaryDelay, func() {
+		if err := d.summarise(start, t, sessionID, trigger, cfg.Channels); err != nil {
+			log.Printf("Error summarising lap %s - %s: %v", start.Format(time.RFC3339), t.Format(time.RFC3339), err)
+		}
+	})
+	return true
+}
+
+// summarise computes and stores the aggregates of a completed lap.
+func (d *detector) summarise(start, end time.Time, sessionID *int64, trigger string, extra []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	d.mu.Lock()
+	queries := d.queries
+	d.mu.Unlock()
+	if queries == nil {
+		return errors.New("lap summaries not configured")
+	}
+
+	l, err := Summarise(ctx, queries, start, end, extra)
+	if err != nil {
+		return err
+	}
+	l.SessionID = sessionID
+	l.Trigger = trigger
+
+	last, err := db.LastLapNumber(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	l.LapNumber = last + 1
+	if l.ID, err = db.InsertLapSummary(ctx, l); err != nil {
+		return err
+	}
+	log.Printf("Lap %d completed in %.3fs (%s)", l.LapNumber, l.DurationS, trigger)
+
+	d.mu.Lock()
+	subs := d.subscribers
+	d.mu.Unlock()
+	for _, fn := range subs {
+		fn(l)
+	}
+	return nil
+}
+
+// haversine returns the great-circle distance in metres between two points.
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,119 @@
+// summary.go
+//
+// Lap aggregate computation.
+package laps
+
+import (
+	"context"
+	"fmt"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Summarise computes the aggregates of the lap [start, end) from stored data.
+// extra lists additional channels aggregated into the channels map.
+func Summarise(ctx context.Context, queries *db.Queries, start, end time.Time, extra []string) (types.LapSummary, error) {
+	l := types.LapSummary{
+		StartedAt: start,
+		EndedAt:   end,
+		DurationS: end.Sub(start).Seconds(),
+	}
+
+	// Speed and distance from the INS
+	speed, err := channels.Fetch(ctx, queries, "derived.speed", start, end)
+	if err != nil {
+		return l, err
+	}
+	if s, ok := stats(speed.Values); ok {
+		l.MaxSpeed, l.AvgSpeed = &s.Max, &s.Avg
+		dist := channels.Integrate(speed)
+		l.DistanceM = &dist.Values[len(dist.Values)-1]
+	}
+
+	// Energy drawn from the pack, split into drive and regen
+	split, err := channels.EnergySplitBetween(ctx, queries, start, end)
+	if err != nil {
+		return l, err
+	}
+	if split.Samples >= 2 {
+		l.EnergyWh, l.DriveEnergyWh, l.RegenEnergyWh = &split.NetWh, &split.DriveWh, &split.RegenWh
+		l.RegenFraction = split.RegenFraction
+	}
+
+	// Temperatures
+	if l.MaxCellTemp, err = columnsMax(ctx, queries, "therm_data", thermColumns, start, end); err != nil {
+		return l, err
+	}
+	if l.MaxMotorTemp, err = columnsMax(ctx, queries, "bamo_car_re_transmit", []string{"motor_temp"}, start, end); err != nil {
+		return l, err
+	}
+	if l.MaxControllerTemp, err = columnsMax(ctx, queries, "bamo_car_re_transmit", []string{"controller_temp"}, start, end); err != nil {
+		return l, err
+	}
+
+	if l.AlertCount, err = db.CountAlertsRaised(ctx, start, end); err != nil {
+		return l, err
+	}
+
+	// Configured key channels
+	for _, id := range extra {
+		s, err := channels.Fetch(ctx, queries, id, start, end)
+		if err != nil {
+			return l, fmt.Errorf("lap channel %s: %w", id, err)
+		}
+		if st, ok := stats(s.Values); ok {
+			if l.Channels == nil {
+				l.Channels = make(map[string]types.ChannelStats)
+			}
+			l.Channels[id] = st
+		}
+	}
+	return l, nil
+}
+
+// thermColumns lists the thermistor columns of therm_data.
+var thermColumns = func() []string {
+	cols := make([]string, 16)
+	for i := range cols {
+		cols[i] = fmt.Sprintf("therm%d", i+1)
+	}
+	return cols
+}()
+
+// columnsMax returns the maximum over several columns of a table, or nil without data.
+func columnsMax(ctx context.Context, queries *db.Queries, table string, columns []string, start, end time.Time) (*float64, error) {
+	_, values, err := queries.FetchColumns(ctx, table, columns, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var max *float64
+	for _, col := range values {
+		if s, ok := stats(col); ok && (max == nil || s.Max > *max) {
+			m := s.Max
+			max = &m
+		}
+	}
+	return max, nil
+}
+
+// stats returns min, max and mean of values.
+func stats(values []float64) (types.ChannelStats, bool) {
+	if len(values) == 0 {
+		return types.ChannelStats{}, false
+	}
+	s := types.ChannelStats{Min: values[0], Max: values[0]}
+	var sum float64
+	for _, v := range values {
+		if v < s.Min {
+			s.Min = v
+		}
+		if v > s.Max {
+			s.Max = v
+		}
+		sum += v
+	}
+	s.Avg = sum / float64(len(values))
+	return s, true
+}
@@ -0,0 +1,189 @@
+// livechannels.go
+//
+// Package livechannels is the registry of live channels: the message types
+// broadcast to live clients, each with a stable numeric ID and the version of its
+// payload schema. Both are sent in every TelemetryMessage so clients can dispatch
+// on the ID and detect payloads newer or older than the ones they understand.
+//
+// IDs are never renumbered or reused. A new type gets the next free ID; a payload
+// change that removes fields or changes their meaning bumps the type's version
+// (adding fields does not).
+//
+// Each channel also has a policy: whether its values are stored, broadcast, or
+// both. Values only useful live (e.g. the fused pack current) are not stored, so
+// they do not fill the database; the defaults can be overridden per channel.
+package livechannels
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// Policy sets what happens to the values of a channel.
+type Policy string
+
+const (
+	PolicyBoth  Policy = "both"  // Stored and broadcast
+	PolicyLive  Policy = "live"  // Broadcast only
+	PolicyStore Policy = "store" // Stored only
+)
+
+// Channel is a registered live message type.
+type Channel struct {
+	ID          uint32 `json:"id"`
+	Type        string `json:"type"`
+	Version     uint32 `json:"schema_version"`
+	Description string `json:"description"`
+	Policy      Policy `json:"policy"` // Default policy in the registry, configured one from Lookup and All
+}
+
+// registry lists every live channel in ID order.
+var registry = []Channel{
+	// Decoded CAN frames
+	{1, "tcu", 1, "Throttle and brake pedal sensors and TCU status", PolicyBoth},
+	{2, "pack_current", 1, "Accumulator pack current (shunt)", PolicyBoth},
+	{3, "pack_voltage", 1, "Accumulator pack voltage", PolicyBoth},
+	{4, "aculv_fd_1", 1, "AMS status, state of charge and accumulator voltages", PolicyBoth},
+	{5, "aculv_fd_2", 1, "Accumulator fan set point and speed", PolicyBoth},
+	{6, "aculv1", 1, "Accumulator charge status", PolicyBoth},
+	{7, "aculv2", 1, "Accumulator charge request", PolicyBoth},
+	{8, "bamocar", 1, "Brake light and motor controller enable signals", PolicyBoth},
+	{9, "bamocar_rx_data", 1, "Motor controller received data", PolicyBoth},
+	{10, "bamocar_tx_data", 1, "Motor controller transmitted data", PolicyBoth},
+	{11, "bamo_car_re_transmit", 1, "Motor and controller temperatures", PolicyBoth},
+	{12, "encoder", 1, "Encoder counts", PolicyBoth},
+	{13, "front_aero", 1, "Front aero pressure sensors", PolicyBoth},
+	{14, "rear_aero", 1, "Rear aero pressure sensors", PolicyBoth},
+	{15, "front_analog", 1, "Front analog sensor board", PolicyBoth},
+	{16, "rear_analog", 1, "Rear analog sensor board", PolicyBoth},
+	{17, "front_frequency", 1, "Front frequency inputs", PolicyBoth},
+	{18, "rear_frequency", 1, "Rear frequency inputs", PolicyBoth},
+	{19, "front_strain_gauges_1", 1, "Front strain gauges, first frame", PolicyBoth},
+	{20, "front_strain_gauges_2", 1, "Front strain gauges, second frame", PolicyBoth},
+	{21, "rear_strain_gauges_1", 1, "Rear strain gauges, first frame", PolicyBoth},
+	{22, "rear_strain_gauges_2", 1, "Rear strain gauges, second frame", PolicyBoth},
+	{23, "gps_best_pos", 1, "GNSS best position", PolicyBoth},
+	{24, "ins_gps", 1, "INS position", PolicyBoth},
+	{25, "ins_imu", 1, "INS accelerations and rates", PolicyBoth},
+	{26, "pdm1", 1, "Power distribution module status", PolicyBoth},
+	{27, "pdm_current", 1, "Power distribution module currents", PolicyBoth},
+	{28, "pdm_re_transmit", 1, "Power distribution module status, retransmitted", PolicyBoth},
+	{29, "thermistor", 1, "Cell thermistors, one message per board", PolicyBoth},
+	{30, "cell", 1, "Aggregated cell voltages", PolicyBoth},
+
+	// Computed by the server
+	{31, "alert", 1, "Alert raised or cleared", PolicyBoth},
+	{32, "alert_state", 1, "Active alert set (retained)", PolicyLive},
+	{33, "bus_load", 1, "CAN bus load", PolicyBoth},
+	{34, "channel_degraded", 1, "Frame that keeps failing to decode", PolicyLive},
+	{35, "driver_input_event", 1, "Steering wheel input event", PolicyBoth},
+	{36, "energy_split", 1, "Pack energy split into drive and regen", PolicyLive},
+	{37, "internal_resistance", 1, "Accumulator internal resistance estimate", PolicyBoth},
+	{38, "lap", 1, "Completed lap summary", PolicyBoth},
+	{39, "pack_current_fused", 1, "Fused shunt and hall pack current", PolicyLive},
+	{40, "pack_health", 1, "Pack charge throughput, cycles and state of health", PolicyBoth},
+	{41, "precharge", 1, "Precharge attempt result", PolicyBoth},
+	{42, "session", 1, "Session started or ended", PolicyBoth},
+	{43, "shutdown_event", 1, "Shutdown circuit event", PolicyBoth},
+	{44, "snapshot_unavailable", 1, "Snapshot requested for a channel without data", PolicyLive},
+	{45, "track_position", 1, "Downsampled position for the live map", PolicyLive},
+	{46, "vehicle_state", 1, "Consolidated vehicle state", PolicyBoth},
+	{47, "imu_accel", 1, "IMU acceleration, decimated mean of the full-rate samples", PolicyLive},
+	{48, "imu_gyro", 1, "IMU angular rate, decimated mean of the full-rate samples", PolicyLive},
+}
+
+// recorded lists the channels whose events are records kept by their own
+// subsystem (alert history, laps, ...). They are always stored.
+var recorded = map[string]bool{
+	"alert":               true,
+	"internal_resistance": true,
+	"lap":                 true,
+	"pack_health":         true,
+	"precharge":           true,
+	"session":             true,
+	"vehicle_state":       true,
+}
+
+// overrides holds the configured policies by type.
+var overrides atomic.Pointer[map[string]Policy]
+
+var byType = func() map[string]Channel {
+	m := make(map[string]Channel, len(registry))
+	for _, c := range registry {
+		m[c.Type] = c
+	}
+	return m
+}()
+
+// Configure overrides the policy of channels, by type. Channels not stored by
+// default cannot be stored and recorded channels cannot be made live-only.
+func Configure(policies map[string]string) error {
+	m := make(map[string]Policy, len(policies))
+	for typ, v := range policies {
+		c, ok := byType[typ]
+		if !ok {
+			return fmt.Errorf("unknown live channel %q", typ)
+		}
+		p := Policy(v)
+		switch p {
+		case PolicyBoth, PolicyLive, PolicyStore:
+		default:
+			return fmt.Errorf("invalid policy %q for %s (both, live or store)", v, typ)
+		}
+		if c.Policy == PolicyLive && p != PolicyLive {
+			return fmt.Errorf("%s is computed live and has no storage", typ)
+		}
+		if recorded[typ] && p == PolicyLive {
+			return fmt.Errorf("%s events are always recorded", typ)
+		}
+		m[typ] = p
+	}
+	overrides.Store(&m)
+	return nil
+}
+
+// PolicyOf returns the configured policy of a message type. Unregistered types
+// are stored and broadcast.
+func PolicyOf(typ string) Policy {
+	if m := overrides.Load(); m != nil {
+		if p, ok := (*m)[typ]; ok {
+			return p
+		}
+	}
+	if c, ok := byType[typ]; ok {
+		return c.Policy
+	}
+	return PolicyBoth
+}
+
+// Stores reports whether values of the message type are stored.
+func Stores(typ string) bool {
+	return PolicyOf(typ) != PolicyLive
+}
+
+// Broadcasts reports whether values of the message type are broadcast.
+func Broadcasts(typ string) bool {
+	return PolicyOf(typ) != PolicyStore
+}
+
+// Lookup returns the registered channel of a message type, with its configured
+// policy.
+func Lookup(typ string) (Channel, bool) {
+	c, ok := byType[typ]
+	if ok {
+		c.Policy = PolicyOf(typ)
+	}
+	return c, ok
+}
+
+// All returns the registered channels ordered by ID, with their configured
+// policies.
+func All() []Channel {
+	out := append([]Channel(nil), registry...)
+	for i := range out {
+		out[i].Policy = PolicyOf(out[i].Type)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
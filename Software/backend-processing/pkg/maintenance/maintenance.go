@@ -0,0 +1,204 @@
+// maintenance.go
+//
+// Package maintenance implements the warm shutdown used to back up or vacuum the
+// database in the middle of a testing day. Quiescing pauses ingest, so frames
+// from every source are discarded (and counted) until it is resumed, waits for
+// the frames already queued for decoding, flushes every batch processor and then
+// checkpoints: the highest stored sequence number of every telemetry table is
+// compared with the last one assigned, and PostgreSQL is asked for a checkpoint.
+// The report says whether everything received before the pause is stored. The
+// API and live clients keep being served while ingest is paused.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/db"
+	"telem-system/pkg/processdata"
+	"time"
+)
+
+const (
+	// Time for frames that passed the ingest gate just before the pause to reach
+	// the decode queue
+	settleDelay = 100 * time.Millisecond
+
+	// Interval between checks of the decode queue
+	pollInterval = 10 * time.Millisecond
+
+	// Alert shown while ingest is paused
+	alertKey = "maintenance.quiesced"
+)
+
+var (
+	// ErrQuiesced is returned by Quiesce while ingest is already paused.
+	ErrQuiesced = errors.New("ingest is already quiesced")
+
+	// ErrNotQuiesced is returned by Resume when ingest is not paused.
+	ErrNotQuiesced = errors.New("ingest is not quiesced")
+)
+
+// Report describes the ingest state.
+type Report struct {
+	Quiesced        bool                             `json:"quiesced"`
+	Clean           bool                             `json:"clean"` // Everything received before the pause is stored
+	Since           *time.Time                       `json:"since,omitempty"`
+	By              string                           `json:"by,omitempty"`
+	QueuedFrames    int                              `json:"queued_frames"`    // Frames waiting to be decoded
+	DiscardedFrames uint64                           `json:"discarded_frames"` // Frames refused while paused
+	Processors      []processdata.PersistenceLag     `json:"processors"`
+	Checkpoint      []processdata.SequenceCheckpoint `json:"checkpoint,omitempty"`
+	DBCheckpoint    string                           `json:"db_checkpoint,omitempty"` // "ok" or the error
+	Problems        []string                         `json:"problems,omitempty"`
+}
+
+var (
+	mu         sync.Mutex // Serializes Quiesce and Resume
+	paused     atomic.Bool
+	discarded  atomic.Uint64
+	state      Report       // Result of the last Quiesce, guarded by mu
+	queueDepth func() int   // Frames queued for decoding; nil counts none
+	stateMu    sync.RWMutex // Guards state for Status
+)
+
+// SetQueueDepth installs the function returning the number of frames accepted
+// but not yet processed. It must be called before ingest starts.
+func SetQueueDepth(fn func() int) {
+	queueDepth = fn
+}
+
+// AcceptFrame reports whether ingest is open. Frames refused while ingest is
+// quiesced are counted.
+func AcceptFrame() bool {
+	if !paused.Load() {
+		return true
+	}
+	discarded.Add(1)
+	return false
+}
+
+// Quiesced reports whether ingest is paused.
+func Quiesced() bool {
+	return paused.Load()
+}
+
+// Quiesce pauses ingest, drains and flushes everything received before the pause
+// and checkpoints. Ingest stays paused until Resume, also when ctx ends before
+// the state is clean; the report then lists the problems.
+func Quiesce(ctx context.Context, by string) (Report, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if paused.Load() {
+		return Status(), ErrQuiesced
+	}
+
+	now := time.Now()
+	discarded.Store(0)
+	paused.Store(true)
+	setState(Report{Quiesced: true, Since: &now, By: by})
+	log.Printf("Ingest quiesced for maintenance by %s", by)
+	alerts.Raise(alerts.Alert{
+		Key:      alertKey,
+		Source:   "maintenance",
+		Severity: alerts.SeverityInfo,
+		Message:  fmt.Sprintf("Ingest paused for maintenance by %s", by),
+	})
+
+	r := Report{Quiesced: true, Since: &now, By: by}
+	if err := drain(ctx); err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("frames still queued for decoding: %v", err))
+	}
+	if err := processdata.FlushAll(ctx); err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("batches not flushed: %v", err))
+	}
+	checkpoint, err := processdata.CheckpointSequences(ctx)
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("sequence checkpoint failed: %v", err))
+	}
+	for _, c := range checkpoint {
+		if !c.Complete() {
+			r.Problems = append(r.Problems, fmt.Sprintf("%s: last stored sequence %d, assigned %d", c.Table, c.Stored, c.Assigned))
+		}
+	}
+	r.Checkpoint = checkpoint
+	r.DBCheckpoint = "ok"
+	if err := db.Checkpoint(ctx); err != nil {
+		r.DBCheckpoint = err.Error()
+	}
+	r.Clean = len(r.Problems) == 0
+	setState(r)
+
+	if r.Clean {
+		log.Printf("Ingest quiesced: all batches stored")
+	} else {
+		log.Printf("Ingest quiesced with problems: %v", r.Problems)
+	}
+	return Status(), nil
+}
+
+// Resume reopens ingest and returns the final report of the pause.
+func Resume(by string) (Report, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !paused.Load() {
+		return Status(), ErrNotQuiesced
+	}
+	r := Status()
+	paused.Store(false)
+	r.Quiesced = false
+	alerts.Resolve(alertKey)
+	log.Printf("Ingest resumed by %s after %s, %d frames discarded", by, time.Since(*r.Since).Round(time.Second), r.DiscardedFrames)
+	return r, nil
+}
+
+// Status returns the current ingest state. While quiesced it includes the result
+// of the drain and checkpoint.
+func Status() Report {
+	r := Report{}
+	if paused.Load() {
+		stateMu.RLock()
+		r = state
+		stateMu.RUnlock()
+	}
+	r.QueuedFrames = queued()
+	r.DiscardedFrames = discarded.Load()
+	r.Processors = processdata.GetPersistenceLag()
+	return r
+}
+
+func setState(r Report) {
+	stateMu.Lock()
+	state = r
+	stateMu.Unlock()
+}
+
+func queued() int {
+	if queueDepth == nil {
+		return 0
+	}
+	return queueDepth()
+}
+
+// drain waits until no frame is queued for decoding.
+func drain(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(settleDelay):
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for queued() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d frames: %w", queued(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// packhealth.go
+//
+// Package packhealth tracks the charge throughput and state of health of the
+// accumulator pack across sessions. When a session ends, its stored pack current
+// is integrated into discharge and charge amp-hours and added to the pack's
+// running totals; the total discharge divided by the nominal capacity gives the
+// equivalent full cycle count. If the state of charge swung far enough during the
+// session, the net discharge over that swing measures the usable capacity, and
+// the median of the recent measurements relative to the nominal capacity is the
+// state of health. One pack_health row is stored per pack and session.
+package packhealth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"telem-system/pkg/utils"
+	"time"
+)
+
+const (
+	// Defaults, overridable through Config
+	defaultPackID       = "default"
+	defaultMinSOCSwing  = 20.0 // Percentage points
+	defaultSummaryDelay = 5 * time.Second
+
+	// Capacity measurements forming the state of health
+	sohMeasurements = 5
+
+	// Longest gap between current samples that is integrated
+	maxIntegrationGap = 2 * time.Second
+)
+
+// Config describes the installed pack.
+type Config struct {
+	PackID            string        // Pack installed in the car
+	NominalCapacityAh float64       // Rated capacity; cycles and SOH need it
+	MinSOCSwing       float64       // Smallest SOC change (percentage points) that measures capacity
+	CurrentSign       float64       // Pack current sign convention, see utils.CurrentSign
+	SummaryDelay      time.Duration // Wait after the session end for its data to be stored
+}
+
+// tracker holds the configuration.
+type tracker struct {
+	mu          sync.Mutex
+	cfg         Config
+	queries     *db.Queries
+	subscribers []func(types.PackHealth)
+}
+
+var trk = &tracker{cfg: withDefaults(Config{})}
+
+// withDefaults fills empty and non-positive values with the defaults.
+func withDefaults(cfg Config) Config {
+	if cfg.PackID == "" {
+		cfg.PackID = defaultPackID
+	}
+	if cfg.NominalCapacityAh < 0 {
+		cfg.NominalCapacityAh = 0
+	}
+	if cfg.MinSOCSwing <= 0 {
+		cfg.MinSOCSwing = defaultMinSOCSwing
+	}
+	cfg.CurrentSign = utils.CurrentSign(cfg.CurrentSign)
+	if cfg.SummaryDelay <= 0 {
+		cfg.SummaryDelay = defaultSummaryDelay
+	}
+	return cfg
+}
+
+// Configure installs the pack description and the query helper used to read
+// session data. Empty and non-positive values select the defaults.
+func Configure(cfg Config, queries *db.Queries) {
+	trk.mu.Lock()
+	trk.cfg = withDefaults(cfg)
+	trk.queries = queries
+	trk.mu.Unlock()
+}
+
+// Settings returns the installed pack description.
+func Settings() Config {
+	trk.mu.Lock()
+	defer trk.mu.Unlock()
+	return trk.cfg
+}
+
+// Subscribe registers fn to be called with every stored pack health record.
+func Subscribe(fn func(types.PackHealth)) {
+	trk.mu.Lock()
+	trk.subscribers = append(trk.subscribers, fn)
+	trk.mu.Unlock()
+}
+
+// NoteSession records the throughput of a session once it has ended. Open
+// sessions are ignored.
+func NoteSession(s types.Session) {
+	if s.EndedAt == nil {
+		return
+	}
+	cfg := Settings()
+	time.AfterFunc(cfg.SummaryDelay, func() {
+		if err := trk.record(s); err != nil {
+			log.Printf("Error recording pack health of session %d: %v", s.ID, err)
+		}
+	})
+}
+
+// record computes and stores the pack health record of an ended session.
+func (t *tracker) record(s types.Session) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	t.mu.Lock()
+	cfg, queries := t.cfg, t.queries
+	t.mu.Unlock()
+	if queries == nil {
+		return errors.New("pack health not configured")
+	}
+
+	h, err := Throughput(ctx, queries, cfg, s.StartedAt, *s.EndedAt)
+	if err != nil {
+		return err
+	}
+	h.PackID, h.SessionID, h.RecordedAt = cfg.PackID, s.ID, *s.EndedAt
+
+	prev, ok, err := db.FetchLatestPackHealth(ctx, cfg.PackID)
+	if err != nil {
+		return err
+	}
+	h.TotalDischargeAh, h.TotalChargeAh = h.DischargeAh, h.ChargeAh
+	if ok {
+		h.TotalDischargeAh += prev.TotalDischargeAh
+		h.TotalChargeAh += prev.TotalChargeAh
+		h.SOH = prev.SOH
+	}
+
+	if cfg.NominalCapacityAh > 0 {
+		cycles := h.TotalDischargeAh / cfg.NominalCapacityAh
+		h.Cycles = &cycles
+		if h.CapacityAh != nil {
+			capacities, err := db.FetchPackCapacities(ctx, cfg.PackID, sohMeasurements-1)
+			if err != nil {
+				return err
+			}
+			soh := utils.Median(append(capacities, *h.CapacityAh)) / cfg.NominalCapacityAh
+			h.SOH = &soh
+		}
+	}
+
+	if h.ID, err = db.InsertPackHealth(ctx, h); err != nil {
+		return err
+	}
+	log.Printf("Pack %s: session %d discharged %.2f Ah, charged %.2f Ah (total %.1f Ah)",
+		h.PackID, h.SessionID, h.DischargeAh, h.ChargeAh, h.TotalDischargeAh)
+
+	t.mu.Lock()
+	subs := t.subscribers
+	t.mu.Unlock()
+	for _, fn := range subs {
+		fn(h)
+	}
+	return nil
+}
+
+// Throughput integrates the stored pack current over [from, to] into discharge
+// and charge amp-hours and measures the capacity from the state of charge swing.
+// Only the throughput and capacity fields of the result are set.
+func Throughput(ctx context.Context, queries *db.Queries, cfg Config, from, to time.Time) (types.PackHealth, error) {
+	var h types.PackHealth
+	current, err := channels.Fetch(ctx, queries, "pack_current.current", from, to)
+	if err != nil {
+		return h, err
+	}
+	for i := 1; i < len(current.Times); i++ {
+		dt := current.Times[i].Sub(current.Times[i-1])
+		if dt <= 0 || dt > maxIntegrationGap {
+			continue
+		}
+		ah := 0.5 * (current.Values[i] + current.Values[i-1]) * cfg.CurrentSign * dt.Hours()
+		if ah > 0 {
+			h.DischargeAh += ah
+		} else {
+			h.ChargeAh -= ah
+		}
+	}
+
+	soc, err := channels.Fetch(ctx, queries, "aculv_fd_1.state_of_charge", from, to)
+	if err != nil {
+		return h, err
+	}
+	if n := len(soc.Values); n > 1 {
+		swing := soc.Values[0] - soc.Values[n-1]
+		net := h.DischargeAh - h.ChargeAh
+		if math.Abs(swing) >= cfg.MinSOCSwing && net*swing > 0 {
+			capacity := net / (swing / 100)
+			h.CapacityAh = &capacity
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,192 @@
+// precharge.go
+//
+// Package precharge detects precharge sequences from the tractive and accumulator
+// voltages. A sequence starts when the tractive voltage rises above the start
+// voltage and is timed until it reaches the target fraction of the accumulator
+// voltage. After a settling period (the AIRs closing) the remaining difference
+// between the two voltages is measured. Every attempt is reported with pass/fail,
+// and out-of-spec attempts raise an alert.
+package precharge
+
+import (
+	"fmt"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Failure reasons
+const (
+	ReasonTooFast    = "too_fast"    // Target reached sooner than the minimum duration
+	ReasonTimeout    = "timeout"     // Target not reached within the maximum duration
+	ReasonAborted    = "aborted"     // Tractive voltage collapsed before reaching the target
+	ReasonFinalDelta = "final_delta" // Voltages still apart after settling
+)
+
+const (
+	// Defaults, overridable through Config
+	defaultStartVoltage   = 5.0
+	defaultTargetFraction = 0.9 // FSAE requires at least 90% before closing the AIRs
+	defaultMinDuration    = 500 * time.Millisecond
+	defaultMaxDuration    = 8 * time.Second
+	defaultSettleTime     = time.Second
+	defaultMaxFinalDelta  = 10.0
+
+	// Alert raised by an out-of-spec attempt, resolved by the next passing one
+	alertKey = "precharge_out_of_spec"
+)
+
+// Config holds the detection thresholds and the pass criteria.
+type Config struct {
+	StartVoltage   float64       // Tractive voltage that starts a sequence
+	TargetFraction float64       // Fraction of the accumulator voltage that ends it
+	MinDuration    time.Duration // Acceptable precharge duration range
+	MaxDuration    time.Duration
+	SettleTime     time.Duration // Wait after reaching the target before measuring the final delta
+	MaxFinalDelta  float64       // Maximum accumulator minus tractive voltage after settling
+}
+
+// Detector phases
+const (
+	phaseIdle     = iota // Waiting for the tractive voltage to rise
+	phaseCharging        // Sequence in progress
+	phaseSettling        // Target reached, waiting to measure the final delta
+	phaseDone            // Attempt reported, waiting for the tractive voltage to fall
+)
+
+// detector holds the sequence state.
+type detector struct {
+	mu          sync.Mutex
+	cfg         Config
+	phase       int
+	start       time.Time
+	reached     time.Time // Target reached
+	subscribers []func(types.PrechargeAttempt)
+}
+
+var det = &detector{cfg: withDefaults(Config{})}
+
+// withDefaults fills non-positive values with the defaults.
+func withDefaults(cfg Config) Config {
+	if cfg.StartVoltage <= 0 {
+		cfg.StartVoltage = defaultStartVoltage
+	}
+	if cfg.TargetFraction <= 0 || cfg.TargetFraction > 1 {
+		cfg.TargetFraction = defaultTargetFraction
+	}
+	if cfg.MinDuration <= 0 {
+		cfg.MinDuration = defaultMinDuration
+	}
+	if cfg.MaxDuration <= 0 {
+		cfg.MaxDuration = defaultMaxDuration
+	}
+	if cfg.SettleTime <= 0 {
+		cfg.SettleTime = defaultSettleTime
+	}
+	if cfg.MaxFinalDelta <= 0 {
+		cfg.MaxFinalDelta = defaultMaxFinalDelta
+	}
+	return cfg
+}
+
+// Configure installs the thresholds. Non-positive values select the defaults.
+func Configure(cfg Config) {
+	det.mu.Lock()
+	det.cfg = withDefaults(cfg)
+	det.mu.Unlock()
+}
+
+// Subscribe registers fn to be called with every completed attempt. fn is called
+// synchronously and must not block.
+func Subscribe(fn func(types.PrechargeAttempt)) {
+	det.mu.Lock()
+	det.subscribers = append(det.subscribers, fn)
+	det.mu.Unlock()
+}
+
+// Observe feeds a sample of the accumulator and tractive voltages.
+func Observe(accumulatorV, tractiveV float64, t time.Time) {
+	det.mu.Lock()
+	a, done := det.step(accumulatorV, tractiveV, t)
+	subs := det.subscribers
+	det.mu.Unlock()
+
+	if !done {
+		return
+	}
+	for _, fn := range subs {
+		fn(a)
+	}
+	if a.Passed {
+		alerts.Resolve(alertKey)
+		return
+	}
+	alerts.Raise(alerts.Alert{
+		Key:      alertKey,
+		Source:   "precharge",
+		Severity: alerts.SeverityWarning,
+		Message:  fmt.Sprintf("Precharge out of spec (%s): %.2fs, final delta %.1f V", a.Reason, a.DurationS, a.FinalDeltaV),
+		Value:    a.DurationS,
+		RaisedAt: a.EndedAt,
+	})
+}
+
+// step advances the state machine and returns the attempt when one completes.
+// The caller holds d.mu.
+func (d *detector) step(acc, ts float64, t time.Time) (types.PrechargeAttempt, bool) {
+	cfg := d.cfg
+	switch d.phase {
+	case phaseIdle:
+		if ts >= cfg.StartVoltage && acc > cfg.StartVoltage {
+			d.phase, d.start = phaseCharging, t
+		}
+	case phaseCharging:
+		elapsed := t.Sub(d.start)
+		switch {
+		case ts < cfg.StartVoltage:
+			return d.finish(t, elapsed, acc, ts, ReasonAborted), true
+		case ts >= cfg.TargetFraction*acc:
+			d.phase, d.reached = phaseSettling, t
+		case elapsed > cfg.MaxDuration:
+			return d.finish(t, elapsed, acc, ts, ReasonTimeout), true
+		}
+	case phaseSettling:
+		if t.Sub(d.reached) < cfg.SettleTime && ts >= cfg.StartVoltage {
+			break
+		}
+		duration := d.reached.Sub(d.start)
+		reason := ""
+		switch {
+		case duration < cfg.MinDuration:
+			reason = ReasonTooFast
+		case duration > cfg.MaxDuration:
+			reason = ReasonTimeout
+		case acc-ts > cfg.MaxFinalDelta:
+			reason = ReasonFinalDelta
+		}
+		return d.finish(t, duration, acc, ts, reason), true
+	case phaseDone:
+		if ts < cfg.StartVoltage {
+			d.phase = phaseIdle
+		}
+	}
+	return types.PrechargeAttempt{}, false
+}
+
+// finish ends the attempt in progress.
+func (d *detector) finish(t time.Time, duration time.Duration, acc, ts float64, reason string) types.PrechargeAttempt {
+	d.phase = phaseDone
+	if ts < d.cfg.StartVoltage {
+		d.phase = phaseIdle
+	}
+	return types.PrechargeAttempt{
+		StartedAt:    d.start,
+		EndedAt:      t,
+		DurationS:    duration.Seconds(),
+		AccumulatorV: acc,
+		FinalDeltaV:  acc - ts,
+		Passed:       reason == "",
+		Reason:       reason,
+	}
+}
@@ -0,0 +1,179 @@
+// alerts.go
+//
+// Live forwarding and history of server-side alerts. Every alert state change is
+// broadcast to dashboard clients as an "alert" message and recorded in the
+// alert_history table.
+package processdata
+
+import (
+	"context"
+	"log"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// InitAlertBroadcast forwards alert state changes to live clients.
+func InitAlertBroadcast() {
+	alerts.Subscribe(broadcastAlert)
+}
+
+// broadcastAlert sends a single alert state change to live clients.
+func broadcastAlert(a alerts.Alert) {
+	t := a.RaisedAt
+	if !a.Active {
+		t = a.ResolvedAt
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	payload := buildPayload("alert", t, map[string]interface{}{
+		"key":       a.Key,
+		"source":    a.Source,
+		"severity":  a.Severity,
+		"message":   a.Message,
+		"value":     a.Value,
+		"threshold": a.Threshold,
+		"active":    a.Active,
+	})
+	broadcastTelemetry(payload)
+}
+
+// Size of the queue between alert notifications and the history writer
+const alertHistoryQueueSize = 256
+
+// InitAlertHistory persists every alert occurrence to the alert_history table so
+// alerts can be reviewed and exported after the event. Writes happen on a single
+// goroutine in notification order; the writer stops when ctx is cancelled.
+// Alerts left active by a previous run are resolved first.
+func InitAlertHistory(ctx context.Context) {
+	if n, err := db.ResolveOpenAlertRecords(ctx, time.Now()); err != nil {
+		log.Printf("Error resolving stale alerts: %v", err)
+	} else if n > 0 {
+		log.Printf("Resolved %d alert(s) left active by a previous run", n)
+	}
+
+	queue := make(chan alerts.Alert, alertHistoryQueueSize)
+	alerts.Subscribe(func(a alerts.Alert) {
+		select {
+		case queue <- a:
+		default:
+			log.Printf("Alert history queue full, dropping %s state change", a.Key)
+		}
+	})
+
+	go func() {
+		for {
+			select {
+			case a := <-queue:
+				if err := storeAlert(a); err != nil {
+					log.Printf("Error storing alert %s: %v", a.Key, err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// storeAlert writes a single alert state change.
+func storeAlert(a alerts.Alert) error {
+	ctx := context.Background()
+	if !a.Active {
+		return db.ResolveAlertRecord(ctx, a.Key, a.RaisedAt, a.ResolvedAt)
+	}
+	return db.UpsertAlertRecord(ctx, types.AlertRecord{
+		Key:       a.Key,
+		Source:    a.Source,
+		Severity:  a.Severity,
+		Message:   a.Message,
+		Value:     a.Value,
+		Threshold: a.Threshold,
+		RaisedAt:  a.RaisedAt,
+	})
+}
+
+// RetainFunc is assigned by main to store a state message on the live WebSocket
+// hub, which broadcasts it and replays it to clients when they connect.
+var RetainFunc func(key string, msg []byte)
+
+// Retained state key of the active alert set
+const alertStateKey = "alert_state"
+
+// Alert severities by rank, used to report the highest active severity
+var severityRank = map[string]int{
+	alerts.SeverityInfo:     1,
+	alerts.SeverityWarning:  2,
+	alerts.SeverityCritical: 3,
+}
+
+// InitAlertState publishes the set of active alerts as a retained "alert_state"
+// message, sent to every dashboard on connect and again whenever an alert is
+// raised, changed or resolved, so alarm banners on all dashboards show the server's
+// view. Bursts of changes are coalesced into a single message; the publisher stops
+// when ctx is cancelled.
+func InitAlertState(ctx context.Context) {
+	changed := make(chan struct{}, 1)
+	alerts.Subscribe(func(alerts.Alert) {
+		select {
+		case changed <- struct{}{}:
+		default: // A publish is already pending and will include this change
+		}
+	})
+
+	go func() {
+		publishAlertState()
+		for {
+			select {
+			case <-changed:
+				publishAlertState()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// publishAlertState sends the current active alert set.
+func publishAlertState() {
+	if RetainFunc == nil {
+		return
+	}
+	active := alerts.Active()
+	list := make([]interface{}, 0, len(active))
+	counts := make(map[string]interface{}, len(severityRank))
+	for severity := range severityRank {
+		counts[severity] = 0.0
+	}
+	highest := ""
+	for _, a := range active {
+		list = append(list, map[string]interface{}{
+			"key":       a.Key,
+			"source":    a.Source,
+			"severity":  a.Severity,
+			"message":   a.Message,
+			"value":     a.Value,
+			"threshold": a.Threshold,
+			"raised_at": float64(a.RaisedAt.UnixMilli()) / 1000,
+		})
+		if n, ok := counts[a.Severity].(float64); ok {
+			counts[a.Severity] = n + 1 // structpb only takes float64 numbers
+		}
+		if severityRank[a.Severity] > severityRank[highest] {
+			highest = a.Severity
+		}
+	}
+
+	payload := buildPayload(alertStateKey, time.Now(), map[string]interface{}{
+		"alerts":           list,
+		"counts":           counts,
+		"highest_severity": highest,
+	})
+	bin, err := marshalTelemetry(payload)
+	if err != nil {
+		log.Printf("Error encoding alert state: %v", err)
+		return
+	}
+	RetainFunc(alertStateKey, bin)
+}
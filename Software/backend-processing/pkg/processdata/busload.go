@@ -0,0 +1,219 @@
+// busload.go
+//
+// CAN bus load estimation. Every frame seen at ingest is counted per frame ID;
+// once per sampling window the counts are turned into frames/sec, bytes/sec and an
+// estimated bus utilisation (including worst-case bit stuffing), which is stored,
+// broadcast as "bus_load" and kept for the stats endpoint.
+package processdata
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"telem-system/pkg/db"
+	"telem-system/pkg/livechannels"
+	"telem-system/pkg/types"
+	"time"
+)
+
+const (
+	// Default nominal bus bitrate used when none is configured
+	defaultBusBitrate = 500000
+
+	// Default sampling window for bus load computation
+	defaultBusLoadInterval = time.Second
+
+	// Number of busiest frame IDs reported per sample
+	busLoadTopIDs = 10
+)
+
+// busIDCounter accumulates traffic for a single frame ID within the current window.
+type busIDCounter struct {
+	frames uint64
+	bytes  uint64
+	bits   uint64
+}
+
+// busMonitor holds the per-window counters and the last computed sample.
+type busMonitor struct {
+	mu          sync.Mutex
+	bitrate     float64
+	counters    map[uint32]*busIDCounter
+	windowStart time.Time
+	last        types.BusLoad_Data
+	lastSeen    map[uint32]time.Time // Arrival time of the latest frame per ID
+}
+
+var busMon = &busMonitor{
+	bitrate:     defaultBusBitrate,
+	counters:    make(map[uint32]*busIDCounter),
+	windowStart: time.Now(),
+	lastSeen:    make(map[uint32]time.Time),
+}
+
+// frameBits estimates the on-wire size of a classic CAN frame in bits, including
+// worst-case bit stuffing (standard: 47 + 8n + (34+8n-1)/4, extended: 67 + 8n + (54+8n-1)/4).
+func frameBits(dlc int, extended bool) uint64 {
+	if dlc < 0 {
+		dlc = 0
+	}
+	n := uint64(dlc)
+	if extended {
+		return 67 + 8*n + (54+8*n-1)/4
+	}
+	return 47 + 8*n + (34+8*n-1)/4
+}
+
+// RecordFrame counts one received frame towards the current bus load window.
+// It is called from the ingest path for every frame, whether or not it is decoded.
+func RecordFrame(frameID uint32, dlc int, extended bool) {
+	busMon.mu.Lock()
+	c, ok := busMon.counters[frameID]
+	if !ok {
+		c = &busIDCounter{}
+		busMon.counters[frameID] = c
+	}
+	c.frames++
+	c.bytes += uint64(dlc)
+	c.bits += frameBits(dlc, extended)
+	busMon.lastSeen[frameID] = time.Now()
+	busMon.mu.Unlock()
+}
+
+// FrameLastSeen returns when a frame with the given ID (as seen on the bus, before
+// remapping) was last received, or the zero time if it never was.
+func FrameLastSeen(frameID uint32) time.Time {
+	busMon.mu.Lock()
+	defer busMon.mu.Unlock()
+	return busMon.lastSeen[frameID]
+}
+
+// GetBusLoadStats returns the most recently computed bus load sample.
+func GetBusLoadStats() types.BusLoad_Data {
+	busMon.mu.Lock()
+	defer busMon.mu.Unlock()
+	sample := busMon.last
+	sample.TopIDs = append([]types.BusLoadID(nil), busMon.last.TopIDs...)
+	return sample
+}
+
+// InitBusMonitor starts the bus load sampler. bitrate is the nominal bus bitrate in
+// bit/s and intervalMs the sampling window; non-positive values select the defaults.
+func InitBusMonitor(ctx context.Context, bitrate int, intervalMs int) {
+	interval := defaultBusLoadInterval
+	if intervalMs > 0 {
+		interval = time.Duration(intervalMs) * time.Millisecond
+	}
+
+	busMon.mu.Lock()
+	if bitrate > 0 {
+		busMon.bitrate = float64(bitrate)
+	}
+	busMon.windowStart = time.Now()
+	busMon.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sample := busMon.sample(time.Now())
+
+				// Broadcast for real-time display
+				broadcastBusLoad(sample)
+
+				// Persist asynchronously so a slow database never stalls sampling
+				if livechannels.Stores("bus_load") {
+					go func(s types.BusLoad_Data) {
+						if err := db.InsertBusLoadData(context.Background(), s); err != nil {
+							log.Printf("Error inserting bus load sample: %v", err)
+						}
+					}(sample)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// sample converts the current window counters into a BusLoad_Data record and resets them.
+func (m *busMonitor) sample(now time.Time) types.BusLoad_Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elapsed := now.Sub(m.windowStart).Seconds()
+	if elapsed <= 0 {
+		elapsed = 1
+	}
+
+	var totalFrames, totalBytes, totalBits uint64
+	ids := make([]types.BusLoadID, 0, len(m.counters))
+	idBits := make(map[uint32]uint64, len(m.counters))
+	for id, c := range m.counters {
+		totalFrames += c.frames
+		totalBytes += c.bytes
+		totalBits += c.bits
+		idBits[id] = c.bits
+		ids = append(ids, types.BusLoadID{
+			FrameID:      id,
+			FramesPerSec: float64(c.frames) / elapsed,
+			BytesPerSec:  float64(c.bytes) / elapsed,
+		})
+	}
+	for i := range ids {
+		if totalBits > 0 {
+			ids[i].Share = float64(idBits[ids[i].FrameID]) / float64(totalBits)
+		}
+	}
+
+	// Busiest IDs first; ties broken by frame ID to keep output stable
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Share != ids[j].Share {
+			return ids[i].Share > ids[j].Share
+		}
+		return ids[i].FrameID < ids[j].FrameID
+	})
+	if len(ids) > busLoadTopIDs {
+		ids = ids[:busLoadTopIDs]
+	}
+
+	bitsPerSec := float64(totalBits) / elapsed
+	sample := types.BusLoad_Data{
+		Timestamp:    now,
+		FramesPerSec: float64(totalFrames) / elapsed,
+		BytesPerSec:  float64(totalBytes) / elapsed,
+		BitsPerSec:   bitsPerSec,
+		LoadPercent:  bitsPerSec / m.bitrate * 100,
+		TopIDs:       ids,
+	}
+
+	m.last = sample
+	m.counters = make(map[uint32]*busIDCounter, len(m.counters))
+	m.windowStart = now
+	return sample
+}
+
+// broadcastBusLoad sends a bus load sample to live clients.
+func broadcastBusLoad(s types.BusLoad_Data) {
+	topIDs := make([]interface{}, 0, len(s.TopIDs))
+	for _, id := range s.TopIDs {
+		topIDs = append(topIDs, map[string]interface{}{
+			"frame_id":       float64(id.FrameID),
+			"frames_per_sec": id.FramesPerSec,
+			"bytes_per_sec":  id.BytesPerSec,
+			"share":          id.Share,
+		})
+	}
+	payload := buildPayload("bus_load", s.Timestamp, map[string]interface{}{
+		"frames_per_sec": s.FramesPerSec,
+		"bytes_per_sec":  s.BytesPerSec,
+		"bits_per_sec":   s.BitsPerSec,
+		"load_percent":   s.LoadPercent,
+		"top_ids":        topIDs,
+	})
+	broadcastTelemetry(payload)
+}
@@ -0,0 +1,150 @@
+// celldelta.go
+//
+// Delta compression for cell_data. In "delta" storage mode a full row (keyframe)
+// is written every N samples and the samples in between only record the cells that
+// differ from that keyframe. The cell_data_full view reconstructs full rows on read.
+package processdata
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+)
+
+const (
+	// Storage modes for cell data
+	CellStorageFull  = "full"
+	CellStorageDelta = "delta"
+
+	// Default number of samples between keyframes
+	defaultKeyframeInterval = 50
+
+	// A delta touching more than this many cells is stored as a keyframe instead
+	maxDeltaCells = 64
+)
+
+// cellDeltaEncoder splits cell samples into keyframes and sparse deltas.
+type cellDeltaEncoder struct {
+	mu               sync.Mutex
+	mode             string
+	keyframeInterval int
+	deadband         float64
+	keyframe         *types.Cell_Data // Last keyframe written
+	sinceKeyframe    int              // Samples encoded since the last keyframe
+}
+
+var cellEncoder = &cellDeltaEncoder{
+	mode:             CellStorageFull,
+	keyframeInterval: defaultKeyframeInterval,
+}
+
+// SetCellStorageMode configures how cell data is stored. mode is "full" (every row
+// stored as-is) or "delta". keyframeInterval and deadband only apply in delta mode;
+// non-positive intervals select the default.
+func SetCellStorageMode(mode string, keyframeInterval int, deadband float64) {
+	cellEncoder.mu.Lock()
+	defer cellEncoder.mu.Unlock()
+
+	if mode != CellStorageDelta {
+		mode = CellStorageFull
+	}
+	if keyframeInterval <= 0 {
+		keyframeInterval = defaultKeyframeInterval
+	}
+	if deadband < 0 {
+		deadband = 0
+	}
+	cellEncoder.mode = mode
+	cellEncoder.keyframeInterval = keyframeInterval
+	cellEncoder.deadband = deadband
+	cellEncoder.keyframe = nil
+	cellEncoder.sinceKeyframe = 0
+}
+
+// reset forces the next sample to be written as a keyframe.
+func (e *cellDeltaEncoder) reset() {
+	e.mu.Lock()
+	e.keyframe = nil
+	e.sinceKeyframe = 0
+	e.mu.Unlock()
+}
+
+// encode splits a batch into keyframe rows and delta rows, in sample order.
+func (e *cellDeltaEncoder) encode(batch []types.Cell_Data) ([]types.Cell_Data, []types.CellDelta_Data) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.mode != CellStorageDelta {
+		return batch, nil
+	}
+
+	keyframes := make([]types.Cell_Data, 0, 1)
+	deltas := make([]types.CellDelta_Data, 0, len(batch))
+	for i := range batch {
+		sample := batch[i]
+		if e.keyframe != nil && e.sinceKeyframe < e.keyframeInterval {
+			if delta, ok := e.diff(&sample); ok {
+				deltas = append(deltas, delta)
+				e.sinceKeyframe++
+				continue
+			}
+		}
+
+		// Write a new keyframe
+		kf := sample
+		e.keyframe = &kf
+		e.sinceKeyframe = 0
+		keyframes = append(keyframes, sample)
+	}
+	return keyframes, deltas
+}
+
+// diff computes the delta of sample against the current keyframe. It reports false
+// when the delta would be too large to be worth storing sparsely.
+func (e *cellDeltaEncoder) diff(sample *types.Cell_Data) (types.CellDelta_Data, bool) {
+	// A delta cannot express a missing cell (it would read back as the keyframe
+	// value), so such samples become keyframes
+	if len(sample.Missing) > 0 {
+		return types.CellDelta_Data{}, false
+	}
+	delta := types.CellDelta_Data{
+		Timestamp:         sample.Timestamp,
+		KeyframeTimestamp: e.keyframe.Timestamp,
+		Seq:               sample.Seq,
+	}
+	for i := 1; i <= 128; i++ {
+		v := getCellValue(sample, i)
+		if math.Abs(v-getCellValue(e.keyframe, i)) > e.deadband {
+			delta.CellIndices = append(delta.CellIndices, int16(i))
+			delta.CellValues = append(delta.CellValues, v)
+			if len(delta.CellIndices) > maxDeltaCells {
+				return types.CellDelta_Data{}, false
+			}
+		}
+	}
+	// Arrays must not be NULL in the database
+	if delta.CellIndices == nil {
+		delta.CellIndices = []int16{}
+		delta.CellValues = []float64{}
+	}
+	return delta, true
+}
+
+// storeCellBatch writes a batch of cell samples using the configured storage mode.
+func storeCellBatch(cells []types.Cell_Data) {
+	keyframes, deltas := cellEncoder.encode(cells)
+
+	// Keyframes first so deltas always reference an existing row
+	if err := db.InsertCellDataBatch(context.Background(), keyframes); err != nil {
+		log.Printf("Error inserting cell data batch: %v", err)
+		// Deltas referencing the lost keyframe cannot be reconstructed
+		cellEncoder.reset()
+		return
+	}
+	if err := db.InsertCellDeltaBatch(context.Background(), deltas); err != nil {
+		log.Printf("Error inserting cell delta batch: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+// channelhealth.go
+//
+// Per-channel decode health for the dashboard. Frames that fail to decode, carry
+// signals the decoder could not extract, or carry values outside the range in the
+// CAN definition would otherwise be plotted as zeros (ParseFloatSignal's default).
+// When a frame ID fails consistently, a "channel_degraded" message names the
+// affected signals and the reason so the dashboard can grey out the gauges; a
+// second message with degraded=false clears it once frames decode cleanly again.
+package processdata
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"telem-system/pkg/types"
+	"time"
+)
+
+const (
+	// Consecutive bad frames before a channel is reported degraded
+	degradeAfterFrames = 10
+
+	// Consecutive good frames before a degraded channel is cleared
+	recoverAfterFrames = 5
+
+	// While degraded, the status is repeated at this interval so clients that
+	// connect later pick it up
+	degradedRepeatInterval = 5 * time.Second
+)
+
+// channelHealth is the decode health of a single frame ID.
+type channelHealth struct {
+	bad, good  int // Consecutive bad and good frames
+	degraded   bool
+	reason     string
+	signals    []string
+	lastReport time.Time
+}
+
+var (
+	channelHealthMu sync.Mutex
+	channelStates   = make(map[uint32]*channelHealth)
+)
+
+// NoteDecodeResult records the outcome of decoding a frame. err is the decode
+// error, if any; otherwise the decoded signals are checked against the definition.
+func NoteDecodeResult(msgDef types.Message, decoded map[string]string, err error) {
+	reason, signals := frameFault(msgDef, decoded, err)
+	now := time.Now()
+
+	channelHealthMu.Lock()
+	h, ok := channelStates[msgDef.FrameID]
+	if !ok {
+		h = &channelHealth{}
+		channelStates[msgDef.FrameID] = h
+	}
+	report := false
+	if reason != "" {
+		h.bad, h.good = h.bad+1, 0
+		h.reason, h.signals = reason, signals
+		if h.bad >= degradeAfterFrames && (!h.degraded || now.Sub(h.lastReport) >= degradedRepeatInterval) {
+			if !h.degraded {
+				log.Printf("Channel %s (frame %d) degraded: %s", msgDef.Name, msgDef.FrameID, reason)
+			}
+			h.degraded, report = true, true
+		}
+	} else {
+		h.good, h.bad = h.good+1, 0
+		if h.degraded && h.good >= recoverAfterFrames {
+			log.Printf("Channel %s (frame %d) recovered", msgDef.Name, msgDef.FrameID)
+			h.degraded, report = false, true
+		}
+	}
+	var status map[string]interface{}
+	if report {
+		h.lastReport = now
+		status = channelStatus(msgDef, h)
+	}
+	channelHealthMu.Unlock()
+
+	if status != nil {
+		broadcastTelemetry(buildPayload("channel_degraded", now, status))
+	}
+}
+
+// frameFault returns why a decoded frame cannot be trusted and the affected
+// signals, or an empty reason for a good frame.
+func frameFault(msgDef types.Message, decoded map[string]string, err error) (string, []string) {
+	if err != nil {
+		signals := make([]string, len(msgDef.Signals))
+		for i, sig := range msgDef.Signals {
+			signals[i] = sig.Name
+		}
+		return "decode error: " + err.Error(), signals
+	}
+
+	var reason string
+	var signals []string
+	for _, sig := range msgDef.Signals {
+		val := decoded[sig.Name]
+		if val == "" {
+			signals = append(signals, sig.Name)
+			if reason == "" {
+				reason = fmt.Sprintf("signal %s could not be decoded", sig.Name)
+			}
+			continue
+		}
+		if outOfRange(sig, val) {
+			signals = append(signals, sig.Name)
+			if reason == "" {
+				reason = fmt.Sprintf("signal %s out of range: %s outside [%g, %g]", sig.Name, val, *sig.Minimum, *sig.Maximum)
+			}
+		}
+	}
+	if len(signals) > 1 {
+		reason += fmt.Sprintf(" (and %d more)", len(signals)-1)
+	}
+	return reason, signals
+}
+
+// outOfRange reports whether a decoded value lies outside the range of its
+// signal definition. Only numeric values are range checked; a minimum equal to the
+// maximum means the definition does not give a range.
+func outOfRange(sig types.Signal, val string) bool {
+	if sig.Minimum == nil || sig.Maximum == nil || *sig.Minimum >= *sig.Maximum {
+		return false
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return false
+	}
+	return f < *sig.Minimum || f > *sig.Maximum
+}
+
+// Whether out-of-range signals are stored as NULL
+var nullOutOfRange atomic.Bool
+
+// SetNullOutOfRange selects whether signals outside the range of their CAN
+// definition are dropped (stored as NULL and broadcast as null) rather than
+// stored as decoded.
+func SetNullOutOfRange(on bool) {
+	nullOutOfRange.Store(on)
+}
+
+// DropInvalidSignals blanks the out-of-range signals of a decoded frame when
+// enabled by SetNullOutOfRange, so the processors record them as missing. It is
+// called after NoteDecodeResult, which still sees the decoded values.
+func DropInvalidSignals(msgDef types.Message, decoded map[string]string) {
+	if !nullOutOfRange.Load() {
+		return
+	}
+	for _, sig := range msgDef.Signals {
+		if val := decoded[sig.Name]; val != "" && outOfRange(sig, val) {
+			decoded[sig.Name] = ""
+		}
+	}
+}
+
+// channelStatus builds the "channel_degraded" payload. The caller holds
+// channelHealthMu.
+func channelStatus(msgDef types.Message, h *channelHealth) map[string]interface{} {
+	signals := make([]interface{}, len(h.signals))
+	for i, s := range h.signals {
+		signals[i] = s
+	}
+	status := map[string]interface{}{
+		"frame_id": float64(msgDef.FrameID),
+		"channel":  msgDef.Name,
+		"degraded": h.degraded,
+		"signals":  signals,
+		"reason":   "",
+	}
+	if h.degraded {
+		status["reason"] = h.reason
+	}
+	return status
+}
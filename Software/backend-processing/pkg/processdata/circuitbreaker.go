@@ -0,0 +1,242 @@
+// circuitbreaker.go
+//
+// Circuit breaker of the live broadcast. When the hub's queue keeps rejecting
+// messages (threshold consecutive drops) the circuit opens and messages are
+// dropped without trying the hub, giving the clients time to catch up. After the
+// open period the circuit is half-open: messages are tried again, the first one
+// accepted closes the circuit and a rejected one opens it again. Transitions are
+// counted for the stats endpoint, logged, and an open circuit raises a warning.
+package processdata
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"telem-system/pkg/alerts"
+	"time"
+)
+
+// Circuit states, as reported in the stats and server metrics
+const (
+	CircuitClosed   int32 = 0 // Normal operation
+	CircuitOpen     int32 = 1 // Dropping without trying the hub
+	CircuitHalfOpen int32 = 2 // Trying the hub again
+)
+
+const (
+	// Defaults, overridable through ConfigureCircuitBreaker
+	defaultCircuitThreshold = 100             // Consecutive drops opening the circuit
+	defaultCircuitOpenFor   = 5 * time.Second // Time open before trying again
+
+	// Alert raised while the circuit is open
+	circuitAlertKey = "throttler.circuit_open"
+)
+
+// CircuitBreakerStats reports the breaker state and its transitions.
+type CircuitBreakerStats struct {
+	State            string     `json:"state"`
+	Threshold        int32      `json:"threshold"`
+	OpenForMs        int64      `json:"open_for_ms"`
+	ConsecutiveDrops int32      `json:"consecutive_drops"`
+	Opened           uint64     `json:"opened"`      // Transitions to open
+	HalfOpened       uint64     `json:"half_opened"` // Transitions to half-open
+	Closed           uint64     `json:"closed"`      // Transitions back to closed
+	LastTransition   *time.Time `json:"last_transition,omitempty"`
+}
+
+// circuitBreaker tracks the state of the broadcast circuit. The state and drop
+// count are read lock-free on the broadcast path; transitions take mu.
+type circuitBreaker struct {
+	state atomic.Int32
+	drops atomic.Int32 // Consecutive drops
+
+	mu          sync.Mutex
+	threshold   int32
+	openFor     time.Duration
+	now         func() time.Time
+	changedAt   time.Time
+	transitions [3]uint64 // Entries into each state
+	notify      func(from, to int32)
+}
+
+// newCircuitBreaker returns a closed breaker. notify, if set, is called after
+// every transition, outside the breaker's lock.
+func newCircuitBreaker(threshold int32, openFor time.Duration, now func() time.Time, notify func(from, to int32)) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, openFor: openFor, now: now, notify: notify}
+}
+
+var broadcastCircuit = newCircuitBreaker(defaultCircuitThreshold, defaultCircuitOpenFor, time.Now, reportCircuitTransition)
+
+// ConfigureCircuitBreaker sets the consecutive drops opening the circuit and how
+// long it stays open; non-positive values select the defaults.
+func ConfigureCircuitBreaker(threshold int, openFor time.Duration) {
+	if threshold <= 0 {
+		threshold = defaultCircuitThreshold
+	}
+	if openFor <= 0 {
+		openFor = defaultCircuitOpenFor
+	}
+	b := broadcastCircuit
+	b.mu.Lock()
+	b.threshold, b.openFor = int32(threshold), openFor
+	b.mu.Unlock()
+}
+
+// GetCircuitBreakerStats returns the state and transition counts of the
+// broadcast circuit.
+func GetCircuitBreakerStats() CircuitBreakerStats {
+	return broadcastCircuit.stats()
+}
+
+// ResetCircuitBreaker forces the circuit breaker back to normal state
+func ResetCircuitBreaker() {
+	broadcastCircuit.reset()
+}
+
+// allow reports whether a message may be offered to the hub. An open circuit
+// turns half-open once the open period has passed.
+func (b *circuitBreaker) allow() bool {
+	if b.state.Load() != CircuitOpen {
+		return true
+	}
+	b.mu.Lock()
+	if b.state.Load() != CircuitOpen {
+		b.mu.Unlock()
+		return true
+	}
+	if b.now().Sub(b.changedAt) < b.openFor {
+		b.mu.Unlock()
+		return false
+	}
+	from := b.transitionLocked(CircuitHalfOpen)
+	b.mu.Unlock()
+	b.report(from, CircuitHalfOpen)
+	return true
+}
+
+// success records a message accepted by the hub, closing a half-open circuit.
+func (b *circuitBreaker) success() {
+	if b.drops.Load() != 0 {
+		b.drops.Store(0)
+	}
+	if b.state.Load() != CircuitHalfOpen {
+		return
+	}
+	b.mu.Lock()
+	if b.state.Load() != CircuitHalfOpen {
+		b.mu.Unlock()
+		return
+	}
+	from := b.transitionLocked(CircuitClosed)
+	b.mu.Unlock()
+	b.report(from, CircuitClosed)
+}
+
+// drop records a message rejected by the hub. The circuit opens after threshold
+// consecutive drops, or on any drop while half-open.
+func (b *circuitBreaker) drop() {
+	n := b.drops.Add(1)
+	state := b.state.Load()
+	if state == CircuitOpen || (state == CircuitClosed && n < b.thresholdValue()) {
+		return
+	}
+	b.mu.Lock()
+	state = b.state.Load()
+	if state == CircuitOpen || (state == CircuitClosed && b.drops.Load() < b.threshold) {
+		b.mu.Unlock()
+		return
+	}
+	from := b.transitionLocked(CircuitOpen)
+	b.mu.Unlock()
+	b.report(from, CircuitOpen)
+}
+
+// reset closes the circuit and clears the drop count.
+func (b *circuitBreaker) reset() {
+	b.mu.Lock()
+	b.drops.Store(0)
+	if b.state.Load() == CircuitClosed {
+		b.mu.Unlock()
+		return
+	}
+	from := b.transitionLocked(CircuitClosed)
+	b.mu.Unlock()
+	b.report(from, CircuitClosed)
+}
+
+// thresholdValue returns the configured threshold.
+func (b *circuitBreaker) thresholdValue() int32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.threshold
+}
+
+// transitionLocked moves the circuit to state to and returns the previous
+// state. It must be called with mu held.
+func (b *circuitBreaker) transitionLocked(to int32) int32 {
+	from := b.state.Swap(to)
+	b.changedAt = b.now()
+	b.transitions[to]++
+	if to == CircuitClosed {
+		b.drops.Store(0)
+	}
+	return from
+}
+
+// report passes a transition to notify.
+func (b *circuitBreaker) report(from, to int32) {
+	if b.notify != nil {
+		b.notify(from, to)
+	}
+}
+
+// stats returns the breaker state and transition counts.
+func (b *circuitBreaker) stats() CircuitBreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := CircuitBreakerStats{
+		State:            circuitStateName(b.state.Load()),
+		Threshold:        b.threshold,
+		OpenForMs:        b.openFor.Milliseconds(),
+		ConsecutiveDrops: b.drops.Load(),
+		Opened:           b.transitions[CircuitOpen],
+		HalfOpened:       b.transitions[CircuitHalfOpen],
+		Closed:           b.transitions[CircuitClosed],
+	}
+	if !b.changedAt.IsZero() {
+		t := b.changedAt
+		s.LastTransition = &t
+	}
+	return s
+}
+
+// reportCircuitTransition logs a transition of the broadcast circuit and raises
+// or resolves its alert.
+func reportCircuitTransition(from, to int32) {
+	log.Printf("Broadcast circuit breaker %s -> %s", circuitStateName(from), circuitStateName(to))
+	switch to {
+	case CircuitOpen:
+		if from == CircuitClosed {
+			alerts.Raise(alerts.Alert{
+				Key:      circuitAlertKey,
+				Source:   "throttler",
+				Severity: alerts.SeverityWarning,
+				Message:  "Live broadcast paused: the WebSocket hub keeps rejecting messages",
+			})
+		}
+	case CircuitClosed:
+		alerts.Resolve(circuitAlertKey)
+	}
+}
+
+// circuitStateName names a circuit state.
+func circuitStateName(state int32) string {
+	switch state {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
@@ -0,0 +1,193 @@
+package processdata
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for the breaker.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+type transition struct{ from, to int32 }
+
+// newTestBreaker returns a breaker on a fake clock recording its transitions.
+func newTestBreaker(threshold int32, openFor time.Duration) (*circuitBreaker, *fakeClock, *[]transition) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	var got []transition
+	b := newCircuitBreaker(threshold, openFor, clock.now, func(from, to int32) {
+		got = append(got, transition{from, to})
+	})
+	return b, clock, &got
+}
+
+// openBreaker drives a closed breaker to open.
+func openBreaker(t *testing.T, b *circuitBreaker) {
+	t.Helper()
+	for i := int32(0); i < b.threshold; i++ {
+		b.drop()
+	}
+	if s := b.state.Load(); s != CircuitOpen {
+		t.Fatalf("state after %d drops = %s, want open", b.threshold, circuitStateName(s))
+	}
+}
+
+func TestCircuitBreakerOpensAtThreshold(t *testing.T) {
+	b, _, got := newTestBreaker(3, time.Second)
+
+	b.drop()
+	b.drop()
+	if s := b.state.Load(); s != CircuitClosed {
+		t.Fatalf("state below threshold = %s, want closed", circuitStateName(s))
+	}
+	if !b.allow() {
+		t.Fatal("closed circuit refused a message")
+	}
+	b.drop()
+	if s := b.state.Load(); s != CircuitOpen {
+		t.Fatalf("state at threshold = %s, want open", circuitStateName(s))
+	}
+	if b.allow() {
+		t.Fatal("open circuit allowed a message")
+	}
+	if len(*got) != 1 || (*got)[0] != (transition{CircuitClosed, CircuitOpen}) {
+		t.Fatalf("transitions = %v, want closed -> open", *got)
+	}
+}
+
+func TestCircuitBreakerSuccessResetsDropCount(t *testing.T) {
+	b, _, _ := newTestBreaker(3, time.Second)
+
+	b.drop()
+	b.drop()
+	b.success()
+	b.drop()
+	b.drop()
+	if s := b.state.Load(); s != CircuitClosed {
+		t.Fatalf("state = %s, want closed as the drops were not consecutive", circuitStateName(s))
+	}
+}
+
+func TestCircuitBreakerHalfOpensAfterOpenPeriod(t *testing.T) {
+	b, clock, got := newTestBreaker(2, 5*time.Second)
+	openBreaker(t, b)
+
+	clock.advance(5*time.Second - time.Millisecond)
+	if b.allow() {
+		t.Fatal("circuit allowed a message before the open period passed")
+	}
+	clock.advance(time.Millisecond)
+	if !b.allow() {
+		t.Fatal("circuit refused a message after the open period")
+	}
+	if s := b.state.Load(); s != CircuitHalfOpen {
+		t.Fatalf("state = %s, want half_open", circuitStateName(s))
+	}
+	want := []transition{{CircuitClosed, CircuitOpen}, {CircuitOpen, CircuitHalfOpen}}
+	if len(*got) != len(want) || (*got)[1] != want[1] {
+		t.Fatalf("transitions = %v, want %v", *got, want)
+	}
+}
+
+func TestCircuitBreakerHalfOpenClosesOnSuccess(t *testing.T) {
+	b, clock, got := newTestBreaker(2, time.Second)
+	openBreaker(t, b)
+	clock.advance(time.Second)
+	b.allow()
+
+	b.success()
+	if s := b.state.Load(); s != CircuitClosed {
+		t.Fatalf("state = %s, want closed", circuitStateName(s))
+	}
+	if d := b.drops.Load(); d != 0 {
+		t.Fatalf("consecutive drops = %d, want 0", d)
+	}
+	if last := (*got)[len(*got)-1]; last != (transition{CircuitHalfOpen, CircuitClosed}) {
+		t.Fatalf("last transition = %v, want half_open -> closed", last)
+	}
+}
+
+func TestCircuitBreakerHalfOpenReopensOnDrop(t *testing.T) {
+	b, clock, got := newTestBreaker(10, time.Second)
+	openBreaker(t, b)
+	clock.advance(time.Second)
+	b.allow()
+
+	// A single drop reopens a half-open circuit, regardless of the threshold
+	b.drop()
+	if s := b.state.Load(); s != CircuitOpen {
+		t.Fatalf("state = %s, want open", circuitStateName(s))
+	}
+	if last := (*got)[len(*got)-1]; last != (transition{CircuitHalfOpen, CircuitOpen}) {
+		t.Fatalf("last transition = %v, want half_open -> open", last)
+	}
+
+	// The open period restarts from the reopening
+	clock.advance(time.Second - time.Millisecond)
+	if b.allow() {
+		t.Fatal("reopened circuit allowed a message before its open period passed")
+	}
+}
+
+func TestCircuitBreakerReset(t *testing.T) {
+	b, _, got := newTestBreaker(2, time.Minute)
+	openBreaker(t, b)
+
+	b.reset()
+	if s := b.state.Load(); s != CircuitClosed {
+		t.Fatalf("state after reset = %s, want closed", circuitStateName(s))
+	}
+	if d := b.drops.Load(); d != 0 {
+		t.Fatalf("consecutive drops after reset = %d, want 0", d)
+	}
+	if !b.allow() {
+		t.Fatal("reset circuit refused a message")
+	}
+	if last := (*got)[len(*got)-1]; last != (transition{CircuitOpen, CircuitClosed}) {
+		t.Fatalf("last transition = %v, want open -> closed", last)
+	}
+
+	// Resetting a closed circuit is not a transition
+	n := len(*got)
+	b.drop()
+	b.reset()
+	if len(*got) != n {
+		t.Fatalf("reset of a closed circuit reported %v", (*got)[n:])
+	}
+	if d := b.drops.Load(); d != 0 {
+		t.Fatalf("consecutive drops after reset = %d, want 0", d)
+	}
+}
+
+func TestCircuitBreakerTransitionCounters(t *testing.T) {
+	b, clock, _ := newTestBreaker(2, time.Second)
+
+	// closed -> open -> half_open -> open -> half_open -> closed, then reset from open
+	openBreaker(t, b)
+	clock.advance(time.Second)
+	b.allow()
+	b.drop()
+	clock.advance(time.Second)
+	b.allow()
+	b.success()
+	openBreaker(t, b)
+	b.reset()
+
+	s := b.stats()
+	if s.Opened != 3 || s.HalfOpened != 2 || s.Closed != 2 {
+		t.Fatalf("opened/half_opened/closed = %d/%d/%d, want 3/2/2", s.Opened, s.HalfOpened, s.Closed)
+	}
+	if s.State != "closed" || s.ConsecutiveDrops != 0 {
+		t.Fatalf("state = %s with %d drops, want closed with 0", s.State, s.ConsecutiveDrops)
+	}
+	if s.Threshold != 2 || s.OpenForMs != 1000 {
+		t.Fatalf("threshold/open_for_ms = %d/%d, want 2/1000", s.Threshold, s.OpenForMs)
+	}
+	if s.LastTransition == nil || !s.LastTransition.Equal(clock.now()) {
+		t.Fatalf("last transition = %v, want %v", s.LastTransition, clock.now())
+	}
+}
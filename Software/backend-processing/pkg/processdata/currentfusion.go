@@ -0,0 +1,140 @@
+// currentfusion.go
+//
+// Live pack current fusion. Every shunt (frame 4) and hall (frame 8) reading is
+// fused with the latest reading of the other sensor, if recent enough, and sent as
+// a "pack_current_fused" message (see channels.FuseCurrent for the policy). When
+// the sensors disagree for several consecutive readings a warning alert is raised;
+// it resolves once they agree again.
+package processdata
+
+import (
+	"fmt"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/channels"
+	"time"
+)
+
+const (
+	// Consecutive diverging readings before the alert is raised, and agreeing
+	// readings before it is resolved
+	divergeAfterReadings = 3
+	agreeAfterReadings   = 3
+
+	currentDivergenceAlertKey = "current_fusion.divergence"
+)
+
+// currentReading is the latest reading of one sensor.
+type currentReading struct {
+	value float64
+	t     time.Time
+	ok    bool
+}
+
+// currentFusionState holds the latest readings and the divergence state.
+type currentFusionState struct {
+	mu            sync.Mutex
+	shunt, hall   currentReading
+	diverging     int // Consecutive diverging readings
+	agreeing      int // Consecutive agreeing readings
+	alertRaised   bool
+	maxDifference float64 // Largest difference while diverged
+}
+
+var liveCurrentFusion = &currentFusionState{}
+
+// noteShuntCurrent fuses a shunt reading.
+func noteShuntCurrent(current float64, t time.Time) {
+	noteFusionReading(&liveCurrentFusion.shunt, current, t)
+}
+
+// noteHallCurrent fuses a hall sensor reading.
+func noteHallCurrent(current float64, t time.Time) {
+	noteFusionReading(&liveCurrentFusion.hall, current, t)
+}
+
+// noteFusionReading stores a reading of one sensor and broadcasts the fused value.
+func noteFusionReading(r *currentReading, current float64, t time.Time) {
+	cfg := channels.CurrentFusionSettings()
+	s := liveCurrentFusion
+
+	s.mu.Lock()
+	*r = currentReading{value: current, t: t, ok: true}
+	shunt, hall := s.shunt.at(t, cfg.MaxAge), s.hall.at(t, cfg.MaxAge)
+	f, _ := channels.FuseCurrent(shunt, hall)
+	raise, resolve := s.track(f, shunt != nil && hall != nil)
+	maxDiff := s.maxDifference
+	s.mu.Unlock()
+
+	data := map[string]interface{}{
+		"current":  f.Current,
+		"source":   f.Source,
+		"shunt":    nil,
+		"hall":     nil,
+		"diverged": f.Diverged,
+	}
+	if shunt != nil {
+		data["shunt"] = *shunt
+	}
+	if hall != nil {
+		data["hall"] = *hall
+		if shunt != nil {
+			data["difference"] = f.Difference
+		}
+	}
+	broadcastTelemetry(buildPayload("pack_current_fused", t, data))
+
+	switch {
+	case raise:
+		alerts.Raise(alerts.Alert{
+			Key:       currentDivergenceAlertKey,
+			Source:    "current_fusion",
+			Severity:  alerts.SeverityWarning,
+			Message:   fmt.Sprintf("Shunt and hall pack current differ by %.1f A (using %s)", maxDiff, f.Source),
+			Value:     maxDiff,
+			Threshold: cfg.Tolerance,
+		})
+	case resolve:
+		alerts.Resolve(currentDivergenceAlertKey)
+	}
+}
+
+// at returns the reading if it is no older than maxAge at t.
+func (r currentReading) at(t time.Time, maxAge time.Duration) *float64 {
+	if !r.ok || t.Sub(r.t) > maxAge {
+		return nil
+	}
+	v := r.value
+	return &v
+}
+
+// track updates the divergence state with a fused reading and reports whether the
+// alert is to be raised (or updated) or resolved. Readings from a single sensor
+// cannot be cross-validated and leave the state unchanged. The caller holds s.mu.
+func (s *currentFusionState) track(f channels.FusedCurrent, both bool) (raise, resolve bool) {
+	if !both {
+		return false, false
+	}
+	if f.Diverged {
+		s.diverging, s.agreeing = s.diverging+1, 0
+		diff := f.Difference
+		if diff < 0 {
+			diff = -diff
+		}
+		if !s.alertRaised {
+			s.maxDifference = 0
+		}
+		if s.diverging >= divergeAfterReadings && diff > s.maxDifference {
+			s.maxDifference = diff
+			s.alertRaised = true
+			return true, false
+		}
+		return false, false
+	}
+	s.agreeing, s.diverging = s.agreeing+1, 0
+	if s.alertRaised && s.agreeing >= agreeAfterReadings {
+		s.alertRaised = false
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,103 @@
+// driverinput.go
+//
+// Steering wheel button and rotary switch handling. The wheel broadcasts the state
+// of all its inputs in a single frame at a fixed rate; only state changes are kept.
+// Each change is stored in driver_input_events and broadcast to dashboards as a
+// "driver_input_event" message.
+package processdata
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"telem-system/pkg/utils"
+	"time"
+)
+
+// Default frame ID of the steering wheel input frame
+const defaultDriverInputFrameID = 1792
+
+var (
+	driverInputFrameID   uint32 = defaultDriverInputFrameID
+	driverInputProcessor *BatchProcessor
+
+	// Last seen state per input
+	driverInputMu    sync.Mutex
+	driverInputState = make(map[string]float64)
+)
+
+// SetDriverInputFrameID sets the frame ID carrying the steering wheel inputs; 0
+// keeps the default.
+func SetDriverInputFrameID(id uint32) {
+	if id != 0 {
+		driverInputFrameID = id
+	}
+}
+
+// initDriverInputProcessor creates the batch processor for input events.
+func initDriverInputProcessor(ctx context.Context, batchSize int, maxWait time.Duration) {
+	driverInputProcessor = &BatchProcessor{
+		data:      make([]interface{}, 0, batchSize),
+		batchSize: batchSize,
+		maxWait:   maxWait,
+		lastFlush: time.Now(),
+		processorFunc: func(batch []interface{}) {
+			items := make([]types.DriverInputEvent, 0, len(batch))
+			for _, item := range batch {
+				if data, ok := item.(types.DriverInputEvent); ok {
+					items = append(items, data)
+				}
+			}
+			if err := db.InsertDriverInputEventsBatch(context.Background(), items); err != nil {
+				log.Printf("Error inserting driver input events: %v", err)
+			}
+		},
+	}
+	startBatchFlusher(ctx, "driver_input_events", driverInputProcessor)
+}
+
+// processDriverInputData records the inputs of a steering wheel frame that changed
+// since the previous frame. Every decoded signal of the frame is an input.
+func processDriverInputData(decoded map[string]string) {
+	t := time.Now()
+
+	names := make([]string, 0, len(decoded))
+	for name := range decoded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var events []types.DriverInputEvent
+	driverInputMu.Lock()
+	for _, name := range names {
+		value := utils.ParseFloatSignal(decoded, name)
+		prev, seen := driverInputState[name]
+		if seen && prev == value {
+			continue
+		}
+		driverInputState[name] = value
+		e := types.DriverInputEvent{Timestamp: t, Input: name, Value: value}
+		if seen {
+			p := prev
+			e.Previous = &p
+		}
+		events = append(events, e)
+	}
+	driverInputMu.Unlock()
+
+	for _, e := range events {
+		driverInputProcessor.add(e)
+
+		data := map[string]interface{}{
+			"input": e.Input,
+			"value": e.Value,
+		}
+		if e.Previous != nil {
+			data["previous"] = *e.Previous
+		}
+		broadcastTelemetry(buildPayload("driver_input_event", t, data))
+	}
+}
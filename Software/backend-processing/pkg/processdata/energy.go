@@ -0,0 +1,92 @@
+// energy.go
+//
+// Live drive/regen energy split. Pack power is accumulated per session from the
+// live pack current and voltage, and an "energy_split" message carrying the totals
+// and the regen fraction is broadcast at most once per second for the regen gauge.
+package processdata
+
+import (
+	"sync"
+	"telem-system/pkg/channels"
+	"telem-system/pkg/sessions"
+	"time"
+)
+
+const (
+	// Minimum interval between energy_split broadcasts
+	energyBroadcastInterval = time.Second
+
+	// Longest gap between pack current samples that is integrated
+	energyMaxGap = 2 * time.Second
+)
+
+// energyTracker accumulates the live energy split.
+type energyTracker struct {
+	mu            sync.Mutex
+	voltage       float64 // Latest pack voltage
+	haveVoltage   bool
+	sessionID     int64 // Session the totals belong to (0 outside sessions)
+	driveJ        float64
+	regenJ        float64
+	lastT         time.Time
+	lastDrive     float64 // Power of the previous sample (W)
+	lastRegen     float64
+	lastBroadcast time.Time
+}
+
+var liveEnergy = &energyTracker{}
+
+// noteEnergyVoltage records the latest pack voltage.
+func noteEnergyVoltage(v float64) {
+	liveEnergy.mu.Lock()
+	liveEnergy.voltage, liveEnergy.haveVoltage = v, true
+	liveEnergy.mu.Unlock()
+}
+
+// noteEnergyCurrent integrates a pack current sample and broadcasts the split when
+// due.
+func noteEnergyCurrent(current float64, t time.Time) {
+	var sessionID int64
+	if s, ok := sessions.Current(); ok {
+		sessionID = s.ID
+	}
+
+	e := liveEnergy
+	e.mu.Lock()
+	if !e.haveVoltage {
+		e.mu.Unlock()
+		return
+	}
+	if sessionID != e.sessionID {
+		e.sessionID, e.driveJ, e.regenJ, e.lastT = sessionID, 0, 0, time.Time{}
+	}
+	drive, regen := channels.PowerSplit(e.voltage, current)
+	if dt := t.Sub(e.lastT); !e.lastT.IsZero() && dt > 0 && dt <= energyMaxGap {
+		e.driveJ += 0.5 * (drive + e.lastDrive) * dt.Seconds()
+		e.regenJ += 0.5 * (regen + e.lastRegen) * dt.Seconds()
+	}
+	e.lastT, e.lastDrive, e.lastRegen = t, drive, regen
+
+	if t.Sub(e.lastBroadcast) < energyBroadcastInterval {
+		e.mu.Unlock()
+		return
+	}
+	e.lastBroadcast = t
+	driveWh, regenWh := e.driveJ/3600, e.regenJ/3600
+	e.mu.Unlock()
+
+	data := map[string]interface{}{
+		"drive_wh": driveWh,
+		"regen_wh": regenWh,
+		"net_wh":   driveWh - regenWh,
+		"drive_w":  drive,
+		"regen_w":  regen,
+	}
+	if sessionID != 0 {
+		data["session_id"] = float64(sessionID)
+	}
+	if f := channels.RegenFraction(driveWh, regenWh); f != nil {
+		data["regen_fraction"] = *f
+	}
+	broadcastTelemetry(buildPayload("energy_split", t, data))
+}
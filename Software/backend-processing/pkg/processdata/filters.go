@@ -0,0 +1,118 @@
+// filters.go
+//
+// Server-side smoothing of live values. Configured fields of the live messages
+// are passed through a filter (see package filters) right before they are
+// broadcast; stored rows and the live rosbag recording keep the raw values. Types
+// sent once per board (see snapshotKeyFields) keep a filter per board.
+package processdata
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"telem-system/pkg/filters"
+	"time"
+)
+
+// liveFilterSet holds the configured filters and their per-signal state.
+type liveFilterSet struct {
+	mu    sync.Mutex
+	specs map[string]map[string]filters.Spec // Message type -> field ("*" for all) -> spec
+	state map[string]filters.Filter          // "type|board|field" -> filter
+}
+
+// Live filters, nil when none are configured. Set once at startup.
+var liveFilters *liveFilterSet
+
+// ConfigureLiveFilters installs the filters applied to broadcast values. A field
+// selector takes precedence over a "type.*" selector of the same type, which
+// leaves integer fields unfiltered.
+func ConfigureLiveFilters(specs []filters.Spec) error {
+	if len(specs) == 0 {
+		return nil
+	}
+	set := &liveFilterSet{specs: make(map[string]map[string]filters.Spec), state: make(map[string]filters.Filter)}
+	for _, s := range specs {
+		typ, field, ok := strings.Cut(strings.TrimSpace(s.Channel), ".")
+		if !ok || typ == "" || field == "" {
+			return fmt.Errorf("invalid filter channel %q: expected type.field or type.*", s.Channel)
+		}
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("filter for %s: %w", s.Channel, err)
+		}
+		if set.specs[typ] == nil {
+			set.specs[typ] = make(map[string]filters.Spec)
+		}
+		set.specs[typ][field] = s
+	}
+	liveFilters = set
+	return nil
+}
+
+// apply replaces the configured numeric fields of a payload built by
+// buildPayload with their filtered values. Missing (nil) values are left alone.
+func (f *liveFilterSet) apply(payloadMap map[string]interface{}) {
+	typ, _ := payloadMap["type"].(string)
+	specs, ok := f.specs[typ]
+	if !ok {
+		return
+	}
+	payload, ok := payloadMap["payload"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	board := ""
+	if field, ok := snapshotKeyFields[typ]; ok {
+		board = fmt.Sprint(payload[field])
+	}
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, raw := range payload {
+		if name == "timestamp" || name == "type" || name == snapshotKeyFields[typ] {
+			continue
+		}
+		spec, explicit := specs[name]
+		if !explicit {
+			var ok bool
+			if spec, ok = specs["*"]; !ok {
+				continue
+			}
+			// Integer fields are mostly states and flags; smooth them only on request
+			if _, isInt := raw.(int); isInt {
+				continue
+			}
+			if _, isInt := raw.(int64); isInt {
+				continue
+			}
+		}
+		key := typ + "|" + board + "|" + name
+		filter, ok := f.state[key]
+		if !ok {
+			filter = spec.New()
+			f.state[key] = filter
+		}
+		switch x := raw.(type) {
+		case float64:
+			payload[name] = filter.Apply(x, now)
+		case int:
+			payload[name] = filter.Apply(float64(x), now)
+		case int64:
+			payload[name] = filter.Apply(float64(x), now)
+		case string:
+			// Pre-formatted values (cells) keep their precision
+			v, err := strconv.ParseFloat(x, 64)
+			if err != nil || math.IsNaN(v) {
+				continue
+			}
+			decimals := -1
+			if i := strings.IndexByte(x, '.'); i >= 0 {
+				decimals = len(x) - i - 1
+			}
+			payload[name] = strconv.FormatFloat(filter.Apply(v, now), 'f', decimals, 64)
+		}
+	}
+}
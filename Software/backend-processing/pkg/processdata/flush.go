@@ -0,0 +1,89 @@
+// flush.go
+//
+// On-demand flushing of every batch processor and verification that what was
+// queued has been stored, used to reach a clean state before database
+// maintenance (see package maintenance).
+package processdata
+
+import (
+	"context"
+	"sort"
+	"telem-system/pkg/db"
+	"time"
+)
+
+// Interval between checks for flushes still running
+const flushPollInterval = 10 * time.Millisecond
+
+// SequenceCheckpoint compares the last sequence number assigned to the records
+// of a telemetry table with the highest one stored.
+type SequenceCheckpoint struct {
+	Table    string `json:"table"`
+	Assigned int64  `json:"assigned"`
+	Stored   int64  `json:"stored"`
+}
+
+// Complete reports whether every assigned record has been stored.
+func (c SequenceCheckpoint) Complete() bool {
+	return c.Stored >= c.Assigned
+}
+
+// registeredProcessors returns the registered batch processors.
+func registeredProcessors() []*BatchProcessor {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]*BatchProcessor(nil), batchProcessors...)
+}
+
+// FlushAll hands the queued records of every batch processor to the database and
+// waits until none is queued or being written. Records queued meanwhile are
+// flushed as well, and the IMU blocks being filled are sealed on every pass so no
+// samples are left in memory. It returns ctx.Err() if ctx ends first.
+func FlushAll(ctx context.Context) error {
+	procs := registeredProcessors()
+	ticker := time.NewTicker(flushPollInterval)
+	defer ticker.Stop()
+	for {
+		if liveIMU.processor != nil {
+			liveIMU.sealBlocks(time.Now())
+		}
+		busy := false
+		for _, p := range procs {
+			p.mu.Lock()
+			if len(p.data) > 0 {
+				busy = true
+				p.flushLocked()
+				continue
+			}
+			// A periodic flush may be writing a batch
+			busy = busy || !p.inFlightSince.IsZero()
+			p.mu.Unlock()
+		}
+		if !busy {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckpointSequences returns the sequence checkpoint of every telemetry table
+// with a batch processor, sorted by table.
+func CheckpointSequences(ctx context.Context) ([]SequenceCheckpoint, error) {
+	var out []SequenceCheckpoint
+	for _, p := range registeredProcessors() {
+		if _, ok := db.LookupTable(p.name); !ok {
+			continue
+		}
+		stored, err := db.MaxSequence(ctx, p.name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, SequenceCheckpoint{Table: p.name, Assigned: p.seq.Load(), Stored: stored})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Table < out[j].Table })
+	return out, nil
+}
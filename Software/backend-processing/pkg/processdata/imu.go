@@ -0,0 +1,412 @@
+// imu.go
+//
+// High-rate IMU board handling. The board streams accelerometer and gyroscope
+// frames at 1 kHz, too fast for a row and a broadcast per frame. Its frames are
+// taken from the ingest path ahead of the worker pool: every sample is stored in
+// blocks of up to db.IMUBlockSpan per sensor in the imu_blocks table, and live
+// clients receive the mean of each decimation interval (50 Hz by default) as
+// "imu_accel" and "imu_gyro" messages. A burst marks the full-rate window around
+// an event (an operator request, an acceleration above the configured threshold
+// or a critical alert); the retention job removes older full-rate data except the
+// burst windows.
+package processdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/db"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// IMU sensors
+const (
+	IMUSensorAccel = "accel"
+	IMUSensorGyro  = "gyro"
+)
+
+// IMU burst triggers
+const (
+	IMUTriggerManual    = "manual"
+	IMUTriggerThreshold = "threshold"
+	IMUTriggerAlert     = "alert"
+)
+
+const (
+	// Defaults, overridable through IMUConfig
+	defaultIMUBroadcastHz = 50
+	defaultIMUBurstBefore = 2 * time.Second
+	defaultIMUBurstAfter  = 3 * time.Second
+
+	// Highest decimated broadcast rate
+	maxIMUBroadcastHz = 1000
+
+	// Longest part of a burst window on either side of its event
+	maxIMUBurstSpan = time.Minute
+
+	// Samples per block, bounding blocks when the board sends faster than expected
+	imuBlockMaxSamples = 2000
+
+	// Limit on recording a burst
+	imuBurstTimeout = 5 * time.Second
+)
+
+var (
+	// ErrIMUDisabled is returned by TriggerIMUBurst when no IMU frame is configured.
+	ErrIMUDisabled = errors.New("no IMU frames are configured")
+
+	// ErrIMUBurstOpen is returned by TriggerIMUBurst while the window of a previous
+	// burst is still open; the event is already covered by it.
+	ErrIMUBurstOpen = errors.New("an IMU burst window is already open")
+)
+
+// IMUConfig selects the IMU frames and how they are handled. The first three
+// signals of each frame definition are its x, y and z axes.
+type IMUConfig struct {
+	AccelFrameID    uint32        // Accelerometer frame; 0 when not present
+	GyroFrameID     uint32        // Gyroscope frame; 0 when not present
+	BroadcastHz     float64       // Decimated broadcast rate; 0 selects 50 Hz
+	BurstBefore     time.Duration // Window kept before a burst event; 0 selects 2 s
+	BurstAfter      time.Duration // Window kept after a burst event; 0 selects 3 s
+	BurstThresholdG float64       // Acceleration magnitude (in the frame's unit) triggering a burst; 0 disables
+	BurstOnAlerts   bool          // Critical alerts trigger a burst
+	Retention       time.Duration // Full-rate data outside bursts is kept this long; 0 keeps everything
+}
+
+// imuSensor is the ingest state of one IMU frame.
+type imuSensor struct {
+	name  string
+	block types.IMUBlock // Block being filled
+
+	// Decimation interval being averaged
+	bin              int64
+	n                int
+	sumX, sumY, sumZ float64
+}
+
+// imuState holds the IMU configuration and ingest state.
+type imuState struct {
+	cfg       IMUConfig
+	period    time.Duration         // Decimation interval
+	sensors   map[uint32]*imuSensor // Fixed once configured
+	processor *BatchProcessor
+
+	mu       sync.Mutex // Guards the sensors' contents and burstEnd
+	burstEnd time.Time  // End of the open burst window
+}
+
+var liveIMU = &imuState{}
+
+// ConfigureIMU validates and installs the IMU settings. It must be called before
+// InitBatchProcessors and before frames are ingested.
+func ConfigureIMU(c IMUConfig) error {
+	if c.AccelFrameID != 0 && c.AccelFrameID == c.GyroFrameID {
+		return fmt.Errorf("accelerometer and gyroscope share frame ID %d", c.AccelFrameID)
+	}
+	if c.BroadcastHz < 0 || c.BroadcastHz > maxIMUBroadcastHz {
+		return fmt.Errorf("broadcast rate must be between 0 and %d Hz, got %g", maxIMUBroadcastHz, c.BroadcastHz)
+	}
+	if c.BurstBefore < 0 || c.BurstAfter < 0 || c.BurstBefore > maxIMUBurstSpan || c.BurstAfter > maxIMUBurstSpan {
+		return fmt.Errorf("burst windows must be between 0 and %s on either side of the event", maxIMUBurstSpan)
+	}
+	if c.BurstThresholdG < 0 || c.Retention < 0 {
+		return errors.New("burst threshold and retention must not be negative")
+	}
+	if c.BroadcastHz == 0 {
+		c.BroadcastHz = defaultIMUBroadcastHz
+	}
+	if c.BurstBefore == 0 {
+		c.BurstBefore = defaultIMUBurstBefore
+	}
+	if c.BurstAfter == 0 {
+		c.BurstAfter = defaultIMUBurstAfter
+	}
+
+	sensors := make(map[uint32]*imuSensor)
+	if c.AccelFrameID != 0 {
+		sensors[c.AccelFrameID] = &imuSensor{name: IMUSensorAccel}
+	}
+	if c.GyroFrameID != 0 {
+		sensors[c.GyroFrameID] = &imuSensor{name: IMUSensorGyro}
+	}
+	liveIMU.cfg = c
+	liveIMU.period = time.Duration(float64(time.Second) / c.BroadcastHz)
+	liveIMU.sensors = sensors
+	return nil
+}
+
+// IMUEnabled reports whether an IMU frame is configured.
+func IMUEnabled() bool {
+	return len(liveIMU.sensors) > 0
+}
+
+// IMURetention returns how long full-rate IMU data outside bursts is kept (0
+// keeps everything).
+func IMURetention() time.Duration {
+	return liveIMU.cfg.Retention
+}
+
+// initIMUProcessor creates the batch processor for IMU blocks and the sealing of
+// blocks once the board stops sending.
+func initIMUProcessor(ctx context.Context, batchSize int, maxWait time.Duration) {
+	m := liveIMU
+	if !IMUEnabled() {
+		return
+	}
+	m.processor = &BatchProcessor{
+		data:      make([]interface{}, 0, batchSize),
+		batchSize: batchSize,
+		maxWait:   maxWait,
+		lastFlush: time.Now(),
+		processorFunc: func(batch []interface{}) {
+			blocks := make([]types.IMUBlock, 0, len(batch))
+			for _, item := range batch {
+				if b, ok := item.(types.IMUBlock); ok {
+					blocks = append(blocks, b)
+				}
+			}
+			if err := db.InsertIMUBlocksBatch(context.Background(), blocks); err != nil {
+				log.Printf("Error inserting IMU blocks: %v", err)
+			}
+		},
+	}
+	startBatchFlusher(ctx, "imu_blocks", m.processor)
+
+	go func() {
+		ticker := time.NewTicker(db.IMUBlockSpan / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				m.sealBlocks(now.Add(-db.IMUBlockSpan))
+			case <-ctx.Done():
+				// Store the blocks being filled along with the processor's last batch
+				m.sealBlocks(time.Now())
+				m.processor.mu.Lock()
+				if len(m.processor.data) > 0 {
+					m.processor.flushLocked()
+				} else {
+					m.processor.mu.Unlock()
+				}
+				return
+			}
+		}
+	}()
+
+	if m.cfg.BurstOnAlerts {
+		alerts.Subscribe(func(a alerts.Alert) {
+			if !a.Active || a.Severity != alerts.SeverityCritical {
+				return
+			}
+			if t := time.Now(); m.openBurst(t) {
+				go m.recordBurst(t, IMUTriggerAlert, a.Key, "")
+			}
+		})
+	}
+}
+
+// IngestIMUFrame handles a frame of the IMU board and reports whether frameID is
+// an IMU frame. Other frames are left to the caller.
+func IngestIMUFrame(frameID uint32, data []byte, msgDef types.Message, t time.Time) bool {
+	m := liveIMU
+	s, ok := m.sensors[frameID]
+	if !ok {
+		return false
+	}
+	decoded, err := candecoder.DecodeMessage(data, msgDef)
+	NoteDecodeResult(msgDef, decoded, err)
+	if err != nil {
+		return true
+	}
+	DropInvalidSignals(msgDef, decoded)
+	x, y, z, ok := imuAxes(msgDef, decoded)
+	if !ok {
+		return true
+	}
+
+	m.mu.Lock()
+	sealed, full := s.add(t, x, y, z)
+	mean, due := s.decimate(t, m.period, x, y, z)
+	trigger := s.name == IMUSensorAccel && m.cfg.BurstThresholdG > 0 &&
+		math.Sqrt(x*x+y*y+z*z) >= m.cfg.BurstThresholdG && m.openBurstLocked(t)
+	m.mu.Unlock()
+
+	if full {
+		m.processor.add(sealed)
+	}
+	if due {
+		broadcastTelemetry(buildPayload("imu_"+s.name, mean.Timestamp, map[string]interface{}{
+			"x": mean.X,
+			"y": mean.Y,
+			"z": mean.Z,
+		}))
+	}
+	if trigger {
+		go m.recordBurst(t, IMUTriggerThreshold, fmt.Sprintf("%.2f over %.2f", math.Sqrt(x*x+y*y+z*z), m.cfg.BurstThresholdG), "")
+	}
+	return true
+}
+
+// imuAxes parses the x, y and z axes: the first three signals of the frame.
+func imuAxes(msgDef types.Message, decoded map[string]string) (x, y, z float64, ok bool) {
+	if len(msgDef.Signals) < 3 {
+		return 0, 0, 0, false
+	}
+	var axes [3]float64
+	for i := range axes {
+		v, err := strconv.ParseFloat(decoded[msgDef.Signals[i].Name], 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, 0, 0, false
+		}
+		axes[i] = v
+	}
+	return axes[0], axes[1], axes[2], true
+}
+
+// add appends a sample to the block being filled. When the sample does not fit,
+// the block is returned as sealed and a new one is started with the sample. It
+// must be called with liveIMU.mu held.
+func (s *imuSensor) add(t time.Time, x, y, z float64) (sealed types.IMUBlock, full bool) {
+	b := &s.block
+	if len(b.OffsetsUs) > 0 && (t.Sub(b.Start) >= db.IMUBlockSpan || len(b.OffsetsUs) >= imuBlockMaxSamples) {
+		sealed, full = *b, true
+		*b = types.IMUBlock{}
+	}
+	if len(b.OffsetsUs) == 0 {
+		*b = types.IMUBlock{Sensor: s.name, Start: t}
+	}
+	offset := t.Sub(b.Start).Microseconds()
+	if offset < 0 {
+		offset = 0 // Clock stepped back; keep the samples in order
+	}
+	b.OffsetsUs = append(b.OffsetsUs, int32(offset))
+	b.X = append(b.X, float32(x))
+	b.Y = append(b.Y, float32(y))
+	b.Z = append(b.Z, float32(z))
+	return sealed, full
+}
+
+// decimate adds a sample to the mean of its decimation interval. When the sample
+// starts a new interval, the mean of the previous one is returned as due. It must
+// be called with liveIMU.mu held.
+func (s *imuSensor) decimate(t time.Time, period time.Duration, x, y, z float64) (mean types.IMUSample, due bool) {
+	bin := t.UnixNano() / int64(period)
+	if s.n > 0 && bin != s.bin {
+		n := float64(s.n)
+		mean = types.IMUSample{
+			Timestamp: time.Unix(0, s.bin*int64(period)),
+			Sensor:    s.name,
+			X:         s.sumX / n,
+			Y:         s.sumY / n,
+			Z:         s.sumZ / n,
+		}
+		due = true
+		s.n, s.sumX, s.sumY, s.sumZ = 0, 0, 0, 0
+	}
+	s.bin = bin
+	s.n++
+	s.sumX += x
+	s.sumY += y
+	s.sumZ += z
+	return mean, due
+}
+
+// sealBlocks queues the blocks started before cutoff for storage.
+func (m *imuState) sealBlocks(cutoff time.Time) {
+	var sealed []types.IMUBlock
+	m.mu.Lock()
+	for _, s := range m.sensors {
+		if len(s.block.OffsetsUs) > 0 && s.block.Start.Before(cutoff) {
+			sealed = append(sealed, s.block)
+			s.block = types.IMUBlock{}
+		}
+	}
+	m.mu.Unlock()
+	for _, b := range sealed {
+		m.processor.add(b)
+	}
+}
+
+// openBurst opens a burst window for an event at t unless one is already open.
+func (m *imuState) openBurst(t time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.openBurstLocked(t)
+}
+
+// openBurstLocked is openBurst with m.mu held.
+func (m *imuState) openBurstLocked(t time.Time) bool {
+	if t.Before(m.burstEnd) {
+		return false
+	}
+	m.burstEnd = t.Add(m.cfg.BurstAfter)
+	return true
+}
+
+// recordBurst stores the window of a burst opened by a trigger.
+func (m *imuState) recordBurst(t time.Time, trigger, note, by string) {
+	ctx, cancel := context.WithTimeout(context.Background(), imuBurstTimeout)
+	defer cancel()
+	if _, err := m.storeBurst(ctx, t, trigger, note, by); err != nil {
+		log.Printf("Error recording IMU burst: %v", err)
+	}
+}
+
+// storeBurst stores the window of a burst with its event at t.
+func (m *imuState) storeBurst(ctx context.Context, t time.Time, trigger, note, by string) (types.IMUBurst, error) {
+	b := types.IMUBurst{
+		EventAt:     t,
+		From:        t.Add(-m.cfg.BurstBefore),
+		To:          t.Add(m.cfg.BurstAfter),
+		Trigger:     trigger,
+		Note:        note,
+		RequestedBy: by,
+	}
+	if s, ok := sessions.Current(); ok {
+		b.SessionID = &s.ID
+	}
+	id, err := db.InsertIMUBurst(ctx, b)
+	if err != nil {
+		return types.IMUBurst{}, err
+	}
+	b.ID = id
+	log.Printf("IMU burst %d recorded (%s) for %s to %s", id, trigger, b.From.Format(time.RFC3339Nano), b.To.Format(time.RFC3339Nano))
+	return b, nil
+}
+
+// TriggerIMUBurst records a burst around the current time, e.g. on an operator's
+// request.
+func TriggerIMUBurst(ctx context.Context, note, by string) (types.IMUBurst, error) {
+	if !IMUEnabled() {
+		return types.IMUBurst{}, ErrIMUDisabled
+	}
+	t := time.Now()
+	if !liveIMU.openBurst(t) {
+		return types.IMUBurst{}, ErrIMUBurstOpen
+	}
+	return liveIMU.storeBurst(ctx, t, IMUTriggerManual, note, by)
+}
+
+// PruneIMUData removes full-rate IMU data older than the retention, except the
+// burst windows. It is the function of the IMU retention job.
+func PruneIMUData(ctx context.Context) error {
+	if liveIMU.cfg.Retention <= 0 {
+		return nil
+	}
+	n, err := db.DeleteIMUBlocksBefore(ctx, time.Now().Add(-liveIMU.cfg.Retention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Removed %d IMU blocks past the retention", n)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// laps.go
+//
+// Live forwarding of completed laps. Every stored lap summary is broadcast to
+// dashboard clients as a "lap" message.
+package processdata
+
+import (
+	"telem-system/pkg/laps"
+	"telem-system/pkg/types"
+)
+
+// InitLapBroadcast forwards completed laps to live clients.
+func InitLapBroadcast() {
+	laps.Subscribe(broadcastLap)
+}
+
+// broadcastLap sends a lap summary to live clients. Aggregates without data are
+// omitted.
+func broadcastLap(l types.LapSummary) {
+	data := map[string]interface{}{
+		"id":          float64(l.ID),
+		"lap_number":  float64(l.LapNumber),
+		"started_at":  l.StartedAt.UnixMilli(),
+		"ended_at":    l.EndedAt.UnixMilli(),
+		"duration_s":  l.DurationS,
+		"alert_count": float64(l.AlertCount),
+		"trigger":     l.Trigger,
+	}
+	if l.SessionID != nil {
+		data["session_id"] = float64(*l.SessionID)
+	}
+	optional := map[string]*float64{
+		"distance_m":          l.DistanceM,
+		"max_speed":           l.MaxSpeed,
+		"avg_speed":           l.AvgSpeed,
+		"energy_wh":           l.EnergyWh,
+		"drive_energy_wh":     l.DriveEnergyWh,
+		"regen_energy_wh":     l.RegenEnergyWh,
+		"regen_fraction":      l.RegenFraction,
+		"max_cell_temp":       l.MaxCellTemp,
+		"max_motor_temp":      l.MaxMotorTemp,
+		"max_controller_temp": l.MaxControllerTemp,
+	}
+	for k, v := range optional {
+		if v != nil {
+			data[k] = *v
+		}
+	}
+	broadcastTelemetry(buildPayload("lap", l.EndedAt, data))
+}
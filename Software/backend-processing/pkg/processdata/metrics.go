@@ -0,0 +1,94 @@
+// metrics.go
+//
+// Pipeline metrics history. The decode cache and throttler counters are sampled
+// periodically into the server_metrics table so drops and cache thrash can be
+// correlated with data gaps after an event.
+package processdata
+
+import (
+	"context"
+	"log"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Default interval between pipeline metrics samples
+const defaultMetricsInterval = 5 * time.Second
+
+// metricsSampler turns cumulative counters into interval samples.
+type metricsSampler struct {
+	last types.ServerMetrics_Data
+}
+
+// sample reads the current counters and computes rates against the previous sample.
+func (m *metricsSampler) sample(now time.Time) types.ServerMetrics_Data {
+	hits, misses := candecoder.GetCacheStats()
+	sent, dropped, state := GetThrottlerStats()
+
+	s := types.ServerMetrics_Data{
+		Timestamp:        now,
+		CacheHits:        hits,
+		CacheMisses:      misses,
+		MessagesSent:     sent,
+		MessagesDropped:  dropped,
+		CircuitState:     state,
+		PersistenceLagMs: MaxPersistenceLag(),
+	}
+
+	if !m.last.Timestamp.IsZero() {
+		elapsed := now.Sub(m.last.Timestamp).Seconds()
+		if elapsed > 0 {
+			s.SentPerSec = float64(counterDelta(sent, m.last.MessagesSent)) / elapsed
+			s.DroppedPerSec = float64(counterDelta(dropped, m.last.MessagesDropped)) / elapsed
+		}
+		dh := counterDelta(hits, m.last.CacheHits)
+		dm := counterDelta(misses, m.last.CacheMisses)
+		if dh+dm > 0 {
+			s.CacheHitRate = float64(dh) / float64(dh+dm)
+		}
+	} else if hits+misses > 0 {
+		s.CacheHitRate = float64(hits) / float64(hits+misses)
+	}
+
+	m.last = s
+	return s
+}
+
+// counterDelta returns cur-prev, treating a counter reset as a restart from zero.
+func counterDelta(cur, prev uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// InitMetricsRecorder starts sampling pipeline metrics into the database every
+// intervalMs milliseconds; a non-positive interval selects the default.
+func InitMetricsRecorder(ctx context.Context, intervalMs int) {
+	interval := defaultMetricsInterval
+	if intervalMs > 0 {
+		interval = time.Duration(intervalMs) * time.Millisecond
+	}
+
+	sampler := &metricsSampler{}
+	sampler.sample(time.Now()) // Baseline for the first interval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s := sampler.sample(time.Now())
+				if err := db.InsertServerMetrics(context.Background(), s); err != nil {
+					log.Printf("Error inserting server metrics: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
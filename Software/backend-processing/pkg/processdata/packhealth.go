@@ -0,0 +1,44 @@
+// packhealth.go
+//
+// Live forwarding of pack health records. Ended sessions are handed to the pack
+// health tracker, and every stored record is broadcast to dashboard clients as a
+// "pack_health" message.
+package processdata
+
+import (
+	"telem-system/pkg/packhealth"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+)
+
+// InitPackHealth feeds ended sessions to the pack health tracker and forwards its
+// records to live clients.
+func InitPackHealth() {
+	sessions.Subscribe(packhealth.NoteSession)
+	packhealth.Subscribe(broadcastPackHealth)
+}
+
+// broadcastPackHealth sends a pack health record to live clients. Figures
+// without data are omitted.
+func broadcastPackHealth(h types.PackHealth) {
+	data := map[string]interface{}{
+		"id":                 float64(h.ID),
+		"pack_id":            h.PackID,
+		"session_id":         float64(h.SessionID),
+		"discharge_ah":       h.DischargeAh,
+		"charge_ah":          h.ChargeAh,
+		"total_discharge_ah": h.TotalDischargeAh,
+		"total_charge_ah":    h.TotalChargeAh,
+	}
+	optional := map[string]*float64{
+		"cycles":      h.Cycles,
+		"capacity_ah": h.CapacityAh,
+		"soh":         h.SOH,
+	}
+	for k, v := range optional {
+		if v != nil {
+			data[k] = *v
+		}
+	}
+	broadcastTelemetry(buildPayload("pack_health", h.RecordedAt, data))
+}
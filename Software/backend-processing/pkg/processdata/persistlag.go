@@ -0,0 +1,186 @@
+// persistlag.go
+//
+// Persistence lag monitoring. Live data is broadcast as soon as it is decoded but
+// only stored once its batch processor flushes. The persistence lag of a processor
+// is the age of its oldest record that has not been durably written yet (queued or
+// in an insert that has not returned). A monitor raises an alert per processor when
+// the lag exceeds the configured threshold.
+//
+// With persistence first on (competition mode), broadcasts of decoded frames are
+// shed while any processor lags, leaving the resources to storage.
+package processdata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"telem-system/pkg/alerts"
+	"time"
+)
+
+const (
+	// Default lag above which an alert is raised
+	defaultLagAlertThreshold = 5 * time.Second
+
+	// Default interval between lag checks
+	defaultLagCheckInterval = time.Second
+)
+
+// PersistenceLag is a snapshot of one batch processor's persistence state.
+type PersistenceLag struct {
+	Name     string  `json:"name"`
+	Pending  int     `json:"pending"`   // Records queued but not yet handed to the database
+	InFlight bool    `json:"in_flight"` // A batch insert is currently running
+	LagMs    float64 `json:"lag_ms"`    // Age of the oldest unflushed record, 0 if none
+}
+
+var (
+	registryMu      sync.Mutex
+	batchProcessors []*BatchProcessor
+	batchChannelSet = make(map[string]bool) // Live channels of the registered processors
+
+	// Persistence first: shed frame broadcasts while storage lags
+	persistenceFirst  atomic.Bool
+	persistenceBehind atomic.Bool // Some processor is over the lag threshold
+	shedBroadcasts    atomic.Uint64
+)
+
+// registerBatchProcessor adds a processor to the set covered by lag monitoring.
+func registerBatchProcessor(p *BatchProcessor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range batchProcessors {
+		if existing.name == p.name {
+			return
+		}
+	}
+	batchProcessors = append(batchProcessors, p)
+	batchChannelSet[p.channel] = true
+}
+
+// SetPersistenceFirst turns shedding of frame broadcasts while storage lags on
+// or off.
+func SetPersistenceFirst(on bool) {
+	persistenceFirst.Store(on)
+}
+
+// ShedBroadcasts returns the number of broadcasts shed to let storage catch up.
+func ShedBroadcasts() uint64 {
+	return shedBroadcasts.Load()
+}
+
+// shedBroadcast reports whether a broadcast of the message type is shed because
+// persistence comes first and storage lags. Only channels stored by a batch
+// processor are shed; alerts and other events always go out.
+func shedBroadcast(typ string) bool {
+	if !persistenceFirst.Load() || !persistenceBehind.Load() {
+		return false
+	}
+	registryMu.Lock()
+	batched := batchChannelSet[typ]
+	registryMu.Unlock()
+	if batched {
+		shedBroadcasts.Add(1)
+	}
+	return batched
+}
+
+// lag returns the persistence state of the processor at time now.
+func (p *BatchProcessor) lag(now time.Time) PersistenceLag {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	oldest := p.inFlightSince
+	if oldest.IsZero() || (!p.oldestPending.IsZero() && p.oldestPending.Before(oldest)) {
+		oldest = p.oldestPending
+	}
+	l := PersistenceLag{
+		Name:     p.name,
+		Pending:  len(p.data),
+		InFlight: !p.inFlightSince.IsZero(),
+	}
+	if !oldest.IsZero() {
+		l.LagMs = float64(now.Sub(oldest)) / float64(time.Millisecond)
+	}
+	return l
+}
+
+// GetPersistenceLag returns the persistence lag of every batch processor, sorted by name.
+func GetPersistenceLag() []PersistenceLag {
+	registryMu.Lock()
+	procs := append([]*BatchProcessor(nil), batchProcessors...)
+	registryMu.Unlock()
+
+	now := time.Now()
+	out := make([]PersistenceLag, 0, len(procs))
+	for _, p := range procs {
+		out = append(out, p.lag(now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// MaxPersistenceLag returns the largest lag across all batch processors in milliseconds.
+func MaxPersistenceLag() float64 {
+	var max float64
+	for _, l := range GetPersistenceLag() {
+		if l.LagMs > max {
+			max = l.LagMs
+		}
+	}
+	return max
+}
+
+// InitPersistenceMonitor starts checking persistence lag and insert latency SLOs
+// (see slo.go). thresholdMs is the lag at which an alert is raised and intervalMs
+// the check interval; non-positive values select the defaults.
+func InitPersistenceMonitor(ctx context.Context, thresholdMs int, intervalMs int) {
+	threshold := defaultLagAlertThreshold
+	if thresholdMs > 0 {
+		threshold = time.Duration(thresholdMs) * time.Millisecond
+	}
+	interval := defaultLagCheckInterval
+	if intervalMs > 0 {
+		interval = time.Duration(intervalMs) * time.Millisecond
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				checkPersistenceLag(threshold)
+				checkSLOs(time.Now())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// checkPersistenceLag raises or resolves the lag alert of every processor.
+func checkPersistenceLag(threshold time.Duration) {
+	thresholdMs := float64(threshold) / float64(time.Millisecond)
+	behind := false
+	for _, l := range GetPersistenceLag() {
+		key := "persistence_lag." + l.Name
+		if l.LagMs > thresholdMs {
+			behind = true
+			alerts.Raise(alerts.Alert{
+				Key:       key,
+				Source:    "persistence",
+				Severity:  alerts.SeverityWarning,
+				Message:   fmt.Sprintf("%s data not yet stored after %s", l.Name, threshold),
+				Value:     l.LagMs,
+				Threshold: thresholdMs,
+			})
+		} else {
+			alerts.Resolve(key)
+		}
+	}
+	persistenceBehind.Store(behind)
+}
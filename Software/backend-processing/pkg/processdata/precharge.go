@@ -0,0 +1,67 @@
+// precharge.go
+//
+// Storage and live forwarding of precharge attempts. Every attempt is stored in
+// precharge_attempts and broadcast to dashboard clients as a "precharge" message.
+package processdata
+
+import (
+	"context"
+	"log"
+	"telem-system/pkg/db"
+	"telem-system/pkg/precharge"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Size of the queue between precharge notifications and the writer
+const prechargeQueueSize = 16
+
+// InitPrechargeLog stores and forwards precharge attempts. Writes happen on a
+// single goroutine that stops when ctx is cancelled.
+func InitPrechargeLog(ctx context.Context) {
+	queue := make(chan types.PrechargeAttempt, prechargeQueueSize)
+	precharge.Subscribe(func(a types.PrechargeAttempt) {
+		select {
+		case queue <- a:
+		default:
+			log.Printf("Precharge queue full, dropping attempt at %s", a.StartedAt.Format(time.RFC3339))
+		}
+	})
+
+	go func() {
+		for {
+			select {
+			case a := <-queue:
+				storePrechargeAttempt(a)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// storePrechargeAttempt writes an attempt and broadcasts it with its ID.
+func storePrechargeAttempt(a types.PrechargeAttempt) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id, err := db.InsertPrechargeAttempt(ctx, a)
+	if err != nil {
+		log.Printf("Error storing precharge attempt: %v", err)
+	}
+	a.ID = id
+
+	if a.Passed {
+		log.Printf("Precharge completed in %.2fs (final delta %.1f V)", a.DurationS, a.FinalDeltaV)
+	} else {
+		log.Printf("Precharge failed (%s) after %.2fs (final delta %.1f V)", a.Reason, a.DurationS, a.FinalDeltaV)
+	}
+	broadcastTelemetry(buildPayload("precharge", a.EndedAt, map[string]interface{}{
+		"id":            float64(a.ID),
+		"started_at":    a.StartedAt.UnixMilli(),
+		"duration_s":    a.DurationS,
+		"accumulator_v": a.AccumulatorV,
+		"final_delta_v": a.FinalDeltaV,
+		"passed":        a.Passed,
+		"reason":        a.Reason,
+	}))
+}
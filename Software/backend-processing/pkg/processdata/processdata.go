@@ -9,13 +9,23 @@ package processdata
 import (
 	"context"
 	"fmt"
+	"log"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"telem-system/pkg/alerts"
 	"telem-system/pkg/db"
+	"telem-system/pkg/laps"
+	"telem-system/pkg/livechannels"
+	"telem-system/pkg/precharge"
+	"telem-system/pkg/resistance"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/shutdown"
 	"telem-system/pkg/types"
 	"telem-system/pkg/utils"
+	"telem-system/pkg/vehiclestate"
 	"telem-system/proto"
 	"time"
 
@@ -25,14 +35,25 @@ import (
 
 // Define batch processor structure
 type BatchProcessor struct {
+	name          string // Destination table, used for monitoring
+	channel       string // Live channel of the records, whose policy decides if they are stored
 	data          []interface{}
 	batchSize     int
 	maxWait       time.Duration
 	lastFlush     time.Time
+	oldestPending time.Time    // When the oldest record in data was queued
+	inFlightSince time.Time    // When the oldest record of the batch being written was queued
+	seq           atomic.Int64 // Last sequence number assigned to a record
 	mu            sync.Mutex
 	processorFunc func([]interface{})
 }
 
+// Reading the last sequence number of a table at startup
+const (
+	resumeAttempts = 3
+	resumeTimeout  = 10 * time.Second
+)
+
 // Global batch processors
 var (
 	// Existing batch processors
@@ -70,8 +91,10 @@ var (
 	pdmReTransProcessor   *BatchProcessor
 )
 
-// InitBatchProcessors initializes all batch processors
-func InitBatchProcessors(ctx context.Context, batchSize int, maxWait time.Duration) {
+// InitBatchProcessors initializes all batch processors and resumes their sequence
+// numbers. It fails when a table's last sequence number cannot be read, as
+// numbering from 1 would store duplicate sequence numbers.
+func InitBatchProcessors(ctx context.Context, batchSize int, maxWait time.Duration) error {
 	// Initialize cell data batch processor
 	cellBatchProcessor = &BatchProcessor{
 		data:      make([]interface{}, 0, batchSize),
@@ -86,7 +109,7 @@ func InitBatchProcessors(ctx context.Context, batchSize int, maxWait time.Durati
 				}
 			}
 			if len(cells) > 0 {
-				db.InsertCellDataBatch(context.Background(), cells)
+				storeCellBatch(cells)
 			}
 		},
 	}
@@ -699,40 +722,75 @@ func InitBatchProcessors(ctx context.Context, batchSize int, maxWait time.Durati
 	}
 
 	// Start batch flusher goroutines
-	startBatchFlusher(ctx, cellBatchProcessor)
-	startBatchFlusher(ctx, thermBatchProcessor)
-	startBatchFlusher(ctx, packCurrentProcessor)
-	startBatchFlusher(ctx, packVoltageProcessor)
-	startBatchFlusher(ctx, bamocarProcessor)
-	startBatchFlusher(ctx, tcuProcessor)
-	startBatchFlusher(ctx, frontAnalogProcessor)
-	startBatchFlusher(ctx, aculvfd1Processor)
-	startBatchFlusher(ctx, aculvfd2Processor)
-	startBatchFlusher(ctx, aculv1Processor)
-	startBatchFlusher(ctx, aculv2Processor)
-	startBatchFlusher(ctx, gpsBestPosProcessor)
-	startBatchFlusher(ctx, insGPSProcessor)
-	startBatchFlusher(ctx, insIMUProcessor)
-	startBatchFlusher(ctx, frontFreqProcessor)
-	startBatchFlusher(ctx, rearFreqProcessor)
-	startBatchFlusher(ctx, pdm1Processor)
-	startBatchFlusher(ctx, frontAeroProcessor)
-	startBatchFlusher(ctx, rearAeroProcessor)
-	startBatchFlusher(ctx, encoderProcessor)
-	startBatchFlusher(ctx, rearAnalogProcessor)
-	startBatchFlusher(ctx, bamocarTxProcessor)
-	startBatchFlusher(ctx, bamocarRxProcessor)
-	startBatchFlusher(ctx, bamoReTransProcessor)
-	startBatchFlusher(ctx, pdmCurrentProcessor)
-	startBatchFlusher(ctx, frontSGauge1Processor)
-	startBatchFlusher(ctx, frontSGauge2Processor)
-	startBatchFlusher(ctx, rearSGauge1Processor)
-	startBatchFlusher(ctx, rearSGauge2Processor)
-	startBatchFlusher(ctx, pdmReTransProcessor)
-}
-
-// startBatchFlusher starts a goroutine to periodically flush a batch processor
-func startBatchFlusher(ctx context.Context, processor *BatchProcessor) {
+	startBatchFlusher(ctx, "cell_data", cellBatchProcessor)
+	startBatchFlusher(ctx, "therm_data", thermBatchProcessor)
+	startBatchFlusher(ctx, "pack_current", packCurrentProcessor)
+	startBatchFlusher(ctx, "pack_voltage", packVoltageProcessor)
+	startBatchFlusher(ctx, "tcu2", bamocarProcessor)
+	startBatchFlusher(ctx, "tcu1", tcuProcessor)
+	startBatchFlusher(ctx, "front_analog", frontAnalogProcessor)
+	startBatchFlusher(ctx, "aculv_fd_1", aculvfd1Processor)
+	startBatchFlusher(ctx, "aculv_fd_2", aculvfd2Processor)
+	startBatchFlusher(ctx, "aculv1", aculv1Processor)
+	startBatchFlusher(ctx, "aculv2", aculv2Processor)
+	startBatchFlusher(ctx, "gps_best_pos", gpsBestPosProcessor)
+	startBatchFlusher(ctx, "ins_gps", insGPSProcessor)
+	startBatchFlusher(ctx, "ins_imu", insIMUProcessor)
+	startBatchFlusher(ctx, "front_frequency", frontFreqProcessor)
+	startBatchFlusher(ctx, "rear_frequency", rearFreqProcessor)
+	startBatchFlusher(ctx, "pdm1", pdm1Processor)
+	startBatchFlusher(ctx, "front_aero", frontAeroProcessor)
+	startBatchFlusher(ctx, "rear_aero", rearAeroProcessor)
+	startBatchFlusher(ctx, "encoder_data", encoderProcessor)
+	startBatchFlusher(ctx, "rear_analog", rearAnalogProcessor)
+	startBatchFlusher(ctx, "bamocar_tx_data", bamocarTxProcessor)
+	startBatchFlusher(ctx, "bamocar_rx_data", bamocarRxProcessor)
+	startBatchFlusher(ctx, "bamo_car_re_transmit", bamoReTransProcessor)
+	startBatchFlusher(ctx, "pdm_current", pdmCurrentProcessor)
+	startBatchFlusher(ctx, "front_strain_gauges_1", frontSGauge1Processor)
+	startBatchFlusher(ctx, "front_strain_gauges_2", frontSGauge2Processor)
+	startBatchFlusher(ctx, "rear_strain_gauges_1", rearSGauge1Processor)
+	startBatchFlusher(ctx, "rear_strain_gauges_2", rearSGauge2Processor)
+	startBatchFlusher(ctx, "pdm_re_transmit", pdmReTransProcessor)
+
+	// Steering wheel inputs (state changes only)
+	initDriverInputProcessor(ctx, batchSize, maxWait)
+
+	// Shutdown circuit element state changes
+	initShutdownProcessor(ctx, batchSize, maxWait)
+
+	// Full-rate IMU blocks
+	initIMUProcessor(ctx, batchSize, maxWait)
+
+	for _, p := range registeredProcessors() {
+		if err := p.resumeSequence(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchChannels maps the tables whose live channel has another name to the channel.
+var batchChannels = map[string]string{
+	"cell_data":           "cell",
+	"therm_data":          "thermistor",
+	"tcu1":                "tcu",
+	"tcu2":                "bamocar",
+	"encoder_data":        "encoder",
+	"driver_input_events": "driver_input_event",
+	"shutdown_events":     "shutdown_event",
+}
+
+// startBatchFlusher registers a batch processor under the given name and starts a
+// goroutine to periodically flush it
+func startBatchFlusher(ctx context.Context, name string, processor *BatchProcessor) {
+	processor.name = name
+	processor.channel = name
+	if ch, ok := batchChannels[name]; ok {
+		processor.channel = ch
+	}
+	registerBatchProcessor(processor)
+
 	go func() {
 		ticker := time.NewTicker(processor.maxWait / 2) // Check at half the max wait time
 		defer ticker.Stop()
@@ -743,15 +801,7 @@ func startBatchFlusher(ctx context.Context, processor *BatchProcessor) {
 				processor.mu.Lock()
 				if len(processor.data) > 0 && (len(processor.data) >= processor.batchSize ||
 					time.Since(processor.lastFlush) >= processor.maxWait) {
-					// Copy the data and reset the slice
-					batch := make([]interface{}, len(processor.data))
-					copy(batch, processor.data)
-					processor.data = processor.data[:0] // Reset without reallocating
-					processor.lastFlush = time.Now()
-					processor.mu.Unlock()
-
-					// Process batch (outside of lock)
-					processor.processorFunc(batch)
+					processor.flushLocked()
 				} else {
 					processor.mu.Unlock()
 				}
@@ -759,11 +809,7 @@ func startBatchFlusher(ctx context.Context, processor *BatchProcessor) {
 				// Flush any remaining data
 				processor.mu.Lock()
 				if len(processor.data) > 0 {
-					batch := make([]interface{}, len(processor.data))
-					copy(batch, processor.data)
-					processor.data = processor.data[:0]
-					processor.mu.Unlock()
-					processor.processorFunc(batch)
+					processor.flushLocked()
 				} else {
 					processor.mu.Unlock()
 				}
@@ -773,186 +819,233 @@ func startBatchFlusher(ctx context.Context, processor *BatchProcessor) {
 	}()
 }
 
+// flushLocked hands the queued records to the processor function. It must be called
+// with the lock held and releases it before the (slow) database write.
+func (p *BatchProcessor) flushLocked() {
+	// Copy the data and reset the slice
+	batch := make([]interface{}, len(p.data))
+	copy(batch, p.data)
+	p.data = p.data[:0] // Reset without reallocating
+	p.lastFlush = time.Now()
+	p.inFlightSince = p.oldestPending
+	p.oldestPending = time.Time{}
+	p.mu.Unlock()
+
+	// Process batch (outside of lock), timing it for the insert latency SLO
+	start := time.Now()
+	p.processorFunc(batch)
+	recordInsert(p.name, start, time.Since(start))
+
+	// The batch is now durably stored (or dropped after logging the error)
+	p.mu.Lock()
+	p.inFlightSince = time.Time{}
+	p.mu.Unlock()
+}
+
+// resumeSequence continues the sequence numbers of a telemetry table from the
+// highest one stored, so they keep increasing across restarts. A failed read is
+// retried before giving up.
+func (p *BatchProcessor) resumeSequence(ctx context.Context) error {
+	if _, ok := db.LookupTable(p.name); !ok || db.DB == nil {
+		return nil
+	}
+	var err error
+	for attempt := 1; attempt <= resumeAttempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("Failed to read the last sequence number of %s (attempt %d/%d): %v", p.name, attempt-1, resumeAttempts, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt-1) * time.Second):
+			}
+		}
+		var seq int64
+		readCtx, cancel := context.WithTimeout(ctx, resumeTimeout)
+		seq, err = db.MaxSequence(readCtx, p.name)
+		cancel()
+		if err == nil {
+			p.seq.Store(seq)
+			return nil
+		}
+	}
+	return fmt.Errorf("read the last sequence number of %s: %w", p.name, err)
+}
+
+// nextSeq returns the sequence number of the next record of the table. Records of
+// live-only channels are dropped by add, so they get 0 and use up no number;
+// otherwise the table would never catch up with its assigned sequence (see
+// CheckpointSequences). Policies only change at startup, so the check agrees
+// with the one in add.
+func (p *BatchProcessor) nextSeq() int64 {
+	if !livechannels.Stores(p.channel) {
+		return 0
+	}
+	return p.seq.Add(1)
+}
+
+// add queues a record for the next flush, unless its channel is live-only.
+func (p *BatchProcessor) add(item interface{}) {
+	if !livechannels.Stores(p.channel) {
+		return
+	}
+	p.mu.Lock()
+	if len(p.data) == 0 {
+		p.oldestPending = time.Now()
+	}
+	p.data = append(p.data, item)
+	p.mu.Unlock()
+}
+
 // Helper functions to add data to batch processors
 func AddCellDataToBatch(data types.Cell_Data) {
-	cellBatchProcessor.mu.Lock()
-	cellBatchProcessor.data = append(cellBatchProcessor.data, data)
-	cellBatchProcessor.mu.Unlock()
+	data.Seq = cellBatchProcessor.nextSeq()
+	cellBatchProcessor.add(data)
 }
 
 func AddThermDataToBatch(data types.Therm_Data) {
-	thermBatchProcessor.mu.Lock()
-	thermBatchProcessor.data = append(thermBatchProcessor.data, data)
-	thermBatchProcessor.mu.Unlock()
+	data.Seq = thermBatchProcessor.nextSeq()
+	thermBatchProcessor.add(data)
 }
 
 func AddPackCurrentToBatch(data types.PackCurrent_Data) {
-	packCurrentProcessor.mu.Lock()
-	packCurrentProcessor.data = append(packCurrentProcessor.data, data)
-	packCurrentProcessor.mu.Unlock()
+	data.Seq = packCurrentProcessor.nextSeq()
+	packCurrentProcessor.add(data)
 }
 
 func AddPackVoltageToBatch(data types.PackVoltage_Data) {
-	packVoltageProcessor.mu.Lock()
-	packVoltageProcessor.data = append(packVoltageProcessor.data, data)
-	packVoltageProcessor.mu.Unlock()
+	data.Seq = packVoltageProcessor.nextSeq()
+	packVoltageProcessor.add(data)
 }
 
 func AddBamocarToBatch(data types.TCU2_data) {
-	bamocarProcessor.mu.Lock()
-	bamocarProcessor.data = append(bamocarProcessor.data, data)
-	bamocarProcessor.mu.Unlock()
+	data.Seq = bamocarProcessor.nextSeq()
+	bamocarProcessor.add(data)
 }
 
 func AddTCUToBatch(data types.TCU_Data) {
-	tcuProcessor.mu.Lock()
-	tcuProcessor.data = append(tcuProcessor.data, data)
-	tcuProcessor.mu.Unlock()
+	data.Seq = tcuProcessor.nextSeq()
+	tcuProcessor.add(data)
 }
 
 func AddFrontAnalogToBatch(data types.FrontAnalog_Data) {
-	frontAnalogProcessor.mu.Lock()
-	frontAnalogProcessor.data = append(frontAnalogProcessor.data, data)
-	frontAnalogProcessor.mu.Unlock()
+	data.Seq = frontAnalogProcessor.nextSeq()
+	frontAnalogProcessor.add(data)
 }
 
 // New Add-to-batch functions
 func AddACULVFD1ToBatch(data types.ACULV_FD_1_Data) {
-	aculvfd1Processor.mu.Lock()
-	aculvfd1Processor.data = append(aculvfd1Processor.data, data)
-	aculvfd1Processor.mu.Unlock()
+	data.Seq = aculvfd1Processor.nextSeq()
+	aculvfd1Processor.add(data)
 }
 
 func AddACULVFD2ToBatch(data types.ACULV_FD_2_Data) {
-	aculvfd2Processor.mu.Lock()
-	aculvfd2Processor.data = append(aculvfd2Processor.data, data)
-	aculvfd2Processor.mu.Unlock()
+	data.Seq = aculvfd2Processor.nextSeq()
+	aculvfd2Processor.add(data)
 }
 
 func AddACULV1ToBatch(data types.ACULV1_Data) {
-	aculv1Processor.mu.Lock()
-	aculv1Processor.data = append(aculv1Processor.data, data)
-	aculv1Processor.mu.Unlock()
+	data.Seq = aculv1Processor.nextSeq()
+	aculv1Processor.add(data)
 }
 
 func AddACULV2ToBatch(data types.ACULV2_Data) {
-	aculv2Processor.mu.Lock()
-	aculv2Processor.data = append(aculv2Processor.data, data)
-	aculv2Processor.mu.Unlock()
+	data.Seq = aculv2Processor.nextSeq()
+	aculv2Processor.add(data)
 }
 
 func AddGPSBestPosToBatch(data types.GPSBestPos_Data) {
-	gpsBestPosProcessor.mu.Lock()
-	gpsBestPosProcessor.data = append(gpsBestPosProcessor.data, data)
-	gpsBestPosProcessor.mu.Unlock()
+	data.Seq = gpsBestPosProcessor.nextSeq()
+	gpsBestPosProcessor.add(data)
 }
 
 func AddINSGPSToBatch(data types.INS_GPS_Data) {
-	insGPSProcessor.mu.Lock()
-	insGPSProcessor.data = append(insGPSProcessor.data, data)
-	insGPSProcessor.mu.Unlock()
+	data.Seq = insGPSProcessor.nextSeq()
+	insGPSProcessor.add(data)
 }
 
 func AddINSIMUToBatch(data types.INS_IMU_Data) {
-	insIMUProcessor.mu.Lock()
-	insIMUProcessor.data = append(insIMUProcessor.data, data)
-	insIMUProcessor.mu.Unlock()
+	data.Seq = insIMUProcessor.nextSeq()
+	insIMUProcessor.add(data)
 }
 
 func AddFrontFrequencyToBatch(data types.FrontFrequency_Data) {
-	frontFreqProcessor.mu.Lock()
-	frontFreqProcessor.data = append(frontFreqProcessor.data, data)
-	frontFreqProcessor.mu.Unlock()
+	data.Seq = frontFreqProcessor.nextSeq()
+	frontFreqProcessor.add(data)
 }
 
 func AddRearFrequencyToBatch(data types.RearFrequency_Data) {
-	rearFreqProcessor.mu.Lock()
-	rearFreqProcessor.data = append(rearFreqProcessor.data, data)
-	rearFreqProcessor.mu.Unlock()
+	data.Seq = rearFreqProcessor.nextSeq()
+	rearFreqProcessor.add(data)
 }
 
 func AddPDM1ToBatch(data types.PDM1_Data) {
-	pdm1Processor.mu.Lock()
-	pdm1Processor.data = append(pdm1Processor.data, data)
-	pdm1Processor.mu.Unlock()
+	data.Seq = pdm1Processor.nextSeq()
+	pdm1Processor.add(data)
 }
 
 func AddFrontAeroToBatch(data types.FrontAero_Data) {
-	frontAeroProcessor.mu.Lock()
-	frontAeroProcessor.data = append(frontAeroProcessor.data, data)
-	frontAeroProcessor.mu.Unlock()
+	data.Seq = frontAeroProcessor.nextSeq()
+	frontAeroProcessor.add(data)
 }
 
 func AddRearAeroToBatch(data types.RearAero_Data) {
-	rearAeroProcessor.mu.Lock()
-	rearAeroProcessor.data = append(rearAeroProcessor.data, data)
-	rearAeroProcessor.mu.Unlock()
+	data.Seq = rearAeroProcessor.nextSeq()
+	rearAeroProcessor.add(data)
 }
 
 func AddEncoderToBatch(data types.Encoder_Data) {
-	encoderProcessor.mu.Lock()
-	encoderProcessor.data = append(encoderProcessor.data, data)
-	encoderProcessor.mu.Unlock()
+	data.Seq = encoderProcessor.nextSeq()
+	encoderProcessor.add(data)
 }
 
 func AddRearAnalogToBatch(data types.RearAnalog_Data) {
-	rearAnalogProcessor.mu.Lock()
-	rearAnalogProcessor.data = append(rearAnalogProcessor.data, data)
-	rearAnalogProcessor.mu.Unlock()
+	data.Seq = rearAnalogProcessor.nextSeq()
+	rearAnalogProcessor.add(data)
 }
 
 func AddBamocarTxToBatch(data types.BamocarTxData_Data) {
-	bamocarTxProcessor.mu.Lock()
-	bamocarTxProcessor.data = append(bamocarTxProcessor.data, data)
-	bamocarTxProcessor.mu.Unlock()
+	data.Seq = bamocarTxProcessor.nextSeq()
+	bamocarTxProcessor.add(data)
 }
 
 func AddBamocarRxToBatch(data types.BamocarRxData_Data) {
-	bamocarRxProcessor.mu.Lock()
-	bamocarRxProcessor.data = append(bamocarRxProcessor.data, data)
-	bamocarRxProcessor.mu.Unlock()
+	data.Seq = bamocarRxProcessor.nextSeq()
+	bamocarRxProcessor.add(data)
 }
 
 func AddBamoCarReTransmitToBatch(data types.BamoCarReTransmit_Data) {
-	bamoReTransProcessor.mu.Lock()
-	bamoReTransProcessor.data = append(bamoReTransProcessor.data, data)
-	bamoReTransProcessor.mu.Unlock()
+	data.Seq = bamoReTransProcessor.nextSeq()
+	bamoReTransProcessor.add(data)
 }
 
 func AddPDMCurrentToBatch(data types.PDMCurrent_Data) {
-	pdmCurrentProcessor.mu.Lock()
-	pdmCurrentProcessor.data = append(pdmCurrentProcessor.data, data)
-	pdmCurrentProcessor.mu.Unlock()
+	data.Seq = pdmCurrentProcessor.nextSeq()
+	pdmCurrentProcessor.add(data)
 }
 
 func AddFrontStrainGauges1ToBatch(data types.FrontStrainGauges1_Data) {
-	frontSGauge1Processor.mu.Lock()
-	frontSGauge1Processor.data = append(frontSGauge1Processor.data, data)
-	frontSGauge1Processor.mu.Unlock()
+	data.Seq = frontSGauge1Processor.nextSeq()
+	frontSGauge1Processor.add(data)
 }
 
 func AddFrontStrainGauges2ToBatch(data types.FrontStrainGauges2_Data) {
-	frontSGauge2Processor.mu.Lock()
-	frontSGauge2Processor.data = append(frontSGauge2Processor.data, data)
-	frontSGauge2Processor.mu.Unlock()
+	data.Seq = frontSGauge2Processor.nextSeq()
+	frontSGauge2Processor.add(data)
 }
 
 func AddRearStrainGauges1ToBatch(data types.RearStrainGauges1_Data) {
-	rearSGauge1Processor.mu.Lock()
-	rearSGauge1Processor.data = append(rearSGauge1Processor.data, data)
-	rearSGauge1Processor.mu.Unlock()
+	data.Seq = rearSGauge1Processor.nextSeq()
+	rearSGauge1Processor.add(data)
 }
 
 func AddRearStrainGauges2ToBatch(data types.RearStrainGauges2_Data) {
-	rearSGauge2Processor.mu.Lock()
-	rearSGauge2Processor.data = append(rearSGauge2Processor.data, data)
-	rearSGauge2Processor.mu.Unlock()
+	data.Seq = rearSGauge2Processor.nextSeq()
+	rearSGauge2Processor.add(data)
 }
 
 func AddPDMReTransmitToBatch(data types.PDMReTransmit_Data) {
-	pdmReTransProcessor.mu.Lock()
-	pdmReTransProcessor.data = append(pdmReTransProcessor.data, data)
-	pdmReTransProcessor.mu.Unlock()
+	data.Seq = pdmReTransProcessor.nextSeq()
+	pdmReTransProcessor.add(data)
 }
 
 // buildPayload constructs a payload with the given type, timestamp and data.
@@ -968,11 +1061,40 @@ func buildPayload(msgType string, t time.Time, data map[string]interface{}) map[
 // broadcastTelemetry converts a map payload into a TelemetryMessage proto,
 // marshals it into binary format and then calls ThrottledBroadcast.
 // BroadcastFunc is assigned by main to push real‑time messages to the WebSocket hub.
-var BroadcastFunc func(msg []byte)
+// channel is the message type and key the board of types sent once per board.
+var BroadcastFunc func(channel, key string, msg []byte)
 
 // broadcastTelemetry converts a map payload into a TelemetryMessage proto,
-// marshals it into binary format and then calls BroadcastFunc.
+// marshals it into binary format and then calls BroadcastFunc. Messages of
+// store-only channels are dropped, as are frames shed while storage lags.
 func broadcastTelemetry(payloadMap map[string]interface{}) {
+	if typ, _ := payloadMap["type"].(string); !livechannels.Broadcasts(typ) || shedBroadcast(typ) {
+		return
+	}
+	if liveRosbag != nil {
+		liveRosbag.observe(payloadMap)
+	}
+	if liveFilters != nil {
+		liveFilters.apply(payloadMap)
+	}
+
+	bin, err := marshalTelemetry(payloadMap)
+	if err != nil {
+		return
+	}
+	typ, key := messageKey(payloadMap)
+	lastValues.store(typ, key, bin)
+
+	// Use BroadcastFunc which is set to ThrottledBroadcast in main.go
+	if BroadcastFunc != nil {
+		BroadcastFunc(typ, key, bin)
+	}
+}
+
+// marshalTelemetry converts a map payload built by buildPayload into a binary
+// TelemetryMessage proto, tagged with the channel ID and schema version of its
+// type from the live channel registry.
+func marshalTelemetry(payloadMap map[string]interface{}) ([]byte, error) {
 	typ, _ := payloadMap["type"].(string)
 	// Use the top‑level time field (not nested in payload)
 	timeStr, _ := payloadMap["time"].(string)
@@ -982,22 +1104,18 @@ func broadcastTelemetry(payloadMap map[string]interface{}) {
 	}
 	st, err := structpb.NewStruct(payloadContent)
 	if err != nil {
-		return
+		return nil, err
 	}
 	msg := &proto.TelemetryMessage{
 		Type:    typ,
 		Payload: st,
 		Time:    timeStr,
 	}
-	bin, err := protobuf.Marshal(msg)
-	if err != nil {
-		return
-	}
-
-	// Use BroadcastFunc which is set to ThrottledBroadcast in main.go
-	if BroadcastFunc != nil {
-		BroadcastFunc(bin)
+	if ch, ok := livechannels.Lookup(typ); ok {
+		msg.ChannelId = ch.ID
+		msg.SchemaVersion = ch.Version
 	}
+	return protobuf.Marshal(msg)
 }
 
 // HandleDataInsertions routes decoded CAN frame data to its appropriate processing function.
@@ -1008,6 +1126,14 @@ func HandleDataInsertions(
 	recordCount int,
 	path string,
 ) {
+	alerts.EvaluateRules(frameID, decoded, time.Now())
+
+	// The steering wheel frame ID is configurable
+	if frameID == driverInputFrameID {
+		processDriverInputData(decoded)
+		return
+	}
+
 	switch frameID {
 	case 4:
 		processPackCurrentData(decoded)
@@ -1117,9 +1243,11 @@ func processCellValuesFromCSV(frameID uint32, decoded map[string]string, cellDat
 		if strings.HasPrefix(k, "Cell") {
 			if idx, err := strconv.Atoi(strings.TrimPrefix(k, "Cell")); err == nil {
 				f, err := strconv.ParseFloat(v, 64)
-				if err == nil {
-					setCellValue(cellDataBuffers[0], idx, f)
+				if err != nil {
+					markCellMissing(cellDataBuffers[0], idx)
+					continue
 				}
+				setCellValue(cellDataBuffers[0], idx, f)
 			}
 		}
 	}
@@ -1131,9 +1259,11 @@ func processCellValuesFromLive(frameID uint32, decoded map[string]string, cellDa
 		if strings.HasPrefix(k, "Cell") {
 			if idx, err := strconv.Atoi(strings.TrimPrefix(k, "Cell")); err == nil {
 				f, err := strconv.ParseFloat(v, 64)
-				if err == nil {
-					setCellValue(cellDataBuffers[0], idx, f)
+				if err != nil {
+					markCellMissing(cellDataBuffers[0], idx)
+					continue
 				}
+				setCellValue(cellDataBuffers[0], idx, f)
 			}
 		}
 	}
@@ -1146,20 +1276,22 @@ func processCellValuesFromLive(frameID uint32, decoded map[string]string, cellDa
 
 func processRearStrainGauges2Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.RearStrainGauges2_Data{
 		Timestamp: t,
-		Gauge1:    utils.ParseIntSignal(decoded, "gauge1"),
-		Gauge2:    utils.ParseIntSignal(decoded, "gauge2"),
-		Gauge3:    utils.ParseIntSignal(decoded, "gauge3"),
-		Gauge4:    utils.ParseIntSignal(decoded, "gauge4"),
-		Gauge5:    utils.ParseIntSignal(decoded, "gauge5"),
-		Gauge6:    utils.ParseIntSignal(decoded, "gauge6"),
+		Gauge1:    sig.int("gauge1", "gauge1"),
+		Gauge2:    sig.int("gauge2", "gauge2"),
+		Gauge3:    sig.int("gauge3", "gauge3"),
+		Gauge4:    sig.int("gauge4", "gauge4"),
+		Gauge5:    sig.int("gauge5", "gauge5"),
+		Gauge6:    sig.int("gauge6", "gauge6"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddRearStrainGauges2ToBatch(d)
 
-	payload := buildPayload("rear_strain_gauges_2", t, map[string]interface{}{
+	payload := buildRecordPayload("rear_strain_gauges_2", t, d.RecordMeta, map[string]interface{}{
 		"gauge1": d.Gauge1,
 		"gauge2": d.Gauge2,
 		"gauge3": d.Gauge3,
@@ -1172,20 +1304,22 @@ func processRearStrainGauges2Data(decoded map[string]string) {
 
 func processRearStrainGauges1Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.RearStrainGauges1_Data{
 		Timestamp: t,
-		Gauge1:    utils.ParseIntSignal(decoded, "Gauge1"),
-		Gauge2:    utils.ParseIntSignal(decoded, "Gauge2"),
-		Gauge3:    utils.ParseIntSignal(decoded, "Gauge3"),
-		Gauge4:    utils.ParseIntSignal(decoded, "Gauge4"),
-		Gauge5:    utils.ParseIntSignal(decoded, "Gauge5"),
-		Gauge6:    utils.ParseIntSignal(decoded, "Gauge6"),
+		Gauge1:    sig.int("Gauge1", "gauge1"),
+		Gauge2:    sig.int("Gauge2", "gauge2"),
+		Gauge3:    sig.int("Gauge3", "gauge3"),
+		Gauge4:    sig.int("Gauge4", "gauge4"),
+		Gauge5:    sig.int("Gauge5", "gauge5"),
+		Gauge6:    sig.int("Gauge6", "gauge6"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddRearStrainGauges1ToBatch(d)
 
-	payload := buildPayload("rear_strain_gauges_1", t, map[string]interface{}{
+	payload := buildRecordPayload("rear_strain_gauges_1", t, d.RecordMeta, map[string]interface{}{
 		"gauge1": d.Gauge1,
 		"gauge2": d.Gauge2,
 		"gauge3": d.Gauge3,
@@ -1198,20 +1332,22 @@ func processRearStrainGauges1Data(decoded map[string]string) {
 
 func processBamocarRxData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	data := types.BamocarRxData_Data{
 		Timestamp: t,
-		REGID:     utils.ParseIntSignal(decoded, "REGID"),
-		Byte1:     utils.ParseIntSignal(decoded, "Byte1"),
-		Byte2:     utils.ParseIntSignal(decoded, "Byte2"),
-		Byte3:     utils.ParseIntSignal(decoded, "Byte3"),
-		Byte4:     utils.ParseIntSignal(decoded, "Byte4"),
-		Byte5:     utils.ParseIntSignal(decoded, "Byte5"),
+		REGID:     sig.int("REGID", "regid"),
+		Byte1:     sig.int("Byte1", "byte1"),
+		Byte2:     sig.int("Byte2", "byte2"),
+		Byte3:     sig.int("Byte3", "byte3"),
+		Byte4:     sig.int("Byte4", "byte4"),
+		Byte5:     sig.int("Byte5", "byte5"),
 	}
+	data.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddBamocarRxToBatch(data)
 
-	payload := buildPayload("bamocar_rx_data", t, map[string]interface{}{
+	payload := buildRecordPayload("bamocar_rx_data", t, data.RecordMeta, map[string]interface{}{
 		"regid": data.REGID,
 		"byte1": data.Byte1,
 		"byte2": data.Byte2,
@@ -1224,31 +1360,33 @@ func processBamocarRxData(decoded map[string]string) {
 
 func processThermData(decoded map[string]string, thermID int) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	th := types.Therm_Data{
 		Timestamp:    t,
 		ThermistorID: thermID,
-		Therm1:       utils.ParseFloatSignal(decoded, "Therm1"),
-		Therm2:       utils.ParseFloatSignal(decoded, "Therm2"),
-		Therm3:       utils.ParseFloatSignal(decoded, "Therm3"),
-		Therm4:       utils.ParseFloatSignal(decoded, "Therm4"),
-		Therm5:       utils.ParseFloatSignal(decoded, "Therm5"),
-		Therm6:       utils.ParseFloatSignal(decoded, "Therm6"),
-		Therm7:       utils.ParseFloatSignal(decoded, "Therm7"),
-		Therm8:       utils.ParseFloatSignal(decoded, "Therm8"),
-		Therm9:       utils.ParseFloatSignal(decoded, "Therm9"),
-		Therm10:      utils.ParseFloatSignal(decoded, "Therm10"),
-		Therm11:      utils.ParseFloatSignal(decoded, "Therm11"),
-		Therm12:      utils.ParseFloatSignal(decoded, "Therm12"),
-		Therm13:      utils.ParseFloatSignal(decoded, "Therm13"),
-		Therm14:      utils.ParseFloatSignal(decoded, "Therm14"),
-		Therm15:      utils.ParseFloatSignal(decoded, "Therm15"),
-		Therm16:      utils.ParseFloatSignal(decoded, "Therm16"),
-	}
+		Therm1:       sig.float("Therm1", "therm1"),
+		Therm2:       sig.float("Therm2", "therm2"),
+		Therm3:       sig.float("Therm3", "therm3"),
+		Therm4:       sig.float("Therm4", "therm4"),
+		Therm5:       sig.float("Therm5", "therm5"),
+		Therm6:       sig.float("Therm6", "therm6"),
+		Therm7:       sig.float("Therm7", "therm7"),
+		Therm8:       sig.float("Therm8", "therm8"),
+		Therm9:       sig.float("Therm9", "therm9"),
+		Therm10:      sig.float("Therm10", "therm10"),
+		Therm11:      sig.float("Therm11", "therm11"),
+		Therm12:      sig.float("Therm12", "therm12"),
+		Therm13:      sig.float("Therm13", "therm13"),
+		Therm14:      sig.float("Therm14", "therm14"),
+		Therm15:      sig.float("Therm15", "therm15"),
+		Therm16:      sig.float("Therm16", "therm16"),
+	}
+	th.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddThermDataToBatch(th)
 
-	payload := buildPayload("thermistor", t, map[string]interface{}{
+	payload := buildRecordPayload("thermistor", t, th.RecordMeta, map[string]interface{}{
 		"thermistor_id": th.ThermistorID,
 		"therm1":        th.Therm1,
 		"therm2":        th.Therm2,
@@ -1272,18 +1410,20 @@ func processThermData(decoded map[string]string, thermID int) {
 
 func processTCUData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	tcu := types.TCU_Data{
 		Timestamp: t,
-		APPS1:     utils.ParseFloatSignal(decoded, "APPS1"),
-		APPS2:     utils.ParseFloatSignal(decoded, "APPS2"),
-		BSE:       utils.ParseFloatSignal(decoded, "BSE"),
-		Status:    utils.ParseIntSignal(decoded, "Status"),
+		APPS1:     sig.float("APPS1", "apps1"),
+		APPS2:     sig.float("APPS2", "apps2"),
+		BSE:       sig.float("BSE", "bse"),
+		Status:    sig.int("Status", "status"),
 	}
+	tcu.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddTCUToBatch(tcu)
 
-	payload := buildPayload("tcu", t, map[string]interface{}{
+	payload := buildRecordPayload("tcu", t, tcu.RecordMeta, map[string]interface{}{
 		"apps1":  tcu.APPS1,
 		"apps2":  tcu.APPS2,
 		"bse":    tcu.BSE,
@@ -1294,15 +1434,22 @@ func processTCUData(decoded map[string]string) {
 
 func processPackCurrentData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.PackCurrent_Data{
 		Timestamp: t,
-		Current:   utils.ParseFloatSignal(decoded, "PackCurrent"),
+		Current:   sig.float("PackCurrent", "current"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddPackCurrentToBatch(d)
+	if !d.IsMissing("current") {
+		noteEnergyCurrent(d.Current, t)
+		noteShuntCurrent(d.Current, t)
+		vehiclestate.ObserveCurrent(d.Current, t)
+	}
 
-	payload := buildPayload("pack_current", t, map[string]interface{}{
+	payload := buildRecordPayload("pack_current", t, d.RecordMeta, map[string]interface{}{
 		"current": d.Current,
 	})
 	broadcastTelemetry(payload)
@@ -1310,15 +1457,20 @@ func processPackCurrentData(decoded map[string]string) {
 
 func processPackVoltageData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.PackVoltage_Data{
 		Timestamp: t,
-		Voltage:   utils.ParseFloatSignal(decoded, "PackVoltage"),
+		Voltage:   sig.float("PackVoltage", "voltage"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddPackVoltageToBatch(d)
+	if !d.IsMissing("voltage") {
+		noteEnergyVoltage(d.Voltage)
+	}
 
-	payload := buildPayload("pack_voltage", t, map[string]interface{}{
+	payload := buildRecordPayload("pack_voltage", t, d.RecordMeta, map[string]interface{}{
 		"voltage": d.Voltage,
 	})
 	broadcastTelemetry(payload)
@@ -1326,17 +1478,23 @@ func processPackVoltageData(decoded map[string]string) {
 
 func processBamocarData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	b := types.TCU2_data{
 		Timestamp:  t,
-		BamocarFRG: utils.ParseIntSignal(decoded, "BamocarFRG"),
-		BamocarRFE: utils.ParseIntSignal(decoded, "BamocarRFE"),
-		BrakeLight: utils.ParseIntSignal(decoded, "BrakeLight"),
+		BamocarFRG: sig.int("BamocarFRG", "bamocar_frg"),
+		BamocarRFE: sig.int("BamocarRFE", "bamocar_rfe"),
+		BrakeLight: sig.int("BrakeLight", "brake_light"),
 	}
+	b.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddBamocarToBatch(b)
+	if !b.IsMissing("bamocar_rfe") && !b.IsMissing("bamocar_frg") {
+		shutdown.ObserveBamocar(b.BamocarRFE, b.BamocarFRG, t)
+		vehiclestate.ObserveBamocar(b.BamocarRFE, b.BamocarFRG, t)
+	}
 
-	payload := buildPayload("bamocar", t, map[string]interface{}{
+	payload := buildRecordPayload("bamocar", t, b.RecordMeta, map[string]interface{}{
 		"bamocar_frg": b.BamocarFRG,
 		"bamocar_rfe": b.BamocarRFE,
 		"brake_light": b.BrakeLight,
@@ -1346,22 +1504,24 @@ func processBamocarData(decoded map[string]string) {
 
 func processFrontAnalogData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.FrontAnalog_Data{
 		Timestamp:     t,
-		LeftRad:       utils.ParseIntSignal(decoded, "LeftRad"),
-		RightRad:      utils.ParseIntSignal(decoded, "RightRad"),
-		FrontRightPot: utils.ParseFloatSignal(decoded, "FrontRightPot"),
-		FrontLeftPot:  utils.ParseFloatSignal(decoded, "FrontLeftPot"),
-		RearRightPot:  utils.ParseFloatSignal(decoded, "RearRightPot"),
-		RearLeftPot:   utils.ParseFloatSignal(decoded, "RearLeftPot"),
-		SteeringAngle: utils.ParseFloatSignal(decoded, "SteeringAngle"),
-		Analog8:       utils.ParseIntSignal(decoded, "Analog8"),
+		LeftRad:       sig.int("LeftRad", "left_rad"),
+		RightRad:      sig.int("RightRad", "right_rad"),
+		FrontRightPot: sig.float("FrontRightPot", "front_right_pot"),
+		FrontLeftPot:  sig.float("FrontLeftPot", "front_left_pot"),
+		RearRightPot:  sig.float("RearRightPot", "rear_right_pot"),
+		RearLeftPot:   sig.float("RearLeftPot", "rear_left_pot"),
+		SteeringAngle: sig.float("SteeringAngle", "steering_angle"),
+		Analog8:       sig.int("Analog8", "analog8"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddFrontAnalogToBatch(d)
 
-	payload := buildPayload("front_analog", t, map[string]interface{}{
+	payload := buildRecordPayload("front_analog", t, d.RecordMeta, map[string]interface{}{
 		"left_rad":        d.LeftRad,
 		"right_rad":       d.RightRad,
 		"front_right_pot": d.FrontRightPot,
@@ -1384,6 +1544,14 @@ func setCellValue(agg *types.Cell_Data, idx int, val float64) {
 	}
 }
 
+// markCellMissing records that a cell had no value in this cycle.
+func markCellMissing(agg *types.Cell_Data, idx int) {
+	column := "cell" + strconv.Itoa(idx)
+	if !agg.IsMissing(column) {
+		agg.Missing = append(agg.Missing, column)
+	}
+}
+
 func getCellValue(agg *types.Cell_Data, idx int) float64 {
 	v := reflect.ValueOf(agg).Elem()
 	fieldName := "Cell" + strconv.Itoa(idx)
@@ -1400,6 +1568,10 @@ func BroadcastCells(agg *types.Cell_Data) {
 	signals["type"] = "cell"
 	for i := 1; i <= 128; i++ {
 		key := "cell" + strconv.Itoa(i)
+		if agg.IsMissing(key) {
+			signals[key] = nil
+			continue
+		}
 		signals[key] = fmt.Sprintf("%.3f", getCellValue(agg, i))
 	}
 	wrapper := map[string]interface{}{
@@ -1413,22 +1585,43 @@ func BroadcastCells(agg *types.Cell_Data) {
 // processACULVFD1Data handles frame ID 8 using the ACULV_FD_1_Data type.
 func processACULVFD1Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.ACULV_FD_1_Data{
 		Timestamp:            t,
-		AMSStatus:            utils.ParseIntSignal(decoded, "AMSStatus"),
-		FLD:                  utils.ParseIntSignal(decoded, "FLD"),
-		StateOfCharge:        utils.ParseFloatSignal(decoded, "StateOfCharge"),
-		AccumulatorVoltage:   utils.ParseFloatSignal(decoded, "AccumulatorVoltage"),
-		TractiveVoltage:      utils.ParseFloatSignal(decoded, "TractiveVoltage"),
-		CellCurrent:          utils.ParseFloatSignal(decoded, "CellCurrent"),
-		IsolationMonitoring:  utils.ParseIntSignal(decoded, "IsolationMonitoring"),
-		IsolationMonitoring1: utils.ParseFloatSignal(decoded, "IsolationMonitoring1"),
+		AMSStatus:            sig.int("AMSStatus", "ams_status"),
+		FLD:                  sig.int("FLD", "fld"),
+		StateOfCharge:        sig.float("StateOfCharge", "state_of_charge"),
+		AccumulatorVoltage:   sig.float("AccumulatorVoltage", "accumulator_voltage"),
+		TractiveVoltage:      sig.float("TractiveVoltage", "tractive_voltage"),
+		CellCurrent:          sig.float("CellCurrent", "cell_current"),
+		IsolationMonitoring:  sig.int("IsolationMonitoring", "isolation_monitoring"),
+		IsolationMonitoring1: sig.float("IsolationMonitoring1", "isolation_monitoring1"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddACULVFD1ToBatch(d)
 
-	payload := buildPayload("aculv_fd_1", t, map[string]interface{}{
+	// Tractive system activation drives automatic session start/stop. A missing
+	// signal must not be taken for 0 V or a tripped AMS.
+	if !d.IsMissing("tractive_voltage") {
+		sessions.NoteTractiveVoltage(d.TractiveVoltage, t)
+		if !d.IsMissing("accumulator_voltage") {
+			precharge.Observe(d.AccumulatorVoltage, d.TractiveVoltage, t)
+		}
+	}
+	if !d.IsMissing("ams_status") {
+		shutdown.ObserveAMS(d.AMSStatus, t)
+	}
+	if !d.IsMissing("cell_current") {
+		noteHallCurrent(d.CellCurrent, t)
+		if !d.IsMissing("accumulator_voltage") {
+			resistance.Observe(d.AccumulatorVoltage, d.CellCurrent, t)
+		}
+	}
+	observeVehicleStateAMS(d, t)
+
+	payload := buildRecordPayload("aculv_fd_1", t, d.RecordMeta, map[string]interface{}{
 		"ams_status":            d.AMSStatus,
 		"fld":                   d.FLD,
 		"state_of_charge":       d.StateOfCharge,
@@ -1444,16 +1637,18 @@ func processACULVFD1Data(decoded map[string]string) {
 // processACULVFD2Data handles frame ID 30 using the ACULV_FD_2_Data type.
 func processACULVFD2Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.ACULV_FD_2_Data{
 		Timestamp:   t,
-		FanSetPoint: utils.ParseFloatSignal(decoded, "FanSetPoint"),
-		RPM:         utils.ParseFloatSignal(decoded, "RPM"),
+		FanSetPoint: sig.float("FanSetPoint", "fan_set_point"),
+		RPM:         sig.float("RPM", "rpm"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddACULVFD2ToBatch(d)
 
-	payload := buildPayload("aculv_fd_2", t, map[string]interface{}{
+	payload := buildRecordPayload("aculv_fd_2", t, d.RecordMeta, map[string]interface{}{
 		"fan_set_point": d.FanSetPoint,
 		"rpm":           d.RPM,
 	})
@@ -1463,16 +1658,18 @@ func processACULVFD2Data(decoded map[string]string) {
 // processACULV1Data handles frame ID 40 using the ACULV1_Data type.
 func processACULV1Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.ACULV1_Data{
 		Timestamp:     t,
-		ChargeStatus1: utils.ParseFloatSignal(decoded, "ChargeStatus1"),
-		ChargeStatus2: utils.ParseFloatSignal(decoded, "ChargeStatus2"),
+		ChargeStatus1: sig.float("ChargeStatus1", "charge_status1"),
+		ChargeStatus2: sig.float("ChargeStatus2", "charge_status2"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddACULV1ToBatch(d)
 
-	payload := buildPayload("aculv1", t, map[string]interface{}{
+	payload := buildRecordPayload("aculv1", t, d.RecordMeta, map[string]interface{}{
 		"charge_status1": d.ChargeStatus1,
 		"charge_status2": d.ChargeStatus2,
 	})
@@ -1482,15 +1679,20 @@ func processACULV1Data(decoded map[string]string) {
 // processACULV2Data handles frame ID 41 using the ACULV2_Data type.
 func processACULV2Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.ACULV2_Data{
 		Timestamp:     t,
-		ChargeRequest: utils.ParseIntSignal(decoded, "ChargeRequest"),
+		ChargeRequest: sig.int("ChargeRequest", "charge_request"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddACULV2ToBatch(d)
+	if !d.IsMissing("charge_request") {
+		vehiclestate.ObserveChargeRequest(d.ChargeRequest, t)
+	}
 
-	payload := buildPayload("aculv2", t, map[string]interface{}{
+	payload := buildRecordPayload("aculv2", t, d.RecordMeta, map[string]interface{}{
 		"charge_request": d.ChargeRequest,
 	})
 	broadcastTelemetry(payload)
@@ -1499,21 +1701,31 @@ func processACULV2Data(decoded map[string]string) {
 // processGPSBestPosData handles frame ID 80 using the GPSBestPos_Data type.
 func processGPSBestPosData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.GPSBestPos_Data{
 		Timestamp:    t,
-		Latitude:     utils.ParseFloatSignal(decoded, "Latitude"),
-		Longitude:    utils.ParseFloatSignal(decoded, "Longitude"),
-		Altitude:     utils.ParseFloatSignal(decoded, "Altitude"),
-		StdLatitude:  utils.ParseFloatSignal(decoded, "StdLatitude"),
-		StdLongitude: utils.ParseFloatSignal(decoded, "StdLongitude"),
-		StdAltitude:  utils.ParseFloatSignal(decoded, "StdAltitude"),
-		GPSStatus:    utils.ParseIntSignal(decoded, "GPSStatus"),
+		Latitude:     sig.float("Latitude", "latitude"),
+		Longitude:    sig.float("Longitude", "longitude"),
+		Altitude:     sig.float("Altitude", "altitude"),
+		StdLatitude:  sig.float("StdLatitude", "std_latitude"),
+		StdLongitude: sig.float("StdLongitude", "std_longitude"),
+		StdAltitude:  sig.float("StdAltitude", "std_altitude"),
+		GPSStatus:    sig.int("GPSStatus", "gps_status"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddGPSBestPosToBatch(d)
 
-	payload := buildPayload("gps_best_pos", t, map[string]interface{}{
+	if !d.IsMissing("latitude") && !d.IsMissing("longitude") {
+		// Start/finish gate detection
+		laps.NoteGPS(d.Latitude, d.Longitude, t)
+
+		// Downsampled position for the live track map
+		noteTrackPosition(d.Latitude, d.Longitude, t)
+	}
+
+	payload := buildRecordPayload("gps_best_pos", t, d.RecordMeta, map[string]interface{}{
 		"latitude":      d.Latitude,
 		"longitude":     d.Longitude,
 		"altitude":      d.Altitude,
@@ -1528,19 +1740,21 @@ func processGPSBestPosData(decoded map[string]string) {
 // processINS_GPS_Data handles frame ID 81 using the INS_GPS_Data type.
 func processINS_GPS_Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.INS_GPS_Data{
 		Timestamp:   t,
-		GNSSWeek:    utils.ParseIntSignal(decoded, "GNSSWeek"),
-		GNSSSeconds: utils.ParseFloatSignal(decoded, "GNSSSeconds"),
-		GNSSLat:     utils.ParseFloatSignal(decoded, "GNSSLat"),
-		GNSSLong:    utils.ParseFloatSignal(decoded, "GNSSLong"),
-		GNSSHeight:  utils.ParseFloatSignal(decoded, "GNSSHeight"),
+		GNSSWeek:    sig.int("GNSSWeek", "gnss_week"),
+		GNSSSeconds: sig.float("GNSSSeconds", "gnss_seconds"),
+		GNSSLat:     sig.float("GNSSLat", "gnss_lat"),
+		GNSSLong:    sig.float("GNSSLong", "gnss_long"),
+		GNSSHeight:  sig.float("GNSSHeight", "gnss_height"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddINSGPSToBatch(d)
 
-	payload := buildPayload("ins_gps", t, map[string]interface{}{
+	payload := buildRecordPayload("ins_gps", t, d.RecordMeta, map[string]interface{}{
 		"gnss_week":    d.GNSSWeek,
 		"gnss_seconds": d.GNSSSeconds,
 		"gnss_lat":     d.GNSSLat,
@@ -1553,21 +1767,26 @@ func processINS_GPS_Data(decoded map[string]string) {
 // processINS_IMUData handles frame ID 82 using the INS_IMU_Data type.
 func processINS_IMUData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.INS_IMU_Data{
 		Timestamp: t,
-		NorthVel:  utils.ParseFloatSignal(decoded, "NorthVel"),
-		EastVel:   utils.ParseFloatSignal(decoded, "EastVel"),
-		UpVel:     utils.ParseFloatSignal(decoded, "UpVel"),
-		Roll:      utils.ParseFloatSignal(decoded, "Roll"),
-		Pitch:     utils.ParseFloatSignal(decoded, "Pitch"),
-		Azimuth:   utils.ParseFloatSignal(decoded, "Azimuth"),
-		Status:    utils.ParseIntSignal(decoded, "Status"),
+		NorthVel:  sig.float("NorthVel", "north_vel"),
+		EastVel:   sig.float("EastVel", "east_vel"),
+		UpVel:     sig.float("UpVel", "up_vel"),
+		Roll:      sig.float("Roll", "roll"),
+		Pitch:     sig.float("Pitch", "pitch"),
+		Azimuth:   sig.float("Azimuth", "azimuth"),
+		Status:    sig.int("Status", "status"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddINSIMUToBatch(d)
+	if !d.IsMissing("north_vel") && !d.IsMissing("east_vel") {
+		vehiclestate.ObserveVelocity(d.NorthVel, d.EastVel, t)
+	}
 
-	payload := buildPayload("ins_imu", t, map[string]interface{}{
+	payload := buildRecordPayload("ins_imu", t, d.RecordMeta, map[string]interface{}{
 		"north_vel": d.NorthVel,
 		"east_vel":  d.EastVel,
 		"up_vel":    d.UpVel,
@@ -1582,18 +1801,20 @@ func processINS_IMUData(decoded map[string]string) {
 // processFrontFrequencyData handles frame ID 101 using the FrontFrequency_Data type.
 func processFrontFrequencyData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.FrontFrequency_Data{
 		Timestamp:  t,
-		RearRight:  utils.ParseFloatSignal(decoded, "RearRight"),
-		FrontRight: utils.ParseFloatSignal(decoded, "FrontRight"),
-		RearLeft:   utils.ParseFloatSignal(decoded, "RearLeft"),
-		FrontLeft:  utils.ParseFloatSignal(decoded, "FrontLeft"),
+		RearRight:  sig.float("RearRight", "rear_right"),
+		FrontRight: sig.float("FrontRight", "front_right"),
+		RearLeft:   sig.float("RearLeft", "rear_left"),
+		FrontLeft:  sig.float("FrontLeft", "front_left"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddFrontFrequencyToBatch(d)
 
-	payload := buildPayload("front_frequency", t, map[string]interface{}{
+	payload := buildRecordPayload("front_frequency", t, d.RecordMeta, map[string]interface{}{
 		"rear_right":  d.RearRight,
 		"front_right": d.FrontRight,
 		"rear_left":   d.RearLeft,
@@ -1605,18 +1826,20 @@ func processFrontFrequencyData(decoded map[string]string) {
 // processRearFrequencyData handles frame ID 102 using the RearFrequency_Data type.
 func processRearFrequencyData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.RearFrequency_Data{
 		Timestamp: t,
-		Freq1:     utils.ParseFloatSignal(decoded, "Freq1"),
-		Freq2:     utils.ParseFloatSignal(decoded, "Freq2"),
-		Freq3:     utils.ParseFloatSignal(decoded, "Freq3"),
-		Freq4:     utils.ParseFloatSignal(decoded, "Freq4"),
+		Freq1:     sig.float("Freq1", "freq1"),
+		Freq2:     sig.float("Freq2", "freq2"),
+		Freq3:     sig.float("Freq3", "freq3"),
+		Freq4:     sig.float("Freq4", "freq4"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddRearFrequencyToBatch(d)
 
-	payload := buildPayload("rear_frequency", t, map[string]interface{}{
+	payload := buildRecordPayload("rear_frequency", t, d.RecordMeta, map[string]interface{}{
 		"freq1": d.Freq1,
 		"freq2": d.Freq2,
 		"freq3": d.Freq3,
@@ -1628,21 +1851,23 @@ func processRearFrequencyData(decoded map[string]string) {
 // processPDM1Data handles frame ID 1280 using the PDM1_Data type.
 func processPDM1Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.PDM1_Data{
 		Timestamp:           t,
-		CompoundID:          utils.ParseIntSignal(decoded, "CompoundID"),
-		PDMIntTemperature:   utils.ParseIntSignal(decoded, "PDMIntTemperature"),
-		PDMBattVoltage:      utils.ParseFloatSignal(decoded, "PDMBattVoltage"),
-		GlobalErrorFlag:     utils.ParseIntSignal(decoded, "GlobalErrorFlag"),
-		TotalCurrent:        utils.ParseIntSignal(decoded, "TotalCurrent"),
-		InternalRailVoltage: utils.ParseFloatSignal(decoded, "InternalRailVoltage"),
-		ResetSource:         utils.ParseIntSignal(decoded, "ResetSource"),
+		CompoundID:          sig.int("CompoundID", "compound_id"),
+		PDMIntTemperature:   sig.int("PDMIntTemperature", "pdm_int_temperature"),
+		PDMBattVoltage:      sig.float("PDMBattVoltage", "pdm_batt_voltage"),
+		GlobalErrorFlag:     sig.int("GlobalErrorFlag", "global_error_flag"),
+		TotalCurrent:        sig.int("TotalCurrent", "total_current"),
+		InternalRailVoltage: sig.float("InternalRailVoltage", "internal_rail_voltage"),
+		ResetSource:         sig.int("ResetSource", "reset_source"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddPDM1ToBatch(d)
 
-	payload := buildPayload("pdm1", t, map[string]interface{}{
+	payload := buildRecordPayload("pdm1", t, d.RecordMeta, map[string]interface{}{
 		"compound_id":           d.CompoundID,
 		"pdm_int_temperature":   d.PDMIntTemperature,
 		"pdm_batt_voltage":      d.PDMBattVoltage,
@@ -1657,20 +1882,22 @@ func processPDM1Data(decoded map[string]string) {
 // processFrontAeroData handles frame ID 1536 using the FrontAero_Data type.
 func processFrontAeroData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.FrontAero_Data{
 		Timestamp:    t,
-		Pressure1:    utils.ParseIntSignal(decoded, "Pressure1"),
-		Pressure2:    utils.ParseIntSignal(decoded, "Pressure2"),
-		Pressure3:    utils.ParseIntSignal(decoded, "Pressure3"),
-		Temperature1: utils.ParseIntSignal(decoded, "Temperature1"),
-		Temperature2: utils.ParseIntSignal(decoded, "Temperature2"),
-		Temperature3: utils.ParseIntSignal(decoded, "Temperature3"),
+		Pressure1:    sig.int("Pressure1", "pressure1"),
+		Pressure2:    sig.int("Pressure2", "pressure2"),
+		Pressure3:    sig.int("Pressure3", "pressure3"),
+		Temperature1: sig.int("Temperature1", "temperature1"),
+		Temperature2: sig.int("Temperature2", "temperature2"),
+		Temperature3: sig.int("Temperature3", "temperature3"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddFrontAeroToBatch(d)
 
-	payload := buildPayload("front_aero", t, map[string]interface{}{
+	payload := buildRecordPayload("front_aero", t, d.RecordMeta, map[string]interface{}{
 		"pressure1":    d.Pressure1,
 		"pressure2":    d.Pressure2,
 		"pressure3":    d.Pressure3,
@@ -1684,20 +1911,22 @@ func processFrontAeroData(decoded map[string]string) {
 // processRearAeroData handles frame ID 1537 using the RearAero_Data type.
 func processRearAeroData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.RearAero_Data{
 		Timestamp:    t,
-		Pressure1:    utils.ParseIntSignal(decoded, "Pressure1"),
-		Pressure2:    utils.ParseIntSignal(decoded, "Pressure2"),
-		Pressure3:    utils.ParseIntSignal(decoded, "Pressure3"),
-		Temperature1: utils.ParseIntSignal(decoded, "Temperature1"),
-		Temperature2: utils.ParseIntSignal(decoded, "Temperature2"),
-		Temperature3: utils.ParseIntSignal(decoded, "Temperature3"),
+		Pressure1:    sig.int("Pressure1", "pressure1"),
+		Pressure2:    sig.int("Pressure2", "pressure2"),
+		Pressure3:    sig.int("Pressure3", "pressure3"),
+		Temperature1: sig.int("Temperature1", "temperature1"),
+		Temperature2: sig.int("Temperature2", "temperature2"),
+		Temperature3: sig.int("Temperature3", "temperature3"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddRearAeroToBatch(d)
 
-	payload := buildPayload("rear_aero", t, map[string]interface{}{
+	payload := buildRecordPayload("rear_aero", t, d.RecordMeta, map[string]interface{}{
 		"pressure1":    d.Pressure1,
 		"pressure2":    d.Pressure2,
 		"pressure3":    d.Pressure3,
@@ -1711,18 +1940,20 @@ func processRearAeroData(decoded map[string]string) {
 // processEncoderData handles frame ID 200 using the Encoder_Data type.
 func processEncoderData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.Encoder_Data{
 		Timestamp: t,
-		Encoder1:  utils.ParseIntSignal(decoded, "Encoder1"),
-		Encoder2:  utils.ParseIntSignal(decoded, "Encoder2"),
-		Encoder3:  utils.ParseIntSignal(decoded, "Encoder3"),
-		Encoder4:  utils.ParseIntSignal(decoded, "Encoder4"),
+		Encoder1:  sig.int("Encoder1", "encoder1"),
+		Encoder2:  sig.int("Encoder2", "encoder2"),
+		Encoder3:  sig.int("Encoder3", "encoder3"),
+		Encoder4:  sig.int("Encoder4", "encoder4"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddEncoderToBatch(d)
 
-	payload := buildPayload("encoder", t, map[string]interface{}{
+	payload := buildRecordPayload("encoder", t, d.RecordMeta, map[string]interface{}{
 		"encoder1": d.Encoder1,
 		"encoder2": d.Encoder2,
 		"encoder3": d.Encoder3,
@@ -1734,22 +1965,24 @@ func processEncoderData(decoded map[string]string) {
 // processRearAnalogData handles frame ID 258 using the RearAnalog_Data type.
 func processRearAnalogData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.RearAnalog_Data{
 		Timestamp: t,
-		Analog1:   utils.ParseIntSignal(decoded, "Analog1"),
-		Analog2:   utils.ParseIntSignal(decoded, "Analog2"),
-		Analog3:   utils.ParseIntSignal(decoded, "Analog3"),
-		Analog4:   utils.ParseIntSignal(decoded, "Analog4"),
-		Analog5:   utils.ParseIntSignal(decoded, "Analog5"),
-		Analog6:   utils.ParseIntSignal(decoded, "Analog6"),
-		Analog7:   utils.ParseIntSignal(decoded, "Analog7"),
-		Analog8:   utils.ParseIntSignal(decoded, "Analog8"),
+		Analog1:   sig.int("Analog1", "analog1"),
+		Analog2:   sig.int("Analog2", "analog2"),
+		Analog3:   sig.int("Analog3", "analog3"),
+		Analog4:   sig.int("Analog4", "analog4"),
+		Analog5:   sig.int("Analog5", "analog5"),
+		Analog6:   sig.int("Analog6", "analog6"),
+		Analog7:   sig.int("Analog7", "analog7"),
+		Analog8:   sig.int("Analog8", "analog8"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddRearAnalogToBatch(d)
 
-	payload := buildPayload("rear_analog", t, map[string]interface{}{
+	payload := buildRecordPayload("rear_analog", t, d.RecordMeta, map[string]interface{}{
 		"analog1": d.Analog1,
 		"analog2": d.Analog2,
 		"analog3": d.Analog3,
@@ -1765,16 +1998,18 @@ func processRearAnalogData(decoded map[string]string) {
 // processBamocarTxData handles frame ID 385 using the BamocarTxData_Data type.
 func processBamocarTxData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.BamocarTxData_Data{
 		Timestamp: t,
-		REGID:     utils.ParseIntSignal(decoded, "REGID"),
-		Data:      utils.ParseIntSignal(decoded, "Data"),
+		REGID:     sig.int("REGID", "regid"),
+		Data:      sig.int("Data", "data"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddBamocarTxToBatch(d)
 
-	payload := buildPayload("bamocar_tx_data", t, map[string]interface{}{
+	payload := buildRecordPayload("bamocar_tx_data", t, d.RecordMeta, map[string]interface{}{
 		"regid": d.REGID,
 		"data":  d.Data,
 	})
@@ -1784,16 +2019,18 @@ func processBamocarTxData(decoded map[string]string) {
 // processBamoCarReTransmitData handles frame ID 600 using the BamoCarReTransmit_Data type.
 func processBamoCarReTransmitData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.BamoCarReTransmit_Data{
 		Timestamp:      t,
-		MotorTemp:      utils.ParseIntSignal(decoded, "MotorTemp"),
-		ControllerTemp: utils.ParseIntSignal(decoded, "ControllerTemp"),
+		MotorTemp:      sig.int("MotorTemp", "motor_temp"),
+		ControllerTemp: sig.int("ControllerTemp", "controller_temp"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddBamoCarReTransmitToBatch(d)
 
-	payload := buildPayload("bamo_car_re_transmit", t, map[string]interface{}{
+	payload := buildRecordPayload("bamo_car_re_transmit", t, d.RecordMeta, map[string]interface{}{
 		"motor_temp":      d.MotorTemp,
 		"controller_temp": d.ControllerTemp,
 	})
@@ -1803,22 +2040,27 @@ func processBamoCarReTransmitData(decoded map[string]string) {
 // processPDMCurrentData handles frame ID 1312 using the PDMCurrent_Data type.
 func processPDMCurrentData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.PDMCurrent_Data{
 		Timestamp:            t,
-		AccumulatorCurrent:   utils.ParseIntSignal(decoded, "AccumulatorCurrent"),
-		TCUCurrent:           utils.ParseIntSignal(decoded, "TCUCurrent"),
-		BamocarCurrent:       utils.ParseIntSignal(decoded, "BamocarCurrent"),
-		PumpsCurrent:         utils.ParseIntSignal(decoded, "PumpsCurrent"),
-		TSALCurrent:          utils.ParseIntSignal(decoded, "TSALCurrent"),
-		DAQCurrent:           utils.ParseIntSignal(decoded, "DAQCurrent"),
-		DisplayKvaserCurrent: utils.ParseIntSignal(decoded, "DisplayKvaserCurrent"),
-		ShutdownResetCurrent: utils.ParseIntSignal(decoded, "ShutdownResetCurrent"),
+		AccumulatorCurrent:   sig.int("AccumulatorCurrent", "accumulator_current"),
+		TCUCurrent:           sig.int("TCUCurrent", "tcu_current"),
+		BamocarCurrent:       sig.int("BamocarCurrent", "bamocar_current"),
+		PumpsCurrent:         sig.int("PumpsCurrent", "pumps_current"),
+		TSALCurrent:          sig.int("TSALCurrent", "tsal_current"),
+		DAQCurrent:           sig.int("DAQCurrent", "daq_current"),
+		DisplayKvaserCurrent: sig.int("DisplayKvaserCurrent", "display_kvaser_current"),
+		ShutdownResetCurrent: sig.int("ShutdownResetCurrent", "shutdown_reset_current"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddPDMCurrentToBatch(d)
+	if !d.IsMissing("shutdown_reset_current") && !d.IsMissing("tsal_current") {
+		shutdown.ObservePDM(float64(d.ShutdownResetCurrent), float64(d.TSALCurrent), t)
+	}
 
-	payload := buildPayload("pdm_current", t, map[string]interface{}{
+	payload := buildRecordPayload("pdm_current", t, d.RecordMeta, map[string]interface{}{
 		"accumulator_current":    d.AccumulatorCurrent,
 		"tcu_current":            d.TCUCurrent,
 		"bamocar_current":        d.BamocarCurrent,
@@ -1834,20 +2076,22 @@ func processPDMCurrentData(decoded map[string]string) {
 // processFrontStrainGauges1Data handles frame ID 1552 using the FrontStrainGauges1_Data type.
 func processFrontStrainGauges1Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.FrontStrainGauges1_Data{
 		Timestamp: t,
-		Gauge1:    utils.ParseIntSignal(decoded, "Gauge1"),
-		Gauge2:    utils.ParseIntSignal(decoded, "Gauge2"),
-		Gauge3:    utils.ParseIntSignal(decoded, "Gauge3"),
-		Gauge4:    utils.ParseIntSignal(decoded, "Gauge4"),
-		Gauge5:    utils.ParseIntSignal(decoded, "Gauge5"),
-		Gauge6:    utils.ParseIntSignal(decoded, "Gauge6"),
+		Gauge1:    sig.int("Gauge1", "gauge1"),
+		Gauge2:    sig.int("Gauge2", "gauge2"),
+		Gauge3:    sig.int("Gauge3", "gauge3"),
+		Gauge4:    sig.int("Gauge4", "gauge4"),
+		Gauge5:    sig.int("Gauge5", "gauge5"),
+		Gauge6:    sig.int("Gauge6", "gauge6"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddFrontStrainGauges1ToBatch(d)
 
-	payload := buildPayload("front_strain_gauges_1", t, map[string]interface{}{
+	payload := buildRecordPayload("front_strain_gauges_1", t, d.RecordMeta, map[string]interface{}{
 		"gauge1": d.Gauge1,
 		"gauge2": d.Gauge2,
 		"gauge3": d.Gauge3,
@@ -1861,20 +2105,22 @@ func processFrontStrainGauges1Data(decoded map[string]string) {
 // processFrontStrainGauges2Data handles frame ID 1553 using the FrontStrainGauges2_Data type.
 func processFrontStrainGauges2Data(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.FrontStrainGauges2_Data{
 		Timestamp: t,
-		Gauge1:    utils.ParseIntSignal(decoded, "Gauge1"),
-		Gauge2:    utils.ParseIntSignal(decoded, "Gauge2"),
-		Gauge3:    utils.ParseIntSignal(decoded, "Gauge3"),
-		Gauge4:    utils.ParseIntSignal(decoded, "Gauge4"),
-		Gauge5:    utils.ParseIntSignal(decoded, "Gauge5"),
-		Gauge6:    utils.ParseIntSignal(decoded, "Gauge6"),
+		Gauge1:    sig.int("Gauge1", "gauge1"),
+		Gauge2:    sig.int("Gauge2", "gauge2"),
+		Gauge3:    sig.int("Gauge3", "gauge3"),
+		Gauge4:    sig.int("Gauge4", "gauge4"),
+		Gauge5:    sig.int("Gauge5", "gauge5"),
+		Gauge6:    sig.int("Gauge6", "gauge6"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddFrontStrainGauges2ToBatch(d)
 
-	payload := buildPayload("front_strain_gauges_2", t, map[string]interface{}{
+	payload := buildRecordPayload("front_strain_gauges_2", t, d.RecordMeta, map[string]interface{}{
 		"gauge1": d.Gauge1,
 		"gauge2": d.Gauge2,
 		"gauge3": d.Gauge3,
@@ -1888,20 +2134,22 @@ func processFrontStrainGauges2Data(decoded map[string]string) {
 // processPDMReTransmitData handles frame ID 1680 using the PDMReTransmit_Data type.
 func processPDMReTransmitData(decoded map[string]string) {
 	t := time.Now()
+	sig := readSignals(decoded)
 	d := types.PDMReTransmit_Data{
 		Timestamp:           t,
-		PDMIntTemperature:   utils.ParseIntSignal(decoded, "PDMIntTemperature"),
-		PDMBattVoltage:      utils.ParseFloatSignal(decoded, "PDMBattVoltage"),
-		GlobalErrorFlag:     utils.ParseIntSignal(decoded, "GlobalErrorFlag"),
-		TotalCurrent:        utils.ParseIntSignal(decoded, "TotalCurrent"),
-		InternalRailVoltage: utils.ParseFloatSignal(decoded, "InternalRailVoltage"),
-		ResetSource:         utils.ParseIntSignal(decoded, "ResetSource"),
+		PDMIntTemperature:   sig.int("PDMIntTemperature", "pdm_int_temperature"),
+		PDMBattVoltage:      sig.float("PDMBattVoltage", "pdm_batt_voltage"),
+		GlobalErrorFlag:     sig.int("GlobalErrorFlag", "global_error_flag"),
+		TotalCurrent:        sig.int("TotalCurrent", "total_current"),
+		InternalRailVoltage: sig.float("InternalRailVoltage", "internal_rail_voltage"),
+		ResetSource:         sig.int("ResetSource", "reset_source"),
 	}
+	d.RecordMeta = sig.meta()
 
 	// Add to batch processor
 	AddPDMReTransmitToBatch(d)
 
-	payload := buildPayload("pdm_re_transmit", t, map[string]interface{}{
+	payload := buildRecordPayload("pdm_re_transmit", t, d.RecordMeta, map[string]interface{}{
 		"pdm_int_temperature":   d.PDMIntTemperature,
 		"pdm_batt_voltage":      d.PDMBattVoltage,
 		"global_error_flag":     d.GlobalErrorFlag,
@@ -0,0 +1,89 @@
+// resistance.go
+//
+// Storage and live forwarding of accumulator internal resistance estimates. Every
+// estimate is stored in resistance_estimates and broadcast to dashboard clients
+// as an "internal_resistance" message; the final estimate of a session is checked
+// against the previous sessions for a rising trend.
+package processdata
+
+import (
+	"context"
+	"log"
+	"telem-system/pkg/db"
+	"telem-system/pkg/resistance"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Size of the queue between resistance estimates and the writer
+const resistanceQueueSize = 16
+
+// InitResistanceLog stores and forwards resistance estimates and closes the
+// estimate of a session when it ends. Writes happen on a single goroutine that
+// stops when ctx is cancelled.
+func InitResistanceLog(ctx context.Context) {
+	queue := make(chan types.ResistanceEstimate, resistanceQueueSize)
+	resistance.Subscribe(func(e types.ResistanceEstimate) {
+		select {
+		case queue <- e:
+		default:
+			log.Printf("Resistance queue full, dropping estimate of session %d", e.SessionID)
+		}
+	})
+	sessions.Subscribe(func(s types.Session) {
+		if s.EndedAt != nil {
+			resistance.EndSession(s.ID, *s.EndedAt)
+		}
+	})
+
+	go func() {
+		for {
+			select {
+			case e := <-queue:
+				storeResistanceEstimate(e)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// storeResistanceEstimate writes an estimate, broadcasts it with its ID and, for
+// the final estimate of a session, checks the trend.
+func storeResistanceEstimate(e types.ResistanceEstimate) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Read the baseline before storing, so it only covers previous sessions
+	var previous []float64
+	if e.Final {
+		var err error
+		if previous, err = db.FetchPreviousResistance(ctx, e.SessionID, resistance.BaselineSessions()); err != nil {
+			log.Printf("Error reading previous resistance estimates: %v", err)
+		}
+	}
+	id, err := db.InsertResistanceEstimate(ctx, e)
+	if err != nil {
+		log.Printf("Error storing resistance estimate: %v", err)
+	}
+	e.ID = id
+
+	data := map[string]interface{}{
+		"id":         float64(e.ID),
+		"session_id": float64(e.SessionID),
+		"pack_ohm":   e.PackOhm,
+		"cell_ohm":   nil,
+		"steps":      float64(e.Steps),
+		"final":      e.Final,
+	}
+	if e.CellOhm != nil {
+		data["cell_ohm"] = *e.CellOhm
+	}
+	broadcastTelemetry(buildPayload("internal_resistance", e.EstimatedAt, data))
+
+	if e.Final {
+		log.Printf("Session %d internal resistance %.1f mOhm (%d steps)", e.SessionID, e.PackOhm*1000, e.Steps)
+		resistance.CheckTrend(e, previous)
+	}
+}
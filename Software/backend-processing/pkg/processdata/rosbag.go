@@ -0,0 +1,181 @@
+// rosbag.go
+//
+// Live rosbag2 recording for the driverless team. Selected fields of the live
+// messages are written to rolling rosbag2 MCAP files as std_msgs/msg/Float64
+// topics named after the message type and field, e.g. "ins_imu.north_vel" is
+// recorded on /fsae/ins_imu/north_vel. Selectors are "type.field" or "type.*" for
+// every numeric field of a message type. A new file is started every rotation
+// interval so an unexpected stop loses at most the file in progress.
+package processdata
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"telem-system/pkg/rosbag"
+	"time"
+)
+
+const (
+	// Default interval between rosbag files
+	defaultRosbagRotate = 10 * time.Minute
+
+	// Size of the queue between the broadcast path and the file writer
+	rosbagQueueSize = 4096
+)
+
+// rosbagSample is a single recorded value.
+type rosbagSample struct {
+	channel string
+	t       time.Time
+	value   float64
+}
+
+// rosbagRecorder filters live messages and queues the selected values.
+type rosbagRecorder struct {
+	fields  map[string]map[string]bool // Message type -> selected fields; nil selects all
+	queue   chan rosbagSample
+	dropped atomic.Uint64
+}
+
+// Live recorder, nil when recording is disabled. Set once at startup.
+var liveRosbag *rosbagRecorder
+
+// InitRosbagRecorder starts recording the selected live fields into dir. Nothing
+// is recorded when dir or selectors is empty. A non-positive rotate selects the
+// default. The file in progress is finished when ctx is cancelled.
+func InitRosbagRecorder(ctx context.Context, dir string, selectors []string, rotate time.Duration) error {
+	if dir == "" || len(selectors) == 0 {
+		return nil
+	}
+	if rotate <= 0 {
+		rotate = defaultRosbagRotate
+	}
+	rec := &rosbagRecorder{fields: make(map[string]map[string]bool), queue: make(chan rosbagSample, rosbagQueueSize)}
+	for _, sel := range selectors {
+		typ, field, ok := strings.Cut(strings.TrimSpace(sel), ".")
+		if !ok || typ == "" || field == "" {
+			return fmt.Errorf("invalid rosbag selector %q: expected type.field or type.*", sel)
+		}
+		if field == "*" {
+			rec.fields[typ] = nil
+			continue
+		}
+		if fields, seen := rec.fields[typ]; seen && fields == nil {
+			continue // Already selecting every field
+		}
+		if rec.fields[typ] == nil {
+			rec.fields[typ] = make(map[string]bool)
+		}
+		rec.fields[typ][field] = true
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating rosbag directory: %w", err)
+	}
+
+	liveRosbag = rec
+	go rec.run(ctx, dir, rotate)
+	log.Printf("Recording %d live selectors to rosbag files in %s", len(selectors), dir)
+	return nil
+}
+
+// observe queues the selected numeric fields of a live payload built by
+// buildPayload. It never blocks; samples are dropped when the writer falls
+// behind.
+func (rec *rosbagRecorder) observe(payloadMap map[string]interface{}) {
+	typ, _ := payloadMap["type"].(string)
+	fields, selected := rec.fields[typ]
+	if !selected {
+		return
+	}
+	payload, ok := payloadMap["payload"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	t := time.Now()
+	if s, ok := payloadMap["time"].(string); ok {
+		if parsed, err := time.ParseInLocation("2006-01-02 15:04:05.000", s, time.Local); err == nil {
+			t = parsed
+		}
+	}
+
+	for name, raw := range payload {
+		if name == "timestamp" || (fields != nil && !fields[name]) {
+			continue
+		}
+		var v float64
+		switch x := raw.(type) {
+		case float64:
+			v = x
+		case int:
+			v = float64(x)
+		case int64:
+			v = float64(x)
+		default:
+			continue
+		}
+		select {
+		case rec.queue <- rosbagSample{channel: typ + "." + name, t: t, value: v}:
+		default:
+			rec.dropped.Add(1)
+		}
+	}
+}
+
+// run writes queued samples, starting a new file every rotate interval.
+func (rec *rosbagRecorder) run(ctx context.Context, dir string, rotate time.Duration) {
+	var file *os.File
+	var buf *bufio.Writer
+	var bag *rosbag.Writer
+	finish := func() {
+		if bag == nil {
+			return
+		}
+		err := bag.Close()
+		if err == nil {
+			err = buf.Flush()
+		}
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Printf("Error finishing rosbag %s: %v", file.Name(), err)
+		}
+		if n := rec.dropped.Swap(0); n > 0 {
+			log.Printf("Rosbag recorder dropped %d samples while writing %s", n, file.Name())
+		}
+		bag = nil
+	}
+	defer finish()
+
+	ticker := time.NewTicker(rotate)
+	defer ticker.Stop()
+	for {
+		select {
+		case s := <-rec.queue:
+			if bag == nil {
+				name := filepath.Join(dir, "fsae_"+time.Now().UTC().Format("20060102T150405Z")+rosbag.FileExtension)
+				f, err := os.Create(name)
+				if err != nil {
+					log.Printf("Error creating rosbag: %v", err)
+					continue
+				}
+				file, buf = f, bufio.NewWriter(f)
+				bag = rosbag.NewWriter(buf)
+			}
+			if err := bag.WriteFloat64(s.channel, s.t, s.value); err != nil {
+				log.Printf("Error writing rosbag %s: %v", file.Name(), err)
+				finish()
+			}
+		case <-ticker.C:
+			finish() // The next sample opens a new file
+		case <-ctx.Done():
+			return
+		}
+	}
+}
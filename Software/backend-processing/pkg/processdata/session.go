@@ -0,0 +1,34 @@
+// session.go
+//
+// Live forwarding of session changes. Every session start and end is broadcast to
+// dashboard clients as a "session" message.
+package processdata
+
+import (
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+)
+
+// InitSessionBroadcast forwards session starts and ends to live clients.
+func InitSessionBroadcast() {
+	sessions.Subscribe(broadcastSession)
+}
+
+// broadcastSession sends a session state change to live clients.
+func broadcastSession(s types.Session) {
+	t := s.StartedAt
+	data := map[string]interface{}{
+		"id":           float64(s.ID),
+		"name":         s.Name,
+		"started_at":   s.StartedAt.UnixMilli(),
+		"start_reason": s.StartReason,
+		"auto":         s.Auto,
+		"open":         s.EndedAt == nil,
+	}
+	if s.EndedAt != nil {
+		t = *s.EndedAt
+		data["ended_at"] = s.EndedAt.UnixMilli()
+		data["end_reason"] = s.EndReason
+	}
+	broadcastTelemetry(buildPayload("session", t, data))
+}
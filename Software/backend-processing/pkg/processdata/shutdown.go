@@ -0,0 +1,63 @@
+// shutdown.go
+//
+// Storage and live forwarding of the reconstructed shutdown circuit timeline.
+// Every element state change is stored in shutdown_events and broadcast to
+// dashboard clients as a "shutdown_event" message.
+package processdata
+
+import (
+	"context"
+	"log"
+	"telem-system/pkg/db"
+	"telem-system/pkg/shutdown"
+	"telem-system/pkg/types"
+	"time"
+)
+
+var shutdownProcessor *BatchProcessor
+
+// initShutdownProcessor creates the batch processor for shutdown events.
+func initShutdownProcessor(ctx context.Context, batchSize int, maxWait time.Duration) {
+	shutdownProcessor = &BatchProcessor{
+		data:      make([]interface{}, 0, batchSize),
+		batchSize: batchSize,
+		maxWait:   maxWait,
+		lastFlush: time.Now(),
+		processorFunc: func(batch []interface{}) {
+			items := make([]types.ShutdownEvent, 0, len(batch))
+			for _, item := range batch {
+				if data, ok := item.(types.ShutdownEvent); ok {
+					items = append(items, data)
+				}
+			}
+			if err := db.InsertShutdownEventsBatch(context.Background(), items); err != nil {
+				log.Printf("Error inserting shutdown events: %v", err)
+			}
+		},
+	}
+	startBatchFlusher(ctx, "shutdown_events", shutdownProcessor)
+}
+
+// InitShutdownTimeline stores and forwards shutdown circuit state changes. It must
+// be called after InitBatchProcessors.
+func InitShutdownTimeline() {
+	shutdown.Subscribe(recordShutdownEvent)
+}
+
+// recordShutdownEvent queues a shutdown event for storage and broadcasts it.
+func recordShutdownEvent(e types.ShutdownEvent) {
+	shutdownProcessor.add(e)
+
+	data := map[string]interface{}{
+		"element": e.Element,
+		"closed":  e.Closed,
+		"value":   e.Value,
+	}
+	if e.TripStart != nil {
+		data["trip_start"] = e.TripStart.UnixMilli()
+	}
+	if e.TripOrder != nil {
+		data["trip_order"] = float64(*e.TripOrder)
+	}
+	broadcastTelemetry(buildPayload("shutdown_event", e.Timestamp, data))
+}
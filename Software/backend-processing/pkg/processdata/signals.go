@@ -0,0 +1,59 @@
+// signals.go
+//
+// Reading decoded signals into telemetry records. A signal that is absent from the
+// decoded frame or cannot be parsed leaves its field at zero, so the record lists
+// the column as missing: it is stored as NULL and broadcast as null rather than as
+// a measurement of zero.
+package processdata
+
+import (
+	"telem-system/pkg/types"
+	"telem-system/pkg/utils"
+	"time"
+)
+
+// signalReader reads the signals of one decoded frame and collects the columns
+// that had no value.
+type signalReader struct {
+	decoded map[string]string
+	missing []string
+}
+
+// readSignals returns a reader for a decoded frame.
+func readSignals(decoded map[string]string) *signalReader {
+	return &signalReader{decoded: decoded}
+}
+
+// float returns signal key, recording column as missing if it has no value.
+func (r *signalReader) float(key, column string) float64 {
+	v, ok := utils.LookupFloatSignal(r.decoded, key)
+	if !ok {
+		r.missing = append(r.missing, column)
+	}
+	return v
+}
+
+// int returns signal key, recording column as missing if it has no value.
+func (r *signalReader) int(key, column string) int {
+	v, ok := utils.LookupIntSignal(r.decoded, key)
+	if !ok {
+		r.missing = append(r.missing, column)
+	}
+	return v
+}
+
+// meta returns the record metadata for the signals read so far.
+func (r *signalReader) meta() types.RecordMeta {
+	return types.RecordMeta{Missing: r.missing}
+}
+
+// buildRecordPayload builds a live payload like buildPayload, sending missing
+// columns as null.
+func buildRecordPayload(msgType string, t time.Time, meta types.RecordMeta, data map[string]interface{}) map[string]interface{} {
+	for _, column := range meta.Missing {
+		if _, ok := data[column]; ok {
+			data[column] = nil
+		}
+	}
+	return buildPayload(msgType, t, data)
+}
@@ -0,0 +1,229 @@
+// slo.go
+//
+// Insert latency SLOs of the batch processors. An SLO requires a percentage of a
+// table's batch inserts to complete within a threshold, e.g. 95% of cell_data
+// batches within 500 ms. The duration of every insert is recorded and compliance
+// is evaluated over a sliding window by the persistence monitor; a table below
+// its target raises an alert, so storage regressions show up in practice runs
+// rather than in endurance.
+package processdata
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"telem-system/pkg/alerts"
+	"time"
+)
+
+const (
+	// Default compliance window
+	defaultSLOWindow = 5 * time.Minute
+
+	// Inserts needed in the window before compliance is judged
+	sloMinSamples = 20
+
+	// Inserts kept per table; older ones leave the window early at high rates
+	sloMaxSamples = 4096
+
+	// SLOs key applying to tables without an SLO of their own
+	SLODefault = "default"
+)
+
+// SLO is the insert latency objective of a table.
+type SLO struct {
+	Percentile float64       // Percentage of inserts that must meet the threshold, in (0, 100]
+	Threshold  time.Duration // Maximum insert duration
+}
+
+// SLOStatus reports the compliance of a table with its SLO over the window.
+type SLOStatus struct {
+	Table         string     `json:"table"`
+	Percentile    float64    `json:"percentile"`
+	ThresholdMs   float64    `json:"threshold_ms"`
+	Samples       int        `json:"samples"`        // Inserts in the window
+	Compliance    float64    `json:"compliance"`     // Percentage of them within the threshold; 100 without samples
+	PercentileMs  float64    `json:"percentile_ms"`  // Insert duration at the SLO percentile
+	LastInsertMs  float64    `json:"last_insert_ms"` // Duration of the latest insert
+	Violated      bool       `json:"violated"`       // Below target with enough samples
+	Violations    uint64     `json:"violations"`     // Times the SLO started being violated
+	ViolatedSince *time.Time `json:"violated_since,omitempty"`
+}
+
+// insertSample is a recorded insert.
+type insertSample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// sloTracker holds the recent inserts of a table.
+type sloTracker struct {
+	slo        SLO
+	samples    []insertSample // Ring buffer of at most sloMaxSamples
+	next       int
+	violated   bool
+	since      time.Time
+	violations uint64
+}
+
+var (
+	sloMu       sync.Mutex
+	sloConfig   map[string]SLO
+	sloWindow   = defaultSLOWindow
+	sloTrackers = make(map[string]*sloTracker)
+)
+
+// ConfigureSLOs sets the insert latency SLOs by table name, with SLODefault
+// applying to the other tables, and the compliance window (non-positive selects
+// the default). It must be called after InitBatchProcessors, as table names are
+// checked against the batch processors.
+func ConfigureSLOs(slos map[string]SLO, window time.Duration) error {
+	known := make(map[string]bool)
+	for _, p := range registeredProcessors() {
+		known[p.name] = true
+	}
+	for table, s := range slos {
+		if table != SLODefault && !known[table] {
+			return fmt.Errorf("slo for unknown table %q", table)
+		}
+		if s.Percentile <= 0 || s.Percentile > 100 {
+			return fmt.Errorf("slo of %s: percentile must be in (0, 100]", table)
+		}
+		if s.Threshold <= 0 {
+			return fmt.Errorf("slo of %s: threshold must be positive", table)
+		}
+	}
+	if window <= 0 {
+		window = defaultSLOWindow
+	}
+
+	sloMu.Lock()
+	defer sloMu.Unlock()
+	sloConfig = slos
+	sloWindow = window
+	sloTrackers = make(map[string]*sloTracker)
+	return nil
+}
+
+// sloOf returns the SLO of a table.
+func sloOf(table string) (SLO, bool) {
+	if s, ok := sloConfig[table]; ok {
+		return s, true
+	}
+	s, ok := sloConfig[SLODefault]
+	return s, ok
+}
+
+// recordInsert records the duration of a batch insert into table.
+func recordInsert(table string, at time.Time, d time.Duration) {
+	sloMu.Lock()
+	defer sloMu.Unlock()
+	t, ok := sloTrackers[table]
+	if !ok {
+		s, ok := sloOf(table)
+		if !ok {
+			return
+		}
+		t = &sloTracker{slo: s}
+		sloTrackers[table] = t
+	}
+	sample := insertSample{at: at, duration: d}
+	if len(t.samples) < sloMaxSamples {
+		t.samples = append(t.samples, sample)
+		return
+	}
+	t.samples[t.next] = sample
+	t.next = (t.next + 1) % sloMaxSamples
+}
+
+// status evaluates the tracker at time now. sloMu must be held.
+func (t *sloTracker) status(table string, now time.Time, window time.Duration) SLOStatus {
+	st := SLOStatus{
+		Table:       table,
+		Percentile:  t.slo.Percentile,
+		ThresholdMs: float64(t.slo.Threshold) / float64(time.Millisecond),
+		Compliance:  100,
+		Violations:  t.violations,
+	}
+	var durations []time.Duration
+	var latest insertSample
+	met := 0
+	for _, s := range t.samples {
+		if s.at.After(latest.at) {
+			latest = s
+		}
+		if now.Sub(s.at) > window {
+			continue
+		}
+		durations = append(durations, s.duration)
+		if s.duration <= t.slo.Threshold {
+			met++
+		}
+	}
+	st.LastInsertMs = float64(latest.duration) / float64(time.Millisecond)
+	st.Samples = len(durations)
+	if st.Samples == 0 {
+		return st
+	}
+	st.Compliance = 100 * float64(met) / float64(st.Samples)
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	idx := int(t.slo.Percentile/100*float64(len(durations))+0.5) - 1
+	idx = max(0, min(idx, len(durations)-1))
+	st.PercentileMs = float64(durations[idx]) / float64(time.Millisecond)
+	st.Violated = st.Samples >= sloMinSamples && st.Compliance < t.slo.Percentile
+	return st
+}
+
+// GetSLOStatus returns the compliance of every table with an SLO that has
+// recorded inserts, sorted by table.
+func GetSLOStatus() []SLOStatus {
+	sloMu.Lock()
+	defer sloMu.Unlock()
+	now := time.Now()
+	out := make([]SLOStatus, 0, len(sloTrackers))
+	for table, t := range sloTrackers {
+		st := t.status(table, now, sloWindow)
+		if t.violated {
+			since := t.since
+			st.ViolatedSince = &since
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Table < out[j].Table })
+	return out
+}
+
+// checkSLOs raises or resolves the SLO alert of every tracked table.
+func checkSLOs(now time.Time) {
+	sloMu.Lock()
+	window := sloWindow
+	statuses := make([]SLOStatus, 0, len(sloTrackers))
+	for table, t := range sloTrackers {
+		st := t.status(table, now, window)
+		if st.Violated && !t.violated {
+			t.violations++
+			t.since = now
+		}
+		t.violated = st.Violated
+		statuses = append(statuses, st)
+	}
+	sloMu.Unlock()
+
+	// Alerts are raised outside the lock, as subscribers may read the status
+	for _, st := range statuses {
+		key := "persistence_slo." + st.Table
+		if !st.Violated {
+			alerts.Resolve(key)
+			continue
+		}
+		alerts.Raise(alerts.Alert{
+			Key:      key,
+			Source:   "persistence",
+			Severity: alerts.SeverityWarning,
+			Message: fmt.Sprintf("%s: %.1f%% of inserts within %g ms over the last %s (SLO %g%%)",
+				st.Table, st.Compliance, st.ThresholdMs, window, st.Percentile),
+			Value:     st.Compliance,
+			Threshold: st.Percentile,
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// snapshot.go
+//
+// Last-value cache of the live messages. The latest broadcast message of every
+// type is kept so a client can ask for a full snapshot of a bulky channel (all
+// cells, all thermistors) instead of waiting for its next broadcast. Types sent
+// as several messages, one per board, keep the latest message of each board.
+package processdata
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Payload field telling apart the messages of a type sent once per board
+var snapshotKeyFields = map[string]string{
+	"thermistor": "thermistor_id",
+}
+
+// lastValueCache holds the latest encoded message per type and board.
+type lastValueCache struct {
+	mu     sync.RWMutex
+	values map[string]map[string][]byte // Type -> board key -> message
+}
+
+var lastValues = &lastValueCache{values: make(map[string]map[string][]byte)}
+
+// messageKey returns the type of a payload built by buildPayload and, for types
+// sent once per board, the board.
+func messageKey(payloadMap map[string]interface{}) (typ, key string) {
+	typ, _ = payloadMap["type"].(string)
+	if field, ok := snapshotKeyFields[typ]; ok {
+		if payload, ok := payloadMap["payload"].(map[string]interface{}); ok {
+			key = fmt.Sprint(payload[field])
+		}
+	}
+	return typ, key
+}
+
+// store records msg as the latest message of its type and board.
+func (c *lastValueCache) store(typ, key string, msg []byte) {
+	if typ == "" {
+		return
+	}
+
+	c.mu.Lock()
+	byKey, ok := c.values[typ]
+	if !ok {
+		byKey = make(map[string][]byte)
+		c.values[typ] = byKey
+	}
+	byKey[key] = msg
+	c.mu.Unlock()
+}
+
+// Snapshot returns the latest messages of a channel (message type), one per
+// board, ordered by board. Without cached data it returns a single
+// "snapshot_unavailable" message naming the channel.
+func Snapshot(channel string) [][]byte {
+	lastValues.mu.RLock()
+	byKey := lastValues.values[channel]
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([][]byte, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, byKey[k])
+	}
+	lastValues.mu.RUnlock()
+
+	if len(out) > 0 {
+		return out
+	}
+	msg, err := marshalTelemetry(buildPayload("snapshot_unavailable", time.Now(), map[string]interface{}{
+		"channel": channel,
+	}))
+	if err != nil {
+		return nil
+	}
+	return [][]byte{msg}
+}
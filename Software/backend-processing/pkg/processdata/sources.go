@@ -0,0 +1,49 @@
+// sources.go
+//
+// Ingest source switches. Frames from a disabled source are read and discarded,
+// so a configured source (e.g. the bench serial adapter) can be silenced without
+// restarting the server.
+package processdata
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Ingest sources
+const (
+	SourceWebSocket = "websocket" // Transmitter on /telemetry
+	SourceSerial    = "serial"    // SLCAN adapter
+)
+
+// Sources lists the ingest sources.
+var Sources = []string{SourceWebSocket, SourceSerial}
+
+// Disabled flags, indexed like Sources; all sources start enabled
+var sourceDisabled [2]atomic.Bool
+
+// sourceIndex returns the index of a source in Sources.
+func sourceIndex(name string) (int, error) {
+	for i, s := range Sources {
+		if s == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown source %q", name)
+}
+
+// SetSourceEnabled enables or disables ingest from a source.
+func SetSourceEnabled(name string, enabled bool) error {
+	i, err := sourceIndex(name)
+	if err != nil {
+		return err
+	}
+	sourceDisabled[i].Store(!enabled)
+	return nil
+}
+
+// SourceEnabled reports whether frames from a source are ingested.
+func SourceEnabled(name string) bool {
+	i, err := sourceIndex(name)
+	return err == nil && !sourceDisabled[i].Load()
+}
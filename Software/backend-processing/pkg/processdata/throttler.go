@@ -9,7 +9,6 @@ package processdata
 import (
 	"sync/atomic"
 	"telem-system/internal/wsserver"
-	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -18,21 +17,12 @@ import (
 const (
 	// Maximum message size to broadcast
 	maxBroadcastMessageSize = 8192 // 8KB
-
-	// Circuit breaker settings
-	circuitBreakerThreshold = 100             // Number of consecutive drops before entering half-open state
-	circuitBreakerResetTime = 5 * time.Second // Time to stay in half-open state
 )
 
 // Monitoring counters
 var (
 	messagesSent    uint64
 	messagesDropped uint64
-
-	// Circuit breaker state (0=closed/normal, 1=open/blocking, 2=half-open/testing)
-	circuitState      int32
-	consecutiveDrops  int32
-	lastCircuitChange time.Time
 )
 
 // limiterHolder will atomically hold a pointer to a rate.Limiter.
@@ -55,9 +45,7 @@ func InitThrottler(intervalMs int, burst int) {
 	limiterHolder.Store(l)
 
 	// Initialize circuit breaker state
-	atomic.StoreInt32(&circuitState, 0)
-	atomic.StoreInt32(&consecutiveDrops, 0)
-	lastCircuitChange = time.Now()
+	broadcastCircuit.reset()
 }
 
 // UpdateThrottler dynamically updates the global rate limiter with a new interval and burst capacity.
@@ -70,21 +58,14 @@ func UpdateThrottler(intervalMs int, burst int) {
 func GetThrottlerStats() (sent uint64, dropped uint64, state int32) {
 	return atomic.LoadUint64(&messagesSent),
 		atomic.LoadUint64(&messagesDropped),
-		atomic.LoadInt32(&circuitState)
-}
-
-// ResetCircuitBreaker forces the circuit breaker back to normal state
-func ResetCircuitBreaker() {
-	atomic.StoreInt32(&circuitState, 0)
-	atomic.StoreInt32(&consecutiveDrops, 0)
-	lastCircuitChange = time.Now()
-	// log.Println("Throttler circuit breaker manually reset")
+		broadcastCircuit.state.Load()
 }
 
 // ThrottledBroadcast sends the given message to the WebSocket hub while enforcing
 // the configured rate limit. If throttling is disabled, the message is sent immediately.
+// channel and key identify the message type and board for client subscriptions.
 // Implements circuit breaker pattern to prevent resource exhaustion.
-func ThrottledBroadcast(msg []byte) {
+func ThrottledBroadcast(channel, key string, msg []byte) {
 	// Check message size limit
 	if len(msg) > maxBroadcastMessageSize {
 		// log.Printf("Message exceeds maximum broadcast size (%d > %d), dropping",
@@ -93,18 +74,10 @@ func ThrottledBroadcast(msg []byte) {
 		return
 	}
 
-	// Check circuit breaker state
-	state := atomic.LoadInt32(&circuitState)
-	if state == 1 {
-		// Circuit is open (blocking), check if we should try half-open
-		if time.Since(lastCircuitChange) > circuitBreakerResetTime {
-			atomic.StoreInt32(&circuitState, 2) // Set to half-open
-			lastCircuitChange = time.Now()
-		} else {
-			// Still in blocking state, drop message
-			atomic.AddUint64(&messagesDropped, 1)
-			return
-		}
+	// Check circuit breaker state; an open circuit drops the message
+	if !broadcastCircuit.allow() {
+		atomic.AddUint64(&messagesDropped, 1)
+		return
 	}
 
 	// Rate limiting check
@@ -120,31 +93,13 @@ func ThrottledBroadcast(msg []byte) {
 	}
 
 	// Try non-blocking send to prevent resource exhaustion
-	select {
-	case wsserver.WsHub.Broadcast <- msg:
+	if wsserver.WsHub.TrySend(channel, key, msg) {
 		// Message sent successfully
 		atomic.AddUint64(&messagesSent, 1)
-		if state == 2 {
-			// In half-open state and successful, reset circuit
-			atomic.StoreInt32(&circuitState, 0)
-			atomic.StoreInt32(&consecutiveDrops, 0)
-			// log.Println("Circuit breaker reset to normal operation")
-		}
-	default:
-		// Channel is full, increment drop counter
-		drops := atomic.AddInt32(&consecutiveDrops, 1)
+		broadcastCircuit.success()
+	} else {
+		// Channel is full; enough consecutive drops open the circuit
 		atomic.AddUint64(&messagesDropped, 1)
-
-		// Only log occasionally to prevent log spam
-		// if drops%10 == 0 {
-		// 	log.Printf("Warning: broadcast channel full, dropping messages (consecutive drops: %d)", drops)
-		// }
-
-		// Check if we need to open the circuit breaker
-		if drops >= circuitBreakerThreshold && state != 1 {
-			// log.Printf("Circuit breaker triggered after %d consecutive message drops", drops)
-			// atomic.StoreInt32(&circuitState, 1)
-			lastCircuitChange = time.Now()
-		}
+		broadcastCircuit.drop()
 	}
 }
@@ -0,0 +1,131 @@
+// trackposition.go
+//
+// Adaptive downsampling of GPS fixes for the live track map. The "track_position"
+// channel carries only the fixes needed to redraw the driven line within a
+// tolerance: fixes are held back while the car moves in a straight line and a
+// vertex is emitted as soon as one of the held fixes would be further than the
+// tolerance from the straight segment (an opening-window line simplification).
+// Corners therefore get many points and straights few. A fix is also emitted when
+// none has been sent for the maximum interval, so the car marker never lags by
+// more than that.
+package processdata
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// Defaults, overridable through ConfigureTrackPosition
+	defaultTrackTolerance   = 0.5 // metres
+	defaultTrackMaxInterval = time.Second
+
+	// Upper bound on held fixes; a vertex is forced when reached
+	maxTrackPending = 256
+
+	// Mean earth radius for the local projection
+	earthRadius = 6371000.0
+)
+
+// trackPoint is a GPS fix.
+type trackPoint struct {
+	lat, lon float64
+	t        time.Time
+}
+
+// trackDownsampler holds the simplification state.
+type trackDownsampler struct {
+	mu          sync.Mutex
+	tolerance   float64
+	maxInterval time.Duration
+	anchor      *trackPoint  // Last emitted fix
+	pending     []trackPoint // Fixes received since the anchor
+}
+
+var trackPos = &trackDownsampler{tolerance: defaultTrackTolerance, maxInterval: defaultTrackMaxInterval}
+
+// ConfigureTrackPosition sets the shape tolerance in metres and the maximum
+// interval between emitted fixes in milliseconds. Non-positive values select the
+// defaults.
+func ConfigureTrackPosition(toleranceM float64, maxIntervalMs int) {
+	trackPos.mu.Lock()
+	defer trackPos.mu.Unlock()
+	trackPos.tolerance = defaultTrackTolerance
+	if toleranceM > 0 {
+		trackPos.tolerance = toleranceM
+	}
+	trackPos.maxInterval = defaultTrackMaxInterval
+	if maxIntervalMs > 0 {
+		trackPos.maxInterval = time.Duration(maxIntervalMs) * time.Millisecond
+	}
+	trackPos.anchor, trackPos.pending = nil, nil
+}
+
+// noteTrackPosition feeds a GPS fix and broadcasts the fixes selected for the
+// track map. Fixes at 0,0 (no position solution) are ignored.
+func noteTrackPosition(lat, lon float64, t time.Time) {
+	if lat == 0 && lon == 0 {
+		return
+	}
+	trackPos.mu.Lock()
+	out, skipped := trackPos.add(trackPoint{lat: lat, lon: lon, t: t})
+	trackPos.mu.Unlock()
+
+	for i, p := range out {
+		broadcastTelemetry(buildPayload("track_position", p.t, map[string]interface{}{
+			"latitude":  p.lat,
+			"longitude": p.lon,
+			"skipped":   float64(skipped[i]), // Fixes dropped since the previous point
+		}))
+	}
+}
+
+// add processes a fix and returns the fixes to emit, in order, with the number of
+// fixes dropped before each. The caller holds d.mu.
+func (d *trackDownsampler) add(p trackPoint) (out []trackPoint, skipped []int) {
+	if d.anchor == nil {
+		d.anchor = &p
+		return []trackPoint{p}, []int{0}
+	}
+
+	// Emit the last held fix if the segment to p no longer covers the held fixes
+	if n := len(d.pending); n > 0 && (n >= maxTrackPending || d.deviates(p)) {
+		vertex := d.pending[n-1]
+		out, skipped = append(out, vertex), append(skipped, n-1)
+		d.anchor, d.pending = &vertex, d.pending[:0]
+	}
+
+	if p.t.Sub(d.anchor.t) >= d.maxInterval {
+		out, skipped = append(out, p), append(skipped, len(d.pending))
+		d.anchor, d.pending = &p, d.pending[:0]
+		return out, skipped
+	}
+	d.pending = append(d.pending, p)
+	return out, skipped
+}
+
+// deviates reports whether any held fix is further than the tolerance from the
+// segment between the anchor and p.
+func (d *trackDownsampler) deviates(p trackPoint) bool {
+	cosLat := math.Cos(d.anchor.lat * math.Pi / 180)
+	project := func(q trackPoint) (x, y float64) {
+		x = (q.lon - d.anchor.lon) * math.Pi / 180 * earthRadius * cosLat
+		y = (q.lat - d.anchor.lat) * math.Pi / 180 * earthRadius
+		return x, y
+	}
+	ex, ey := project(p)
+	length2 := ex*ex + ey*ey
+	for _, q := range d.pending {
+		qx, qy := project(q)
+		// Distance to the closest point of the segment
+		u := 0.0
+		if length2 > 0 {
+			u = math.Max(0, math.Min(1, (qx*ex+qy*ey)/length2))
+		}
+		if math.Hypot(qx-u*ex, qy-u*ey) > d.tolerance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// vehiclestate.go
+//
+// Storage and live forwarding of the consolidated vehicle state. Every transition
+// is stored in vehicle_state_events and the state is broadcast to dashboard
+// clients as a "vehicle_state" message, on every transition and periodically so
+// newly connected clients pick it up.
+package processdata
+
+import (
+	"context"
+	"log"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"telem-system/pkg/vehiclestate"
+	"time"
+)
+
+const (
+	// Size of the queue between state transitions and the writer
+	vehicleStateQueueSize = 32
+
+	// Interval of the OFF timeout check and the repeated broadcast
+	vehicleStateInterval = time.Second
+)
+
+// InitVehicleState stores and forwards vehicle state transitions and evaluates
+// the state periodically. Writes happen on a single goroutine that stops when ctx
+// is cancelled.
+func InitVehicleState(ctx context.Context) {
+	queue := make(chan types.VehicleStateChange, vehicleStateQueueSize)
+	vehiclestate.Subscribe(func(c types.VehicleStateChange) {
+		broadcastVehicleState(c.State, c.Previous, c.Timestamp)
+		select {
+		case queue <- c:
+		default:
+			log.Printf("Vehicle state queue full, dropping transition to %s", c.State)
+		}
+	})
+
+	go func() {
+		ticker := time.NewTicker(vehicleStateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case c := <-queue:
+				storeVehicleStateChange(c)
+			case now := <-ticker.C:
+				vehiclestate.Check(now)
+				state, since := vehiclestate.Current()
+				broadcastVehicleState(state, "", since)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// observeVehicleStateAMS feeds the AMS frame to the vehicle state, leaving out
+// missing signals.
+func observeVehicleStateAMS(d types.ACULV_FD_1_Data, t time.Time) {
+	var status *int
+	var accumulatorV, tractiveV *float64
+	if !d.IsMissing("ams_status") {
+		status = &d.AMSStatus
+	}
+	if !d.IsMissing("accumulator_voltage") {
+		accumulatorV = &d.AccumulatorVoltage
+	}
+	if !d.IsMissing("tractive_voltage") {
+		tractiveV = &d.TractiveVoltage
+	}
+	vehiclestate.ObserveAMS(status, accumulatorV, tractiveV, t)
+}
+
+// broadcastVehicleState sends the state entered at since; previous is empty for
+// the periodic repeat.
+func broadcastVehicleState(state, previous string, since time.Time) {
+	data := map[string]interface{}{
+		"state":    state,
+		"previous": previous,
+		"since":    since.UnixMilli(),
+	}
+	broadcastTelemetry(buildPayload("vehicle_state", time.Now(), data))
+}
+
+// storeVehicleStateChange writes a transition.
+func storeVehicleStateChange(c types.VehicleStateChange) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.InsertVehicleStateChange(ctx, c); err != nil {
+		log.Printf("Error storing vehicle state transition: %v", err)
+	}
+	log.Printf("Vehicle state %s -> %s", c.Previous, c.State)
+}
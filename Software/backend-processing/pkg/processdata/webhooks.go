@@ -0,0 +1,30 @@
+// webhooks.go
+//
+// Forwarding of session and alert changes to the outbound webhooks.
+package processdata
+
+import (
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+	"telem-system/pkg/webhooks"
+)
+
+// InitWebhookEvents fires webhooks on session starts and ends and on alerts being
+// raised (or escalated) and resolved.
+func InitWebhookEvents() {
+	sessions.Subscribe(func(s types.Session) {
+		event := webhooks.EventSessionStarted
+		if s.EndedAt != nil {
+			event = webhooks.EventSessionEnded
+		}
+		webhooks.Fire(event, s)
+	})
+	alerts.Subscribe(func(a alerts.Alert) {
+		event := webhooks.EventAlertRaised
+		if !a.Active {
+			event = webhooks.EventAlertResolved
+		}
+		webhooks.Fire(event, a)
+	})
+}
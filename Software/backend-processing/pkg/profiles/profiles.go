@@ -0,0 +1,184 @@
+// profiles.go
+//
+// Package profiles switches between named configuration profiles (e.g. track,
+// garage, dyno). A profile overrides part of the base configuration: the live
+// throttle interval, the cell storage mode, the muted alerts, the active alert
+// rule sets and which ingest sources are enabled. Settings a profile leaves unset keep their base value.
+// A profile is selected at startup and can be switched at runtime, so changing
+// context does not mean editing YAML under time pressure.
+package profiles
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/processdata"
+)
+
+// Settings are the profile-controlled settings in effect.
+type Settings struct {
+	ThrottlerInterval int             `json:"throttler_interval_ms"` // 0 disables throttling
+	CellStorageMode   string          `json:"cell_storage_mode"`
+	KeyframeInterval  int             `json:"keyframe_interval"`
+	Deadband          float64         `json:"deadband"`
+	MutedAlerts       []string        `json:"muted_alerts"`    // Alert keys or sources
+	AlertRuleSets     []string        `json:"alert_rule_sets"` // Active threshold rule sets
+	Sources           map[string]bool `json:"sources"`
+}
+
+// Profile overrides base settings. Nil fields and sources not listed inherit the
+// base value.
+type Profile struct {
+	ThrottlerInterval *int
+	CellStorageMode   *string
+	KeyframeInterval  *int
+	Deadband          *float64
+	MutedAlerts       []string
+	AlertRuleSets     []string
+	Sources           map[string]bool
+}
+
+// state holds the configured profiles.
+type state struct {
+	mu       sync.Mutex
+	base     Settings
+	profiles map[string]Profile
+	active   string
+	current  Settings
+}
+
+var st = &state{profiles: make(map[string]Profile)}
+
+// Configure installs the base settings and the named profiles. Profile names are
+// case-insensitive. Nothing is applied until Activate is called. Alert rule sets
+// must be configured first (see alerts.ConfigureRuleSets).
+func Configure(base Settings, profiles map[string]Profile) error {
+	if err := alerts.CheckRuleSets(base.AlertRuleSets); err != nil {
+		return err
+	}
+	if base.Sources == nil {
+		base.Sources = make(map[string]bool)
+	}
+	for _, src := range processdata.Sources {
+		if _, ok := base.Sources[src]; !ok {
+			base.Sources[src] = true
+		}
+	}
+
+	named := make(map[string]Profile, len(profiles))
+	for name, p := range profiles {
+		if p.CellStorageMode != nil && *p.CellStorageMode != processdata.CellStorageFull && *p.CellStorageMode != processdata.CellStorageDelta {
+			return fmt.Errorf("profile %s: unknown cell storage mode %q", name, *p.CellStorageMode)
+		}
+		for src := range p.Sources {
+			if !slices.Contains(processdata.Sources, src) {
+				return fmt.Errorf("profile %s: unknown source %q", name, src)
+			}
+		}
+		if p.AlertRuleSets != nil {
+			if err := alerts.CheckRuleSets(p.AlertRuleSets); err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+		}
+		named[strings.ToLower(name)] = p
+	}
+
+	st.mu.Lock()
+	st.base, st.profiles = base, named
+	st.mu.Unlock()
+	return nil
+}
+
+// Activate applies the named profile on top of the base settings. An empty name
+// applies the base settings alone.
+func Activate(name string) (Settings, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	s := st.base
+	if name != "" {
+		p, ok := st.profiles[name]
+		if !ok {
+			return Settings{}, fmt.Errorf("unknown profile %q", name)
+		}
+		s = p.apply(st.base)
+	}
+	apply(s)
+	st.active, st.current = name, s
+
+	if name == "" {
+		name = "base"
+	}
+	log.Printf("Configuration profile %s active: throttle %d ms, cell storage %s, %d muted alerts, alert rule sets %v, sources %v",
+		name, s.ThrottlerInterval, s.CellStorageMode, len(s.MutedAlerts), s.AlertRuleSets, s.Sources)
+	return s, nil
+}
+
+// Active returns the active profile name (empty for the base settings) and the
+// settings in effect.
+func Active() (string, Settings) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.active, st.current
+}
+
+// Names returns the configured profile names, sorted.
+func Names() []string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	names := make([]string, 0, len(st.profiles))
+	for name := range st.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apply returns base with the profile overrides.
+func (p Profile) apply(base Settings) Settings {
+	s := base
+	if p.ThrottlerInterval != nil {
+		s.ThrottlerInterval = *p.ThrottlerInterval
+	}
+	if p.CellStorageMode != nil {
+		s.CellStorageMode = *p.CellStorageMode
+	}
+	if p.KeyframeInterval != nil {
+		s.KeyframeInterval = *p.KeyframeInterval
+	}
+	if p.Deadband != nil {
+		s.Deadband = *p.Deadband
+	}
+	if p.MutedAlerts != nil {
+		s.MutedAlerts = p.MutedAlerts
+	}
+	if p.AlertRuleSets != nil {
+		s.AlertRuleSets = p.AlertRuleSets
+	}
+	s.Sources = make(map[string]bool, len(base.Sources))
+	for src, on := range base.Sources {
+		s.Sources[src] = on
+	}
+	for src, on := range p.Sources {
+		s.Sources[src] = on
+	}
+	return s
+}
+
+// apply puts settings into effect.
+func apply(s Settings) {
+	processdata.InitThrottler(s.ThrottlerInterval, 0)
+	processdata.SetCellStorageMode(s.CellStorageMode, s.KeyframeInterval, s.Deadband)
+	alerts.SetMuted(s.MutedAlerts)
+	if err := alerts.ActivateRuleSets(s.AlertRuleSets); err != nil {
+		log.Printf("Failed to activate alert rule sets: %v", err)
+	}
+	for src, on := range s.Sources {
+		processdata.SetSourceEnabled(src, on)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+// disk_other.go
+//
+// Free disk space lookup stub for platforms without statfs.
+package readiness
+
+import "errors"
+
+// freeBytes is not supported on this platform.
+func freeBytes(path string) (uint64, error) {
+	return 0, errors.New("free space lookup not supported on this platform")
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+// disk_unix.go
+//
+// Free disk space lookup for Unix systems.
+package readiness
+
+import "golang.org/x/sys/unix"
+
+// freeBytes returns the space available to unprivileged users on the filesystem
+// holding path.
+func freeBytes(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
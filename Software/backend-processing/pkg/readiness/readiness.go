@@ -0,0 +1,319 @@
+// readiness.go
+//
+// Package readiness aggregates the pre-run checks (node liveness, database health,
+// alert state, calibration freshness and storage headroom) into a single go/no-go
+// verdict with a composite health score, so the pit crew can see at a glance
+// whether the system is ready before the car goes out.
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/db"
+	"telem-system/pkg/processdata"
+	"time"
+)
+
+// Check statuses
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Overall verdicts
+const (
+	VerdictGo   = "go"
+	VerdictNoGo = "no_go"
+)
+
+const (
+	// Default time after which a node without frames counts as offline
+	defaultNodeTimeout = 5 * time.Second
+
+	// Default maximum age of a required calibration
+	defaultCalibrationMaxAge = 30 * 24 * time.Hour
+
+	// Default database round-trip time above which the database check warns
+	defaultDBMaxLatency = 200 * time.Millisecond
+
+	// Default free space below which the storage check fails
+	defaultMinFreeBytes = 1 << 30
+
+	// The disk check warns below this multiple of the minimum free space
+	diskWarnFactor = 2
+
+	// Fraction of the database quota above which the size check warns
+	quotaWarnFraction = 0.9
+)
+
+// Node is a vehicle node considered alive while any of its frames is received.
+type Node struct {
+	Name     string
+	FrameIDs []uint32 // As seen on the bus (before remapping)
+}
+
+// Config selects what the pre-run checks require. Zero values select the defaults.
+type Config struct {
+	Nodes             []Node
+	NodeTimeout       time.Duration
+	Calibrations      []string // Calibrations that must be present and fresh
+	CalibrationMaxAge time.Duration
+	DBMaxLatency      time.Duration
+	StoragePath       string // Filesystem holding the database; empty skips the disk check
+	MinFreeBytes      uint64
+	DBQuotaBytes      int64 // Maximum database size; 0 disables the quota check
+}
+
+// Check is the result of a single pre-run check.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// Report is the aggregated pre-run verdict. Score is 0-100, with warnings counting
+// half; the verdict is no-go as soon as any check fails.
+type Report struct {
+	Status    string    `json:"status"`
+	Score     float64   `json:"score"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Check   `json:"checks"`
+}
+
+var (
+	cfgMu sync.RWMutex
+	cfg   = Config{}
+)
+
+// Configure installs the readiness requirements.
+func Configure(c Config) {
+	if c.NodeTimeout <= 0 {
+		c.NodeTimeout = defaultNodeTimeout
+	}
+	if c.CalibrationMaxAge <= 0 {
+		c.CalibrationMaxAge = defaultCalibrationMaxAge
+	}
+	if c.DBMaxLatency <= 0 {
+		c.DBMaxLatency = defaultDBMaxLatency
+	}
+	if c.MinFreeBytes == 0 {
+		c.MinFreeBytes = defaultMinFreeBytes
+	}
+	cfgMu.Lock()
+	cfg = c
+	cfgMu.Unlock()
+}
+
+func init() {
+	Configure(Config{})
+}
+
+// Evaluate runs every check and aggregates the results.
+func Evaluate(ctx context.Context, queries *db.Queries) Report {
+	cfgMu.RLock()
+	c := cfg
+	cfgMu.RUnlock()
+
+	now := time.Now()
+	var checks []Check
+	checks = append(checks, checkNodes(c, now)...)
+	checks = append(checks, checkDatabase(ctx, c, queries))
+	checks = append(checks, checkAlerts())
+	checks = append(checks, checkCalibrations(ctx, c, queries, now))
+	checks = append(checks, checkStorage(ctx, c, queries)...)
+
+	report := Report{Status: VerdictGo, CheckedAt: now, Checks: checks}
+	var points float64
+	for _, ch := range checks {
+		switch ch.Status {
+		case StatusPass:
+			points++
+		case StatusWarn:
+			points += 0.5
+		case StatusFail:
+			report.Status = VerdictNoGo
+		}
+	}
+	if len(checks) > 0 {
+		report.Score = 100 * points / float64(len(checks))
+	}
+	return report
+}
+
+// checkNodes reports one check per configured node, or a single bus activity check
+// when no nodes are configured.
+func checkNodes(c Config, now time.Time) []Check {
+	if len(c.Nodes) == 0 {
+		last := processdata.GetBusLoadStats()
+		if last.FramesPerSec > 0 {
+			return []Check{{Name: "bus_activity", Status: StatusPass, Reason: fmt.Sprintf("%.0f frames/s on the bus", last.FramesPerSec)}}
+		}
+		return []Check{{Name: "bus_activity", Status: StatusFail, Reason: "no frames received in the last sampling window"}}
+	}
+
+	checks := make([]Check, 0, len(c.Nodes))
+	for _, n := range c.Nodes {
+		var latest time.Time
+		for _, id := range n.FrameIDs {
+			if t := processdata.FrameLastSeen(id); t.After(latest) {
+				latest = t
+			}
+		}
+		ch := Check{Name: "node." + n.Name}
+		switch {
+		case latest.IsZero():
+			ch.Status, ch.Reason = StatusFail, "no frames received since server start"
+		case now.Sub(latest) > c.NodeTimeout:
+			ch.Status, ch.Reason = StatusFail, fmt.Sprintf("last frame %s ago", now.Sub(latest).Round(time.Second))
+		default:
+			ch.Status, ch.Reason = StatusPass, fmt.Sprintf("last frame %dms ago", now.Sub(latest).Milliseconds())
+		}
+		checks = append(checks, ch)
+	}
+	return checks
+}
+
+// checkDatabase pings the database and checks the round-trip time.
+func checkDatabase(ctx context.Context, c Config, queries *db.Queries) Check {
+	ch := Check{Name: "database"}
+	start := time.Now()
+	if err := queries.Ping(ctx); err != nil {
+		ch.Status, ch.Reason = StatusFail, "unreachable: "+err.Error()
+		return ch
+	}
+	latency := time.Since(start)
+	if latency > c.DBMaxLatency {
+		ch.Status, ch.Reason = StatusWarn, fmt.Sprintf("slow round trip (%dms)", latency.Milliseconds())
+		return ch
+	}
+	if lag := processdata.MaxPersistenceLag(); lag > 0 {
+		ch.Reason = fmt.Sprintf("reachable (%dms), persistence lag %.0fms", latency.Milliseconds(), lag)
+	} else {
+		ch.Reason = fmt.Sprintf("reachable (%dms)", latency.Milliseconds())
+	}
+	ch.Status = StatusPass
+	return ch
+}
+
+// checkAlerts fails on active critical alerts and warns on active warnings.
+func checkAlerts() Check {
+	ch := Check{Name: "alerts", Status: StatusPass, Reason: "no active alerts"}
+	var critical, warning []string
+	for _, a := range alerts.Active() {
+		switch a.Severity {
+		case alerts.SeverityCritical:
+			critical = append(critical, a.Key)
+		case alerts.SeverityWarning:
+			warning = append(warning, a.Key)
+		}
+	}
+	switch {
+	case len(critical) > 0:
+		ch.Status, ch.Reason = StatusFail, "critical alerts active: "+strings.Join(critical, ", ")
+	case len(warning) > 0:
+		ch.Status, ch.Reason = StatusWarn, "warnings active: "+strings.Join(warning, ", ")
+	}
+	return ch
+}
+
+// checkCalibrations fails when a required calibration is missing or stale.
+func checkCalibrations(ctx context.Context, c Config, queries *db.Queries, now time.Time) Check {
+	ch := Check{Name: "calibrations"}
+	if len(c.Calibrations) == 0 {
+		ch.Status, ch.Reason = StatusPass, "no calibrations required"
+		return ch
+	}
+	latest, err := queries.FetchLatestCalibrations(ctx)
+	if err != nil {
+		ch.Status, ch.Reason = StatusFail, "cannot read calibrations: "+err.Error()
+		return ch
+	}
+	byName := make(map[string]time.Time, len(latest))
+	for _, cal := range latest {
+		byName[cal.Name] = cal.CalibratedAt
+	}
+
+	var missing, stale []string
+	for _, name := range c.Calibrations {
+		at, ok := byName[name]
+		switch {
+		case !ok:
+			missing = append(missing, name)
+		case now.Sub(at) > c.CalibrationMaxAge:
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing: "+strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		problems = append(problems, fmt.Sprintf("older than %s: %s", c.CalibrationMaxAge, strings.Join(stale, ", ")))
+	}
+	if len(problems) > 0 {
+		ch.Status, ch.Reason = StatusFail, strings.Join(problems, "; ")
+		return ch
+	}
+	ch.Status, ch.Reason = StatusPass, fmt.Sprintf("%d calibration(s) current", len(c.Calibrations))
+	return ch
+}
+
+// checkStorage checks free disk space and the database size quota.
+func checkStorage(ctx context.Context, c Config, queries *db.Queries) []Check {
+	var checks []Check
+
+	if c.StoragePath != "" {
+		ch := Check{Name: "disk_space"}
+		free, err := freeBytes(c.StoragePath)
+		switch {
+		case err != nil:
+			ch.Status, ch.Reason = StatusWarn, "cannot read free space: "+err.Error()
+		case free < c.MinFreeBytes:
+			ch.Status, ch.Reason = StatusFail, fmt.Sprintf("%s free, need %s", formatBytes(free), formatBytes(c.MinFreeBytes))
+		case free < diskWarnFactor*c.MinFreeBytes:
+			ch.Status, ch.Reason = StatusWarn, fmt.Sprintf("%s free", formatBytes(free))
+		default:
+			ch.Status, ch.Reason = StatusPass, fmt.Sprintf("%s free", formatBytes(free))
+		}
+		checks = append(checks, ch)
+	}
+
+	if c.DBQuotaBytes > 0 {
+		ch := Check{Name: "database_size"}
+		size, err := queries.DatabaseSize(ctx)
+		switch {
+		case err != nil:
+			ch.Status, ch.Reason = StatusWarn, "cannot read database size: "+err.Error()
+		case size >= c.DBQuotaBytes:
+			ch.Status, ch.Reason = StatusFail, fmt.Sprintf("%s used of %s quota", formatBytes(uint64(size)), formatBytes(uint64(c.DBQuotaBytes)))
+		case float64(size) >= quotaWarnFraction*float64(c.DBQuotaBytes):
+			ch.Status, ch.Reason = StatusWarn, fmt.Sprintf("%s used of %s quota", formatBytes(uint64(size)), formatBytes(uint64(c.DBQuotaBytes)))
+		default:
+			ch.Status, ch.Reason = StatusPass, fmt.Sprintf("%s used of %s quota", formatBytes(uint64(size)), formatBytes(uint64(c.DBQuotaBytes)))
+		}
+		checks = append(checks, ch)
+	}
+	return checks
+}
+
+// formatBytes renders a byte count with a binary unit.
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,269 @@
+// resistance.go
+//
+// Package resistance estimates the accumulator internal resistance while driving.
+// Whenever the pack current changes by at least the minimum step between two
+// consecutive AMS samples, the accompanying voltage change gives one resistance
+// reading (R = -dV/dI, discharge current positive). The rolling estimate is the
+// median of the latest readings of the session, which rejects steps distorted by
+// relaxation or sensor noise. Estimates are reported periodically and once more
+// when the session ends; a final estimate well above those of the previous
+// sessions raises an alert, since rising resistance is an early sign of cell
+// degradation.
+package resistance
+
+import (
+	"fmt"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+	"telem-system/pkg/utils"
+	"time"
+)
+
+const (
+	// Defaults, overridable through Config
+	defaultMinStep          = 20.0 // A
+	defaultMaxStepInterval  = 250 * time.Millisecond
+	defaultMaxResistance    = 1.0 // Ohm; larger readings are measurement artefacts
+	defaultWindow           = 25
+	defaultMinSteps         = 10
+	defaultReportInterval   = 30 * time.Second
+	defaultTrendThreshold   = 0.15
+	defaultBaselineSessions = 5
+
+	// Fewer previous sessions than this give no usable baseline
+	minBaselineSessions = 2
+
+	// Alert raised by a rising trend, resolved by the next session within bounds
+	alertKey = "resistance_rising"
+)
+
+// Config holds the step detection and trend settings.
+type Config struct {
+	MinStep          float64       // Smallest current change (A) used as a step
+	MaxStepInterval  time.Duration // Longest time between the two samples of a step
+	MaxResistance    float64       // Readings above this (Ohm) are discarded
+	Window           int           // Readings in the rolling median
+	MinSteps         int           // Readings before a session estimate is reported
+	ReportInterval   time.Duration // Interval between reported estimates of a session
+	SeriesCells      int           // Cells in series, for the per-cell figure (0 omits it)
+	CurrentSign      float64       // Pack current sign convention, see utils.CurrentSign
+	TrendThreshold   float64       // Fractional rise over the baseline that raises the alert
+	BaselineSessions int           // Previous sessions forming the baseline
+}
+
+// sample is one AMS voltage/current sample.
+type sample struct {
+	voltage, current float64
+	t                time.Time
+}
+
+// estimator holds the state of the current session.
+type estimator struct {
+	mu          sync.Mutex
+	cfg         Config
+	sessionID   int64
+	prev        *sample
+	readings    []float64 // Latest readings, oldest first (at most cfg.Window)
+	steps       int       // Readings in the session
+	lastReport  time.Time
+	subscribers []func(types.ResistanceEstimate)
+}
+
+var est = &estimator{cfg: withDefaults(Config{})}
+
+// withDefaults fills non-positive values with the defaults.
+func withDefaults(cfg Config) Config {
+	if cfg.MinStep <= 0 {
+		cfg.MinStep = defaultMinStep
+	}
+	if cfg.MaxStepInterval <= 0 {
+		cfg.MaxStepInterval = defaultMaxStepInterval
+	}
+	if cfg.MaxResistance <= 0 {
+		cfg.MaxResistance = defaultMaxResistance
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MinSteps <= 0 {
+		cfg.MinSteps = defaultMinSteps
+	}
+	if cfg.ReportInterval <= 0 {
+		cfg.ReportInterval = defaultReportInterval
+	}
+	if cfg.SeriesCells < 0 {
+		cfg.SeriesCells = 0
+	}
+	cfg.CurrentSign = utils.CurrentSign(cfg.CurrentSign)
+	if cfg.TrendThreshold <= 0 {
+		cfg.TrendThreshold = defaultTrendThreshold
+	}
+	if cfg.BaselineSessions <= 0 {
+		cfg.BaselineSessions = defaultBaselineSessions
+	}
+	return cfg
+}
+
+// Configure installs the settings. Non-positive values select the defaults.
+func Configure(cfg Config) {
+	est.mu.Lock()
+	est.cfg = withDefaults(cfg)
+	est.mu.Unlock()
+}
+
+// BaselineSessions returns the number of previous sessions to pass to CheckTrend.
+func BaselineSessions() int {
+	est.mu.Lock()
+	defer est.mu.Unlock()
+	return est.cfg.BaselineSessions
+}
+
+// Subscribe registers fn to be called with every reported estimate. fn is called
+// synchronously and must not block.
+func Subscribe(fn func(types.ResistanceEstimate)) {
+	est.mu.Lock()
+	est.subscribers = append(est.subscribers, fn)
+	est.mu.Unlock()
+}
+
+// Observe feeds a sample of the accumulator voltage and pack current, taken at
+// the same instant. Readings are only collected within sessions.
+func Observe(voltage, current float64, t time.Time) {
+	var sessionID int64
+	if s, ok := sessions.Current(); ok {
+		sessionID = s.ID
+	}
+
+	est.mu.Lock()
+	var out []types.ResistanceEstimate
+	if sessionID != est.sessionID {
+		if f, ok := est.finish(t); ok {
+			out = append(out, f)
+		}
+		est.sessionID = sessionID
+	}
+	if sessionID != 0 {
+		est.step(sample{voltage: voltage, current: current, t: t})
+		if est.steps >= est.cfg.MinSteps && t.Sub(est.lastReport) >= est.cfg.ReportInterval {
+			out = append(out, est.estimate(t, false))
+			est.lastReport = t
+		}
+	}
+	subs := est.subscribers
+	est.mu.Unlock()
+
+	for _, e := range out {
+		for _, fn := range subs {
+			fn(e)
+		}
+	}
+}
+
+// EndSession reports the final estimate of a session that has ended.
+func EndSession(sessionID int64, t time.Time) {
+	est.mu.Lock()
+	if sessionID != est.sessionID {
+		est.mu.Unlock()
+		return
+	}
+	f, ok := est.finish(t)
+	est.sessionID = 0
+	subs := est.subscribers
+	est.mu.Unlock()
+
+	if ok {
+		for _, fn := range subs {
+			fn(f)
+		}
+	}
+}
+
+// step adds the reading of a current step ending at s, if any. The caller holds
+// est.mu.
+func (e *estimator) step(s sample) {
+	prev := e.prev
+	e.prev = &s
+	if prev == nil {
+		return
+	}
+	dt := s.t.Sub(prev.t)
+	if dt <= 0 || dt > e.cfg.MaxStepInterval {
+		return
+	}
+	dI := (s.current - prev.current) * e.cfg.CurrentSign
+	if dI > -e.cfg.MinStep && dI < e.cfg.MinStep {
+		return
+	}
+	r := -(s.voltage - prev.voltage) / dI
+	if r <= 0 || r > e.cfg.MaxResistance {
+		return
+	}
+	e.readings = append(e.readings, r)
+	if len(e.readings) > e.cfg.Window {
+		e.readings = e.readings[len(e.readings)-e.cfg.Window:]
+	}
+	e.steps++
+}
+
+// estimate builds the current estimate. The caller holds est.mu.
+func (e *estimator) estimate(t time.Time, final bool) types.ResistanceEstimate {
+	r := types.ResistanceEstimate{
+		SessionID:   e.sessionID,
+		EstimatedAt: t,
+		PackOhm:     utils.Median(e.readings),
+		Steps:       e.steps,
+		Final:       final,
+	}
+	if e.cfg.SeriesCells > 0 {
+		cell := r.PackOhm / float64(e.cfg.SeriesCells)
+		r.CellOhm = &cell
+	}
+	return r
+}
+
+// finish returns the final estimate of the session and resets the state. It
+// reports false when the session had too few readings. The caller holds est.mu.
+func (e *estimator) finish(t time.Time) (types.ResistanceEstimate, bool) {
+	var f types.ResistanceEstimate
+	ok := e.sessionID != 0 && e.steps >= e.cfg.MinSteps
+	if ok {
+		f = e.estimate(t, true)
+	}
+	e.prev, e.readings, e.steps, e.lastReport = nil, nil, 0, time.Time{}
+	return f, ok
+}
+
+// CheckTrend compares the final estimate of a session with the final estimates
+// of the previous sessions, newest first, and raises or resolves the alert.
+func CheckTrend(final types.ResistanceEstimate, previous []float64) {
+	est.mu.Lock()
+	cfg := est.cfg
+	est.mu.Unlock()
+
+	if len(previous) > cfg.BaselineSessions {
+		previous = previous[:cfg.BaselineSessions]
+	}
+	if len(previous) < minBaselineSessions {
+		return
+	}
+	baseline := utils.Median(previous)
+	if baseline <= 0 {
+		return
+	}
+	ratio := final.PackOhm / baseline
+	if ratio <= 1+cfg.TrendThreshold {
+		alerts.Resolve(alertKey)
+		return
+	}
+	alerts.Raise(alerts.Alert{
+		Key:      alertKey,
+		Source:   "accumulator",
+		Severity: alerts.SeverityWarning,
+		Message: fmt.Sprintf("Pack internal resistance %.1f mOhm is %.0f%% above the previous %d sessions (%.1f mOhm)",
+			final.PackOhm*1000, (ratio-1)*100, len(previous), baseline*1000),
+		Value:     ratio,
+		Threshold: 1 + cfg.TrendThreshold,
+	})
+}
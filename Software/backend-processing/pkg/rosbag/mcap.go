@@ -0,0 +1,252 @@
+// mcap.go
+//
+// Minimal MCAP writer. rosbag2 reads and writes MCAP files through its mcap
+// storage plugin, so a file with the "ros2" profile, ros2msg schemas and CDR
+// encoded messages opens with `ros2 bag info/play` and with Foxglove. Only the
+// records needed for that are written: header, schemas, channels, messages and a
+// summary section with the schemas, channels and statistics. Messages are not
+// chunked, so readers scan the data section linearly.
+package rosbag
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+// MCAP magic, written at the start and the end of the file
+var mcapMagic = []byte{0x89, 'M', 'C', 'A', 'P', 0x30, '\r', '\n'}
+
+// MCAP record opcodes
+const (
+	opHeader        = 0x01
+	opFooter        = 0x02
+	opSchema        = 0x03
+	opChannel       = 0x04
+	opMessage       = 0x05
+	opStatistics    = 0x0B
+	opSummaryOffset = 0x0E
+	opDataEnd       = 0x0F
+)
+
+// mcapSchema is a registered schema record.
+type mcapSchema struct {
+	id       uint16
+	name     string
+	encoding string
+	data     []byte
+}
+
+// mcapChannel is a registered channel record.
+type mcapChannel struct {
+	id              uint16
+	schemaID        uint16
+	topic           string
+	messageEncoding string
+	metadata        map[string]string
+}
+
+// mcapWriter writes an MCAP file to an io.Writer. It is not safe for concurrent
+// use.
+type mcapWriter struct {
+	w        io.Writer
+	offset   uint64
+	err      error
+	buf      []byte
+	schemas  []mcapSchema
+	channels []mcapChannel
+	counts   map[uint16]uint64
+	messages uint64
+	start    uint64
+	end      uint64
+}
+
+var errTooManyChannels = errors.New("mcap: too many channels")
+
+// newMCAPWriter writes the magic and the header record.
+func newMCAPWriter(w io.Writer, profile, library string) *mcapWriter {
+	m := &mcapWriter{w: w, counts: make(map[uint16]uint64), start: math.MaxUint64}
+	m.write(mcapMagic)
+	m.record(opHeader, func() {
+		m.putString(profile)
+		m.putString(library)
+	})
+	return m
+}
+
+// addSchema writes a schema record and returns its ID.
+func (m *mcapWriter) addSchema(name, encoding string, data []byte) uint16 {
+	s := mcapSchema{id: uint16(len(m.schemas) + 1), name: name, encoding: encoding, data: data}
+	m.schemas = append(m.schemas, s)
+	m.writeSchema(s)
+	return s.id
+}
+
+// addChannel writes a channel record and returns its ID.
+func (m *mcapWriter) addChannel(schemaID uint16, topic, encoding string, metadata map[string]string) (uint16, error) {
+	if len(m.channels) >= math.MaxUint16 {
+		return 0, errTooManyChannels
+	}
+	c := mcapChannel{id: uint16(len(m.channels)), schemaID: schemaID, topic: topic, messageEncoding: encoding, metadata: metadata}
+	m.channels = append(m.channels, c)
+	m.writeChannel(c)
+	return c.id, m.err
+}
+
+// writeMessage writes a message record. logTime is in nanoseconds since the Unix
+// epoch and is also used as the publish time.
+func (m *mcapWriter) writeMessage(channelID uint16, logTime uint64, data []byte) error {
+	seq := uint32(m.counts[channelID])
+	m.record(opMessage, func() {
+		m.buf = binary.LittleEndian.AppendUint16(m.buf, channelID)
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, seq)
+		m.buf = binary.LittleEndian.AppendUint64(m.buf, logTime)
+		m.buf = binary.LittleEndian.AppendUint64(m.buf, logTime)
+		m.buf = append(m.buf, data...)
+	})
+	m.counts[channelID]++
+	m.messages++
+	m.start = min(m.start, logTime)
+	m.end = max(m.end, logTime)
+	return m.err
+}
+
+// close ends the data section and writes the summary, the footer and the closing
+// magic. The underlying writer is not closed.
+func (m *mcapWriter) close() error {
+	m.record(opDataEnd, func() {
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, 0) // CRC not computed
+	})
+
+	type group struct {
+		opcode        byte
+		start, length uint64
+	}
+	var groups []group
+	summaryStart := m.offset
+
+	begin := m.offset
+	for _, s := range m.schemas {
+		m.writeSchema(s)
+	}
+	if len(m.schemas) > 0 {
+		groups = append(groups, group{opSchema, begin, m.offset - begin})
+	}
+
+	begin = m.offset
+	for _, c := range m.channels {
+		m.writeChannel(c)
+	}
+	if len(m.channels) > 0 {
+		groups = append(groups, group{opChannel, begin, m.offset - begin})
+	}
+
+	begin = m.offset
+	m.writeStatistics()
+	groups = append(groups, group{opStatistics, begin, m.offset - begin})
+
+	summaryOffsetStart := m.offset
+	for _, g := range groups {
+		m.record(opSummaryOffset, func() {
+			m.buf = append(m.buf, g.opcode)
+			m.buf = binary.LittleEndian.AppendUint64(m.buf, g.start)
+			m.buf = binary.LittleEndian.AppendUint64(m.buf, g.length)
+		})
+	}
+
+	m.record(opFooter, func() {
+		m.buf = binary.LittleEndian.AppendUint64(m.buf, summaryStart)
+		m.buf = binary.LittleEndian.AppendUint64(m.buf, summaryOffsetStart)
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, 0) // CRC not computed
+	})
+	m.write(mcapMagic)
+	return m.err
+}
+
+// writeSchema writes a schema record.
+func (m *mcapWriter) writeSchema(s mcapSchema) {
+	m.record(opSchema, func() {
+		m.buf = binary.LittleEndian.AppendUint16(m.buf, s.id)
+		m.putString(s.name)
+		m.putString(s.encoding)
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, uint32(len(s.data)))
+		m.buf = append(m.buf, s.data...)
+	})
+}
+
+// writeChannel writes a channel record.
+func (m *mcapWriter) writeChannel(c mcapChannel) {
+	m.record(opChannel, func() {
+		m.buf = binary.LittleEndian.AppendUint16(m.buf, c.id)
+		m.buf = binary.LittleEndian.AppendUint16(m.buf, c.schemaID)
+		m.putString(c.topic)
+		m.putString(c.messageEncoding)
+
+		keys := make([]string, 0, len(c.metadata))
+		for k := range c.metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var size int
+		for _, k := range keys {
+			size += 8 + len(k) + len(c.metadata[k])
+		}
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, uint32(size))
+		for _, k := range keys {
+			m.putString(k)
+			m.putString(c.metadata[k])
+		}
+	})
+}
+
+// writeStatistics writes the statistics record.
+func (m *mcapWriter) writeStatistics() {
+	start, end := m.start, m.end
+	if m.messages == 0 {
+		start, end = 0, 0
+	}
+	m.record(opStatistics, func() {
+		m.buf = binary.LittleEndian.AppendUint64(m.buf, m.messages)
+		m.buf = binary.LittleEndian.AppendUint16(m.buf, uint16(len(m.schemas)))
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, uint32(len(m.channels)))
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, 0) // Attachments
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, 0) // Metadata
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, 0) // Chunks
+		m.buf = binary.LittleEndian.AppendUint64(m.buf, start)
+		m.buf = binary.LittleEndian.AppendUint64(m.buf, end)
+		m.buf = binary.LittleEndian.AppendUint32(m.buf, uint32(len(m.counts)*10))
+		for _, c := range m.channels {
+			if n, ok := m.counts[c.id]; ok {
+				m.buf = binary.LittleEndian.AppendUint16(m.buf, c.id)
+				m.buf = binary.LittleEndian.AppendUint64(m.buf, n)
+			}
+		}
+	})
+}
+
+// record builds a record body with fill and writes it with its opcode and length.
+func (m *mcapWriter) record(opcode byte, fill func()) {
+	m.buf = append(m.buf[:0], opcode, 0, 0, 0, 0, 0, 0, 0, 0)
+	fill()
+	binary.LittleEndian.PutUint64(m.buf[1:9], uint64(len(m.buf)-9))
+	m.write(m.buf)
+}
+
+// putString appends a length-prefixed string to the record being built.
+func (m *mcapWriter) putString(s string) {
+	m.buf = binary.LittleEndian.AppendUint32(m.buf, uint32(len(s)))
+	m.buf = append(m.buf, s...)
+}
+
+// write writes p and tracks the file offset. The first error is kept and later
+// writes are skipped.
+func (m *mcapWriter) write(p []byte) {
+	if m.err != nil {
+		return
+	}
+	n, err := m.w.Write(p)
+	m.offset += uint64(n)
+	m.err = err
+}
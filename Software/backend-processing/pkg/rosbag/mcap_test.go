@@ -0,0 +1,355 @@
+package rosbag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// mcapRecord is a record read back from a written file.
+type mcapRecord struct {
+	offset uint64
+	opcode byte
+	body   []byte
+}
+
+// readRecords splits b into records, failing if a record overruns b.
+func readRecords(t *testing.T, b []byte, base uint64) []mcapRecord {
+	t.Helper()
+	var out []mcapRecord
+	for offset := uint64(0); offset < uint64(len(b)); {
+		if uint64(len(b))-offset < 9 {
+			t.Fatalf("truncated record header at %d", base+offset)
+		}
+		length := binary.LittleEndian.Uint64(b[offset+1:])
+		if length > uint64(len(b))-offset-9 {
+			t.Fatalf("record 0x%02x at %d: length %d overruns the section", b[offset], base+offset, length)
+		}
+		out = append(out, mcapRecord{offset: base + offset, opcode: b[offset], body: b[offset+9 : offset+9+length]})
+		offset += 9 + length
+	}
+	return out
+}
+
+// recordReader reads the fields of a record body.
+type recordReader struct {
+	t *testing.T
+	b []byte
+}
+
+func (r *recordReader) take(n int) []byte {
+	r.t.Helper()
+	if len(r.b) < n {
+		r.t.Fatalf("record body too short: need %d bytes, have %d", n, len(r.b))
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *recordReader) u8() byte     { return r.take(1)[0] }
+func (r *recordReader) u16() uint16  { return binary.LittleEndian.Uint16(r.take(2)) }
+func (r *recordReader) u32() uint32  { return binary.LittleEndian.Uint32(r.take(4)) }
+func (r *recordReader) u64() uint64  { return binary.LittleEndian.Uint64(r.take(8)) }
+func (r *recordReader) str() string  { return string(r.take(int(r.u32()))) }
+func (r *recordReader) rest() []byte { return r.take(len(r.b)) }
+
+// done fails if fields are left unread.
+func (r *recordReader) done() {
+	r.t.Helper()
+	if len(r.b) != 0 {
+		r.t.Fatalf("%d bytes left in the record body", len(r.b))
+	}
+}
+
+func TestMCAPRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	m := newMCAPWriter(&buf, "ros2", "test-lib")
+	schema := m.addSchema("std_msgs/msg/Float64", "ros2msg", []byte("float64 data\n"))
+	voltage, err := m.addChannel(schema, "/fsae/pack/voltage", "cdr", map[string]string{"offered_qos_profiles": "", "b": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := m.addChannel(schema, "/fsae/pack/current", "cdr", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idle, err := m.addChannel(schema, "/fsae/idle", "cdr", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writes := []struct {
+		channel uint16
+		at      uint64
+		data    string
+	}{
+		{voltage, 2000, "v1"},
+		{current, 1000, "c1"},
+		{voltage, 3000, "v2"},
+		{current, 5000, ""},
+	}
+	for _, w := range writes {
+		if err := m.writeMessage(w.channel, w.at, []byte(w.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.close(); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+
+	if !bytes.HasPrefix(file, mcapMagic) || !bytes.HasSuffix(file, mcapMagic) {
+		t.Fatal("file does not start and end with the MCAP magic")
+	}
+	records := readRecords(t, file[len(mcapMagic):len(file)-len(mcapMagic)], uint64(len(mcapMagic)))
+
+	// Data section: header, schema, channels, messages, data end
+	var ops []byte
+	for _, rec := range records {
+		ops = append(ops, rec.opcode)
+		if rec.opcode == opDataEnd {
+			break
+		}
+	}
+	wantOps := []byte{opHeader, opSchema, opChannel, opChannel, opChannel, opMessage, opMessage, opMessage, opMessage, opDataEnd}
+	if !bytes.Equal(ops, wantOps) {
+		t.Fatalf("data section opcodes = %x, want %x", ops, wantOps)
+	}
+	r := &recordReader{t, records[0].body}
+	if profile, lib := r.str(), r.str(); profile != "ros2" || lib != "test-lib" {
+		t.Errorf("header = %q, %q", profile, lib)
+	}
+	r.done()
+
+	r = &recordReader{t, records[2].body}
+	if id, sid, topic, enc := r.u16(), r.u16(), r.str(), r.str(); id != voltage || sid != schema || topic != "/fsae/pack/voltage" || enc != "cdr" {
+		t.Errorf("channel = %d, %d, %q, %q", id, sid, topic, enc)
+	}
+	meta := &recordReader{t, r.take(int(r.u32()))}
+	if k1, v1, k2, v2 := meta.str(), meta.str(), meta.str(), meta.str(); k1 != "b" || v1 != "x" || k2 != "offered_qos_profiles" || v2 != "" {
+		t.Errorf("channel metadata = %q=%q, %q=%q, want sorted keys", k1, v1, k2, v2)
+	}
+	meta.done()
+	r.done()
+
+	seqs := map[uint16]uint32{}
+	for i, w := range writes {
+		r := &recordReader{t, records[5+i].body}
+		channel, seq, logTime, publishTime, data := r.u16(), r.u32(), r.u64(), r.u64(), r.rest()
+		if channel != w.channel || seq != seqs[w.channel] || logTime != w.at || publishTime != w.at || string(data) != w.data {
+			t.Errorf("message %d = channel %d seq %d at %d/%d %q", i, channel, seq, logTime, publishTime, data)
+		}
+		seqs[w.channel]++
+	}
+
+	// Footer: the last record, pointing at the summary and summary offset sections
+	footer := records[len(records)-1]
+	if footer.opcode != opFooter {
+		t.Fatalf("last record is 0x%02x, want the footer", footer.opcode)
+	}
+	r = &recordReader{t, footer.body}
+	summaryStart, summaryOffsetStart, crc := r.u64(), r.u64(), r.u32()
+	r.done()
+	if crc != 0 {
+		t.Errorf("summary CRC = %d, want 0 (not computed)", crc)
+	}
+	dataEnd := records[len(wantOps)-1]
+	if summaryStart != dataEnd.offset+9+uint64(len(dataEnd.body)) {
+		t.Fatalf("summary starts at %d, want right after the data end record", summaryStart)
+	}
+
+	byOffset := make(map[uint64]mcapRecord, len(records))
+	for _, rec := range records {
+		byOffset[rec.offset] = rec
+	}
+	var summary, offsets []mcapRecord
+	for _, rec := range records[len(wantOps) : len(records)-1] {
+		if rec.offset < summaryOffsetStart {
+			summary = append(summary, rec)
+		} else {
+			offsets = append(offsets, rec)
+		}
+	}
+	if _, ok := byOffset[summaryOffsetStart]; !ok {
+		t.Fatalf("summary offset section at %d is not at a record boundary", summaryOffsetStart)
+	}
+
+	// Summary section: the schema and channel records repeated, then statistics
+	ops = ops[:0]
+	for _, rec := range summary {
+		ops = append(ops, rec.opcode)
+	}
+	wantOps = []byte{opSchema, opChannel, opChannel, opChannel, opStatistics}
+	if !bytes.Equal(ops, wantOps) {
+		t.Fatalf("summary opcodes = %x, want %x", ops, wantOps)
+	}
+	for i, rec := range summary[:4] {
+		if !bytes.Equal(rec.body, records[1+i].body) {
+			t.Errorf("summary record %d differs from its data section copy", i)
+		}
+	}
+
+	r = &recordReader{t, summary[4].body}
+	messages, schemas, channels := r.u64(), r.u16(), r.u32()
+	attachments, metadata, chunks := r.u32(), r.u32(), r.u32()
+	start, end := r.u64(), r.u64()
+	counts := &recordReader{t, r.take(int(r.u32()))}
+	r.done()
+	if messages != 4 || schemas != 1 || channels != 3 || attachments != 0 || metadata != 0 || chunks != 0 {
+		t.Errorf("statistics = %d messages, %d schemas, %d channels, %d attachments, %d metadata, %d chunks",
+			messages, schemas, channels, attachments, metadata, chunks)
+	}
+	if start != 1000 || end != 5000 {
+		t.Errorf("statistics time range = %d..%d, want 1000..5000", start, end)
+	}
+	gotCounts := map[uint16]uint64{}
+	for len(counts.b) > 0 {
+		gotCounts[counts.u16()] = counts.u64()
+	}
+	if len(gotCounts) != 2 || gotCounts[voltage] != 2 || gotCounts[current] != 2 {
+		t.Errorf("channel message counts = %v, want 2 each on %d and %d (none on %d)", gotCounts, voltage, current, idle)
+	}
+
+	// Summary offsets: one per group, covering the summary section exactly
+	covered := summaryStart
+	for _, rec := range offsets {
+		if rec.opcode != opSummaryOffset {
+			t.Fatalf("record 0x%02x in the summary offset section", rec.opcode)
+		}
+		r := &recordReader{t, rec.body}
+		op, groupStart, groupLength := r.u8(), r.u64(), r.u64()
+		r.done()
+		if groupStart != covered {
+			t.Errorf("group 0x%02x starts at %d, want %d", op, groupStart, covered)
+		}
+		for at := groupStart; at < groupStart+groupLength; {
+			rec, ok := byOffset[at]
+			if !ok || rec.opcode != op {
+				t.Fatalf("group 0x%02x at %d+%d does not hold only 0x%02x records", op, groupStart, groupLength, op)
+			}
+			at += 9 + uint64(len(rec.body))
+		}
+		covered = groupStart + groupLength
+	}
+	if len(offsets) != 3 || covered != summaryOffsetStart {
+		t.Errorf("%d summary offsets covering %d..%d, want 3 covering %d..%d", len(offsets), summaryStart, covered, summaryStart, summaryOffsetStart)
+	}
+}
+
+func TestMCAPEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	m := newMCAPWriter(&buf, "ros2", library)
+	if err := m.close(); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+	records := readRecords(t, file[len(mcapMagic):len(file)-len(mcapMagic)], uint64(len(mcapMagic)))
+
+	var ops []byte
+	for _, rec := range records {
+		ops = append(ops, rec.opcode)
+	}
+	want := []byte{opHeader, opDataEnd, opStatistics, opSummaryOffset, opFooter}
+	if !bytes.Equal(ops, want) {
+		t.Fatalf("opcodes = %x, want %x", ops, want)
+	}
+	r := &recordReader{t, records[2].body}
+	if messages := r.u64(); messages != 0 {
+		t.Errorf("statistics count %d messages", messages)
+	}
+	r.take(2 + 4*4)
+	if start, end := r.u64(), r.u64(); start != 0 || end != 0 {
+		t.Errorf("statistics time range = %d..%d, want 0..0", start, end)
+	}
+}
+
+// failWriter fails every write after n bytes.
+type failWriter struct {
+	n int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestMCAPWriteError(t *testing.T) {
+	m := newMCAPWriter(&failWriter{n: 160}, "ros2", library)
+	schema := m.addSchema(float64Type, "ros2msg", []byte(float64Definition))
+	if _, err := m.addChannel(schema, "/fsae/a", "cdr", nil); err != nil {
+		t.Fatalf("addChannel before the failure: %v", err)
+	}
+	var err error
+	for i := 0; err == nil && i < 10; i++ {
+		err = m.writeMessage(0, uint64(i), make([]byte, 12))
+	}
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("writeMessage error = %v, want the write error", err)
+	}
+	if err := m.close(); !errors.Is(err, errWrite) {
+		t.Fatalf("close error = %v, want the first write error", err)
+	}
+}
+
+func TestWriterMessages(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	at := time.Unix(1714564800, 250000000)
+	for i, v := range []float64{1.5, -2, math.Inf(1)} {
+		if err := w.WriteFloat64("pack.voltage", at.Add(time.Duration(i)*time.Millisecond), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteFloat64("9lives.x-y", at, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Messages() != 4 {
+		t.Errorf("Messages() = %d, want 4", w.Messages())
+	}
+
+	file := buf.Bytes()
+	records := readRecords(t, file[len(mcapMagic):len(file)-len(mcapMagic)], uint64(len(mcapMagic)))
+	var topics []string
+	var values []float64
+	for _, rec := range records {
+		if rec.opcode == opDataEnd {
+			break
+		}
+		r := &recordReader{t, rec.body}
+		switch rec.opcode {
+		case opSchema:
+			if id, name, enc, def := r.u16(), r.str(), r.str(), r.take(int(r.u32())); id != 1 || name != float64Type || enc != "ros2msg" || string(def) != float64Definition {
+				t.Errorf("schema = %d %q %q %q", id, name, enc, def)
+			}
+		case opChannel:
+			r.take(4)
+			topics = append(topics, r.str())
+		case opMessage:
+			r.take(2 + 4 + 8 + 8)
+			data := r.rest()
+			if !bytes.Equal(data[:4], cdrHeader) || len(data) != 12 {
+				t.Fatalf("message data %x is not a CDR float64", data)
+			}
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(data[4:])))
+		}
+	}
+	if len(topics) != 2 || topics[0] != "/fsae/pack/voltage" || topics[1] != "/fsae/_9lives/x_y" {
+		t.Errorf("topics = %q", topics)
+	}
+	if len(values) != 4 || values[0] != 1.5 || values[1] != -2 || !math.IsInf(values[2], 1) || values[3] != 0 {
+		t.Errorf("values = %v", values)
+	}
+}
@@ -0,0 +1,105 @@
+// rosbag.go
+//
+// Package rosbag writes telemetry channels as rosbag2 recordings so the
+// driverless stack can replay chassis telemetry with standard ROS 2 tooling
+// (`ros2 bag play`, Foxglove, rosbag2_py). Every channel becomes a topic under
+// /fsae carrying std_msgs/msg/Float64 messages stamped with the sample time, e.g.
+// "pack_voltage.voltage" is published on /fsae/pack_voltage/voltage. Recordings
+// use the MCAP storage format, readable by rosbag2 since Humble.
+package rosbag
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// TopicPrefix is the namespace of every exported topic.
+const TopicPrefix = "/fsae"
+
+// FileExtension is the extension of rosbag2 MCAP recordings.
+const FileExtension = ".mcap"
+
+const (
+	// Library name stored in the MCAP header
+	library = "fsae-telemetry"
+
+	// Message type of every topic and its ROS 2 definition
+	float64Type       = "std_msgs/msg/Float64"
+	float64Definition = "float64 data\n"
+)
+
+// CDR encapsulation header for little-endian plain CDR
+var cdrHeader = []byte{0x00, 0x01, 0x00, 0x00}
+
+// Writer writes a rosbag2 recording. Topics are registered on first use. A Writer
+// is not safe for concurrent use.
+type Writer struct {
+	mcap   *mcapWriter
+	schema uint16
+	topics map[string]uint16
+	msg    []byte
+}
+
+// NewWriter starts a recording on w.
+func NewWriter(w io.Writer) *Writer {
+	m := newMCAPWriter(w, "ros2", library)
+	return &Writer{
+		mcap:   m,
+		schema: m.addSchema(float64Type, "ros2msg", []byte(float64Definition)),
+		topics: make(map[string]uint16),
+		msg:    make([]byte, len(cdrHeader)+8),
+	}
+}
+
+// WriteFloat64 records a std_msgs/msg/Float64 message on the topic of channel.
+func (b *Writer) WriteFloat64(channel string, t time.Time, v float64) error {
+	id, ok := b.topics[channel]
+	if !ok {
+		var err error
+		// rosbag2 reads the QoS profiles from the channel metadata; empty selects the defaults
+		id, err = b.mcap.addChannel(b.schema, TopicName(channel), "cdr", map[string]string{"offered_qos_profiles": ""})
+		if err != nil {
+			return err
+		}
+		b.topics[channel] = id
+	}
+	copy(b.msg, cdrHeader)
+	binary.LittleEndian.PutUint64(b.msg[len(cdrHeader):], math.Float64bits(v))
+	return b.mcap.writeMessage(id, uint64(t.UnixNano()), b.msg)
+}
+
+// Messages returns the number of messages written so far.
+func (b *Writer) Messages() uint64 {
+	return b.mcap.messages
+}
+
+// Close finishes the recording. The underlying writer is not closed.
+func (b *Writer) Close() error {
+	return b.mcap.close()
+}
+
+// TopicName returns the ROS 2 topic of a channel ID such as "table.column".
+// Characters that are not valid in ROS names are replaced by underscores, and
+// name tokens starting with a digit are prefixed with one.
+func TopicName(channel string) string {
+	var sb strings.Builder
+	sb.WriteString(TopicPrefix)
+	for _, token := range strings.Split(channel, ".") {
+		sb.WriteByte('/')
+		if token == "" || (token[0] >= '0' && token[0] <= '9') {
+			sb.WriteByte('_')
+		}
+		for _, c := range token {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+				sb.WriteRune(c)
+			default:
+				sb.WriteByte('_')
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, 5, 1, 12, 34, 56, 0, time.UTC)
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", from, at(5, 1, 12, 35)},
+		{"*/15 * * * *", from, at(5, 1, 12, 45)},
+		{"*/15 * * * *", at(5, 1, 12, 45), at(5, 1, 13, 0)}, // Strictly after
+		{"10-20/5 * * * *", from, at(5, 1, 13, 10)},
+		{"5/20 * * * *", from, at(5, 1, 12, 45)},
+		{"0,30 * * * *", from, at(5, 1, 13, 0)},
+		{"0 12 * * *", from, at(5, 2, 12, 0)},
+		{"  0 12  * * *  ", from, at(5, 2, 12, 0)},
+		{"59 23 31 12 *", from, at(12, 31, 23, 59)},
+		{"30 2 * * 1,3", from, at(5, 6, 2, 30)},
+		{"0 0 * * 0", from, at(5, 5, 0, 0)},
+		{"0 0 * * 7", from, at(5, 5, 0, 0)}, // 7 is also Sunday
+		{"0 9 15 * *", from, at(5, 15, 9, 0)},
+		{"0 9 15 * 1", from, at(5, 6, 9, 0)}, // Both day fields restricted: either matches
+		{"0 9 * * 1-5", at(5, 3, 18, 0), at(5, 6, 9, 0)},
+		{"0 0 1 1 *", from, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", from, time.Time{}}, // Never matches
+		{"@hourly", from, at(5, 1, 13, 0)},
+		{"@daily", from, at(5, 2, 0, 0)},
+		{"@weekly", from, at(5, 5, 0, 0)},
+		{"@monthly", from, at(6, 1, 0, 0)},
+		{"@every 90s", from, from.Add(90 * time.Second)},
+		{"@every  1h30m ", from, from.Add(90 * time.Minute)},
+		{"@every 1s", from, from.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q).Next(%s) = %s, want %s", tt.spec, tt.from.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"5-1 * * * *",
+		"1-2-3 * * * *",
+		"1- * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+		"*/0 * * * *",
+		"*/-5 * * * *",
+		"*/x * * * *",
+		"*/ * * * *",
+		"MON * * * *",
+		"@yearly",
+		"@every",
+		"@every ",
+		"@every 500ms",
+		"@every -1m",
+		"@every 10",
+		"@every soon",
+	}
+	for _, spec := range tests {
+		if s, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) = %v, want an error", spec, s)
+		}
+	}
+}
+
+func TestParseField(t *testing.T) {
+	bits := func(values ...int) uint64 {
+		var set uint64
+		for _, v := range values {
+			set |= 1 << uint(v)
+		}
+		return set
+	}
+	tests := []struct {
+		field  string
+		lo, hi int
+		want   uint64
+	}{
+		{"*", 0, 7, bits(0, 1, 2, 3, 4, 5, 6, 7)},
+		{"*/3", 1, 12, bits(1, 4, 7, 10)},
+		{"3", 0, 59, bits(3)},
+		{"0", 0, 59, bits(0)},
+		{"59", 0, 59, bits(59)},
+		{"2-4", 0, 23, bits(2, 3, 4)},
+		{"4-4", 0, 23, bits(4)},
+		{"0-23/6", 0, 23, bits(0, 6, 12, 18)},
+		{"20/15", 0, 59, bits(20, 35, 50)},
+		{"1,3-5,10/20", 0, 59, bits(1, 3, 4, 5, 10, 30, 50)},
+		{"1,1", 0, 59, bits(1)},
+		{"5/100", 0, 59, bits(5)},
+	}
+	for _, tt := range tests {
+		got, err := parseField(tt.field, tt.lo, tt.hi)
+		if err != nil {
+			t.Errorf("parseField(%q, %d, %d): %v", tt.field, tt.lo, tt.hi, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseField(%q, %d, %d) = %b, want %b", tt.field, tt.lo, tt.hi, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextLocalTime(t *testing.T) {
+	// Cron expressions follow the wall clock of the time passed in
+	zone := time.FixedZone("CEST", 2*60*60)
+	s, err := ParseSchedule("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 5, 1, 2, 59, 30, 0, zone)
+	want := time.Date(2024, 5, 1, 3, 0, 0, 0, zone)
+	if got := s.Next(from); !got.Equal(want) || got.Location() != zone {
+		t.Errorf("Next(%s) = %s, want %s", from, got, want)
+	}
+}
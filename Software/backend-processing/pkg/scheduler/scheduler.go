@@ -0,0 +1,285 @@
+// scheduler.go
+//
+// Package scheduler runs background jobs (retention, compaction, archiving,
+// reports, sync) on cron-like schedules. Jobs are registered at startup with a
+// name, a schedule and a function. A job never runs concurrently with itself: a
+// run that comes due, or is triggered manually, while the previous one is still
+// going is skipped. The last run of every job is stored in the database so its
+// status survives restarts.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"telem-system/pkg/db"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Run statuses
+const (
+	StatusRunning = "running"
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+)
+
+// Default limit on the duration of a single run
+const defaultTimeout = time.Hour
+
+var (
+	// ErrUnknownJob is returned when triggering a job that is not registered.
+	ErrUnknownJob = errors.New("unknown job")
+
+	// ErrJobRunning is returned when triggering a job that is already running.
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Job is a registered background job.
+type Job struct {
+	Name     string
+	Schedule string                          // See ParseSchedule; empty runs the job on manual triggers only
+	Timeout  time.Duration                   // Limit on a single run; 0 selects the default
+	Run      func(ctx context.Context) error // Must return when ctx is cancelled
+}
+
+// JobStatus describes a job for the admin API.
+type JobStatus struct {
+	Name     string        `json:"name"`
+	Schedule string        `json:"schedule"`
+	Running  bool          `json:"running"`
+	NextRun  *time.Time    `json:"next_run,omitempty"` // nil for manual-only jobs
+	Skipped  int64         `json:"skipped"`            // Runs skipped because the job was still running
+	LastRun  *types.JobRun `json:"last_run,omitempty"`
+}
+
+// entry is the scheduler state of a job.
+type entry struct {
+	job      Job
+	schedule Schedule
+	running  bool
+	next     time.Time
+	skipped  int64
+	last     *types.JobRun
+}
+
+// scheduler holds the registered jobs.
+type scheduler struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	ctx     context.Context // Set by Start; runs are cancelled with it
+	wake    chan struct{}
+	wg      sync.WaitGroup
+}
+
+var sched = &scheduler{entries: make(map[string]*entry), wake: make(chan struct{}, 1)}
+
+// Register adds a job. Jobs registered after Start are scheduled immediately.
+func Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("job needs a name and a run function")
+	}
+	e := &entry{job: job}
+	if job.Schedule != "" {
+		s, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		e.schedule = s
+		e.next = s.Next(time.Now())
+	}
+	if e.job.Timeout <= 0 {
+		e.job.Timeout = defaultTimeout
+	}
+
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	if _, dup := sched.entries[job.Name]; dup {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	sched.entries[job.Name] = e
+	sched.signal()
+	return nil
+}
+
+// Start loads the last runs from the database and runs due jobs until ctx is
+// cancelled. Cancelling ctx also cancels runs in progress.
+func Start(ctx context.Context) {
+	loadCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	runs, err := db.FetchJobRuns(loadCtx)
+	cancel()
+	if err != nil {
+		log.Printf("Error loading job runs: %v", err)
+	}
+
+	sched.mu.Lock()
+	sched.ctx = ctx
+	for name, run := range runs {
+		if e, ok := sched.entries[name]; ok {
+			if run.Status == StatusRunning {
+				// The server stopped during this run
+				run.Status, run.Error = StatusFailed, "interrupted by server shutdown"
+			}
+			e.last = &run
+		}
+	}
+	sched.mu.Unlock()
+
+	go sched.loop(ctx)
+}
+
+// Trigger starts a job immediately, outside its schedule.
+func Trigger(name string) error {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	e, ok := sched.entries[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+	if sched.ctx == nil {
+		return errors.New("scheduler is not running")
+	}
+	if e.running {
+		return ErrJobRunning
+	}
+	sched.launch(e, TriggerManual)
+	return nil
+}
+
+// Jobs returns the status of every registered job, ordered by name.
+func Jobs() []JobStatus {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	out := make([]JobStatus, 0, len(sched.entries))
+	for _, e := range sched.entries {
+		st := JobStatus{Name: e.job.Name, Schedule: e.job.Schedule, Running: e.running, Skipped: e.skipped}
+		if !e.next.IsZero() {
+			next := e.next
+			st.NextRun = &next
+		}
+		if e.last != nil {
+			last := *e.last
+			st.LastRun = &last
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Wait blocks until runs in progress have returned, e.g. during shutdown after
+// the context passed to Start is cancelled.
+func Wait() {
+	sched.wg.Wait()
+}
+
+// loop sleeps until the next job is due and launches due jobs.
+func (s *scheduler) loop(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		wait := time.Hour
+		for _, e := range s.entries {
+			if e.next.IsZero() {
+				continue
+			}
+			if !e.next.After(now) {
+				if e.running {
+					e.skipped++
+					log.Printf("Job %s is still running, skipping scheduled run", e.job.Name)
+				} else {
+					s.launch(e, TriggerSchedule)
+				}
+				e.next = e.schedule.Next(now)
+				if e.next.IsZero() {
+					continue
+				}
+			}
+			wait = min(wait, e.next.Sub(now))
+		}
+		s.mu.Unlock()
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// signal wakes the loop to recompute the next due job.
+func (s *scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// launch starts a run of e. The caller holds s.mu.
+func (s *scheduler) launch(e *entry, trigger string) {
+	run := types.JobRun{Job: e.job.Name, Trigger: trigger, StartedAt: time.Now(), Status: StatusRunning}
+	if e.last != nil {
+		run.RunCount = e.last.RunCount
+	}
+	run.RunCount++
+	started := run
+	e.running = true
+	e.last = &started
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		s.persist(run)
+
+		ctx, cancel := context.WithTimeout(s.ctx, e.job.Timeout)
+		err := safeRun(ctx, e.job.Run)
+		cancel()
+
+		finished := time.Now()
+		run.FinishedAt = &finished
+		run.Status = StatusOK
+		if err != nil {
+			run.Status, run.Error = StatusFailed, err.Error()
+			log.Printf("Job %s failed after %s: %v", e.job.Name, finished.Sub(run.StartedAt).Round(time.Millisecond), err)
+		}
+
+		s.mu.Lock()
+		e.running = false
+		e.last = &run
+		s.mu.Unlock()
+		s.persist(run)
+	}()
+}
+
+// persist stores a run. Failures are logged; the in-memory status stays current.
+func (s *scheduler) persist(run types.JobRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.UpsertJobRun(ctx, run); err != nil {
+		log.Printf("Error storing run of job %s: %v", run.Job, err)
+	}
+}
+
+// safeRun calls fn, turning a panic into an error so a faulty job cannot take the
+// server down.
+func safeRun(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,313 @@
+// sessions.go
+//
+// Package sessions tracks recording sessions. Sessions can be started and stopped
+// manually; in auto mode a session is also opened when frames start arriving after
+// an idle period and closed after a configurable quiet period or when the tractive
+// system (TS) is deactivated, so data is always attributed to a session.
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"telem-system/pkg/db"
+	"telem-system/pkg/features"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Session start and end reasons
+const (
+	ReasonManual        = "manual"
+	ReasonActivity      = "activity"
+	ReasonTSActive      = "ts_active"
+	ReasonIdle          = "idle"
+	ReasonTSDeactivated = "ts_deactivated"
+	ReasonShutdown      = "shutdown"
+	ReasonServerRestart = "server_restart"
+)
+
+const (
+	// Default time without frames after which an automatic session is closed
+	defaultQuietPeriod = 30 * time.Second
+
+	// Default tractive voltage above which the TS is considered active (FSAE low
+	// voltage limit)
+	defaultTSThreshold = 60.0
+
+	// How often the auto start/stop conditions are evaluated
+	checkInterval = 500 * time.Millisecond
+)
+
+// ErrNoSession is returned by Stop when no session is open.
+var ErrNoSession = errors.New("no session is open")
+
+// manager holds the session state.
+type manager struct {
+	opMu sync.Mutex // Serialises session transitions (database writes)
+
+	mu           sync.Mutex
+	auto         bool
+	quietPeriod  time.Duration
+	tsThreshold  float64
+	current      *types.Session
+	lastActivity time.Time
+	burstStart   time.Time // First frame after the last idle period
+	suppressed   bool      // Auto start disabled until the next burst or TS activation
+	tsActive     bool
+	tsOnAt       time.Time // Pending TS activation edge
+	tsOffAt      time.Time // Pending TS deactivation edge
+	lastEnded    time.Time // End of the previous session; sessions never overlap
+	subscribers  []func(types.Session)
+}
+
+var mgr = &manager{
+	auto:        true,
+	quietPeriod: defaultQuietPeriod,
+	tsThreshold: defaultTSThreshold,
+}
+
+// Configure sets the session mode. auto enables automatic start/stop; non-positive
+// quietPeriod and tsThreshold select the defaults.
+func Configure(auto bool, quietPeriod time.Duration, tsThreshold float64) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.auto = auto
+	mgr.quietPeriod = defaultQuietPeriod
+	if quietPeriod > 0 {
+		mgr.quietPeriod = quietPeriod
+	}
+	mgr.tsThreshold = defaultTSThreshold
+	if tsThreshold > 0 {
+		mgr.tsThreshold = tsThreshold
+	}
+}
+
+// Subscribe registers fn to be called whenever a session starts or ends.
+func Subscribe(fn func(types.Session)) {
+	mgr.mu.Lock()
+	mgr.subscribers = append(mgr.subscribers, fn)
+	mgr.mu.Unlock()
+}
+
+// Init closes sessions left open by a previous run and starts the auto start/stop
+// monitor. The open session is closed when ctx is cancelled.
+func Init(ctx context.Context) error {
+	n, err := db.CloseOpenSessions(ctx, time.Now(), ReasonServerRestart)
+	if err != nil {
+		return fmt.Errorf("closing stale sessions: %w", err)
+	}
+	if n > 0 {
+		log.Printf("Closed %d session(s) left open by a previous run", n)
+	}
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				mgr.check(time.Now())
+			case <-ctx.Done():
+				if _, err := mgr.stop(context.Background(), time.Now(), ReasonShutdown); err != nil && !errors.Is(err, ErrNoSession) {
+					log.Printf("Error closing session on shutdown: %v", err)
+				}
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// NoteActivity records that a frame arrived at t. It is called for every frame.
+func NoteActivity(t time.Time) {
+	mgr.mu.Lock()
+	if mgr.lastActivity.IsZero() || t.Sub(mgr.lastActivity) > mgr.quietPeriod {
+		mgr.burstStart = t
+		mgr.suppressed = false
+	}
+	if t.After(mgr.lastActivity) {
+		mgr.lastActivity = t
+	}
+	mgr.mu.Unlock()
+}
+
+// NoteTractiveVoltage records a tractive voltage sample used to detect TS
+// activation and deactivation.
+func NoteTractiveVoltage(v float64, t time.Time) {
+	mgr.mu.Lock()
+	active := v >= mgr.tsThreshold
+	if active && !mgr.tsActive {
+		mgr.tsOnAt = t
+		mgr.tsOffAt = time.Time{}
+	} else if !active && mgr.tsActive {
+		mgr.tsOffAt = t
+		mgr.tsOnAt = time.Time{}
+	}
+	mgr.tsActive = active
+	mgr.mu.Unlock()
+}
+
+// Current returns the open session, if any.
+func Current() (types.Session, bool) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.current == nil {
+		return types.Session{}, false
+	}
+	return *mgr.current, true
+}
+
+// Start manually opens a session, closing the current one first. An empty name
+// selects a name derived from the start time.
+func Start(ctx context.Context, name string) (types.Session, error) {
+	now := time.Now()
+	if _, err := mgr.stop(ctx, now, ReasonManual); err != nil && !errors.Is(err, ErrNoSession) {
+		return types.Session{}, err
+	}
+	if name == "" {
+		name = "Session " + now.Format("2006-01-02 15:04")
+	}
+	return mgr.start(ctx, types.Session{Name: name, StartedAt: now, StartReason: ReasonManual})
+}
+
+// Stop manually closes the open session. Automatic start is suppressed until
+// activity resumes after an idle period or the TS is activated again.
+func Stop(ctx context.Context) (types.Session, error) {
+	s, err := mgr.stop(ctx, time.Now(), ReasonManual)
+	if err == nil {
+		mgr.mu.Lock()
+		mgr.suppressed = true
+		mgr.mu.Unlock()
+	}
+	return s, err
+}
+
+// check evaluates the automatic start and stop conditions.
+func (m *manager) check(now time.Time) {
+	m.mu.Lock()
+	if !m.auto {
+		m.mu.Unlock()
+		return
+	}
+	cur := m.current
+	lastActivity, burstStart := m.lastActivity, m.burstStart
+	tsOnAt, tsOffAt := m.tsOnAt, m.tsOffAt
+	m.tsOnAt, m.tsOffAt = time.Time{}, time.Time{}
+	if !tsOnAt.IsZero() {
+		m.suppressed = false
+	}
+	quiet := m.quietPeriod
+	m.mu.Unlock()
+
+	ctx := context.Background()
+
+	// Automatic stop (manual sessions are only closed by the user)
+	if cur != nil && cur.Auto {
+		switch {
+		case !tsOffAt.IsZero():
+			if _, err := m.stop(ctx, tsOffAt, ReasonTSDeactivated); err != nil {
+				log.Printf("Error closing session: %v", err)
+			}
+			m.mu.Lock()
+			m.suppressed = true
+			m.mu.Unlock()
+			return
+		case now.Sub(lastActivity) > quiet:
+			if _, err := m.stop(ctx, lastActivity, ReasonIdle); err != nil {
+				log.Printf("Error closing session: %v", err)
+			}
+			return
+		}
+	}
+
+	// Automatic start
+	m.mu.Lock()
+	idle := lastActivity.IsZero() || now.Sub(lastActivity) > quiet
+	canStart := m.current == nil && !m.suppressed && !idle
+	m.mu.Unlock()
+	if !canStart {
+		return
+	}
+
+	s := types.Session{StartedAt: burstStart, StartReason: ReasonActivity, Auto: true}
+	if !tsOnAt.IsZero() {
+		s.StartReason = ReasonTSActive
+	}
+	m.mu.Lock()
+	if s.StartedAt.Before(m.lastEnded) {
+		s.StartedAt = m.lastEnded
+	}
+	m.mu.Unlock()
+	s.Name = "Auto " + s.StartedAt.Format("2006-01-02 15:04")
+	if _, err := m.start(ctx, s); err != nil {
+		log.Printf("Error opening session: %v", err)
+	}
+}
+
+// start persists and installs a new open session.
+func (m *manager) start(ctx context.Context, s types.Session) (types.Session, error) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	if _, open := Current(); open {
+		return types.Session{}, errors.New("a session is already open")
+	}
+	// Record which code paths write the session's data
+	s.FeatureFlags = features.Snapshot()
+	id, err := db.CreateSession(ctx, s)
+	if err != nil {
+		return types.Session{}, err
+	}
+	s.ID = id
+
+	m.mu.Lock()
+	stored := s
+	m.current = &stored
+	subs := m.subscribers
+	m.mu.Unlock()
+
+	log.Printf("Session %d %q started (%s)", s.ID, s.Name, s.StartReason)
+	for _, fn := range subs {
+		fn(s)
+	}
+	return s, nil
+}
+
+// stop persists the end of the open session.
+func (m *manager) stop(ctx context.Context, endedAt time.Time, reason string) (types.Session, error) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	cur := m.current
+	m.mu.Unlock()
+	if cur == nil {
+		return types.Session{}, ErrNoSession
+	}
+
+	if endedAt.Before(cur.StartedAt) {
+		endedAt = cur.StartedAt
+	}
+	if err := db.EndSession(ctx, cur.ID, endedAt, reason); err != nil {
+		return types.Session{}, err
+	}
+	s := *cur
+	s.EndedAt = &endedAt
+	s.EndReason = reason
+
+	m.mu.Lock()
+	m.current = nil
+	m.lastEnded = endedAt
+	subs := m.subscribers
+	m.mu.Unlock()
+
+	log.Printf("Session %d %q ended (%s)", s.ID, s.Name, reason)
+	for _, fn := range subs {
+		fn(s)
+	}
+	return s, nil
+}
@@ -0,0 +1,229 @@
+// shutdown.go
+//
+// Package shutdown reconstructs the state of the shutdown circuit from the signals
+// that reflect it: the PDM shutdown/reset and TSAL currents, the AMS status and
+// the Bamocar RFE/FRG enables. Every element state change becomes an event. When
+// an element opens while the whole circuit was closed a trip starts; the elements
+// opening during the trip are numbered in order, so the first one answers "what
+// opened the shutdown circuit". The trip ends once every element is closed again.
+package shutdown
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/types"
+	"time"
+)
+
+// Shutdown circuit elements
+const (
+	ElementShutdownLoop = "shutdown_loop" // PDM shutdown/reset current
+	ElementTSAL         = "tsal"          // PDM TSAL current
+	ElementAMS          = "ams"           // AMS status
+	ElementRFE          = "bamocar_rfe"   // Bamocar RFE enable
+	ElementFRG          = "bamocar_frg"   // Bamocar FRG enable
+)
+
+const (
+	// Default currents at or above which the shutdown loop and the TSAL count as
+	// closed (PDM units)
+	defaultShutdownCurrentMin = 100
+	defaultTSALCurrentMin     = 50
+
+	// Alert raised while a trip is in progress
+	tripAlertKey = "shutdown_trip"
+)
+
+// Config holds the thresholds that turn signals into element states.
+type Config struct {
+	ShutdownCurrentMin float64
+	TSALCurrentMin     float64
+	AMSOKStatus        int // AMS status value meaning no fault
+}
+
+// tracker holds the element states.
+type tracker struct {
+	mu          sync.Mutex
+	cfg         Config
+	closed      map[string]bool // Known element states
+	tripStart   time.Time       // Zero when no trip is in progress
+	tripOrder   int             // Elements opened so far in the trip
+	subscribers []func(types.ShutdownEvent)
+}
+
+var tr = &tracker{
+	cfg:    Config{ShutdownCurrentMin: defaultShutdownCurrentMin, TSALCurrentMin: defaultTSALCurrentMin},
+	closed: make(map[string]bool),
+}
+
+// Configure installs the element thresholds. Non-positive currents select the
+// defaults.
+func Configure(cfg Config) {
+	if cfg.ShutdownCurrentMin <= 0 {
+		cfg.ShutdownCurrentMin = defaultShutdownCurrentMin
+	}
+	if cfg.TSALCurrentMin <= 0 {
+		cfg.TSALCurrentMin = defaultTSALCurrentMin
+	}
+	tr.mu.Lock()
+	tr.cfg = cfg
+	tr.mu.Unlock()
+}
+
+// Subscribe registers fn to be called with every element state change. fn is
+// called synchronously and must not block.
+func Subscribe(fn func(types.ShutdownEvent)) {
+	tr.mu.Lock()
+	tr.subscribers = append(tr.subscribers, fn)
+	tr.mu.Unlock()
+}
+
+// ObservePDM feeds the PDM shutdown/reset and TSAL currents.
+func ObservePDM(shutdownCurrent, tsalCurrent float64, t time.Time) {
+	tr.mu.Lock()
+	cfg := tr.cfg
+	tr.mu.Unlock()
+	observe(ElementShutdownLoop, shutdownCurrent, shutdownCurrent >= cfg.ShutdownCurrentMin, t)
+	observe(ElementTSAL, tsalCurrent, tsalCurrent >= cfg.TSALCurrentMin, t)
+}
+
+// ObserveAMS feeds the AMS status.
+func ObserveAMS(status int, t time.Time) {
+	tr.mu.Lock()
+	ok := status == tr.cfg.AMSOKStatus
+	tr.mu.Unlock()
+	observe(ElementAMS, float64(status), ok, t)
+}
+
+// ObserveBamocar feeds the Bamocar RFE and FRG enables.
+func ObserveBamocar(rfe, frg int, t time.Time) {
+	observe(ElementRFE, float64(rfe), rfe != 0, t)
+	observe(ElementFRG, float64(frg), frg != 0, t)
+}
+
+// States returns the known element states.
+func States() map[string]bool {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	out := make(map[string]bool, len(tr.closed))
+	for k, v := range tr.closed {
+		out[k] = v
+	}
+	return out
+}
+
+// observe records the state of an element and emits an event when it changed.
+func observe(element string, value float64, closed bool, t time.Time) {
+	tr.mu.Lock()
+	prev, known := tr.closed[element]
+	if known && prev == closed {
+		tr.mu.Unlock()
+		return
+	}
+	wasHealthy := tr.healthy()
+	tr.closed[element] = closed
+
+	e := types.ShutdownEvent{Timestamp: t, Element: element, Closed: closed, Value: value}
+	var tripStarted, tripEnded bool
+	switch {
+	case known && !closed && wasHealthy:
+		tr.tripStart, tr.tripOrder = t, 0
+		tripStarted = true
+	case closed && !tr.tripStart.IsZero() && tr.healthy():
+		tripEnded = true
+	}
+	if !tr.tripStart.IsZero() {
+		start := tr.tripStart
+		e.TripStart = &start
+		if !closed {
+			tr.tripOrder++
+			order := tr.tripOrder
+			e.TripOrder = &order
+		}
+	}
+	if tripEnded {
+		tr.tripStart = time.Time{}
+	}
+	subs := tr.subscribers
+	tr.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(e)
+	}
+	if tripStarted {
+		alerts.Raise(alerts.Alert{
+			Key:      tripAlertKey,
+			Source:   "shutdown",
+			Severity: alerts.SeverityWarning,
+			Message:  fmt.Sprintf("Shutdown circuit opened: %s opened first", element),
+			Value:    value,
+			RaisedAt: t,
+		})
+	} else if tripEnded {
+		alerts.Resolve(tripAlertKey)
+	}
+}
+
+// healthy reports whether every known element is closed. The caller holds tr.mu.
+func (tr *tracker) healthy() bool {
+	if len(tr.closed) == 0 {
+		return false
+	}
+	for _, c := range tr.closed {
+		if !c {
+			return false
+		}
+	}
+	return true
+}
+
+// Trip is a reconstructed shutdown: the elements that opened, in order, and the
+// closing events up to the circuit being fully closed again.
+type Trip struct {
+	Start        time.Time             `json:"start"`
+	FirstElement string                `json:"first_element"`
+	Opened       []string              `json:"opened"` // Elements in the order they opened
+	Events       []types.ShutdownEvent `json:"events"`
+}
+
+// GroupTrips groups trip events (as returned by db.FetchShutdownTripEvents) into
+// trips, oldest first.
+func GroupTrips(events []types.ShutdownEvent) []Trip {
+	byStart := make(map[time.Time]*Trip)
+	var trips []*Trip
+	for _, e := range events {
+		if e.TripStart == nil {
+			continue
+		}
+		key := e.TripStart.UTC()
+		trip, ok := byStart[key]
+		if !ok {
+			trip = &Trip{Start: *e.TripStart}
+			byStart[key] = trip
+			trips = append(trips, trip)
+		}
+		trip.Events = append(trip.Events, e)
+	}
+
+	out := make([]Trip, 0, len(trips))
+	for _, trip := range trips {
+		var opened []types.ShutdownEvent
+		for _, e := range trip.Events {
+			if e.TripOrder != nil {
+				opened = append(opened, e)
+			}
+		}
+		sort.SliceStable(opened, func(i, j int) bool { return *opened[i].TripOrder < *opened[j].TripOrder })
+		for _, e := range opened {
+			trip.Opened = append(trip.Opened, e.Element)
+		}
+		if len(trip.Opened) > 0 {
+			trip.FirstElement = trip.Opened[0]
+		}
+		out = append(out, *trip)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
@@ -0,0 +1,47 @@
+// port.go
+//
+// Adapter connection: opens the serial device, configures the CAN channel and
+// exposes a frame reader.
+package slcan
+
+import (
+	"fmt"
+	"os"
+)
+
+// Port is an open SLCAN adapter.
+type Port struct {
+	file *os.File
+	*Reader
+}
+
+// Open opens the adapter on device (e.g. /dev/ttyACM0), configures the serial line
+// for baud (ignored by USB CDC adapters) and opens the CAN channel at bitrate.
+func Open(device string, baud, bitrate int) (*Port, error) {
+	cmds, err := SetupCommands(bitrate)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(device, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := configureSerial(f, baud); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("configure %s: %w", device, err)
+	}
+	for _, cmd := range cmds {
+		if _, err := f.WriteString(cmd); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("write %q to %s: %w", cmd, device, err)
+		}
+	}
+	return &Port{file: f, Reader: NewReader(f)}, nil
+}
+
+// Close closes the CAN channel and the serial device.
+func (p *Port) Close() error {
+	_, _ = p.file.WriteString("C\r")
+	return p.file.Close()
+}
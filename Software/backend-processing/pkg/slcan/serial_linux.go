@@ -0,0 +1,61 @@
+//go:build linux
+
+// serial_linux.go
+//
+// Raw-mode serial line configuration using termios.
+package slcan
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// baudRates maps supported baud rates to their termios constants.
+var baudRates = map[int]uint32{
+	9600:    unix.B9600,
+	19200:   unix.B19200,
+	38400:   unix.B38400,
+	57600:   unix.B57600,
+	115200:  unix.B115200,
+	230400:  unix.B230400,
+	460800:  unix.B460800,
+	921600:  unix.B921600,
+	1000000: unix.B1000000,
+	2000000: unix.B2000000,
+	3000000: unix.B3000000,
+}
+
+// configureSerial puts the line in raw 8N1 mode at the given baud rate. A
+// non-positive baud rate leaves the speed unchanged.
+func configureSerial(f *os.File, baud int) error {
+	fd := int(f.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+
+	// Equivalent of cfmakeraw
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB
+	t.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL
+
+	// Block until at least one byte is available
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+
+	if baud > 0 {
+		speed, ok := baudRates[baud]
+		if !ok {
+			return fmt.Errorf("unsupported baud rate %d", baud)
+		}
+		t.Cflag &^= unix.CBAUD
+		t.Cflag |= speed
+		t.Ispeed = speed
+		t.Ospeed = speed
+	}
+	return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+// serial_other.go
+//
+// Serial configuration is only implemented for Linux (the Raspberry Pi target).
+package slcan
+
+import (
+	"errors"
+	"os"
+)
+
+// configureSerial reports that serial adapters are unsupported on this platform.
+func configureSerial(f *os.File, baud int) error {
+	return errors.New("serial CAN adapters are only supported on Linux")
+}
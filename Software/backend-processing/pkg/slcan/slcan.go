@@ -0,0 +1,182 @@
+// slcan.go
+//
+// Package slcan reads CAN frames from serial (UART/USB CDC) CAN adapters speaking
+// the SLCAN (Lawicel) ASCII protocol, such as the CANable. It is used as a direct
+// ingest source for bench testing without the wireless transmitter.
+package slcan
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Frame is a single CAN frame received from the adapter.
+type Frame struct {
+	ID           uint32
+	Extended     bool
+	RTR          bool
+	Data         []byte
+	Timestamp    uint16 // Adapter timestamp in ms (0-59999), if enabled
+	HasTimestamp bool
+}
+
+// bitrateCodes maps nominal CAN bitrates to the SLCAN "Sn" setup command.
+var bitrateCodes = map[int]byte{
+	10000:   '0',
+	20000:   '1',
+	50000:   '2',
+	100000:  '3',
+	125000:  '4',
+	250000:  '5',
+	500000:  '6',
+	800000:  '7',
+	1000000: '8',
+}
+
+// ErrNotFrame is returned by ParseFrame for adapter responses that are not frames
+// (acknowledgements, status replies, version strings).
+var ErrNotFrame = errors.New("slcan: not a frame")
+
+// ParseError reports a line that looks like a frame but cannot be parsed.
+type ParseError struct {
+	Line   string
+	Reason string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("slcan: %s in %q: %v", e.Reason, e.Line, e.Err)
+	}
+	return fmt.Sprintf("slcan: %s in %q", e.Reason, e.Line)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+func parseError(line, reason string, err error) error {
+	return &ParseError{Line: line, Reason: reason, Err: err}
+}
+
+// ParseFrame parses one SLCAN line without its terminating carriage return.
+// Supported frame types are t (standard), T (extended), r and R (remote).
+func ParseFrame(line string) (Frame, error) {
+	if len(line) == 0 {
+		return Frame{}, ErrNotFrame
+	}
+
+	var f Frame
+	idLen := 3
+	switch line[0] {
+	case 't':
+	case 'T':
+		f.Extended, idLen = true, 8
+	case 'r':
+		f.RTR = true
+	case 'R':
+		f.Extended, f.RTR, idLen = true, true, 8
+	default:
+		return Frame{}, ErrNotFrame
+	}
+
+	// Type, ID and DLC
+	if len(line) < 1+idLen+1 {
+		return Frame{}, parseError(line, "short frame", nil)
+	}
+	id, err := strconv.ParseUint(line[1:1+idLen], 16, 32)
+	if err != nil {
+		return Frame{}, parseError(line, "bad ID", err)
+	}
+	if (!f.Extended && id > 0x7FF) || id > 0x1FFFFFFF {
+		return Frame{}, parseError(line, "ID out of range", nil)
+	}
+	f.ID = uint32(id)
+
+	dlc := int(line[1+idLen] - '0')
+	if dlc < 0 || dlc > 8 {
+		return Frame{}, parseError(line, "bad DLC", nil)
+	}
+	pos := 2 + idLen
+
+	// Data bytes (remote frames carry none)
+	if !f.RTR {
+		if len(line) < pos+2*dlc {
+			return Frame{}, parseError(line, "truncated data", nil)
+		}
+		f.Data = make([]byte, dlc)
+		for i := 0; i < dlc; i++ {
+			b, err := strconv.ParseUint(line[pos+2*i:pos+2*i+2], 16, 8)
+			if err != nil {
+				return Frame{}, parseError(line, "bad data", err)
+			}
+			f.Data[i] = byte(b)
+		}
+		pos += 2 * dlc
+	}
+
+	// Optional 4-digit timestamp
+	if rest := line[pos:]; len(rest) == 4 {
+		ts, err := strconv.ParseUint(rest, 16, 16)
+		if err != nil {
+			return Frame{}, parseError(line, "bad timestamp", err)
+		}
+		f.Timestamp, f.HasTimestamp = uint16(ts), true
+	} else if len(rest) != 0 {
+		return Frame{}, parseError(line, "trailing characters", nil)
+	}
+	return f, nil
+}
+
+// Reader reads SLCAN frames from a byte stream.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a Reader consuming r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 4096)}
+}
+
+// ReadFrame returns the next frame, skipping adapter responses. Malformed lines
+// are returned as *ParseError so the caller can count them and continue; any
+// other error comes from the underlying stream.
+func (r *Reader) ReadFrame() (Frame, error) {
+	for {
+		line, err := r.r.ReadString('\r')
+		if err != nil {
+			return Frame{}, err
+		}
+		// Drop the terminator and any line feeds or BEL (error response) characters
+		line = trimControl(line)
+		f, err := ParseFrame(line)
+		if errors.Is(err, ErrNotFrame) {
+			continue
+		}
+		return f, err
+	}
+}
+
+// trimControl removes control characters from both ends of a line.
+func trimControl(s string) string {
+	start, end := 0, len(s)
+	for start < end && s[start] < 0x20 {
+		start++
+	}
+	for end > start && s[end-1] < 0x20 {
+		end--
+	}
+	return s[start:end]
+}
+
+// SetupCommands returns the commands that close the channel, set the bitrate and
+// reopen it. Closing first makes the setup work on an adapter left open by a
+// previous run.
+func SetupCommands(bitrate int) ([]string, error) {
+	code, ok := bitrateCodes[bitrate]
+	if !ok {
+		return nil, fmt.Errorf("slcan: unsupported bitrate %d", bitrate)
+	}
+	return []string{"C\r", "S" + string(code) + "\r", "O\r"}, nil
+}
@@ -0,0 +1,294 @@
+// tccapture.go
+//
+// Package tccapture implements the traction-control development logging mode.
+// While a capture run is active, every frame of the selected IDs (wheel speeds,
+// torque request, inverter actuals, ...) is taken straight from the ingest path,
+// ahead of the worker pool and any throttling, and stored with its raw bytes and
+// decoded signals in the tc_capture_frames table. Frames are buffered and written
+// in batches by a single writer; frames that do not fit in the buffer are counted
+// as dropped on the run instead of stalling ingest.
+package tccapture
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"telem-system/pkg/candecoder"
+	"telem-system/pkg/db"
+	"telem-system/pkg/sessions"
+	"telem-system/pkg/types"
+	"time"
+)
+
+const (
+	// Defaults, overridable through Config
+	defaultMaxDuration   = 30 * time.Minute
+	defaultBufferFrames  = 50000
+	defaultBatchSize     = 2000
+	defaultFlushInterval = 100 * time.Millisecond
+
+	// Limit on writing a single batch
+	writeTimeout = 10 * time.Second
+)
+
+// DefaultFrameIDs are captured when a run does not select its own: the wheel
+// frequencies (101, 102), wheel encoders (200), the Bamocar torque request (385)
+// and the Bamocar actuals (513).
+var DefaultFrameIDs = []uint32{101, 102, 200, 385, 513}
+
+var (
+	// ErrActive is returned by Start while a run is in progress.
+	ErrActive = errors.New("a capture run is already active")
+
+	// ErrNotActive is returned by Stop when no run is in progress.
+	ErrNotActive = errors.New("no capture run is active")
+)
+
+// Config holds the capture limits.
+type Config struct {
+	FrameIDs      []uint32      // Frames captured when a run selects none (default DefaultFrameIDs)
+	MaxDuration   time.Duration // A run stops by itself after this long
+	BufferFrames  int           // Frames buffered ahead of the writer
+	BatchSize     int           // Frames written per transaction
+	FlushInterval time.Duration // Longest time a frame waits in the buffer
+}
+
+// withDefaults fills non-positive values with the defaults.
+func withDefaults(cfg Config) Config {
+	if len(cfg.FrameIDs) == 0 {
+		cfg.FrameIDs = DefaultFrameIDs
+	}
+	if cfg.MaxDuration <= 0 {
+		cfg.MaxDuration = defaultMaxDuration
+	}
+	if cfg.BufferFrames <= 0 {
+		cfg.BufferFrames = defaultBufferFrames
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	return cfg
+}
+
+// frame is a captured frame waiting for the writer.
+type frame struct {
+	id   uint32
+	data []byte
+	msg  types.Message
+	t    time.Time
+}
+
+// run is an active capture run.
+type run struct {
+	info    types.TCCaptureRun
+	ids     map[uint32]bool
+	frames  chan frame
+	stored  atomic.Int64
+	dropped atomic.Int64
+	stop    chan struct{} // Closed to end the run
+	done    chan struct{} // Closed once the writer has flushed
+	timer   *time.Timer
+}
+
+var (
+	mu      sync.Mutex // Serialises Start and Stop
+	cfg     = withDefaults(Config{})
+	current atomic.Pointer[run]
+)
+
+// Configure sets the capture limits. It must be called before Start.
+func Configure(c Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	cfg = withDefaults(c)
+}
+
+// Recover closes runs left open by a previous process, e.g. after a crash.
+func Recover(ctx context.Context) error {
+	return db.EndOpenTCCaptureRuns(ctx)
+}
+
+// CaptureFrame offers a frame to the active run. data is copied, so the caller
+// may reuse it. It is cheap when no run is active or the frame is not selected,
+// and never blocks.
+func CaptureFrame(frameID uint32, data []byte, msg types.Message, t time.Time) {
+	r := current.Load()
+	if r == nil || !r.ids[frameID] {
+		return
+	}
+	select {
+	case r.frames <- frame{id: frameID, data: slices.Clone(data), msg: msg, t: t}:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Start begins a capture run of the given frame IDs (the configured set when
+// empty). The run is tied to the open session, if any.
+func Start(ctx context.Context, frameIDs []uint32, note, startedBy string) (types.TCCaptureRun, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current.Load() != nil {
+		return types.TCCaptureRun{}, ErrActive
+	}
+	if len(frameIDs) == 0 {
+		frameIDs = cfg.FrameIDs
+	}
+
+	info := types.TCCaptureRun{StartedAt: time.Now(), Note: note, StartedBy: startedBy}
+	if s, ok := sessions.Current(); ok {
+		info.SessionID = &s.ID
+	}
+	r := &run{
+		ids:    make(map[uint32]bool, len(frameIDs)),
+		frames: make(chan frame, cfg.BufferFrames),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for _, id := range frameIDs {
+		if !r.ids[id] {
+			r.ids[id] = true
+			info.FrameIDs = append(info.FrameIDs, int(id))
+		}
+	}
+	slices.Sort(info.FrameIDs)
+
+	id, err := db.InsertTCCaptureRun(ctx, info)
+	if err != nil {
+		return types.TCCaptureRun{}, err
+	}
+	info.ID = id
+	r.info = info
+
+	go r.write(cfg.BatchSize, cfg.FlushInterval)
+	r.timer = time.AfterFunc(cfg.MaxDuration, func() {
+		if _, err := stop(context.Background(), r); err == nil {
+			log.Printf("TC capture run %d reached its maximum duration and was stopped", r.info.ID)
+		}
+	})
+	current.Store(r)
+	log.Printf("TC capture run %d started by %s for frames %v", id, startedBy, info.FrameIDs)
+	return info, nil
+}
+
+// Stop ends the active run once its buffered frames are written and returns it.
+func Stop(ctx context.Context) (types.TCCaptureRun, error) {
+	r := current.Load()
+	if r == nil {
+		return types.TCCaptureRun{}, ErrNotActive
+	}
+	return stop(ctx, r)
+}
+
+// stop ends run r if it is still the active run.
+func stop(ctx context.Context, r *run) (types.TCCaptureRun, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !current.CompareAndSwap(r, nil) {
+		return types.TCCaptureRun{}, ErrNotActive
+	}
+	r.timer.Stop()
+	close(r.stop)
+	<-r.done
+
+	now := time.Now()
+	info := r.status()
+	info.EndedAt = &now
+	if err := db.UpdateTCCaptureRun(ctx, info.ID, info.Frames, info.Dropped, &now); err != nil {
+		log.Printf("Error closing TC capture run %d: %v", info.ID, err)
+	}
+	log.Printf("TC capture run %d stopped: %d frames stored, %d dropped", info.ID, info.Frames, info.Dropped)
+	return info, nil
+}
+
+// Status returns the active run with its counters so far. It reports false when
+// no run is active.
+func Status() (types.TCCaptureRun, bool) {
+	r := current.Load()
+	if r == nil {
+		return types.TCCaptureRun{}, false
+	}
+	return r.status(), true
+}
+
+// status returns the run with its current counters.
+func (r *run) status() types.TCCaptureRun {
+	info := r.info
+	info.Frames = r.stored.Load()
+	info.Dropped = r.dropped.Load()
+	return info
+}
+
+// write decodes buffered frames and stores them in batches until the run is
+// stopped, then writes what is left in the buffer.
+func (r *run) write(batchSize int, interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]types.TCCaptureFrame, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		err := db.InsertTCCaptureFramesBatch(ctx, batch)
+		cancel()
+		if err != nil {
+			log.Printf("Error storing %d TC capture frames: %v", len(batch), err)
+			r.dropped.Add(int64(len(batch)))
+		} else {
+			r.stored.Add(int64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+	add := func(f frame) {
+		batch = append(batch, decode(r.info.ID, f))
+		if len(batch) >= batchSize {
+			flush()
+		}
+	}
+
+	for {
+		select {
+		case f := <-r.frames:
+			add(f)
+		case <-ticker.C:
+			flush()
+		case <-r.stop:
+			for {
+				select {
+				case f := <-r.frames:
+					add(f)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// decode converts a buffered frame into a row. Signals that fail to decode or are
+// not numeric are left out; the raw bytes are always kept.
+func decode(runID int64, f frame) types.TCCaptureFrame {
+	row := types.TCCaptureFrame{RunID: runID, CapturedAt: f.t, FrameID: f.id, Data: f.data, Signals: map[string]float64{}}
+	decoded, err := candecoder.DecodeMessage(f.data, f.msg)
+	if err != nil {
+		return row
+	}
+	for name, s := range decoded {
+		if v, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			row.Signals[name] = v
+		}
+	}
+	return row
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +33,7 @@ type RearStrainGauges2_Data struct {
 	Gauge4    int       `json:"gauge4"`
 	Gauge5    int       `json:"gauge5"`
 	Gauge6    int       `json:"gauge6"`
+	RecordMeta
 }
 
 type RearStrainGauges1_Data struct {
@@ -42,6 +44,23 @@ type RearStrainGauges1_Data struct {
 	Gauge4    int       `json:"gauge4"`
 	Gauge5    int       `json:"gauge5"`
 	Gauge6    int       `json:"gauge6"`
+	RecordMeta
+}
+
+// RecordMeta is embedded in every telemetry record type.
+type RecordMeta struct {
+	// Columns whose signal was absent or could not be parsed. Their fields hold
+	// zero, which must not be read as a measurement; they are stored as NULL.
+	Missing []string `json:"missing,omitempty"`
+
+	// Server-assigned sequence number, increasing by one per record stored in
+	// the table. It orders records that share a timestamp and exposes gaps.
+	Seq int64 `json:"seq,omitempty"`
+}
+
+// IsMissing reports whether the record has no value for column.
+func (m RecordMeta) IsMissing(column string) bool {
+	return slices.Contains(m.Missing, column)
 }
 
 // Message represents a CAN message.
@@ -60,6 +79,7 @@ type TCU_Data struct {
 	APPS2     float64   `json:"apps2"`
 	BSE       float64   `json:"bse"`
 	Status    int       `json:"status"`
+	RecordMeta
 }
 
 // TCU2_data represents the TCU2 (Bamocar) telemetry data.
@@ -68,6 +88,7 @@ type TCU2_data struct {
 	BrakeLight int       `json:"brake_light"`
 	BamocarRFE int       `json:"bamocar_rfe"`
 	BamocarFRG int       `json:"bamocar_frg"`
+	RecordMeta
 }
 
 type RearAero_Data struct {
@@ -78,6 +99,7 @@ type RearAero_Data struct {
 	Temperature1 int       `json:"temperature1"`
 	Temperature2 int       `json:"temperature2"`
 	Temperature3 int       `json:"temperature3"`
+	RecordMeta
 }
 
 type BamocarRxData_Data struct {
@@ -88,6 +110,7 @@ type BamocarRxData_Data struct {
 	Byte3     int       `json:"byte3"`
 	Byte4     int       `json:"byte4"`
 	Byte5     int       `json:"byte5"`
+	RecordMeta
 }
 
 type RearAnalog_Data struct {
@@ -100,6 +123,7 @@ type RearAnalog_Data struct {
 	Analog6   int       `json:"analog6"`
 	Analog7   int       `json:"analog7"`
 	Analog8   int       `json:"analog8"`
+	RecordMeta
 }
 
 type FrontAero_Data struct {
@@ -110,6 +134,7 @@ type FrontAero_Data struct {
 	Temperature1 int       `json:"temperature1"`
 	Temperature2 int       `json:"temperature2"`
 	Temperature3 int       `json:"temperature3"`
+	RecordMeta
 }
 
 type PDM1_Data struct {
@@ -121,6 +146,7 @@ type PDM1_Data struct {
 	TotalCurrent        int       `json:"total_current"`
 	InternalRailVoltage float64   `json:"internal_rail_voltage"`
 	ResetSource         int       `json:"reset_source"`
+	RecordMeta
 }
 
 type RearFrequency_Data struct {
@@ -129,12 +155,14 @@ type RearFrequency_Data struct {
 	Freq2     float64   `json:"freq2"`
 	Freq3     float64   `json:"freq3"`
 	Freq4     float64   `json:"freq4"`
+	RecordMeta
 }
 
 type ACULV_FD_2_Data struct {
 	Timestamp   time.Time `json:"timestamp"`
 	FanSetPoint float64   `json:"fan_set_point"`
 	RPM         float64   `json:"rpm"`
+	RecordMeta
 }
 
 type GPSBestPos_Data struct {
@@ -146,6 +174,7 @@ type GPSBestPos_Data struct {
 	StdLongitude float64   `json:"std_longitude"`
 	StdAltitude  float64   `json:"std_altitude"`
 	GPSStatus    int       `json:"gps_status"`
+	RecordMeta
 }
 
 type Therm_Data struct {
@@ -167,6 +196,7 @@ type Therm_Data struct {
 	Therm14      float64   `json:"therm14"`
 	Therm15      float64   `json:"therm15"`
 	Therm16      float64   `json:"therm16"`
+	RecordMeta
 }
 
 type Cell_Data struct {
@@ -299,18 +329,21 @@ type Cell_Data struct {
 	Cell126   float64   `json:"cell126"`
 	Cell127   float64   `json:"cell127"`
 	Cell128   float64   `json:"cell128"`
+	RecordMeta
 }
 
 type BamocarTxData_Data struct {
 	Timestamp time.Time `json:"timestamp"`
 	REGID     int       `json:"regid"`
 	Data      int       `json:"data"`
+	RecordMeta
 }
 
 type BamoCarReTransmit_Data struct {
 	Timestamp      time.Time `json:"timestamp"`
 	MotorTemp      int       `json:"motor_temp"`
 	ControllerTemp int       `json:"controller_temp"`
+	RecordMeta
 }
 
 type Encoder_Data struct {
@@ -319,16 +352,19 @@ type Encoder_Data struct {
 	Encoder2  int       `json:"encoder2"`
 	Encoder3  int       `json:"encoder3"`
 	Encoder4  int       `json:"encoder4"`
+	RecordMeta
 }
 
 type PackCurrent_Data struct {
 	Timestamp time.Time `json:"timestamp"`
 	Current   float64   `json:"current"`
+	RecordMeta
 }
 
 type PackVoltage_Data struct {
 	Timestamp time.Time `json:"timestamp"`
 	Voltage   float64   `json:"voltage"`
+	RecordMeta
 }
 
 type PDMCurrent_Data struct {
@@ -341,6 +377,7 @@ type PDMCurrent_Data struct {
 	DAQCurrent           int       `json:"daq_current"`
 	DisplayKvaserCurrent int       `json:"display_kvaser_current"`
 	ShutdownResetCurrent int       `json:"shutdown_reset_current"`
+	RecordMeta
 }
 
 type PDMReTransmit_Data struct {
@@ -351,6 +388,7 @@ type PDMReTransmit_Data struct {
 	TotalCurrent        int       `json:"total_current"`
 	InternalRailVoltage float64   `json:"internal_rail_voltage"`
 	ResetSource         int       `json:"reset_source"`
+	RecordMeta
 }
 
 type INS_GPS_Data struct {
@@ -360,6 +398,7 @@ type INS_GPS_Data struct {
 	GNSSLat     float64   `json:"gnss_lat"`
 	GNSSLong    float64   `json:"gnss_long"`
 	GNSSHeight  float64   `json:"gnss_height"`
+	RecordMeta
 }
 
 type INS_IMU_Data struct {
@@ -371,6 +410,7 @@ type INS_IMU_Data struct {
 	Pitch     float64   `json:"pitch"`
 	Azimuth   float64   `json:"azimuth"`
 	Status    int       `json:"status"`
+	RecordMeta
 }
 
 type FrontFrequency_Data struct {
@@ -379,6 +419,7 @@ type FrontFrequency_Data struct {
 	FrontRight float64   `json:"front_right"`
 	RearLeft   float64   `json:"rear_left"`
 	FrontLeft  float64   `json:"front_left"`
+	RecordMeta
 }
 
 type FrontAnalog_Data struct {
@@ -391,6 +432,7 @@ type FrontAnalog_Data struct {
 	RearLeftPot   float64   `json:"rear_left_pot"`
 	SteeringAngle float64   `json:"steering_angle"`
 	Analog8       int       `json:"analog8"`
+	RecordMeta
 }
 
 type FrontStrainGauges1_Data struct {
@@ -401,6 +443,7 @@ type FrontStrainGauges1_Data struct {
 	Gauge4    int       `json:"gauge4"`
 	Gauge5    int       `json:"gauge5"`
 	Gauge6    int       `json:"gauge6"`
+	RecordMeta
 }
 
 type FrontStrainGauges2_Data struct {
@@ -411,11 +454,13 @@ type FrontStrainGauges2_Data struct {
 	Gauge4    int       `json:"gauge4"`
 	Gauge5    int       `json:"gauge5"`
 	Gauge6    int       `json:"gauge6"`
+	RecordMeta
 }
 
 type ACULV2_Data struct {
 	Timestamp     time.Time `json:"timestamp"`
 	ChargeRequest int       `json:"charge_request"`
+	RecordMeta
 }
 
 type ACULV_FD_1_Data struct {
@@ -428,12 +473,14 @@ type ACULV_FD_1_Data struct {
 	CellCurrent          float64   `json:"cell_current"`
 	IsolationMonitoring  int       `json:"isolation_monitoring"`
 	IsolationMonitoring1 float64   `json:"isolation_monitoring1"`
+	RecordMeta
 }
 
 type ACULV1_Data struct {
 	Timestamp     time.Time `json:"timestamp"`
 	ChargeStatus1 float64   `json:"charge_status1"`
 	ChargeStatus2 float64   `json:"charge_status2"`
+	RecordMeta
 }
 
 // Option represents a selectable CAN ID option with a description.
@@ -479,3 +526,394 @@ func extractNumber(header, prefix string) (int, error) {
 	}
 	return strconv.Atoi(numStr)
 }
+
+// BusLoadID is the per-frame-ID share of bus traffic within a sampling window.
+type BusLoadID struct {
+	FrameID      uint32  `json:"frame_id"`
+	FramesPerSec float64 `json:"frames_per_sec"`
+	BytesPerSec  float64 `json:"bytes_per_sec"`
+	Share        float64 `json:"share"` // Fraction of estimated bus bits used by this ID (0-1)
+}
+
+// BusLoad_Data represents one CAN bus load sample computed from ingest.
+type BusLoad_Data struct {
+	Timestamp    time.Time   `json:"timestamp"`
+	FramesPerSec float64     `json:"frames_per_sec"`
+	BytesPerSec  float64     `json:"bytes_per_sec"`
+	BitsPerSec   float64     `json:"bits_per_sec"`
+	LoadPercent  float64     `json:"load_percent"`
+	TopIDs       []BusLoadID `json:"top_ids"`
+}
+
+// CellDelta_Data is a sparse cell_data sample: only the cells that differ from the
+// referenced keyframe row (by more than the configured deadband) are recorded.
+type CellDelta_Data struct {
+	Timestamp         time.Time `json:"timestamp"`
+	KeyframeTimestamp time.Time `json:"keyframe_timestamp"`
+	CellIndices       []int16   `json:"cell_indices"` // 1-based cell numbers
+	CellValues        []float64 `json:"cell_values"`
+	Seq               int64     `json:"seq,omitempty"` // Sequence number of the sample in cell_data
+}
+
+// ServerMetrics_Data is one periodic sample of pipeline counters. Counters are
+// cumulative since server start; the rates cover the preceding sampling interval.
+type ServerMetrics_Data struct {
+	Timestamp        time.Time `json:"timestamp"`
+	CacheHits        uint64    `json:"cache_hits"`
+	CacheMisses      uint64    `json:"cache_misses"`
+	CacheHitRate     float64   `json:"cache_hit_rate"` // Hits / lookups within the interval (0-1)
+	MessagesSent     uint64    `json:"messages_sent"`
+	MessagesDropped  uint64    `json:"messages_dropped"`
+	SentPerSec       float64   `json:"sent_per_sec"`
+	DroppedPerSec    float64   `json:"dropped_per_sec"`
+	CircuitState     int32     `json:"circuit_state"`
+	PersistenceLagMs float64   `json:"persistence_lag_ms"` // Largest lag across batch processors
+}
+
+// Session is a contiguous period of vehicle activity. Telemetry rows belong to the
+// session whose time range contains their timestamp.
+type Session struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	StartedAt   time.Time  `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"` // nil while the session is open
+	StartReason string     `json:"start_reason"`       // "manual", "activity" or "ts_active"
+	EndReason   string     `json:"end_reason,omitempty"`
+	Auto        bool       `json:"auto"` // Opened automatically rather than by a user
+
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"` // Flag states when the session started
+}
+
+// Calibration records when a sensor or subsystem was last calibrated, together
+// with any parameters determined by the calibration (e.g. model coefficients).
+type Calibration struct {
+	ID           int64              `json:"id"`
+	Name         string             `json:"name"`
+	CalibratedAt time.Time          `json:"calibrated_at"`
+	Notes        string             `json:"notes,omitempty"`
+	Params       map[string]float64 `json:"params,omitempty"`
+}
+
+// AlertRecord is a persisted alert occurrence.
+type AlertRecord struct {
+	ID         int64      `json:"id"`
+	Key        string     `json:"key"`
+	Source     string     `json:"source"`
+	Severity   string     `json:"severity"`
+	Message    string     `json:"message"`
+	Value      float64    `json:"value"`
+	Threshold  float64    `json:"threshold"`
+	RaisedAt   time.Time  `json:"raised_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"` // nil while the alert is active
+}
+
+// Annotation is a user note attached to a point or range in time.
+type Annotation struct {
+	ID        int64      `json:"id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"` // nil for point annotations
+	Text      string     `json:"text"`
+	Author    string     `json:"author,omitempty"`
+}
+
+// Marker is an alert or annotation placed on the timeline of exported data.
+type Marker struct {
+	Time     time.Time  `json:"time"`
+	End      *time.Time `json:"end,omitempty"`
+	Kind     string     `json:"kind"`               // "alert" or "annotation"
+	Severity string     `json:"severity,omitempty"` // Alerts only
+	Label    string     `json:"label"`              // Alert key or annotation author
+	Text     string     `json:"text"`
+}
+
+// ChannelStats holds the aggregates of one channel over a lap.
+type ChannelStats struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	Avg float64 `json:"avg"`
+}
+
+// LapSummary holds the aggregates of a completed lap. Aggregates without data in
+// the lap are nil.
+type LapSummary struct {
+	ID                int64                   `json:"id"`
+	SessionID         *int64                  `json:"session_id,omitempty"`
+	LapNumber         int                     `json:"lap_number"`
+	StartedAt         time.Time               `json:"started_at"`
+	EndedAt           time.Time               `json:"ended_at"`
+	DurationS         float64                 `json:"duration_s"`
+	DistanceM         *float64                `json:"distance_m"`
+	MaxSpeed          *float64                `json:"max_speed"` // m/s
+	AvgSpeed          *float64                `json:"avg_speed"` // m/s
+	EnergyWh          *float64                `json:"energy_wh"` // Drawn from the pack (drive minus regen)
+	DriveEnergyWh     *float64                `json:"drive_energy_wh"`
+	RegenEnergyWh     *float64                `json:"regen_energy_wh"`
+	RegenFraction     *float64                `json:"regen_fraction"` // Regen / drive
+	MaxCellTemp       *float64                `json:"max_cell_temp"`
+	MaxMotorTemp      *float64                `json:"max_motor_temp"`
+	MaxControllerTemp *float64                `json:"max_controller_temp"`
+	AlertCount        int                     `json:"alert_count"`
+	Channels          map[string]ChannelStats `json:"channels,omitempty"` // Configured key channels
+	Trigger           string                  `json:"trigger"`            // "gps" or "manual"
+}
+
+// DriverInputEvent is a state change of a steering wheel button or rotary switch.
+type DriverInputEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Input     string    `json:"input"` // Signal name, e.g. "ButtonDRS" or "RotaryMap"
+	Value     float64   `json:"value"`
+	Previous  *float64  `json:"previous"` // nil for the first state seen after startup
+}
+
+// ShutdownEvent is a state change of a shutdown circuit element. Events that are
+// part of a trip (the circuit opening after being fully closed) carry the trip
+// start and their position in the trip; TripOrder 1 is the element that opened
+// first.
+type ShutdownEvent struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Element   string     `json:"element"`
+	Closed    bool       `json:"closed"`
+	Value     float64    `json:"value"` // Signal the state was derived from
+	TripStart *time.Time `json:"trip_start"`
+	TripOrder *int       `json:"trip_order"`
+}
+
+// PrechargeAttempt is a detected precharge sequence: the tractive voltage rising
+// towards the accumulator voltage before the AIRs close.
+type PrechargeAttempt struct {
+	ID           int64     `json:"id"`
+	StartedAt    time.Time `json:"started_at"`
+	EndedAt      time.Time `json:"ended_at"`
+	DurationS    float64   `json:"duration_s"`    // Start to reaching the target fraction
+	AccumulatorV float64   `json:"accumulator_v"` // Accumulator voltage at the end
+	FinalDeltaV  float64   `json:"final_delta_v"` // Accumulator minus tractive voltage after settling
+	Passed       bool      `json:"passed"`
+	Reason       string    `json:"reason"` // Why the attempt failed; empty when passed
+}
+
+// EnergySplit is the pack energy over an interval split into drive (discharge)
+// and regen (charge). RegenFraction is regen divided by drive, nil without drive
+// energy.
+type EnergySplit struct {
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	DriveWh       float64   `json:"drive_wh"`
+	RegenWh       float64   `json:"regen_wh"`
+	NetWh         float64   `json:"net_wh"`
+	RegenFraction *float64  `json:"regen_fraction"`
+	Samples       int       `json:"samples"`
+}
+
+// JobRun is the outcome of the last run of a scheduled background job.
+type JobRun struct {
+	Job        string     `json:"job"`
+	Trigger    string     `json:"trigger"` // "schedule" or "manual"
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // nil while running
+	Status     string     `json:"status"`                // "running", "ok" or "failed"
+	Error      string     `json:"error,omitempty"`
+	RunCount   int64      `json:"run_count"` // Runs since the job was first registered
+}
+
+// ResistanceEstimate is a rolling estimate of the accumulator internal resistance,
+// derived from voltage/current steps during a session.
+type ResistanceEstimate struct {
+	ID          int64     `json:"id"`
+	SessionID   int64     `json:"session_id"`
+	EstimatedAt time.Time `json:"estimated_at"`
+	PackOhm     float64   `json:"pack_ohm"`
+	CellOhm     *float64  `json:"cell_ohm"` // Pack resistance per series cell; nil without a cell count
+	Steps       int       `json:"steps"`    // Current steps the estimate is based on
+	Final       bool      `json:"final"`    // Last estimate of the session
+}
+
+// PackHealth is the charge throughput of an accumulator pack during one session
+// and the pack's cumulative totals, cycle count and state of health after it.
+// Figures that need the nominal capacity are nil when it is not configured.
+type PackHealth struct {
+	ID               int64     `json:"id"`
+	PackID           string    `json:"pack_id"`
+	SessionID        int64     `json:"session_id"`
+	RecordedAt       time.Time `json:"recorded_at"` // End of the session
+	DischargeAh      float64   `json:"discharge_ah"`
+	ChargeAh         float64   `json:"charge_ah"` // Regen and charging
+	TotalDischargeAh float64   `json:"total_discharge_ah"`
+	TotalChargeAh    float64   `json:"total_charge_ah"`
+	Cycles           *float64  `json:"cycles"`      // Equivalent full cycles: total discharge / nominal capacity
+	CapacityAh       *float64  `json:"capacity_ah"` // Measured from the SOC swing of the session; nil when too small
+	SOH              *float64  `json:"soh"`         // Recent measured capacity / nominal capacity
+}
+
+// VehicleStateChange is a transition of the consolidated vehicle state.
+type VehicleStateChange struct {
+	Timestamp time.Time `json:"timestamp"`
+	State     string    `json:"state"`
+	Previous  string    `json:"previous"`
+}
+
+// ShareLink is an expiring, read-only grant to the data of one session. The token
+// itself is only returned when the link is created.
+type ShareLink struct {
+	ID        int64      `json:"id"`
+	SessionID int64      `json:"session_id"`
+	Channels  []string   `json:"channels"`
+	Note      string     `json:"note,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// TCCaptureRun is a traction-control capture run: a period during which every
+// frame of the selected IDs was stored at full resolution.
+type TCCaptureRun struct {
+	ID        int64      `json:"id"`
+	SessionID *int64     `json:"session_id,omitempty"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"` // nil while capturing
+	FrameIDs  []int      `json:"frame_ids"`
+	Note      string     `json:"note,omitempty"`
+	StartedBy string     `json:"started_by"`
+	Frames    int64      `json:"frames"`  // Frames stored
+	Dropped   int64      `json:"dropped"` // Frames lost because the writer fell behind
+}
+
+// TCCaptureFrame is a single captured frame with its decoded signals.
+type TCCaptureFrame struct {
+	RunID      int64              `json:"run_id"`
+	CapturedAt time.Time          `json:"captured_at"`
+	FrameID    uint32             `json:"frame_id"`
+	Data       []byte             `json:"data"`
+	Signals    map[string]float64 `json:"signals"`
+}
+
+// WSProfile is a named live subscription profile. Live clients connecting with
+// ?profile=<name> receive only its channels, at most MaxRate messages per second
+// per channel and board, in its format.
+type WSProfile struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Channels    []string  `json:"channels"` // Message types; empty selects all
+	MaxRate     float64   `json:"max_rate"` // 0 is unlimited
+	Format      string    `json:"format"`
+	UpdatedBy   string    `json:"updated_by"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DBHealth is a storage health report built from the PostgreSQL statistics views.
+type DBHealth struct {
+	Timestamp     time.Time        `json:"timestamp"`
+	DatabaseBytes int64            `json:"database_bytes"`
+	Autovacuum    bool             `json:"autovacuum"` // Autovacuum launcher enabled
+	Tables        []TableHealth    `json:"tables"`     // Largest first
+	Indexes       []IndexHealth    `json:"indexes"`    // Largest first
+	Vacuums       []VacuumProgress `json:"vacuums"`    // Vacuums running now
+	Connections   ConnectionHealth `json:"connections"`
+	Warnings      []string         `json:"warnings,omitempty"`
+}
+
+// TableHealth is the size, dead tuple bloat estimate and vacuum history of a table.
+type TableHealth struct {
+	Name             string     `json:"name"`
+	TotalBytes       int64      `json:"total_bytes"` // Heap, indexes and TOAST
+	HeapBytes        int64      `json:"heap_bytes"`
+	IndexBytes       int64      `json:"index_bytes"`
+	LiveTuples       int64      `json:"live_tuples"`
+	DeadTuples       int64      `json:"dead_tuples"`
+	DeadRatio        float64    `json:"dead_ratio"`         // Dead / (live + dead) tuples
+	BloatBytes       int64      `json:"bloat_bytes"`        // Estimate: heap share of dead tuples
+	ModsSinceAnalyze int64      `json:"mods_since_analyze"` // Rows changed since the last analyze
+	LastVacuum       *time.Time `json:"last_vacuum,omitempty"`
+	LastAutovacuum   *time.Time `json:"last_autovacuum,omitempty"`
+	LastAnalyze      *time.Time `json:"last_analyze,omitempty"`
+	LastAutoanalyze  *time.Time `json:"last_autoanalyze,omitempty"`
+	VacuumCount      int64      `json:"vacuum_count"`
+	AutovacuumCount  int64      `json:"autovacuum_count"`
+	AutoanalyzeCount int64      `json:"autoanalyze_count"`
+}
+
+// IndexHealth is the size and usage of an index.
+type IndexHealth struct {
+	Name       string  `json:"name"`
+	Table      string  `json:"table"`
+	Bytes      int64   `json:"bytes"`
+	HeapRatio  float64 `json:"heap_ratio"` // Index size / table heap size
+	Scans      int64   `json:"scans"`
+	TuplesRead int64   `json:"tuples_read"`
+}
+
+// VacuumProgress is a vacuum running on a table.
+type VacuumProgress struct {
+	PID       int64      `json:"pid"`
+	Table     string     `json:"table"`
+	Phase     string     `json:"phase"`
+	Progress  float64    `json:"progress"` // Share of heap blocks scanned
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// ConnectionHealth is the utilization of the connection pool of the server and of
+// the database connection limit.
+type ConnectionHealth struct {
+	PoolMaxOpen     int            `json:"pool_max_open"`
+	PoolOpen        int            `json:"pool_open"`
+	PoolInUse       int            `json:"pool_in_use"`
+	PoolIdle        int            `json:"pool_idle"`
+	PoolUtilization float64        `json:"pool_utilization"` // In use / max open
+	PoolWaitCount   int64          `json:"pool_wait_count"`  // Requests that waited for a connection
+	PoolWaitMs      float64        `json:"pool_wait_ms"`     // Total time waited
+	ServerMax       int            `json:"server_max"`       // max_connections
+	ServerTotal     int            `json:"server_total"`     // Backends of all databases
+	ServerByState   map[string]int `json:"server_by_state"`  // Backends of this database by state
+	LongestXactSecs float64        `json:"longest_xact_s"`   // Oldest open transaction of this database
+}
+
+// Backup is a database backup written to the backup drive, with the result of its
+// verification.
+type Backup struct {
+	ID         int64      `json:"id"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Status     string     `json:"status"` // "ok" or "failed"
+	File       string     `json:"file,omitempty"`
+	SizeBytes  int64      `json:"size_bytes"`
+	SHA256     string     `json:"sha256,omitempty"`
+	Tables     int        `json:"tables"` // Tables whose data the verified archive holds
+	Error      string     `json:"error,omitempty"`
+}
+
+// IMUBlock is a run of full-rate samples of one IMU sensor, stored as one row.
+// Sample i was taken at Start plus OffsetsUs[i] microseconds.
+type IMUBlock struct {
+	Sensor    string // "accel" or "gyro"
+	Start     time.Time
+	OffsetsUs []int32
+	X, Y, Z   []float32
+}
+
+// IMUSample is a single full-rate IMU sample.
+type IMUSample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Sensor    string    `json:"sensor"`
+	X         float64   `json:"x"`
+	Y         float64   `json:"y"`
+	Z         float64   `json:"z"`
+}
+
+// IMUBurst is a window of full-rate IMU data around an event, kept when older
+// full-rate data is removed.
+type IMUBurst struct {
+	ID          int64     `json:"id"`
+	SessionID   *int64    `json:"session_id,omitempty"`
+	EventAt     time.Time `json:"event_at"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Trigger     string    `json:"trigger"` // "manual", "threshold" or "alert"
+	Note        string    `json:"note,omitempty"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+}
+
+// TimedValue is a channel value with the time it was recorded.
+type TimedValue struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
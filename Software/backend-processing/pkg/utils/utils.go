@@ -1,13 +1,15 @@
 // utils.go
 //
 // Package utils provides a collection of helper functions for string manipulation,
-// CSV parsing, JSON conversion, timestamp formatting, and safe number parsing.
+// CSV parsing, JSON conversion, timestamp formatting, safe number parsing, and the
+// small numeric helpers shared by the battery analyses.
 // These utilities are used throughout the telemetry system for decoding and data processing.
 package utils
 
 import (
 	"encoding/csv"
 	"encoding/json"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -43,24 +45,33 @@ func AtoiSafe(s string) (int, error) {
 	return strconv.Atoi(s)
 }
 
-// ParseFloatSignal extracts a float64 value from a map given a key.
-// If the value is missing or cannot be parsed, it returns 0.
-func ParseFloatSignal(decoded map[string]string, key string) float64 {
+// LookupFloatSignal extracts a float64 value from a map given a key.
+// ok is false if the value is missing or cannot be parsed.
+func LookupFloatSignal(decoded map[string]string, key string) (float64, bool) {
 	if val, ok := decoded[key]; ok && val != "" {
 		if f, err := strconv.ParseFloat(val, 64); err == nil {
-			return f
+			return f, true
 		}
 	}
-	return 0
+	return 0, false
 }
 
-// ParseIntSignal extracts an integer value from a map given a key.
-// If the value is missing or cannot be parsed, it returns 0.
-func ParseIntSignal(decoded map[string]string, key string) int {
+// ParseFloatSignal extracts a float64 value from a map given a key.
+// If the value is missing or cannot be parsed, it returns 0; use
+// LookupFloatSignal where that must be told apart from a real zero.
+func ParseFloatSignal(decoded map[string]string, key string) float64 {
+	f, _ := LookupFloatSignal(decoded, key)
+	return f
+}
+
+// LookupIntSignal extracts an integer value from a map given a key, falling back
+// to a case-insensitive match. ok is false if the value is missing or cannot be
+// parsed.
+func LookupIntSignal(decoded map[string]string, key string) (int, bool) {
 	// Try direct lookup first
 	if val, ok := decoded[key]; ok && val != "" {
 		if i, err := strconv.Atoi(val); err == nil {
-			return i
+			return i, true
 		}
 	}
 
@@ -69,12 +80,20 @@ func ParseIntSignal(decoded map[string]string, key string) int {
 	for k, val := range decoded {
 		if strings.ToLower(k) == lowerKey && val != "" {
 			if i, err := strconv.Atoi(val); err == nil {
-				return i
+				return i, true
 			}
 			break
 		}
 	}
-	return 0
+	return 0, false
+}
+
+// ParseIntSignal extracts an integer value from a map given a key.
+// If the value is missing or cannot be parsed, it returns 0; use
+// LookupIntSignal where that must be told apart from a real zero.
+func ParseIntSignal(decoded map[string]string, key string) int {
+	i, _ := LookupIntSignal(decoded, key)
+	return i
 }
 
 // ParseCSVLine reads a CSV line and returns a slice of non-empty fields.
@@ -104,3 +123,27 @@ func RemoveDuplicates(ids []int) []int {
 	}
 	return result
 }
+
+// Median returns the median of v, or 0 for an empty slice. v is not modified.
+func Median(v []float64) float64 {
+	if len(v) == 0 {
+		return 0
+	}
+	s := slices.Clone(v)
+	slices.Sort(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// CurrentSign normalizes a configured pack current sign: 1 (also for the zero
+// value) when discharge current is positive, -1 when it is negative. Multiplying
+// a pack current by it gives positive discharge current.
+func CurrentSign(sign float64) float64 {
+	if sign >= 0 {
+		return 1
+	}
+	return -1
+}
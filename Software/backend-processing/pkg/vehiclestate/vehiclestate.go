@@ -0,0 +1,281 @@
+// vehiclestate.go
+//
+// Package vehiclestate derives a single high-level vehicle state from the AMS
+// status, the accumulator and tractive voltages, the Bamocar RFE/FRG enables, the
+// pack current, the vehicle speed and the charger request. States are evaluated
+// in priority order:
+//
+//	FAULT        AMS reports a fault
+//	CHARGING     charger requests current, or the pack is charged at standstill
+//	LV_ON        tractive system below the TS-on voltage
+//	TS_PRECHARGE tractive voltage still below the precharge fraction of the pack
+//	DRIVING      inverter enabled and the car moving
+//	TS_ACTIVE    tractive system energised otherwise
+//	OFF          no input received for the off timeout
+//
+// Inputs older than two seconds count as unknown. A new state other than
+// FAULT must hold for the debounce time before it is entered. On every transition
+// subscribers are notified and the alerts configured for the new state are
+// suppressed.
+package vehiclestate
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"telem-system/pkg/alerts"
+	"telem-system/pkg/types"
+	"telem-system/pkg/utils"
+	"time"
+)
+
+// Vehicle states
+const (
+	StateOff       = "OFF"
+	StateLVOn      = "LV_ON"
+	StatePrecharge = "TS_PRECHARGE"
+	StateTSActive  = "TS_ACTIVE"
+	StateDriving   = "DRIVING"
+	StateFault     = "FAULT"
+	StateCharging  = "CHARGING"
+)
+
+// States lists every vehicle state.
+var States = []string{StateOff, StateLVOn, StatePrecharge, StateTSActive, StateDriving, StateFault, StateCharging}
+
+const (
+	// Defaults, overridable through Config
+	defaultTSOnVoltage       = 60.0 // FSAE low voltage limit
+	defaultPrechargeFraction = 0.9
+	defaultDrivingSpeed      = 1.0 // m/s
+	defaultChargeCurrent     = 2.0 // A
+	defaultOffTimeout        = 2 * time.Second
+	defaultDebounce          = 300 * time.Millisecond
+
+	// Inputs older than this count as unknown
+	staleAfter = 2 * time.Second
+)
+
+// Config holds the thresholds and the per-state alert suppression.
+type Config struct {
+	AMSOKStatus       int                 // AMS status value meaning no fault
+	TSOnVoltage       float64             // Tractive voltage above which the TS counts as on
+	PrechargeFraction float64             // Fraction of the pack voltage that ends precharge
+	DrivingSpeed      float64             // Speed (m/s) above which an enabled car is driving
+	ChargeCurrent     float64             // Charge current (A) that means charging at standstill
+	CurrentSign       float64             // Pack current sign convention, see utils.CurrentSign
+	OffTimeout        time.Duration       // Without any input for this long the vehicle is OFF
+	Debounce          time.Duration       // A new state must hold this long (FAULT is immediate)
+	Suppress          map[string][]string // Alert keys and sources suppressed in each state
+}
+
+// input is the latest value of one signal.
+type input struct {
+	value float64
+	t     time.Time
+}
+
+// machine holds the inputs and the current state.
+type machine struct {
+	mu          sync.Mutex
+	cfg         Config
+	ams         input
+	accuV       input
+	tractiveV   input
+	rfe, frg    input
+	current     input
+	speed       input
+	charge      input
+	lastInput   time.Time
+	state       string
+	since       time.Time
+	candidate   string
+	candSince   time.Time
+	subscribers []func(types.VehicleStateChange)
+}
+
+var m = &machine{cfg: withDefaults(Config{}), state: StateOff}
+
+// withDefaults fills non-positive values with the defaults.
+func withDefaults(cfg Config) Config {
+	if cfg.TSOnVoltage <= 0 {
+		cfg.TSOnVoltage = defaultTSOnVoltage
+	}
+	if cfg.PrechargeFraction <= 0 || cfg.PrechargeFraction > 1 {
+		cfg.PrechargeFraction = defaultPrechargeFraction
+	}
+	if cfg.DrivingSpeed <= 0 {
+		cfg.DrivingSpeed = defaultDrivingSpeed
+	}
+	if cfg.ChargeCurrent <= 0 {
+		cfg.ChargeCurrent = defaultChargeCurrent
+	}
+	cfg.CurrentSign = utils.CurrentSign(cfg.CurrentSign)
+	if cfg.OffTimeout <= 0 {
+		cfg.OffTimeout = defaultOffTimeout
+	}
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = defaultDebounce
+	}
+	return cfg
+}
+
+// Configure installs the thresholds and suppression lists. Non-positive values
+// select the defaults; the states of the suppression lists are case-insensitive.
+func Configure(cfg Config) error {
+	suppress := make(map[string][]string, len(cfg.Suppress))
+	for state, keys := range cfg.Suppress {
+		upper := strings.ToUpper(state)
+		if !slices.Contains(States, upper) {
+			return fmt.Errorf("unknown vehicle state %q", state)
+		}
+		suppress[upper] = keys
+	}
+	cfg.Suppress = suppress
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cfg = withDefaults(cfg)
+	alerts.SetSuppressed(m.cfg.Suppress[m.state])
+	return nil
+}
+
+// Subscribe registers fn to be called with every state transition. fn is called
+// synchronously and must not block.
+func Subscribe(fn func(types.VehicleStateChange)) {
+	m.mu.Lock()
+	m.subscribers = append(m.subscribers, fn)
+	m.mu.Unlock()
+}
+
+// Current returns the current state and when it was entered.
+func Current() (string, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state, m.since
+}
+
+// ObserveAMS feeds the AMS status and the accumulator and tractive voltages.
+// Missing values are passed as nil.
+func ObserveAMS(status *int, accumulatorV, tractiveV *float64, t time.Time) {
+	m.update(t, func() {
+		if status != nil {
+			m.ams = input{float64(*status), t}
+		}
+		if accumulatorV != nil {
+			m.accuV = input{*accumulatorV, t}
+		}
+		if tractiveV != nil {
+			m.tractiveV = input{*tractiveV, t}
+		}
+	})
+}
+
+// ObserveBamocar feeds the Bamocar RFE and FRG enables.
+func ObserveBamocar(rfe, frg int, t time.Time) {
+	m.update(t, func() {
+		m.rfe, m.frg = input{float64(rfe), t}, input{float64(frg), t}
+	})
+}
+
+// ObserveCurrent feeds the pack current.
+func ObserveCurrent(current float64, t time.Time) {
+	m.update(t, func() { m.current = input{current, t} })
+}
+
+// ObserveVelocity feeds the horizontal velocity components in m/s.
+func ObserveVelocity(north, east float64, t time.Time) {
+	m.update(t, func() { m.speed = input{math.Hypot(north, east), t} })
+}
+
+// ObserveChargeRequest feeds the charger request flag.
+func ObserveChargeRequest(request int, t time.Time) {
+	m.update(t, func() { m.charge = input{float64(request), t} })
+}
+
+// Check re-evaluates the state at now; it is called periodically so the vehicle
+// turns OFF once inputs stop arriving.
+func Check(now time.Time) {
+	m.update(now, nil)
+}
+
+// update applies an input change under the lock and evaluates the state. The
+// suppression list of a new state is installed before the lock is released, so
+// concurrent transitions install their lists in transition order and the list
+// in effect always belongs to the current state.
+func (mc *machine) update(t time.Time, apply func()) {
+	mc.mu.Lock()
+	if apply != nil {
+		apply()
+		mc.lastInput = t
+	}
+	change, ok := mc.evaluate(t)
+	var subs []func(types.VehicleStateChange)
+	if ok {
+		subs = mc.subscribers
+		alerts.SetSuppressed(mc.cfg.Suppress[change.State])
+	}
+	mc.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(change)
+	}
+}
+
+// evaluate derives the state at now and reports a transition. The caller holds
+// mc.mu.
+func (mc *machine) evaluate(now time.Time) (types.VehicleStateChange, bool) {
+	next := mc.derive(now)
+	if next == mc.state {
+		mc.candidate = ""
+		return types.VehicleStateChange{}, false
+	}
+	if next != StateFault {
+		if next != mc.candidate {
+			mc.candidate, mc.candSince = next, now
+		}
+		if now.Sub(mc.candSince) < mc.cfg.Debounce {
+			return types.VehicleStateChange{}, false
+		}
+	}
+	change := types.VehicleStateChange{Timestamp: now, State: next, Previous: mc.state}
+	mc.state, mc.since, mc.candidate = next, now, ""
+	return change, true
+}
+
+// derive returns the state indicated by the inputs at now. The caller holds
+// mc.mu.
+func (mc *machine) derive(now time.Time) string {
+	cfg := mc.cfg
+	fresh := func(in input) bool { return !in.t.IsZero() && now.Sub(in.t) <= staleAfter }
+
+	if mc.lastInput.IsZero() || now.Sub(mc.lastInput) > cfg.OffTimeout {
+		return StateOff
+	}
+	if fresh(mc.ams) && int(mc.ams.value) != cfg.AMSOKStatus {
+		return StateFault
+	}
+	moving := fresh(mc.speed) && mc.speed.value >= cfg.DrivingSpeed
+	charging := fresh(mc.current) && mc.current.value*cfg.CurrentSign <= -cfg.ChargeCurrent
+	if (fresh(mc.charge) && mc.charge.value != 0) || (charging && !moving && !mc.enabled(fresh)) {
+		return StateCharging
+	}
+	if !fresh(mc.tractiveV) || mc.tractiveV.value < cfg.TSOnVoltage {
+		return StateLVOn
+	}
+	if fresh(mc.accuV) && mc.tractiveV.value < cfg.PrechargeFraction*mc.accuV.value {
+		return StatePrecharge
+	}
+	if mc.enabled(fresh) && moving {
+		return StateDriving
+	}
+	return StateTSActive
+}
+
+// enabled reports whether the inverter is enabled (RFE and FRG set). The caller
+// holds mc.mu.
+func (mc *machine) enabled(fresh func(input) bool) bool {
+	return fresh(mc.rfe) && fresh(mc.frg) && mc.rfe.value != 0 && mc.frg.value != 0
+}
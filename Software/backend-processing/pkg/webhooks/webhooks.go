@@ -0,0 +1,221 @@
+// webhooks.go
+//
+// Package webhooks delivers server events to external systems (team dashboards,
+// CI) so they stay in sync without polling. Every configured hook receives a JSON
+// POST for the events it subscribes to:
+//
+//	{"id": "...", "event": "session.started", "time": "...", "data": {...}}
+//
+// When the hook has a secret the body is signed with HMAC-SHA256 and the hex
+// digest is sent as "X-Webhook-Signature: sha256=<digest>". Deliveries to a hook
+// are made in order by a single goroutine and retried with backoff; events are
+// dropped rather than blocking the caller when a hook falls behind.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Events
+const (
+	EventSessionStarted  = "session.started"
+	EventSessionEnded    = "session.ended"
+	EventAlertRaised     = "alert.raised"
+	EventAlertResolved   = "alert.resolved"
+	EventExportCompleted = "export.completed"
+)
+
+// Events lists every event a hook can subscribe to.
+var Events = []string{EventSessionStarted, EventSessionEnded, EventAlertRaised, EventAlertResolved, EventExportCompleted}
+
+const (
+	// Size of the delivery queue of each hook
+	queueSize = 256
+
+	// Timeout of a single delivery attempt
+	deliveryTimeout = 10 * time.Second
+
+	// User agent of webhook requests
+	userAgent = "fsae-telemetry-webhooks"
+)
+
+// Delay before each retry of a failed delivery
+var retryDelays = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+
+// Hook is an outbound webhook.
+type Hook struct {
+	URL    string
+	Secret string   // HMAC-SHA256 signing key; empty disables signing
+	Events []string // Subscribed events; empty subscribes to all
+}
+
+// Delivery is the JSON body of a webhook request.
+type Delivery struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+// queued is an encoded event waiting for delivery.
+type queued struct {
+	id, event string
+	body      []byte
+}
+
+// hook is a configured hook with its delivery queue.
+type hook struct {
+	Hook
+	queue chan queued
+}
+
+var (
+	mu     sync.RWMutex
+	hooks  []*hook
+	client = &http.Client{Timeout: deliveryTimeout}
+)
+
+// Configure validates and installs the hooks. Deliveries start with Start.
+func Configure(list []Hook) error {
+	configured := make([]*hook, 0, len(list))
+	for _, h := range list {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %q: URL must be http or https", h.URL)
+		}
+		for _, e := range h.Events {
+			if !slices.Contains(Events, e) {
+				return fmt.Errorf("webhook %q: unknown event %q", h.URL, e)
+			}
+		}
+		configured = append(configured, &hook{Hook: h, queue: make(chan queued, queueSize)})
+	}
+	mu.Lock()
+	hooks = configured
+	mu.Unlock()
+	return nil
+}
+
+// Start runs one delivery goroutine per configured hook until ctx is cancelled.
+func Start(ctx context.Context) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, h := range hooks {
+		go h.run(ctx)
+	}
+	if len(hooks) > 0 {
+		log.Printf("Delivering events to %d webhooks", len(hooks))
+	}
+}
+
+// Fire queues event with data for every hook subscribed to it. It never blocks.
+func Fire(event string, data interface{}) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	q := queued{id: newID(), event: event}
+	var err error
+	q.body, err = json.Marshal(Delivery{ID: q.id, Event: event, Time: time.Now().UTC(), Data: data})
+	if err != nil {
+		log.Printf("Error encoding webhook event %s: %v", event, err)
+		return
+	}
+	for _, h := range hooks {
+		if len(h.Events) > 0 && !slices.Contains(h.Events, event) {
+			continue
+		}
+		select {
+		case h.queue <- q:
+		default:
+			log.Printf("Webhook %s queue full, dropping %s event", h.URL, event)
+		}
+	}
+}
+
+// run delivers queued events in order.
+func (h *hook) run(ctx context.Context) {
+	for {
+		select {
+		case q := <-h.queue:
+			h.deliver(ctx, q)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver posts an event, retrying failed attempts.
+func (h *hook) deliver(ctx context.Context, q queued) {
+	for attempt := 0; ; attempt++ {
+		err := h.post(ctx, q)
+		if err == nil {
+			return
+		}
+		if attempt == len(retryDelays) {
+			log.Printf("Webhook %s: giving up on %s event %s: %v", h.URL, q.event, q.id, err)
+			return
+		}
+		select {
+		case <-time.After(retryDelays[attempt]):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// post makes a single delivery attempt. Any 2xx response counts as delivered.
+func (h *hook) post(ctx context.Context, q queued) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(q.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Webhook-Event", q.event)
+	req.Header.Set("X-Webhook-ID", q.id)
+	if h.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+Sign(h.Secret, q.body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body with secret, as sent in the
+// X-Webhook-Signature header. Receivers compare it against their own digest.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newID returns a random delivery ID, which receivers can use to deduplicate
+// retried deliveries.
+func newID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -141,6 +141,6 @@ go mod download || error_exit "Failed to download Go dependencies."
 # Final Instructions
 ####################################
 log_info "=== Setup complete! ==="
-echo -e "${GREEN}[INFO] To run the sender, execute: go run simulate_sender.go${NC}"
+echo -e "${GREEN}[INFO] To run the sender, execute: go run .${NC}"
 echo -e "${GREEN}[INFO] To run the receiver, execute: go run main.go${NC}"
 echo -e "${GREEN}[INFO] Note: The system-wide PATH has been updated in /etc/profile. You may need to re-login or run 'source ~/.profile' to apply changes fully.${NC}"